@@ -52,6 +52,7 @@ func (src *Cluster) ConvertTo(dstRaw conversion.Hub) error {
 	dst.Status.FailureDomains = restored.Status.FailureDomains
 	dst.Spec.Paused = restored.Spec.Paused
 	dst.Status.Conditions = restored.Status.Conditions
+	dst.Status.ControlPlaneProbe = restored.Status.ControlPlaneProbe
 	dst.Status.ObservedGeneration = restored.Status.ObservedGeneration
 
 	return nil
@@ -191,6 +192,9 @@ func (src *MachineSet) ConvertTo(dstRaw conversion.Hub) error {
 		dst.Spec.ClusterName = restored.Spec.ClusterName
 	}
 	restoreMachineSpec(&restored.Spec.Template.Spec, &dst.Spec.Template.Spec)
+	dst.Spec.MachineNamingStrategy = restored.Spec.MachineNamingStrategy
+	dst.Spec.SpreadAcrossFailureDomains = restored.Spec.SpreadAcrossFailureDomains
+	dst.Status.Conditions = restored.Status.Conditions
 
 	return nil
 }
@@ -254,6 +258,8 @@ func (src *MachineDeployment) ConvertTo(dstRaw conversion.Hub) error {
 		dst.Spec.ClusterName = restored.Spec.ClusterName
 	}
 	dst.Spec.Paused = restored.Spec.Paused
+	dst.Spec.RolloutAfter = restored.Spec.RolloutAfter
+	dst.Spec.SpreadAcrossFailureDomains = restored.Spec.SpreadAcrossFailureDomains
 	dst.Status.Phase = restored.Status.Phase
 	restoreMachineSpec(&restored.Spec.Template.Spec, &dst.Spec.Template.Spec)
 
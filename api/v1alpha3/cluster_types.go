@@ -139,11 +139,25 @@ type ClusterStatus struct {
 	// +optional
 	Conditions Conditions `json:"conditions,omitempty"`
 
+	// ControlPlaneProbe reports the result of the last probe of the workload cluster's API server.
+	// +optional
+	ControlPlaneProbe *ControlPlaneProbe `json:"controlPlaneProbe,omitempty"`
+
 	// ObservedGeneration is the latest generation observed by the controller.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
+// ControlPlaneProbe reports the result of a probe of the workload cluster's API server.
+type ControlPlaneProbe struct {
+	// LastProbeTime is the time the API server was last probed.
+	LastProbeTime metav1.Time `json:"lastProbeTime"`
+
+	// Latency is the time the API server took to answer the last probe; it is not set if the probe failed.
+	// +optional
+	Latency *metav1.Duration `json:"latency,omitempty"`
+}
+
 // ANCHOR_END: ClusterStatus
 
 // SetTypedPhase sets the Phase field to the string representation of ClusterPhase.
@@ -195,6 +209,7 @@ func (v APIEndpoint) String() string {
 // +kubebuilder:storageversion
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Cluster status such as Pending/Provisioning/Provisioned/Deleting/Failed"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of Cluster"
 
 // Cluster is the Schema for the clusters API
 type Cluster struct {
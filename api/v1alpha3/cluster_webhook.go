@@ -17,8 +17,13 @@ limitations under the License.
 package v1alpha3
 
 import (
+	"fmt"
+	"net"
+	"reflect"
+
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -48,12 +53,16 @@ func (c *Cluster) Default() {
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (c *Cluster) ValidateCreate() error {
-	return c.validate()
+	return c.validate(nil)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (c *Cluster) ValidateUpdate(old runtime.Object) error {
-	return c.validate()
+	oldC, ok := old.(*Cluster)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a Cluster but got a %T", old))
+	}
+	return c.validate(oldC)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -61,7 +70,7 @@ func (c *Cluster) ValidateDelete() error {
 	return nil
 }
 
-func (c *Cluster) validate() error {
+func (c *Cluster) validate(old *Cluster) error {
 	var allErrs field.ErrorList
 	if c.Spec.InfrastructureRef != nil && c.Spec.InfrastructureRef.Namespace != c.Namespace {
 		allErrs = append(
@@ -87,8 +96,85 @@ func (c *Cluster) validate() error {
 
 	}
 
+	allErrs = append(allErrs, c.validateClusterNetwork(old)...)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
 	return apierrors.NewInvalid(GroupVersion.WithKind("Cluster").GroupKind(), c.Name, allErrs)
 }
+
+func (c *Cluster) validateClusterNetwork(old *Cluster) field.ErrorList {
+	var allErrs field.ErrorList
+	path := field.NewPath("spec", "clusterNetwork")
+
+	// The values are validated on create, and on update only if the cluster network changed, so that Clusters
+	// created before the validation was introduced can still be updated, e.g. for removing their finalizers.
+	network := c.Spec.ClusterNetwork
+	if network != nil && (old == nil || !reflect.DeepEqual(old.Spec.ClusterNetwork, network)) {
+		if network.APIServerPort != nil && (*network.APIServerPort < 1 || *network.APIServerPort > 65535) {
+			allErrs = append(allErrs, field.Invalid(path.Child("apiServerPort"), *network.APIServerPort, "must be a valid port number between 1 and 65535"))
+		}
+
+		podCIDRs, errs := parseCIDRBlocks(path.Child("pods", "cidrBlocks"), network.Pods)
+		allErrs = append(allErrs, errs...)
+		serviceCIDRs, errs := parseCIDRBlocks(path.Child("services", "cidrBlocks"), network.Services)
+		allErrs = append(allErrs, errs...)
+
+		for i, pod := range podCIDRs {
+			for _, service := range serviceCIDRs {
+				if pod == nil || service == nil {
+					continue
+				}
+				if pod.Contains(service.IP) || service.Contains(pod.IP) {
+					allErrs = append(allErrs, field.Invalid(path.Child("pods", "cidrBlocks").Index(i), pod.String(), fmt.Sprintf("must not overlap with the services CIDR block %s", service.String())))
+				}
+			}
+		}
+
+		if network.ServiceDomain != "" {
+			for _, msg := range validation.IsDNS1123Subdomain(network.ServiceDomain) {
+				allErrs = append(allErrs, field.Invalid(path.Child("serviceDomain"), network.ServiceDomain, msg))
+			}
+		}
+	}
+
+	// Changes to the cluster network are not supported once the cluster network is defined, because
+	// the values are consumed by the infrastructure and bootstrap providers when the cluster is created.
+	if old != nil && old.Spec.ClusterNetwork != nil {
+		oldNetwork := old.Spec.ClusterNetwork
+		if network == nil {
+			network = &ClusterNetwork{}
+		}
+		if !reflect.DeepEqual(oldNetwork.Pods, network.Pods) {
+			allErrs = append(allErrs, field.Invalid(path.Child("pods"), network.Pods.String(), "field is immutable"))
+		}
+		if !reflect.DeepEqual(oldNetwork.Services, network.Services) {
+			allErrs = append(allErrs, field.Invalid(path.Child("services"), network.Services.String(), "field is immutable"))
+		}
+		if oldNetwork.ServiceDomain != network.ServiceDomain {
+			allErrs = append(allErrs, field.Invalid(path.Child("serviceDomain"), network.ServiceDomain, "field is immutable"))
+		}
+	}
+
+	return allErrs
+}
+
+// parseCIDRBlocks parses the CIDR blocks in a NetworkRanges, returning an error for each invalid CIDR block.
+// Invalid CIDR blocks are returned as nil, so the returned slice preserves the indexes of the CIDR blocks.
+func parseCIDRBlocks(path *field.Path, ranges *NetworkRanges) ([]*net.IPNet, field.ErrorList) {
+	if ranges == nil {
+		return nil, nil
+	}
+
+	var allErrs field.ErrorList
+	cidrs := make([]*net.IPNet, 0, len(ranges.CIDRBlocks))
+	for i, block := range ranges.CIDRBlocks {
+		_, cidr, err := net.ParseCIDR(block)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Index(i), block, "must be a valid CIDR block"))
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, allErrs
+}
@@ -23,6 +23,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 )
 
 func TestClusterDefault(t *testing.T) {
@@ -91,10 +92,212 @@ func TestClusterValidation(t *testing.T) {
 
 			if tt.expectErr {
 				g.Expect(tt.c.ValidateCreate()).NotTo(Succeed())
-				g.Expect(tt.c.ValidateUpdate(nil)).NotTo(Succeed())
+				g.Expect(tt.c.ValidateUpdate(tt.c)).NotTo(Succeed())
 			} else {
 				g.Expect(tt.c.ValidateCreate()).To(Succeed())
-				g.Expect(tt.c.ValidateUpdate(nil)).To(Succeed())
+				g.Expect(tt.c.ValidateUpdate(tt.c)).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestClusterNetworkValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		expectErr bool
+		network   *ClusterNetwork
+	}{
+		{
+			name:      "should succeed without a cluster network",
+			expectErr: false,
+			network:   nil,
+		},
+		{
+			name:      "should succeed with valid and not overlapping CIDR blocks",
+			expectErr: false,
+			network: &ClusterNetwork{
+				Pods:          &NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16"}},
+				Services:      &NetworkRanges{CIDRBlocks: []string{"10.128.0.0/12"}},
+				ServiceDomain: "cluster.local",
+			},
+		},
+		{
+			name:      "should return error for invalid CIDR blocks",
+			expectErr: true,
+			network: &ClusterNetwork{
+				Pods: &NetworkRanges{CIDRBlocks: []string{"192.168.0.0"}},
+			},
+		},
+		{
+			name:      "should return error for overlapping CIDR blocks",
+			expectErr: true,
+			network: &ClusterNetwork{
+				Pods:     &NetworkRanges{CIDRBlocks: []string{"10.0.0.0/8"}},
+				Services: &NetworkRanges{CIDRBlocks: []string{"10.128.0.0/12"}},
+			},
+		},
+		{
+			name:      "should return error for an invalid service domain",
+			expectErr: true,
+			network: &ClusterNetwork{
+				ServiceDomain: "Cluster_Local",
+			},
+		},
+		{
+			name:      "should return error for an invalid API server port",
+			expectErr: true,
+			network: &ClusterNetwork{
+				APIServerPort: pointer.Int32Ptr(70000),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := &Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "foo",
+				},
+				Spec: ClusterSpec{
+					ClusterNetwork: tt.network,
+				},
+			}
+
+			if tt.expectErr {
+				g.Expect(c.ValidateCreate()).NotTo(Succeed())
+			} else {
+				g.Expect(c.ValidateCreate()).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestClusterNetworkImmutability(t *testing.T) {
+	old := &Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "foo",
+		},
+		Spec: ClusterSpec{
+			ClusterNetwork: &ClusterNetwork{
+				Pods:          &NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16"}},
+				Services:      &NetworkRanges{CIDRBlocks: []string{"10.128.0.0/12"}},
+				ServiceDomain: "cluster.local",
+			},
+		},
+	}
+
+	changedPods := old.DeepCopy()
+	changedPods.Spec.ClusterNetwork.Pods.CIDRBlocks = []string{"192.169.0.0/16"}
+
+	changedServiceDomain := old.DeepCopy()
+	changedServiceDomain.Spec.ClusterNetwork.ServiceDomain = "example.local"
+
+	removedNetwork := old.DeepCopy()
+	removedNetwork.Spec.ClusterNetwork = nil
+
+	changedPort := old.DeepCopy()
+	changedPort.Spec.ClusterNetwork.APIServerPort = pointer.Int32Ptr(443)
+
+	tests := []struct {
+		name      string
+		expectErr bool
+		c         *Cluster
+	}{
+		{
+			name:      "should return error when the pods CIDR blocks change",
+			expectErr: true,
+			c:         changedPods,
+		},
+		{
+			name:      "should return error when the service domain changes",
+			expectErr: true,
+			c:         changedServiceDomain,
+		},
+		{
+			name:      "should return error when the cluster network is removed",
+			expectErr: true,
+			c:         removedNetwork,
+		},
+		{
+			name:      "should succeed when the API server port changes",
+			expectErr: false,
+			c:         changedPort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			if tt.expectErr {
+				g.Expect(tt.c.ValidateUpdate(old)).NotTo(Succeed())
+			} else {
+				g.Expect(tt.c.ValidateUpdate(old)).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestClusterNetworkValidationOnUpdate(t *testing.T) {
+	// A Cluster created before the cluster network was validated.
+	invalid := &Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "foo",
+		},
+		Spec: ClusterSpec{
+			ClusterNetwork: &ClusterNetwork{
+				Pods:          &NetworkRanges{CIDRBlocks: []string{"10.0.0.0/8"}},
+				Services:      &NetworkRanges{CIDRBlocks: []string{"10.128.0.0/12"}},
+				ServiceDomain: "Cluster_Local",
+			},
+		},
+	}
+
+	changedLabels := invalid.DeepCopy()
+	changedLabels.Labels = map[string]string{"foo": "bar"}
+
+	changedPort := invalid.DeepCopy()
+	changedPort.Spec.ClusterNetwork.APIServerPort = pointer.Int32Ptr(443)
+
+	withoutNetwork := invalid.DeepCopy()
+	withoutNetwork.Spec.ClusterNetwork = nil
+
+	tests := []struct {
+		name      string
+		expectErr bool
+		old       *Cluster
+		c         *Cluster
+	}{
+		{
+			name:      "should succeed when the invalid cluster network does not change",
+			expectErr: false,
+			old:       invalid,
+			c:         changedLabels,
+		},
+		{
+			name:      "should return error when the invalid cluster network changes",
+			expectErr: true,
+			old:       invalid,
+			c:         changedPort,
+		},
+		{
+			name:      "should return error when an invalid cluster network is added",
+			expectErr: true,
+			old:       withoutNetwork,
+			c:         invalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			if tt.expectErr {
+				g.Expect(tt.c.ValidateUpdate(tt.old)).NotTo(Succeed())
+			} else {
+				g.Expect(tt.c.ValidateUpdate(tt.old)).To(Succeed())
 			}
 		})
 	}
@@ -26,6 +26,34 @@ const (
 	// external objects(bootstrap and infrastructure providers)
 	ClusterLabelName = "cluster.x-k8s.io/cluster-name"
 
+	// PropagatedMetadataDomain is the domain of the labels and annotations propagated from MachineDeployments
+	// to MachineSets, Machines and Nodes; keys with this domain, or a subdomain, as prefix (e.g. node.cluster.x-k8s.io/rack
+	// or tier.node.cluster.x-k8s.io/name) are updated in place, without triggering a rollout.
+	PropagatedMetadataDomain = "node.cluster.x-k8s.io"
+
+	// NodeMetadataPropagatedAnnotation is set on Machines whose propagated labels or annotations have been applied
+	// to the Node, so the Node is updated when they are removed from the Machine.
+	NodeMetadataPropagatedAnnotation = "cluster.x-k8s.io/node-metadata-propagated"
+
+	// BootstrapDataRegeneratedAnnotation is set by bootstrap providers on the bootstrap config when the bootstrap data
+	// is regenerated before the Machine gets a Node, e.g. because the join token expired while waiting for infrastructure
+	// capacity; the value is the time of the regeneration in RFC3339 format.
+	// The Machine controller propagates the annotation to the Machine and to the infrastructure machine, so infrastructure
+	// providers supporting it can re-inject the user data when the annotation value changes.
+	BootstrapDataRegeneratedAnnotation = "cluster.x-k8s.io/bootstrap-data-regenerated"
+
+	// DiagnosticsRequestedAnnotation is set by bootstrap providers on the infrastructure machine of a Machine that did
+	// not complete the bootstrap process in time; the value is the name of a Secret, in the namespace of the
+	// infrastructure machine, created by the bootstrap provider for storing the diagnostic bundle.
+	// Infrastructure providers supporting it collect the diagnostic data from the host, e.g. the cloud-init and kubeadm
+	// logs read from the serial console, and store them in the Secret, with a key for each file; the bootstrap provider
+	// removes the annotation once the Secret has data.
+	DiagnosticsRequestedAnnotation = "cluster.x-k8s.io/diagnostics-requested"
+
+	// DiagnosticsFailedAnnotation is set by infrastructure providers on the infrastructure machine when the collection
+	// of the diagnostic bundle requested with DiagnosticsRequestedAnnotation fails; the value is the error message.
+	DiagnosticsFailedAnnotation = "cluster.x-k8s.io/diagnostics-failed"
+
 	// ProviderLabelName is the label set on components in the provider manifest.
 	// This label allows to easily identify all the components belonging to a provider; the clusterctl
 	// tool uses this label for implementing provider's lifecycle operations.
@@ -73,6 +101,25 @@ type MachineAddress struct {
 // MachineAddresses is a slice of MachineAddress items to be used by infrastructure providers.
 type MachineAddresses []MachineAddress
 
+// SSHEndpoint describes how to reach a machine over SSH for debugging purposes.
+// Infrastructure providers may publish it in the status.sshEndpoint field of their infrastructure machines.
+type SSHEndpoint struct {
+	// Host is the host name or the IP address to connect to.
+	Host string `json:"host"`
+
+	// Port is the SSH port; if unspecified, port 22 is used.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// User is the user to log in as, e.g. the default user of the machine image.
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// ProxyJump is the jump host to connect through, e.g. a bastion host, in the [user@]host[:port] form.
+	// +optional
+	ProxyJump string `json:"proxyJump,omitempty"`
+}
+
 // ObjectMeta is metadata that all persisted resources must have, which includes all objects
 // users must create. This is a copy of customizable fields from metav1.ObjectMeta.
 //
@@ -54,6 +54,30 @@ const (
 	WaitingForControlPlaneFallbackReason = "WaitingForControlPlane"
 )
 
+const (
+	// ControlPlaneReachableCondition reports if the API server of the workload cluster answered the last probe.
+	ControlPlaneReachableCondition ConditionType = "ControlPlaneReachable"
+
+	// ControlPlaneUnreachableReason (Severity=Warning) documents a cluster whose API server did not answer the last probe.
+	ControlPlaneUnreachableReason = "ControlPlaneUnreachable"
+)
+
+const (
+	// NodesNetworkReadyCondition reports if the network plugin (CNI) is ready on all the Nodes of the workload cluster.
+	// This condition is set only when the nodes network check is enabled in the Cluster API controller manager.
+	NodesNetworkReadyCondition ConditionType = "NodesNetworkReady"
+
+	// WaitingForNodesReason (Severity=Info) documents a cluster waiting for the first Node to join the workload cluster.
+	WaitingForNodesReason = "WaitingForNodes"
+
+	// NodesNetworkNotReadyReason (Severity=Info) documents a cluster with Nodes reporting that the network plugin
+	// is not ready, e.g. because the CNI is not installed yet.
+	NodesNetworkNotReadyReason = "NodesNetworkNotReady"
+
+	// NodesProbeFailedReason (Severity=Warning) documents a cluster whose Nodes could not be read from the workload cluster.
+	NodesProbeFailedReason = "NodesProbeFailed"
+)
+
 // Conditions and condition Reasons for the Machine object
 
 const (
@@ -67,6 +91,51 @@ const (
 	// to be available.
 	// NOTE: This reason is used only as a fallback when the bootstrap object is not reporting its own ready condition.
 	WaitingForDataSecretFallbackReason = "WaitingForDataSecret"
+
+	// DataSecretNotFoundReason (Severity=Warning) documents a machine without a bootstrap object whose bootstrap data
+	// secret, e.g. a pre-generated secret shared by many machines, does not exist.
+	DataSecretNotFoundReason = "DataSecretNotFound"
+)
+
+const (
+	// TerminationNoticeReceivedCondition is set by infrastructure providers on interruptible infrastructure machines when
+	// the infrastructure is going to be reclaimed, e.g. when a spot instance termination notice is received.
+	// This condition is mirrored on the machine, and the machine controller drains the node without waiting for
+	// the machine to be deleted.
+	TerminationNoticeReceivedCondition ConditionType = "TerminationNoticeReceived"
+
+	// PreTerminationDrainSucceededCondition documents the result of the node drain performed by the machine controller
+	// after a termination notice is received.
+	PreTerminationDrainSucceededCondition ConditionType = "PreTerminationDrainSucceeded"
+
+	// DrainingFailedReason (Severity=Warning) documents a machine controller failing to drain the node.
+	DrainingFailedReason = "DrainingFailed"
+)
+
+const (
+	// VolumeDetachSucceededCondition documents the wait performed by the machine controller for the volumes attached
+	// to the node to be detached before deleting the infrastructure of a machine being deleted.
+	VolumeDetachSucceededCondition ConditionType = "VolumeDetachSucceeded"
+
+	// WaitingForVolumeDetachReason (Severity=Info) documents a machine being deleted waiting for the volumes attached
+	// to the node to be detached.
+	WaitingForVolumeDetachReason = "WaitingForVolumeDetach"
+
+	// VolumeDetachTimedOutReason (Severity=Warning) documents a machine being deleted whose node volumes were not
+	// detached in time, and whose VolumeAttachments were deleted by the machine controller.
+	VolumeDetachTimedOutReason = "VolumeDetachTimedOut"
+)
+
+const (
+	// DeletionSafetyCheckSucceededCondition documents the check performed by the machine controller, when enabled,
+	// before deleting the infrastructure of a machine being deleted: the infrastructure of a machine whose node is
+	// still Ready is deleted only if the machine has been marked unhealthy by a MachineHealthCheck, or if the
+	// deletion is forced with the ForceMachineDeletionAnnotation.
+	DeletionSafetyCheckSucceededCondition ConditionType = "DeletionSafetyCheckSucceeded"
+
+	// NodeStillReadyReason (Severity=Warning) documents a machine being deleted whose infrastructure is not deleted
+	// because its node is still Ready.
+	NodeStillReadyReason = "NodeStillReady"
 )
 
 const (
@@ -95,3 +164,19 @@ const (
 	// WaitingForRemediation is the reason used when a machine fails a health check and remediation is needed.
 	WaitingForRemediation = "WaitingForRemediation"
 )
+
+// Conditions and condition Reasons for the MachineSet object
+
+const (
+	// PreflightChecksSucceededCondition documents the result of the preflight checks run by the MachineSet controller
+	// before creating new Machines; when the checks fail, the creation of new Machines is paused.
+	PreflightChecksSucceededCondition ConditionType = "PreflightChecksSucceeded"
+
+	// ControlPlaneNotStableReason (Severity=Info) documents a MachineSet waiting for the control plane to be ready
+	// and not rolling out machines, e.g. during an upgrade, before creating new Machines.
+	ControlPlaneNotStableReason = "ControlPlaneNotStable"
+
+	// KubernetesVersionSkewReason (Severity=Warning) documents a MachineSet not creating new Machines because their
+	// Kubernetes version is not within the version skew policy with respect to the control plane.
+	KubernetesVersionSkewReason = "KubernetesVersionSkew"
+)
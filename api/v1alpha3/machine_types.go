@@ -32,11 +32,33 @@ const (
 	// ExcludeNodeDrainingAnnotation annotation explicitly skips node draining if set
 	ExcludeNodeDrainingAnnotation = "machine.cluster.x-k8s.io/exclude-node-draining"
 
+	// ExcludeWaitForNodeVolumeDetachAnnotation annotation explicitly skips the wait for the node volumes to be detached if set
+	ExcludeWaitForNodeVolumeDetachAnnotation = "machine.cluster.x-k8s.io/exclude-wait-for-node-volume-detach"
+
+	// SkipRemediationAnnotation is set on Machines that must not be remediated when unhealthy, e.g. Machines running
+	// stateful workloads that require a manual intervention; MachineHealthChecks do not mark them for remediation.
+	SkipRemediationAnnotation = "cluster.x-k8s.io/skip-remediation"
+
+	// ProtectMachineAnnotation is set on Machines that must not be deleted by the controllers, e.g. Machines running
+	// stateful or licensed workloads; they are not selected for deletion when their MachineSet scales down, including
+	// during a MachineDeployment rollout, and they are not remediated. Explicitly deleting the Machine, or its owners,
+	// is not prevented.
+	ProtectMachineAnnotation = "cluster.x-k8s.io/protect-machine"
+
+	// ForceMachineDeletionAnnotation explicitly allows deleting the infrastructure of a Machine whose Node is still Ready,
+	// when the machine deletion safety check is enabled. It is never set by the controllers, so deleting such a Machine,
+	// including when a MachineSet or a KubeadmControlPlane scales down, requires an explicit opt-in.
+	ForceMachineDeletionAnnotation = "machine.cluster.x-k8s.io/force-deletion"
+
 	// MachineSetLabelName is the label set on machines if they're controlled by MachineSet
 	MachineSetLabelName = "cluster.x-k8s.io/set-name"
 
 	// MachineDeploymentLabelName is the label set on machines if they're controlled by MachineDeployment
 	MachineDeploymentLabelName = "cluster.x-k8s.io/deployment-name"
+
+	// InterruptibleLabel is the label set on machines, and on their nodes, when the infrastructure provider reports
+	// that the infrastructure can be reclaimed at any time, e.g. for spot or preemptible instances.
+	InterruptibleLabel = "cluster.x-k8s.io/interruptible"
 )
 
 // ANCHOR: MachineSpec
@@ -224,7 +246,9 @@ type Bootstrap struct {
 // +kubebuilder:storageversion
 // +kubebuilder:printcolumn:name="ProviderID",type="string",JSONPath=".spec.providerID",description="Provider ID"
 // +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Machine status such as Terminating/Pending/Running/Failed etc"
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".spec.version",description="Kubernetes version associated with this Machine"
 // +kubebuilder:printcolumn:name="NodeName",type="string",JSONPath=".status.nodeRef.name",description="Node name associated with this machine",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of Machine"
 
 // Machine is the Schema for the machines API
 type Machine struct {
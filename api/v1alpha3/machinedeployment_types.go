@@ -40,6 +40,14 @@ const (
 	// is machinedeployment.spec.replicas + maxSurge. Used by the underlying machine sets to estimate their
 	// proportions in case the deployment has surge replicas.
 	MaxReplicasAnnotation = "machinedeployment.clusters.x-k8s.io/max-replicas"
+	// RestartedAtAnnotation can be set on a machine deployment to a RFC3339 timestamp for forcing a rollout of the
+	// machines created before it, without changing the spec; it behaves like Spec.RolloutAfter, and the latest of the
+	// two times is used when both are set.
+	RestartedAtAnnotation = "machinedeployment.clusters.x-k8s.io/restarted-at"
+	// RolloutAfterAnnotation is set on the machine template of the machine sets created by a rollout triggered by
+	// Spec.RolloutAfter or by the RestartedAtAnnotation, recording the rollout time; it makes the name of the new machine set,
+	// derived from the machine template hash, different from the one of the machine set being replaced.
+	RolloutAfterAnnotation = "machinedeployment.clusters.x-k8s.io/rollout-after"
 )
 
 // ANCHOR: MachineDeploymentSpec
@@ -67,6 +75,13 @@ type MachineDeploymentSpec struct {
 	// +optional
 	Strategy *MachineDeploymentStrategy `json:"strategy,omitempty"`
 
+	// RolloutAfter is a field to indicate a rollout should be performed
+	// after the specified time even if no changes have been made to the
+	// MachineDeployment, e.g. for rotating certificates or refreshing images;
+	// machines created before this time are replaced by a new MachineSet.
+	// +optional
+	RolloutAfter *metav1.Time `json:"rolloutAfter,omitempty"`
+
 	// Minimum number of seconds for which a newly created machine should
 	// be ready.
 	// Defaults to 0 (machine will be considered available as soon as it
@@ -90,6 +105,12 @@ type MachineDeploymentSpec struct {
 	// reason will be surfaced in the deployment status. Note that progress will
 	// not be estimated during the time a deployment is paused. Defaults to 600s.
 	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+
+	// SpreadAcrossFailureDomains spreads the Machines of the MachineDeployment across the failure domains of
+	// the Cluster, when the machine template does not define a failure domain.
+	// The value is propagated to the MachineSets of the MachineDeployment.
+	// +optional
+	SpreadAcrossFailureDomains bool `json:"spreadAcrossFailureDomains,omitempty"`
 }
 
 // ANCHOR_END: MachineDeploymentSpec
@@ -248,6 +269,8 @@ func (md *MachineDeploymentStatus) GetTypedPhase() MachineDeploymentPhase {
 // +kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".status.replicas",description="Total number of non-terminated machines targeted by this deployment"
 // +kubebuilder:printcolumn:name="Available",type="integer",JSONPath=".status.availableReplicas",description="Total number of available machines (ready for at least minReadySeconds)"
 // +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas",description="Total number of ready machines targeted by this deployment."
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".spec.template.spec.version",description="Kubernetes version associated with this MachineDeployment"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of MachineDeployment"
 
 // MachineDeployment is the Schema for the machinedeployments API
 type MachineDeployment struct {
@@ -18,6 +18,7 @@ package v1alpha3
 
 import (
 	"fmt"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -85,6 +86,15 @@ func (m *MachineDeployment) validate(old *MachineDeployment) error {
 		)
 	}
 
+	if restartedAt, ok := m.Annotations[RestartedAtAnnotation]; ok {
+		if _, err := time.Parse(time.RFC3339, restartedAt); err != nil {
+			allErrs = append(
+				allErrs,
+				field.Invalid(field.NewPath("metadata", "annotations", RestartedAtAnnotation), restartedAt, "must be a RFC3339 timestamp"),
+			)
+		}
+	}
+
 	if old != nil && old.Spec.ClusterName != m.Spec.ClusterName {
 		allErrs = append(
 			allErrs,
@@ -114,6 +114,43 @@ func TestMachineDeploymentValidation(t *testing.T) {
 	}
 }
 
+func TestMachineDeploymentRestartedAtValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		restartedAt string
+		expectErr   bool
+	}{
+		{
+			name:        "should not return error for a RFC3339 timestamp",
+			restartedAt: "2020-10-01T00:00:00Z",
+			expectErr:   false,
+		},
+		{
+			name:        "should return error for an invalid timestamp",
+			restartedAt: "now",
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			md := &MachineDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{RestartedAtAnnotation: tt.restartedAt},
+				},
+			}
+			if tt.expectErr {
+				g.Expect(md.ValidateCreate()).NotTo(Succeed())
+				g.Expect(md.ValidateUpdate(md)).NotTo(Succeed())
+			} else {
+				g.Expect(md.ValidateCreate()).To(Succeed())
+				g.Expect(md.ValidateUpdate(md)).To(Succeed())
+			}
+		})
+	}
+}
+
 func TestMachineDeploymentWithSpec(t *testing.T) {
 	g := NewWithT(t)
 	md := MachineDeployment{
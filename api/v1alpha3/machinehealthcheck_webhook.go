@@ -31,11 +31,11 @@ import (
 )
 
 var (
-	// Default time allowed for a node to start up. Can be made longer as part of
+	// DefaultNodeStartupTimeout is the default time allowed for a node to start up. Can be made longer as part of
 	// spec if required for particular provider.
 	// 10 minutes should allow the instance to start and the node to join the
 	// cluster on most providers.
-	defaultNodeStartupTimeout = metav1.Duration{Duration: 10 * time.Minute}
+	DefaultNodeStartupTimeout = metav1.Duration{Duration: 10 * time.Minute}
 )
 
 func (m *MachineHealthCheck) SetupWebhookWithManager(mgr ctrl.Manager) error {
@@ -63,7 +63,8 @@ func (m *MachineHealthCheck) Default() {
 	}
 
 	if m.Spec.NodeStartupTimeout == nil {
-		m.Spec.NodeStartupTimeout = &defaultNodeStartupTimeout
+		nodeStartupTimeout := DefaultNodeStartupTimeout
+		m.Spec.NodeStartupTimeout = &nodeStartupTimeout
 	}
 }
 
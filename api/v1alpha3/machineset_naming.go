@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+const (
+	// DefaultMachineNamingTemplate is the default template of MachineNamingStrategy.
+	DefaultMachineNamingTemplate = "{{ .machineSet.name }}-{{ .random }}"
+
+	// DefaultMachineNamingRandomLength is the default random string length of MachineNamingStrategy.
+	DefaultMachineNamingRandomLength = 5
+
+	// DefaultMachineNamingMaxLength is the default maximum name length of MachineNamingStrategy.
+	DefaultMachineNamingMaxLength = 63
+)
+
+// GenerateMachineName generates the name of a Machine created by a MachineSet according to the naming strategy.
+func GenerateMachineName(strategy *MachineNamingStrategy, clusterName, machineSetName string) (string, error) {
+	randomLength := DefaultMachineNamingRandomLength
+	if strategy != nil && strategy.RandomLength != nil {
+		randomLength = int(*strategy.RandomLength)
+	}
+
+	// Nb. the random string starts with a letter, so names starting with it are valid DNS-1035 labels.
+	random := rand.String(randomLength)
+	random = string(machineNameLetters[rand.Intn(len(machineNameLetters))]) + random[1:]
+
+	return renderMachineName(strategy, clusterName, machineSetName, random)
+}
+
+// machineNameLetters are the letters used for the first character of the random string.
+const machineNameLetters = "bcdfghjklmnpqrstvwxz"
+
+// renderMachineName renders the name of a Machine using the given random string.
+func renderMachineName(strategy *MachineNamingStrategy, clusterName, machineSetName, random string) (string, error) {
+	text := DefaultMachineNamingTemplate
+	maxLength := DefaultMachineNamingMaxLength
+	if strategy != nil {
+		if strategy.Template != "" {
+			text = strategy.Template
+		}
+		if strategy.MaxLength != nil {
+			maxLength = int(*strategy.MaxLength)
+		}
+	}
+
+	tpl, err := template.New("machineName").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse the machine naming template")
+	}
+
+	// The random string is rendered as a placeholder, so the text before and after it can be sanitized and
+	// truncated separately.
+	const placeholder = "\x00"
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, map[string]interface{}{
+		"cluster":    map[string]interface{}{"name": clusterName},
+		"machineSet": map[string]interface{}{"name": machineSetName},
+		"random":     placeholder,
+	}); err != nil {
+		return "", errors.Wrap(err, "failed to render the machine naming template")
+	}
+
+	parts := strings.SplitN(buf.String(), placeholder, 2)
+	if len(parts) != 2 {
+		return "", errors.New("the machine naming template must use .random")
+	}
+	prefix := strings.TrimLeftFunc(sanitizeMachineName(parts[0]), func(r rune) bool { return r < 'a' || r > 'z' })
+	suffix := strings.TrimRight(sanitizeMachineName(strings.Replace(parts[1], placeholder, "", -1)), "-")
+
+	// Truncates the suffix first and then the prefix, always preserving the random string.
+	if excess := len(prefix) + len(random) + len(suffix) - maxLength; excess > 0 {
+		n := excess
+		if n > len(suffix) {
+			n = len(suffix)
+		}
+		suffix = strings.TrimRight(suffix[:len(suffix)-n], "-")
+		excess -= n
+		if excess > len(prefix) {
+			return "", errors.Errorf("the random string does not fit the maximum name length %d", maxLength)
+		}
+		if excess > 0 {
+			// Preserves the separator before the random string, if any.
+			separator := ""
+			if strings.HasSuffix(prefix, "-") && excess < len(prefix) {
+				separator = "-"
+				prefix = prefix[:len(prefix)-1]
+			}
+			prefix = strings.TrimRight(prefix[:len(prefix)-excess], "-")
+			if prefix != "" {
+				prefix += separator
+			}
+		}
+	}
+	if prefix == "" && (random[0] < 'a' || random[0] > 'z') {
+		return "", errors.New("the machine name must start with a letter")
+	}
+
+	return prefix + random + suffix, nil
+}
+
+// sanitizeMachineName lowercases s and replaces the characters that are not valid in DNS-1035 labels with "-".
+func sanitizeMachineName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '-'
+		}
+	}, s)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/pointer"
+)
+
+func TestRenderMachineName(t *testing.T) {
+	tests := []struct {
+		name           string
+		strategy       *MachineNamingStrategy
+		clusterName    string
+		machineSetName string
+		want           string
+	}{
+		{
+			name:           "default strategy",
+			strategy:       nil,
+			machineSetName: "md-0-abcde",
+			want:           "md-0-abcde-xyz12",
+		},
+		{
+			name:           "custom template",
+			strategy:       &MachineNamingStrategy{Template: "{{ .cluster.name }}-{{ .random }}-worker"},
+			clusterName:    "prod",
+			machineSetName: "md-0-abcde",
+			want:           "prod-xyz12-worker",
+		},
+		{
+			name:           "sanitizes the name",
+			strategy:       &MachineNamingStrategy{Template: "1_{{ .cluster.name }}.{{ .random }}_"},
+			clusterName:    "Prod",
+			machineSetName: "md-0",
+			want:           "prod-xyz12",
+		},
+		{
+			name:           "truncates the text before the random string",
+			strategy:       &MachineNamingStrategy{Template: "{{ .cluster.name }}-{{ .random }}", MaxLength: pointer.Int32Ptr(15)},
+			clusterName:    "my-very-long-cluster-name",
+			machineSetName: "md-0",
+			want:           "my-very-l-xyz12",
+		},
+		{
+			name:           "truncates the text after the random string first",
+			strategy:       &MachineNamingStrategy{Template: "w{{ .random }}-{{ .machineSet.name }}", MaxLength: pointer.Int32Ptr(10)},
+			machineSetName: "md-0-abcde",
+			want:           "wxyz12-md",
+		},
+		{
+			name:           "truncates the text after and before the random string",
+			strategy:       &MachineNamingStrategy{Template: "{{ .cluster.name }}-{{ .random }}-{{ .machineSet.name }}", MaxLength: pointer.Int32Ptr(8)},
+			clusterName:    "prod",
+			machineSetName: "md-0-abcde",
+			want:           "pr-xyz12",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := renderMachineName(tt.strategy, tt.clusterName, tt.machineSetName, "xyz12")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(validation.IsDNS1035Label(got)).To(BeEmpty())
+		})
+	}
+}
+
+func TestGenerateMachineName(t *testing.T) {
+	g := NewWithT(t)
+
+	strategy := &MachineNamingStrategy{Template: "{{ .random }}", RandomLength: pointer.Int32Ptr(8)}
+	for i := 0; i < 100; i++ {
+		got, err := GenerateMachineName(strategy, "prod", "md-0")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(got).To(HaveLen(8))
+		g.Expect(validation.IsDNS1035Label(got)).To(BeEmpty())
+	}
+}
@@ -24,6 +24,12 @@ import (
 	capierrors "sigs.k8s.io/cluster-api/errors"
 )
 
+const (
+	// MachineSetSkipPreflightChecksAnnotation can be set on a MachineSet for creating new Machines without running
+	// the preflight checks, e.g. the control plane stability and the Kubernetes version skew checks.
+	MachineSetSkipPreflightChecksAnnotation = "machineset.cluster.x-k8s.io/skip-preflight-checks"
+)
+
 // ANCHOR: MachineSetSpec
 
 // MachineSetSpec defines the desired state of MachineSet
@@ -59,10 +65,50 @@ type MachineSetSpec struct {
 	// Object references to custom resources resources are treated as templates.
 	// +optional
 	Template MachineTemplateSpec `json:"template,omitempty"`
+
+	// MachineNamingStrategy allows changing the names of the Machines created by the MachineSet, and of their
+	// bootstrap and infrastructure objects, e.g. to fit the naming constraints of the infrastructure.
+	// If unspecified, names are generated from the MachineSet name with a random suffix.
+	// +optional
+	MachineNamingStrategy *MachineNamingStrategy `json:"machineNamingStrategy,omitempty"`
+
+	// SpreadAcrossFailureDomains spreads the Machines of the MachineSet across the failure domains of the Cluster,
+	// when the machine template does not define a failure domain.
+	// New Machines are placed in the failure domain with the fewest Machines of the MachineSet, and scaling down
+	// deletes Machines from the failure domain with the most Machines, so the MachineSet gets rebalanced over time
+	// without disrupting running Machines.
+	// +optional
+	SpreadAcrossFailureDomains bool `json:"spreadAcrossFailureDomains,omitempty"`
 }
 
 // ANCHOR_END: MachineSetSpec
 
+// MachineNamingStrategy defines how the names of the Machines created by a MachineSet are generated.
+type MachineNamingStrategy struct {
+	// Template is the Go template used to generate the names; the .cluster.name, .machineSet.name and .random
+	// variables are available, and .random is required to make the names unique.
+	// The generated names are lowercased, invalid characters are replaced with "-", and leading characters
+	// other than letters are removed, so that they are valid DNS-1035 labels.
+	// Defaults to "{{ .machineSet.name }}-{{ .random }}".
+	// +optional
+	Template string `json:"template,omitempty"`
+
+	// RandomLength is the length of the random string available as .random in the template.
+	// Defaults to 5.
+	// +kubebuilder:validation:Minimum=3
+	// +kubebuilder:validation:Maximum=16
+	// +optional
+	RandomLength *int32 `json:"randomLength,omitempty"`
+
+	// MaxLength is the maximum length of the generated names, e.g. 15 for the NetBIOS names of Windows
+	// machines; longer names are truncated before the random string, which is always preserved.
+	// Defaults to 63.
+	// +kubebuilder:validation:Minimum=8
+	// +kubebuilder:validation:Maximum=63
+	// +optional
+	MaxLength *int32 `json:"maxLength,omitempty"`
+}
+
 // ANCHOR: MachineTemplateSpec
 
 // MachineTemplateSpec describes the data needed to create a Machine from a template
@@ -156,6 +202,10 @@ type MachineSetStatus struct {
 	FailureReason *capierrors.MachineSetStatusError `json:"failureReason,omitempty"`
 	// +optional
 	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// Conditions defines current service state of the MachineSet.
+	// +optional
+	Conditions Conditions `json:"conditions,omitempty"`
 }
 
 // ANCHOR_END: MachineSetStatus
@@ -191,6 +241,8 @@ func (m *MachineSet) Validate() field.ErrorList {
 // +kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".status.replicas",description="Total number of non-terminated machines targeted by this machineset"
 // +kubebuilder:printcolumn:name="Available",type="integer",JSONPath=".status.availableReplicas",description="Total number of available machines (ready for at least minReadySeconds)"
 // +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas",description="Total number of ready machines targeted by this machineset."
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".spec.template.spec.version",description="Kubernetes version associated with this MachineSet"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of MachineSet"
 
 // MachineSet is the Schema for the machinesets API
 type MachineSet struct {
@@ -201,6 +253,14 @@ type MachineSet struct {
 	Status MachineSetStatus `json:"status,omitempty"`
 }
 
+func (m *MachineSet) GetConditions() Conditions {
+	return m.Status.Conditions
+}
+
+func (m *MachineSet) SetConditions(conditions Conditions) {
+	m.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // MachineSetList contains a list of MachineSet
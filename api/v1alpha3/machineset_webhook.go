@@ -18,6 +18,7 @@ package v1alpha3
 
 import (
 	"fmt"
+	"strings"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -69,6 +70,18 @@ func (m *MachineSet) Default() {
 		m.Spec.Selector.MatchLabels[MachineSetLabelName] = m.Name
 		m.Spec.Template.Labels[MachineSetLabelName] = m.Name
 	}
+
+	if s := m.Spec.MachineNamingStrategy; s != nil {
+		if s.Template == "" {
+			s.Template = DefaultMachineNamingTemplate
+		}
+		if s.RandomLength == nil {
+			s.RandomLength = pointer.Int32Ptr(DefaultMachineNamingRandomLength)
+		}
+		if s.MaxLength == nil {
+			s.MaxLength = pointer.Int32Ptr(DefaultMachineNamingMaxLength)
+		}
+	}
 }
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
@@ -109,6 +122,10 @@ func (m *MachineSet) validate(old *MachineSet) error {
 		)
 	}
 
+	if s := m.Spec.MachineNamingStrategy; s != nil {
+		allErrs = append(allErrs, s.validate(field.NewPath("spec", "machineNamingStrategy"), m.Spec.ClusterName, m.Name)...)
+	}
+
 	if old != nil && old.Spec.ClusterName != m.Spec.ClusterName {
 		allErrs = append(
 			allErrs,
@@ -122,3 +139,22 @@ func (m *MachineSet) validate(old *MachineSet) error {
 
 	return apierrors.NewInvalid(GroupVersion.WithKind("MachineSet").GroupKind(), m.Name, allErrs)
 }
+
+func (s *MachineNamingStrategy) validate(path *field.Path, clusterName, machineSetName string) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if s.RandomLength != nil && s.MaxLength != nil && *s.MaxLength < *s.RandomLength {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxLength"), *s.MaxLength, "must not be less than randomLength"))
+	}
+	if s.Template != "" && !strings.Contains(s.Template, ".random") {
+		allErrs = append(allErrs, field.Invalid(path.Child("template"), s.Template, "must use .random"))
+	}
+	if len(allErrs) > 0 {
+		return allErrs
+	}
+
+	if _, err := GenerateMachineName(s, clusterName, machineSetName); err != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("template"), s.Template, err.Error()))
+	}
+	return allErrs
+}
@@ -153,3 +153,64 @@ func TestMachineSetClusterNameImmutable(t *testing.T) {
 		})
 	}
 }
+
+func TestMachineSetMachineNamingStrategyValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  *MachineNamingStrategy
+		expectErr bool
+	}{
+		{
+			name:      "should not return error with the default strategy",
+			strategy:  &MachineNamingStrategy{},
+			expectErr: false,
+		},
+		{
+			name:      "should not return error with a valid template",
+			strategy:  &MachineNamingStrategy{Template: "{{ .cluster.name }}-{{ .machineSet.name }}-{{ .random }}", MaxLength: pointer.Int32Ptr(15)},
+			expectErr: false,
+		},
+		{
+			name:      "should return error if the template does not use .random",
+			strategy:  &MachineNamingStrategy{Template: "{{ .machineSet.name }}"},
+			expectErr: true,
+		},
+		{
+			name:      "should return error if the template can not be parsed",
+			strategy:  &MachineNamingStrategy{Template: "{{ .random "},
+			expectErr: true,
+		},
+		{
+			name:      "should return error if the template uses unknown variables",
+			strategy:  &MachineNamingStrategy{Template: "{{ .machine.name }}-{{ .random }}"},
+			expectErr: true,
+		},
+		{
+			name:      "should return error if the random string does not fit the maximum length",
+			strategy:  &MachineNamingStrategy{RandomLength: pointer.Int32Ptr(10), MaxLength: pointer.Int32Ptr(8)},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ms := &MachineSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-ms",
+				},
+				Spec: MachineSetSpec{
+					ClusterName:           "test-cluster",
+					MachineNamingStrategy: tt.strategy,
+				},
+			}
+			ms.Default()
+
+			if tt.expectErr {
+				g.Expect(ms.ValidateCreate()).NotTo(Succeed())
+			} else {
+				g.Expect(ms.ValidateCreate()).To(Succeed())
+			}
+		})
+	}
+}
@@ -40,9 +40,24 @@ func (src *KubeadmConfig) ConvertTo(dstRaw conversion.Hub) error {
 	dst.Status.ObservedGeneration = restored.Status.ObservedGeneration
 	dst.Spec.Verbosity = restored.Spec.Verbosity
 	dst.Spec.UseExperimentalRetryJoin = restored.Spec.UseExperimentalRetryJoin
+	dst.Spec.BootstrapMode = restored.Spec.BootstrapMode
+	dst.Spec.UseDiscoveryFile = restored.Spec.UseDiscoveryFile
+	dst.Spec.Staging = restored.Spec.Staging
+	dst.Spec.NetworkConfig = restored.Spec.NetworkConfig
 	dst.Spec.DiskSetup = restored.Spec.DiskSetup
 	dst.Spec.Mounts = restored.Spec.Mounts
 	dst.Spec.Files = restored.Spec.Files
+	dst.Spec.Kubelet = restored.Spec.Kubelet
+	dst.Spec.Sysctls = restored.Spec.Sysctls
+	dst.Spec.KernelModules = restored.Spec.KernelModules
+	dst.Spec.Profiles = restored.Spec.Profiles
+	dst.Spec.EncryptionProviderConfig = restored.Spec.EncryptionProviderConfig
+	dst.Spec.AuditConfig = restored.Spec.AuditConfig
+	dst.Spec.ExternalCloudProvider = restored.Spec.ExternalCloudProvider
+	dst.Spec.Addons = restored.Spec.Addons
+	dst.Spec.DNSDomain = restored.Spec.DNSDomain
+	dst.Spec.Images = restored.Spec.Images
+	dst.Spec.CertificateRefs = restored.Spec.CertificateRefs
 	dst.Status.Conditions = restored.Status.Conditions
 
 	// Track files successfully up-converted. We need this to dedupe
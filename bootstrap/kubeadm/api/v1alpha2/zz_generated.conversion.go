@@ -168,6 +168,7 @@ func autoConvert_v1alpha3_File_To_v1alpha2_File(in *v1alpha3.File, out *File, s
 	out.Owner = in.Owner
 	out.Permissions = in.Permissions
 	out.Encoding = Encoding(in.Encoding)
+	// WARNING: in.Append requires manual conversion: does not exist in peer-type
 	out.Content = in.Content
 	// WARNING: in.ContentFrom requires manual conversion: does not exist in peer-type
 	return nil
@@ -291,7 +292,22 @@ func autoConvert_v1alpha3_KubeadmConfigSpec_To_v1alpha2_KubeadmConfigSpec(in *v1
 	out.PostKubeadmCommands = *(*[]string)(unsafe.Pointer(&in.PostKubeadmCommands))
 	out.Users = *(*[]User)(unsafe.Pointer(&in.Users))
 	out.NTP = (*NTP)(unsafe.Pointer(in.NTP))
+	// WARNING: in.Kubelet requires manual conversion: does not exist in peer-type
+	// WARNING: in.Sysctls requires manual conversion: does not exist in peer-type
+	// WARNING: in.KernelModules requires manual conversion: does not exist in peer-type
+	// WARNING: in.Profiles requires manual conversion: does not exist in peer-type
+	// WARNING: in.Addons requires manual conversion: does not exist in peer-type
+	// WARNING: in.EncryptionProviderConfig requires manual conversion: does not exist in peer-type
+	// WARNING: in.AuditConfig requires manual conversion: does not exist in peer-type
+	// WARNING: in.ExternalCloudProvider requires manual conversion: does not exist in peer-type
+	// WARNING: in.DNSDomain requires manual conversion: does not exist in peer-type
+	// WARNING: in.Images requires manual conversion: does not exist in peer-type
+	// WARNING: in.CertificateRefs requires manual conversion: does not exist in peer-type
 	out.Format = Format(in.Format)
+	// WARNING: in.BootstrapMode requires manual conversion: does not exist in peer-type
+	// WARNING: in.Staging requires manual conversion: does not exist in peer-type
+	// WARNING: in.NetworkConfig requires manual conversion: does not exist in peer-type
+	// WARNING: in.UseDiscoveryFile requires manual conversion: does not exist in peer-type
 	// WARNING: in.Verbosity requires manual conversion: does not exist in peer-type
 	// WARNING: in.UseExperimentalRetryJoin requires manual conversion: does not exist in peer-type
 	return nil
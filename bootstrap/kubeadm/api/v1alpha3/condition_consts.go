@@ -66,3 +66,26 @@ const (
 	// an error while while retrieving certificates for a joining node.
 	CertificatesCorruptedReason = "CertificatesCorrupted"
 )
+
+const (
+	// BootstrapCompletedCondition documents the completion of the bootstrap process on the Machine, i.e. the
+	// node joining the workload cluster.
+	//
+	// NOTE: This condition is set only if the KubeadmConfig controller is configured with a bootstrap timeout,
+	// and it is not part of the Ready condition summary.
+	BootstrapCompletedCondition clusterv1.ConditionType = "BootstrapCompleted"
+
+	// WaitingForDiagnosticsReason (Severity=Warning) documents a Machine not completing the bootstrap process within
+	// the bootstrap timeout, and the KubeadmConfig controller waiting for the infrastructure provider to collect the
+	// diagnostic bundle from the Machine.
+	WaitingForDiagnosticsReason = "WaitingForDiagnostics"
+
+	// BootstrapTimedOutReason (Severity=Warning) documents a Machine not completing the bootstrap process within
+	// the bootstrap timeout; the condition message points at the Secret storing the diagnostic bundle collected
+	// from the Machine, e.g. the cloud-init and kubeadm logs.
+	BootstrapTimedOutReason = "BootstrapTimedOut"
+
+	// DiagnosticsCollectionFailedReason (Severity=Warning) documents a Machine not completing the bootstrap process
+	// within the bootstrap timeout, and the infrastructure provider failing to collect the diagnostic bundle.
+	DiagnosticsCollectionFailedReason = "DiagnosticsCollectionFailed"
+)
@@ -31,6 +31,19 @@ const (
 	CloudConfig Format = "cloud-config"
 )
 
+// BootstrapMode specifies how kubeadm init/join is run on the machine.
+// +kubebuilder:validation:Enum=runcmd;systemd
+type BootstrapMode string
+
+const (
+	// BootstrapModeRunCmd runs kubeadm as a cloud-init runcmd command.
+	BootstrapModeRunCmd BootstrapMode = "runcmd"
+
+	// BootstrapModeSystemd runs kubeadm in a systemd unit started by cloud-init; the unit is restarted
+	// on failure, and the kubeadm logs are captured by journald.
+	BootstrapModeSystemd BootstrapMode = "systemd"
+)
+
 // KubeadmConfigSpec defines the desired state of KubeadmConfig.
 // Either ClusterConfiguration and InitConfiguration should be defined or the JoinConfiguration should be defined.
 type KubeadmConfigSpec struct {
@@ -58,11 +71,13 @@ type KubeadmConfigSpec struct {
 	// +optional
 	Mounts []MountPoints `json:"mounts,omitempty"`
 
-	// PreKubeadmCommands specifies extra commands to run before kubeadm runs
+	// PreKubeadmCommands specifies extra commands to run before kubeadm runs.
+	// Commands can reference machine variables, e.g. {{ .MachineName }}.
 	// +optional
 	PreKubeadmCommands []string `json:"preKubeadmCommands,omitempty"`
 
-	// PostKubeadmCommands specifies extra commands to run after kubeadm runs
+	// PostKubeadmCommands specifies extra commands to run after kubeadm runs.
+	// Commands can reference machine variables, e.g. {{ .MachineName }}.
 	// +optional
 	PostKubeadmCommands []string `json:"postKubeadmCommands,omitempty"`
 
@@ -74,10 +89,93 @@ type KubeadmConfigSpec struct {
 	// +optional
 	NTP *NTP `json:"ntp,omitempty"`
 
+	// Kubelet specifies additional configuration for the kubelet, rendered as a systemd drop-in
+	// for the kubelet service.
+	// +optional
+	Kubelet *KubeletOptions `json:"kubelet,omitempty"`
+
+	// Sysctls specifies kernel parameters to be set, e.g. net.ipv4.ip_forward; they are written to a file
+	// in /etc/sysctl.d, so they persist across reboots, and applied before kubeadm runs.
+	// +optional
+	Sysctls map[string]string `json:"sysctls,omitempty"`
+
+	// KernelModules specifies kernel modules to be loaded, e.g. br_netfilter; they are written to a file
+	// in /etc/modules-load.d, so they persist across reboots, and loaded before kubeadm runs.
+	// +optional
+	KernelModules []string `json:"kernelModules,omitempty"`
+
+	// Profiles specifies node profiles, e.g. nvidia-gpu, each expanding to the packages, kernel modules, sysctls and
+	// containerd configuration required by the corresponding node capability; they are rendered before Sysctls and
+	// KernelModules, which take precedence over the values set by the profiles.
+	// +optional
+	Profiles []NodeProfile `json:"profiles,omitempty"`
+
+	// Addons specifies which of the addons installed by kubeadm init should be skipped, e.g. for clusters
+	// using a CNI plugin replacing kube-proxy or a custom DNS.
+	// +optional
+	Addons *KubeadmAddons `json:"addons,omitempty"`
+
+	// EncryptionProviderConfig specifies the encryption at rest configuration for the API server of control plane
+	// machines; the EncryptionConfiguration file is generated from the referenced keys, and the API server is
+	// configured to use it.
+	// +optional
+	EncryptionProviderConfig *EncryptionProviderConfig `json:"encryptionProviderConfig,omitempty"`
+
+	// AuditConfig specifies the audit configuration for the API server of control plane machines; the audit
+	// Policy file is generated, and the API server is configured to use it and to write the audit log.
+	// +optional
+	AuditConfig *AuditConfig `json:"auditConfig,omitempty"`
+
+	// ExternalCloudProvider configures the API server, the controller manager and the kubelet for an external
+	// cloud provider, i.e. with cloud-provider: external, and optionally writes the cloud config file on the machine.
+	// +optional
+	ExternalCloudProvider *ExternalCloudProvider `json:"externalCloudProvider,omitempty"`
+
+	// DNSDomain specifies the DNS domain used by the services of the cluster, e.g. cluster.local; it is rendered
+	// into ClusterConfiguration.Networking.DNSDomain, taking precedence over the value set there.
+	// +optional
+	DNSDomain string `json:"dnsDomain,omitempty"`
+
+	// Images specifies overrides for the container images of the control plane components, rendered into
+	// ClusterConfiguration and taking precedence over the values set there.
+	// +optional
+	Images *ImageOverrides `json:"images,omitempty"`
+
+	// CertificateRefs references Secrets with user-provided certificates and keys to be used for the cluster CA,
+	// the etcd CA, the front-proxy CA and the service account keys, instead of generating new ones.
+	// The referenced Secrets are validated and copied into the cluster certificate Secrets when those do not
+	// exist yet; certificates not referenced here are generated as usual.
+	// +optional
+	CertificateRefs []CertificateRef `json:"certificateRefs,omitempty"`
+
 	// Format specifies the output format of the bootstrap data
 	// +optional
 	Format Format `json:"format,omitempty"`
 
+	// BootstrapMode specifies how kubeadm init/join is run on the machine; if unspecified, runcmd is used.
+	// With systemd, kubeadm runs in the kubeadm-bootstrap systemd unit, which is restarted on failure and
+	// whose logs can be read with journalctl -u kubeadm-bootstrap.
+	// +optional
+	BootstrapMode BootstrapMode `json:"bootstrapMode,omitempty"`
+
+	// Staging moves the files of the bootstrap data to a payload fetched at boot when the bootstrap data exceeds a
+	// size limit, e.g. the user data size limit of some clouds; the bootstrap data Secret then stores the payload
+	// in the payload key, and the user data in the value key embeds only a small script fetching the payload.
+	// +optional
+	Staging *Staging `json:"staging,omitempty"`
+
+	// NetworkConfig specifies the network configuration of the machine, e.g. static IP addresses or secondary network
+	// interfaces, rendered in the cloud-init network configuration version 2 format, i.e. the netplan format.
+	// +optional
+	NetworkConfig *NetworkConfig `json:"networkConfig,omitempty"`
+
+	// UseDiscoveryFile makes joining nodes discover the cluster using a file with the cluster CA certificate and the
+	// control plane endpoint, instead of the cluster-info ConfigMap signed with the bootstrap token and verified
+	// with the CA certificate hashes; the bootstrap token is still used for the TLS bootstrap of the kubelet.
+	// It is ignored if JoinConfiguration.Discovery.File is set, or if the cluster CA certificate is not available.
+	// +optional
+	UseDiscoveryFile bool `json:"useDiscoveryFile,omitempty"`
+
 	// Verbosity is the number for the kubeadm log level verbosity.
 	// It overrides the `--v` flag in kubeadm commands.
 	// +optional
@@ -185,18 +283,27 @@ type File struct {
 	Path string `json:"path"`
 
 	// Owner specifies the ownership of the file, e.g. "root:root".
+	// If unspecified, "root:root" is used.
 	// +optional
 	Owner string `json:"owner,omitempty"`
 
 	// Permissions specifies the permissions to assign to the file, e.g. "0640".
+	// If unspecified, "0644" is used.
 	// +optional
 	Permissions string `json:"permissions,omitempty"`
 
 	// Encoding specifies the encoding of the file contents.
+	// Compressing large files with gzip+base64 allows to keep the bootstrap data within the user data size
+	// limits of the infrastructure providers.
 	// +optional
 	Encoding Encoding `json:"encoding,omitempty"`
 
+	// Append specifies whether to append Content to the file if it already exists.
+	// +optional
+	Append bool `json:"append,omitempty"`
+
 	// Content is the actual content of the file.
+	// Unless the content is encoded, it can reference machine variables, e.g. {{ .MachineName }}.
 	// +optional
 	Content string `json:"content,omitempty"`
 
@@ -282,6 +389,336 @@ type NTP struct {
 	Enabled *bool `json:"enabled,omitempty"`
 }
 
+// KubeletOptions defines additional configuration for the kubelet service.
+// The options are rendered in a systemd drop-in for the kubelet service, and they are
+// applied in addition to the flags generated by kubeadm from nodeRegistration.kubeletExtraArgs.
+type KubeletOptions struct {
+	// ExtraArgs specifies additional flags to pass to the kubelet, e.g. `max-pods: "110"`.
+	// Flag names must be provided without the leading dashes.
+	// The flags are passed using the KUBELET_EXTRA_ARGS environment variable, so they replace the value
+	// defined in /etc/default/kubelet (or /etc/sysconfig/kubelet), if any.
+	// +optional
+	ExtraArgs map[string]string `json:"extraArgs,omitempty"`
+
+	// Environment specifies additional environment variables to set for the kubelet service.
+	// +optional
+	Environment map[string]string `json:"environment,omitempty"`
+}
+
+// KubeadmAddons defines the addons installed by kubeadm init that should be skipped.
+// Skipped addons are rendered as the corresponding kubeadm init phases in the --skip-phases flag.
+type KubeadmAddons struct {
+	// SkipCoreDNS skips the installation of CoreDNS (the addon/coredns phase).
+	// +optional
+	SkipCoreDNS bool `json:"skipCoreDNS,omitempty"`
+
+	// SkipKubeProxy skips the installation of kube-proxy (the addon/kube-proxy phase).
+	// +optional
+	SkipKubeProxy bool `json:"skipKubeProxy,omitempty"`
+}
+
+// EncryptionProvider defines the provider used to encrypt resources at rest.
+type EncryptionProvider string
+
+const (
+	// AESCBCEncryptionProvider encrypts resources with AES-CBC, using PKCS#7 padding.
+	AESCBCEncryptionProvider EncryptionProvider = "aescbc"
+	// AESGCMEncryptionProvider encrypts resources with AES-GCM.
+	AESGCMEncryptionProvider EncryptionProvider = "aesgcm"
+	// SecretboxEncryptionProvider encrypts resources with XSalsa20 and Poly1305.
+	SecretboxEncryptionProvider EncryptionProvider = "secretbox"
+)
+
+// EncryptionProviderConfig defines the encryption at rest configuration for the API server.
+// The configuration is rendered as an EncryptionConfiguration file on control plane machines, and
+// it is passed to the API server using the --encryption-provider-config flag.
+type EncryptionProviderConfig struct {
+	// Resources specifies the resources to encrypt, e.g. "secrets".
+	// If unspecified, only secrets are encrypted.
+	// +optional
+	Resources []string `json:"resources,omitempty"`
+
+	// Provider specifies the encryption provider.
+	// If unspecified, "aescbc" is used.
+	// +kubebuilder:validation:Enum=aescbc;aesgcm;secretbox
+	// +optional
+	Provider EncryptionProvider `json:"provider,omitempty"`
+
+	// Keys specifies the encryption keys; the first key is used for encryption, while
+	// all the keys are used for decryption, thus allowing key rotation.
+	// +kubebuilder:validation:MinItems=1
+	Keys []EncryptionKey `json:"keys"`
+}
+
+// EncryptionKey defines an encryption key read from a Secret.
+type EncryptionKey struct {
+	// Name of the key in the EncryptionConfiguration.
+	Name string `json:"name"`
+
+	// Secret references the Secret data key storing the base64 encoded encryption key, e.g.
+	// the output of "head -c 32 /dev/urandom | base64".
+	Secret SecretFileSource `json:"secret"`
+}
+
+// AuditConfig defines the audit configuration for the API server.
+// The audit Policy is rendered as a file on control plane machines, and it is passed to the API server
+// using the --audit-policy-file flag, together with the --audit-log-* flags.
+type AuditConfig struct {
+	// Policy specifies the audit Policy, as a YAML document of kind Policy, e.g. with apiVersion audit.k8s.io/v1.
+	// If unspecified, a policy recording the metadata of all the requests is used.
+	// +optional
+	Policy string `json:"policy,omitempty"`
+
+	// LogPath specifies the absolute path of the audit log file on control plane machines.
+	// If unspecified, /var/log/kubernetes/audit/audit.log is used.
+	// +optional
+	LogPath string `json:"logPath,omitempty"`
+
+	// LogMaxAge specifies the maximum number of days to retain old audit log files.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	LogMaxAge *int32 `json:"logMaxAge,omitempty"`
+
+	// LogMaxBackup specifies the maximum number of old audit log files to retain.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	LogMaxBackup *int32 `json:"logMaxBackup,omitempty"`
+
+	// LogMaxSize specifies the maximum size in megabytes of the audit log file before it gets rotated.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	LogMaxSize *int32 `json:"logMaxSize,omitempty"`
+}
+
+// ExternalCloudProvider defines the configuration for an external cloud provider.
+// The cloud-provider: external flag is set on the API server and the controller manager of control plane machines,
+// and on the kubelet of all the machines.
+type ExternalCloudProvider struct {
+	// CloudConfig is a reference to the Secret key containing the cloud config file, e.g. for the cloud controller
+	// manager or the CSI drivers running on the machine.
+	// +optional
+	CloudConfig *SecretFileSource `json:"cloudConfig,omitempty"`
+
+	// CloudConfigPath specifies the absolute path of the cloud config file on the machine.
+	// If unspecified, /etc/kubernetes/cloud.conf is used.
+	// +optional
+	CloudConfigPath string `json:"cloudConfigPath,omitempty"`
+}
+
+// ImageOverrides defines overrides for the container images used by kubeadm.
+type ImageOverrides struct {
+	// ImageRepository is the container registry the control plane images are pulled from, e.g.
+	// registry.example.com/k8s; it is rendered into ClusterConfiguration.ImageRepository.
+	// +optional
+	ImageRepository string `json:"imageRepository,omitempty"`
+
+	// CoreDNSImageTag is the tag of the CoreDNS image; it is rendered into ClusterConfiguration.DNS.ImageTag.
+	// +optional
+	CoreDNSImageTag string `json:"coreDNSImageTag,omitempty"`
+
+	// EtcdImageTag is the tag of the etcd image; it is rendered into ClusterConfiguration.Etcd.Local.ImageTag,
+	// so it can't be used with an external etcd.
+	// +optional
+	EtcdImageTag string `json:"etcdImageTag,omitempty"`
+}
+
+// NodeProfile is a named set of node prerequisites, e.g. packages, kernel modules, sysctls and containerd
+// configuration, rendered in the bootstrap data.
+// +kubebuilder:validation:Enum=nvidia-gpu;selinux-enforcing;ipvs
+type NodeProfile string
+
+const (
+	// NvidiaGPUProfile configures containerd to run containers with the NVIDIA container runtime, and loads the NVIDIA
+	// kernel modules; the NVIDIA driver and the NVIDIA package repository must be available in the machine image.
+	NvidiaGPUProfile NodeProfile = "nvidia-gpu"
+
+	// SELinuxEnforcingProfile sets SELinux in enforcing mode, installs the SELinux policy for containers,
+	// and enables SELinux support in containerd.
+	SELinuxEnforcingProfile NodeProfile = "selinux-enforcing"
+
+	// IPVSProfile loads the kernel modules and installs the packages required by kube-proxy in IPVS mode.
+	IPVSProfile NodeProfile = "ipvs"
+)
+
+// Staging defines how the files of the bootstrap data are staged when the bootstrap data exceeds a size limit.
+type Staging struct {
+	// MaxUserDataSize is the size, in bytes, above which the files of the bootstrap data are staged;
+	// it defaults to 16384.
+	// +kubebuilder:validation:Minimum=1024
+	// +optional
+	MaxUserDataSize *int32 `json:"maxUserDataSize,omitempty"`
+
+	// URL the payload is fetched from at boot, e.g. a signed URL of an object in a bucket; ${NAMESPACE} and ${NAME}
+	// are replaced with the namespace and the name of the KubeadmConfig. The payload must be published at the URL
+	// by another component, e.g. the infrastructure provider, reading it from the bootstrap data Secret.
+	// If empty, the payload is fetched from a Secret in the workload cluster that can be read only with the
+	// bootstrap token of the machine; this is not possible for the first control plane machine, whose bootstrap
+	// data is not staged in that case.
+	// +optional
+	URL string `json:"url,omitempty"`
+}
+
+// NetworkConfigMode defines how the network configuration is delivered to the machine.
+type NetworkConfigMode string
+
+const (
+	// CloudInitNetworkConfigMode stores the network configuration in the networkConfig key of the bootstrap data
+	// Secret, for the infrastructure provider to pass it to cloud-init as network-config, e.g. in the vSphere
+	// guestinfo metadata or in the NoCloud data source.
+	CloudInitNetworkConfigMode = NetworkConfigMode("cloud-init")
+
+	// NetplanNetworkConfigMode writes the network configuration in a netplan file with the bootstrap data, and
+	// applies it with netplan apply before kubeadm runs; the image must include netplan.
+	NetplanNetworkConfigMode = NetworkConfigMode("netplan")
+)
+
+// NetworkRenderer defines the backend configuring the network interfaces.
+type NetworkRenderer string
+
+const (
+	// NetworkdRenderer configures the network interfaces with systemd-networkd.
+	NetworkdRenderer = NetworkRenderer("networkd")
+
+	// NetworkManagerRenderer configures the network interfaces with NetworkManager.
+	NetworkManagerRenderer = NetworkRenderer("NetworkManager")
+)
+
+// NetworkConfig defines the network configuration of a machine.
+type NetworkConfig struct {
+	// Mode defines how the network configuration is delivered to the machine; if unspecified, cloud-init is used.
+	// +kubebuilder:validation:Enum=cloud-init;netplan
+	// +optional
+	Mode NetworkConfigMode `json:"mode,omitempty"`
+
+	// Renderer defines the backend configuring the network interfaces; if unspecified, the default of the image is used.
+	// +kubebuilder:validation:Enum=networkd;NetworkManager
+	// +optional
+	Renderer NetworkRenderer `json:"renderer,omitempty"`
+
+	// Interfaces defines the ethernet interfaces to be configured.
+	// +kubebuilder:validation:MinItems=1
+	Interfaces []NetworkInterface `json:"interfaces"`
+}
+
+// NetworkInterface defines the configuration of an ethernet interface.
+type NetworkInterface struct {
+	// Name identifies the interface in the network configuration; it is the name of the interface, e.g. eth1,
+	// unless MACAddress is set.
+	Name string `json:"name"`
+
+	// MACAddress selects the interface by its MAC address rather than by name; the interface is renamed to Name.
+	// +optional
+	MACAddress string `json:"macAddress,omitempty"`
+
+	// DHCP4 enables DHCP for IPv4.
+	// +optional
+	DHCP4 bool `json:"dhcp4,omitempty"`
+
+	// DHCP6 enables DHCP for IPv6.
+	// +optional
+	DHCP6 bool `json:"dhcp6,omitempty"`
+
+	// Addresses are the static IP addresses of the interface, in CIDR notation, e.g. 192.168.1.10/24.
+	// +optional
+	Addresses []string `json:"addresses,omitempty"`
+
+	// Gateway4 is the IPv4 default gateway.
+	// +optional
+	Gateway4 string `json:"gateway4,omitempty"`
+
+	// Gateway6 is the IPv6 default gateway.
+	// +optional
+	Gateway6 string `json:"gateway6,omitempty"`
+
+	// Nameservers are the IP addresses of the DNS servers.
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// SearchDomains are the DNS search domains.
+	// +optional
+	SearchDomains []string `json:"searchDomains,omitempty"`
+
+	// Routes are the static routes of the interface.
+	// +optional
+	Routes []NetworkRoute `json:"routes,omitempty"`
+
+	// MTU is the maximum transmission unit of the interface.
+	// +kubebuilder:validation:Minimum=576
+	// +optional
+	MTU *int32 `json:"mtu,omitempty"`
+}
+
+// NetworkRoute defines a static route.
+type NetworkRoute struct {
+	// To is the destination of the route, in CIDR notation.
+	To string `json:"to"`
+
+	// Via is the IP address of the gateway of the route.
+	Via string `json:"via"`
+
+	// Metric is the metric of the route.
+	// +optional
+	Metric *int32 `json:"metric,omitempty"`
+}
+
+// CertificatePurpose is the purpose of a user-provided certificate.
+type CertificatePurpose string
+
+const (
+	// ClusterCACertificate is the purpose of the cluster CA.
+	ClusterCACertificate = CertificatePurpose("ca")
+
+	// EtcdCACertificate is the purpose of the etcd CA.
+	EtcdCACertificate = CertificatePurpose("etcd")
+
+	// FrontProxyCACertificate is the purpose of the front-proxy CA.
+	FrontProxyCACertificate = CertificatePurpose("proxy")
+
+	// ServiceAccountCertificate is the purpose of the service account key pair.
+	ServiceAccountCertificate = CertificatePurpose("sa")
+)
+
+// CertificateRef references a Secret with a user-provided certificate and key.
+type CertificateRef struct {
+	// Purpose of the certificate.
+	// +kubebuilder:validation:Enum=ca;etcd;proxy;sa
+	Purpose CertificatePurpose `json:"purpose"`
+
+	// SecretName is the name of a Secret in the namespace of the Cluster, with the PEM encoded certificate and
+	// key in the tls.crt and tls.key data fields; for service account keys, tls.crt is the public key.
+	SecretName string `json:"secretName"`
+}
+
+// ApplyClusterConfigurationOverrides renders DNSDomain and Images into ClusterConfiguration, creating it if
+// required; the values set in ClusterConfiguration are overridden.
+func (c *KubeadmConfigSpec) ApplyClusterConfigurationOverrides() {
+	if c.DNSDomain == "" && c.Images == nil {
+		return
+	}
+	if c.ClusterConfiguration == nil {
+		c.ClusterConfiguration = &kubeadmv1beta1.ClusterConfiguration{}
+	}
+
+	if c.DNSDomain != "" {
+		c.ClusterConfiguration.Networking.DNSDomain = c.DNSDomain
+	}
+	if c.Images == nil {
+		return
+	}
+	if c.Images.ImageRepository != "" {
+		c.ClusterConfiguration.ImageRepository = c.Images.ImageRepository
+	}
+	if c.Images.CoreDNSImageTag != "" {
+		c.ClusterConfiguration.DNS.ImageTag = c.Images.CoreDNSImageTag
+	}
+	if c.Images.EtcdImageTag != "" {
+		if c.ClusterConfiguration.Etcd.Local == nil {
+			c.ClusterConfiguration.Etcd.Local = &kubeadmv1beta1.LocalEtcd{}
+		}
+		c.ClusterConfiguration.Etcd.Local.ImageTag = c.Images.EtcdImageTag
+	}
+}
+
 // DiskSetup defines input for generated disk_setup and fs_setup in cloud-init.
 type DiskSetup struct {
 	// Partitions specifies the list of the partitions to setup.
@@ -22,6 +22,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
 )
 
 // These tests are written in BDD-style using Ginkgo framework. Refer to
@@ -67,6 +68,156 @@ func TestClusterValidate(t *testing.T) {
 				},
 			},
 		},
+		"valid encryptionProviderConfig": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					EncryptionProviderConfig: &EncryptionProviderConfig{
+						Keys: []EncryptionKey{
+							{
+								Name: "key1",
+								Secret: SecretFileSource{
+									Name: "foo",
+									Key:  "bar",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"invalid encryptionProviderConfig without keys": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					EncryptionProviderConfig: &EncryptionProviderConfig{},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid encryptionProviderConfig with incomplete key": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					EncryptionProviderConfig: &EncryptionProviderConfig{
+						Keys: []EncryptionKey{
+							{
+								Secret: SecretFileSource{
+									Name: "foo",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"valid auditConfig": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					AuditConfig: &AuditConfig{
+						Policy:  "apiVersion: audit.k8s.io/v1\nkind: Policy\nrules:\n- level: RequestResponse",
+						LogPath: "/var/log/audit.log",
+					},
+				},
+			},
+		},
+		"invalid auditConfig with relative log path": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					AuditConfig: &AuditConfig{
+						LogPath: "audit.log",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid auditConfig with a policy of another kind": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					AuditConfig: &AuditConfig{
+						Policy: "apiVersion: v1\nkind: ConfigMap",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"valid sysctls and kernel modules": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Sysctls: map[string]string{
+						"net.ipv4.ip_forward":                "1",
+						"net/bridge/bridge-nf-call-iptables": "1",
+					},
+					KernelModules: []string{"br_netfilter", "ip_vs"},
+				},
+			},
+		},
+		"invalid sysctl name": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Sysctls: map[string]string{
+						"net.ipv4.ip_forward = 1": "1",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid sysctl value": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Sysctls: map[string]string{
+						"net.ipv4.ip_forward": "1\nkernel.panic = 0",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid kernel module": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					KernelModules: []string{"br_netfilter; reboot"},
+				},
+			},
+			expectErr: true,
+		},
 		"invalid content and contentFrom": {
 			in: &KubeadmConfig{
 				ObjectMeta: metav1.ObjectMeta{
@@ -145,6 +296,339 @@ func TestClusterValidate(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		"valid external cloud provider": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					ExternalCloudProvider: &ExternalCloudProvider{
+						CloudConfig:     &SecretFileSource{Name: "cloud-config", Key: "cloud.conf"},
+						CloudConfigPath: "/etc/kubernetes/cloud.conf",
+					},
+					JoinConfiguration: &kubeadmv1beta1.JoinConfiguration{
+						NodeRegistration: kubeadmv1beta1.NodeRegistrationOptions{
+							KubeletExtraArgs: map[string]string{"cloud-provider": "external"},
+						},
+					},
+				},
+			},
+		},
+		"invalid external cloud provider": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					ExternalCloudProvider: &ExternalCloudProvider{
+						CloudConfig:     &SecretFileSource{Name: "cloud-config"},
+						CloudConfigPath: "cloud.conf",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"external cloud provider conflicting with the cloud-provider flags": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					ExternalCloudProvider: &ExternalCloudProvider{},
+					ClusterConfiguration: &kubeadmv1beta1.ClusterConfiguration{
+						ControllerManager: kubeadmv1beta1.ControlPlaneComponent{
+							ExtraArgs: map[string]string{"cloud-provider": "aws"},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"valid machine variables": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Files: []File{
+						{
+							Path:    "/etc/machine",
+							Content: "name={{ .MachineName }} cluster={{.ClusterName}} hostname={{ ds.meta_data.local_hostname }}",
+						},
+						{
+							Path:     "/etc/encoded",
+							Content:  "{{ .Unknown }}",
+							Encoding: Base64,
+						},
+					},
+					PreKubeadmCommands:  []string{"echo {{ .ProviderID }}"},
+					PostKubeadmCommands: []string{"echo {{ .FailureDomain }}"},
+				},
+			},
+		},
+		"unrelated templates in files and commands": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Files: []File{
+						{
+							Path:    "/etc/template",
+							Content: "{{ .Values.image }}",
+						},
+					},
+					PreKubeadmCommands:  []string{"docker ps --format '{{ .Names }}'"},
+					PostKubeadmCommands: []string{"crictl ps -o go-template --template '{{ .Names }}'"},
+				},
+			},
+		},
+		"valid dns domain and image overrides": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					DNSDomain: "cluster.example.com",
+					Images: &ImageOverrides{
+						ImageRepository: "registry.example.com:5000/k8s",
+						CoreDNSImageTag: "1.6.7",
+						EtcdImageTag:    "3.4.3-0",
+					},
+				},
+			},
+		},
+		"invalid dns domain": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					DNSDomain: "Cluster_Local",
+				},
+			},
+			expectErr: true,
+		},
+		"invalid image repository": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Images: &ImageOverrides{
+						ImageRepository: "registry.example.com/k8s:v1.0",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"invalid image tag": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					Images: &ImageOverrides{
+						CoreDNSImageTag: "v1.6.7+build",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"etcd image tag with external etcd": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					ClusterConfiguration: &kubeadmv1beta1.ClusterConfiguration{
+						Etcd: kubeadmv1beta1.Etcd{
+							External: &kubeadmv1beta1.ExternalEtcd{Endpoints: []string{"https://etcd:2379"}},
+						},
+					},
+					Images: &ImageOverrides{
+						EtcdImageTag: "3.4.3-0",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"valid certificate refs": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					CertificateRefs: []CertificateRef{
+						{Purpose: ClusterCACertificate, SecretName: "my-ca"},
+						{Purpose: ServiceAccountCertificate, SecretName: "my-sa"},
+					},
+				},
+			},
+		},
+		"duplicate certificate refs": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					CertificateRefs: []CertificateRef{
+						{Purpose: ClusterCACertificate, SecretName: "my-ca"},
+						{Purpose: ClusterCACertificate, SecretName: "my-other-ca"},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"certificate ref without secret name": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					CertificateRefs: []CertificateRef{
+						{Purpose: FrontProxyCACertificate},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"etcd certificate ref with external etcd": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					ClusterConfiguration: &kubeadmv1beta1.ClusterConfiguration{
+						Etcd: kubeadmv1beta1.Etcd{
+							External: &kubeadmv1beta1.ExternalEtcd{Endpoints: []string{"https://etcd:2379"}},
+						},
+					},
+					CertificateRefs: []CertificateRef{
+						{Purpose: EtcdCACertificate, SecretName: "my-etcd-ca"},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"valid network config": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					NetworkConfig: &NetworkConfig{
+						Interfaces: []NetworkInterface{
+							{
+								Name:        "eth0",
+								Addresses:   []string{"192.168.1.10/24", "fd00::10/64"},
+								Gateway4:    "192.168.1.1",
+								Gateway6:    "fd00::1",
+								Nameservers: []string{"192.168.1.2"},
+								Routes:      []NetworkRoute{{To: "10.0.0.0/8", Via: "192.168.1.254"}},
+							},
+							{Name: "storage", MACAddress: "00:50:56:12:34:56", DHCP4: true},
+						},
+					},
+				},
+			},
+		},
+		"network config with duplicate interface names": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					NetworkConfig: &NetworkConfig{
+						Interfaces: []NetworkInterface{
+							{Name: "eth0", DHCP4: true},
+							{Name: "eth0", DHCP6: true},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"network config with invalid MAC address": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					NetworkConfig: &NetworkConfig{
+						Interfaces: []NetworkInterface{
+							{Name: "eth1", MACAddress: "00:50:56"},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"network config with address without prefix length": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					NetworkConfig: &NetworkConfig{
+						Interfaces: []NetworkInterface{
+							{Name: "eth0", Addresses: []string{"192.168.1.10"}},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"network config with IPv6 gateway4": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					NetworkConfig: &NetworkConfig{
+						Interfaces: []NetworkInterface{
+							{Name: "eth0", Addresses: []string{"192.168.1.10/24"}, Gateway4: "fd00::1"},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"network config with invalid route": {
+			in: &KubeadmConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "baz",
+					Namespace: "default",
+				},
+				Spec: KubeadmConfigSpec{
+					NetworkConfig: &NetworkConfig{
+						Interfaces: []NetworkInterface{
+							{Name: "eth0", Routes: []NetworkRoute{{To: "10.0.0.0/8", Via: "gateway"}}},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
 	}
 
 	for name, tt := range cases {
@@ -160,3 +644,68 @@ func TestClusterValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestExpandMachineVariables(t *testing.T) {
+	values := map[string]string{
+		MachineNameVariable: "machine-1",
+		ClusterNameVariable: "cluster-1",
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "expands machine variables",
+			in:   "{{ .MachineName }}.{{.ClusterName}}",
+			want: "machine-1.cluster-1",
+		},
+		{
+			name: "does not change jinja templates",
+			in:   "{{ ds.meta_data.local_hostname }}",
+			want: "{{ ds.meta_data.local_hostname }}",
+		},
+		{
+			name: "does not change references to variables without a value",
+			in:   "{{ .FailureDomain }}",
+			want: "{{ .FailureDomain }}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(ExpandMachineVariables(tt.in, values)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestApplyClusterConfigurationOverrides(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := &KubeadmConfigSpec{}
+	spec.ApplyClusterConfigurationOverrides()
+	g.Expect(spec.ClusterConfiguration).To(BeNil())
+
+	spec = &KubeadmConfigSpec{
+		ClusterConfiguration: &kubeadmv1beta1.ClusterConfiguration{
+			ImageRepository: "k8s.gcr.io",
+			Networking:      kubeadmv1beta1.Networking{DNSDomain: "cluster.local", PodSubnet: "192.168.0.0/16"},
+			DNS:             kubeadmv1beta1.DNS{ImageMeta: kubeadmv1beta1.ImageMeta{ImageRepository: "k8s.gcr.io/coredns", ImageTag: "1.6.5"}},
+		},
+		DNSDomain: "cluster.example.com",
+		Images: &ImageOverrides{
+			ImageRepository: "registry.example.com/k8s",
+			CoreDNSImageTag: "1.6.7",
+			EtcdImageTag:    "3.4.3-0",
+		},
+	}
+	spec.ApplyClusterConfigurationOverrides()
+	g.Expect(spec.ClusterConfiguration).To(Equal(&kubeadmv1beta1.ClusterConfiguration{
+		ImageRepository: "registry.example.com/k8s",
+		Networking:      kubeadmv1beta1.Networking{DNSDomain: "cluster.example.com", PodSubnet: "192.168.0.0/16"},
+		DNS:             kubeadmv1beta1.DNS{ImageMeta: kubeadmv1beta1.ImageMeta{ImageRepository: "k8s.gcr.io/coredns", ImageTag: "1.6.7"}},
+		Etcd:            kubeadmv1beta1.Etcd{Local: &kubeadmv1beta1.LocalEtcd{ImageMeta: kubeadmv1beta1.ImageMeta{ImageTag: "3.4.3-0"}}},
+	}))
+}
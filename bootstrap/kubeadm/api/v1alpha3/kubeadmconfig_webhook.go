@@ -18,20 +18,52 @@ package v1alpha3
 
 import (
 	"fmt"
+	"net"
+	"regexp"
+	"strings"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/cluster-api/util/container"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/yaml"
 )
 
 var (
-	ConflictingFileSourceMsg = "only one of content of contentFrom may be specified for a single file"
-	MissingFileSourceMsg     = "source for file content must be specified if contenFrom is non-nil"
-	MissingSecretNameMsg     = "secret file source must specify non-empty secret name"
-	MissingSecretKeyMsg      = "secret file source must specify non-empty secret key"
-	PathConflictMsg          = "path property must be unique among all files"
+	ConflictingFileSourceMsg   = "only one of content of contentFrom may be specified for a single file"
+	MissingFileSourceMsg       = "source for file content must be specified if contenFrom is non-nil"
+	MissingSecretNameMsg       = "secret file source must specify non-empty secret name"
+	MissingSecretKeyMsg        = "secret file source must specify non-empty secret key"
+	PathConflictMsg            = "path property must be unique among all files"
+	MissingEncryptionKeysMsg   = "at least one encryption key must be specified"
+	MissingKeyNameMsg          = "encryption key must specify a non-empty name"
+	InvalidSysctlNameMsg       = "sysctl name must be a dot or slash separated list of alphanumeric, '-' or '_' segments"
+	InvalidSysctlValueMsg      = "sysctl value must not be empty nor contain line breaks"
+	InvalidKernelModuleMsg     = "kernel module name must consist of alphanumeric, '-' or '_' characters"
+	InvalidAuditLogPathMsg     = "audit log path must be an absolute path"
+	InvalidAuditPolicyMsg      = "audit policy must be a YAML document of kind Policy"
+	InvalidCloudConfigMsg      = "cloud config path must be an absolute path"
+	CloudProviderConflictMsg   = "cloud-provider must be external when an external cloud provider is configured"
+	InvalidImageRepositoryMsg  = "image repository must be a valid image reference without tag or digest, e.g. registry.example.com/k8s"
+	InvalidImageTagMsg         = "image tag must consist of alphanumeric, '-', '_' or '.' characters"
+	EtcdImageTagConflictMsg    = "etcd image tag can't be used with an external etcd"
+	DuplicateCertificateMsg    = "only one certificate may be referenced for each purpose"
+	MissingCertSecretNameMsg   = "certificate reference must specify non-empty secret name"
+	EtcdCertificateConflictMsg = "etcd CA can't be referenced with an external etcd"
+	MissingInterfaceNameMsg    = "network interface must specify a non-empty name"
+	DuplicateInterfaceNameMsg  = "network interface name must be unique among all interfaces"
+	InvalidMACAddressMsg       = "MAC address must be a valid IEEE 802 MAC-48 address, e.g. 00:50:56:12:34:56"
+	InvalidCIDRMsg             = "must be an IP address in CIDR notation, e.g. 192.168.1.10/24"
+	InvalidIPAddressMsg        = "must be a valid IP address"
+)
+
+var (
+	sysctlNameRegex   = regexp.MustCompile(`^[a-zA-Z0-9_-]+([./][a-zA-Z0-9_-]+)*$`)
+	kernelModuleRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 )
 
 func (c *KubeadmConfig) SetupWebhookWithManager(mgr ctrl.Manager) error {
@@ -115,8 +147,232 @@ func (c *KubeadmConfigSpec) validate(name string) error {
 		knownPaths[file.Path] = struct{}{}
 	}
 
+	if c.EncryptionProviderConfig != nil {
+		allErrs = append(allErrs, c.EncryptionProviderConfig.validate(field.NewPath("spec", "encryptionProviderConfig"))...)
+	}
+
+	if c.AuditConfig != nil {
+		allErrs = append(allErrs, c.AuditConfig.validate(field.NewPath("spec", "auditConfig"))...)
+	}
+
+	if c.ExternalCloudProvider != nil {
+		allErrs = append(allErrs, c.ExternalCloudProvider.validate(field.NewPath("spec", "externalCloudProvider"))...)
+		allErrs = append(allErrs, c.validateCloudProviderArgs()...)
+	}
+
+	allErrs = append(allErrs, c.ValidateClusterConfigurationOverrides(field.NewPath("spec"))...)
+	allErrs = append(allErrs, c.ValidateCertificateRefs(field.NewPath("spec"))...)
+
+	if c.NetworkConfig != nil {
+		allErrs = append(allErrs, c.NetworkConfig.validate(field.NewPath("spec", "networkConfig"))...)
+	}
+
+	for name, value := range c.Sysctls {
+		if !sysctlNameRegex.MatchString(name) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "sysctls").Key(name), name, InvalidSysctlNameMsg))
+		}
+		if value == "" || strings.ContainsAny(value, "\r\n") {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "sysctls").Key(name), value, InvalidSysctlValueMsg))
+		}
+	}
+
+	for i, module := range c.KernelModules {
+		if !kernelModuleRegex.MatchString(module) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "kernelModules").Index(i), module, InvalidKernelModuleMsg))
+		}
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
 	return apierrors.NewInvalid(GroupVersion.WithKind("KubeadmConfig").GroupKind(), name, allErrs)
 }
+
+func (c *EncryptionProviderConfig) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if len(c.Keys) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("keys"), MissingEncryptionKeysMsg))
+	}
+
+	for i, key := range c.Keys {
+		keyPath := path.Child("keys").Index(i)
+		if key.Name == "" {
+			allErrs = append(allErrs, field.Invalid(keyPath.Child("name"), key, MissingKeyNameMsg))
+		}
+		if key.Secret.Name == "" {
+			allErrs = append(allErrs, field.Invalid(keyPath.Child("secret", "name"), key, MissingSecretNameMsg))
+		}
+		if key.Secret.Key == "" {
+			allErrs = append(allErrs, field.Invalid(keyPath.Child("secret", "key"), key, MissingSecretKeyMsg))
+		}
+	}
+
+	return allErrs
+}
+
+func (c *AuditConfig) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if c.LogPath != "" && !strings.HasPrefix(c.LogPath, "/") {
+		allErrs = append(allErrs, field.Invalid(path.Child("logPath"), c.LogPath, InvalidAuditLogPathMsg))
+	}
+
+	if c.Policy != "" {
+		policy := &metav1.TypeMeta{}
+		if err := yaml.Unmarshal([]byte(c.Policy), policy); err != nil || policy.Kind != "Policy" {
+			allErrs = append(allErrs, field.Invalid(path.Child("policy"), c.Policy, InvalidAuditPolicyMsg))
+		}
+	}
+
+	return allErrs
+}
+
+func (c *NetworkConfig) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := map[string]struct{}{}
+	for i, iface := range c.Interfaces {
+		ifacePath := path.Child("interfaces").Index(i)
+		if iface.Name == "" {
+			allErrs = append(allErrs, field.Invalid(ifacePath.Child("name"), iface.Name, MissingInterfaceNameMsg))
+		}
+		if _, ok := names[iface.Name]; ok {
+			allErrs = append(allErrs, field.Invalid(ifacePath.Child("name"), iface.Name, DuplicateInterfaceNameMsg))
+		}
+		names[iface.Name] = struct{}{}
+
+		if iface.MACAddress != "" {
+			if mac, err := net.ParseMAC(iface.MACAddress); err != nil || len(mac) != 6 {
+				allErrs = append(allErrs, field.Invalid(ifacePath.Child("macAddress"), iface.MACAddress, InvalidMACAddressMsg))
+			}
+		}
+		for j, address := range iface.Addresses {
+			if _, _, err := net.ParseCIDR(address); err != nil {
+				allErrs = append(allErrs, field.Invalid(ifacePath.Child("addresses").Index(j), address, InvalidCIDRMsg))
+			}
+		}
+		if iface.Gateway4 != "" {
+			if ip := net.ParseIP(iface.Gateway4); ip == nil || ip.To4() == nil {
+				allErrs = append(allErrs, field.Invalid(ifacePath.Child("gateway4"), iface.Gateway4, InvalidIPAddressMsg))
+			}
+		}
+		if iface.Gateway6 != "" {
+			if ip := net.ParseIP(iface.Gateway6); ip == nil || ip.To4() != nil {
+				allErrs = append(allErrs, field.Invalid(ifacePath.Child("gateway6"), iface.Gateway6, InvalidIPAddressMsg))
+			}
+		}
+		for j, nameserver := range iface.Nameservers {
+			if net.ParseIP(nameserver) == nil {
+				allErrs = append(allErrs, field.Invalid(ifacePath.Child("nameservers").Index(j), nameserver, InvalidIPAddressMsg))
+			}
+		}
+		for j, route := range iface.Routes {
+			if _, _, err := net.ParseCIDR(route.To); err != nil {
+				allErrs = append(allErrs, field.Invalid(ifacePath.Child("routes").Index(j).Child("to"), route.To, InvalidCIDRMsg))
+			}
+			if net.ParseIP(route.Via) == nil {
+				allErrs = append(allErrs, field.Invalid(ifacePath.Child("routes").Index(j).Child("via"), route.Via, InvalidIPAddressMsg))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func (c *ExternalCloudProvider) validate(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if c.CloudConfigPath != "" && !strings.HasPrefix(c.CloudConfigPath, "/") {
+		allErrs = append(allErrs, field.Invalid(path.Child("cloudConfigPath"), c.CloudConfigPath, InvalidCloudConfigMsg))
+	}
+
+	if c.CloudConfig != nil {
+		if c.CloudConfig.Name == "" {
+			allErrs = append(allErrs, field.Invalid(path.Child("cloudConfig", "name"), c.CloudConfig, MissingSecretNameMsg))
+		}
+		if c.CloudConfig.Key == "" {
+			allErrs = append(allErrs, field.Invalid(path.Child("cloudConfig", "key"), c.CloudConfig, MissingSecretKeyMsg))
+		}
+	}
+
+	return allErrs
+}
+
+// ValidateClusterConfigurationOverrides validates the DNSDomain and Images fields, rendered into ClusterConfiguration.
+func (c *KubeadmConfigSpec) ValidateClusterConfigurationOverrides(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if c.DNSDomain != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(c.DNSDomain) {
+			allErrs = append(allErrs, field.Invalid(path.Child("dnsDomain"), c.DNSDomain, msg))
+		}
+	}
+
+	if c.Images == nil {
+		return allErrs
+	}
+	imagesPath := path.Child("images")
+	if c.Images.ImageRepository != "" && !container.ImageRepositoryIsValid(c.Images.ImageRepository) {
+		allErrs = append(allErrs, field.Invalid(imagesPath.Child("imageRepository"), c.Images.ImageRepository, InvalidImageRepositoryMsg))
+	}
+	if !container.ImageTagIsValid(c.Images.CoreDNSImageTag) {
+		allErrs = append(allErrs, field.Invalid(imagesPath.Child("coreDNSImageTag"), c.Images.CoreDNSImageTag, InvalidImageTagMsg))
+	}
+	if !container.ImageTagIsValid(c.Images.EtcdImageTag) {
+		allErrs = append(allErrs, field.Invalid(imagesPath.Child("etcdImageTag"), c.Images.EtcdImageTag, InvalidImageTagMsg))
+	}
+	if c.Images.EtcdImageTag != "" && c.ClusterConfiguration != nil && c.ClusterConfiguration.Etcd.External != nil {
+		allErrs = append(allErrs, field.Forbidden(imagesPath.Child("etcdImageTag"), EtcdImageTagConflictMsg))
+	}
+
+	return allErrs
+}
+
+// ValidateCertificateRefs checks each certificate purpose is referenced at most once, each reference has a
+// Secret name, and the etcd CA is not referenced when using an external etcd.
+func (c *KubeadmConfigSpec) ValidateCertificateRefs(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	purposes := map[CertificatePurpose]struct{}{}
+	for i, ref := range c.CertificateRefs {
+		refPath := path.Child("certificateRefs").Index(i)
+		if _, ok := purposes[ref.Purpose]; ok {
+			allErrs = append(allErrs, field.Invalid(refPath.Child("purpose"), ref.Purpose, DuplicateCertificateMsg))
+		}
+		purposes[ref.Purpose] = struct{}{}
+		if ref.SecretName == "" {
+			allErrs = append(allErrs, field.Invalid(refPath.Child("secretName"), ref.SecretName, MissingCertSecretNameMsg))
+		}
+		if ref.Purpose == EtcdCACertificate && c.ClusterConfiguration != nil && c.ClusterConfiguration.Etcd.External != nil {
+			allErrs = append(allErrs, field.Forbidden(refPath.Child("purpose"), EtcdCertificateConflictMsg))
+		}
+	}
+
+	return allErrs
+}
+
+// validateCloudProviderArgs checks the cloud-provider flags explicitly set for the API server, the controller manager
+// and the kubelet do not conflict with the external cloud provider.
+func (c *KubeadmConfigSpec) validateCloudProviderArgs() field.ErrorList {
+	var allErrs field.ErrorList
+
+	validateArgs := func(path *field.Path, args map[string]string) {
+		if value, ok := args["cloud-provider"]; ok && value != "external" {
+			allErrs = append(allErrs, field.Invalid(path.Key("cloud-provider"), value, CloudProviderConflictMsg))
+		}
+	}
+
+	if c.ClusterConfiguration != nil {
+		validateArgs(field.NewPath("spec", "clusterConfiguration", "apiServer", "extraArgs"), c.ClusterConfiguration.APIServer.ExtraArgs)
+		validateArgs(field.NewPath("spec", "clusterConfiguration", "controllerManager", "extraArgs"), c.ClusterConfiguration.ControllerManager.ExtraArgs)
+	}
+	if c.InitConfiguration != nil {
+		validateArgs(field.NewPath("spec", "initConfiguration", "nodeRegistration", "kubeletExtraArgs"), c.InitConfiguration.NodeRegistration.KubeletExtraArgs)
+	}
+	if c.JoinConfiguration != nil {
+		validateArgs(field.NewPath("spec", "joinConfiguration", "nodeRegistration", "kubeletExtraArgs"), c.JoinConfiguration.NodeRegistration.KubeletExtraArgs)
+	}
+
+	return allErrs
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"regexp"
+)
+
+// Machine variables can be referenced in the content of files and in the pre/post kubeadm commands using the
+// {{ .VariableName }} syntax; references are expanded by the bootstrap controller when generating the bootstrap data
+// for a specific machine, so the same KubeadmConfigTemplate can be used for all the machines.
+const (
+	// MachineNameVariable is the name of the Machine (or MachinePool) the bootstrap data is generated for.
+	MachineNameVariable = "MachineName"
+
+	// ClusterNameVariable is the name of the Cluster the machine belongs to.
+	ClusterNameVariable = "ClusterName"
+
+	// ProviderIDVariable is the provider ID of the Machine, if already known when generating the bootstrap data,
+	// e.g. for pre-provisioned hosts; it is expanded to an empty string otherwise.
+	ProviderIDVariable = "ProviderID"
+
+	// FailureDomainVariable is the failure domain of the Machine, if any; it is expanded to an empty string otherwise.
+	FailureDomainVariable = "FailureDomain"
+)
+
+// machineVariableRegex matches references to machine variables, e.g. {{ .MachineName }}.
+// Nb. references start with a dot, so they do not conflict with the jinja templates rendered by cloud-init,
+// e.g. {{ ds.meta_data.local_hostname }}; references to unknown variables are not rejected, because they are
+// commonly part of other templates, e.g. docker ps --format '{{ .Names }}'.
+var machineVariableRegex = regexp.MustCompile(`{{\s*\.([a-zA-Z0-9_]+)\s*}}`)
+
+// ExpandMachineVariables replaces the references to machine variables in s with the given values.
+// References to unknown variables are left untouched.
+func ExpandMachineVariables(s string, values map[string]string) string {
+	return machineVariableRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		name := machineVariableRegex.FindStringSubmatch(ref)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return ref
+	})
+}
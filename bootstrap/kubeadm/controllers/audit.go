@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"path/filepath"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+)
+
+const (
+	// auditPolicyDir is the directory hosting the audit Policy file on control plane machines.
+	auditPolicyDir = "/etc/kubernetes/audit"
+
+	// auditPolicyPath is the path of the audit Policy file on control plane machines.
+	auditPolicyPath = auditPolicyDir + "/policy.yaml"
+
+	// auditLogDefaultPath is the default path of the audit log file on control plane machines.
+	auditLogDefaultPath = "/var/log/kubernetes/audit/audit.log"
+
+	// auditPolicyVolume is the name of the API server volume hosting the audit Policy file.
+	auditPolicyVolume = "audit-policy"
+
+	// auditLogVolume is the name of the API server volume hosting the audit log files.
+	auditLogVolume = "audit-log"
+
+	// defaultAuditPolicy records the metadata of all the requests.
+	defaultAuditPolicy = `apiVersion: audit.k8s.io/v1
+kind: Policy
+rules:
+- level: Metadata
+`
+)
+
+// auditPolicyFiles returns the file with the audit Policy for the API server; no files are returned if
+// auditing is not configured.
+func auditPolicyFiles(cfg *bootstrapv1.KubeadmConfig) []bootstrapv1.File {
+	audit := cfg.Spec.AuditConfig
+	if audit == nil {
+		return nil
+	}
+
+	policy := audit.Policy
+	if policy == "" {
+		policy = defaultAuditPolicy
+	}
+
+	return []bootstrapv1.File{
+		{
+			Path:        auditPolicyPath,
+			Owner:       "root:root",
+			Permissions: "0600",
+			Content:     policy,
+		},
+	}
+}
+
+// reconcileAuditArgs configures the API server to use the audit Policy file and to write the audit log,
+// mounting the directories hosting the Policy file and the audit log files into the API server pod.
+func reconcileAuditArgs(clusterConfiguration *kubeadmv1beta1.ClusterConfiguration, audit *bootstrapv1.AuditConfig) {
+	logPath := audit.LogPath
+	if logPath == "" {
+		logPath = auditLogDefaultPath
+	}
+
+	apiServer := &clusterConfiguration.APIServer
+	if apiServer.ExtraArgs == nil {
+		apiServer.ExtraArgs = map[string]string{}
+	}
+	apiServer.ExtraArgs["audit-policy-file"] = auditPolicyPath
+	apiServer.ExtraArgs["audit-log-path"] = logPath
+	if audit.LogMaxAge != nil {
+		apiServer.ExtraArgs["audit-log-maxage"] = strconv.Itoa(int(*audit.LogMaxAge))
+	}
+	if audit.LogMaxBackup != nil {
+		apiServer.ExtraArgs["audit-log-maxbackup"] = strconv.Itoa(int(*audit.LogMaxBackup))
+	}
+	if audit.LogMaxSize != nil {
+		apiServer.ExtraArgs["audit-log-maxsize"] = strconv.Itoa(int(*audit.LogMaxSize))
+	}
+
+	apiServer.ExtraVolumes = ensureHostPathMount(apiServer.ExtraVolumes, kubeadmv1beta1.HostPathMount{
+		Name:      auditPolicyVolume,
+		HostPath:  auditPolicyDir,
+		MountPath: auditPolicyDir,
+		ReadOnly:  true,
+		PathType:  corev1.HostPathDirectoryOrCreate,
+	})
+	logDir := filepath.Dir(logPath)
+	apiServer.ExtraVolumes = ensureHostPathMount(apiServer.ExtraVolumes, kubeadmv1beta1.HostPathMount{
+		Name:      auditLogVolume,
+		HostPath:  logDir,
+		MountPath: logDir,
+		PathType:  corev1.HostPathDirectoryOrCreate,
+	})
+}
+
+// ensureHostPathMount adds the mount to the list, unless a mount with the same name already exists.
+func ensureHostPathMount(mounts []kubeadmv1beta1.HostPathMount, mount kubeadmv1beta1.HostPathMount) []kubeadmv1beta1.HostPathMount {
+	for _, m := range mounts {
+		if m.Name == mount.Name {
+			return mounts
+		}
+	}
+	return append(mounts, mount)
+}
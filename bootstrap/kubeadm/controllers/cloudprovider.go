@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+)
+
+const (
+	// cloudProviderArg is the flag of the Kubernetes components defining the cloud provider.
+	cloudProviderArg = "cloud-provider"
+
+	// externalCloudProvider is the value of the cloud-provider flag for external cloud providers.
+	externalCloudProvider = "external"
+
+	// cloudConfigDefaultPath is the default path of the cloud config file.
+	cloudConfigDefaultPath = "/etc/kubernetes/cloud.conf"
+)
+
+// cloudConfigFiles returns the file with the cloud config, read from the referenced Secret; no files are returned if
+// an external cloud provider is not configured, or if it does not reference a cloud config.
+func cloudConfigFiles(cfg *bootstrapv1.KubeadmConfig) []bootstrapv1.File {
+	cloudProvider := cfg.Spec.ExternalCloudProvider
+	if cloudProvider == nil || cloudProvider.CloudConfig == nil {
+		return nil
+	}
+
+	path := cloudProvider.CloudConfigPath
+	if path == "" {
+		path = cloudConfigDefaultPath
+	}
+
+	return []bootstrapv1.File{
+		{
+			Path:        path,
+			Owner:       "root:root",
+			Permissions: "0600",
+			ContentFrom: &bootstrapv1.FileSource{Secret: *cloudProvider.CloudConfig},
+		},
+	}
+}
+
+// reconcileClusterConfigurationCloudProviderArgs configures the API server and the controller manager for an external
+// cloud provider.
+func reconcileClusterConfigurationCloudProviderArgs(clusterConfiguration *kubeadmv1beta1.ClusterConfiguration) {
+	clusterConfiguration.APIServer.ExtraArgs = withExternalCloudProvider(clusterConfiguration.APIServer.ExtraArgs)
+	clusterConfiguration.ControllerManager.ExtraArgs = withExternalCloudProvider(clusterConfiguration.ControllerManager.ExtraArgs)
+}
+
+// reconcileNodeRegistrationCloudProviderArgs configures the kubelet for an external cloud provider.
+func reconcileNodeRegistrationCloudProviderArgs(nodeRegistration *kubeadmv1beta1.NodeRegistrationOptions) {
+	nodeRegistration.KubeletExtraArgs = withExternalCloudProvider(nodeRegistration.KubeletExtraArgs)
+}
+
+// withExternalCloudProvider sets the cloud-provider flag to external in the given extra args.
+func withExternalCloudProvider(args map[string]string) map[string]string {
+	if args == nil {
+		args = map[string]string{}
+	}
+	args[cloudProviderArg] = externalCloudProvider
+	return args
+}
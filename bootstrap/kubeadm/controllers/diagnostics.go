@@ -0,0 +1,198 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// diagnosticsRetryInterval is the interval between the checks of the diagnostic bundle requested to the
+	// infrastructure provider.
+	diagnosticsRetryInterval = 1 * time.Minute
+)
+
+// DiagnosticsSecretName returns the name of the Secret storing the diagnostic bundle for a KubeadmConfig.
+func DiagnosticsSecretName(configName string) string {
+	return fmt.Sprintf("%s-diagnostics", configName)
+}
+
+// reconcileBootstrapDiagnostics checks if the Machine owning the KubeadmConfig completed the bootstrap process
+// within the bootstrap timeout, and requests the diagnostic bundle to the infrastructure provider if not.
+//
+// Collecting the diagnostic data requires access to the host, e.g. via the serial console, so it is delegated to the
+// infrastructure providers: the controller creates an empty Secret for the bundle, owned by the KubeadmConfig, and
+// sets the DiagnosticsRequestedAnnotation on the infrastructure machine; the infrastructure provider stores the
+// bundle in the Secret, or reports a failure with the DiagnosticsFailedAnnotation.
+func (r *KubeadmConfigReconciler) reconcileBootstrapDiagnostics(ctx context.Context, scope *Scope) (ctrl.Result, error) {
+	// Diagnostics are collected only for Machines.
+	if scope.ConfigOwner.GetKind() != "Machine" {
+		return ctrl.Result{}, nil
+	}
+
+	machine := &clusterv1.Machine{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(scope.ConfigOwner.Object, machine); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "cannot convert %s to Machine", scope.ConfigOwner.GetKind())
+	}
+
+	reason := conditions.GetReason(scope.Config, bootstrapv1.BootstrapCompletedCondition)
+	if machine.Status.NodeRef != nil {
+		// Withdraw the request if the Machine completed the bootstrap process while waiting for the bundle.
+		if reason == bootstrapv1.WaitingForDiagnosticsReason || reason == bootstrapv1.DiagnosticsCollectionFailedReason {
+			if err := r.setDiagnosticsRequest(ctx, machine, ""); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		conditions.MarkTrue(scope.Config, bootstrapv1.BootstrapCompletedCondition)
+		return ctrl.Result{}, nil
+	}
+
+	// Nothing to do if the bundle is already collected, if the Machine is being deleted, or if the infrastructure
+	// is not ready yet, because in this case there are no hosts to collect the bundle from.
+	if reason == bootstrapv1.BootstrapTimedOutReason || !machine.DeletionTimestamp.IsZero() || !scope.ConfigOwner.IsInfrastructureReady() {
+		return ctrl.Result{}, nil
+	}
+
+	dataSecretTime := conditions.GetLastTransitionTime(scope.Config, bootstrapv1.DataSecretAvailableCondition)
+	if dataSecretTime == nil {
+		return ctrl.Result{}, nil
+	}
+	if remaining := time.Until(dataSecretTime.Add(r.BootstrapTimeout)); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	secret, err := r.ensureDiagnosticsSecret(ctx, scope)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	secretName := fmt.Sprintf("%s/%s", secret.Namespace, secret.Name)
+
+	// The infrastructure provider stored the bundle.
+	if len(secret.Data) > 0 {
+		if err := r.setDiagnosticsRequest(ctx, machine, ""); err != nil {
+			return ctrl.Result{}, err
+		}
+		conditions.MarkFalse(scope.Config, bootstrapv1.BootstrapCompletedCondition, bootstrapv1.BootstrapTimedOutReason, clusterv1.ConditionSeverityWarning,
+			"The Machine did not complete the bootstrap process within %s; diagnostics are stored in Secret %s", r.BootstrapTimeout.String(), secretName)
+		return ctrl.Result{}, nil
+	}
+
+	infraMachine, err := external.Get(ctx, r.Client, &machine.Spec.InfrastructureRef, machine.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if msg, ok := infraMachine.GetAnnotations()[clusterv1.DiagnosticsFailedAnnotation]; ok {
+		conditions.MarkFalse(scope.Config, bootstrapv1.BootstrapCompletedCondition, bootstrapv1.DiagnosticsCollectionFailedReason, clusterv1.ConditionSeverityWarning,
+			"The Machine did not complete the bootstrap process within %s; failed to collect diagnostics: %s", r.BootstrapTimeout.String(), msg)
+		return ctrl.Result{RequeueAfter: diagnosticsRetryInterval}, nil
+	}
+
+	if reason != bootstrapv1.WaitingForDiagnosticsReason {
+		scope.Info("Machine did not complete the bootstrap process in time, requesting diagnostics", "timeout", r.BootstrapTimeout.String())
+	}
+	if err := r.setDiagnosticsRequest(ctx, machine, secret.Name); err != nil {
+		return ctrl.Result{}, err
+	}
+	conditions.MarkFalse(scope.Config, bootstrapv1.BootstrapCompletedCondition, bootstrapv1.WaitingForDiagnosticsReason, clusterv1.ConditionSeverityWarning,
+		"The Machine did not complete the bootstrap process within %s; waiting for the infrastructure provider to store diagnostics in Secret %s", r.BootstrapTimeout.String(), secretName)
+	return ctrl.Result{RequeueAfter: diagnosticsRetryInterval}, nil
+}
+
+// setDiagnosticsRequest sets the DiagnosticsRequestedAnnotation on the infrastructure machine of the Machine to the
+// name of the diagnostics Secret, or removes the annotation if the name is empty.
+func (r *KubeadmConfigReconciler) setDiagnosticsRequest(ctx context.Context, machine *clusterv1.Machine, secretName string) error {
+	infraMachine, err := external.Get(ctx, r.Client, &machine.Spec.InfrastructureRef, machine.Namespace)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) && secretName == "" {
+			return nil
+		}
+		return err
+	}
+
+	annotations := infraMachine.GetAnnotations()
+	current, ok := annotations[clusterv1.DiagnosticsRequestedAnnotation]
+	if (secretName == "" && !ok) || (secretName != "" && current == secretName) {
+		return nil
+	}
+
+	patch := client.MergeFrom(infraMachine.DeepCopy())
+	if secretName == "" {
+		delete(annotations, clusterv1.DiagnosticsRequestedAnnotation)
+		delete(annotations, clusterv1.DiagnosticsFailedAnnotation)
+	} else {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[clusterv1.DiagnosticsRequestedAnnotation] = secretName
+	}
+	infraMachine.SetAnnotations(annotations)
+	if err := r.Client.Patch(ctx, infraMachine, patch); err != nil {
+		return errors.Wrapf(err, "failed to patch the diagnostics request on %s %s/%s", infraMachine.GetKind(), infraMachine.GetNamespace(), infraMachine.GetName())
+	}
+	return nil
+}
+
+// ensureDiagnosticsSecret returns the Secret storing the diagnostic bundle, owned by the KubeadmConfig, creating it
+// empty if it does not exist.
+func (r *KubeadmConfigReconciler) ensureDiagnosticsSecret(ctx context.Context, scope *Scope) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: scope.Config.Namespace, Name: DiagnosticsSecretName(scope.Config.Name)}
+	if err := r.Client.Get(ctx, key, secret); err == nil {
+		return secret, nil
+	} else if !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "failed to get diagnostics secret for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
+	}
+
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterLabelName: scope.Cluster.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: bootstrapv1.GroupVersion.String(),
+					Kind:       "KubeadmConfig",
+					Name:       scope.Config.Name,
+					UID:        scope.Config.UID,
+					Controller: pointer.BoolPtr(true),
+				},
+			},
+		},
+		Type: clusterv1.ClusterSecretType,
+	}
+	if err := r.Client.Create(ctx, secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to create diagnostics secret for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
+	}
+	return secret, nil
+}
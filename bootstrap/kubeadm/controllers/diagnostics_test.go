@@ -0,0 +1,221 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/cluster-api/test/helpers"
+)
+
+func TestKubeadmConfigReconciler_Reconcile_BootstrapDiagnostics(t *testing.T) {
+	tests := []struct {
+		name                   string
+		bootstrapTimeout       time.Duration
+		dataSecretAge          time.Duration
+		nodeRef                bool
+		reason                 string
+		infraAnnotations       map[string]string
+		secretData             map[string][]byte
+		wantRequeue            bool
+		wantSecret             bool
+		wantConditionTrue      bool
+		wantReason             string
+		wantRequestAnnotation  string
+		wantAnnotationsRemoved bool
+	}{
+		{
+			name:          "Does nothing if the bootstrap timeout is not configured",
+			dataSecretAge: 30 * time.Minute,
+		},
+		{
+			name:             "Requeues if the bootstrap timeout is not expired",
+			bootstrapTimeout: 20 * time.Minute,
+			dataSecretAge:    5 * time.Minute,
+			wantRequeue:      true,
+		},
+		{
+			name:              "Marks the bootstrap completed if the Machine has a node",
+			bootstrapTimeout:  20 * time.Minute,
+			dataSecretAge:     30 * time.Minute,
+			nodeRef:           true,
+			wantConditionTrue: true,
+		},
+		{
+			name:                   "Withdraws the request if the Machine gets a node while waiting for the diagnostics",
+			bootstrapTimeout:       20 * time.Minute,
+			dataSecretAge:          30 * time.Minute,
+			nodeRef:                true,
+			reason:                 bootstrapv1.WaitingForDiagnosticsReason,
+			infraAnnotations:       map[string]string{clusterv1.DiagnosticsRequestedAnnotation: "cfg-diagnostics"},
+			wantConditionTrue:      true,
+			wantAnnotationsRemoved: true,
+		},
+		{
+			name:                  "Requests the diagnostics to the infrastructure provider if the bootstrap timeout is expired",
+			bootstrapTimeout:      20 * time.Minute,
+			dataSecretAge:         30 * time.Minute,
+			wantRequeue:           true,
+			wantSecret:            true,
+			wantReason:            bootstrapv1.WaitingForDiagnosticsReason,
+			wantRequestAnnotation: "cfg-diagnostics",
+		},
+		{
+			name:                   "Reports the diagnostics stored by the infrastructure provider",
+			bootstrapTimeout:       20 * time.Minute,
+			dataSecretAge:          30 * time.Minute,
+			reason:                 bootstrapv1.WaitingForDiagnosticsReason,
+			infraAnnotations:       map[string]string{clusterv1.DiagnosticsRequestedAnnotation: "cfg-diagnostics"},
+			secretData:             map[string][]byte{"cloud-init-output.log": []byte("kubeadm join failed")},
+			wantSecret:             true,
+			wantReason:             bootstrapv1.BootstrapTimedOutReason,
+			wantAnnotationsRemoved: true,
+		},
+		{
+			name:             "Reports the failures of the infrastructure provider",
+			bootstrapTimeout: 20 * time.Minute,
+			dataSecretAge:    30 * time.Minute,
+			reason:           bootstrapv1.WaitingForDiagnosticsReason,
+			infraAnnotations: map[string]string{
+				clusterv1.DiagnosticsRequestedAnnotation: "cfg-diagnostics",
+				clusterv1.DiagnosticsFailedAnnotation:    "console not available",
+			},
+			wantRequeue:           true,
+			wantSecret:            true,
+			wantReason:            bootstrapv1.DiagnosticsCollectionFailedReason,
+			wantRequestAnnotation: "cfg-diagnostics",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := newCluster("cluster")
+			cluster.Status.InfrastructureReady = true
+			cluster.Status.ControlPlaneInitialized = true
+
+			infraMachine := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+					"kind":       "GenericInfrastructureMachine",
+					"metadata": map[string]interface{}{
+						"namespace": "default",
+						"name":      "infra-machine",
+					},
+				},
+			}
+			infraMachine.SetAnnotations(tt.infraAnnotations)
+
+			machine := newWorkerMachine(cluster)
+			machine.Spec.InfrastructureRef = corev1.ObjectReference{
+				APIVersion: infraMachine.GetAPIVersion(),
+				Kind:       infraMachine.GetKind(),
+				Name:       infraMachine.GetName(),
+			}
+			machine.Status.InfrastructureReady = true
+			if tt.nodeRef {
+				machine.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: "node"}
+			}
+
+			config := newKubeadmConfig(machine, "cfg")
+			config.Status.Ready = true
+			config.Status.DataSecretName = &config.Name
+			machine.Spec.Bootstrap.DataSecretName = &config.Name
+			config.Status.Conditions = clusterv1.Conditions{
+				{
+					Type:               bootstrapv1.DataSecretAvailableCondition,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-tt.dataSecretAge)),
+				},
+			}
+			if tt.reason != "" {
+				conditions.MarkFalse(config, bootstrapv1.BootstrapCompletedCondition, tt.reason, clusterv1.ConditionSeverityWarning, "")
+			}
+
+			objects := []runtime.Object{cluster, machine, config, infraMachine}
+			if tt.secretData != nil {
+				objects = append(objects, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: DiagnosticsSecretName("cfg")},
+					Data:       tt.secretData,
+				})
+			}
+			myclient := helpers.NewFakeClientWithScheme(setupScheme(), objects...)
+
+			k := &KubeadmConfigReconciler{
+				Log:              log.Log,
+				Client:           myclient,
+				BootstrapTimeout: tt.bootstrapTimeout,
+			}
+
+			request := ctrl.Request{
+				NamespacedName: client.ObjectKey{
+					Namespace: "default",
+					Name:      "cfg",
+				},
+			}
+			result, err := k.Reconcile(request)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(result.RequeueAfter > 0).To(Equal(tt.wantRequeue))
+
+			secret := &corev1.Secret{}
+			err = myclient.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: DiagnosticsSecretName("cfg")}, secret)
+			if tt.wantSecret {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(secret.Data).To(Equal(tt.secretData))
+			} else {
+				g.Expect(err).To(HaveOccurred())
+			}
+
+			updatedInfraMachine := infraMachine.DeepCopy()
+			g.Expect(myclient.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "infra-machine"}, updatedInfraMachine)).To(Succeed())
+			switch {
+			case tt.wantRequestAnnotation != "":
+				g.Expect(updatedInfraMachine.GetAnnotations()).To(HaveKeyWithValue(clusterv1.DiagnosticsRequestedAnnotation, tt.wantRequestAnnotation))
+			case tt.wantAnnotationsRemoved:
+				g.Expect(updatedInfraMachine.GetAnnotations()).NotTo(HaveKey(clusterv1.DiagnosticsRequestedAnnotation))
+				g.Expect(updatedInfraMachine.GetAnnotations()).NotTo(HaveKey(clusterv1.DiagnosticsFailedAnnotation))
+			default:
+				g.Expect(updatedInfraMachine.GetAnnotations()).To(Equal(tt.infraAnnotations))
+			}
+
+			switch {
+			case tt.wantConditionTrue:
+				assertHasTrueCondition(g, myclient, request, bootstrapv1.BootstrapCompletedCondition)
+			case tt.wantReason != "":
+				assertHasFalseCondition(g, myclient, request, bootstrapv1.BootstrapCompletedCondition, clusterv1.ConditionSeverityWarning, tt.wantReason)
+			default:
+				updated := &bootstrapv1.KubeadmConfig{}
+				g.Expect(myclient.Get(context.TODO(), request.NamespacedName, updated)).To(Succeed())
+				g.Expect(conditions.Has(updated, bootstrapv1.BootstrapCompletedCondition)).To(BeFalse())
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+	"sigs.k8s.io/cluster-api/util/secret"
+)
+
+const (
+	// discoveryFilePath is the path of the kubeconfig file used by kubeadm join for the cluster discovery.
+	discoveryFilePath = "/etc/kubernetes/discovery.conf"
+
+	// discoveryClusterName is the name of the cluster in the discovery file.
+	discoveryClusterName = "kubernetes"
+)
+
+// discoveryFileJoinConfiguration returns the JoinConfiguration to be used by kubeadm join and the files it requires.
+// If the KubeadmConfig uses file discovery and the cluster CA certificate is available, a copy of the JoinConfiguration
+// discovering the cluster with a kubeconfig file holding the cluster CA certificate is returned together with the
+// file; the bootstrap token is used for the TLS bootstrap only. Otherwise the JoinConfiguration is returned unchanged.
+func discoveryFileJoinConfiguration(cfg *bootstrapv1.KubeadmConfig, certificates secret.Certificates) (*kubeadmv1beta1.JoinConfiguration, []bootstrapv1.File, error) {
+	joinConfiguration := cfg.Spec.JoinConfiguration
+	discovery := joinConfiguration.Discovery
+	if !cfg.Spec.UseDiscoveryFile || discovery.File != nil || discovery.BootstrapToken == nil {
+		return joinConfiguration, nil, nil
+	}
+
+	ca := certificates.GetByPurpose(secret.ClusterCA)
+	if ca == nil || ca.KeyPair == nil || len(ca.KeyPair.Cert) == 0 {
+		return joinConfiguration, nil, nil
+	}
+
+	content, err := discoveryFile(discovery.BootstrapToken.APIServerEndpoint, ca.KeyPair.Cert)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The JoinConfiguration in the spec keeps the token discovery, so the bootstrap token can still be refreshed.
+	joinConfiguration = joinConfiguration.DeepCopy()
+	joinConfiguration.Discovery.TLSBootstrapToken = discovery.BootstrapToken.Token
+	joinConfiguration.Discovery.BootstrapToken = nil
+	joinConfiguration.Discovery.File = &kubeadmv1beta1.FileDiscovery{
+		KubeConfigPath: discoveryFilePath,
+	}
+
+	return joinConfiguration, []bootstrapv1.File{
+		{
+			Path:        discoveryFilePath,
+			Owner:       "root:root",
+			Permissions: "0600",
+			Content:     content,
+		},
+	}, nil
+}
+
+// discoveryFile renders a kubeconfig with the control plane endpoint and the cluster CA certificate, without credentials.
+func discoveryFile(apiServerEndpoint string, caCert []byte) (string, error) {
+	config := clientcmdapi.NewConfig()
+	config.Clusters[discoveryClusterName] = &clientcmdapi.Cluster{
+		Server:                   fmt.Sprintf("https://%s", apiServerEndpoint),
+		CertificateAuthorityData: caCert,
+	}
+	config.Contexts[discoveryClusterName] = &clientcmdapi.Context{
+		Cluster: discoveryClusterName,
+	}
+	config.CurrentContext = discoveryClusterName
+
+	out, err := clientcmd.Write(*config)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to render the discovery file")
+	}
+	return string(out), nil
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"k8s.io/client-go/tools/clientcmd"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+	"sigs.k8s.io/cluster-api/util/secret"
+)
+
+func TestDiscoveryFileJoinConfiguration(t *testing.T) {
+	g := NewWithT(t)
+
+	certificates := secret.NewCertificatesForInitialControlPlane(&kubeadmv1beta1.ClusterConfiguration{})
+	g.Expect(certificates.Generate()).To(Succeed())
+
+	tokenDiscovery := func() *kubeadmv1beta1.JoinConfiguration {
+		return &kubeadmv1beta1.JoinConfiguration{
+			Discovery: kubeadmv1beta1.Discovery{
+				BootstrapToken: &kubeadmv1beta1.BootstrapTokenDiscovery{
+					APIServerEndpoint: "example.com:6443",
+					Token:             "abcdef.0123456789abcdef",
+					CACertHashes:      []string{"sha256:abc"},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		useDiscoveryFile  bool
+		joinConfiguration *kubeadmv1beta1.JoinConfiguration
+		certificates      secret.Certificates
+		wantFile          bool
+	}{
+		{
+			name:              "token discovery if file discovery is not enabled",
+			joinConfiguration: tokenDiscovery(),
+			certificates:      certificates,
+		},
+		{
+			name:              "file discovery if enabled and the cluster CA is available",
+			useDiscoveryFile:  true,
+			joinConfiguration: tokenDiscovery(),
+			certificates:      certificates,
+			wantFile:          true,
+		},
+		{
+			name:              "token discovery if the cluster CA is not available",
+			useDiscoveryFile:  true,
+			joinConfiguration: tokenDiscovery(),
+			certificates:      secret.NewCertificatesForWorker(""),
+		},
+		{
+			name:             "respects a user provided file discovery",
+			useDiscoveryFile: true,
+			joinConfiguration: &kubeadmv1beta1.JoinConfiguration{
+				Discovery: kubeadmv1beta1.Discovery{
+					File: &kubeadmv1beta1.FileDiscovery{KubeConfigPath: "/tmp/discovery.conf"},
+				},
+			},
+			certificates: certificates,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cfg := &bootstrapv1.KubeadmConfig{
+				Spec: bootstrapv1.KubeadmConfigSpec{
+					UseDiscoveryFile:  tt.useDiscoveryFile,
+					JoinConfiguration: tt.joinConfiguration,
+				},
+			}
+			original := tt.joinConfiguration.DeepCopy()
+
+			got, files, err := discoveryFileJoinConfiguration(cfg, tt.certificates)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			// The JoinConfiguration in the spec is never changed, so the bootstrap token can still be refreshed.
+			g.Expect(cfg.Spec.JoinConfiguration).To(Equal(original))
+
+			if !tt.wantFile {
+				g.Expect(got).To(Equal(original))
+				g.Expect(files).To(BeEmpty())
+				return
+			}
+
+			g.Expect(got.Discovery.BootstrapToken).To(BeNil())
+			g.Expect(got.Discovery.File).To(Equal(&kubeadmv1beta1.FileDiscovery{KubeConfigPath: discoveryFilePath}))
+			g.Expect(got.Discovery.TLSBootstrapToken).To(Equal("abcdef.0123456789abcdef"))
+
+			g.Expect(files).To(HaveLen(1))
+			g.Expect(files[0].Path).To(Equal(discoveryFilePath))
+			config, err := clientcmd.Load([]byte(files[0].Content))
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(config.Clusters).To(HaveKey(discoveryClusterName))
+			g.Expect(config.Clusters[discoveryClusterName].Server).To(Equal("https://example.com:6443"))
+			g.Expect(config.Clusters[discoveryClusterName].CertificateAuthorityData).To(Equal(certificates.GetByPurpose(secret.ClusterCA).KeyPair.Cert))
+			g.Expect(config.AuthInfos).To(BeEmpty())
+		})
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apiserverv1 "k8s.io/apiserver/pkg/apis/config/v1"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// encryptionProviderConfigDir is the directory hosting the EncryptionConfiguration file on control plane machines.
+	encryptionProviderConfigDir = "/etc/kubernetes/encryption"
+
+	// encryptionProviderConfigPath is the path of the EncryptionConfiguration file on control plane machines.
+	encryptionProviderConfigPath = encryptionProviderConfigDir + "/config.yaml"
+
+	// encryptionProviderConfigArg is the API server flag for the EncryptionConfiguration file.
+	encryptionProviderConfigArg = "encryption-provider-config"
+
+	// encryptionProviderConfigVolume is the name of the API server volume hosting the EncryptionConfiguration file.
+	encryptionProviderConfigVolume = "encryption-provider-config"
+)
+
+// resolveEncryptionProviderConfigFiles returns the file with the EncryptionConfiguration for the API server,
+// reading the encryption keys from the referenced secrets; no files are returned if encryption at rest is not configured.
+func (r *KubeadmConfigReconciler) resolveEncryptionProviderConfigFiles(ctx context.Context, cfg *bootstrapv1.KubeadmConfig) ([]bootstrapv1.File, error) {
+	encryption := cfg.Spec.EncryptionProviderConfig
+	if encryption == nil {
+		return nil, nil
+	}
+
+	keys := make([]apiserverv1.Key, 0, len(encryption.Keys))
+	for _, k := range encryption.Keys {
+		data, err := r.resolveSecretFileContent(ctx, cfg.Namespace, bootstrapv1.File{
+			ContentFrom: &bootstrapv1.FileSource{Secret: k.Secret},
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve encryption key %q", k.Name)
+		}
+		keys = append(keys, apiserverv1.Key{Name: k.Name, Secret: string(data)})
+	}
+
+	content, err := encryptionConfiguration(encryption, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return []bootstrapv1.File{
+		{
+			Path:        encryptionProviderConfigPath,
+			Owner:       "root:root",
+			Permissions: "0600",
+			Content:     content,
+		},
+	}, nil
+}
+
+// encryptionConfiguration renders the EncryptionConfiguration for the given encryption settings and keys.
+// Nb. the identity provider is appended, so resources written before encryption at rest was enabled can still be read.
+func encryptionConfiguration(encryption *bootstrapv1.EncryptionProviderConfig, keys []apiserverv1.Key) (string, error) {
+	resources := encryption.Resources
+	if len(resources) == 0 {
+		resources = []string{"secrets"}
+	}
+
+	provider := apiserverv1.ProviderConfiguration{}
+	switch encryption.Provider {
+	case "", bootstrapv1.AESCBCEncryptionProvider:
+		provider.AESCBC = &apiserverv1.AESConfiguration{Keys: keys}
+	case bootstrapv1.AESGCMEncryptionProvider:
+		provider.AESGCM = &apiserverv1.AESConfiguration{Keys: keys}
+	case bootstrapv1.SecretboxEncryptionProvider:
+		provider.Secretbox = &apiserverv1.SecretboxConfiguration{Keys: keys}
+	default:
+		return "", errors.Errorf("unsupported encryption provider %q", encryption.Provider)
+	}
+
+	config := &apiserverv1.EncryptionConfiguration{
+		Resources: []apiserverv1.ResourceConfiguration{
+			{
+				Resources: resources,
+				Providers: []apiserverv1.ProviderConfiguration{
+					provider,
+					{Identity: &apiserverv1.IdentityConfiguration{}},
+				},
+			},
+		},
+	}
+	config.APIVersion = apiserverv1.SchemeGroupVersion.String()
+	config.Kind = "EncryptionConfiguration"
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the encryption configuration")
+	}
+	return string(data), nil
+}
+
+// reconcileEncryptionProviderConfigArgs configures the API server to use the EncryptionConfiguration file,
+// mounting the directory hosting it into the API server pod.
+func reconcileEncryptionProviderConfigArgs(clusterConfiguration *kubeadmv1beta1.ClusterConfiguration) {
+	apiServer := &clusterConfiguration.APIServer
+	if apiServer.ExtraArgs == nil {
+		apiServer.ExtraArgs = map[string]string{}
+	}
+	apiServer.ExtraArgs[encryptionProviderConfigArg] = encryptionProviderConfigPath
+
+	for _, v := range apiServer.ExtraVolumes {
+		if v.Name == encryptionProviderConfigVolume {
+			return
+		}
+	}
+	apiServer.ExtraVolumes = append(apiServer.ExtraVolumes, kubeadmv1beta1.HostPathMount{
+		Name:      encryptionProviderConfigVolume,
+		HostPath:  encryptionProviderConfigDir,
+		MountPath: encryptionProviderConfigDir,
+		ReadOnly:  true,
+		PathType:  corev1.HostPathDirectoryOrCreate,
+	})
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"strconv"
@@ -35,6 +36,7 @@ import (
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/internal/cloudinit"
 	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/internal/locking"
+	kubeadmtypes "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types"
 	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
 	bsutil "sigs.k8s.io/cluster-api/bootstrap/util"
 	"sigs.k8s.io/cluster-api/controllers/remote"
@@ -43,6 +45,7 @@ import (
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/predicates"
 	"sigs.k8s.io/cluster-api/util/secret"
@@ -53,6 +56,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+// networkConfigKey is the key of the bootstrap data Secret holding the cloud-init network configuration, if any.
+const networkConfigKey = "networkConfig"
+
 // InitLocker is a lock that is used around kubeadm init
 type InitLocker interface {
 	Lock(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) bool
@@ -63,6 +69,7 @@ type InitLocker interface {
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status;machines;machines/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=exp.cluster.x-k8s.io,resources=machinepools;machinepools/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets;events;configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=*,verbs=get;list;watch;patch
 
 // KubeadmConfigReconciler reconciles a KubeadmConfig object
 type KubeadmConfigReconciler struct {
@@ -71,6 +78,11 @@ type KubeadmConfigReconciler struct {
 	KubeadmInitLock InitLocker
 	scheme          *runtime.Scheme
 
+	// BootstrapTimeout, if set, is the time a Machine has for completing the bootstrap process, starting from the
+	// generation of the bootstrap data; the diagnostic bundle of the Machines exceeding it is requested to the
+	// infrastructure provider.
+	BootstrapTimeout time.Duration
+
 	remoteClientGetter remote.ClusterClientGetter
 }
 
@@ -134,7 +146,7 @@ func (r *KubeadmConfigReconciler) SetupWithManager(mgr ctrl.Manager, option cont
 // Reconcile handles KubeadmConfig events.
 func (r *KubeadmConfigReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, rerr error) {
 	ctx := context.Background()
-	log := r.Log.WithValues("kubeadmconfig", req.NamespacedName)
+	log := logutil.WithReconcileID(r.Log).WithValues(logutil.NamespaceKey, req.Namespace, "kubeadmconfig", req.Name)
 
 	// Lookup the kubeadm config
 	config := &bootstrapv1.KubeadmConfig{}
@@ -160,6 +172,14 @@ func (r *KubeadmConfigReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, re
 		return ctrl.Result{}, nil
 	}
 	log = log.WithValues("kind", configOwner.GetKind(), "version", configOwner.GetResourceVersion(), "name", configOwner.GetName())
+	log = log.WithValues(logutil.ClusterKey, configOwner.ClusterName())
+	if configOwner.GetKind() == "Machine" {
+		log = log.WithValues(logutil.MachineKey, configOwner.GetName())
+		if name, ok := configOwner.GetLabels()[clusterv1.MachineSetLabelName]; ok {
+			log = log.WithValues(logutil.MachineSetKey, name)
+		}
+	}
+	ctx = logutil.IntoContext(ctx, log)
 
 	// Lookup the cluster the config owner is associated with
 	cluster, err := util.GetClusterByName(ctx, r.Client, configOwner.GetNamespace(), configOwner.ClusterName())
@@ -226,7 +246,7 @@ func (r *KubeadmConfigReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, re
 		return ctrl.Result{}, nil
 	// Migrate plaintext data to secret.
 	case config.Status.BootstrapData != nil && config.Status.DataSecretName == nil:
-		if err := r.storeBootstrapData(ctx, scope, config.Status.BootstrapData); err != nil {
+		if err := r.storeBootstrapData(ctx, scope, config.Status.BootstrapData, nil); err != nil {
 			return ctrl.Result{}, err
 		}
 		return ctrl.Result{}, nil
@@ -251,16 +271,33 @@ func (r *KubeadmConfigReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, re
 				return ctrl.Result{}, err
 			}
 
-			log.Info("Refreshing token until the infrastructure has a chance to consume it")
-			err = refreshToken(remoteClient, token)
+			// Nb. the token could be empty, e.g. if it was removed from the config; a new one is created in this case.
+			if token != "" {
+				log.Info("Refreshing token until the infrastructure has a chance to consume it")
+				err = refreshToken(remoteClient, token)
+				if err != nil && !apierrors.IsNotFound(err) {
+					return ctrl.Result{}, errors.Wrapf(err, "failed to refresh bootstrap token")
+				}
+				if err == nil {
+					// NB: this may not be sufficient to keep the token live if we don't see it before it expires, but when we generate a config we will set the status to "ready" which should generate an update event
+					return ctrl.Result{
+						RequeueAfter: DefaultTokenTTL / 2,
+					}, nil
+				}
+			}
+
+			// The token expired and it was deleted before the infrastructure consumed it (e.g. because of infrastructure
+			// capacity delays), so the bootstrap data is regenerated with a new token.
+			log.Info("Bootstrap token expired before the infrastructure consumed it, regenerating the bootstrap data")
+			newToken, err := createToken(remoteClient)
 			if err != nil {
-				// It would be nice to re-create the bootstrap token if the error was "not found", but we have no way to update the Machine's bootstrap data
-				return ctrl.Result{}, errors.Wrapf(err, "failed to refresh bootstrap token")
+				return ctrl.Result{}, errors.Wrapf(err, "failed to create new bootstrap token")
 			}
-			// NB: this may not be sufficient to keep the token live if we don't see it before it expires, but when we generate a config we will set the status to "ready" which should generate an update event
-			return ctrl.Result{
-				RequeueAfter: DefaultTokenTTL / 2,
-			}, nil
+			return r.regenerateJoinBootstrapData(ctx, scope, newToken)
+		}
+		// If a bootstrap timeout is configured, check if the Machine completed the bootstrap process in time.
+		if r.BootstrapTimeout > 0 {
+			return r.reconcileBootstrapDiagnostics(ctx, scope)
 		}
 		// In any other case just return as the config is already generated and need not be generated again.
 		return ctrl.Result{}, nil
@@ -291,6 +328,40 @@ func (r *KubeadmConfigReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, re
 	return r.joinWorker(ctx, scope)
 }
 
+// regenerateJoinBootstrapData regenerates the join bootstrap data of a ready config with a new bootstrap token; the
+// regeneration is signaled using an annotation, which is propagated by the Machine controller to the infrastructure
+// machine.
+// The config references the new token only if the bootstrap data secret is updated, so the token refreshed by the
+// following reconciles is always the one in the bootstrap data; if the regeneration fails, the config keeps the previous
+// token, which is regenerated again by the next reconcile, and the new token is left to expire.
+func (r *KubeadmConfigReconciler) regenerateJoinBootstrapData(ctx context.Context, scope *Scope, token string) (ctrl.Result, error) {
+	config := scope.Config
+	previousToken := config.Spec.JoinConfiguration.Discovery.BootstrapToken.Token
+	config.Spec.JoinConfiguration.Discovery.BootstrapToken.Token = token
+
+	// Nb. the status is reset only while generating the bootstrap data, which sets it back to ready once the bootstrap
+	// data secret is updated.
+	config.Status.Ready = false
+	var res ctrl.Result
+	var err error
+	if scope.ConfigOwner.IsControlPlaneMachine() {
+		res, err = r.joinControlplane(ctx, scope)
+	} else {
+		res, err = r.joinWorker(ctx, scope)
+	}
+	if !config.Status.Ready {
+		config.Spec.JoinConfiguration.Discovery.BootstrapToken.Token = previousToken
+		config.Status.Ready = true
+		return res, err
+	}
+
+	if config.Annotations == nil {
+		config.Annotations = map[string]string{}
+	}
+	config.Annotations[clusterv1.BootstrapDataRegeneratedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	return res, err
+}
+
 func (r *KubeadmConfigReconciler) handleClusterNotInitialized(ctx context.Context, scope *Scope) (_ ctrl.Result, reterr error) {
 	// initialize the DataSecretAvailableCondition if missing.
 	// this is required in order to avoid the condition's LastTransitionTime to flicker in case of errors surfacing
@@ -347,7 +418,11 @@ func (r *KubeadmConfigReconciler) handleClusterNotInitialized(ctx context.Contex
 			},
 		}
 	}
-	initdata, err := kubeadmv1beta1.ConfigurationToYAML(scope.Config.Spec.InitConfiguration)
+	if scope.Config.Spec.ExternalCloudProvider != nil {
+		reconcileNodeRegistrationCloudProviderArgs(&scope.Config.Spec.InitConfiguration.NodeRegistration)
+	}
+
+	initdata, err := kubeadmtypes.MarshalInitConfigurationForVersion(scope.Config.Spec.InitConfiguration, scope.ConfigOwner.KubernetesVersion())
 	if err != nil {
 		scope.Error(err, "Failed to marshal init configuration")
 		return ctrl.Result{}, err
@@ -362,16 +437,33 @@ func (r *KubeadmConfigReconciler) handleClusterNotInitialized(ctx context.Contex
 		}
 	}
 
+	// injects into config.ClusterConfiguration the DNS domain and the image overrides, taking precedence over the
+	// values from the top level objects
+	scope.Config.Spec.ApplyClusterConfigurationOverrides()
+
 	// injects into config.ClusterConfiguration values from top level object
 	r.reconcileTopLevelObjectSettings(scope.Cluster, machine, scope.Config)
 
-	clusterdata, err := kubeadmv1beta1.ConfigurationToYAML(scope.Config.Spec.ClusterConfiguration)
+	if scope.Config.Spec.EncryptionProviderConfig != nil {
+		reconcileEncryptionProviderConfigArgs(scope.Config.Spec.ClusterConfiguration)
+	}
+
+	if scope.Config.Spec.AuditConfig != nil {
+		reconcileAuditArgs(scope.Config.Spec.ClusterConfiguration, scope.Config.Spec.AuditConfig)
+	}
+
+	if scope.Config.Spec.ExternalCloudProvider != nil {
+		reconcileClusterConfigurationCloudProviderArgs(scope.Config.Spec.ClusterConfiguration)
+	}
+
+	clusterdata, err := kubeadmtypes.MarshalClusterConfigurationForVersion(scope.Config.Spec.ClusterConfiguration, scope.ConfigOwner.KubernetesVersion())
 	if err != nil {
 		scope.Error(err, "Failed to marshal cluster configuration")
 		return ctrl.Result{}, err
 	}
 
 	certificates := secret.NewCertificatesForInitialControlPlane(scope.Config.Spec.ClusterConfiguration)
+	certificates.UseCertificateRefs(scope.Config.Spec.CertificateRefs)
 	err = certificates.LookupOrGenerate(
 		ctx,
 		r.Client,
@@ -389,25 +481,45 @@ func (r *KubeadmConfigReconciler) handleClusterNotInitialized(ctx context.Contex
 		verbosityFlag = fmt.Sprintf("--v %s", strconv.Itoa(int(*scope.Config.Spec.Verbosity)))
 	}
 
-	files, err := r.resolveFiles(ctx, scope.Config, certificates.AsFiles()...)
+	encryptionFiles, err := r.resolveEncryptionProviderConfigFiles(ctx, scope.Config)
 	if err != nil {
 		conditions.MarkFalse(scope.Config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
 		return ctrl.Result{}, err
 	}
 
+	additionalFiles := append(certificates.AsFiles(), encryptionFiles...)
+	additionalFiles = append(additionalFiles, auditPolicyFiles(scope.Config)...)
+	additionalFiles = append(additionalFiles, cloudConfigFiles(scope.Config)...)
+	variables := machineVariables(scope)
+	files, err := r.resolveFiles(ctx, scope.Config, variables, additionalFiles...)
+	if err != nil {
+		conditions.MarkFalse(scope.Config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	staging := stagingInput(scope, nil, certificates)
 	cloudInitData, err := cloudinit.NewInitControlPlane(&cloudinit.ControlPlaneInput{
 		BaseUserData: cloudinit.BaseUserData{
 			AdditionalFiles:     files,
 			NTP:                 scope.Config.Spec.NTP,
-			PreKubeadmCommands:  scope.Config.Spec.PreKubeadmCommands,
-			PostKubeadmCommands: scope.Config.Spec.PostKubeadmCommands,
+			Kubelet:             scope.Config.Spec.Kubelet,
+			Sysctls:             scope.Config.Spec.Sysctls,
+			KernelModules:       scope.Config.Spec.KernelModules,
+			NetworkConfig:       scope.Config.Spec.NetworkConfig,
+			Profiles:            scope.Config.Spec.Profiles,
+			PreKubeadmCommands:  expandCommands(scope.Config.Spec.PreKubeadmCommands, variables),
+			PostKubeadmCommands: expandCommands(scope.Config.Spec.PostKubeadmCommands, variables),
 			Users:               scope.Config.Spec.Users,
 			Mounts:              scope.Config.Spec.Mounts,
 			DiskSetup:           scope.Config.Spec.DiskSetup,
 			KubeadmVerbosity:    verbosityFlag,
+			SkipPhases:          scope.Config.Spec.InitConfiguration.SkipPhases,
+			UseSystemdUnit:      scope.Config.Spec.BootstrapMode == bootstrapv1.BootstrapModeSystemd,
+			Staging:             staging,
 		},
 		InitConfiguration:    initdata,
 		ClusterConfiguration: clusterdata,
+		Addons:               scope.Config.Spec.Addons,
 		Certificates:         certificates,
 	})
 	if err != nil {
@@ -415,7 +527,7 @@ func (r *KubeadmConfigReconciler) handleClusterNotInitialized(ctx context.Contex
 		return ctrl.Result{}, err
 	}
 
-	if err := r.storeBootstrapData(ctx, scope, cloudInitData); err != nil {
+	if err := r.storeBootstrapData(ctx, scope, cloudInitData, stagedPayload(staging)); err != nil {
 		scope.Error(err, "Failed to store bootstrap data")
 		return ctrl.Result{}, err
 	}
@@ -447,7 +559,17 @@ func (r *KubeadmConfigReconciler) joinWorker(ctx context.Context, scope *Scope)
 		return res, nil
 	}
 
-	joinData, err := kubeadmv1beta1.ConfigurationToYAML(scope.Config.Spec.JoinConfiguration)
+	if scope.Config.Spec.ExternalCloudProvider != nil {
+		reconcileNodeRegistrationCloudProviderArgs(&scope.Config.Spec.JoinConfiguration.NodeRegistration)
+	}
+
+	joinConfiguration, discoveryFiles, err := discoveryFileJoinConfiguration(scope.Config, certificates)
+	if err != nil {
+		scope.Error(err, "Failed to generate the discovery file")
+		return ctrl.Result{}, err
+	}
+
+	joinData, err := kubeadmtypes.MarshalJoinConfigurationForVersion(joinConfiguration, scope.ConfigOwner.KubernetesVersion())
 	if err != nil {
 		scope.Error(err, "Failed to marshal join configuration")
 		return ctrl.Result{}, err
@@ -464,23 +586,34 @@ func (r *KubeadmConfigReconciler) joinWorker(ctx context.Context, scope *Scope)
 		verbosityFlag = fmt.Sprintf("--v %s", strconv.Itoa(int(*scope.Config.Spec.Verbosity)))
 	}
 
-	files, err := r.resolveFiles(ctx, scope.Config)
+	variables := machineVariables(scope)
+	files, err := r.resolveFiles(ctx, scope.Config, variables, append(discoveryFiles, cloudConfigFiles(scope.Config)...)...)
 	if err != nil {
 		conditions.MarkFalse(scope.Config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
 		return ctrl.Result{}, err
 	}
 
+	discovery := scope.Config.Spec.JoinConfiguration.Discovery.BootstrapToken
+	staging := stagingInput(scope, discovery, certificates)
 	cloudJoinData, err := cloudinit.NewNode(&cloudinit.NodeInput{
 		BaseUserData: cloudinit.BaseUserData{
 			AdditionalFiles:      files,
 			NTP:                  scope.Config.Spec.NTP,
-			PreKubeadmCommands:   scope.Config.Spec.PreKubeadmCommands,
-			PostKubeadmCommands:  scope.Config.Spec.PostKubeadmCommands,
+			Kubelet:              scope.Config.Spec.Kubelet,
+			Sysctls:              scope.Config.Spec.Sysctls,
+			KernelModules:        scope.Config.Spec.KernelModules,
+			NetworkConfig:        scope.Config.Spec.NetworkConfig,
+			Profiles:             scope.Config.Spec.Profiles,
+			PreKubeadmCommands:   expandCommands(scope.Config.Spec.PreKubeadmCommands, variables),
+			PostKubeadmCommands:  expandCommands(scope.Config.Spec.PostKubeadmCommands, variables),
 			Users:                scope.Config.Spec.Users,
 			Mounts:               scope.Config.Spec.Mounts,
 			DiskSetup:            scope.Config.Spec.DiskSetup,
 			KubeadmVerbosity:     verbosityFlag,
 			UseExperimentalRetry: scope.Config.Spec.UseExperimentalRetryJoin,
+			UseSystemdUnit:       scope.Config.Spec.BootstrapMode == bootstrapv1.BootstrapModeSystemd,
+			SkipPhases:           scope.Config.Spec.JoinConfiguration.SkipPhases,
+			Staging:              staging,
 		},
 		JoinConfiguration: joinData,
 	})
@@ -489,7 +622,12 @@ func (r *KubeadmConfigReconciler) joinWorker(ctx context.Context, scope *Scope)
 		return ctrl.Result{}, err
 	}
 
-	if err := r.storeBootstrapData(ctx, scope, cloudJoinData); err != nil {
+	if err := r.reconcileStagedPayload(ctx, scope, discovery, staging); err != nil {
+		scope.Error(err, "Failed to store the staged payload in the workload cluster")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.storeBootstrapData(ctx, scope, cloudJoinData, stagedPayload(staging)); err != nil {
 		scope.Error(err, "Failed to store bootstrap data")
 		return ctrl.Result{}, err
 	}
@@ -528,7 +666,17 @@ func (r *KubeadmConfigReconciler) joinControlplane(ctx context.Context, scope *S
 		return res, nil
 	}
 
-	joinData, err := kubeadmv1beta1.ConfigurationToYAML(scope.Config.Spec.JoinConfiguration)
+	if scope.Config.Spec.ExternalCloudProvider != nil {
+		reconcileNodeRegistrationCloudProviderArgs(&scope.Config.Spec.JoinConfiguration.NodeRegistration)
+	}
+
+	joinConfiguration, discoveryFiles, err := discoveryFileJoinConfiguration(scope.Config, certificates)
+	if err != nil {
+		scope.Error(err, "Failed to generate the discovery file")
+		return ctrl.Result{}, err
+	}
+
+	joinData, err := kubeadmtypes.MarshalJoinConfigurationForVersion(joinConfiguration, scope.ConfigOwner.KubernetesVersion())
 	if err != nil {
 		scope.Error(err, "Failed to marshal join configuration")
 		return ctrl.Result{}, err
@@ -541,25 +689,46 @@ func (r *KubeadmConfigReconciler) joinControlplane(ctx context.Context, scope *S
 		verbosityFlag = fmt.Sprintf("--v %s", strconv.Itoa(int(*scope.Config.Spec.Verbosity)))
 	}
 
-	files, err := r.resolveFiles(ctx, scope.Config, certificates.AsFiles()...)
+	encryptionFiles, err := r.resolveEncryptionProviderConfigFiles(ctx, scope.Config)
+	if err != nil {
+		conditions.MarkFalse(scope.Config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	additionalFiles := append(certificates.AsFiles(), encryptionFiles...)
+	additionalFiles = append(additionalFiles, auditPolicyFiles(scope.Config)...)
+	additionalFiles = append(additionalFiles, cloudConfigFiles(scope.Config)...)
+	additionalFiles = append(additionalFiles, discoveryFiles...)
+	variables := machineVariables(scope)
+	files, err := r.resolveFiles(ctx, scope.Config, variables, additionalFiles...)
 	if err != nil {
 		conditions.MarkFalse(scope.Config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
 		return ctrl.Result{}, err
 	}
 
+	discovery := scope.Config.Spec.JoinConfiguration.Discovery.BootstrapToken
+	staging := stagingInput(scope, discovery, certificates)
 	cloudJoinData, err := cloudinit.NewJoinControlPlane(&cloudinit.ControlPlaneJoinInput{
 		JoinConfiguration: joinData,
 		Certificates:      certificates,
 		BaseUserData: cloudinit.BaseUserData{
 			AdditionalFiles:      files,
 			NTP:                  scope.Config.Spec.NTP,
-			PreKubeadmCommands:   scope.Config.Spec.PreKubeadmCommands,
-			PostKubeadmCommands:  scope.Config.Spec.PostKubeadmCommands,
+			Kubelet:              scope.Config.Spec.Kubelet,
+			Sysctls:              scope.Config.Spec.Sysctls,
+			KernelModules:        scope.Config.Spec.KernelModules,
+			NetworkConfig:        scope.Config.Spec.NetworkConfig,
+			Profiles:             scope.Config.Spec.Profiles,
+			PreKubeadmCommands:   expandCommands(scope.Config.Spec.PreKubeadmCommands, variables),
+			PostKubeadmCommands:  expandCommands(scope.Config.Spec.PostKubeadmCommands, variables),
 			Users:                scope.Config.Spec.Users,
 			Mounts:               scope.Config.Spec.Mounts,
 			DiskSetup:            scope.Config.Spec.DiskSetup,
 			KubeadmVerbosity:     verbosityFlag,
 			UseExperimentalRetry: scope.Config.Spec.UseExperimentalRetryJoin,
+			UseSystemdUnit:       scope.Config.Spec.BootstrapMode == bootstrapv1.BootstrapModeSystemd,
+			SkipPhases:           scope.Config.Spec.JoinConfiguration.SkipPhases,
+			Staging:              staging,
 		},
 	})
 	if err != nil {
@@ -567,7 +736,12 @@ func (r *KubeadmConfigReconciler) joinControlplane(ctx context.Context, scope *S
 		return ctrl.Result{}, err
 	}
 
-	if err := r.storeBootstrapData(ctx, scope, cloudJoinData); err != nil {
+	if err := r.reconcileStagedPayload(ctx, scope, discovery, staging); err != nil {
+		scope.Error(err, "Failed to store the staged payload in the workload cluster")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.storeBootstrapData(ctx, scope, cloudJoinData, stagedPayload(staging)); err != nil {
 		scope.Error(err, "Failed to store bootstrap data")
 		return ctrl.Result{}, err
 	}
@@ -576,9 +750,10 @@ func (r *KubeadmConfigReconciler) joinControlplane(ctx context.Context, scope *S
 }
 
 // resolveFiles maps .Spec.Files into cloudinit.Files, resolving any object references
-// along the way.
-func (r *KubeadmConfigReconciler) resolveFiles(ctx context.Context, cfg *bootstrapv1.KubeadmConfig, merge ...bootstrapv1.File) ([]bootstrapv1.File, error) {
-	collected := append(cfg.Spec.Files, merge...)
+// and expanding the machine variables along the way.
+func (r *KubeadmConfigReconciler) resolveFiles(ctx context.Context, cfg *bootstrapv1.KubeadmConfig, variables map[string]string, merge ...bootstrapv1.File) ([]bootstrapv1.File, error) {
+	// n.b.: files are copied, so resolving them does not change the KubeadmConfig spec.
+	collected := append(append([]bootstrapv1.File{}, cfg.Spec.Files...), merge...)
 
 	for i := range collected {
 		in := collected[i]
@@ -589,9 +764,12 @@ func (r *KubeadmConfigReconciler) resolveFiles(ctx context.Context, cfg *bootstr
 			}
 			in.ContentFrom = nil
 			in.Content = string(data)
-			collected[i] = in
 		}
-
+		// machine variables are expanded only in the files provided by the user, and only if the content is not encoded.
+		if i < len(cfg.Spec.Files) && in.Encoding == "" {
+			in.Content = bootstrapv1.ExpandMachineVariables(in.Content, variables)
+		}
+		collected[i] = in
 	}
 
 	return collected, nil
@@ -687,7 +865,7 @@ func (r *KubeadmConfigReconciler) MachinePoolToBootstrapMapFunc(o handler.MapObj
 // is automatically injected into config.JoinConfiguration.Discovery.
 // This allows to simplify configuration UX, by providing the option to delegate to CABPK the configuration of kubeadm join discovery.
 func (r *KubeadmConfigReconciler) reconcileDiscovery(ctx context.Context, cluster *clusterv1.Cluster, config *bootstrapv1.KubeadmConfig, certificates secret.Certificates) (ctrl.Result, error) {
-	log := r.Log.WithValues("kubeadmconfig", fmt.Sprintf("%s/%s", config.Namespace, config.Name))
+	log := logutil.FromContext(ctx, r.Log.WithValues("kubeadmconfig", fmt.Sprintf("%s/%s", config.Namespace, config.Name)))
 
 	// if config already contains a file discovery configuration, respect it without further validations
 	if config.Spec.JoinConfiguration.Discovery.File != nil {
@@ -793,9 +971,9 @@ func (r *KubeadmConfigReconciler) reconcileTopLevelObjectSettings(cluster *clust
 	}
 }
 
-// storeBootstrapData creates a new secret with the data passed in as input,
+// storeBootstrapData creates a new secret with the data passed in as input, and the staged payload if any,
 // sets the reference in the configuration status and ready to true.
-func (r *KubeadmConfigReconciler) storeBootstrapData(ctx context.Context, scope *Scope, data []byte) error {
+func (r *KubeadmConfigReconciler) storeBootstrapData(ctx context.Context, scope *Scope, data, payload []byte) error {
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      scope.Config.Name,
@@ -818,6 +996,16 @@ func (r *KubeadmConfigReconciler) storeBootstrapData(ctx context.Context, scope
 		},
 		Type: clusterv1.ClusterSecretType,
 	}
+	if payload != nil {
+		secret.Data[stagedPayloadKey] = payload
+	}
+	if networkConfig := scope.Config.Spec.NetworkConfig; networkConfig != nil && networkConfig.Mode != bootstrapv1.NetplanNetworkConfigMode {
+		data, err := cloudinit.NetworkConfig(networkConfig)
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate the network configuration for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
+		}
+		secret.Data[networkConfigKey] = data
+	}
 
 	// as secret creation and scope.Config status patch are not atomic operations
 	// it is possible that secret creation happens but the config.Status patches are not applied
@@ -826,6 +1014,19 @@ func (r *KubeadmConfigReconciler) storeBootstrapData(ctx context.Context, scope
 			return errors.Wrapf(err, "failed to create bootstrap data secret for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
 		}
 		r.Log.Info("bootstrap data secret for KubeadmConfig already exists", "secret", secret.Name, "KubeadmConfig", scope.Config.Name)
+
+		// If the bootstrap data is regenerated, e.g. because the bootstrap token expired, update the secret.
+		existing := &corev1.Secret{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: secret.Namespace, Name: secret.Name}, existing); err != nil {
+			return errors.Wrapf(err, "failed to get bootstrap data secret for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
+		}
+		if !bytes.Equal(existing.Data["value"], data) || !bytes.Equal(existing.Data[stagedPayloadKey], payload) ||
+			!bytes.Equal(existing.Data[networkConfigKey], secret.Data[networkConfigKey]) {
+			existing.Data = secret.Data
+			if err := r.Client.Update(ctx, existing); err != nil {
+				return errors.Wrapf(err, "failed to update bootstrap data secret for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
+			}
+		}
 	}
 	scope.Config.Status.DataSecretName = pointer.StringPtr(secret.Name)
 	scope.Config.Status.Ready = true
@@ -26,9 +26,11 @@ import (
 
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	apiserverv1 "k8s.io/apiserver/pkg/apis/config/v1"
 	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
 	"k8s.io/klog/klogr"
 	"k8s.io/utils/pointer"
@@ -63,6 +65,9 @@ func setupScheme() *runtime.Scheme {
 	if err := corev1.AddToScheme(scheme); err != nil {
 		panic(err)
 	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
 	return scheme
 }
 
@@ -476,6 +481,313 @@ func TestKubeadmConfigReconciler_Reconcile_GenerateCloudConfigData(t *testing.T)
 	g.Expect(err).NotTo(HaveOccurred())
 }
 
+func TestKubeadmConfigReconciler_Reconcile_EncryptionProviderConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := newCluster("cluster")
+	cluster.Status.InfrastructureReady = true
+
+	controlPlaneInitMachine := newControlPlaneMachine(cluster, "control-plane-init-machine")
+	controlPlaneInitConfig := newControlPlaneInitKubeadmConfig(controlPlaneInitMachine, "control-plane-init-cfg")
+	controlPlaneInitConfig.Spec.EncryptionProviderConfig = &bootstrapv1.EncryptionProviderConfig{
+		Keys: []bootstrapv1.EncryptionKey{
+			{
+				Name:   "key1",
+				Secret: bootstrapv1.SecretFileSource{Name: "encryption-keys", Key: "key1"},
+			},
+		},
+	}
+	encryptionKeys := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "encryption-keys",
+		},
+		Data: map[string][]byte{
+			"key1": []byte("c2VjcmV0LWtleQ=="),
+		},
+	}
+
+	objects := []runtime.Object{
+		cluster,
+		controlPlaneInitMachine,
+		controlPlaneInitConfig,
+		encryptionKeys,
+	}
+	objects = append(objects, createSecrets(t, cluster, controlPlaneInitConfig)...)
+
+	myclient := helpers.NewFakeClientWithScheme(setupScheme(), objects...)
+
+	k := &KubeadmConfigReconciler{
+		Log:             log.Log,
+		Client:          myclient,
+		KubeadmInitLock: &myInitLocker{},
+	}
+
+	request := ctrl.Request{
+		NamespacedName: client.ObjectKey{
+			Namespace: "default",
+			Name:      "control-plane-init-cfg",
+		},
+	}
+	_, err := k.Reconcile(request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cfg, err := getKubeadmConfig(myclient, "control-plane-init-cfg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Status.Ready).To(BeTrue())
+
+	// The API server is configured to use the EncryptionConfiguration file.
+	apiServer := cfg.Spec.ClusterConfiguration.APIServer
+	g.Expect(apiServer.ExtraArgs).To(HaveKeyWithValue("encryption-provider-config", "/etc/kubernetes/encryption/config.yaml"))
+	g.Expect(apiServer.ExtraVolumes).To(ContainElement(kubeadmv1beta1.HostPathMount{
+		Name:      "encryption-provider-config",
+		HostPath:  "/etc/kubernetes/encryption",
+		MountPath: "/etc/kubernetes/encryption",
+		ReadOnly:  true,
+		PathType:  corev1.HostPathDirectoryOrCreate,
+	}))
+
+	// The EncryptionConfiguration file is part of the bootstrap data.
+	dataSecret := &corev1.Secret{}
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: *cfg.Status.DataSecretName}, dataSecret)).To(Succeed())
+	g.Expect(string(dataSecret.Data["value"])).To(ContainSubstring("path: /etc/kubernetes/encryption/config.yaml"))
+	g.Expect(string(dataSecret.Data["value"])).To(ContainSubstring("secret: c2VjcmV0LWtleQ=="))
+}
+
+func TestKubeadmConfigReconciler_Reconcile_AuditConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := newCluster("cluster")
+	cluster.Status.InfrastructureReady = true
+
+	controlPlaneInitMachine := newControlPlaneMachine(cluster, "control-plane-init-machine")
+	controlPlaneInitConfig := newControlPlaneInitKubeadmConfig(controlPlaneInitMachine, "control-plane-init-cfg")
+	controlPlaneInitConfig.Spec.AuditConfig = &bootstrapv1.AuditConfig{
+		LogMaxAge: pointer.Int32Ptr(30),
+	}
+
+	objects := []runtime.Object{
+		cluster,
+		controlPlaneInitMachine,
+		controlPlaneInitConfig,
+	}
+	objects = append(objects, createSecrets(t, cluster, controlPlaneInitConfig)...)
+
+	myclient := helpers.NewFakeClientWithScheme(setupScheme(), objects...)
+
+	k := &KubeadmConfigReconciler{
+		Log:             log.Log,
+		Client:          myclient,
+		KubeadmInitLock: &myInitLocker{},
+	}
+
+	request := ctrl.Request{
+		NamespacedName: client.ObjectKey{
+			Namespace: "default",
+			Name:      "control-plane-init-cfg",
+		},
+	}
+	_, err := k.Reconcile(request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cfg, err := getKubeadmConfig(myclient, "control-plane-init-cfg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Status.Ready).To(BeTrue())
+
+	// The API server is configured to use the audit Policy file and to write the audit log.
+	apiServer := cfg.Spec.ClusterConfiguration.APIServer
+	g.Expect(apiServer.ExtraArgs).To(HaveKeyWithValue("audit-policy-file", "/etc/kubernetes/audit/policy.yaml"))
+	g.Expect(apiServer.ExtraArgs).To(HaveKeyWithValue("audit-log-path", "/var/log/kubernetes/audit/audit.log"))
+	g.Expect(apiServer.ExtraArgs).To(HaveKeyWithValue("audit-log-maxage", "30"))
+	g.Expect(apiServer.ExtraArgs).NotTo(HaveKey("audit-log-maxsize"))
+	g.Expect(apiServer.ExtraVolumes).To(ConsistOf(
+		kubeadmv1beta1.HostPathMount{
+			Name:      "audit-policy",
+			HostPath:  "/etc/kubernetes/audit",
+			MountPath: "/etc/kubernetes/audit",
+			ReadOnly:  true,
+			PathType:  corev1.HostPathDirectoryOrCreate,
+		},
+		kubeadmv1beta1.HostPathMount{
+			Name:      "audit-log",
+			HostPath:  "/var/log/kubernetes/audit",
+			MountPath: "/var/log/kubernetes/audit",
+			PathType:  corev1.HostPathDirectoryOrCreate,
+		},
+	))
+
+	// The default audit Policy file is part of the bootstrap data.
+	dataSecret := &corev1.Secret{}
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: *cfg.Status.DataSecretName}, dataSecret)).To(Succeed())
+	g.Expect(string(dataSecret.Data["value"])).To(ContainSubstring("path: /etc/kubernetes/audit/policy.yaml"))
+	g.Expect(string(dataSecret.Data["value"])).To(ContainSubstring("kind: Policy"))
+}
+
+func TestKubeadmConfigReconciler_Reconcile_ExternalCloudProvider(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := newCluster("cluster")
+	cluster.Status.InfrastructureReady = true
+
+	controlPlaneInitMachine := newControlPlaneMachine(cluster, "control-plane-init-machine")
+	controlPlaneInitConfig := newControlPlaneInitKubeadmConfig(controlPlaneInitMachine, "control-plane-init-cfg")
+	controlPlaneInitConfig.Spec.ExternalCloudProvider = &bootstrapv1.ExternalCloudProvider{
+		CloudConfig: &bootstrapv1.SecretFileSource{Name: "cloud-config", Key: "cloud.conf"},
+	}
+	cloudConfig := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "cloud-config",
+		},
+		Data: map[string][]byte{
+			"cloud.conf": []byte("[Global]\nregion = region-1\n"),
+		},
+	}
+
+	objects := []runtime.Object{
+		cluster,
+		controlPlaneInitMachine,
+		controlPlaneInitConfig,
+		cloudConfig,
+	}
+	objects = append(objects, createSecrets(t, cluster, controlPlaneInitConfig)...)
+
+	myclient := helpers.NewFakeClientWithScheme(setupScheme(), objects...)
+
+	k := &KubeadmConfigReconciler{
+		Log:             log.Log,
+		Client:          myclient,
+		KubeadmInitLock: &myInitLocker{},
+	}
+
+	request := ctrl.Request{
+		NamespacedName: client.ObjectKey{
+			Namespace: "default",
+			Name:      "control-plane-init-cfg",
+		},
+	}
+	_, err := k.Reconcile(request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cfg, err := getKubeadmConfig(myclient, "control-plane-init-cfg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Status.Ready).To(BeTrue())
+
+	// The API server, the controller manager and the kubelet are configured for an external cloud provider.
+	g.Expect(cfg.Spec.ClusterConfiguration.APIServer.ExtraArgs).To(HaveKeyWithValue("cloud-provider", "external"))
+	g.Expect(cfg.Spec.ClusterConfiguration.ControllerManager.ExtraArgs).To(HaveKeyWithValue("cloud-provider", "external"))
+	g.Expect(cfg.Spec.InitConfiguration.NodeRegistration.KubeletExtraArgs).To(HaveKeyWithValue("cloud-provider", "external"))
+
+	// The cloud config file is part of the bootstrap data.
+	dataSecret := &corev1.Secret{}
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: *cfg.Status.DataSecretName}, dataSecret)).To(Succeed())
+	g.Expect(string(dataSecret.Data["value"])).To(ContainSubstring("path: /etc/kubernetes/cloud.conf"))
+	g.Expect(string(dataSecret.Data["value"])).To(ContainSubstring("region = region-1"))
+}
+
+func TestKubeadmConfigReconciler_Reconcile_MachineVariables(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := newCluster("cluster")
+	cluster.Status.InfrastructureReady = true
+
+	controlPlaneInitMachine := newControlPlaneMachine(cluster, "control-plane-init-machine")
+	controlPlaneInitMachine.Spec.FailureDomain = pointer.StringPtr("fd-1")
+	controlPlaneInitConfig := newControlPlaneInitKubeadmConfig(controlPlaneInitMachine, "control-plane-init-cfg")
+	controlPlaneInitConfig.Spec.Files = []bootstrapv1.File{
+		{
+			Path:    "/etc/machine-info",
+			Content: "machine={{ .MachineName }} cluster={{ .ClusterName }} failure-domain={{ .FailureDomain }}",
+		},
+	}
+	controlPlaneInitConfig.Spec.PreKubeadmCommands = []string{"echo {{.MachineName}} > /etc/machine-name"}
+
+	objects := []runtime.Object{
+		cluster,
+		controlPlaneInitMachine,
+		controlPlaneInitConfig,
+	}
+	objects = append(objects, createSecrets(t, cluster, controlPlaneInitConfig)...)
+
+	myclient := helpers.NewFakeClientWithScheme(setupScheme(), objects...)
+
+	k := &KubeadmConfigReconciler{
+		Log:             log.Log,
+		Client:          myclient,
+		KubeadmInitLock: &myInitLocker{},
+	}
+
+	request := ctrl.Request{
+		NamespacedName: client.ObjectKey{
+			Namespace: "default",
+			Name:      "control-plane-init-cfg",
+		},
+	}
+	_, err := k.Reconcile(request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cfg, err := getKubeadmConfig(myclient, "control-plane-init-cfg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Status.Ready).To(BeTrue())
+
+	// The machine variables are expanded in the bootstrap data.
+	dataSecret := &corev1.Secret{}
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: *cfg.Status.DataSecretName}, dataSecret)).To(Succeed())
+	g.Expect(string(dataSecret.Data["value"])).To(ContainSubstring("machine=control-plane-init-machine cluster=cluster failure-domain=fd-1"))
+	g.Expect(string(dataSecret.Data["value"])).To(ContainSubstring("echo control-plane-init-machine > /etc/machine-name"))
+
+	// The KubeadmConfig spec is not changed.
+	g.Expect(cfg.Spec.Files[0].Content).To(ContainSubstring("{{ .MachineName }}"))
+	g.Expect(cfg.Spec.PreKubeadmCommands[0]).To(Equal("echo {{.MachineName}} > /etc/machine-name"))
+}
+
+func TestEncryptionConfiguration(t *testing.T) {
+	keys := []apiserverv1.Key{{Name: "key1", Secret: "c2VjcmV0LWtleQ=="}}
+
+	tests := []struct {
+		name       string
+		encryption *bootstrapv1.EncryptionProviderConfig
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name:       "defaults to aescbc for secrets",
+			encryption: &bootstrapv1.EncryptionProviderConfig{},
+			want:       []string{"kind: EncryptionConfiguration", "- secrets", "aescbc:", "name: key1", "identity: {}"},
+		},
+		{
+			name: "uses the given provider and resources",
+			encryption: &bootstrapv1.EncryptionProviderConfig{
+				Provider:  bootstrapv1.SecretboxEncryptionProvider,
+				Resources: []string{"configmaps"},
+			},
+			want: []string{"- configmaps", "secretbox:", "name: key1"},
+		},
+		{
+			name: "fails for unsupported providers",
+			encryption: &bootstrapv1.EncryptionProviderConfig{
+				Provider: "kms",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := encryptionConfiguration(tt.encryption, keys)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			for _, w := range tt.want {
+				g.Expect(got).To(ContainSubstring(w))
+			}
+		})
+	}
+}
+
 // If a control plane has no JoinConfiguration, then we will create a default and no error will occur
 func TestKubeadmConfigReconciler_Reconcile_ErrorIfJoiningControlPlaneHasInvalidConfiguration(t *testing.T) {
 	g := NewWithT(t)
@@ -944,6 +1256,227 @@ func TestBootstrapTokenTTLExtension(t *testing.T) {
 	}
 }
 
+func TestBootstrapTokenRegenerationOnExpiry(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := newCluster("cluster")
+	cluster.Status.InfrastructureReady = true
+	cluster.Status.ControlPlaneInitialized = true
+	cluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "100.105.150.1", Port: 6443}
+
+	initConfig := newControlPlaneInitKubeadmConfig(newControlPlaneMachine(cluster, "control-plane-init-machine"), "control-plane-init-config")
+	workerMachine := newWorkerMachine(cluster)
+	workerJoinConfig := newWorkerJoinKubeadmConfig(workerMachine)
+	objects := []runtime.Object{
+		cluster,
+		workerMachine,
+		workerJoinConfig,
+	}
+
+	objects = append(objects, createSecrets(t, cluster, initConfig)...)
+	myclient := helpers.NewFakeClientWithScheme(setupScheme(), objects...)
+	k := &KubeadmConfigReconciler{
+		Log:                log.Log,
+		Client:             myclient,
+		KubeadmInitLock:    &myInitLocker{},
+		remoteClientGetter: fakeremote.NewClusterClient,
+	}
+	request := ctrl.Request{
+		NamespacedName: client.ObjectKey{
+			Namespace: "default",
+			Name:      "worker-join-cfg",
+		},
+	}
+	_, err := k.Reconcile(request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cfg, err := getKubeadmConfig(myclient, "worker-join-cfg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Status.Ready).To(BeTrue())
+	token := cfg.Spec.JoinConfiguration.Discovery.BootstrapToken.Token
+
+	dataSecret := &corev1.Secret{}
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: *cfg.Status.DataSecretName}, dataSecret)).To(Succeed())
+	data := dataSecret.Data["value"]
+	g.Expect(string(data)).To(ContainSubstring(token))
+
+	// The token expires and it is deleted before the infrastructure consumes it.
+	l := &corev1.SecretList{}
+	g.Expect(myclient.List(context.Background(), l, client.InNamespace(metav1.NamespaceSystem))).To(Succeed())
+	g.Expect(l.Items).To(HaveLen(1))
+	g.Expect(myclient.Delete(context.Background(), &l.Items[0])).To(Succeed())
+
+	_, err = k.Reconcile(request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The bootstrap data is regenerated with a new token, and the regeneration is signaled.
+	cfg, err = getKubeadmConfig(myclient, "worker-join-cfg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Status.Ready).To(BeTrue())
+	g.Expect(cfg.Annotations).To(HaveKey(clusterv1.BootstrapDataRegeneratedAnnotation))
+	newToken := cfg.Spec.JoinConfiguration.Discovery.BootstrapToken.Token
+	g.Expect(newToken).NotTo(BeEmpty())
+	g.Expect(newToken).NotTo(Equal(token))
+
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: *cfg.Status.DataSecretName}, dataSecret)).To(Succeed())
+	g.Expect(string(dataSecret.Data["value"])).To(ContainSubstring(newToken))
+	g.Expect(string(dataSecret.Data["value"])).NotTo(ContainSubstring(token))
+
+	l = &corev1.SecretList{}
+	g.Expect(myclient.List(context.Background(), l, client.InNamespace(metav1.NamespaceSystem))).To(Succeed())
+	g.Expect(l.Items).To(HaveLen(1))
+}
+
+func TestBootstrapTokenRegenerationFailure(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := newCluster("cluster")
+	cluster.Status.InfrastructureReady = true
+	cluster.Status.ControlPlaneInitialized = true
+	cluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "100.105.150.1", Port: 6443}
+
+	initConfig := newControlPlaneInitKubeadmConfig(newControlPlaneMachine(cluster, "control-plane-init-machine"), "control-plane-init-config")
+	workerMachine := newWorkerMachine(cluster)
+	workerJoinConfig := newWorkerJoinKubeadmConfig(workerMachine)
+	objects := []runtime.Object{
+		cluster,
+		workerMachine,
+		workerJoinConfig,
+	}
+
+	secrets := createSecrets(t, cluster, initConfig)
+	objects = append(objects, secrets...)
+	myclient := helpers.NewFakeClientWithScheme(setupScheme(), objects...)
+	k := &KubeadmConfigReconciler{
+		Log:                log.Log,
+		Client:             myclient,
+		KubeadmInitLock:    &myInitLocker{},
+		remoteClientGetter: fakeremote.NewClusterClient,
+	}
+	request := ctrl.Request{
+		NamespacedName: client.ObjectKey{
+			Namespace: "default",
+			Name:      "worker-join-cfg",
+		},
+	}
+	_, err := k.Reconcile(request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cfg, err := getKubeadmConfig(myclient, "worker-join-cfg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Status.Ready).To(BeTrue())
+	token := cfg.Spec.JoinConfiguration.Discovery.BootstrapToken.Token
+
+	dataSecret := &corev1.Secret{}
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: *cfg.Status.DataSecretName}, dataSecret)).To(Succeed())
+	data := dataSecret.Data["value"]
+
+	// The token expires and it is deleted before the infrastructure consumes it.
+	l := &corev1.SecretList{}
+	g.Expect(myclient.List(context.Background(), l, client.InNamespace(metav1.NamespaceSystem))).To(Succeed())
+	g.Expect(l.Items).To(HaveLen(1))
+	g.Expect(myclient.Delete(context.Background(), &l.Items[0])).To(Succeed())
+
+	// Generating the join bootstrap data fails, because the cluster certificates can't be read.
+	for _, s := range secrets {
+		g.Expect(myclient.Delete(context.Background(), s)).To(Succeed())
+	}
+	_, err = k.Reconcile(request)
+	g.Expect(err).To(HaveOccurred())
+
+	// The config still references the token in the bootstrap data secret, which is unchanged.
+	cfg, err = getKubeadmConfig(myclient, "worker-join-cfg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Status.Ready).To(BeTrue())
+	g.Expect(cfg.Annotations).NotTo(HaveKey(clusterv1.BootstrapDataRegeneratedAnnotation))
+	g.Expect(cfg.Spec.JoinConfiguration.Discovery.BootstrapToken.Token).To(Equal(token))
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: *cfg.Status.DataSecretName}, dataSecret)).To(Succeed())
+	g.Expect(dataSecret.Data["value"]).To(Equal(data))
+
+	// Once the certificates are available again, the bootstrap data is regenerated with a new token.
+	for _, s := range secrets {
+		s.(*corev1.Secret).ResourceVersion = ""
+		g.Expect(myclient.Create(context.Background(), s)).To(Succeed())
+	}
+	_, err = k.Reconcile(request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cfg, err = getKubeadmConfig(myclient, "worker-join-cfg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Status.Ready).To(BeTrue())
+	g.Expect(cfg.Annotations).To(HaveKey(clusterv1.BootstrapDataRegeneratedAnnotation))
+	newToken := cfg.Spec.JoinConfiguration.Discovery.BootstrapToken.Token
+	g.Expect(newToken).NotTo(Equal(token))
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: *cfg.Status.DataSecretName}, dataSecret)).To(Succeed())
+	g.Expect(string(dataSecret.Data["value"])).To(ContainSubstring(newToken))
+
+	// The new token is refreshed.
+	result, err := k.Reconcile(request)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(Equal(DefaultTokenTTL / 2))
+}
+
+func TestBootstrapTokenRegenerationWithEmptyToken(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := newCluster("cluster")
+	cluster.Status.InfrastructureReady = true
+	cluster.Status.ControlPlaneInitialized = true
+	cluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "100.105.150.1", Port: 6443}
+
+	initConfig := newControlPlaneInitKubeadmConfig(newControlPlaneMachine(cluster, "control-plane-init-machine"), "control-plane-init-config")
+	workerMachine := newWorkerMachine(cluster)
+	workerJoinConfig := newWorkerJoinKubeadmConfig(workerMachine)
+	objects := []runtime.Object{
+		cluster,
+		workerMachine,
+		workerJoinConfig,
+	}
+
+	objects = append(objects, createSecrets(t, cluster, initConfig)...)
+	myclient := helpers.NewFakeClientWithScheme(setupScheme(), objects...)
+	k := &KubeadmConfigReconciler{
+		Log:                log.Log,
+		Client:             myclient,
+		KubeadmInitLock:    &myInitLocker{},
+		remoteClientGetter: fakeremote.NewClusterClient,
+	}
+	request := ctrl.Request{
+		NamespacedName: client.ObjectKey{
+			Namespace: "default",
+			Name:      "worker-join-cfg",
+		},
+	}
+	_, err := k.Reconcile(request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The config is ready, but it does not reference a token, e.g. because a previous regeneration failed.
+	cfg, err := getKubeadmConfig(myclient, "worker-join-cfg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Status.Ready).To(BeTrue())
+	cfg.Spec.JoinConfiguration.Discovery.BootstrapToken.Token = ""
+	g.Expect(myclient.Update(context.Background(), cfg)).To(Succeed())
+
+	_, err = k.Reconcile(request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The bootstrap data is regenerated with a new token, which can be refreshed.
+	cfg, err = getKubeadmConfig(myclient, "worker-join-cfg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Status.Ready).To(BeTrue())
+	g.Expect(cfg.Annotations).To(HaveKey(clusterv1.BootstrapDataRegeneratedAnnotation))
+	newToken := cfg.Spec.JoinConfiguration.Discovery.BootstrapToken.Token
+	g.Expect(newToken).NotTo(BeEmpty())
+
+	dataSecret := &corev1.Secret{}
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: *cfg.Status.DataSecretName}, dataSecret)).To(Succeed())
+	g.Expect(string(dataSecret.Data["value"])).To(ContainSubstring(newToken))
+
+	result, err := k.Reconcile(request)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(Equal(DefaultTokenTTL / 2))
+}
+
 // Ensure the discovery portion of the JoinConfiguration gets generated correctly.
 func TestKubeadmConfigReconciler_Reconcile_DiscoveryReconcileBehaviors(t *testing.T) {
 	k := &KubeadmConfigReconciler{
@@ -1487,6 +2020,78 @@ func TestKubeadmConfigReconciler_Reconcile_PatchWhenErrorOccurred(t *testing.T)
 	g.Expect(cfg.Status.ObservedGeneration).NotTo(BeNil())
 }
 
+func TestKubeadmConfigReconciler_Reconcile_NetworkConfig(t *testing.T) {
+	networkConfig := bootstrapv1.NetworkConfig{
+		Interfaces: []bootstrapv1.NetworkInterface{{Name: "eth1", Addresses: []string{"10.0.0.10/24"}}},
+	}
+
+	tests := []struct {
+		name                  string
+		mode                  bootstrapv1.NetworkConfigMode
+		expectNetworkConfig   bool
+		expectNetplanUserData bool
+	}{
+		{
+			name:                "cloud-init network-config is stored in the bootstrap data secret",
+			mode:                "",
+			expectNetworkConfig: true,
+		},
+		{
+			name:                  "netplan network config is written with the user data",
+			mode:                  bootstrapv1.NetplanNetworkConfigMode,
+			expectNetplanUserData: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := newCluster("cluster")
+			cluster.Status.InfrastructureReady = true
+			cluster.Status.ControlPlaneInitialized = true
+			cluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "100.105.150.1", Port: 6443}
+
+			machine := newWorkerMachine(cluster)
+			config := newWorkerJoinKubeadmConfig(machine)
+			config.Spec.NetworkConfig = networkConfig.DeepCopy()
+			config.Spec.NetworkConfig.Mode = tt.mode
+
+			objects := []runtime.Object{cluster, machine, config}
+			objects = append(objects, createSecrets(t, cluster, config)...)
+			myclient := helpers.NewFakeClientWithScheme(setupScheme(), objects...)
+			k := &KubeadmConfigReconciler{
+				Log:                log.Log,
+				Client:             myclient,
+				KubeadmInitLock:    &myInitLocker{},
+				remoteClientGetter: fakeremote.NewClusterClient,
+			}
+
+			request := ctrl.Request{NamespacedName: client.ObjectKey{Namespace: config.Namespace, Name: config.Name}}
+			_, err := k.Reconcile(request)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			cfg, err := getKubeadmConfig(myclient, config.Name)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cfg.Status.DataSecretName).NotTo(BeNil())
+
+			bootstrapData := &corev1.Secret{}
+			g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: config.Namespace, Name: *cfg.Status.DataSecretName}, bootstrapData)).To(Succeed())
+			if tt.expectNetworkConfig {
+				g.Expect(bootstrapData.Data).To(HaveKey(networkConfigKey))
+				g.Expect(string(bootstrapData.Data[networkConfigKey])).To(ContainSubstring("- 10.0.0.10/24"))
+			} else {
+				g.Expect(bootstrapData.Data).NotTo(HaveKey(networkConfigKey))
+			}
+			if tt.expectNetplanUserData {
+				g.Expect(string(bootstrapData.Data["value"])).To(ContainSubstring("/etc/netplan/60-kubeadm-bootstrap.yaml"))
+			} else {
+				g.Expect(string(bootstrapData.Data["value"])).NotTo(ContainSubstring("netplan"))
+			}
+		})
+	}
+}
+
 // test utils
 
 // newCluster return a CAPI cluster object
@@ -0,0 +1,203 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	bootstraputil "k8s.io/cluster-bootstrap/token/util"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/internal/cloudinit"
+	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/cluster-api/util/shell"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// defaultMaxUserDataSize is the size, in bytes, above which the files of the bootstrap data are staged.
+	defaultMaxUserDataSize = 16384
+
+	// stagedPayloadKey is the key of the bootstrap data Secret holding the staged payload, if any.
+	stagedPayloadKey = "payload"
+
+	// stagingCACertPath is the path of the cluster CA certificate used for fetching the staged payload from the
+	// workload cluster; it is not the kubeadm CA certificate path, which must not exist on joining workers.
+	stagingCACertPath = "/run/kubeadm/staging-ca.crt"
+)
+
+// stagingInput returns the cloudinit.StagingInput for the KubeadmConfig, or nil if staging is not enabled.
+// Without a URL, the payload is fetched from the workload cluster using the bootstrap token of the discovery;
+// if there is no bootstrap token, e.g. for the first control plane machine, staging is not possible and nil is returned.
+func stagingInput(scope *Scope, discovery *kubeadmv1beta1.BootstrapTokenDiscovery, certificates secret.Certificates) *cloudinit.StagingInput {
+	staging := scope.Config.Spec.Staging
+	if staging == nil {
+		return nil
+	}
+	maxSize := defaultMaxUserDataSize
+	if staging.MaxUserDataSize != nil {
+		maxSize = int(*staging.MaxUserDataSize)
+	}
+
+	if staging.URL != "" {
+		url := strings.NewReplacer("${NAMESPACE}", scope.Config.Namespace, "${NAME}", scope.Config.Name).Replace(staging.URL)
+		return &cloudinit.StagingInput{
+			MaxSize:      maxSize,
+			FetchCommand: fmt.Sprintf("curl -fsSL %s", shell.Quote(url)),
+		}
+	}
+
+	ca := certificates.GetByPurpose(secret.ClusterCA)
+	if discovery == nil || discovery.Token == "" || discovery.APIServerEndpoint == "" || ca == nil || ca.KeyPair == nil {
+		scope.Info("The bootstrap data can't be staged in the workload cluster, please set Staging.URL to stage it")
+		return nil
+	}
+
+	// Nb. kubectl is installed together with kubeadm, and it is used for extracting the payload from the Secret.
+	kubectl := fmt.Sprintf("kubectl --server %s --certificate-authority %s --token %s --namespace %s",
+		shell.Quote("https://"+discovery.APIServerEndpoint), stagingCACertPath, shell.Quote(discovery.Token), metav1.NamespaceSystem)
+	name := shell.Quote(stagedPayloadName(scope.Config))
+	return &cloudinit.StagingInput{
+		MaxSize:        maxSize,
+		FetchCommand:   fmt.Sprintf("%s get secret %s -o jsonpath='{.data.value}' | base64 -d", kubectl, name),
+		CleanupCommand: fmt.Sprintf("%s delete secret %s > /dev/null", kubectl, name),
+		Files: []bootstrapv1.File{
+			{
+				Path:        stagingCACertPath,
+				Owner:       "root:root",
+				Permissions: "0644",
+				Content:     string(ca.KeyPair.Cert),
+			},
+		},
+	}
+}
+
+// stagedPayloadName returns the name of the Secret, and of the RBAC rules granting access to it, storing the
+// staged payload of a KubeadmConfig in the workload cluster.
+func stagedPayloadName(config *bootstrapv1.KubeadmConfig) string {
+	return fmt.Sprintf("kubeadm-staged-%s", config.Name)
+}
+
+// reconcileStagedPayload stores the staged payload, if any, in a Secret of the workload cluster that can be read and
+// deleted only with the bootstrap token of the machine, which deletes the Secret as soon as the payload has been
+// fetched. The Secret is owned by the bootstrap token Secret, so it is garbage collected once the bootstrap token
+// expires, e.g. if the machine never fetches the payload. Nothing is stored if the payload is fetched from a URL.
+func (r *KubeadmConfigReconciler) reconcileStagedPayload(ctx context.Context, scope *Scope, discovery *kubeadmv1beta1.BootstrapTokenDiscovery, staging *cloudinit.StagingInput) error {
+	if staging == nil || staging.Payload == nil || scope.Config.Spec.Staging.URL != "" {
+		return nil
+	}
+
+	substrs := bootstraputil.BootstrapTokenRegexp.FindStringSubmatch(discovery.Token)
+	if len(substrs) != 3 {
+		return errors.Errorf("the bootstrap token %q was not of the form %q", discovery.Token, bootstrapapi.BootstrapTokenPattern)
+	}
+
+	remoteClient, err := r.remoteClientGetter(ctx, r.Client, util.ObjectKey(scope.Cluster), r.scheme)
+	if err != nil {
+		return errors.Wrap(err, "failed to create remote cluster client")
+	}
+
+	tokenSecret := &corev1.Secret{}
+	tokenSecretKey := client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: bootstraputil.BootstrapTokenSecretName(substrs[1])}
+	if err := remoteClient.Get(ctx, tokenSecretKey, tokenSecret); err != nil {
+		return errors.Wrapf(err, "failed to get the bootstrap token Secret %s of the workload cluster", tokenSecretKey)
+	}
+
+	name := stagedPayloadName(scope.Config)
+	payload := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceSystem}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, remoteClient, payload, func() error {
+		// Nb. the owner is updated when the bootstrap token is regenerated.
+		payload.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: "v1",
+				Kind:       "Secret",
+				Name:       tokenSecret.Name,
+				UID:        tokenSecret.UID,
+			},
+		}
+		payload.Type = corev1.SecretTypeOpaque
+		payload.Data = map[string][]byte{"value": staging.Payload}
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "failed to store the staged payload in Secret %s/%s of the workload cluster", metav1.NamespaceSystem, name)
+	}
+
+	// The Role and the RoleBinding are garbage collected when the Secret is deleted.
+	ownerReferences := []metav1.OwnerReference{
+		{
+			APIVersion: "v1",
+			Kind:       "Secret",
+			Name:       payload.Name,
+			UID:        payload.UID,
+		},
+	}
+
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceSystem}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, remoteClient, role, func() error {
+		role.OwnerReferences = ownerReferences
+		role.Rules = []rbacv1.PolicyRule{
+			{
+				APIGroups:     []string{""},
+				Resources:     []string{"secrets"},
+				ResourceNames: []string{name},
+				Verbs:         []string{"get", "delete"},
+			},
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "failed to create Role %s/%s in the workload cluster", metav1.NamespaceSystem, name)
+	}
+
+	binding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceSystem}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, remoteClient, binding, func() error {
+		binding.OwnerReferences = ownerReferences
+		binding.RoleRef = rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     name,
+		}
+		// Nb. the subject is updated when the bootstrap token is regenerated.
+		binding.Subjects = []rbacv1.Subject{
+			{
+				APIGroup: rbacv1.GroupName,
+				Kind:     rbacv1.UserKind,
+				Name:     bootstrapapi.BootstrapUserPrefix + substrs[1],
+			},
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "failed to create RoleBinding %s/%s in the workload cluster", metav1.NamespaceSystem, name)
+	}
+	return nil
+}
+
+// stagedPayload returns the staged payload, if any.
+func stagedPayload(staging *cloudinit.StagingInput) []byte {
+	if staging == nil {
+		return nil
+	}
+	return staging.Payload
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+	fakeremote "sigs.k8s.io/cluster-api/controllers/remote/fake"
+	"sigs.k8s.io/cluster-api/test/helpers"
+	"sigs.k8s.io/cluster-api/util/secret"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestReconcileStagedBootstrapData(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := newCluster("cluster")
+	cluster.Status.InfrastructureReady = true
+	cluster.Status.ControlPlaneInitialized = true
+	cluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "100.105.150.1", Port: 6443}
+
+	machine := newWorkerMachine(cluster)
+	config := newWorkerJoinKubeadmConfig(machine)
+	config.Spec.Staging = &bootstrapv1.Staging{MaxUserDataSize: pointer.Int32Ptr(1024)}
+	config.Spec.Files = []bootstrapv1.File{
+		{
+			Path:    "/etc/large-file",
+			Content: strings.Repeat("a", 2048),
+		},
+	}
+
+	objects := []runtime.Object{cluster, machine, config}
+	objects = append(objects, createSecrets(t, cluster, config)...)
+	myclient := helpers.NewFakeClientWithScheme(setupScheme(), objects...)
+	k := &KubeadmConfigReconciler{
+		Log:                log.Log,
+		Client:             myclient,
+		KubeadmInitLock:    &myInitLocker{},
+		remoteClientGetter: fakeremote.NewClusterClient,
+	}
+
+	request := ctrl.Request{NamespacedName: client.ObjectKey{Namespace: config.Namespace, Name: config.Name}}
+	_, err := k.Reconcile(request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cfg, err := getKubeadmConfig(myclient, config.Name)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Status.DataSecretName).NotTo(BeNil())
+
+	// The bootstrap data embeds only the script fetching the payload, which holds the files.
+	bootstrapData := &corev1.Secret{}
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: config.Namespace, Name: *cfg.Status.DataSecretName}, bootstrapData)).To(Succeed())
+	g.Expect(string(bootstrapData.Data["value"])).To(ContainSubstring("kubeadm-fetch-staged-files"))
+	g.Expect(string(bootstrapData.Data["value"])).NotTo(ContainSubstring("/etc/large-file"))
+	g.Expect(string(bootstrapData.Data[stagedPayloadKey])).To(ContainSubstring("/etc/large-file"))
+
+	// The payload is stored in the workload cluster, readable only with the bootstrap token of the machine.
+	name := stagedPayloadName(config)
+	payload := &corev1.Secret{}
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: name}, payload)).To(Succeed())
+	g.Expect(payload.Data["value"]).To(Equal(bootstrapData.Data[stagedPayloadKey]))
+
+	// The payload is garbage collected once the bootstrap token expires.
+	tokenID := strings.Split(cfg.Spec.JoinConfiguration.Discovery.BootstrapToken.Token, ".")[0]
+	g.Expect(payload.OwnerReferences).To(HaveLen(1))
+	g.Expect(payload.OwnerReferences[0].Kind).To(Equal("Secret"))
+	g.Expect(payload.OwnerReferences[0].Name).To(Equal("bootstrap-token-" + tokenID))
+
+	binding := &rbacv1.RoleBinding{}
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: name}, binding)).To(Succeed())
+	g.Expect(binding.Subjects).To(ConsistOf(rbacv1.Subject{APIGroup: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: "system:bootstrap:" + tokenID}))
+	g.Expect(binding.OwnerReferences).To(HaveLen(1))
+	g.Expect(binding.OwnerReferences[0].Name).To(Equal(name))
+
+	role := &rbacv1.Role{}
+	g.Expect(myclient.Get(context.Background(), client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: name}, role)).To(Succeed())
+	g.Expect(role.Rules).To(HaveLen(1))
+	g.Expect(role.Rules[0].ResourceNames).To(ConsistOf(name))
+}
+
+func TestStagingInput(t *testing.T) {
+	certificates := secret.NewCertificatesForInitialControlPlane(&kubeadmv1beta1.ClusterConfiguration{})
+	if err := certificates.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	discovery := &kubeadmv1beta1.BootstrapTokenDiscovery{
+		Token:             "abcdef.0123456789abcdef",
+		APIServerEndpoint: "example.com:6443",
+	}
+
+	tests := []struct {
+		name             string
+		staging          *bootstrapv1.Staging
+		discovery        *kubeadmv1beta1.BootstrapTokenDiscovery
+		wantNil          bool
+		wantMaxSize      int
+		wantFetchCommand string
+	}{
+		{
+			name:    "staging not enabled",
+			wantNil: true,
+		},
+		{
+			name:             "payload fetched from a URL",
+			staging:          &bootstrapv1.Staging{URL: "https://bucket.example.com/${NAMESPACE}/${NAME}?signature=foo"},
+			wantMaxSize:      defaultMaxUserDataSize,
+			wantFetchCommand: "curl -fsSL 'https://bucket.example.com/default/cfg?signature=foo'",
+		},
+		{
+			name:             "payload fetched from the workload cluster",
+			staging:          &bootstrapv1.Staging{MaxUserDataSize: pointer.Int32Ptr(65536)},
+			discovery:        discovery,
+			wantMaxSize:      65536,
+			wantFetchCommand: "kubectl --server 'https://example.com:6443' --certificate-authority /run/kubeadm/staging-ca.crt --token 'abcdef.0123456789abcdef' --namespace kube-system get secret 'kubeadm-staged-cfg' -o jsonpath='{.data.value}' | base64 -d",
+		},
+		{
+			name:    "payload can't be fetched from the workload cluster without a bootstrap token",
+			staging: &bootstrapv1.Staging{},
+			wantNil: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			config := newKubeadmConfig(nil, "cfg")
+			config.Spec.Staging = tt.staging
+			scope := &Scope{Logger: log.Log, Config: config}
+
+			got := stagingInput(scope, tt.discovery, certificates)
+			if tt.wantNil {
+				g.Expect(got).To(BeNil())
+				return
+			}
+			g.Expect(got).NotTo(BeNil())
+			g.Expect(got.MaxSize).To(Equal(tt.wantMaxSize))
+			g.Expect(got.FetchCommand).To(ContainSubstring(tt.wantFetchCommand))
+		})
+	}
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+)
+
+// machineVariables returns the values of the machine variables which can be referenced in the content of files and
+// in the pre/post kubeadm commands.
+func machineVariables(scope *Scope) map[string]string {
+	return map[string]string{
+		bootstrapv1.MachineNameVariable:   scope.ConfigOwner.GetName(),
+		bootstrapv1.ClusterNameVariable:   scope.Cluster.Name,
+		bootstrapv1.ProviderIDVariable:    scope.ConfigOwner.ProviderID(),
+		bootstrapv1.FailureDomainVariable: scope.ConfigOwner.FailureDomain(),
+	}
+}
+
+// expandCommands returns a copy of the given commands with the references to machine variables expanded.
+func expandCommands(commands []string, variables map[string]string) []string {
+	if commands == nil {
+		return nil
+	}
+	expanded := make([]string, len(commands))
+	for i, command := range commands {
+		expanded[i] = bootstrapv1.ExpandMachineVariables(command, variables)
+	}
+	return expanded
+}
@@ -26,6 +26,7 @@ import (
 )
 
 const (
+	standardInitCommand            = "kubeadm init --config /tmp/kubeadm.yaml %s"
 	standardJoinCommand            = "kubeadm join --config /tmp/kubeadm-join-config.yaml %s"
 	retriableJoinScriptName        = "/usr/local/bin/kubeadm-bootstrap-script"
 	retriableJoinScriptOwner       = "root"
@@ -44,18 +45,37 @@ type BaseUserData struct {
 	WriteFiles           []bootstrapv1.File
 	Users                []bootstrapv1.User
 	NTP                  *bootstrapv1.NTP
+	Kubelet              *bootstrapv1.KubeletOptions
+	Sysctls              map[string]string
+	KernelModules        []string
+	Profiles             []bootstrapv1.NodeProfile
+	Packages             []string
 	DiskSetup            *bootstrapv1.DiskSetup
 	Mounts               []bootstrapv1.MountPoints
+	NetworkConfig        *bootstrapv1.NetworkConfig
 	ControlPlane         bool
 	UseExperimentalRetry bool
+	UseSystemdUnit       bool
 	KubeadmCommand       string
+	KubeadmUnitCommands  []string
 	KubeadmVerbosity     string
+	SkipPhases           []string
+	Staging              *StagingInput
 }
 
 func (input *BaseUserData) prepare() error {
+	if err := input.applyProfiles(); err != nil {
+		return err
+	}
 	input.Header = cloudConfigHeader
 	input.WriteFiles = append(input.WriteFiles, input.AdditionalFiles...)
-	input.KubeadmCommand = fmt.Sprintf(standardJoinCommand, input.KubeadmVerbosity)
+	input.WriteFiles = append(input.WriteFiles, kubeletDropInFiles(input.Kubelet)...)
+	input.WriteFiles = append(input.WriteFiles, kernelConfigFiles(input.Sysctls, input.KernelModules)...)
+	input.PreKubeadmCommands = append(kernelConfigCommands(input.Sysctls, input.KernelModules), input.PreKubeadmCommands...)
+	if err := input.prepareNetworkConfig(); err != nil {
+		return err
+	}
+	input.KubeadmCommand = fmt.Sprintf(standardJoinCommand, kubeadmFlags(skipPhasesFlag(input.SkipPhases), input.KubeadmVerbosity))
 	if input.UseExperimentalRetry {
 		input.KubeadmCommand = retriableJoinScriptName
 		joinScriptFile, err := generateBootstrapScript(input)
@@ -64,9 +84,20 @@ func (input *BaseUserData) prepare() error {
 		}
 		input.WriteFiles = append(input.WriteFiles, *joinScriptFile)
 	}
+	input.prepareSystemdUnit()
 	return nil
 }
 
+// prepareSystemdUnit replaces the kubeadm runcmd command with the commands running KubeadmCommand in a systemd unit,
+// if required.
+func (input *BaseUserData) prepareSystemdUnit() {
+	if !input.UseSystemdUnit {
+		return
+	}
+	input.WriteFiles = append(input.WriteFiles, kubeadmUnitFile(input.KubeadmCommand))
+	input.KubeadmUnitCommands = kubeadmUnitCommands()
+}
+
 func generate(kind string, tpl string, data interface{}) ([]byte, error) {
 	tm := template.New(kind).Funcs(defaultTemplateFuncMap)
 	if _, err := tm.Parse(filesTemplate); err != nil {
@@ -97,6 +128,10 @@ func generate(kind string, tpl string, data interface{}) ([]byte, error) {
 		return nil, errors.Wrap(err, "failed to parse users template")
 	}
 
+	if _, err := tm.Parse(packagesTemplate); err != nil {
+		return nil, errors.Wrap(err, "failed to parse packages template")
+	}
+
 	t, err := tm.Parse(tpl)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse %s template", kind)
@@ -17,6 +17,10 @@ limitations under the License.
 package cloudinit
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -69,9 +73,13 @@ func TestNewInitControlPlaneAdditionalFileEncodings(t *testing.T) {
 	expectedFiles := []string{
 		`-   path: /tmp/my-path
     encoding: "base64"
+    owner: root:root
+    permissions: '0644'
     content: |
       aGk=`,
 		`-   path: /tmp/my-other-path
+    owner: root:root
+    permissions: '0644'
     content: |
       hi`,
 	}
@@ -179,3 +187,540 @@ func TestNewInitControlPlaneDiskMounts(t *testing.T) {
 	g.Expect(out).To(ContainSubstring(expectedFSSetup))
 	g.Expect(out).To(ContainSubstring(expectedMounts))
 }
+
+func TestNewNodeKubeletDropIn(t *testing.T) {
+	g := NewWithT(t)
+
+	nodeinput := &NodeInput{
+		BaseUserData: BaseUserData{
+			Kubelet: &bootstrapv1.KubeletOptions{
+				ExtraArgs: map[string]string{
+					"node-labels": "role=worker",
+					"max-pods":    "110",
+				},
+				Environment: map[string]string{
+					"HTTP_PROXY": "http://proxy:3128",
+				},
+			},
+		},
+		JoinConfiguration: "my-join-config",
+	}
+
+	out, err := NewNode(nodeinput)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	expectedEnvFile := `-   path: /etc/kubernetes/kubelet-bootstrap.env
+    owner: root:root
+    permissions: '0644'
+    content: |
+      KUBELET_EXTRA_ARGS="--max-pods=110 --node-labels=role=worker"`
+	g.Expect(out).To(ContainSubstring(expectedEnvFile))
+
+	expectedDropIn := `-   path: /etc/systemd/system/kubelet.service.d/20-kubeadm-bootstrap.conf
+    owner: root:root
+    permissions: '0644'
+    content: |
+      [Service]
+      Environment="HTTP_PROXY=http://proxy:3128"
+      EnvironmentFile=/etc/kubernetes/kubelet-bootstrap.env`
+	g.Expect(out).To(ContainSubstring(expectedDropIn))
+	g.Expect(out).NotTo(ContainSubstring("ExecStart="))
+}
+
+func TestKubeletDropInFilesEmpty(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(kubeletDropInFiles(nil)).To(BeEmpty())
+	g.Expect(kubeletDropInFiles(&bootstrapv1.KubeletOptions{})).To(BeEmpty())
+}
+
+func TestNewNodeSysctlsAndKernelModules(t *testing.T) {
+	g := NewWithT(t)
+
+	nodeinput := &NodeInput{
+		BaseUserData: BaseUserData{
+			Sysctls: map[string]string{
+				"net.ipv4.ip_forward":                "1",
+				"net.bridge.bridge-nf-call-iptables": "1",
+			},
+			KernelModules:      []string{"overlay", "br_netfilter"},
+			PreKubeadmCommands: []string{"echo pre"},
+		},
+		JoinConfiguration: "my-join-config",
+	}
+
+	out, err := NewNode(nodeinput)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	expectedFiles := `-   path: /etc/modules-load.d/kubeadm-bootstrap.conf
+    owner: root:root
+    permissions: '0644'
+    content: |
+      overlay
+      br_netfilter
+      
+-   path: /etc/sysctl.d/99-kubeadm-bootstrap.conf
+    owner: root:root
+    permissions: '0644'
+    content: |
+      net.bridge.bridge-nf-call-iptables = 1
+      net.ipv4.ip_forward = 1`
+	g.Expect(out).To(ContainSubstring(expectedFiles))
+
+	expectedCommands := `runcmd:
+  - "modprobe overlay"
+  - "modprobe br_netfilter"
+  - "sysctl -p /etc/sysctl.d/99-kubeadm-bootstrap.conf"
+  - "echo pre"`
+	g.Expect(out).To(ContainSubstring(expectedCommands))
+}
+
+func TestNewNodeProfiles(t *testing.T) {
+	g := NewWithT(t)
+
+	nodeinput := &NodeInput{
+		BaseUserData: BaseUserData{
+			Profiles:           []bootstrapv1.NodeProfile{bootstrapv1.NvidiaGPUProfile, bootstrapv1.IPVSProfile, bootstrapv1.NvidiaGPUProfile},
+			KernelModules:      []string{"br_netfilter", "nvidia"},
+			PreKubeadmCommands: []string{"echo pre"},
+		},
+		JoinConfiguration: "my-join-config",
+	}
+
+	out, err := NewNode(nodeinput)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	expectedModules := `-   path: /etc/modules-load.d/kubeadm-bootstrap.conf
+    owner: root:root
+    permissions: '0644'
+    content: |
+      nvidia
+      nvidia_uvm
+      ip_vs
+      ip_vs_rr
+      ip_vs_wrr
+      ip_vs_sh
+      nf_conntrack
+      br_netfilter
+`
+	g.Expect(out).To(ContainSubstring(expectedModules))
+	g.Expect(out).To(ContainSubstring("-   path: /etc/containerd/conf.d/nvidia-gpu.toml"))
+
+	expectedCommands := `  - "modprobe br_netfilter"
+  - "test -f /etc/containerd/config.toml || containerd config default > /etc/containerd/config.toml"
+  - "grep -q '^imports' /etc/containerd/config.toml || sed -i '1i imports = [\"/etc/containerd/conf.d/*.toml\"]' /etc/containerd/config.toml"
+  - "systemctl restart containerd"
+  - "echo pre"`
+	g.Expect(out).To(ContainSubstring(expectedCommands))
+
+	expectedPackages := `packages:
+  - nvidia-container-runtime
+  - ipset
+  - ipvsadm`
+	g.Expect(out).To(ContainSubstring(expectedPackages))
+}
+
+func TestApplyProfilesSysctlsPrecedence(t *testing.T) {
+	g := NewWithT(t)
+
+	nodeProfiles["test"] = nodeProfile{sysctls: map[string]string{"net.ipv4.ip_forward": "0", "vm.max_map_count": "262144"}}
+	defer delete(nodeProfiles, "test")
+
+	userSysctls := map[string]string{"net.ipv4.ip_forward": "1"}
+	input := &BaseUserData{
+		Profiles: []bootstrapv1.NodeProfile{"test"},
+		Sysctls:  userSysctls,
+	}
+	g.Expect(input.applyProfiles()).To(Succeed())
+	g.Expect(input.Sysctls).To(Equal(map[string]string{"net.ipv4.ip_forward": "1", "vm.max_map_count": "262144"}))
+	// The Sysctls of the KubeadmConfig must not be modified.
+	g.Expect(userSysctls).To(HaveLen(1))
+
+	input = &BaseUserData{Profiles: []bootstrapv1.NodeProfile{"unknown"}}
+	g.Expect(input.applyProfiles()).NotTo(Succeed())
+}
+
+func TestKernelConfigEmpty(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(kernelConfigFiles(nil, nil)).To(BeEmpty())
+	g.Expect(kernelConfigCommands(nil, nil)).To(BeEmpty())
+}
+
+func TestNewNodeFiles(t *testing.T) {
+	g := NewWithT(t)
+
+	nodeinput := &NodeInput{
+		BaseUserData: BaseUserData{
+			AdditionalFiles: []bootstrapv1.File{
+				{
+					Path:     "/etc/kubernetes/audit-policy.yaml",
+					Content:  "H4sIAAAAAAAA/0rLz+cCAAAA//8BAAD//w==",
+					Encoding: bootstrapv1.GzipBase64,
+				},
+				{
+					Path:        "/etc/hosts",
+					Owner:       "root:adm",
+					Permissions: "0640",
+					Content:     "10.0.0.1 registry.local",
+					Append:      true,
+				},
+			},
+		},
+		JoinConfiguration: "my-join-config",
+	}
+
+	out, err := NewNode(nodeinput)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	expectedFiles := `-   path: /etc/kubernetes/audit-policy.yaml
+    encoding: "gzip+base64"
+    owner: root:root
+    permissions: '0644'
+    content: |
+      H4sIAAAAAAAA/0rLz+cCAAAA//8BAAD//w==
+-   path: /etc/hosts
+    owner: root:adm
+    permissions: '0640'
+    append: true
+    content: |
+      10.0.0.1 registry.local`
+	g.Expect(out).To(ContainSubstring(expectedFiles))
+}
+
+func TestNewInitControlPlaneSkipAddons(t *testing.T) {
+	tests := []struct {
+		name       string
+		addons     *bootstrapv1.KubeadmAddons
+		skipPhases []string
+		command    string
+	}{
+		{
+			name:    "no addons skipped",
+			command: "kubeadm init --config /tmp/kubeadm.yaml --v 5",
+		},
+		{
+			name:    "kube-proxy skipped",
+			addons:  &bootstrapv1.KubeadmAddons{SkipKubeProxy: true},
+			command: "kubeadm init --config /tmp/kubeadm.yaml --skip-phases=addon/kube-proxy --v 5",
+		},
+		{
+			name:    "all addons skipped",
+			addons:  &bootstrapv1.KubeadmAddons{SkipCoreDNS: true, SkipKubeProxy: true},
+			command: "kubeadm init --config /tmp/kubeadm.yaml --skip-phases=addon/coredns,addon/kube-proxy --v 5",
+		},
+		{
+			name:       "addons and init phases skipped",
+			addons:     &bootstrapv1.KubeadmAddons{SkipKubeProxy: true},
+			skipPhases: []string{"preflight", "addon/kube-proxy"},
+			command:    "kubeadm init --config /tmp/kubeadm.yaml --skip-phases=addon/kube-proxy,preflight --v 5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cpinput := &ControlPlaneInput{
+				BaseUserData: BaseUserData{
+					KubeadmVerbosity: "--v 5",
+					SkipPhases:       tt.skipPhases,
+				},
+				Certificates:         secret.Certificates{},
+				ClusterConfiguration: "my-cluster-config",
+				InitConfiguration:    "my-init-config",
+				Addons:               tt.addons,
+			}
+
+			out, err := NewInitControlPlane(cpinput)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(out)).To(ContainSubstring("  - '" + tt.command + "'\n"))
+		})
+	}
+}
+
+func TestNewNodeSkipPhases(t *testing.T) {
+	tests := []struct {
+		name       string
+		skipPhases []string
+		verbosity  string
+		command    string
+	}{
+		{
+			name:    "no phases skipped",
+			command: "kubeadm join --config /tmp/kubeadm-join-config.yaml ",
+		},
+		{
+			name:       "phases skipped",
+			skipPhases: []string{"preflight"},
+			verbosity:  "--v 5",
+			command:    "kubeadm join --config /tmp/kubeadm-join-config.yaml --skip-phases=preflight --v 5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			nodeinput := &NodeInput{
+				BaseUserData: BaseUserData{
+					KubeadmVerbosity: tt.verbosity,
+					SkipPhases:       tt.skipPhases,
+				},
+				JoinConfiguration: "my-join-config",
+			}
+
+			out, err := NewNode(nodeinput)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(out)).To(ContainSubstring("  - " + tt.command + "\n"))
+		})
+	}
+}
+
+func TestNewNodeSystemdUnit(t *testing.T) {
+	g := NewWithT(t)
+
+	nodeinput := &NodeInput{
+		BaseUserData: BaseUserData{
+			PreKubeadmCommands:  []string{"echo pre"},
+			PostKubeadmCommands: []string{"echo post"},
+			KubeadmVerbosity:    "--v 5",
+			UseSystemdUnit:      true,
+		},
+		JoinConfiguration: "my-join-config",
+	}
+
+	out, err := NewNode(nodeinput)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// kubeadm runs in the systemd unit, and runcmd waits for it to complete before running the post kubeadm commands.
+	g.Expect(string(out)).To(ContainSubstring(`-   path: /etc/systemd/system/kubeadm-bootstrap.service`))
+	g.Expect(string(out)).To(ContainSubstring(`ExecStart=/bin/sh -c "kubeadm join --config /tmp/kubeadm-join-config.yaml --v 5 && mkdir -p /run/cluster-api && touch /run/cluster-api/kubeadm-bootstrap.complete"`))
+	g.Expect(string(out)).To(ContainSubstring("Restart=on-failure"))
+	g.Expect(string(out)).To(ContainSubstring(`runcmd:
+  - "echo pre"
+  - "systemctl daemon-reload"
+  - "systemctl start --no-block kubeadm-bootstrap.service"
+  - "until [ -f /run/cluster-api/kubeadm-bootstrap.complete ]; do sleep 5; done"
+  - "echo post"
+`))
+	g.Expect(string(out)).NotTo(ContainSubstring("  - kubeadm join"))
+}
+
+func TestNewInitControlPlaneSystemdUnit(t *testing.T) {
+	g := NewWithT(t)
+
+	cpinput := &ControlPlaneInput{
+		BaseUserData: BaseUserData{
+			UseSystemdUnit: true,
+		},
+		Addons:               &bootstrapv1.KubeadmAddons{SkipKubeProxy: true},
+		ClusterConfiguration: "my-cluster-config",
+		InitConfiguration:    "my-init-config",
+	}
+
+	out, err := NewInitControlPlane(cpinput)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(string(out)).To(ContainSubstring(`ExecStart=/bin/sh -c "kubeadm init --config /tmp/kubeadm.yaml --skip-phases=addon/kube-proxy && mkdir -p /run/cluster-api && touch /run/cluster-api/kubeadm-bootstrap.complete"`))
+	g.Expect(string(out)).To(ContainSubstring(`  - "systemctl start --no-block kubeadm-bootstrap.service"`))
+	g.Expect(string(out)).NotTo(ContainSubstring("  - 'kubeadm init"))
+}
+
+func TestKubeadmUnitFileEscaping(t *testing.T) {
+	g := NewWithT(t)
+
+	file := kubeadmUnitFile(`kubeadm join --token "a%b$c"`)
+	g.Expect(file.Content).To(ContainSubstring(`ExecStart=/bin/sh -c "kubeadm join --token \"a%%b$$c\" && `))
+}
+
+func TestNewNodeStaging(t *testing.T) {
+	newInput := func(maxSize int) *NodeInput {
+		return &NodeInput{
+			BaseUserData: BaseUserData{
+				PreKubeadmCommands: []string{"echo pre"},
+				AdditionalFiles: []bootstrapv1.File{
+					{
+						Path:        "/etc/large-file",
+						Owner:       "nobody:nogroup",
+						Permissions: "0600",
+						Content:     strings.Repeat("a", 2048),
+					},
+					{
+						Path:     "/etc/encoded-file",
+						Encoding: bootstrapv1.Base64,
+						Content:  "Zm9v",
+						Append:   true,
+					},
+				},
+				Staging: &StagingInput{
+					MaxSize:        maxSize,
+					FetchCommand:   "curl -fsSL 'https://example.com/payload'",
+					CleanupCommand: "echo done",
+					Files:          []bootstrapv1.File{{Path: "/run/kubeadm/staging-ca.crt", Content: "ca"}},
+				},
+			},
+			JoinConfiguration: "my-join-config",
+		}
+	}
+
+	t.Run("user data within the size limit is not staged", func(t *testing.T) {
+		g := NewWithT(t)
+
+		input := newInput(65536)
+		out, err := NewNode(input)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(out)).To(ContainSubstring("-   path: /etc/large-file"))
+		g.Expect(string(out)).NotTo(ContainSubstring(stagingFetchScriptPath))
+		g.Expect(input.Staging.Payload).To(BeNil())
+	})
+
+	t.Run("user data exceeding the size limit is staged", func(t *testing.T) {
+		g := NewWithT(t)
+
+		input := newInput(1024)
+		out, err := NewNode(input)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(len(out)).To(BeNumerically("<", 2048))
+
+		// The files are replaced by the script fetching them, which runs before any other command.
+		g.Expect(string(out)).NotTo(ContainSubstring("/etc/large-file"))
+		g.Expect(string(out)).To(ContainSubstring("-   path: /run/kubeadm/staging-ca.crt"))
+		g.Expect(string(out)).To(ContainSubstring("-   path: " + stagingFetchScriptPath))
+		g.Expect(string(out)).To(ContainSubstring("curl -fsSL 'https://example.com/payload'"))
+		g.Expect(string(out)).To(ContainSubstring(fmt.Sprintf("%x", sha256.Sum256(input.Staging.Payload))))
+		// The payload is cleaned up as soon as it is fetched, before writing the files.
+		g.Expect(strings.Index(string(out), "(echo done) || true")).To(BeNumerically("<", strings.Index(string(out), `sh "${payload}"`)))
+		g.Expect(string(out)).To(ContainSubstring(`runcmd:
+  - "` + stagingFetchScriptPath + `"
+  - "echo pre"
+`))
+		// The join configuration is still embedded in the user data.
+		g.Expect(string(out)).To(ContainSubstring("my-join-config"))
+
+		payload := string(input.Staging.Payload)
+		g.Expect(payload).To(ContainSubstring("base64 -d <<'EOF' > '/etc/large-file'\n" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 2048))) + "\nEOF\n"))
+		g.Expect(payload).To(ContainSubstring("chown 'nobody:nogroup' '/etc/large-file'\nchmod '0600' '/etc/large-file'\n"))
+		g.Expect(payload).To(ContainSubstring("base64 -d <<'EOF' >> '/etc/encoded-file'\nZm9v\nEOF\n"))
+		g.Expect(payload).To(ContainSubstring("chown 'root:root' '/etc/encoded-file'\nchmod '0644' '/etc/encoded-file'\n"))
+	})
+}
+
+func TestNetworkConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	out, err := NetworkConfig(&bootstrapv1.NetworkConfig{
+		Renderer: bootstrapv1.NetworkManagerRenderer,
+		Interfaces: []bootstrapv1.NetworkInterface{
+			{Name: "eth0", DHCP4: true},
+			{
+				Name:          "storage",
+				MACAddress:    "00:50:56:12:34:56",
+				Addresses:     []string{"10.0.0.10/24"},
+				Gateway4:      "10.0.0.1",
+				Nameservers:   []string{"10.0.0.2"},
+				SearchDomains: []string{"example.com"},
+				Routes:        []bootstrapv1.NetworkRoute{{To: "172.16.0.0/12", Via: "10.0.0.254", Metric: pointer.Int32Ptr(100)}},
+				MTU:           pointer.Int32Ptr(9000),
+			},
+		},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(out)).To(Equal(`network:
+  ethernets:
+    eth0:
+      dhcp4: true
+      dhcp6: false
+    storage:
+      addresses:
+      - 10.0.0.10/24
+      dhcp4: false
+      dhcp6: false
+      gateway4: 10.0.0.1
+      match:
+        macaddress: "00:50:56:12:34:56"
+      mtu: 9000
+      nameservers:
+        addresses:
+        - 10.0.0.2
+        search:
+        - example.com
+      routes:
+      - metric: 100
+        to: 172.16.0.0/12
+        via: 10.0.0.254
+      set-name: storage
+  renderer: NetworkManager
+  version: 2
+`))
+}
+
+func TestNewNodeNetplanNetworkConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	nodeinput := &NodeInput{
+		BaseUserData: BaseUserData{
+			NetworkConfig: &bootstrapv1.NetworkConfig{
+				Mode:       bootstrapv1.NetplanNetworkConfigMode,
+				Interfaces: []bootstrapv1.NetworkInterface{{Name: "eth1", Addresses: []string{"10.0.0.10/24"}}},
+			},
+			Sysctls:            map[string]string{"net.ipv4.ip_forward": "1"},
+			PreKubeadmCommands: []string{"echo pre"},
+		},
+		JoinConfiguration: "my-join-config",
+	}
+
+	out, err := NewNode(nodeinput)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	expectedFile := `-   path: /etc/netplan/60-kubeadm-bootstrap.yaml
+    owner: root:root
+    permissions: '0600'
+    content: |
+      network:
+        ethernets:
+          eth1:
+            addresses:
+            - 10.0.0.10/24
+            dhcp4: false
+            dhcp6: false
+        version: 2`
+	g.Expect(string(out)).To(ContainSubstring(expectedFile))
+
+	expectedCommands := `runcmd:
+  - "netplan apply"
+  - "sysctl -p /etc/sysctl.d/99-kubeadm-bootstrap.conf"
+  - "echo pre"`
+	g.Expect(string(out)).To(ContainSubstring(expectedCommands))
+}
+
+func TestNewInitControlPlaneNetworkConfig(t *testing.T) {
+	networkConfig := bootstrapv1.NetworkConfig{
+		Interfaces: []bootstrapv1.NetworkInterface{{Name: "eth1", Addresses: []string{"10.0.0.10/24"}}},
+	}
+
+	t.Run("netplan", func(t *testing.T) {
+		g := NewWithT(t)
+
+		config := networkConfig.DeepCopy()
+		config.Mode = bootstrapv1.NetplanNetworkConfigMode
+		out, err := NewInitControlPlane(&ControlPlaneInput{
+			BaseUserData:         BaseUserData{NetworkConfig: config},
+			ClusterConfiguration: "my-cluster-config",
+			InitConfiguration:    "my-init-config",
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(out)).To(ContainSubstring("path: /etc/netplan/60-kubeadm-bootstrap.yaml"))
+		g.Expect(string(out)).To(ContainSubstring("runcmd:\n  - \"netplan apply\""))
+	})
+
+	t.Run("cloud-init network-config is not part of the user data", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := NewInitControlPlane(&ControlPlaneInput{
+			BaseUserData:         BaseUserData{NetworkConfig: networkConfig.DeepCopy()},
+			ClusterConfiguration: "my-cluster-config",
+			InitConfiguration:    "my-init-config",
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(out)).NotTo(ContainSubstring("netplan"))
+	})
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package cloudinit
 
 import (
+	"fmt"
+
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/util/secret"
 )
 
@@ -33,13 +36,15 @@ const (
 {{.InitConfiguration | Indent 6}}
 runcmd:
 {{- template "commands" .PreKubeadmCommands }}
-  - 'kubeadm init --config /tmp/kubeadm.yaml {{.KubeadmVerbosity}}'
+{{- if .KubeadmUnitCommands }}{{ template "commands" .KubeadmUnitCommands }}{{ else }}
+  - 'kubeadm init --config /tmp/kubeadm.yaml {{with .KubeadmSkipPhases}}{{.}} {{end}}{{.KubeadmVerbosity}}'{{ end }}
 {{- template "commands" .PostKubeadmCommands }}
 {{- template "ntp" .NTP }}
 {{- template "users" .Users }}
 {{- template "disk_setup" .DiskSetup}}
 {{- template "fs_setup" .DiskSetup}}
 {{- template "mounts" .Mounts}}
+{{- template "packages" .Packages}}
 `
 )
 
@@ -50,17 +55,44 @@ type ControlPlaneInput struct {
 
 	ClusterConfiguration string
 	InitConfiguration    string
+	Addons               *bootstrapv1.KubeadmAddons
+	KubeadmSkipPhases    string
 }
 
 // NewInitControlPlane returns the user data string to be used on a controlplane instance.
 func NewInitControlPlane(input *ControlPlaneInput) ([]byte, error) {
+	if err := input.applyProfiles(); err != nil {
+		return nil, err
+	}
 	input.Header = cloudConfigHeader
 	input.WriteFiles = input.Certificates.AsFiles()
 	input.WriteFiles = append(input.WriteFiles, input.AdditionalFiles...)
-	userData, err := generate("InitControlplane", controlPlaneCloudInit, input)
+	input.WriteFiles = append(input.WriteFiles, kubeletDropInFiles(input.Kubelet)...)
+	input.WriteFiles = append(input.WriteFiles, kernelConfigFiles(input.Sysctls, input.KernelModules)...)
+	input.PreKubeadmCommands = append(kernelConfigCommands(input.Sysctls, input.KernelModules), input.PreKubeadmCommands...)
+	if err := input.prepareNetworkConfig(); err != nil {
+		return nil, err
+	}
+	input.KubeadmSkipPhases = kubeadmSkipPhasesFlag(input.Addons, input.SkipPhases)
+	input.KubeadmCommand = fmt.Sprintf(standardInitCommand, kubeadmFlags(input.KubeadmSkipPhases, input.KubeadmVerbosity))
+	input.prepareSystemdUnit()
+	userData, err := generateUserData("InitControlplane", controlPlaneCloudInit, input, &input.BaseUserData)
 	if err != nil {
 		return nil, err
 	}
 
 	return userData, nil
 }
+
+// kubeadmSkipPhasesFlag returns the kubeadm init --skip-phases flag for the skipped addons and the
+// phases listed in InitConfiguration.SkipPhases, if any.
+func kubeadmSkipPhasesFlag(addons *bootstrapv1.KubeadmAddons, skipPhases []string) string {
+	phases := []string{}
+	if addons != nil && addons.SkipCoreDNS {
+		phases = append(phases, "addon/coredns")
+	}
+	if addons != nil && addons.SkipKubeProxy {
+		phases = append(phases, "addon/kube-proxy")
+	}
+	return skipPhasesFlag(append(phases, skipPhases...))
+}
@@ -31,13 +31,15 @@ const (
 {{.JoinConfiguration | Indent 6}}
 runcmd:
 {{- template "commands" .PreKubeadmCommands }}
-  - {{ .KubeadmCommand }}
+{{- if .KubeadmUnitCommands }}{{ template "commands" .KubeadmUnitCommands }}{{ else }}
+  - {{ .KubeadmCommand }}{{ end }}
 {{- template "commands" .PostKubeadmCommands }}
 {{- template "ntp" .NTP }}
 {{- template "users" .Users }}
 {{- template "disk_setup" .DiskSetup}}
 {{- template "fs_setup" .DiskSetup}}
 {{- template "mounts" .Mounts}}
+{{- template "packages" .Packages}}
 `
 )
 
@@ -57,7 +59,7 @@ func NewJoinControlPlane(input *ControlPlaneJoinInput) ([]byte, error) {
 	if err := input.prepare(); err != nil {
 		return nil, err
 	}
-	userData, err := generate("JoinControlplane", controlPlaneJoinCloudInit, input)
+	userData, err := generateUserData("JoinControlplane", controlPlaneJoinCloudInit, input, &input.BaseUserData)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to generate user data for machine joining control plane")
 	}
@@ -23,11 +23,10 @@ write_files:{{ range . }}
     {{ if ne .Encoding "" -}}
     encoding: "{{.Encoding}}"
     {{ end -}}
-    {{ if ne .Owner "" -}}
-    owner: {{.Owner}}
-    {{ end -}}
-    {{ if ne .Permissions "" -}}
-    permissions: '{{.Permissions}}'
+    owner: {{ or .Owner "root:root" }}
+    permissions: '{{ or .Permissions "0644" }}'
+    {{ if .Append -}}
+    append: true
     {{ end -}}
     content: |
 {{.Content | Indent 6}}
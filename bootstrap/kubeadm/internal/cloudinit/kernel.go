@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"fmt"
+	"strings"
+
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+)
+
+const (
+	sysctlPath             = "/etc/sysctl.d/99-kubeadm-bootstrap.conf"
+	kernelModulesPath      = "/etc/modules-load.d/kubeadm-bootstrap.conf"
+	kernelFilesOwner       = "root:root"
+	kernelFilesPermissions = "0644"
+)
+
+// kernelConfigFiles returns the files to be written for persisting the given sysctls and kernel modules across reboots.
+func kernelConfigFiles(sysctls map[string]string, modules []string) []bootstrapv1.File {
+	var files []bootstrapv1.File
+
+	if len(modules) > 0 {
+		files = append(files, bootstrapv1.File{
+			Path:        kernelModulesPath,
+			Owner:       kernelFilesOwner,
+			Permissions: kernelFilesPermissions,
+			Content:     strings.Join(modules, "\n") + "\n",
+		})
+	}
+
+	if len(sysctls) > 0 {
+		var b strings.Builder
+		for _, k := range sortedKeys(sysctls) {
+			fmt.Fprintf(&b, "%s = %s\n", k, sysctls[k])
+		}
+		files = append(files, bootstrapv1.File{
+			Path:        sysctlPath,
+			Owner:       kernelFilesOwner,
+			Permissions: kernelFilesPermissions,
+			Content:     b.String(),
+		})
+	}
+
+	return files
+}
+
+// kernelConfigCommands returns the commands applying the given sysctls and kernel modules immediately, to be run before
+// the other commands. The kernel modules are loaded first, given that some sysctls, e.g. net.bridge.bridge-nf-call-iptables,
+// are available only after the corresponding module is loaded.
+func kernelConfigCommands(sysctls map[string]string, modules []string) []string {
+	var commands []string
+	for _, m := range modules {
+		commands = append(commands, fmt.Sprintf("modprobe %s", m))
+	}
+	if len(sysctls) > 0 {
+		commands = append(commands, fmt.Sprintf("sysctl -p %s", sysctlPath))
+	}
+	return commands
+}
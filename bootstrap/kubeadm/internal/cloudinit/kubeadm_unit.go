@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"fmt"
+	"strings"
+
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+)
+
+const (
+	kubeadmUnitName        = "kubeadm-bootstrap.service"
+	kubeadmUnitPath        = "/etc/systemd/system/" + kubeadmUnitName
+	kubeadmUnitOwner       = "root:root"
+	kubeadmUnitPermissions = "0644"
+
+	// kubeadmUnitSentinel is the file created by the unit once kubeadm completes successfully.
+	// Nb. the unit is a simple service restarted on failure, because Restart= is not supported for one-shot
+	// services by the systemd versions shipped with older distros, so completion is tracked with a file.
+	kubeadmUnitSentinelDir = "/run/cluster-api"
+	kubeadmUnitSentinel    = kubeadmUnitSentinelDir + "/kubeadm-bootstrap.complete"
+
+	// kubeadmUnitRestartSec is the delay before restarting kubeadm after a failure.
+	kubeadmUnitRestartSec = 10
+)
+
+// kubeadmUnitFile returns the systemd unit running the given kubeadm command.
+// The unit is restarted on failure without limits, and its output is captured by journald and copied to the console,
+// so it is included in the cloud-init output as well.
+func kubeadmUnitFile(command string) bootstrapv1.File {
+	execStart := fmt.Sprintf("%s && mkdir -p %s && touch %s", command, kubeadmUnitSentinelDir, kubeadmUnitSentinel)
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=kubeadm bootstrap of the Cluster API machine\n")
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=simple\n")
+	fmt.Fprintf(&b, "ExecStart=/bin/sh -c \"%s\"\n", escapeSystemdCommand(execStart))
+	b.WriteString("Restart=on-failure\n")
+	fmt.Fprintf(&b, "RestartSec=%d\n", kubeadmUnitRestartSec)
+	b.WriteString("StartLimitInterval=0\n")
+	b.WriteString("StandardOutput=journal+console\n")
+	b.WriteString("StandardError=journal+console\n")
+
+	return bootstrapv1.File{
+		Path:        kubeadmUnitPath,
+		Owner:       kubeadmUnitOwner,
+		Permissions: kubeadmUnitPermissions,
+		Content:     b.String(),
+	}
+}
+
+// kubeadmUnitCommands returns the commands starting the kubeadm unit and waiting for kubeadm to complete, so
+// the commands defined in PostKubeadmCommands run only after kubeadm succeeds.
+func kubeadmUnitCommands() []string {
+	return []string{
+		"systemctl daemon-reload",
+		fmt.Sprintf("systemctl start --no-block %s", kubeadmUnitName),
+		fmt.Sprintf("until [ -f %s ]; do sleep 5; done", kubeadmUnitSentinel),
+	}
+}
+
+// escapeSystemdCommand escapes a command to be used as a double quoted argument of a systemd Exec setting,
+// including the specifiers and the environment variables expanded by systemd.
+func escapeSystemdCommand(s string) string {
+	s = escapeSystemdValue(s)
+	s = strings.ReplaceAll(s, "%", "%%")
+	return strings.ReplaceAll(s, "$", "$$")
+}
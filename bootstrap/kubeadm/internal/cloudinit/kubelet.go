@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+)
+
+const (
+	kubeletDropInPath       = "/etc/systemd/system/kubelet.service.d/20-kubeadm-bootstrap.conf"
+	kubeletEnvFilePath      = "/etc/kubernetes/kubelet-bootstrap.env"
+	kubeletFilesOwner       = "root:root"
+	kubeletFilesPermissions = "0644"
+
+	// kubeletExtraArgsEnv is the environment variable used by the kubeadm drop-in (10-kubeadm.conf) for passing
+	// user-defined flags to the kubelet, used for passing the flags defined in KubeletOptions.ExtraArgs.
+	// Nb. The kubelet packages define it in an EnvironmentFile (/etc/default/kubelet or /etc/sysconfig/kubelet), and values
+	// from an EnvironmentFile take precedence over values set via Environment, so it is set in an EnvironmentFile too;
+	// given that our drop-in is loaded after the kubeadm one, its value overrides the one defined by the packages.
+	kubeletExtraArgsEnv = "KUBELET_EXTRA_ARGS"
+)
+
+// kubeletDropInFiles returns the files to be written for applying the given KubeletOptions.
+// The systemd drop-in is loaded when kubeadm restarts the kubelet service, given that kubeadm reloads
+// the systemd configuration before doing so.
+func kubeletDropInFiles(kubelet *bootstrapv1.KubeletOptions) []bootstrapv1.File {
+	if kubelet == nil || (len(kubelet.ExtraArgs) == 0 && len(kubelet.Environment) == 0) {
+		return nil
+	}
+
+	var files []bootstrapv1.File
+	var b strings.Builder
+	b.WriteString("[Service]\n")
+	for _, k := range sortedKeys(kubelet.Environment) {
+		fmt.Fprintf(&b, "Environment=\"%s=%s\"\n", k, escapeSystemdValue(kubelet.Environment[k]))
+	}
+
+	if len(kubelet.ExtraArgs) > 0 {
+		args := make([]string, 0, len(kubelet.ExtraArgs))
+		for _, k := range sortedKeys(kubelet.ExtraArgs) {
+			args = append(args, fmt.Sprintf("--%s=%s", k, kubelet.ExtraArgs[k]))
+		}
+		fmt.Fprintf(&b, "EnvironmentFile=%s\n", kubeletEnvFilePath)
+		files = append(files, bootstrapv1.File{
+			Path:        kubeletEnvFilePath,
+			Owner:       kubeletFilesOwner,
+			Permissions: kubeletFilesPermissions,
+			Content:     fmt.Sprintf("%s=\"%s\"\n", kubeletExtraArgsEnv, escapeSystemdValue(strings.Join(args, " "))),
+		})
+	}
+
+	return append(files, bootstrapv1.File{
+		Path:        kubeletDropInPath,
+		Owner:       kubeletFilesOwner,
+		Permissions: kubeletFilesPermissions,
+		Content:     b.String(),
+	})
+}
+
+// escapeSystemdValue escapes a value to be used in a double quoted systemd assignment.
+func escapeSystemdValue(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
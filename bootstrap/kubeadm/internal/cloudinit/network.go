@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"github.com/pkg/errors"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	netplanPath        = "/etc/netplan/60-kubeadm-bootstrap.yaml"
+	netplanOwner       = "root:root"
+	netplanPermissions = "0600"
+	netplanCommand     = "netplan apply"
+)
+
+// netplanConfig is the network configuration version 2 format, shared by cloud-init and netplan.
+type netplanConfig struct {
+	Network netplanNetwork `json:"network"`
+}
+
+type netplanNetwork struct {
+	Version   int                        `json:"version"`
+	Renderer  string                     `json:"renderer,omitempty"`
+	Ethernets map[string]netplanEthernet `json:"ethernets"`
+}
+
+type netplanEthernet struct {
+	Match       *netplanMatch       `json:"match,omitempty"`
+	SetName     string              `json:"set-name,omitempty"`
+	DHCP4       bool                `json:"dhcp4"`
+	DHCP6       bool                `json:"dhcp6"`
+	Addresses   []string            `json:"addresses,omitempty"`
+	Gateway4    string              `json:"gateway4,omitempty"`
+	Gateway6    string              `json:"gateway6,omitempty"`
+	Nameservers *netplanNameservers `json:"nameservers,omitempty"`
+	Routes      []netplanRoute      `json:"routes,omitempty"`
+	MTU         *int32              `json:"mtu,omitempty"`
+}
+
+type netplanMatch struct {
+	MACAddress string `json:"macaddress"`
+}
+
+type netplanNameservers struct {
+	Addresses []string `json:"addresses,omitempty"`
+	Search    []string `json:"search,omitempty"`
+}
+
+type netplanRoute struct {
+	To     string `json:"to"`
+	Via    string `json:"via"`
+	Metric *int32 `json:"metric,omitempty"`
+}
+
+// NetworkConfig renders the network configuration in the version 2 format, which can be used both as cloud-init
+// network-config and as a netplan file.
+func NetworkConfig(config *bootstrapv1.NetworkConfig) ([]byte, error) {
+	network := netplanNetwork{
+		Version:   2,
+		Renderer:  string(config.Renderer),
+		Ethernets: map[string]netplanEthernet{},
+	}
+	for _, i := range config.Interfaces {
+		ethernet := netplanEthernet{
+			DHCP4:     i.DHCP4,
+			DHCP6:     i.DHCP6,
+			Addresses: i.Addresses,
+			Gateway4:  i.Gateway4,
+			Gateway6:  i.Gateway6,
+			MTU:       i.MTU,
+		}
+		if i.MACAddress != "" {
+			ethernet.Match = &netplanMatch{MACAddress: i.MACAddress}
+			ethernet.SetName = i.Name
+		}
+		if len(i.Nameservers) > 0 || len(i.SearchDomains) > 0 {
+			ethernet.Nameservers = &netplanNameservers{Addresses: i.Nameservers, Search: i.SearchDomains}
+		}
+		for _, r := range i.Routes {
+			ethernet.Routes = append(ethernet.Routes, netplanRoute{To: r.To, Via: r.Via, Metric: r.Metric})
+		}
+		network.Ethernets[i.Name] = ethernet
+	}
+
+	out, err := yaml.Marshal(netplanConfig{Network: network})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render the network configuration")
+	}
+	return out, nil
+}
+
+// prepareNetworkConfig adds the netplan file and the command applying it, if the network configuration is applied
+// with netplan; the command runs before the other commands, so the network is configured before anything is downloaded.
+func (input *BaseUserData) prepareNetworkConfig() error {
+	if input.NetworkConfig == nil || input.NetworkConfig.Mode != bootstrapv1.NetplanNetworkConfigMode {
+		return nil
+	}
+	content, err := NetworkConfig(input.NetworkConfig)
+	if err != nil {
+		return err
+	}
+	input.WriteFiles = append(input.WriteFiles, bootstrapv1.File{
+		Path:        netplanPath,
+		Owner:       netplanOwner,
+		Permissions: netplanPermissions,
+		Content:     string(content),
+	})
+	input.PreKubeadmCommands = append([]string{netplanCommand}, input.PreKubeadmCommands...)
+	return nil
+}
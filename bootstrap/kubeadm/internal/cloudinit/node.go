@@ -27,13 +27,15 @@ const (
 {{.JoinConfiguration | Indent 6}}
 runcmd:
 {{- template "commands" .PreKubeadmCommands }}
-  - {{ .KubeadmCommand }}
+{{- if .KubeadmUnitCommands }}{{ template "commands" .KubeadmUnitCommands }}{{ else }}
+  - {{ .KubeadmCommand }}{{ end }}
 {{- template "commands" .PostKubeadmCommands }}
 {{- template "ntp" .NTP }}
 {{- template "users" .Users }}
 {{- template "disk_setup" .DiskSetup}}
 {{- template "fs_setup" .DiskSetup}}
 {{- template "mounts" .Mounts}}
+{{- template "packages" .Packages}}
 `
 )
 
@@ -50,5 +52,5 @@ func NewNode(input *NodeInput) ([]byte, error) {
 	}
 	input.Header = cloudConfigHeader
 	input.WriteFiles = append(input.WriteFiles, input.AdditionalFiles...)
-	return generate("Node", nodeCloudInit, input)
+	return generateUserData("Node", nodeCloudInit, input, &input.BaseUserData)
 }
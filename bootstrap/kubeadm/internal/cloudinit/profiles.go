@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+)
+
+const (
+	containerdConfigPath      = "/etc/containerd/config.toml"
+	containerdConfigDropInDir = "/etc/containerd/conf.d"
+)
+
+var (
+	// containerdConfigCommands make containerd import the drop-in files written by the profiles, creating the default
+	// configuration if missing, and restart containerd so the configuration is applied before kubeadm runs.
+	containerdConfigCommands = []string{
+		fmt.Sprintf("test -f %[1]s || containerd config default > %[1]s", containerdConfigPath),
+		fmt.Sprintf(`grep -q '^imports' %[1]s || sed -i '1i imports = ["%[2]s/*.toml"]' %[1]s`, containerdConfigPath, containerdConfigDropInDir),
+		"systemctl restart containerd",
+	}
+)
+
+// nodeProfile is the set of prerequisites a NodeProfile expands to.
+type nodeProfile struct {
+	packages      []string
+	kernelModules []string
+	sysctls       map[string]string
+	// containerdConfig is a containerd configuration file imported by the main containerd configuration.
+	containerdConfig string
+	// commands are run after the kernel modules are loaded and the sysctls are applied.
+	commands []string
+}
+
+// nodeProfiles are the profiles that can be referenced by KubeadmConfigSpec.Profiles.
+var nodeProfiles = map[bootstrapv1.NodeProfile]nodeProfile{
+	bootstrapv1.NvidiaGPUProfile: {
+		packages:      []string{"nvidia-container-runtime"},
+		kernelModules: []string{"nvidia", "nvidia_uvm"},
+		containerdConfig: `version = 2
+[plugins."io.containerd.grpc.v1.cri".containerd]
+  default_runtime_name = "nvidia"
+[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia]
+  runtime_type = "io.containerd.runc.v2"
+[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.nvidia.options]
+  BinaryName = "/usr/bin/nvidia-container-runtime"
+`,
+	},
+	bootstrapv1.SELinuxEnforcingProfile: {
+		packages: []string{"container-selinux"},
+		containerdConfig: `version = 2
+[plugins."io.containerd.grpc.v1.cri"]
+  enable_selinux = true
+`,
+		commands: []string{
+			"sed -i 's/^SELINUX=.*/SELINUX=enforcing/' /etc/selinux/config",
+			"setenforce 1",
+		},
+	},
+	bootstrapv1.IPVSProfile: {
+		packages:      []string{"ipset", "ipvsadm"},
+		kernelModules: []string{"ip_vs", "ip_vs_rr", "ip_vs_wrr", "ip_vs_sh", "nf_conntrack"},
+	},
+}
+
+// applyProfiles expands the profiles into packages, kernel modules, sysctls, files and commands. The kernel modules and
+// sysctls of the profiles come before the ones set by the user, which take precedence, and the profile commands run
+// before PreKubeadmCommands.
+func (input *BaseUserData) applyProfiles() error {
+	if len(input.Profiles) == 0 {
+		return nil
+	}
+
+	var (
+		modules        []string
+		sysctls        = map[string]string{}
+		files          []bootstrapv1.File
+		commands       []string
+		seenPackages   = map[string]bool{}
+		seenModules    = map[string]bool{}
+		seenProfiles   = map[bootstrapv1.NodeProfile]bool{}
+		restartRuntime bool
+	)
+	for _, name := range input.Profiles {
+		if seenProfiles[name] {
+			continue
+		}
+		seenProfiles[name] = true
+
+		profile, ok := nodeProfiles[name]
+		if !ok {
+			return errors.Errorf("unknown node profile %q", name)
+		}
+		for _, p := range profile.packages {
+			if !seenPackages[p] {
+				seenPackages[p] = true
+				input.Packages = append(input.Packages, p)
+			}
+		}
+		for _, m := range profile.kernelModules {
+			if !seenModules[m] {
+				seenModules[m] = true
+				modules = append(modules, m)
+			}
+		}
+		for k, v := range profile.sysctls {
+			sysctls[k] = v
+		}
+		if profile.containerdConfig != "" {
+			files = append(files, bootstrapv1.File{
+				Path:        fmt.Sprintf("%s/%s.toml", containerdConfigDropInDir, name),
+				Owner:       kernelFilesOwner,
+				Permissions: kernelFilesPermissions,
+				Content:     profile.containerdConfig,
+			})
+			restartRuntime = true
+		}
+		commands = append(commands, profile.commands...)
+	}
+	if restartRuntime {
+		commands = append(commands, containerdConfigCommands...)
+	}
+
+	for _, m := range input.KernelModules {
+		if !seenModules[m] {
+			seenModules[m] = true
+			modules = append(modules, m)
+		}
+	}
+	for k, v := range input.Sysctls {
+		sysctls[k] = v
+	}
+
+	input.KernelModules = modules
+	input.Sysctls = sysctls
+	input.AdditionalFiles = append(files, input.AdditionalFiles...)
+	input.PreKubeadmCommands = append(commands, input.PreKubeadmCommands...)
+	return nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/shell"
+)
+
+const (
+	stagingFetchScriptPath = "/usr/local/bin/kubeadm-fetch-staged-files"
+	stagedFilesPath        = "/run/kubeadm/staged-files.sh"
+
+	stagingFetchScript = `#!/bin/sh
+# Fetches the files staged by the kubeadm bootstrap provider, verifies their checksum and writes them.
+umask 077
+payload={{ .Path }}
+mkdir -p "$(dirname "${payload}")"
+for i in $(seq 1 30); do
+  if ({{ .FetchCommand }}) > "${payload}" && echo "{{ .Checksum }}  ${payload}" | sha256sum -c - > /dev/null; then
+{{- if .CleanupCommand }}
+    ({{ .CleanupCommand }}) || true
+{{- end }}
+    sh "${payload}" || { rm -f "${payload}"; exit 1; }
+    rm -f "${payload}"
+    exit 0
+  fi
+  echo "failed to fetch the staged files, retrying in 10 seconds" >&2
+  sleep 10
+done
+rm -f "${payload}"
+echo "failed to fetch the staged files" >&2
+exit 1
+`
+)
+
+// StagingInput defines how the files of the user data are staged when the user data exceeds a size limit, i.e.
+// moved to a payload fetched at boot by a small script embedded in the user data.
+type StagingInput struct {
+	// MaxSize is the size, in bytes, above which the files are staged.
+	MaxSize int
+
+	// FetchCommand is the shell command writing the payload to its standard output.
+	FetchCommand string
+
+	// CleanupCommand is the shell command run as soon as the payload has been fetched and verified, if any.
+	CleanupCommand string
+
+	// Files are required by FetchCommand, e.g. a CA certificate, and are always embedded in the user data.
+	Files []bootstrapv1.File
+
+	// Payload is set, if the files have been staged, to the script writing them, which must be served by FetchCommand.
+	Payload []byte
+}
+
+// generateUserData generates the user data and, if staging is enabled and the user data exceeds the size limit,
+// stages the files and generates the user data again.
+func generateUserData(kind string, tpl string, data interface{}, input *BaseUserData) ([]byte, error) {
+	userData, err := generate(kind, tpl, data)
+	if err != nil || input.Staging == nil || len(userData) <= input.Staging.MaxSize {
+		return userData, err
+	}
+	if err := input.stageFiles(); err != nil {
+		return nil, err
+	}
+	return generate(kind, tpl, data)
+}
+
+// stageFiles moves the files to the staging payload, replacing them with the script fetching the payload,
+// which runs before any other command.
+func (input *BaseUserData) stageFiles() error {
+	payload := stagedFilesScript(input.WriteFiles)
+	fetchScript, err := generate("StagingFetchScript", stagingFetchScript, struct {
+		Path           string
+		FetchCommand   string
+		CleanupCommand string
+		Checksum       string
+	}{
+		Path:           stagedFilesPath,
+		FetchCommand:   input.Staging.FetchCommand,
+		CleanupCommand: input.Staging.CleanupCommand,
+		Checksum:       fmt.Sprintf("%x", sha256.Sum256(payload)),
+	})
+	if err != nil {
+		return err
+	}
+
+	input.Staging.Payload = payload
+	input.WriteFiles = append(append([]bootstrapv1.File{}, input.Staging.Files...), bootstrapv1.File{
+		Path:        stagingFetchScriptPath,
+		Owner:       "root:root",
+		Permissions: "0700",
+		Content:     string(fetchScript),
+	})
+	input.PreKubeadmCommands = append([]string{stagingFetchScriptPath}, input.PreKubeadmCommands...)
+	return nil
+}
+
+// stagedFilesScript returns a shell script writing the files with the same content, owner and permissions
+// cloud-init would use.
+func stagedFilesScript(files []bootstrapv1.File) []byte {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\nset -e\numask 077\n")
+	for _, f := range files {
+		content := f.Content
+		if f.Encoding != bootstrapv1.Base64 && f.Encoding != bootstrapv1.GzipBase64 {
+			content = base64.StdEncoding.EncodeToString([]byte(f.Content))
+		}
+		pipe := ""
+		if f.Encoding == bootstrapv1.Gzip || f.Encoding == bootstrapv1.GzipBase64 {
+			pipe = "| gunzip "
+		}
+		redirect := ">"
+		if f.Append {
+			redirect = ">>"
+		}
+		owner := f.Owner
+		if owner == "" {
+			owner = "root:root"
+		}
+		permissions := f.Permissions
+		if permissions == "" {
+			permissions = "0644"
+		}
+
+		path := shell.Quote(f.Path)
+		fmt.Fprintf(&b, "mkdir -p \"$(dirname %s)\"\n", path)
+		fmt.Fprintf(&b, "base64 -d <<'EOF' %s%s %s\n%s\nEOF\n", pipe, redirect, path, content)
+		fmt.Fprintf(&b, "chown %s %s\n", shell.Quote(owner), path)
+		fmt.Fprintf(&b, "chmod %s %s\n", shell.Quote(permissions), path)
+	}
+	return []byte(b.String())
+}
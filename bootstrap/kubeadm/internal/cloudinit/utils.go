@@ -32,3 +32,31 @@ func templateYAMLIndent(i int, input string) string {
 	ident := "\n" + strings.Repeat(" ", i)
 	return strings.Repeat(" ", i) + strings.Join(split, ident)
 }
+
+// skipPhasesFlag returns the kubeadm --skip-phases flag for the given phases, if any; duplicated phases are skipped once.
+func skipPhasesFlag(phases []string) string {
+	unique := []string{}
+	seen := map[string]bool{}
+	for _, p := range phases {
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		unique = append(unique, p)
+	}
+	if len(unique) == 0 {
+		return ""
+	}
+	return "--skip-phases=" + strings.Join(unique, ",")
+}
+
+// kubeadmFlags joins the non empty kubeadm flags.
+func kubeadmFlags(flags ...string) string {
+	nonEmpty := []string{}
+	for _, f := range flags {
+		if f != "" {
+			nonEmpty = append(nonEmpty, f)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package types implements the version-aware serialization of the kubeadm configuration types,
+// so the configuration files generated for a machine use the kubeadm API version supported by its Kubernetes version.
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+	kubeadmv1beta2 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta2"
+)
+
+var (
+	// v1beta2KubernetesVersion is the first Kubernetes version supporting the kubeadm v1beta2 API.
+	v1beta2KubernetesVersion = semver.MustParse("1.15.0")
+)
+
+// KubeadmAPIVersion returns the kubeadm API version to be used for the given Kubernetes version;
+// kubeadm v1beta1 is used if the version is empty or it cannot be parsed.
+func KubeadmAPIVersion(kubernetesVersion string) schema.GroupVersion {
+	if kubernetesVersion == "" {
+		return kubeadmv1beta1.GroupVersion
+	}
+	v, err := semver.ParseTolerant(kubernetesVersion)
+	if err != nil {
+		return kubeadmv1beta1.GroupVersion
+	}
+	// Pre-releases of v1.15 (e.g. v1.15.0-beta.1) already support kubeadm v1beta2.
+	v.Pre = nil
+	if v.GTE(v1beta2KubernetesVersion) {
+		return kubeadmv1beta2.GroupVersion
+	}
+	return kubeadmv1beta1.GroupVersion
+}
+
+// MarshalInitConfigurationForVersion converts an InitConfiguration to its YAML representation for the kubeadm API
+// version supported by the given Kubernetes version. Fields not supported by that API version are dropped.
+func MarshalInitConfigurationForVersion(obj *kubeadmv1beta1.InitConfiguration, kubernetesVersion string) (string, error) {
+	if KubeadmAPIVersion(kubernetesVersion) == kubeadmv1beta2.GroupVersion {
+		return marshalV1beta2(obj, &kubeadmv1beta2.InitConfiguration{})
+	}
+
+	obj = obj.DeepCopy()
+	obj.CertificateKey = ""
+	obj.SkipPhases = nil
+	dropV1beta2NodeRegistrationOptions(&obj.NodeRegistration)
+	return kubeadmv1beta1.ConfigurationToYAML(obj)
+}
+
+// MarshalClusterConfigurationForVersion converts a ClusterConfiguration to its YAML representation for the kubeadm API
+// version supported by the given Kubernetes version.
+func MarshalClusterConfigurationForVersion(obj *kubeadmv1beta1.ClusterConfiguration, kubernetesVersion string) (string, error) {
+	if KubeadmAPIVersion(kubernetesVersion) == kubeadmv1beta2.GroupVersion {
+		return marshalV1beta2(obj, &kubeadmv1beta2.ClusterConfiguration{})
+	}
+	return kubeadmv1beta1.ConfigurationToYAML(obj)
+}
+
+// MarshalJoinConfigurationForVersion converts a JoinConfiguration to its YAML representation for the kubeadm API
+// version supported by the given Kubernetes version. Fields not supported by that API version are dropped.
+func MarshalJoinConfigurationForVersion(obj *kubeadmv1beta1.JoinConfiguration, kubernetesVersion string) (string, error) {
+	if KubeadmAPIVersion(kubernetesVersion) == kubeadmv1beta2.GroupVersion {
+		return marshalV1beta2(obj, &kubeadmv1beta2.JoinConfiguration{})
+	}
+
+	obj = obj.DeepCopy()
+	obj.SkipPhases = nil
+	if obj.ControlPlane != nil {
+		obj.ControlPlane.CertificateKey = ""
+	}
+	dropV1beta2NodeRegistrationOptions(&obj.NodeRegistration)
+	return kubeadmv1beta1.ConfigurationToYAML(obj)
+}
+
+// dropV1beta2NodeRegistrationOptions removes from NodeRegistrationOptions the fields not supported by kubeadm v1beta1.
+func dropV1beta2NodeRegistrationOptions(obj *kubeadmv1beta1.NodeRegistrationOptions) {
+	obj.IgnorePreflightErrors = nil
+}
+
+// marshalV1beta2 converts a kubeadm v1beta1 object into the corresponding kubeadm v1beta2 object, and returns its
+// YAML representation. The conversion relies on the two API versions sharing the same serialized field names;
+// fields existing only in the v1beta1 types, like SkipPhases, are dropped.
+func marshalV1beta2(in runtime.Object, out runtime.Object) (string, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal configuration")
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return "", errors.Wrap(err, "failed to convert configuration to kubeadm v1beta2")
+	}
+	return kubeadmv1beta2.ConfigurationToYAML(out)
+}
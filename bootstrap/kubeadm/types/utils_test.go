@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+	kubeadmv1beta2 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta2"
+)
+
+func TestKubeadmAPIVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    schema.GroupVersion
+	}{
+		{
+			name:    "v1beta1 if the version is not set",
+			version: "",
+			want:    kubeadmv1beta1.GroupVersion,
+		},
+		{
+			name:    "v1beta1 if the version is not valid",
+			version: "latest",
+			want:    kubeadmv1beta1.GroupVersion,
+		},
+		{
+			name:    "v1beta1 for Kubernetes v1.14",
+			version: "v1.14.10",
+			want:    kubeadmv1beta1.GroupVersion,
+		},
+		{
+			name:    "v1beta2 for pre-releases of Kubernetes v1.15",
+			version: "v1.15.0-beta.1",
+			want:    kubeadmv1beta2.GroupVersion,
+		},
+		{
+			name:    "v1beta2 for Kubernetes v1.19",
+			version: "v1.19.1",
+			want:    kubeadmv1beta2.GroupVersion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(KubeadmAPIVersion(tt.version)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestMarshalInitConfigurationForVersion(t *testing.T) {
+	obj := &kubeadmv1beta1.InitConfiguration{
+		NodeRegistration: kubeadmv1beta1.NodeRegistrationOptions{
+			Name:                  "node",
+			IgnorePreflightErrors: []string{"some-preflight-check"},
+		},
+		CertificateKey: "secret",
+		SkipPhases:     []string{"addon/kube-proxy"},
+	}
+
+	tests := []struct {
+		name       string
+		version    string
+		want       []string
+		wantNotHas []string
+	}{
+		{
+			name:       "v1beta1 drops the fields not supported by kubeadm v1beta1",
+			version:    "v1.14.0",
+			want:       []string{"apiVersion: kubeadm.k8s.io/v1beta1", "kind: InitConfiguration", "name: node"},
+			wantNotHas: []string{"certificateKey", "ignorePreflightErrors", "skipPhases"},
+		},
+		{
+			name:       "v1beta2 keeps the fields supported by kubeadm v1beta2",
+			version:    "v1.16.2",
+			want:       []string{"apiVersion: kubeadm.k8s.io/v1beta2", "kind: InitConfiguration", "name: node", "certificateKey: secret", "- some-preflight-check"},
+			wantNotHas: []string{"skipPhases"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := MarshalInitConfigurationForVersion(obj, tt.version)
+			g.Expect(err).NotTo(HaveOccurred())
+			for _, s := range tt.want {
+				g.Expect(got).To(ContainSubstring(s))
+			}
+			for _, s := range tt.wantNotHas {
+				g.Expect(got).NotTo(ContainSubstring(s))
+			}
+		})
+	}
+
+	// The original object is not modified.
+	g := NewWithT(t)
+	g.Expect(obj.CertificateKey).To(Equal("secret"))
+	g.Expect(obj.SkipPhases).To(Equal([]string{"addon/kube-proxy"}))
+}
+
+func TestMarshalJoinConfigurationForVersion(t *testing.T) {
+	obj := &kubeadmv1beta1.JoinConfiguration{
+		ControlPlane: &kubeadmv1beta1.JoinControlPlane{
+			CertificateKey: "secret",
+		},
+		SkipPhases: []string{"preflight"},
+	}
+
+	g := NewWithT(t)
+
+	got, err := MarshalJoinConfigurationForVersion(obj, "v1.14.0")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(ContainSubstring("apiVersion: kubeadm.k8s.io/v1beta1"))
+	g.Expect(got).NotTo(ContainSubstring("certificateKey"))
+	g.Expect(got).NotTo(ContainSubstring("skipPhases"))
+
+	got, err = MarshalJoinConfigurationForVersion(obj, "v1.18.0")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(ContainSubstring("apiVersion: kubeadm.k8s.io/v1beta2"))
+	g.Expect(got).To(ContainSubstring("certificateKey: secret"))
+	g.Expect(got).NotTo(ContainSubstring("skipPhases"))
+}
+
+func TestMarshalClusterConfigurationForVersion(t *testing.T) {
+	obj := &kubeadmv1beta1.ClusterConfiguration{
+		KubernetesVersion: "v1.18.0",
+		APIServer: kubeadmv1beta1.APIServer{
+			ControlPlaneComponent: kubeadmv1beta1.ControlPlaneComponent{
+				ExtraVolumes: []kubeadmv1beta1.HostPathMount{
+					{Name: "audit", HostPath: "/etc/audit", MountPath: "/etc/audit", PathType: "DirectoryOrCreate"},
+				},
+			},
+		},
+	}
+
+	g := NewWithT(t)
+
+	got, err := MarshalClusterConfigurationForVersion(obj, "v1.18.0")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(ContainSubstring("apiVersion: kubeadm.k8s.io/v1beta2"))
+	g.Expect(got).To(ContainSubstring("kind: ClusterConfiguration"))
+	g.Expect(got).To(ContainSubstring("pathType: DirectoryOrCreate"))
+
+	got, err = MarshalClusterConfigurationForVersion(obj, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(ContainSubstring("apiVersion: kubeadm.k8s.io/v1beta1"))
+}
@@ -63,6 +63,49 @@ func (co ConfigOwner) DataSecretName() *string {
 	return &dataSecretName
 }
 
+// KubernetesVersion returns the Kubernetes version for the config owner object.
+func (co ConfigOwner) KubernetesVersion() string {
+	fields := []string{"spec", "version"}
+	if co.IsMachinePool() {
+		fields = []string{"spec", "template", "spec", "version"}
+	}
+
+	version, _, err := unstructured.NestedString(co.Object, fields...)
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+// ProviderID extracts spec.providerID from the config owner; it is empty for MachinePools.
+func (co ConfigOwner) ProviderID() string {
+	if co.IsMachinePool() {
+		return ""
+	}
+	providerID, _, err := unstructured.NestedString(co.Object, "spec", "providerID")
+	if err != nil {
+		return ""
+	}
+	return providerID
+}
+
+// FailureDomain extracts spec.failureDomain from the config owner; it is empty for MachinePools.
+func (co ConfigOwner) FailureDomain() string {
+	if co.IsMachinePool() {
+		return ""
+	}
+	failureDomain, _, err := unstructured.NestedString(co.Object, "spec", "failureDomain")
+	if err != nil {
+		return ""
+	}
+	return failureDomain
+}
+
+// IsMachinePool checks if an unstructured object is a MachinePool.
+func (co ConfigOwner) IsMachinePool() bool {
+	return co.GetKind() == "MachinePool"
+}
+
 // IsControlPlaneMachine checks if an unstructured object is Machine with the control plane role.
 func (co ConfigOwner) IsControlPlaneMachine() bool {
 	if co.GetKind() != "Machine" {
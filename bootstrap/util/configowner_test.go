@@ -51,6 +51,7 @@ func TestGetConfigOwner(t *testing.T) {
 			},
 			Spec: clusterv1.MachineSpec{
 				ClusterName: "my-cluster",
+				Version:     pointer.StringPtr("v1.19.6"),
 				Bootstrap: clusterv1.Bootstrap{
 					DataSecretName: pointer.StringPtr("my-data-secret"),
 				},
@@ -81,6 +82,7 @@ func TestGetConfigOwner(t *testing.T) {
 		g.Expect(configOwner.IsInfrastructureReady()).To(BeTrue())
 		g.Expect(configOwner.IsControlPlaneMachine()).To(BeTrue())
 		g.Expect(*configOwner.DataSecretName()).To(BeEquivalentTo("my-data-secret"))
+		g.Expect(configOwner.KubernetesVersion()).To(Equal("v1.19.6"))
 	})
 
 	t.Run("should get the owner when present (MachinePool)", func(t *testing.T) {
@@ -97,6 +99,11 @@ func TestGetConfigOwner(t *testing.T) {
 			},
 			Spec: expv1.MachinePoolSpec{
 				ClusterName: "my-cluster",
+				Template: clusterv1.MachineTemplateSpec{
+					Spec: clusterv1.MachineSpec{
+						Version: pointer.StringPtr("v1.19.6"),
+					},
+				},
 			},
 			Status: expv1.MachinePoolStatus{
 				InfrastructureReady: true,
@@ -124,6 +131,7 @@ func TestGetConfigOwner(t *testing.T) {
 		g.Expect(configOwner.IsInfrastructureReady()).To(BeTrue())
 		g.Expect(configOwner.IsControlPlaneMachine()).To(BeFalse())
 		g.Expect(configOwner.DataSecretName()).To(BeNil())
+		g.Expect(configOwner.KubernetesVersion()).To(Equal("v1.19.6"))
 	})
 
 	t.Run("return an error when not found", func(t *testing.T) {
@@ -30,6 +30,16 @@ const (
 	// Example: resources shared between instances of the same provider:  CRDs,
 	// ValidatingWebhookConfiguration, MutatingWebhookConfiguration, and so on.
 	ClusterctlResourceLifecyleLabelName = "clusterctl.cluster.x-k8s.io/lifecycle"
+
+	// ClusterctlProviderVersionLabelName is applied to all the provider components, and it contains the version of the
+	// provider that applied the component.
+	ClusterctlProviderVersionLabelName = "clusterctl.cluster.x-k8s.io/provider-version"
+
+	// ClusterctlInstanceLabelName is applied to all the instance specific provider components, and it contains the
+	// namespace where the provider instance is installed; together with the cluster.x-k8s.io/provider label, it
+	// uniquely identifies the provider instance a component belongs to.
+	// NB. This label is not applied to resources shared between instances of the same provider.
+	ClusterctlInstanceLabelName = "clusterctl.cluster.x-k8s.io/instance"
 )
 
 // ResourceLifecycle configures the lifecycle of a resource
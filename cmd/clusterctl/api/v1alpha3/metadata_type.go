@@ -30,6 +30,11 @@ type Metadata struct {
 
 	// +optional
 	ReleaseSeries []ReleaseSeries `json:"releaseSeries"`
+
+	// Templates lists the workload cluster templates published in the provider repository, so they can be
+	// discovered without knowing the flavor names in advance.
+	// +optional
+	Templates []TemplateMetadata `json:"templates,omitempty"`
 }
 
 // ReleaseSeries maps a provider release series (major/minor) with a API Version of Cluster API (contract).
@@ -44,6 +49,32 @@ type ReleaseSeries struct {
 	//
 	// The value is an API Version, e.g. `v1alpha3`.
 	Contract string `json:"contract,omitempty"`
+
+	// Deprecated marks the release series as deprecated; clusterctl warns the users selecting one of its versions.
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// EndOfLife is the date, in the YYYY-MM-DD format, from which the release series is no longer supported;
+	// clusterctl warns the users selecting one of its versions from that date.
+	EndOfLife string `json:"endOfLife,omitempty"`
+}
+
+// TemplateMetadata describes a workload cluster template published in a provider repository.
+type TemplateMetadata struct {
+	// Flavor of the template, that is the name of the cluster-template-<flavor>.yaml file.
+	// An empty value identifies the default cluster-template.yaml file.
+	Flavor string `json:"flavor,omitempty"`
+
+	// Description of the template.
+	Description string `json:"description,omitempty"`
+
+	// MinKubernetesVersion is the oldest Kubernetes minor version supported by the template, e.g. v1.17.
+	MinKubernetesVersion string `json:"minKubernetesVersion,omitempty"`
+
+	// MaxKubernetesVersion is the newest Kubernetes minor version supported by the template, e.g. v1.18.
+	MaxKubernetesVersion string `json:"maxKubernetesVersion,omitempty"`
+
+	// Tags for searching the template, e.g. ha, windows or private-network.
+	Tags []string `json:"tags,omitempty"`
 }
 
 func init() {
@@ -51,6 +51,11 @@ type Provider struct {
 	// if empty the provider controller is watching for objects in all namespaces.
 	// +optional
 	WatchedNamespace string `json:"watchedNamespace,omitempty"`
+
+	// FeatureGates indicates the feature gates of the provider controller set by clusterctl, overriding the
+	// values defined in the provider components.
+	// +optional
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
 }
 
 // ManifestLabel returns the cluster.x-k8s.io/provider label value for an entry in the provider inventory.
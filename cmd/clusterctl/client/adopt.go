@@ -0,0 +1,282 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/container"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AdoptOptions carries the options supported by Adopt.
+type AdoptOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// DryRun returns the providers to be adopted without changing the management cluster.
+	DryRun bool
+}
+
+// Adopt adds to the inventory the providers installed in the management cluster without clusterctl, e.g. with kubectl apply,
+// so they can be upgraded, moved and deleted by clusterctl; it returns the inventory objects for the adopted providers.
+//
+// Providers are detected from the Deployments with the "cluster.x-k8s.io/provider" label; the provider name and type are
+// inferred from the label value, the version from the image tag of the manager container, and the watching namespace
+// from the --namespace flag of the manager container. The components of the adopted providers are labeled with the
+// "clusterctl.cluster.x-k8s.io" label, as if they were installed by clusterctl.
+func (c *clusterctlClient) Adopt(options AdoptOptions) ([]clusterctlv1.Provider, error) {
+	log := logf.Log
+
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	if !options.DryRun {
+		if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+
+	providers, err := c.discoverProviders(clusterClient)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.DryRun {
+		return providers, nil
+	}
+
+	refs := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		log.Info("Adopting", "Provider", provider.Name, "Version", provider.Version, "TargetNamespace", provider.Namespace)
+		if err := labelProviderComponents(clusterClient.Proxy(), provider); err != nil {
+			return nil, err
+		}
+		if err := clusterClient.ProviderInventory().Create(provider); err != nil {
+			return nil, err
+		}
+		refs = append(refs, cluster.AuditProviderRef(provider, provider.Version))
+	}
+
+	if len(refs) > 0 {
+		// Records the operation in the audit log of the management cluster.
+		recordOperation(clusterClient, cluster.AuditAdoptOperation, refs, nil)
+	}
+
+	return providers, nil
+}
+
+// discoverProviders returns the inventory objects for the providers installed in the cluster and not yet in the inventory.
+func (c *clusterctlClient) discoverProviders(clusterClient cluster.Client) ([]clusterctlv1.Provider, error) {
+	log := logf.Log
+
+	cl, err := clusterClient.Proxy().NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := cl.List(context.Background(), deployments, client.HasLabels{clusterv1.ProviderLabelName}); err != nil {
+		return nil, errors.Wrap(err, "failed to list the provider Deployments")
+	}
+
+	installed := map[string]bool{}
+	if inventory, err := clusterClient.ProviderInventory().List(); err == nil {
+		for _, p := range inventory.Items {
+			installed[p.InstanceName()] = true
+		}
+	}
+
+	var providers []clusterctlv1.Provider
+	var errList []error
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		// The webhook Deployments are shared by all the instances of a provider, and they are adopted together with
+		// the components of the provider's instance.
+		if d.Namespace == repository.WebhookNamespaceName {
+			continue
+		}
+
+		provider, err := providerFromDeployment(d)
+		if err != nil {
+			errList = append(errList, err)
+			continue
+		}
+
+		if installed[provider.InstanceName()] {
+			continue
+		}
+		installed[provider.InstanceName()] = true
+
+		if _, err := c.configClient.Providers().Get(provider.ProviderName, provider.GetProviderType()); err != nil {
+			log.Info("The provider is not defined in the clusterctl configuration; upgrades will not be possible until it is added", "Provider", provider.InstanceName())
+		}
+		providers = append(providers, provider)
+	}
+	if len(errList) > 0 {
+		return nil, kerrors.NewAggregate(errList)
+	}
+	return providers, nil
+}
+
+// providerFromDeployment infers the inventory object for a provider from its controller Deployment.
+func providerFromDeployment(d *appsv1.Deployment) (clusterctlv1.Provider, error) {
+	manifestLabel := d.Labels[clusterv1.ProviderLabelName]
+	name, providerType := parseManifestLabel(manifestLabel)
+	if name == "" {
+		return clusterctlv1.Provider{}, errors.Errorf("invalid %q label %q on Deployment %s/%s", clusterv1.ProviderLabelName, manifestLabel, d.Namespace, d.Name)
+	}
+
+	manager := managerContainer(d.Spec.Template.Spec.Containers)
+	if manager == nil {
+		return clusterctlv1.Provider{}, errors.Errorf("failed to identify the manager container of Deployment %s/%s", d.Namespace, d.Name)
+	}
+
+	image, err := container.ImageFromString(manager.Image)
+	if err != nil {
+		return clusterctlv1.Provider{}, errors.Wrapf(err, "failed to parse the image of Deployment %s/%s", d.Namespace, d.Name)
+	}
+	if _, err := util.ParseMajorMinorPatch(image.Tag); err != nil {
+		return clusterctlv1.Provider{}, errors.Errorf("failed to infer the version of the %s provider from the %q image of Deployment %s/%s: the image tag is not a semantic version", manifestLabel, manager.Image, d.Namespace, d.Name)
+	}
+
+	labels := map[string]string{
+		clusterctlv1.ClusterctlLabelName:     "",
+		clusterv1.ProviderLabelName:          manifestLabel,
+		clusterctlv1.ClusterctlCoreLabelName: "inventory",
+	}
+	return clusterctlv1.Provider{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterctlv1.GroupVersion.String(),
+			Kind:       "Provider",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: d.Namespace,
+			Name:      manifestLabel,
+			Labels:    labels,
+		},
+		ProviderName:     name,
+		Type:             string(providerType),
+		Version:          image.Tag,
+		WatchedNamespace: watchingNamespace(manager.Args),
+	}, nil
+}
+
+// parseManifestLabel returns the provider name and type from a "cluster.x-k8s.io/provider" label value; this is the
+// reverse of clusterctlv1.ManifestLabel.
+func parseManifestLabel(label string) (string, clusterctlv1.ProviderType) {
+	prefixes := []struct {
+		prefix       string
+		providerType clusterctlv1.ProviderType
+	}{
+		{"bootstrap-", clusterctlv1.BootstrapProviderType},
+		{"control-plane-", clusterctlv1.ControlPlaneProviderType},
+		{"infrastructure-", clusterctlv1.InfrastructureProviderType},
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(label, p.prefix) {
+			return strings.TrimPrefix(label, p.prefix), p.providerType
+		}
+	}
+	return label, clusterctlv1.CoreProviderType
+}
+
+// managerContainer returns the container running the provider controller: the container named "manager", or the
+// only container if there is just one.
+func managerContainer(containers []corev1.Container) *corev1.Container {
+	for i := range containers {
+		if containers[i].Name == "manager" {
+			return &containers[i]
+		}
+	}
+	if len(containers) == 1 {
+		return &containers[0]
+	}
+	return nil
+}
+
+// watchingNamespace returns the value of the --namespace flag, if any.
+func watchingNamespace(args []string) string {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "--namespace=") {
+			return strings.TrimPrefix(arg, "--namespace=")
+		}
+		if arg == "--namespace" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// labelProviderComponents adds the "clusterctl.cluster.x-k8s.io" label to all the components of a provider, so they are
+// managed by clusterctl like the components of providers installed by clusterctl.
+func labelProviderComponents(proxy cluster.Proxy, provider clusterctlv1.Provider) error {
+	labels := map[string]string{
+		clusterv1.ProviderLabelName: provider.ManifestLabel(),
+	}
+	objs, err := proxy.ListResources(labels, provider.Namespace, repository.WebhookNamespaceName)
+	if err != nil {
+		return err
+	}
+
+	cl, err := proxy.NewClient()
+	if err != nil {
+		return err
+	}
+	for i := range objs {
+		obj := &objs[i]
+		if _, ok := obj.GetLabels()[clusterctlv1.ClusterctlLabelName]; ok {
+			continue
+		}
+		// Namespaces other than the provider namespace are not labeled, because they could host other components.
+		if obj.GetKind() == "Namespace" && obj.GetName() != provider.Namespace {
+			continue
+		}
+		if err := addClusterctlLabel(cl, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addClusterctlLabel adds the "clusterctl.cluster.x-k8s.io" label to an object.
+func addClusterctlLabel(cl client.Client, obj *unstructured.Unstructured) error {
+	patch := client.MergeFrom(obj.DeepCopy())
+	labels := obj.GetLabels()
+	labels[clusterctlv1.ClusterctlLabelName] = ""
+	obj.SetLabels(labels)
+	if err := cl.Patch(context.Background(), obj, patch); err != nil {
+		return errors.Wrapf(err, "failed to label %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+	}
+	return nil
+}
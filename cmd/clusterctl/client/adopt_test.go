@@ -0,0 +1,206 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func providerDeployment(namespace, manifestLabel, image string, args ...string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      "controller-manager",
+			Labels: map[string]string{
+				clusterv1.ProviderLabelName: manifestLabel,
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "kube-rbac-proxy", Image: "gcr.io/kubebuilder/kube-rbac-proxy:v0.4.1"},
+						{Name: "manager", Image: image, Args: args},
+					},
+				},
+			},
+		},
+	}
+}
+
+func Test_clusterctlClient_Adopt(t *testing.T) {
+	tests := []struct {
+		name          string
+		objs          []runtime.Object
+		inventory     bool
+		dryRun        bool
+		wantProviders []clusterctlv1.Provider
+		wantInventory []string
+		wantErr       bool
+	}{
+		{
+			name: "adopts the providers installed without clusterctl",
+			objs: []runtime.Object{
+				providerDeployment("capi-system", "cluster-api", "us.gcr.io/k8s-artifacts-prod/cluster-api/cluster-api-controller:v0.3.9", "--metrics-addr=127.0.0.1:8080"),
+				providerDeployment("infra-system", "infrastructure-infra", "gcr.io/infra/infra-controller:v1.2.0", "--namespace=foo"),
+			},
+			wantProviders: []clusterctlv1.Provider{
+				{ObjectMeta: metav1.ObjectMeta{Namespace: "capi-system", Name: "cluster-api"}, ProviderName: "cluster-api", Type: string(clusterctlv1.CoreProviderType), Version: "v0.3.9"},
+				{ObjectMeta: metav1.ObjectMeta{Namespace: "infra-system", Name: "infrastructure-infra"}, ProviderName: "infra", Type: string(clusterctlv1.InfrastructureProviderType), Version: "v1.2.0", WatchedNamespace: "foo"},
+			},
+			wantInventory: []string{"capi-system/cluster-api", "infra-system/infrastructure-infra"},
+		},
+		{
+			name: "ignores the webhook Deployments",
+			objs: []runtime.Object{
+				providerDeployment("capi-system", "cluster-api", "us.gcr.io/k8s-artifacts-prod/cluster-api/cluster-api-controller:v0.3.9"),
+				providerDeployment(repository.WebhookNamespaceName, "cluster-api", "us.gcr.io/k8s-artifacts-prod/cluster-api/cluster-api-controller:v0.3.9", "--webhook-port=9443"),
+			},
+			wantProviders: []clusterctlv1.Provider{
+				{ObjectMeta: metav1.ObjectMeta{Namespace: "capi-system", Name: "cluster-api"}, ProviderName: "cluster-api", Type: string(clusterctlv1.CoreProviderType), Version: "v0.3.9"},
+			},
+			wantInventory: []string{"capi-system/cluster-api"},
+		},
+		{
+			name: "ignores the providers already in the inventory",
+			objs: []runtime.Object{
+				providerDeployment("capi-system", "cluster-api", "us.gcr.io/k8s-artifacts-prod/cluster-api/cluster-api-controller:v0.3.9"),
+				providerDeployment("capbpk-system", "bootstrap-kubeadm", "us.gcr.io/k8s-artifacts-prod/cluster-api/kubeadm-bootstrap-controller:v0.3.9"),
+			},
+			inventory: true,
+			wantProviders: []clusterctlv1.Provider{
+				{ObjectMeta: metav1.ObjectMeta{Namespace: "capbpk-system", Name: "bootstrap-kubeadm"}, ProviderName: "kubeadm", Type: string(clusterctlv1.BootstrapProviderType), Version: "v0.3.9"},
+			},
+			wantInventory: []string{"capi-system/cluster-api", "capbpk-system/bootstrap-kubeadm"},
+		},
+		{
+			name: "does not change the cluster in dry run mode",
+			objs: []runtime.Object{
+				providerDeployment("capi-system", "cluster-api", "us.gcr.io/k8s-artifacts-prod/cluster-api/cluster-api-controller:v0.3.9"),
+			},
+			dryRun: true,
+			wantProviders: []clusterctlv1.Provider{
+				{ObjectMeta: metav1.ObjectMeta{Namespace: "capi-system", Name: "cluster-api"}, ProviderName: "cluster-api", Type: string(clusterctlv1.CoreProviderType), Version: "v0.3.9"},
+			},
+			wantInventory: []string{},
+		},
+		{
+			name: "fails if the version cannot be inferred from the image",
+			objs: []runtime.Object{
+				providerDeployment("capi-system", "cluster-api", "us.gcr.io/k8s-artifacts-prod/cluster-api/cluster-api-controller:latest"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			config1 := newFakeConfig().
+				WithProvider(capiProviderConfig).
+				WithProvider(bootstrapProviderConfig).
+				WithProvider(infraProviderConfig)
+			kubeconfig := cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}
+			cluster1 := newFakeCluster(kubeconfig, config1).WithObjs(tt.objs...)
+			if tt.inventory {
+				cluster1.WithProviderInventory(capiProviderConfig.Name(), capiProviderConfig.Type(), "v0.3.9", "capi-system", "")
+			}
+			c := newFakeClient(config1).WithCluster(cluster1)
+
+			got, err := c.Adopt(AdoptOptions{
+				Kubeconfig: Kubeconfig(kubeconfig),
+				DryRun:     tt.dryRun,
+			})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			g.Expect(got).To(HaveLen(len(tt.wantProviders)))
+			for i := range tt.wantProviders {
+				g.Expect(got[i].Namespace).To(Equal(tt.wantProviders[i].Namespace))
+				g.Expect(got[i].Name).To(Equal(tt.wantProviders[i].Name))
+				g.Expect(got[i].ProviderName).To(Equal(tt.wantProviders[i].ProviderName))
+				g.Expect(got[i].Type).To(Equal(tt.wantProviders[i].Type))
+				g.Expect(got[i].Version).To(Equal(tt.wantProviders[i].Version))
+				g.Expect(got[i].WatchedNamespace).To(Equal(tt.wantProviders[i].WatchedNamespace))
+			}
+
+			cl, err := cluster1.Proxy().NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			inventory := &clusterctlv1.ProviderList{}
+			g.Expect(cl.List(context.Background(), inventory)).To(Succeed())
+			gotInventory := []string{}
+			for _, p := range inventory.Items {
+				gotInventory = append(gotInventory, p.InstanceName())
+			}
+			g.Expect(gotInventory).To(ConsistOf(tt.wantInventory))
+
+			// The components of the adopted providers are managed by clusterctl.
+			for _, p := range got {
+				d := &unstructured.Unstructured{}
+				d.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+				g.Expect(cl.Get(context.Background(), client.ObjectKey{Namespace: p.Namespace, Name: "controller-manager"}, d)).To(Succeed())
+				_, hasLabel := d.GetLabels()[clusterctlv1.ClusterctlLabelName]
+				g.Expect(hasLabel).To(Equal(!tt.dryRun))
+			}
+		})
+	}
+}
+
+func Test_parseManifestLabel(t *testing.T) {
+	tests := []struct {
+		label    string
+		wantName string
+		wantType clusterctlv1.ProviderType
+	}{
+		{label: "cluster-api", wantName: "cluster-api", wantType: clusterctlv1.CoreProviderType},
+		{label: "bootstrap-kubeadm", wantName: "kubeadm", wantType: clusterctlv1.BootstrapProviderType},
+		{label: "control-plane-kubeadm", wantName: "kubeadm", wantType: clusterctlv1.ControlPlaneProviderType},
+		{label: "infrastructure-aws", wantName: "aws", wantType: clusterctlv1.InfrastructureProviderType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			g := NewWithT(t)
+
+			name, providerType := parseManifestLabel(tt.label)
+			g.Expect(name).To(Equal(tt.wantName))
+			g.Expect(providerType).To(Equal(tt.wantType))
+			g.Expect(clusterctlv1.ManifestLabel(name, providerType)).To(Equal(tt.label))
+		})
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package client
 
 import (
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/bootstrap"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
@@ -38,12 +39,35 @@ type ComponentsOptions repository.ComponentsOptions
 // Template wraps a YAML file that defines the cluster objects (Cluster, Machines etc.).
 type Template repository.Template
 
+// TemplatePatch defines a patch to be applied to the objects of a workload cluster template after the template is rendered.
+type TemplatePatch repository.TemplatePatch
+
 // UpgradePlan defines a list of possible upgrade targets for a management group.
 type UpgradePlan cluster.UpgradePlan
 
+// WorkloadClusterUpgradePlan defines the objects of a workload cluster to be upgraded to a new Kubernetes version.
+type WorkloadClusterUpgradePlan cluster.WorkloadClusterUpgradePlan
+
+// MachineSSHEndpoint describes how to reach a Machine over SSH, as published by its infrastructure provider.
+type MachineSSHEndpoint cluster.MachineSSHEndpoint
+
+// ObjectGraph is the graph of the Cluster API objects existing in a management cluster, with their ownership relations
+// and status.
+type ObjectGraph cluster.ObjectGraph
+
+// MovePlan describes the objects a move would move to the target management cluster and the objects that would stay
+// in the source management cluster.
+type MovePlan cluster.MovePlan
+
+// ProviderDeletePlan describes the components of a provider instance that would be deleted from the management cluster.
+type ProviderDeletePlan cluster.ProviderDeletePlan
+
 // Kubeconfig is a type that specifies inputs related to the actual kubeconfig.
 type Kubeconfig cluster.Kubeconfig
 
+// BootstrapClusterProvider creates and deletes the temporary clusters used for bootstrapping self-hosted management clusters.
+type BootstrapClusterProvider bootstrap.Provider
+
 // Processor defines the methods necessary for creating a specific yaml
 // processor.
 type Processor yaml.Processor
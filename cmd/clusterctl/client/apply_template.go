@@ -0,0 +1,79 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+// ApplyClusterTemplateOptions carries the options supported by ApplyClusterTemplate.
+type ApplyClusterTemplateOptions struct {
+	// GetClusterTemplateOptions defines the workload cluster template to apply, and the management cluster to apply
+	// it to. The target namespace is always created, if it does not exist, while ListVariablesOnly is not supported.
+	GetClusterTemplateOptions
+
+	// WaitForProvisioned sets the ApplyClusterTemplate method to wait for the Clusters defined in the template to
+	// reach the Provisioned phase.
+	WaitForProvisioned bool
+
+	// WaitTimeout is the time to wait for each Cluster to be provisioned.
+	// If unspecified, cluster.DefaultClusterProvisioningTimeout is used.
+	WaitTimeout time.Duration
+}
+
+func (c *clusterctlClient) ApplyClusterTemplate(options ApplyClusterTemplateOptions) ([]corev1.ObjectReference, error) {
+	if options.ListVariablesOnly {
+		return nil, errors.New("the ListVariablesOnly option can not be used when applying a cluster template")
+	}
+
+	// Renders the template, creating the target namespace so the template objects can be applied.
+	options.CreateNamespace = true
+	template, err := c.GetClusterTemplate(options.GetClusterTemplateOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := clusterClient.ClusterProvisioning().Apply(template.Objs())
+	if err != nil {
+		return refs, err
+	}
+
+	if !options.WaitForProvisioned {
+		return refs, nil
+	}
+
+	clusterGroupKind := schema.GroupKind{Group: clusterv1.GroupVersion.Group, Kind: "Cluster"}
+	for _, ref := range refs {
+		if ref.GroupVersionKind().GroupKind() != clusterGroupKind {
+			continue
+		}
+		if err := clusterClient.ClusterProvisioning().WaitForProvisioned(ref.Namespace, ref.Name, options.WaitTimeout); err != nil {
+			return refs, err
+		}
+	}
+	return refs, nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_clusterctlClient_ApplyClusterTemplate(t *testing.T) {
+	g := NewWithT(t)
+
+	rawTemplate := []byte(`apiVersion: cluster.x-k8s.io/v1alpha3
+kind: Cluster
+metadata:
+  name: ${ CLUSTER_NAME }
+  namespace: ns1
+`)
+
+	tmpDir, err := ioutil.TempDir("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "cluster-template.yaml")
+	g.Expect(ioutil.WriteFile(path, rawTemplate, 0600)).To(Succeed())
+
+	tests := []struct {
+		name              string
+		listVariablesOnly bool
+		wantErr           bool
+	}{
+		{
+			name: "applies the template to the management cluster",
+		},
+		{
+			name:              "fails if only the list of variables is requested",
+			listVariablesOnly: true,
+			wantErr:           true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			config1 := newFakeConfig()
+			cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1)
+			c := newFakeClient(config1).WithCluster(cluster1)
+
+			refs, err := c.ApplyClusterTemplate(ApplyClusterTemplateOptions{
+				GetClusterTemplateOptions: GetClusterTemplateOptions{
+					Kubeconfig:               Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					URLSource:                &URLSourceOptions{URL: path},
+					ClusterName:              "test",
+					TargetNamespace:          "ns1",
+					ControlPlaneMachineCount: pointer.Int64Ptr(1),
+					ListVariablesOnly:        tt.listVariablesOnly,
+				},
+			})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(refs).To(HaveLen(1))
+			g.Expect(refs[0].Kind).To(Equal("Cluster"))
+			g.Expect(refs[0].Name).To(Equal("test"))
+
+			// The target namespace is created, and the Cluster is applied.
+			exists, err := cluster1.Namespaces().Check("ns1")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(exists).To(BeTrue())
+
+			cl, err := cluster1.Proxy().NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cl.Get(context.Background(), client.ObjectKey{Namespace: "ns1", Name: "test"}, &clusterv1.Cluster{})).To(Succeed())
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"os"
+	"os/user"
+
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/cluster-api/cmd/version"
+)
+
+// recordOperation adds a record for a clusterctl operation to the audit log stored in the management cluster.
+// Nb. The audit log is a best effort support for operators, so failing to record an operation does not make the
+// operation itself fail.
+func recordOperation(clusterClient cluster.Client, operation cluster.AuditOperation, providers []string, details map[string]string) {
+	log := logf.Log
+
+	record := cluster.AuditRecord{
+		Operation:         operation,
+		User:              currentUser(),
+		ClusterctlVersion: version.Get().GitVersion,
+		Providers:         providers,
+		Details:           details,
+	}
+	if err := clusterClient.Audit().Record(record); err != nil {
+		log.V(1).Info("Failed to record the operation in the audit log", "Operation", operation, "Cause", err.Error())
+	}
+}
+
+// recordInitOperation records the installation of a set of provider components in the audit log.
+func recordInitOperation(clusterClient cluster.Client, components []repository.Components) {
+	refs := make([]string, 0, len(components))
+	for _, c := range components {
+		refs = append(refs, cluster.AuditProviderRef(c.InventoryObject(), c.Version()))
+	}
+	recordOperation(clusterClient, cluster.AuditInitOperation, refs, nil)
+}
+
+// inventoryProviderRefs returns the audit references for all the providers in the inventory of the management cluster.
+func inventoryProviderRefs(clusterClient cluster.Client) []string {
+	providerList, err := clusterClient.ProviderInventory().List()
+	if err != nil {
+		return nil
+	}
+
+	refs := make([]string, 0, len(providerList.Items))
+	for _, p := range providerList.Items {
+		refs = append(refs, cluster.AuditProviderRef(p, p.Version))
+	}
+	return refs
+}
+
+// managementClusterRef returns the reference used for identifying a management cluster in an audit record, i.e.
+// the address of its API server or, if not available, the name of the kubeconfig context; the kubeconfig path is
+// not used, because it is meaningful only on the machine where clusterctl runs.
+func managementClusterRef(clusterClient cluster.Client) string {
+	if config, err := clusterClient.Proxy().GetConfig(); err == nil && config != nil && config.Host != "" {
+		return config.Host
+	}
+	return clusterClient.Kubeconfig().Context
+}
+
+// currentUser returns the name of the user running clusterctl.
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
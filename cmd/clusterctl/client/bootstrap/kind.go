@@ -0,0 +1,135 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+)
+
+const (
+	// defaultKindBinary is the kind CLI used if not configured otherwise.
+	defaultKindBinary = "kind"
+
+	// defaultKindWaitTimeout is the time to wait for the control plane of the kind cluster to be ready.
+	defaultKindWaitTimeout = 5 * time.Minute
+)
+
+// commandRunner runs a command and returns its combined output.
+type commandRunner func(name string, args ...string) ([]byte, error)
+
+func runCommand(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// kindProvider implements Provider using the kind CLI (https://kind.sigs.k8s.io).
+type kindProvider struct {
+	binary      string
+	nodeImage   string
+	config      string
+	waitTimeout time.Duration
+	run         commandRunner
+}
+
+// ensure kindProvider implements Provider.
+var _ Provider = &kindProvider{}
+
+// KindOption is a configuration option supplied to NewKindProvider.
+type KindOption func(*kindProvider)
+
+// WithKindBinary sets the path of the kind CLI; if unspecified, kind is looked up in the PATH.
+func WithKindBinary(binary string) KindOption {
+	return func(k *kindProvider) {
+		k.binary = binary
+	}
+}
+
+// WithKindNodeImage sets the node image of the kind clusters, e.g. kindest/node:v1.18.8; if unspecified,
+// the default image of the kind CLI is used.
+func WithKindNodeImage(image string) KindOption {
+	return func(k *kindProvider) {
+		k.nodeImage = image
+	}
+}
+
+// WithKindConfig sets the path of the kind configuration file, e.g. for mounting the Docker socket in the nodes
+// of the kind clusters when using the Docker infrastructure provider.
+func WithKindConfig(config string) KindOption {
+	return func(k *kindProvider) {
+		k.config = config
+	}
+}
+
+// WithKindWaitTimeout sets the time to wait for the control plane of the kind clusters to be ready; if unspecified,
+// it defaults to 5 minutes.
+func WithKindWaitTimeout(timeout time.Duration) KindOption {
+	return func(k *kindProvider) {
+		k.waitTimeout = timeout
+	}
+}
+
+// injectCommandRunner allows to override the function running the kind CLI.
+func injectCommandRunner(run commandRunner) KindOption {
+	return func(k *kindProvider) {
+		k.run = run
+	}
+}
+
+// NewKindProvider returns a Provider creating local kind clusters using the kind CLI.
+func NewKindProvider(options ...KindOption) Provider {
+	k := &kindProvider{
+		binary:      defaultKindBinary,
+		waitTimeout: defaultKindWaitTimeout,
+		run:         runCommand,
+	}
+	for _, o := range options {
+		o(k)
+	}
+	return k
+}
+
+func (k *kindProvider) Create(name, kubeconfigPath string) error {
+	log := logf.Log
+	log.Info("Creating the kind bootstrap cluster", "Name", name)
+
+	args := []string{"create", "cluster", "--name", name, "--kubeconfig", kubeconfigPath, "--wait", k.waitTimeout.String()}
+	if k.nodeImage != "" {
+		args = append(args, "--image", k.nodeImage)
+	}
+	if k.config != "" {
+		args = append(args, "--config", k.config)
+	}
+	if out, err := k.run(k.binary, args...); err != nil {
+		return errors.Wrapf(err, "failed to create the kind cluster %q: %s", name, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (k *kindProvider) Delete(name string) error {
+	log := logf.Log
+	log.Info("Deleting the kind bootstrap cluster", "Name", name)
+
+	// Nb. kind does not fail if the cluster does not exist.
+	if out, err := k.run(k.binary, "delete", "cluster", "--name", name); err != nil {
+		return errors.Wrapf(err, "failed to delete the kind cluster %q: %s", name, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
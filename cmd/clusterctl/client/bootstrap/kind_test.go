@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func Test_kindProvider(t *testing.T) {
+	var commands [][]string
+	fakeRunner := func(fail bool) commandRunner {
+		return func(name string, args ...string) ([]byte, error) {
+			commands = append(commands, append([]string{name}, args...))
+			if fail {
+				return []byte("ERROR: node(s) already exist for a cluster with the name \"bootstrap\"\n"), errors.New("exit status 1")
+			}
+			return nil, nil
+		}
+	}
+
+	t.Run("Create with defaults", func(t *testing.T) {
+		g := NewWithT(t)
+		commands = nil
+
+		p := NewKindProvider(injectCommandRunner(fakeRunner(false)))
+		g.Expect(p.Create("bootstrap", "/tmp/bootstrap.kubeconfig")).To(Succeed())
+		g.Expect(commands).To(Equal([][]string{
+			{"kind", "create", "cluster", "--name", "bootstrap", "--kubeconfig", "/tmp/bootstrap.kubeconfig", "--wait", "5m0s"},
+		}))
+	})
+
+	t.Run("Create with options", func(t *testing.T) {
+		g := NewWithT(t)
+		commands = nil
+
+		p := NewKindProvider(
+			injectCommandRunner(fakeRunner(false)),
+			WithKindBinary("/usr/local/bin/kind"),
+			WithKindNodeImage("kindest/node:v1.18.8"),
+			WithKindConfig("kind-config.yaml"),
+			WithKindWaitTimeout(time.Minute),
+		)
+		g.Expect(p.Create("bootstrap", "/tmp/bootstrap.kubeconfig")).To(Succeed())
+		g.Expect(commands).To(Equal([][]string{
+			{"/usr/local/bin/kind", "create", "cluster", "--name", "bootstrap", "--kubeconfig", "/tmp/bootstrap.kubeconfig", "--wait", "1m0s", "--image", "kindest/node:v1.18.8", "--config", "kind-config.yaml"},
+		}))
+	})
+
+	t.Run("Create reports the kind output on failure", func(t *testing.T) {
+		g := NewWithT(t)
+		commands = nil
+
+		p := NewKindProvider(injectCommandRunner(fakeRunner(true)))
+		err := p.Create("bootstrap", "/tmp/bootstrap.kubeconfig")
+		g.Expect(err).To(MatchError(ContainSubstring("node(s) already exist for a cluster with the name")))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		g := NewWithT(t)
+		commands = nil
+
+		p := NewKindProvider(injectCommandRunner(fakeRunner(false)))
+		g.Expect(p.Delete("bootstrap")).To(Succeed())
+		g.Expect(commands).To(Equal([][]string{
+			{"kind", "delete", "cluster", "--name", "bootstrap"},
+		}))
+	})
+}
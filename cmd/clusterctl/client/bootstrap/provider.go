@@ -0,0 +1,29 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bootstrap implements the providers of the temporary clusters used for bootstrapping
+// self-hosted management clusters, e.g. local kind clusters.
+package bootstrap
+
+// Provider creates and deletes the temporary clusters used for bootstrapping self-hosted management clusters.
+type Provider interface {
+	// Create creates a bootstrap cluster with the given name, and writes the kubeconfig for accessing it to
+	// kubeconfigPath; Create returns once the API server of the bootstrap cluster is ready.
+	Create(name, kubeconfigPath string) error
+
+	// Delete deletes the bootstrap cluster with the given name; deleting a cluster that does not exist is not an error.
+	Delete(name string) error
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package client
 
 import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
@@ -37,15 +39,37 @@ type Client interface {
 	// InitImages returns the list of images required for executing the init command.
 	InitImages(options InitOptions) ([]string, error)
 
+	// GetProviderComponentsVariablesSchema returns a JSON Schema describing the variables of the provider components.
+	GetProviderComponentsVariablesSchema(provider string, providerType clusterctlv1.ProviderType, options ComponentsOptions) (*VariablesSchema, error)
+
 	// GetClusterTemplate returns a workload cluster template.
 	GetClusterTemplate(options GetClusterTemplateOptions) (Template, error)
 
-	// Delete deletes providers from a management cluster.
-	Delete(options DeleteOptions) error
+	// ApplyClusterTemplate renders a workload cluster template and applies it to the management cluster, optionally
+	// waiting for the Clusters defined in the template to be provisioned, and returns the references to the applied objects.
+	ApplyClusterTemplate(options ApplyClusterTemplateOptions) ([]corev1.ObjectReference, error)
+
+	// GetClusterTemplateVariablesSchema returns a JSON Schema describing the variables of a workload cluster template.
+	GetClusterTemplateVariablesSchema(options GetClusterTemplateOptions) (*VariablesSchema, error)
+
+	// ListClusterTemplates returns the workload cluster templates published by the infrastructure providers and
+	// by additional catalogs, filtered by Kubernetes version, infrastructure provider, flavor and tags.
+	ListClusterTemplates(options ListClusterTemplatesOptions) ([]ClusterTemplateInfo, error)
+
+	// Adopt adds to the inventory of a management cluster the providers installed without clusterctl.
+	Adopt(options AdoptOptions) ([]clusterctlv1.Provider, error)
+
+	// Delete deletes providers from a management cluster; it returns the objects deleted, or the objects that would be
+	// deleted in case of a dry run.
+	Delete(options DeleteOptions) (*DeletePlan, error)
 
 	// Move moves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a target management cluster.
 	Move(options MoveOptions) error
 
+	// PlanMove returns the objects a move would move to the target management cluster and the objects that would stay
+	// in the source management cluster, with the reason for each of them, without changing anything.
+	PlanMove(options PlanMoveOptions) (*MovePlan, error)
+
 	// PlanUpgrade returns a set of suggested Upgrade plans for the cluster, and more specifically:
 	// - Each management group gets separated upgrade plans.
 	// - For each management group, an upgrade plan is generated for each API Version of Cluster API (contract) available, e.g.
@@ -56,9 +80,70 @@ type Client interface {
 	// ApplyUpgrade executes an upgrade plan.
 	ApplyUpgrade(options ApplyUpgradeOptions) error
 
+	// UpgradeWorkloadCluster upgrades the Kubernetes version of a workload cluster using a KubeadmControlPlane,
+	// first the control plane, then the MachineDeployments, and returns the upgrade plan.
+	UpgradeWorkloadCluster(options UpgradeWorkloadClusterOptions) (*WorkloadClusterUpgradePlan, error)
+
 	// ProcessYAML provides a direct way to process a yaml and inspect its
 	// variables.
 	ProcessYAML(options ProcessYAMLOptions) (YamlPrinter, error)
+
+	// ConvertTemplate converts a workload cluster template between the envsubst, kustomize and helm formats.
+	ConvertTemplate(options ConvertTemplateOptions) (map[string][]byte, error)
+
+	// GetMachineSSHEndpoint returns how to reach a Machine over SSH, as published by its infrastructure provider;
+	// nil is returned if the infrastructure provider does not publish it.
+	GetMachineSSHEndpoint(options GetMachineSSHEndpointOptions) (*MachineSSHEndpoint, error)
+
+	// ExportObjectGraph returns the graph of the Cluster API objects existing in the management cluster, with their
+	// ownership relations, versions and conditions, e.g. for rendering the graph in a UI.
+	ExportObjectGraph(options ExportObjectGraphOptions) (*ObjectGraph, error)
+
+	// RemoteExec executes clusterctl in a Job in the management cluster and writes its output once the Job completes,
+	// for the environments where the management cluster can't be reached for long running operations.
+	RemoteExec(options RemoteExecOptions) error
+
+	// RotateCredentials updates the credentials Secret of a provider, restarts the provider Deployments and verifies
+	// they become available with the new credentials.
+	RotateCredentials(options RotateCredentialsOptions) error
+
+	// CreateBootstrapCluster creates a temporary bootstrap cluster, by default a local kind cluster, and returns
+	// the kubeconfig for accessing it.
+	CreateBootstrapCluster(options CreateBootstrapClusterOptions) (Kubeconfig, error)
+
+	// DeleteBootstrapCluster deletes a temporary bootstrap cluster.
+	DeleteBootstrapCluster(options DeleteBootstrapClusterOptions) error
+
+	// SelfHostedBootstrap creates a self-hosted management cluster: it creates a temporary bootstrap cluster and
+	// initializes it, creates the target cluster from the bootstrap cluster, initializes the target cluster and moves
+	// the Cluster API objects to it, then deletes the bootstrap cluster; it returns the kubeconfig for accessing the
+	// self-hosted management cluster.
+	SelfHostedBootstrap(options SelfHostedBootstrapOptions) (Kubeconfig, error)
+
+	// ListProviderNames returns the names of the providers defined in the clusterctl configuration, e.g. for
+	// shell completion or interactive UIs.
+	ListProviderNames(options ListProviderNamesOptions) ([]string, error)
+
+	// ListProviderVersions returns the versions available in the repository of a provider, newest first.
+	ListProviderVersions(options ListProviderVersionsOptions) ([]string, error)
+
+	// ListFlavors returns the flavors of the workload cluster templates published by an infrastructure provider.
+	ListFlavors(options ListFlavorsOptions) ([]string, error)
+
+	// ListNamespaces returns the names of the namespaces existing in the management cluster.
+	ListNamespaces(options ListNamespacesOptions) ([]string, error)
+
+	// Config returns the client for the clusterctl configuration, e.g. for reading the configured providers,
+	// variables or image overrides.
+	Config() config.Client
+
+	// Repository returns a client for the repository of the given provider, e.g. for reading the provider
+	// metadata or the list of available versions.
+	Repository(provider Provider) (repository.Client, error)
+
+	// Cluster returns a client for the management cluster defined by the given kubeconfig, e.g. for
+	// querying the provider inventory.
+	Cluster(kubeconfig Kubeconfig) (cluster.Client, error)
 }
 
 // YamlPrinter exposes methods that prints the processed template and
@@ -76,6 +161,7 @@ type clusterctlClient struct {
 	configClient            config.Client
 	repositoryClientFactory RepositoryClientFactory
 	clusterClientFactory    ClusterClientFactory
+	providers               []config.Provider
 }
 
 // RepositoryClientFactoryInput represents the inputs required by the
@@ -123,6 +209,16 @@ func InjectClusterClientFactory(factory ClusterClientFactory) Option {
 	}
 }
 
+// InjectProviders allows to add provider configurations to the ones hard-coded in clusterctl, e.g. for
+// supporting the providers of a vendor extending clusterctl. Provider is an interface, so custom
+// implementations can be injected.
+// Nb. This option cannot be used together with InjectConfig; use config.InjectProviders instead.
+func InjectProviders(providers ...config.Provider) Option {
+	return func(c *clusterctlClient) {
+		c.providers = append(c.providers, providers...)
+	}
+}
+
 // New returns a configClient.
 func New(path string, options ...Option) (Client, error) {
 	return newClusterctlClient(path, options...)
@@ -137,11 +233,13 @@ func newClusterctlClient(path string, options ...Option) (*clusterctlClient, err
 	// if there is an injected config, use it, otherwise use the default one
 	// provided by the config low level library.
 	if client.configClient == nil {
-		c, err := config.New(path)
+		c, err := config.New(path, config.InjectProviders(client.providers...))
 		if err != nil {
 			return nil, err
 		}
 		client.configClient = c
+	} else if len(client.providers) > 0 {
+		return nil, errors.New("the InjectProviders option cannot be used together with InjectConfig")
 	}
 
 	// if there is an injected RepositoryFactory, use it, otherwise use a default one.
@@ -157,6 +255,26 @@ func newClusterctlClient(path string, options ...Option) (*clusterctlClient, err
 	return client, nil
 }
 
+// Config returns the client for the clusterctl configuration.
+func (c *clusterctlClient) Config() config.Client {
+	return c.configClient
+}
+
+// Repository returns a client for the repository of the given provider, created with the same
+// RepositoryClientFactory used by the high-level operations.
+func (c *clusterctlClient) Repository(provider Provider) (repository.Client, error) {
+	if provider == nil {
+		return nil, errors.New("provider is required")
+	}
+	return c.repositoryClientFactory(RepositoryClientFactoryInput{provider: provider})
+}
+
+// Cluster returns a client for the management cluster defined by the given kubeconfig, created with the
+// same ClusterClientFactory used by the high-level operations.
+func (c *clusterctlClient) Cluster(kubeconfig Kubeconfig) (cluster.Client, error) {
+	return c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: kubeconfig})
+}
+
 // defaultRepositoryFactory is a RepositoryClientFactory func the uses the default client provided by the repository low level library.
 func defaultRepositoryFactory(configClient config.Client) RepositoryClientFactory {
 	return func(input RepositoryClientFactoryInput) (repository.Client, error) {
@@ -21,7 +21,9 @@ import (
 	"testing"
 	"time"
 
+	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -59,6 +61,38 @@ func TestNewFakeClient(t *testing.T) {
 		WithCluster(cluster1)
 }
 
+func Test_clusterctlClient_SubClients(t *testing.T) {
+	g := NewWithT(t)
+
+	repository1Config := config.NewProvider("p1", "url", clusterctlv1.CoreProviderType)
+	config1 := newFakeConfig().
+		WithProvider(repository1Config)
+	repository1 := newFakeRepository(repository1Config, config1).
+		WithPaths("root", "components").
+		WithDefaultVersion("v1.0")
+	cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "cluster1"}, config1).
+		WithProviderInventory(repository1Config.Name(), repository1Config.Type(), "v1.0", "ns1", "")
+
+	c := newFakeClient(config1).
+		WithRepository(repository1).
+		WithCluster(cluster1)
+
+	g.Expect(c.Config()).To(Equal(config1))
+
+	r, err := c.Repository(repository1Config)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(r.DefaultVersion()).To(Equal("v1.0"))
+
+	_, err = c.Repository(nil)
+	g.Expect(err).To(HaveOccurred())
+
+	cc, err := c.Cluster(Kubeconfig{Path: "cluster1"})
+	g.Expect(err).NotTo(HaveOccurred())
+	providers, err := cc.ProviderInventory().List()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(providers.Items).To(HaveLen(1))
+}
+
 type fakeClient struct {
 	configClient config.Client
 	// mapping between kubeconfigPath/context with cluster client
@@ -81,6 +115,14 @@ func (f fakeClient) GetClusterTemplate(options GetClusterTemplateOptions) (Templ
 	return f.internalClient.GetClusterTemplate(options)
 }
 
+func (f fakeClient) GetProviderComponentsVariablesSchema(provider string, providerType clusterctlv1.ProviderType, options ComponentsOptions) (*VariablesSchema, error) {
+	return f.internalClient.GetProviderComponentsVariablesSchema(provider, providerType, options)
+}
+
+func (f fakeClient) GetClusterTemplateVariablesSchema(options GetClusterTemplateOptions) (*VariablesSchema, error) {
+	return f.internalClient.GetClusterTemplateVariablesSchema(options)
+}
+
 func (f fakeClient) Init(options InitOptions) ([]Components, error) {
 	return f.internalClient.Init(options)
 }
@@ -89,7 +131,15 @@ func (f fakeClient) InitImages(options InitOptions) ([]string, error) {
 	return f.internalClient.InitImages(options)
 }
 
-func (f fakeClient) Delete(options DeleteOptions) error {
+func (f fakeClient) ListClusterTemplates(options ListClusterTemplatesOptions) ([]ClusterTemplateInfo, error) {
+	return f.internalClient.ListClusterTemplates(options)
+}
+
+func (f fakeClient) Adopt(options AdoptOptions) ([]clusterctlv1.Provider, error) {
+	return f.internalClient.Adopt(options)
+}
+
+func (f fakeClient) Delete(options DeleteOptions) (*DeletePlan, error) {
 	return f.internalClient.Delete(options)
 }
 
@@ -97,6 +147,10 @@ func (f fakeClient) Move(options MoveOptions) error {
 	return f.internalClient.Move(options)
 }
 
+func (f fakeClient) PlanMove(options PlanMoveOptions) (*MovePlan, error) {
+	return f.internalClient.PlanMove(options)
+}
+
 func (f fakeClient) PlanUpgrade(options PlanUpgradeOptions) ([]UpgradePlan, error) {
 	return f.internalClient.PlanUpgrade(options)
 }
@@ -105,10 +159,78 @@ func (f fakeClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
 	return f.internalClient.ApplyUpgrade(options)
 }
 
+func (f fakeClient) UpgradeWorkloadCluster(options UpgradeWorkloadClusterOptions) (*WorkloadClusterUpgradePlan, error) {
+	return f.internalClient.UpgradeWorkloadCluster(options)
+}
+
 func (f fakeClient) ProcessYAML(options ProcessYAMLOptions) (YamlPrinter, error) {
 	return f.internalClient.ProcessYAML(options)
 }
 
+func (f fakeClient) ConvertTemplate(options ConvertTemplateOptions) (map[string][]byte, error) {
+	return f.internalClient.ConvertTemplate(options)
+}
+
+func (f fakeClient) GetMachineSSHEndpoint(options GetMachineSSHEndpointOptions) (*MachineSSHEndpoint, error) {
+	return f.internalClient.GetMachineSSHEndpoint(options)
+}
+
+func (f fakeClient) ApplyClusterTemplate(options ApplyClusterTemplateOptions) ([]corev1.ObjectReference, error) {
+	return f.internalClient.ApplyClusterTemplate(options)
+}
+
+func (f fakeClient) ExportObjectGraph(options ExportObjectGraphOptions) (*ObjectGraph, error) {
+	return f.internalClient.ExportObjectGraph(options)
+}
+
+func (f fakeClient) RemoteExec(options RemoteExecOptions) error {
+	return f.internalClient.RemoteExec(options)
+}
+
+func (f fakeClient) RotateCredentials(options RotateCredentialsOptions) error {
+	return f.internalClient.RotateCredentials(options)
+}
+
+func (f fakeClient) CreateBootstrapCluster(options CreateBootstrapClusterOptions) (Kubeconfig, error) {
+	return f.internalClient.CreateBootstrapCluster(options)
+}
+
+func (f fakeClient) DeleteBootstrapCluster(options DeleteBootstrapClusterOptions) error {
+	return f.internalClient.DeleteBootstrapCluster(options)
+}
+
+func (f fakeClient) SelfHostedBootstrap(options SelfHostedBootstrapOptions) (Kubeconfig, error) {
+	return f.internalClient.SelfHostedBootstrap(options)
+}
+
+func (f fakeClient) ListProviderNames(options ListProviderNamesOptions) ([]string, error) {
+	return f.internalClient.ListProviderNames(options)
+}
+
+func (f fakeClient) ListProviderVersions(options ListProviderVersionsOptions) ([]string, error) {
+	return f.internalClient.ListProviderVersions(options)
+}
+
+func (f fakeClient) ListFlavors(options ListFlavorsOptions) ([]string, error) {
+	return f.internalClient.ListFlavors(options)
+}
+
+func (f fakeClient) ListNamespaces(options ListNamespacesOptions) ([]string, error) {
+	return f.internalClient.ListNamespaces(options)
+}
+
+func (f fakeClient) Config() config.Client {
+	return f.internalClient.Config()
+}
+
+func (f fakeClient) Repository(provider Provider) (repository.Client, error) {
+	return f.internalClient.Repository(provider)
+}
+
+func (f fakeClient) Cluster(kubeconfig Kubeconfig) (cluster.Client, error) {
+	return f.internalClient.Cluster(kubeconfig)
+}
+
 // newFakeClient returns a clusterctl client that allows to execute tests on a set of fake config, fake repositories and fake clusters.
 // you can use WithCluster and WithRepository to prepare for the test case.
 func newFakeClient(configClient config.Client) *fakeClient {
@@ -212,6 +334,10 @@ func (p *fakeCertManagerClient) Images() ([]string, error) {
 	return p.images, p.imagesError
 }
 
+func (p *fakeCertManagerClient) PlanUpgrade() (cluster.CertManagerUpgradePlan, error) {
+	return cluster.CertManagerUpgradePlan{}, nil
+}
+
 type fakeClusterClient struct {
 	kubeconfig      cluster.Kubeconfig
 	fakeProxy       *test.FakeProxy
@@ -262,6 +388,42 @@ func (f *fakeClusterClient) Template() cluster.TemplateClient {
 	return f.internalclient.Template()
 }
 
+func (f *fakeClusterClient) Audit() cluster.AuditClient {
+	return f.internalclient.Audit()
+}
+
+func (f *fakeClusterClient) WorkloadClusterUpgrader() cluster.WorkloadClusterUpgrader {
+	return f.internalclient.WorkloadClusterUpgrader()
+}
+
+func (f *fakeClusterClient) Namespaces() cluster.NamespaceClient {
+	return f.internalclient.Namespaces()
+}
+
+func (f *fakeClusterClient) OperationLock() cluster.OperationLockClient {
+	return f.internalclient.OperationLock()
+}
+
+func (f *fakeClusterClient) MachineDebug() cluster.MachineDebugClient {
+	return f.internalclient.MachineDebug()
+}
+
+func (f *fakeClusterClient) ObjectGraph() cluster.ObjectGraphClient {
+	return f.internalclient.ObjectGraph()
+}
+
+func (f *fakeClusterClient) ClusterProvisioning() cluster.ClusterProvisioningClient {
+	return f.internalclient.ClusterProvisioning()
+}
+
+func (f *fakeClusterClient) RemoteExec() cluster.RemoteExecClient {
+	return f.internalclient.RemoteExec()
+}
+
+func (f *fakeClusterClient) Credentials() cluster.CredentialsClient {
+	return f.internalclient.Credentials()
+}
+
 func (f *fakeClusterClient) WithObjs(objs ...runtime.Object) *fakeClusterClient {
 	f.fakeProxy.WithObjs(objs...)
 	return f
@@ -320,6 +482,14 @@ func (f fakeConfigClient) ImageMeta() config.ImageMetaClient {
 	return f.internalclient.ImageMeta()
 }
 
+func (f fakeConfigClient) VariableValidations() ([]config.VariableValidation, error) {
+	return f.internalclient.VariableValidations()
+}
+
+func (f fakeConfigClient) ComponentsVerification() (*config.ComponentsVerification, error) {
+	return f.internalclient.ComponentsVerification()
+}
+
 func (f *fakeConfigClient) WithVar(key, value string) *fakeConfigClient {
 	f.fakeReader.WithVar(key, value)
 	return f
@@ -0,0 +1,215 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AuditConfigMapName is the name of the ConfigMap where clusterctl records the operations
+	// executed against a management cluster.
+	AuditConfigMapName = "clusterctl-audit"
+
+	// AuditConfigMapNamespace is the namespace hosting the audit ConfigMap.
+	// Nb. kube-system is used because it exists in every cluster, so recording does not require creating a namespace.
+	AuditConfigMapNamespace = "kube-system"
+
+	// maxAuditRecords is the maximum number of records kept in the audit ConfigMap; older records are pruned
+	// so the ConfigMap stays well below the object size limit.
+	maxAuditRecords = 100
+)
+
+// AuditOperation defines the type of the clusterctl operations recorded in the audit log.
+type AuditOperation string
+
+const (
+	AuditInitOperation    = AuditOperation("init")
+	AuditUpgradeOperation = AuditOperation("upgrade")
+	AuditMoveOperation    = AuditOperation("move")
+	AuditDeleteOperation  = AuditOperation("delete")
+	AuditAdoptOperation   = AuditOperation("adopt")
+
+	AuditRotateCredentialsOperation = AuditOperation("rotate-credentials")
+)
+
+// AuditRecord describes a clusterctl operation executed against a management cluster.
+type AuditRecord struct {
+	// Operation executed (e.g. init, upgrade).
+	Operation AuditOperation `json:"operation"`
+
+	// User that executed the operation.
+	User string `json:"user,omitempty"`
+
+	// Timestamp of the operation.
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// ClusterctlVersion is the version of the clusterctl binary used for the operation.
+	ClusterctlVersion string `json:"clusterctlVersion,omitempty"`
+
+	// Providers affected by the operation, in the form namespace/name:version.
+	Providers []string `json:"providers,omitempty"`
+
+	// Details contains additional, operation specific information (e.g. the namespace being moved).
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// key returns the ConfigMap key used for storing the record; keys are sortable by timestamp.
+func (r *AuditRecord) key() string {
+	return fmt.Sprintf("%s-%s", r.Timestamp.UTC().Format("20060102T150405.000000000Z"), r.Operation)
+}
+
+// AuditProviderRef returns the reference used for identifying a provider in an AuditRecord.
+func AuditProviderRef(provider clusterctlv1.Provider, version string) string {
+	return fmt.Sprintf("%s/%s:%s", provider.Namespace, provider.ManifestLabel(), version)
+}
+
+// AuditClient has methods to record clusterctl operations in the management cluster.
+type AuditClient interface {
+	// Record adds an AuditRecord to the audit log stored in the management cluster.
+	Record(record AuditRecord) error
+
+	// List returns the AuditRecords stored in the management cluster, sorted by timestamp.
+	List() ([]AuditRecord, error)
+}
+
+// auditClient implements AuditClient.
+type auditClient struct {
+	proxy Proxy
+}
+
+// ensure auditClient implements AuditClient.
+var _ AuditClient = &auditClient{}
+
+// newAuditClient returns an auditClient.
+func newAuditClient(proxy Proxy) *auditClient {
+	return &auditClient{
+		proxy: proxy,
+	}
+}
+
+func (a *auditClient) Record(record AuditRecord) error {
+	if record.Timestamp.IsZero() {
+		record.Timestamp = metav1.Now()
+	}
+
+	value, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the audit record")
+	}
+
+	// Nb. The operation is wrapped in a retry loop to make Record more resilient to conflicts and temporary failures.
+	return retryWithExponentialBackoff(newWriteBackoff(), func() error {
+		c, err := a.proxy.NewClient()
+		if err != nil {
+			return err
+		}
+
+		cm := &corev1.ConfigMap{}
+		key := client.ObjectKey{Namespace: AuditConfigMapNamespace, Name: AuditConfigMapName}
+		if err := c.Get(ctx, key, cm); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return errors.Wrapf(err, "failed to get the %s/%s ConfigMap", AuditConfigMapNamespace, AuditConfigMapName)
+			}
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: AuditConfigMapNamespace,
+					Name:      AuditConfigMapName,
+					Labels: map[string]string{
+						clusterctlv1.ClusterctlLabelName: "",
+					},
+				},
+				Data: map[string]string{
+					record.key(): string(value),
+				},
+			}
+			if err := c.Create(ctx, cm); err != nil {
+				return errors.Wrapf(err, "failed to create the %s/%s ConfigMap", AuditConfigMapNamespace, AuditConfigMapName)
+			}
+			return nil
+		}
+
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[record.key()] = string(value)
+		pruneAuditRecords(cm.Data)
+
+		if err := c.Update(ctx, cm); err != nil {
+			return errors.Wrapf(err, "failed to update the %s/%s ConfigMap", AuditConfigMapNamespace, AuditConfigMapName)
+		}
+		return nil
+	})
+}
+
+func (a *auditClient) List() ([]AuditRecord, error) {
+	c, err := a.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	cm := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: AuditConfigMapNamespace, Name: AuditConfigMapName}
+	if err := c.Get(ctx, key, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get the %s/%s ConfigMap", AuditConfigMapNamespace, AuditConfigMapName)
+	}
+
+	keys := make([]string, 0, len(cm.Data))
+	for k := range cm.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	records := make([]AuditRecord, 0, len(keys))
+	for _, k := range keys {
+		record := AuditRecord{}
+		if err := json.Unmarshal([]byte(cm.Data[k]), &record); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal the audit record %q", k)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// pruneAuditRecords removes the oldest records in excess of maxAuditRecords.
+func pruneAuditRecords(data map[string]string) {
+	if len(data) <= maxAuditRecords {
+		return
+	}
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys[:len(keys)-maxAuditRecords] {
+		delete(data, k)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_auditClient_Record(t *testing.T) {
+	g := NewWithT(t)
+
+	proxy := test.NewFakeProxy()
+	a := newAuditClient(proxy)
+
+	init := AuditRecord{
+		Operation: AuditInitOperation,
+		User:      "admin",
+		Timestamp: metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Providers: []string{"capi-system/cluster-api:v0.3.0"},
+	}
+	upgrade := AuditRecord{
+		Operation: AuditUpgradeOperation,
+		User:      "admin",
+		Timestamp: metav1.NewTime(time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)),
+		Providers: []string{"capi-system/cluster-api:v0.3.1"},
+		Details:   map[string]string{"contract": "v1alpha3"},
+	}
+
+	// Records are stored in reverse order to check List sorts them by timestamp.
+	g.Expect(a.Record(upgrade)).To(Succeed())
+	g.Expect(a.Record(init)).To(Succeed())
+
+	got, err := a.List()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(HaveLen(2))
+	g.Expect(got[0].Operation).To(Equal(AuditInitOperation))
+	g.Expect(got[0].Providers).To(Equal(init.Providers))
+	g.Expect(got[1].Operation).To(Equal(AuditUpgradeOperation))
+	g.Expect(got[1].Details).To(Equal(upgrade.Details))
+}
+
+func Test_auditClient_List_NoAuditLog(t *testing.T) {
+	g := NewWithT(t)
+
+	a := newAuditClient(test.NewFakeProxy())
+
+	got, err := a.List()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(BeEmpty())
+}
+
+func Test_auditClient_Record_Prune(t *testing.T) {
+	g := NewWithT(t)
+
+	proxy := test.NewFakeProxy()
+	a := newAuditClient(proxy)
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < maxAuditRecords+5; i++ {
+		g.Expect(a.Record(AuditRecord{
+			Operation: AuditMoveOperation,
+			Timestamp: metav1.NewTime(start.Add(time.Duration(i) * time.Minute)),
+			Details:   map[string]string{"index": fmt.Sprintf("%d", i)},
+		})).To(Succeed())
+	}
+
+	c, err := proxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cm := &corev1.ConfigMap{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: AuditConfigMapNamespace, Name: AuditConfigMapName}, cm)).To(Succeed())
+	g.Expect(cm.Data).To(HaveLen(maxAuditRecords))
+
+	got, err := a.List()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got[0].Details["index"]).To(Equal("5"))
+}
@@ -17,16 +17,23 @@ limitations under the License.
 package cluster
 
 import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/version"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	manifests "sigs.k8s.io/cluster-api/cmd/clusterctl/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/util"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/cluster-api/util/container"
 	utilresource "sigs.k8s.io/cluster-api/util/resource"
 	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -34,12 +41,22 @@ import (
 
 const (
 	embeddedCertManagerManifestPath = "cmd/clusterctl/config/assets/cert-manager.yaml"
+	embeddedCertManagerVersion      = "v0.11.0"
+
+	// certManagerReleaseURLFormat is the format of the URL of the cert-manager manifest for a given release.
+	certManagerReleaseURLFormat = "https://github.com/jetstack/cert-manager/releases/download/%s/cert-manager.yaml"
+
+	downloadCertManagerTimeout = 30 * time.Second
 
 	waitCertManagerInterval       = 1 * time.Second
 	waitCertManagerDefaultTimeout = 10 * time.Minute
 
 	certManagerImageComponent = "cert-manager"
+	certManagerNamespace      = "cert-manager"
+	certManagerDeploymentName = "cert-manager"
 	timeoutConfigKey          = "cert-manager-timeout"
+	urlConfigKey              = "cert-manager-url"
+	versionConfigKey          = "cert-manager-version"
 )
 
 // CertManagerClient has methods to work with cert-manager components in the cluster.
@@ -50,6 +67,37 @@ type CertManagerClient interface {
 
 	// Images return the list of images required for installing the cert-manager.
 	Images() ([]string, error)
+
+	// PlanUpgrade returns the version of the cert-manager installed in the cluster and the version clusterctl is
+	// configured to install.
+	PlanUpgrade() (CertManagerUpgradePlan, error)
+}
+
+// CertManagerUpgradePlan defines the cert-manager version installed in a management cluster and the cert-manager
+// version clusterctl is configured to install.
+type CertManagerUpgradePlan struct {
+	// CurrentVersion is the version of the cert-manager installed in the cluster, inferred from the image tag of the
+	// cert-manager controller; it is empty if cert-manager is not installed, or it is not installed by the
+	// cert-manager manifest.
+	CurrentVersion string
+
+	// TargetVersion is the version of the cert-manager manifest clusterctl is configured to install, i.e. the
+	// cert-manager-version config variable or the embedded version; it is empty if a custom manifest is configured
+	// with the cert-manager-url config variable and the cert-manager-version config variable is not set.
+	TargetVersion string
+}
+
+// ShouldUpgrade returns true if the installed cert-manager is older than the version clusterctl is configured to install.
+func (p CertManagerUpgradePlan) ShouldUpgrade() bool {
+	current, err := version.ParseSemantic(p.CurrentVersion)
+	if err != nil {
+		return false
+	}
+	target, err := version.ParseSemantic(p.TargetVersion)
+	if err != nil {
+		return false
+	}
+	return current.LessThan(target)
 }
 
 // certManagerClient implements CertManagerClient .
@@ -81,10 +129,10 @@ func (cm *certManagerClient) Images() ([]string, error) {
 		return []string{}, nil
 	}
 
-	// Gets the cert-manager objects from the embedded assets.
+	// Gets the cert-manager objects.
 	objs, err := cm.getManifestObjs()
 	if err != nil {
-		return []string{}, nil
+		return nil, err
 	}
 
 	images, err := util.InspectImages(objs)
@@ -97,7 +145,7 @@ func (cm *certManagerClient) Images() ([]string, error) {
 // EnsureWebhook makes sure the cert-manager Web-hook is Available in a cluster:
 // this is a requirement to install a new provider
 // Nb. In order to provide a simpler out-of-the box experience, the cert-manager manifest
-// is embedded in the clusterctl binary.
+// is embedded in the clusterctl binary; a different version or location can be configured, e.g. for air-gapped environments.
 func (cm *certManagerClient) EnsureWebhook() error {
 	log := logf.Log
 
@@ -113,10 +161,10 @@ func (cm *certManagerClient) EnsureWebhook() error {
 	// Otherwise install cert-manager
 	log.Info("Installing cert-manager")
 
-	// Gets the cert-manager objects from the embedded assets.
+	// Gets the cert-manager objects.
 	objs, err := cm.getManifestObjs()
 	if err != nil {
-		return nil
+		return err
 	}
 
 	// installs the web-hook
@@ -160,6 +208,49 @@ func (cm *certManagerClient) EnsureWebhook() error {
 	return nil
 }
 
+// PlanUpgrade returns the version of the cert-manager installed in the cluster and the version clusterctl is
+// configured to install.
+func (cm *certManagerClient) PlanUpgrade() (CertManagerUpgradePlan, error) {
+	plan := CertManagerUpgradePlan{
+		TargetVersion: cm.getTargetVersion(),
+	}
+
+	c, err := cm.proxy.NewClient()
+	if err != nil {
+		return plan, err
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: certManagerNamespace, Name: certManagerDeploymentName}, deployment); err != nil {
+		if apierrors.IsNotFound(err) {
+			return plan, nil
+		}
+		return plan, errors.Wrap(err, "failed to get the cert-manager Deployment")
+	}
+	for _, controller := range deployment.Spec.Template.Spec.Containers {
+		if controller.Name != certManagerDeploymentName {
+			continue
+		}
+		image, err := container.ImageFromString(controller.Image)
+		if err != nil {
+			return plan, nil
+		}
+		plan.CurrentVersion = image.Tag
+	}
+	return plan, nil
+}
+
+// getTargetVersion returns the version of the cert-manager manifest clusterctl is configured to install, if known.
+func (cm *certManagerClient) getTargetVersion() string {
+	if version, err := cm.configClient.Variables().Get(versionConfigKey); err == nil && version != "" {
+		return version
+	}
+	if manifestURL, err := cm.configClient.Variables().Get(urlConfigKey); err == nil && manifestURL != "" {
+		return ""
+	}
+	return embeddedCertManagerVersion
+}
+
 func (cm *certManagerClient) getWaitTimeout() time.Duration {
 	log := logf.Log
 
@@ -175,9 +266,69 @@ func (cm *certManagerClient) getWaitTimeout() time.Duration {
 	return timeoutDuration
 }
 
+// getManifestURL returns the location of the cert-manager manifest, or an empty string if the embedded manifest should be used.
+// The location is read from the cert-manager-url config variable; if it is not set, and the cert-manager-version
+// config variable is set to a version other than the embedded one, the manifest of the given cert-manager release is used.
+func (cm *certManagerClient) getManifestURL() string {
+	if manifestURL, err := cm.configClient.Variables().Get(urlConfigKey); err == nil && manifestURL != "" {
+		return manifestURL
+	}
+	if version, err := cm.configClient.Variables().Get(versionConfigKey); err == nil && version != "" && version != embeddedCertManagerVersion {
+		return fmt.Sprintf(certManagerReleaseURLFormat, version)
+	}
+	return ""
+}
+
+// getManifest returns the cert-manager manifest from the configured location, which can be a local file or an http(s)
+// URL, or from the embedded assets.
+func (cm *certManagerClient) getManifest() ([]byte, error) {
+	manifestURL := cm.getManifestURL()
+	if manifestURL == "" {
+		return manifests.Asset(embeddedCertManagerManifestPath)
+	}
+
+	rURL, err := url.Parse(manifestURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the cert-manager manifest location %q", manifestURL)
+	}
+
+	switch rURL.Scheme {
+	case "", "file":
+		content, err := ioutil.ReadFile(rURL.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the cert-manager manifest from %q", rURL.Path)
+		}
+		return content, nil
+	case "http", "https":
+		return downloadCertManagerManifest(manifestURL)
+	default:
+		return nil, errors.Errorf("invalid cert-manager manifest location %q. Only http(s) URLs and local files are supported", manifestURL)
+	}
+}
+
+// downloadCertManagerManifest downloads the cert-manager manifest from an http(s) URL.
+func downloadCertManagerManifest(manifestURL string) ([]byte, error) {
+	httpClient := &http.Client{Timeout: downloadCertManagerTimeout}
+	response, err := httpClient.Get(manifestURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download the cert-manager manifest from %q", manifestURL)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to download the cert-manager manifest from %q: %s", manifestURL, response.Status)
+	}
+
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the cert-manager manifest downloaded from %q", manifestURL)
+	}
+	return content, nil
+}
+
 // getManifestObjs gets the cert-manager manifest, convert to unstructured objects, and fix images
 func (cm *certManagerClient) getManifestObjs() ([]unstructured.Unstructured, error) {
-	yaml, err := manifests.Asset(embeddedCertManagerManifestPath)
+	yaml, err := cm.getManifest()
 	if err != nil {
 		return nil, err
 	}
@@ -17,12 +17,22 @@ limitations under the License.
 package cluster
 
 import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	. "github.com/onsi/gomega"
 	admissionregistration "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/scheme"
@@ -126,6 +136,194 @@ func Test_certManagerClient_getManifestObjects(t *testing.T) {
 
 }
 
+func Test_certManagerClient_getManifest(t *testing.T) {
+	g := NewWithT(t)
+
+	manifest := []byte("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: cert-manager")
+
+	dir, err := ioutil.TempDir("", "cert-manager")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+	manifestPath := filepath.Join(dir, "cert-manager.yaml")
+	g.Expect(ioutil.WriteFile(manifestPath, manifest, 0600)).To(Succeed())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cert-manager.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, string(manifest))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		wantURL string
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name:    "read from a local file",
+			vars:    map[string]string{urlConfigKey: manifestPath},
+			wantURL: manifestPath,
+			want:    manifest,
+		},
+		{
+			name:    "read from a file URL",
+			vars:    map[string]string{urlConfigKey: "file://" + manifestPath},
+			wantURL: "file://" + manifestPath,
+			want:    manifest,
+		},
+		{
+			name:    "download from an http URL",
+			vars:    map[string]string{urlConfigKey: server.URL + "/cert-manager.yaml"},
+			wantURL: server.URL + "/cert-manager.yaml",
+			want:    manifest,
+		},
+		{
+			name:    "fails if the download fails",
+			vars:    map[string]string{urlConfigKey: server.URL + "/missing.yaml"},
+			wantURL: server.URL + "/missing.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "fails if the local file does not exist",
+			vars:    map[string]string{urlConfigKey: filepath.Join(dir, "missing.yaml")},
+			wantURL: filepath.Join(dir, "missing.yaml"),
+			wantErr: true,
+		},
+		{
+			name:    "fails for unsupported schemes",
+			vars:    map[string]string{urlConfigKey: "ftp://example.com/cert-manager.yaml"},
+			wantURL: "ftp://example.com/cert-manager.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "the URL takes precedence over the version",
+			vars:    map[string]string{urlConfigKey: manifestPath, versionConfigKey: "v0.16.0"},
+			wantURL: manifestPath,
+			want:    manifest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			fakeConfigClient := newFakeConfig("")
+			for k, v := range tt.vars {
+				fakeConfigClient.WithVar(k, v)
+			}
+
+			cm := newCertMangerClient(fakeConfigClient, nil, nil)
+			g.Expect(cm.getManifestURL()).To(Equal(tt.wantURL))
+
+			got, err := cm.getManifest()
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_certManagerClient_getManifestURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{
+			name:    "use the embedded manifest by default",
+			version: "",
+			want:    "",
+		},
+		{
+			name:    "use the embedded manifest for the embedded version",
+			version: embeddedCertManagerVersion,
+			want:    "",
+		},
+		{
+			name:    "use the release manifest for other versions",
+			version: "v0.16.0",
+			want:    "https://github.com/jetstack/cert-manager/releases/download/v0.16.0/cert-manager.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			fakeConfigClient := newFakeConfig("")
+			fakeConfigClient.WithVar(versionConfigKey, tt.version)
+
+			cm := newCertMangerClient(fakeConfigClient, nil, nil)
+			g.Expect(cm.getManifestURL()).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_certManagerClient_PlanUpgrade(t *testing.T) {
+	certManager := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: certManagerNamespace, Name: certManagerDeploymentName},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "cert-manager", Image: "quay.io/jetstack/cert-manager-controller:v0.10.1"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		objs              []runtime.Object
+		vars              map[string]string
+		want              CertManagerUpgradePlan
+		wantShouldUpgrade bool
+	}{
+		{
+			name: "cert-manager not installed",
+			want: CertManagerUpgradePlan{TargetVersion: embeddedCertManagerVersion},
+		},
+		{
+			name:              "cert-manager older than the embedded version",
+			objs:              []runtime.Object{certManager},
+			want:              CertManagerUpgradePlan{CurrentVersion: "v0.10.1", TargetVersion: embeddedCertManagerVersion},
+			wantShouldUpgrade: true,
+		},
+		{
+			name: "cert-manager newer than the configured version",
+			objs: []runtime.Object{certManager},
+			vars: map[string]string{versionConfigKey: "v0.9.0"},
+			want: CertManagerUpgradePlan{CurrentVersion: "v0.10.1", TargetVersion: "v0.9.0"},
+		},
+		{
+			name: "custom cert-manager manifest",
+			objs: []runtime.Object{certManager},
+			vars: map[string]string{urlConfigKey: "https://example.com/cert-manager.yaml"},
+			want: CertManagerUpgradePlan{CurrentVersion: "v0.10.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			fakeConfigClient := newFakeConfig("")
+			for k, v := range tt.vars {
+				fakeConfigClient.WithVar(k, v)
+			}
+
+			cm := newCertMangerClient(fakeConfigClient, test.NewFakeProxy().WithObjs(tt.objs...), nil)
+			got, err := cm.PlanUpgrade()
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(got.ShouldUpgrade()).To(Equal(tt.wantShouldUpgrade))
+		})
+	}
+}
+
 func Test_GetTimeout(t *testing.T) {
 
 	pollImmediateWaiter := func(interval, timeout time.Duration, condition wait.ConditionFunc) error {
@@ -199,6 +397,14 @@ func (f fakeConfigClient) ImageMeta() config.ImageMetaClient {
 	return f.internalclient.ImageMeta()
 }
 
+func (f fakeConfigClient) VariableValidations() ([]config.VariableValidation, error) {
+	return f.internalclient.VariableValidations()
+}
+
+func (f fakeConfigClient) ComponentsVerification() (*config.ComponentsVerification, error) {
+	return f.internalclient.ComponentsVerification()
+}
+
 func (f *fakeConfigClient) WithVar(key, value string) *fakeConfigClient {
 	f.fakeReader.WithVar(key, value)
 	return f
@@ -22,6 +22,7 @@ import (
 
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
@@ -86,6 +87,37 @@ type Client interface {
 
 	// Template has methods to work with templates stored in the cluster.
 	Template() TemplateClient
+
+	// Audit returns an AuditClient that can be used for recording clusterctl operations in the management cluster.
+	Audit() AuditClient
+
+	// WorkloadClusterUpgrader returns a WorkloadClusterUpgrader that supports upgrading the Kubernetes version of workload clusters.
+	WorkloadClusterUpgrader() WorkloadClusterUpgrader
+
+	// Namespaces returns a NamespaceClient that can be used for checking and creating namespaces in the management cluster.
+	Namespaces() NamespaceClient
+
+	// OperationLock returns an OperationLockClient that can be used for preventing concurrent clusterctl operations
+	// against the management cluster.
+	OperationLock() OperationLockClient
+
+	// MachineDebug returns a MachineDebugClient that can be used for helping users debugging the Machines of a workload cluster.
+	MachineDebug() MachineDebugClient
+
+	// ObjectGraph returns an ObjectGraphClient that can be used for exporting the graph of the Cluster API objects
+	// existing in the management cluster, e.g. for rendering it in a UI.
+	ObjectGraph() ObjectGraphClient
+
+	// ClusterProvisioning returns a ClusterProvisioningClient that can be used for provisioning workload clusters
+	// from the objects of a cluster template.
+	ClusterProvisioning() ClusterProvisioningClient
+
+	// RemoteExec returns a RemoteExecClient that can be used for executing clusterctl in a Job in the management
+	// cluster, for the environments where the management cluster can't be reached for long running operations.
+	RemoteExec() RemoteExecClient
+
+	// Credentials returns a CredentialsClient that can be used for rotating the credentials used by the providers.
+	Credentials() CredentialsClient
 }
 
 // PollImmediateWaiter tries a condition func until it returns true, an error, or the timeout is reached.
@@ -127,7 +159,7 @@ func (c *clusterClient) ProviderInventory() InventoryClient {
 }
 
 func (c *clusterClient) ProviderInstaller() ProviderInstaller {
-	return newProviderInstaller(c.configClient, c.repositoryClientFactory, c.proxy, c.ProviderInventory(), c.ProviderComponents())
+	return newProviderInstaller(c.configClient, c.repositoryClientFactory, c.proxy, c.ProviderInventory(), c.ProviderComponents(), c.Namespaces(), c.pollImmediateWaiter)
 }
 
 func (c *clusterClient) ObjectMover() ObjectMover {
@@ -135,13 +167,49 @@ func (c *clusterClient) ObjectMover() ObjectMover {
 }
 
 func (c *clusterClient) ProviderUpgrader() ProviderUpgrader {
-	return newProviderUpgrader(c.configClient, c.repositoryClientFactory, c.ProviderInventory(), c.ProviderComponents())
+	return newProviderUpgrader(c.proxy, c.configClient, c.repositoryClientFactory, c.ProviderInventory(), c.ProviderComponents())
 }
 
 func (c *clusterClient) Template() TemplateClient {
 	return newTemplateClient(TemplateClientInput{c.proxy, c.configClient, c.processor})
 }
 
+func (c *clusterClient) Audit() AuditClient {
+	return newAuditClient(c.proxy)
+}
+
+func (c *clusterClient) WorkloadClusterUpgrader() WorkloadClusterUpgrader {
+	return newWorkloadClusterUpgrader(c.proxy, c.pollImmediateWaiter)
+}
+
+func (c *clusterClient) Namespaces() NamespaceClient {
+	return newNamespaceClient(c.proxy)
+}
+
+func (c *clusterClient) OperationLock() OperationLockClient {
+	return newOperationLockClient(c.proxy)
+}
+
+func (c *clusterClient) MachineDebug() MachineDebugClient {
+	return newMachineDebugClient(c.proxy)
+}
+
+func (c *clusterClient) ObjectGraph() ObjectGraphClient {
+	return newObjectGraphClient(c.proxy)
+}
+
+func (c *clusterClient) ClusterProvisioning() ClusterProvisioningClient {
+	return newClusterProvisioningClient(c.proxy, c.pollImmediateWaiter)
+}
+
+func (c *clusterClient) RemoteExec() RemoteExecClient {
+	return newRemoteExecClient(c.proxy, c.pollImmediateWaiter)
+}
+
+func (c *clusterClient) Credentials() CredentialsClient {
+	return newCredentialsClient(c.proxy, c.pollImmediateWaiter)
+}
+
 // Option is a configuration option supplied to New
 type Option func(*clusterClient)
 
@@ -221,6 +289,9 @@ type Proxy interface {
 	// ValidateKubernetesVersion returns an error if management cluster version less than minimumKubernetesVersion
 	ValidateKubernetesVersion() error
 
+	// GetServerVersion returns the Kubernetes version of the management cluster.
+	GetServerVersion() (*utilversion.Version, error)
+
 	// NewClient returns a new controller runtime Client object for working on the management cluster
 	NewClient() (client.Client, error)
 
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+)
+
+// kubernetesVersionRange defines the Kubernetes versions of the management cluster supported by a release series
+// of the Cluster API core provider.
+type kubernetesVersionRange struct {
+	// Min is the oldest supported Kubernetes version; older versions lack API server features required by the
+	// provider components, e.g. webhooks or CRD features, so they are rejected.
+	Min string
+
+	// Max is the newest Kubernetes minor version the release series was tested with; newer versions are allowed,
+	// but a warning is reported.
+	Max string
+}
+
+// coreProviderKubernetesCompatibility is the compatibility matrix between the release series of the Cluster API
+// core provider and the Kubernetes version of the management cluster.
+// Nb. release series not listed here are not checked; when adding a new release series, please extend the matrix.
+var coreProviderKubernetesCompatibility = map[string]kubernetesVersionRange{
+	"v0.3": {Min: "v1.16.0", Max: "v1.19"},
+}
+
+// validateKubernetesCompatibility checks the Kubernetes version of the management cluster is supported by the given
+// version of a provider; an error is returned if the Kubernetes version is older than the minimum supported version,
+// while only a warning is reported if it is newer than the tested versions.
+// Nb. only the Cluster API core provider is checked.
+func validateKubernetesCompatibility(proxy Proxy, provider clusterctlv1.Provider, providerVersion string) error {
+	log := logf.Log
+
+	if provider.GetProviderType() != clusterctlv1.CoreProviderType || provider.ProviderName != config.ClusterAPIProviderName {
+		return nil
+	}
+
+	v, err := version.ParseSemantic(providerVersion)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse version %q for provider %q", providerVersion, provider.InstanceName())
+	}
+
+	releaseSeries := fmt.Sprintf("v%d.%d", v.Major(), v.Minor())
+	supported, ok := coreProviderKubernetesCompatibility[releaseSeries]
+	if !ok {
+		log.V(1).Info("No Kubernetes compatibility information for the provider release series, skipping the check", "Provider", provider.InstanceName(), "ReleaseSeries", releaseSeries)
+		return nil
+	}
+
+	serverVersion, err := proxy.GetServerVersion()
+	if err != nil {
+		return err
+	}
+
+	if serverVersion.LessThan(version.MustParseGeneric(supported.Min)) {
+		return errors.Errorf("the management cluster Kubernetes version %s is not supported by %s %s: the minimum supported version is %s", serverVersion, provider.InstanceName(), providerVersion, supported.Min)
+	}
+
+	max := version.MustParseGeneric(supported.Max)
+	if serverVersion.Major() > max.Major() || (serverVersion.Major() == max.Major() && serverVersion.Minor() > max.Minor()) {
+		log.Info("Warning: the management cluster Kubernetes version is newer than the versions tested with the provider", "Provider", provider.InstanceName(), "Version", providerVersion, "KubernetesVersion", serverVersion.String(), "MaxTestedKubernetesVersion", supported.Max)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_validateKubernetesCompatibility(t *testing.T) {
+	core := fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v0.3.0", "capi-system", "")
+	infra := fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v0.3.0", "infra-system", "")
+
+	type args struct {
+		provider        clusterctlv1.Provider
+		providerVersion string
+		serverVersion   string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "pass if the Kubernetes version is supported",
+			args: args{
+				provider:        core,
+				providerVersion: "v0.3.10",
+				serverVersion:   "v1.18.2",
+			},
+			wantErr: false,
+		},
+		{
+			name: "pass if the Kubernetes version is newer than the tested versions",
+			args: args{
+				provider:        core,
+				providerVersion: "v0.3.10",
+				serverVersion:   "v1.20.0",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fails if the Kubernetes version is older than the minimum supported version",
+			args: args{
+				provider:        core,
+				providerVersion: "v0.3.10",
+				serverVersion:   "v1.15.3",
+			},
+			wantErr: true,
+		},
+		{
+			name: "pass if there is no compatibility information for the release series",
+			args: args{
+				provider:        core,
+				providerVersion: "v0.4.0",
+				serverVersion:   "v1.15.3",
+			},
+			wantErr: false,
+		},
+		{
+			name: "pass for providers other than the core provider",
+			args: args{
+				provider:        infra,
+				providerVersion: "v0.3.10",
+				serverVersion:   "v1.15.3",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fails if the provider version is invalid",
+			args: args{
+				provider:        core,
+				providerVersion: "foo",
+				serverVersion:   "v1.18.2",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			proxy := test.NewFakeProxy().WithServerVersion(tt.args.serverVersion)
+			err := validateKubernetesCompatibility(proxy, tt.args.provider, tt.args.providerVersion)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/pkg/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -33,22 +34,59 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	// FieldManager is the field manager used by clusterctl when applying provider components.
+	// Nb. Objects created by previous versions of clusterctl are managed by the field manager the API server derives
+	// from the clusterctl user agent, with the Update operation, so applying them would report conflicts on every
+	// field changed by the new version of the provider; such objects are adopted by forcing ownership, see createObj.
+	FieldManager = "clusterctl"
+)
+
+// CreateOptions defines the options for creating provider components in the management cluster.
+type CreateOptions struct {
+	// ForceOwnership forces clusterctl to take ownership of the fields managed by other field managers,
+	// e.g. fields of provider components edited by hand. If false, the operation fails in case of conflicts.
+	ForceOwnership bool
+}
+
 type DeleteOptions struct {
 	Provider         clusterctlv1.Provider
 	IncludeNamespace bool
 	IncludeCRDs      bool
 }
 
+// DeleteStaleOptions defines the options for deleting stale provider components from the management cluster.
+type DeleteStaleOptions struct {
+	// Provider is the provider instance whose components have just been applied.
+	Provider clusterctlv1.Provider
+
+	// Providers is the list of providers in the inventory; the components belonging to one of those providers
+	// are preserved, unless they belong to Provider and they have been applied by a different version of it.
+	Providers []clusterctlv1.Provider
+}
+
 // ComponentsClient has methods to work with provider components in the cluster.
 type ComponentsClient interface {
 	// Create creates the provider components in the management cluster.
-	Create(objs []unstructured.Unstructured) error
+	// Components are applied using server-side apply with the clusterctl field manager, so changes
+	// made by other field managers are detected and reported as conflicts, unless ownership is forced.
+	Create(objs []unstructured.Unstructured, options CreateOptions) error
 
 	// Delete deletes the provider components from the management cluster.
 	// The operation is designed to prevent accidental deletion of user created objects, so
 	// it is required to explicitly opt-in for the deletion of the namespace where the provider components are hosted
 	// and for the deletion of the provider's CRDs.
 	Delete(options DeleteOptions) error
+
+	// PlanDelete returns the provider components Delete would delete with the given options, without deleting them.
+	PlanDelete(options DeleteOptions) (*ProviderDeletePlan, error)
+
+	// DeleteStale deletes the components in the namespace of a provider instance that are left over by a previous
+	// installation, e.g. objects removed or renamed by a new version of the provider, objects of a provider
+	// that has been renamed, or objects created by a failed install that never made it to the inventory.
+	// Stale components are identified by the clusterctl.cluster.x-k8s.io/instance and clusterctl.cluster.x-k8s.io/provider-version
+	// labels, so objects created by previous versions of clusterctl, namespaces and shared resources are never deleted.
+	DeleteStale(options DeleteStaleOptions) error
 }
 
 // providerComponents implements ComponentsClient.
@@ -56,65 +94,128 @@ type providerComponents struct {
 	proxy Proxy
 }
 
-func (p *providerComponents) Create(objs []unstructured.Unstructured) error {
+func (p *providerComponents) Create(objs []unstructured.Unstructured, options CreateOptions) error {
 	createComponentObjectBackoff := newWriteBackoff()
 	for i := range objs {
 		obj := objs[i]
 
 		// Create the Kubernetes object.
-		// Nb. The operation is wrapped in a retry loop to make Create more resilient to unexpected conditions.
+		// Nb. The operation is wrapped in a retry loop to make Create more resilient to unexpected conditions;
+		// conflicts are not retried, because they are not going to be solved by retrying.
+		var conflictErr error
 		if err := retryWithExponentialBackoff(createComponentObjectBackoff, func() error {
-			return p.createObj(obj)
+			err := p.createObj(obj, options)
+			if apierrors.IsConflict(errors.Cause(err)) {
+				conflictErr = err
+				return nil
+			}
+			return err
 		}); err != nil {
 			return err
 		}
+		if conflictErr != nil {
+			return conflictErr
+		}
 	}
 
 	return nil
 }
 
-func (p *providerComponents) createObj(obj unstructured.Unstructured) error {
+func (p *providerComponents) createObj(obj unstructured.Unstructured, options CreateOptions) error {
 	log := logf.Log
 	c, err := p.proxy.NewClient()
 	if err != nil {
 		return err
 	}
 
-	// check if the component already exists, and eventually update it
-	currentR := &unstructured.Unstructured{}
-	currentR.SetGroupVersionKind(obj.GroupVersionKind())
+	// Apply the component; this creates the component if it does not exists, otherwise the new object gets
+	// merged server side with the current one, and fields changed by other field managers are reported as conflicts.
+	log.V(5).Info("Applying", logf.UnstructuredToValues(obj)...)
+	obj.SetResourceVersion("")
+	obj.SetManagedFields(nil)
+
+	forceOwnership := options.ForceOwnership
+	if !forceOwnership {
+		adopt, err := p.shouldAdopt(c, obj)
+		if err != nil {
+			return err
+		}
+		if adopt {
+			log.V(5).Info("Adopting object created by a previous version of clusterctl", logf.UnstructuredToValues(obj)...)
+			forceOwnership = true
+		}
+	}
 
-	key := client.ObjectKey{
-		Namespace: obj.GetNamespace(),
-		Name:      obj.GetName(),
+	patchOptions := []client.PatchOption{client.FieldOwner(FieldManager)}
+	if forceOwnership {
+		patchOptions = append(patchOptions, client.ForceOwnership)
 	}
-	if err := c.Get(ctx, key, currentR); err != nil {
-		if !apierrors.IsNotFound(err) {
-			return errors.Wrapf(err, "failed to get current provider object")
+	if err := c.Patch(ctx, &obj, client.Apply, patchOptions...); err != nil {
+		if apierrors.IsConflict(err) {
+			return errors.Wrapf(err, "failed to apply provider object %s, %s/%s: the object has fields managed by other field managers, e.g. because it has been edited by hand; "+
+				"revert the changes or use clusterctl upgrade to take ownership of the conflicting fields", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
 		}
+		return errors.Wrapf(err, "failed to apply provider object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+	}
+	return nil
+}
 
-		//if it does not exists, create the component
-		log.V(5).Info("Creating", logf.UnstructuredToValues(obj)...)
-		if err := c.Create(ctx, &obj); err != nil {
-			return errors.Wrapf(err, "failed to create provider object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+// shouldAdopt returns true if the object exists, it is labeled as managed by clusterctl, and it has never been
+// applied by the clusterctl field manager, i.e. it has been created by a previous version of clusterctl.
+func (p *providerComponents) shouldAdopt(c client.Client, obj unstructured.Unstructured) (bool, error) {
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(obj.GroupVersionKind())
+	key := client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	if err := c.Get(ctx, key, current); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
 		}
-		return nil
+		return false, errors.Wrapf(err, "failed to get provider object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
 	}
 
-	// otherwise update the component
-	// NB. we are using client.Merge PatchOption so the new objects gets compared with the current one server side
-	log.V(5).Info("Patching", logf.UnstructuredToValues(obj)...)
-	obj.SetResourceVersion(currentR.GetResourceVersion())
-	if err := c.Patch(ctx, &obj, client.Merge); err != nil {
-		return errors.Wrapf(err, "failed to patch provider object")
+	if _, ok := current.GetLabels()[clusterctlv1.ClusterctlLabelName]; !ok {
+		return false, nil
 	}
-	return nil
+	for _, e := range current.GetManagedFields() {
+		if e.Manager == FieldManager && e.Operation == metav1.ManagedFieldsOperationApply {
+			return false, nil
+		}
+	}
+	return true, nil
 }
 
 func (p *providerComponents) Delete(options DeleteOptions) error {
 	log := logf.Log
 	log.Info("Deleting", "Provider", options.Provider.Name, "Version", options.Provider.Version, "TargetNamespace", options.Provider.Namespace)
 
+	resourcesToDelete, err := p.objectsToDelete(options)
+	if err != nil {
+		return err
+	}
+
+	namespacesToDelete := sets.NewString()
+	for _, obj := range resourcesToDelete {
+		if obj.GetKind() == "Namespace" {
+			namespacesToDelete.Insert(obj.GetName())
+		}
+	}
+	log.V(1).Info("Deleting objects", "Provider", options.Provider.Name, "Count", len(resourcesToDelete), "Namespaces", namespacesToDelete.List())
+
+	// Delete all the provider components, skipping the objects in the namespaces that are going to be deleted,
+	// because everything that is contained in the namespace will be deleted by the Namespace controller.
+	return p.deleteObjs(resourcesToDelete, namespacesToDelete)
+}
+
+func (p *providerComponents) PlanDelete(options DeleteOptions) (*ProviderDeletePlan, error) {
+	resourcesToDelete, err := p.objectsToDelete(options)
+	if err != nil {
+		return nil, err
+	}
+	return planProviderDelete(options.Provider, resourcesToDelete), nil
+}
+
+// objectsToDelete returns the provider components to be deleted according to the delete options.
+func (p *providerComponents) objectsToDelete(options DeleteOptions) ([]unstructured.Unstructured, error) {
 	// Fetch all the components belonging to a provider.
 	// We want that the delete operation is able to clean-up everything in a the most common use case that is
 	// single-tenant management clusters. However, the downside of this is that this operation might be destructive
@@ -134,12 +235,11 @@ func (p *providerComponents) Delete(options DeleteOptions) error {
 
 	resources, err := p.proxy.ListResources(labels, namespaces...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Filter the resources according to the delete options
 	resourcesToDelete := []unstructured.Unstructured{}
-	namespacesToDelete := sets.NewString()
 	instanceNamespacePrefix := fmt.Sprintf("%s-", options.Provider.Namespace)
 	for _, obj := range resources {
 		// If the CRDs (and by extensions, all the shared resources) should NOT be deleted, skip it;
@@ -156,12 +256,11 @@ func (p *providerComponents) Delete(options DeleteOptions) error {
 			if obj.GetName() != options.Provider.Namespace {
 				continue
 			}
-			// If the  Namespace should NOT be deleted, skip it, otherwise keep track of the namespaces we are deleting;
+			// If the  Namespace should NOT be deleted, skip it;
 			// NB. Skipping Namespaces deletion ensures that also the objects hosted in the namespace but without the "clusterctl.cluster.x-k8s.io" and the "cluster.x-k8s.io/provider" label are not deleted.
 			if !options.IncludeNamespace {
 				continue
 			}
-			namespacesToDelete.Insert(obj.GetName())
 		}
 
 		// If not a shared resource or not a namespace
@@ -170,8 +269,13 @@ func (p *providerComponents) Delete(options DeleteOptions) error {
 			// This is required because there are cluster resources like e.g. ClusterRoles and ClusterRoleBinding, which are instance specific;
 			// During the installation, clusterctl adds the instance namespace prefix to such resources (see fixRBAC), and so we can rely
 			// on that for deleting only the global resources belonging the the instance we are processing.
+			// Cluster resources applied by recent versions of clusterctl have the instance label, and this takes precedence over the name prefix.
 			if util.IsClusterResource(obj.GetKind()) {
-				if !strings.HasPrefix(obj.GetName(), instanceNamespacePrefix) {
+				if instance, ok := obj.GetLabels()[clusterctlv1.ClusterctlInstanceLabelName]; ok {
+					if instance != options.Provider.Namespace {
+						continue
+					}
+				} else if !strings.HasPrefix(obj.GetName(), instanceNamespacePrefix) {
 					continue
 				}
 			}
@@ -180,25 +284,86 @@ func (p *providerComponents) Delete(options DeleteOptions) error {
 		resourcesToDelete = append(resourcesToDelete, obj)
 	}
 
-	// Delete all the provider components.
+	return resourcesToDelete, nil
+}
+
+func (p *providerComponents) DeleteStale(options DeleteStaleOptions) error {
+	log := logf.Log
+
+	// Fetch all the components belonging to the provider instances hosted in the provider namespace.
+	labels := map[string]string{
+		clusterctlv1.ClusterctlInstanceLabelName: options.Provider.Namespace,
+	}
+	resources, err := p.proxy.ListResources(labels, options.Provider.Namespace)
+	if err != nil {
+		return err
+	}
+
+	// Keep track of the providers in the inventory with an instance in the provider namespace.
+	inventoryProviders := sets.NewString()
+	for _, provider := range options.Providers {
+		if provider.Namespace == options.Provider.Namespace {
+			inventoryProviders.Insert(provider.ManifestLabel())
+		}
+	}
+	version := repository.VersionLabelValue(options.Provider.Version)
+
+	resourcesToDelete := []unstructured.Unstructured{}
+	for _, obj := range resources {
+		labels := obj.GetLabels()
+		if _, ok := labels[clusterctlv1.ClusterctlLabelName]; !ok || labels[clusterctlv1.ClusterctlInstanceLabelName] != options.Provider.Namespace {
+			continue
+		}
+
+		// Never delete namespaces, inventory entries and shared resources; they are managed by clusterctl init and clusterctl delete.
+		if obj.GroupVersionKind().Kind == "Namespace" || util.IsSharedResource(obj) {
+			continue
+		}
+		if _, ok := labels[clusterctlv1.ClusterctlCoreLabelName]; ok {
+			continue
+		}
+
+		// Components of the provider are stale if applied by a different version of the provider, while components
+		// of other providers are stale if the provider is not in the inventory.
+		providerLabel := labels[clusterv1.ProviderLabelName]
+		switch {
+		case providerLabel == options.Provider.ManifestLabel():
+			if labels[clusterctlv1.ClusterctlProviderVersionLabelName] == version {
+				continue
+			}
+		case inventoryProviders.Has(providerLabel):
+			continue
+		}
+
+		resourcesToDelete = append(resourcesToDelete, obj)
+	}
+
+	if len(resourcesToDelete) > 0 {
+		log.V(1).Info("Deleting stale objects", "Provider", options.Provider.ManifestLabel(), "Version", options.Provider.Version, "TargetNamespace", options.Provider.Namespace, "Count", len(resourcesToDelete))
+	}
+	return p.deleteObjs(resourcesToDelete, sets.NewString())
+}
+
+// deleteObjs deletes a list of objects, skipping the objects in the given namespaces.
+// Objects are deleted with background propagation, so the objects created by the provider components, e.g. the
+// ReplicaSets and Pods of a Deployment, are garbage collected following the ownerReferences chain.
+func (p *providerComponents) deleteObjs(objs []unstructured.Unstructured, skipNamespaces sets.String) error {
+	log := logf.Log
 	cs, err := p.proxy.NewClient()
 	if err != nil {
 		return err
 	}
 
 	errList := []error{}
-	for i := range resourcesToDelete {
-		obj := resourcesToDelete[i]
+	for i := range objs {
+		obj := objs[i]
 
-		// if the objects is in a namespace that is going to be deleted, skip deletion
-		// because everything that is contained in the namespace will be deleted by the Namespace controller
-		if namespacesToDelete.Has(obj.GetNamespace()) {
+		if skipNamespaces.Has(obj.GetNamespace()) {
 			continue
 		}
 
-		// Otherwise delete the object
 		log.V(5).Info("Deleting", logf.UnstructuredToValues(obj)...)
-		if err := cs.Delete(ctx, &obj); err != nil {
+		if err := cs.Delete(ctx, &obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
 			if apierrors.IsNotFound(err) {
 				// Tolerate IsNotFound error that might happen because we are not enforcing a deletion order
 				// that considers relation across objects (e.g. Deployments -> ReplicaSets -> Pods)
@@ -0,0 +1,113 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
+)
+
+// ProviderDeletePlan describes the components of a provider instance that would be deleted from the management cluster,
+// grouped by their role.
+type ProviderDeletePlan struct {
+	// Provider is the provider instance being deleted, in the namespace/name form.
+	Provider string `json:"provider"`
+
+	// Version of the provider instance being deleted.
+	Version string `json:"version,omitempty"`
+
+	// Namespaces hosting the provider; all the objects contained in a namespace are deleted with it, including the
+	// objects not installed by clusterctl.
+	Namespaces []DeletePlanObject `json:"namespaces,omitempty"`
+
+	// CustomResourceDefinitions of the provider; all the objects of the kinds they define are deleted with them.
+	CustomResourceDefinitions []DeletePlanObject `json:"customResourceDefinitions,omitempty"`
+
+	// Webhooks are the webhook configurations of the provider and the components hosted in the webhook namespace.
+	Webhooks []DeletePlanObject `json:"webhooks,omitempty"`
+
+	// Resources are the other components of the provider, e.g. the Deployment of the controller and its RBAC rules.
+	Resources []DeletePlanObject `json:"resources,omitempty"`
+}
+
+// DeletePlanObject is an object in a ProviderDeletePlan.
+type DeletePlanObject struct {
+	// APIVersion of the object.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the object.
+	Kind string `json:"kind"`
+
+	// Namespace of the object; it is empty for cluster-wide objects.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the object.
+	Name string `json:"name"`
+}
+
+// Count returns the number of objects that would be deleted.
+func (p *ProviderDeletePlan) Count() int {
+	return len(p.Namespaces) + len(p.CustomResourceDefinitions) + len(p.Webhooks) + len(p.Resources)
+}
+
+// planProviderDelete returns the ProviderDeletePlan for the components of a provider instance to be deleted.
+func planProviderDelete(provider clusterctlv1.Provider, objs []unstructured.Unstructured) *ProviderDeletePlan {
+	plan := &ProviderDeletePlan{
+		Provider: provider.InstanceName(),
+		Version:  provider.Version,
+	}
+	for _, obj := range objs {
+		o := DeletePlanObject{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+		}
+		switch {
+		case o.Kind == "Namespace":
+			plan.Namespaces = append(plan.Namespaces, o)
+		case o.Kind == "CustomResourceDefinition":
+			plan.CustomResourceDefinitions = append(plan.CustomResourceDefinitions, o)
+		case o.Kind == "ValidatingWebhookConfiguration", o.Kind == "MutatingWebhookConfiguration", o.Namespace == repository.WebhookNamespaceName:
+			plan.Webhooks = append(plan.Webhooks, o)
+		default:
+			plan.Resources = append(plan.Resources, o)
+		}
+	}
+
+	for _, list := range [][]DeletePlanObject{plan.Namespaces, plan.CustomResourceDefinitions, plan.Webhooks, plan.Resources} {
+		sortDeletePlanObjects(list)
+	}
+	return plan
+}
+
+// sortDeletePlanObjects sorts the objects by kind, namespace and name.
+func sortDeletePlanObjects(objs []DeletePlanObject) {
+	sort.Slice(objs, func(i, j int) bool {
+		a, b := objs[i], objs[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+}
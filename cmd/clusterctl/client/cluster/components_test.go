@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -43,6 +44,13 @@ func Test_providerComponents_Delete(t *testing.T) {
 		clusterctlv1.ClusterctlResourceLifecyleLabelName: string(clusterctlv1.ResourceLifecycleShared),
 	}
 
+	instanceLabels := func(namespace string) map[string]string {
+		return map[string]string{
+			clusterv1.ProviderLabelName:              "infrastructure-infra",
+			clusterctlv1.ClusterctlInstanceLabelName: namespace,
+		}
+	}
+
 	crd := unstructured.Unstructured{}
 	crd.SetAPIVersion("apiextensions.k8s.io/v1beta1")
 	crd.SetKind("CustomResourceDefinition")
@@ -132,6 +140,26 @@ func Test_providerComponents_Delete(t *testing.T) {
 				Labels: labels,
 			},
 		},
+		// A cluster-wide provider component with the instance label, but without the namespace prefix (should always be deleted)
+		&rbacv1.ClusterRole{
+			TypeMeta: metav1.TypeMeta{
+				Kind: "ClusterRole",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "instance-cluster-role",
+				Labels: instanceLabels("ns1"),
+			},
+		},
+		// A cluster-wide component of another instance of the provider, with the namespace prefix (should never be deleted)
+		&rbacv1.ClusterRole{
+			TypeMeta: metav1.TypeMeta{
+				Kind: "ClusterRole",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "ns1-other-instance-cluster-role",
+				Labels: instanceLabels("ns1-other"),
+			},
+		},
 		// Another object out of the provider namespace (should never be deleted)
 		&corev1.Pod{
 			TypeMeta: metav1.TypeMeta{
@@ -169,16 +197,18 @@ func Test_providerComponents_Delete(t *testing.T) {
 				includeCRD:       false,
 			},
 			wantDiff: []wantDiff{
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "ns1"}, deleted: false},                                                       // namespace should be preserved
-				{object: corev1.ObjectReference{APIVersion: "apiextensions.k8s.io/v1beta1", Kind: "CustomResourceDefinition", Name: "crd1"}, deleted: false},             // crd should be preserved
-				{object: corev1.ObjectReference{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "MutatingWebhookConfiguration", Name: "mwh1"}, deleted: false}, // MutatingWebhookConfiguration should be preserved
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: repository.WebhookNamespaceName}, deleted: false},                             // capi-webhook-system namespace should never be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: repository.WebhookNamespaceName, Name: "podx"}, deleted: false},                // provider objects in the capi-webhook-system namespace should be preserved
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod1"}, deleted: true},                                           // provider components should be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod2"}, deleted: false},                                          // other objects in the namespace should not be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns2", Name: "pod3"}, deleted: false},                                          // this object is in another namespace, and should never be touched by delete
-				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "ns1-cluster-role"}, deleted: true},               // cluster-wide provider components should be deleted
-				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "some-cluster-role"}, deleted: false},             // other cluster-wide objects should be preserved
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "ns1"}, deleted: false},                                                         // namespace should be preserved
+				{object: corev1.ObjectReference{APIVersion: "apiextensions.k8s.io/v1beta1", Kind: "CustomResourceDefinition", Name: "crd1"}, deleted: false},               // crd should be preserved
+				{object: corev1.ObjectReference{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "MutatingWebhookConfiguration", Name: "mwh1"}, deleted: false},   // MutatingWebhookConfiguration should be preserved
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: repository.WebhookNamespaceName}, deleted: false},                               // capi-webhook-system namespace should never be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: repository.WebhookNamespaceName, Name: "podx"}, deleted: false},                  // provider objects in the capi-webhook-system namespace should be preserved
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod1"}, deleted: true},                                             // provider components should be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod2"}, deleted: false},                                            // other objects in the namespace should not be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns2", Name: "pod3"}, deleted: false},                                            // this object is in another namespace, and should never be touched by delete
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "ns1-cluster-role"}, deleted: true},                 // cluster-wide provider components should be deleted
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "some-cluster-role"}, deleted: false},               // other cluster-wide objects should be preserved
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "instance-cluster-role"}, deleted: true},            // cluster-wide components with the instance label should be deleted
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "ns1-other-instance-cluster-role"}, deleted: false}, // cluster-wide components of other instances should be preserved
 			},
 			wantErr: false,
 		},
@@ -190,16 +220,18 @@ func Test_providerComponents_Delete(t *testing.T) {
 				includeCRD:       false,
 			},
 			wantDiff: []wantDiff{
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "ns1"}, deleted: true},                                                        // namespace should be deleted
-				{object: corev1.ObjectReference{APIVersion: "apiextensions.k8s.io/v1beta1", Kind: "CustomResourceDefinition", Name: "crd1"}, deleted: false},             // crd should be preserved
-				{object: corev1.ObjectReference{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "MutatingWebhookConfiguration", Name: "mwh1"}, deleted: false}, // MutatingWebhookConfiguration should be preserved
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: repository.WebhookNamespaceName}, deleted: false},                             // capi-webhook-system namespace should never be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: repository.WebhookNamespaceName, Name: "podx"}, deleted: false},                // provider objects in the capi-webhook-system namespace should be preserved
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod1"}, deleted: true},                                           // provider components should be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod2"}, deleted: true},                                           // other objects in the namespace goes away when deleting the namespace
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns2", Name: "pod3"}, deleted: false},                                          // this object is in another namespace, and should never be touched by delete
-				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "ns1-cluster-role"}, deleted: true},               // cluster-wide provider components should be deleted
-				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "some-cluster-role"}, deleted: false},             // other cluster-wide objects should be preserved
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "ns1"}, deleted: true},                                                          // namespace should be deleted
+				{object: corev1.ObjectReference{APIVersion: "apiextensions.k8s.io/v1beta1", Kind: "CustomResourceDefinition", Name: "crd1"}, deleted: false},               // crd should be preserved
+				{object: corev1.ObjectReference{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "MutatingWebhookConfiguration", Name: "mwh1"}, deleted: false},   // MutatingWebhookConfiguration should be preserved
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: repository.WebhookNamespaceName}, deleted: false},                               // capi-webhook-system namespace should never be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: repository.WebhookNamespaceName, Name: "podx"}, deleted: false},                  // provider objects in the capi-webhook-system namespace should be preserved
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod1"}, deleted: true},                                             // provider components should be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod2"}, deleted: true},                                             // other objects in the namespace goes away when deleting the namespace
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns2", Name: "pod3"}, deleted: false},                                            // this object is in another namespace, and should never be touched by delete
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "ns1-cluster-role"}, deleted: true},                 // cluster-wide provider components should be deleted
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "some-cluster-role"}, deleted: false},               // other cluster-wide objects should be preserved
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "instance-cluster-role"}, deleted: true},            // cluster-wide components with the instance label should be deleted
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "ns1-other-instance-cluster-role"}, deleted: false}, // cluster-wide components of other instances should be preserved
 			},
 			wantErr: false,
 		},
@@ -211,16 +243,18 @@ func Test_providerComponents_Delete(t *testing.T) {
 				includeCRD:       true,
 			},
 			wantDiff: []wantDiff{
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "ns1"}, deleted: false},                                                      // namespace should be preserved
-				{object: corev1.ObjectReference{APIVersion: "apiextensions.k8s.io/v1beta1", Kind: "CustomResourceDefinition", Name: "crd1"}, deleted: true},             // crd should be deleted
-				{object: corev1.ObjectReference{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "MutatingWebhookConfiguration", Name: "mwh1"}, deleted: true}, // MutatingWebhookConfiguration should be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: repository.WebhookNamespaceName}, deleted: false},                            // capi-webhook-system namespace should never be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: repository.WebhookNamespaceName, Name: "podx"}, deleted: true},                // provider objects in the capi-webhook-system namespace should be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod1"}, deleted: true},                                          // provider components should be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod2"}, deleted: false},                                         // other objects in the namespace should not be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns2", Name: "pod3"}, deleted: false},                                         // this object is in another namespace, and should never be touched by delete
-				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "ns1-cluster-role"}, deleted: true},              // cluster-wide provider components should be deleted
-				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "some-cluster-role"}, deleted: false},            // other cluster-wide objects should be preserved
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "ns1"}, deleted: false},                                                         // namespace should be preserved
+				{object: corev1.ObjectReference{APIVersion: "apiextensions.k8s.io/v1beta1", Kind: "CustomResourceDefinition", Name: "crd1"}, deleted: true},                // crd should be deleted
+				{object: corev1.ObjectReference{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "MutatingWebhookConfiguration", Name: "mwh1"}, deleted: true},    // MutatingWebhookConfiguration should be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: repository.WebhookNamespaceName}, deleted: false},                               // capi-webhook-system namespace should never be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: repository.WebhookNamespaceName, Name: "podx"}, deleted: true},                   // provider objects in the capi-webhook-system namespace should be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod1"}, deleted: true},                                             // provider components should be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod2"}, deleted: false},                                            // other objects in the namespace should not be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns2", Name: "pod3"}, deleted: false},                                            // this object is in another namespace, and should never be touched by delete
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "ns1-cluster-role"}, deleted: true},                 // cluster-wide provider components should be deleted
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "some-cluster-role"}, deleted: false},               // other cluster-wide objects should be preserved
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "instance-cluster-role"}, deleted: true},            // cluster-wide components with the instance label should be deleted
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "ns1-other-instance-cluster-role"}, deleted: false}, // cluster-wide components of other instances should be preserved
 			},
 			wantErr: false,
 		},
@@ -232,16 +266,18 @@ func Test_providerComponents_Delete(t *testing.T) {
 				includeCRD:       true,
 			},
 			wantDiff: []wantDiff{
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "ns1"}, deleted: true},                                                       // namespace should be deleted
-				{object: corev1.ObjectReference{APIVersion: "apiextensions.k8s.io/v1beta1", Kind: "CustomResourceDefinition", Name: "crd1"}, deleted: true},             // crd should be deleted
-				{object: corev1.ObjectReference{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "MutatingWebhookConfiguration", Name: "mwh1"}, deleted: true}, // MutatingWebhookConfiguration should be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: repository.WebhookNamespaceName}, deleted: false},                            // capi-webhook-namespace should never be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: repository.WebhookNamespaceName, Name: "podx"}, deleted: true},                // provider objects in the capi-webhook-namespace should be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod1"}, deleted: true},                                          // provider components should be deleted
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod2"}, deleted: true},                                          // other objects in the namespace goes away when deleting the namespace
-				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns2", Name: "pod3"}, deleted: false},                                         // this object is in another namespace, and should never be touched by delete
-				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "ns1-cluster-role"}, deleted: true},              // cluster-wide provider components should be deleted
-				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "some-cluster-role"}, deleted: false},            // other cluster-wide objects should be preserved
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "ns1"}, deleted: true},                                                          // namespace should be deleted
+				{object: corev1.ObjectReference{APIVersion: "apiextensions.k8s.io/v1beta1", Kind: "CustomResourceDefinition", Name: "crd1"}, deleted: true},                // crd should be deleted
+				{object: corev1.ObjectReference{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "MutatingWebhookConfiguration", Name: "mwh1"}, deleted: true},    // MutatingWebhookConfiguration should be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: repository.WebhookNamespaceName}, deleted: false},                               // capi-webhook-namespace should never be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: repository.WebhookNamespaceName, Name: "podx"}, deleted: true},                   // provider objects in the capi-webhook-namespace should be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod1"}, deleted: true},                                             // provider components should be deleted
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod2"}, deleted: true},                                             // other objects in the namespace goes away when deleting the namespace
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns2", Name: "pod3"}, deleted: false},                                            // this object is in another namespace, and should never be touched by delete
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "ns1-cluster-role"}, deleted: true},                 // cluster-wide provider components should be deleted
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "some-cluster-role"}, deleted: false},               // other cluster-wide objects should be preserved
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "instance-cluster-role"}, deleted: true},            // cluster-wide components with the instance label should be deleted
+				{object: corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "ns1-other-instance-cluster-role"}, deleted: false}, // cluster-wide components of other instances should be preserved
 			},
 			wantErr: false,
 		},
@@ -252,7 +288,35 @@ func Test_providerComponents_Delete(t *testing.T) {
 
 			proxy := test.NewFakeProxy().WithObjs(initObjs...)
 			c := newComponentsClient(proxy)
-			err := c.Delete(DeleteOptions{
+			plan, err := c.PlanDelete(DeleteOptions{
+				Provider:         tt.args.provider,
+				IncludeNamespace: tt.args.includeNamespace,
+				IncludeCRDs:      tt.args.includeCRD,
+			})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			// The plan lists the objects being deleted, except the ones deleted by the namespace controller.
+			planned := []DeletePlanObject{}
+			for _, objs := range [][]DeletePlanObject{plan.Namespaces, plan.CustomResourceDefinitions, plan.Webhooks, plan.Resources} {
+				planned = append(planned, objs...)
+			}
+			for _, want := range tt.wantDiff {
+				if want.object.Name == "pod2" && tt.args.includeNamespace {
+					continue
+				}
+				o := DeletePlanObject{APIVersion: want.object.APIVersion, Kind: want.object.Kind, Namespace: want.object.Namespace, Name: want.object.Name}
+				if want.deleted {
+					g.Expect(planned).To(ContainElement(o))
+				} else {
+					g.Expect(planned).NotTo(ContainElement(o))
+				}
+			}
+
+			err = c.Delete(DeleteOptions{
 				Provider:         tt.args.provider,
 				IncludeNamespace: tt.args.includeNamespace,
 				IncludeCRDs:      tt.args.includeCRD,
@@ -296,3 +360,242 @@ func Test_providerComponents_Delete(t *testing.T) {
 		})
 	}
 }
+
+func Test_planProviderDelete(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := func(apiVersion, kind, namespace, name string) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetNamespace(namespace)
+		u.SetName(name)
+		return u
+	}
+
+	provider := clusterctlv1.Provider{ObjectMeta: metav1.ObjectMeta{Name: "infrastructure-infra", Namespace: "ns1"}, ProviderName: "infra", Type: string(clusterctlv1.InfrastructureProviderType), Version: "v1.0.0"}
+	plan := planProviderDelete(provider, []unstructured.Unstructured{
+		obj("v1", "ServiceAccount", "ns1", "manager"),
+		obj("apps/v1", "Deployment", "ns1", "manager"),
+		obj("v1", "Namespace", "", "ns1"),
+		obj("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "infraclusters.infrastructure.cluster.x-k8s.io"),
+		obj("admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "", "validating-webhook-configuration"),
+		obj("apps/v1", "Deployment", repository.WebhookNamespaceName, "webhook"),
+	})
+
+	g.Expect(plan).To(Equal(&ProviderDeletePlan{
+		Provider: "ns1/infrastructure-infra",
+		Version:  "v1.0.0",
+		Namespaces: []DeletePlanObject{
+			{APIVersion: "v1", Kind: "Namespace", Name: "ns1"},
+		},
+		CustomResourceDefinitions: []DeletePlanObject{
+			{APIVersion: "apiextensions.k8s.io/v1", Kind: "CustomResourceDefinition", Name: "infraclusters.infrastructure.cluster.x-k8s.io"},
+		},
+		Webhooks: []DeletePlanObject{
+			{APIVersion: "apps/v1", Kind: "Deployment", Namespace: repository.WebhookNamespaceName, Name: "webhook"},
+			{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "ValidatingWebhookConfiguration", Name: "validating-webhook-configuration"},
+		},
+		Resources: []DeletePlanObject{
+			{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "ns1", Name: "manager"},
+			{APIVersion: "v1", Kind: "ServiceAccount", Namespace: "ns1", Name: "manager"},
+		},
+	}))
+	g.Expect(plan.Count()).To(Equal(6))
+}
+
+func Test_providerComponents_DeleteStale(t *testing.T) {
+	g := NewWithT(t)
+
+	componentLabels := func(provider, version, namespace string) map[string]string {
+		return map[string]string{
+			clusterctlv1.ClusterctlLabelName:                "",
+			clusterv1.ProviderLabelName:                     provider,
+			clusterctlv1.ClusterctlProviderVersionLabelName: version,
+			clusterctlv1.ClusterctlInstanceLabelName:        namespace,
+		}
+	}
+	inventoryLabels := componentLabels("infrastructure-infra", "v1.0.0", "ns1")
+	inventoryLabels[clusterctlv1.ClusterctlCoreLabelName] = "inventory"
+
+	initObjs := []runtime.Object{
+		// The provider namespace, applied by the previous version (should be preserved)
+		&corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: "ns1", Labels: componentLabels("infrastructure-infra", "v1.0.0", "ns1")},
+		},
+		// The inventory entry of the provider, applied by the previous version (should be preserved)
+		&clusterctlv1.Provider{
+			TypeMeta:   metav1.TypeMeta{Kind: "Provider", APIVersion: clusterctlv1.GroupVersion.String()},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "infrastructure-infra", Labels: inventoryLabels},
+		},
+		// A component of the current version of the provider (should be preserved)
+		&corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "current", Labels: componentLabels("infrastructure-infra", "v1.1.0", "ns1")},
+		},
+		// A component applied by the previous version of the provider (should be deleted)
+		&corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "renamed", Labels: componentLabels("infrastructure-infra", "v1.0.0", "ns1")},
+		},
+		// A cluster-wide component applied by the previous version of the provider (should be deleted)
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: "ns1-renamed", Labels: componentLabels("infrastructure-infra", "v1.0.0", "ns1")},
+		},
+		// A component of another provider in the inventory hosted in the same namespace (should be preserved)
+		&corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "other", Labels: componentLabels("bootstrap-other", "v0.1.0", "ns1")},
+		},
+		// A component of a provider not in the inventory, e.g. left over by a failed install (should be deleted)
+		&corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "leftover", Labels: componentLabels("infrastructure-old", "v0.1.0", "ns1")},
+		},
+		// A component of another instance of the provider (should be preserved)
+		&corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "other-instance", Labels: componentLabels("infrastructure-infra", "v1.0.0", "ns2")},
+		},
+	}
+
+	proxy := test.NewFakeProxy().WithObjs(initObjs...)
+	c := newComponentsClient(proxy)
+	g.Expect(c.DeleteStale(DeleteStaleOptions{
+		Provider: fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v1.1.0", "ns1", ""),
+		Providers: []clusterctlv1.Provider{
+			fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns1", ""),
+			fakeProvider("other", clusterctlv1.BootstrapProviderType, "v0.1.0", "ns1", ""),
+			fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns2", ""),
+		},
+	})).To(Succeed())
+
+	cs, err := proxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	wantDeleted := map[corev1.ObjectReference]bool{
+		{APIVersion: "v1", Kind: "Namespace", Name: "ns1"}:                                                                 false,
+		{APIVersion: clusterctlv1.GroupVersion.String(), Kind: "Provider", Namespace: "ns1", Name: "infrastructure-infra"}: false,
+		{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "current"}:                                                 false,
+		{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "renamed"}:                                                 true,
+		{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "ns1-renamed"}:                             true,
+		{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "other"}:                                                   false,
+		{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "leftover"}:                                                true,
+		{APIVersion: "v1", Kind: "Pod", Namespace: "ns2", Name: "other-instance"}:                                          false,
+	}
+	for ref, deleted := range wantDeleted {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(ref.APIVersion)
+		obj.SetKind(ref.Kind)
+
+		err := cs.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, obj)
+		if deleted {
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "%s %s/%s should be deleted", ref.Kind, ref.Namespace, ref.Name)
+			continue
+		}
+		g.Expect(err).NotTo(HaveOccurred(), "%s %s/%s should be preserved", ref.Kind, ref.Namespace, ref.Name)
+	}
+}
+
+func Test_providerComponents_Create(t *testing.T) {
+	existing := func(labels map[string]string, managedFields ...metav1.ManagedFieldsEntry) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:     "ns1",
+				Name:          "existing",
+				Labels:        labels,
+				ManagedFields: managedFields,
+			},
+			Data: map[string]string{"key": "old"},
+		}
+	}
+	clusterctlLabels := map[string]string{clusterctlv1.ClusterctlLabelName: ""}
+	appliedByClusterctl := metav1.ManagedFieldsEntry{Manager: FieldManager, Operation: metav1.ManagedFieldsOperationApply}
+	editedByHand := metav1.ManagedFieldsEntry{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate}
+
+	tests := []struct {
+		name     string
+		existing *corev1.ConfigMap
+		options  CreateOptions
+		wantErr  bool
+	}{
+		{
+			name:     "updates objects applied by clusterctl",
+			existing: existing(clusterctlLabels, appliedByClusterctl),
+		},
+		{
+			name:     "adopts objects created by previous versions of clusterctl",
+			existing: existing(clusterctlLabels),
+		},
+		{
+			name:     "fails for objects applied by clusterctl and edited by hand",
+			existing: existing(clusterctlLabels, appliedByClusterctl, editedByHand),
+			wantErr:  true,
+		},
+		{
+			name:     "fails for objects not created by clusterctl",
+			existing: existing(nil, editedByHand),
+			wantErr:  true,
+		},
+		{
+			name:     "takes ownership of objects edited by hand if ownership is forced",
+			existing: existing(clusterctlLabels, appliedByClusterctl, editedByHand),
+			options:  CreateOptions{ForceOwnership: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			proxy := test.NewFakeProxy().WithObjs(tt.existing)
+			c := newComponentsClient(proxy)
+
+			objs := []unstructured.Unstructured{
+				{
+					Object: map[string]interface{}{
+						"apiVersion": "v1",
+						"kind":       "ConfigMap",
+						"metadata": map[string]interface{}{
+							"namespace": "ns1",
+							"name":      "new",
+						},
+					},
+				},
+				{
+					Object: map[string]interface{}{
+						"apiVersion": "v1",
+						"kind":       "ConfigMap",
+						"metadata": map[string]interface{}{
+							"namespace": "ns1",
+							"name":      "existing",
+						},
+						"data": map[string]interface{}{"key": "new"},
+					},
+				},
+			}
+
+			err := c.Create(objs, tt.options)
+
+			cs, csErr := proxy.NewClient()
+			g.Expect(csErr).NotTo(HaveOccurred())
+			g.Expect(cs.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "new"}, &corev1.ConfigMap{})).To(Succeed())
+
+			existing := &corev1.ConfigMap{}
+			g.Expect(cs.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "existing"}, existing)).To(Succeed())
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(apierrors.IsConflict(errors.Cause(err))).To(BeTrue())
+				g.Expect(existing.Data).To(HaveKeyWithValue("key", "old"))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(existing.Data).To(HaveKeyWithValue("key", "new"))
+		})
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RestartedAtAnnotation is set on the Pod template of the provider Deployments to restart them, e.g. after
+	// rotating the provider credentials, in the same way as kubectl rollout restart.
+	RestartedAtAnnotation = "clusterctl.cluster.x-k8s.io/restartedAt"
+
+	// DefaultRotateCredentialsTimeout is the default time the provider Deployments are given to become available
+	// after rotating the credentials.
+	DefaultRotateCredentialsTimeout = 5 * time.Minute
+
+	rotateCredentialsPollInterval = 2 * time.Second
+)
+
+// RotateCredentialsOptions defines the options for rotating the credentials of a provider.
+type RotateCredentialsOptions struct {
+	// Provider whose credentials are rotated.
+	Provider clusterctlv1.Provider
+
+	// SecretName is the name of the Secret in the provider namespace holding the credentials; if empty, the only
+	// Opaque Secret of the provider components is used.
+	SecretName string
+
+	// Data are the new values of the credentials; keys not included in Data are left unchanged.
+	Data map[string][]byte
+
+	// Timeout is the time the provider Deployments are given to become available with the new credentials;
+	// it defaults to DefaultRotateCredentialsTimeout.
+	Timeout time.Duration
+
+	// Rollback restores the previous credentials if the provider Deployments do not become available with the
+	// new credentials.
+	Rollback bool
+}
+
+// CredentialsClient has methods to rotate the credentials used by the providers, e.g. the Secret with the
+// infrastructure credentials used by an infrastructure provider.
+type CredentialsClient interface {
+	// Rotate updates the credentials Secret of a provider, restarts the provider Deployments so they use the new
+	// credentials, and verifies the Deployments become available with all their Pods running without restarts;
+	// it returns the name of the Secret that has been updated.
+	Rotate(options RotateCredentialsOptions) (string, error)
+}
+
+// credentialsClient implements CredentialsClient.
+type credentialsClient struct {
+	proxy               Proxy
+	pollImmediateWaiter PollImmediateWaiter
+}
+
+// ensure credentialsClient implements CredentialsClient.
+var _ CredentialsClient = &credentialsClient{}
+
+// newCredentialsClient returns a credentialsClient.
+func newCredentialsClient(proxy Proxy, pollImmediateWaiter PollImmediateWaiter) *credentialsClient {
+	return &credentialsClient{
+		proxy:               proxy,
+		pollImmediateWaiter: pollImmediateWaiter,
+	}
+}
+
+func (r *credentialsClient) Rotate(options RotateCredentialsOptions) (string, error) {
+	log := logf.Log
+
+	if len(options.Data) == 0 {
+		return "", errors.New("the new credentials must not be empty")
+	}
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = DefaultRotateCredentialsTimeout
+	}
+
+	c, err := r.proxy.NewClient()
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := r.getCredentialsSecret(c, options.Provider, options.SecretName)
+	if err != nil {
+		return "", err
+	}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := c.List(ctx, deployments, client.InNamespace(options.Provider.Namespace), client.MatchingLabels{clusterv1.ProviderLabelName: options.Provider.ManifestLabel()}); err != nil {
+		return "", errors.Wrapf(err, "failed to list the Deployments of the %s provider", options.Provider.InstanceName())
+	}
+	if len(deployments.Items) == 0 {
+		return "", errors.Errorf("failed to find the Deployments of the %s provider", options.Provider.InstanceName())
+	}
+
+	// Nb. the new credentials are merged into the existing data, while the rollback restores exactly the previous
+	// data, dropping any key added by the new credentials.
+	previous := map[string][]byte{}
+	data := map[string][]byte{}
+	for k, v := range secret.Data {
+		previous[k] = v
+		data[k] = v
+	}
+	for k, v := range options.Data {
+		data[k] = v
+	}
+
+	log.Info("Rotating credentials", "Provider", options.Provider.InstanceName(), "Secret", secret.Name)
+	err = r.updateAndRestart(c, secret, data, deployments.Items, timeout)
+	if err == nil {
+		log.Info("Credentials rotated", "Provider", options.Provider.InstanceName(), "Secret", secret.Name)
+		return secret.Name, nil
+	}
+	if !options.Rollback {
+		return secret.Name, errors.Wrapf(err, "failed to verify the %s provider with the new credentials", options.Provider.InstanceName())
+	}
+
+	log.Info("The provider is not available with the new credentials, restoring the previous credentials", "Provider", options.Provider.InstanceName(), "Cause", err.Error())
+	if rollbackErr := r.updateAndRestart(c, secret, previous, deployments.Items, timeout); rollbackErr != nil {
+		return secret.Name, errors.Wrapf(rollbackErr, "failed to restore the previous credentials of the %s provider after failing to verify the new credentials (%v)", options.Provider.InstanceName(), err)
+	}
+	return secret.Name, errors.Wrapf(err, "failed to verify the %s provider with the new credentials, the previous credentials have been restored", options.Provider.InstanceName())
+}
+
+// getCredentialsSecret returns the Secret with the given name in the provider namespace or, if name is empty,
+// the only Opaque Secret of the provider components.
+func (r *credentialsClient) getCredentialsSecret(c client.Client, provider clusterctlv1.Provider, name string) (*corev1.Secret, error) {
+	if name != "" {
+		secret := &corev1.Secret{}
+		key := client.ObjectKey{Namespace: provider.Namespace, Name: name}
+		if err := c.Get(ctx, key, secret); err != nil {
+			return nil, errors.Wrapf(err, "failed to get the credentials Secret %s/%s", provider.Namespace, name)
+		}
+		return secret, nil
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := c.List(ctx, secrets, client.InNamespace(provider.Namespace), client.MatchingLabels{clusterv1.ProviderLabelName: provider.ManifestLabel()}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list the Secrets of the %s provider", provider.InstanceName())
+	}
+	var candidates []*corev1.Secret
+	for i := range secrets.Items {
+		s := &secrets.Items[i]
+		if s.Type == "" || s.Type == corev1.SecretTypeOpaque {
+			candidates = append(candidates, s)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, errors.Errorf("failed to find the credentials Secret of the %s provider, please specify the Secret name", provider.InstanceName())
+	case 1:
+		return candidates[0], nil
+	default:
+		names := make([]string, 0, len(candidates))
+		for _, s := range candidates {
+			names = append(names, s.Name)
+		}
+		sort.Strings(names)
+		return nil, errors.Errorf("the %s provider has more than one Secret (%s), please specify the name of the credentials Secret", provider.InstanceName(), strings.Join(names, ", "))
+	}
+}
+
+// updateAndRestart replaces the data of the Secret, restarts the Deployments and waits for them to be available.
+func (r *credentialsClient) updateAndRestart(c client.Client, secret *corev1.Secret, data map[string][]byte, deployments []appsv1.Deployment, timeout time.Duration) error {
+	log := logf.Log
+
+	secret.Data = data
+	if err := c.Update(ctx, secret); err != nil {
+		return errors.Wrapf(err, "failed to update the credentials Secret %s/%s", secret.Namespace, secret.Name)
+	}
+
+	restartedAt := time.Now().UTC().Format(time.RFC3339)
+	for i := range deployments {
+		d := &deployments[i]
+		log.V(1).Info("Restarting", "Deployment", d.Name, "Namespace", d.Namespace)
+		patch := client.MergeFrom(d.DeepCopy())
+		if d.Spec.Template.Annotations == nil {
+			d.Spec.Template.Annotations = map[string]string{}
+		}
+		d.Spec.Template.Annotations[RestartedAtAnnotation] = restartedAt
+		if err := c.Patch(ctx, d, patch); err != nil {
+			return errors.Wrapf(err, "failed to restart the Deployment %s/%s", d.Namespace, d.Name)
+		}
+	}
+
+	var notReady []string
+	err := r.pollImmediateWaiter(rotateCredentialsPollInterval, timeout, func() (bool, error) {
+		notReady = nil
+		for i := range deployments {
+			ready, err := isDeploymentRestarted(c, &deployments[i])
+			if err != nil {
+				return false, err
+			}
+			if !ready {
+				notReady = append(notReady, deployments[i].Name)
+			}
+		}
+		return len(notReady) == 0, nil
+	})
+	if err != nil {
+		if len(notReady) > 0 {
+			return errors.Wrapf(err, "the following Deployments are not available: %s", strings.Join(notReady, ", "))
+		}
+		return err
+	}
+	return nil
+}
+
+// isDeploymentRestarted returns true if a Deployment completed the rollout of the restart and all its Pods are
+// running without restarts; it returns an error if a Pod is restarting, e.g. because the new credentials are invalid.
+func isDeploymentRestarted(c client.Client, d *appsv1.Deployment) (bool, error) {
+	deployment := &appsv1.Deployment{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: d.Namespace, Name: d.Name}, deployment); err != nil {
+		return false, nil
+	}
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return false, nil
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	// Nb. Pods of the previous ReplicaSet, still using the previous credentials, must be gone.
+	if deployment.Status.UpdatedReplicas < replicas || deployment.Status.AvailableReplicas < replicas || deployment.Status.Replicas > replicas {
+		return false, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid selector of the Deployment %s/%s", deployment.Namespace, deployment.Name)
+	}
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(deployment.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return false, nil
+	}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.RestartCount > 0 {
+				return false, errors.Errorf("the container %s of the Pod %s/%s is restarting", status.Name, pod.Namespace, pod.Name)
+			}
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_credentialsClient_Rotate(t *testing.T) {
+	provider := clusterctlv1.Provider{
+		ObjectMeta:   metav1.ObjectMeta{Namespace: "capa-system", Name: "infrastructure-aws"},
+		ProviderName: "aws",
+		Type:         string(clusterctlv1.InfrastructureProviderType),
+		Version:      "v0.5.0",
+	}
+	providerLabels := map[string]string{clusterv1.ProviderLabelName: "infrastructure-aws"}
+
+	credentialsSecret := func(name string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "capa-system", Name: name, Labels: providerLabels},
+			Type:       corev1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				"credentials": []byte("old"),
+				"region":      []byte("us-east-1"),
+			},
+		}
+	}
+	webhookSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "capa-system", Name: "capa-webhook-service-cert", Labels: providerLabels},
+		Type:       corev1.SecretTypeTLS,
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "capa-system", Name: "capa-controller-manager", Labels: providerLabels},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"control-plane": "capa-controller-manager"}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		// restarting is true for each restart of the Deployment whose Pod restarts.
+		restarting []bool
+		options    RotateCredentialsOptions
+		wantData   map[string][]byte
+		wantErr    bool
+	}{
+		{
+			name:       "rotates the credentials in the only Opaque Secret",
+			restarting: []bool{false},
+			options:    RotateCredentialsOptions{Data: map[string][]byte{"credentials": []byte("new")}},
+			wantData:   map[string][]byte{"credentials": []byte("new"), "region": []byte("us-east-1")},
+		},
+		{
+			name:       "fails if the provider restarts with the new credentials",
+			restarting: []bool{true},
+			options:    RotateCredentialsOptions{Data: map[string][]byte{"credentials": []byte("new")}},
+			wantData:   map[string][]byte{"credentials": []byte("new"), "region": []byte("us-east-1")},
+			wantErr:    true,
+		},
+		{
+			name:       "restores the previous credentials if the provider restarts with the new credentials",
+			restarting: []bool{true, false},
+			options:    RotateCredentialsOptions{Data: map[string][]byte{"credentials": []byte("new"), "token": []byte("new")}, Rollback: true},
+			wantData:   map[string][]byte{"credentials": []byte("old"), "region": []byte("us-east-1")},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			proxy := test.NewFakeProxy().WithObjs(credentialsSecret("capa-manager-bootstrap-credentials"), webhookSecret, deployment.DeepCopy())
+			c, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			// Emulates the Deployment controller, replacing the Pod of the Deployment at each restart.
+			restarts := 0
+			waiter := func(interval, timeout time.Duration, condition wait.ConditionFunc) error {
+				g.Expect(restarts).To(BeNumerically("<", len(tt.restarting)))
+
+				d := &appsv1.Deployment{}
+				g.Expect(c.Get(ctx, client.ObjectKey{Namespace: deployment.Namespace, Name: deployment.Name}, d)).To(Succeed())
+				g.Expect(d.Spec.Template.Annotations).To(HaveKey(RestartedAtAnnotation))
+				d.Status.Replicas = 1
+				d.Status.UpdatedReplicas = 1
+				d.Status.AvailableReplicas = 1
+				g.Expect(c.Update(ctx, d)).To(Succeed())
+
+				g.Expect(c.DeleteAllOf(ctx, &corev1.Pod{}, client.InNamespace(deployment.Namespace))).To(Succeed())
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: deployment.Namespace,
+						Name:      fmt.Sprintf("%s-%d", deployment.Name, restarts),
+						Labels:    deployment.Spec.Selector.MatchLabels,
+					},
+				}
+				if tt.restarting[restarts] {
+					pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "manager", RestartCount: 1}}
+				}
+				g.Expect(c.Create(ctx, pod)).To(Succeed())
+				restarts++
+
+				return wait.PollImmediate(time.Millisecond, 10*time.Millisecond, condition)
+			}
+
+			tt.options.Provider = provider
+			secretName, err := newCredentialsClient(proxy, waiter).Rotate(tt.options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(secretName).To(Equal("capa-manager-bootstrap-credentials"))
+			g.Expect(restarts).To(Equal(len(tt.restarting)))
+
+			secret := &corev1.Secret{}
+			g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "capa-system", Name: secretName}, secret)).To(Succeed())
+			g.Expect(secret.Data).To(Equal(tt.wantData))
+		})
+	}
+
+	t.Run("requires the Secret name if the provider has many Opaque Secrets", func(t *testing.T) {
+		g := NewWithT(t)
+
+		proxy := test.NewFakeProxy().WithObjs(credentialsSecret("secret1"), credentialsSecret("secret2"), deployment.DeepCopy())
+		_, err := newCredentialsClient(proxy, nil).Rotate(RotateCredentialsOptions{
+			Provider: provider,
+			Data:     map[string][]byte{"credentials": []byte("new")},
+		})
+		g.Expect(err).To(MatchError(ContainSubstring("secret1, secret2")))
+	})
+}
@@ -17,13 +17,29 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/version"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/cluster-api/util/trace"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	waitProviderInterval       = 2 * time.Second
+	waitProviderDefaultTimeout = 5 * time.Minute
 )
 
 // ProviderInstaller defines methods for enforcing consistency rules for provider installation.
@@ -34,7 +50,11 @@ type ProviderInstaller interface {
 	Add(repository.Components)
 
 	// Install performs the installation of the providers ready in the install queue.
-	Install() ([]repository.Components, error)
+	// Before installing, it checks that the target namespaces of the providers can be accessed, and it creates
+	// the target namespaces that do not exist. After installing each provider, it waits for the provider webhooks to be
+	// reachable, so the next providers are not rejected by webhooks not yet running; if requested, after installing
+	// it waits for the providers to be ready.
+	Install(options InstallOptions) ([]repository.Components, error)
 
 	// Validate performs steps to validate a management cluster by looking at the current state and the providers in the queue.
 	// The following checks are performed in order to ensure a fully operational cluster:
@@ -49,6 +69,20 @@ type ProviderInstaller interface {
 	Images() []string
 }
 
+// InstallOptions defines the options for installing providers.
+type InstallOptions struct {
+	// Namespace defines the labels and annotations applied to the target namespaces created during the installation.
+	Namespace NamespaceOptions
+
+	// WaitProviders instructs the installer to wait, after installing all the providers, for the CRDs of each provider
+	// to be Established and for its Deployments to be Available.
+	WaitProviders bool
+
+	// WaitProviderTimeout defines the maximum time to wait for each provider to be ready, and for the webhooks of
+	// each provider to be reachable; if unspecified, it defaults to 5 minutes.
+	WaitProviderTimeout time.Duration
+}
+
 // providerInstaller implements ProviderInstaller
 type providerInstaller struct {
 	configClient            config.Client
@@ -56,6 +90,9 @@ type providerInstaller struct {
 	proxy                   Proxy
 	providerComponents      ComponentsClient
 	providerInventory       InventoryClient
+	namespaceClient         NamespaceClient
+	pollImmediateWaiter     PollImmediateWaiter
+	probeWebhookService     webhookServiceProber
 	installQueue            []repository.Components
 }
 
@@ -65,19 +102,182 @@ func (i *providerInstaller) Add(components repository.Components) {
 	i.installQueue = append(i.installQueue, components)
 }
 
-func (i *providerInstaller) Install() ([]repository.Components, error) {
+func (i *providerInstaller) Install(options InstallOptions) (_ []repository.Components, reterr error) {
+	ctx, span := trace.Start(context.Background(), "clusterctl.install", trace.Int("providers", len(i.installQueue)))
+	defer func() {
+		span.RecordError(reterr)
+		span.End()
+	}()
+
+	// Check all the target namespaces before installing any provider, so permission problems are reported
+	// without leaving the management cluster partially initialized.
+	for _, components := range i.installQueue {
+		if _, err := i.namespaceClient.Check(components.TargetNamespace()); err != nil {
+			return nil, errors.Wrapf(err, "failed to check the target namespace of the %s provider", components.ManifestLabel())
+		}
+	}
+
+	timeout := options.WaitProviderTimeout
+	if timeout == 0 {
+		timeout = waitProviderDefaultTimeout
+	}
+
 	ret := make([]repository.Components, 0, len(i.installQueue))
 	for _, components := range i.installQueue {
-		if err := installComponentsAndUpdateInventory(components, i.providerComponents, i.providerInventory); err != nil {
+		if err := i.installProvider(ctx, components, options, timeout); err != nil {
 			return nil, err
 		}
-
 		ret = append(ret, components)
 	}
+
+	if options.WaitProviders {
+		_, waitSpan := trace.Start(ctx, "clusterctl.install.waitProviders", trace.Int("providers", len(ret)))
+		defer waitSpan.End()
+
+		// Nb. All the providers are installed before waiting, so they start in parallel.
+		for _, components := range ret {
+			if err := i.waitForProvider(components, timeout); err != nil {
+				waitSpan.RecordError(err)
+				return nil, err
+			}
+		}
+	}
 	return ret, nil
 }
 
-func installComponentsAndUpdateInventory(components repository.Components, providerComponents ComponentsClient, providerInventory InventoryClient) error {
+// installProvider installs the components of a provider, waiting for its webhooks.
+func (i *providerInstaller) installProvider(ctx context.Context, components repository.Components, options InstallOptions, timeout time.Duration) (reterr error) {
+	_, span := trace.Start(ctx, "clusterctl.install.provider",
+		trace.String("provider", components.ManifestLabel()),
+		trace.String("version", components.Version()),
+		trace.String("targetNamespace", components.TargetNamespace()),
+	)
+	defer func() {
+		span.RecordError(reterr)
+		span.End()
+	}()
+
+	// Ensure the target namespace exists, because some provider components do not include the Namespace object.
+	if err := i.namespaceClient.Ensure(components.TargetNamespace(), options.Namespace); err != nil {
+		return err
+	}
+
+	// Nb. On install, ownership of the fields managed by other field managers is not forced, so
+	// conflicts with pre-existing objects are reported to the user.
+	if err := installComponentsAndUpdateInventory(components, i.providerComponents, i.providerInventory, CreateOptions{}); err != nil {
+		return err
+	}
+
+	// Wait for the provider webhooks before installing the next provider, whose objects could be intercepted by them.
+	return i.waitForWebhooks(components, timeout)
+}
+
+// waitForProvider waits for the CRDs of a provider to be Established and for its Deployments to be Available,
+// reporting the objects as they become ready.
+func (i *providerInstaller) waitForProvider(components repository.Components, timeout time.Duration) error {
+	log := logf.Log
+	log.Info("Waiting for provider to be available", "Provider", components.ManifestLabel(), "Timeout", timeout.String())
+
+	pending := []unstructured.Unstructured{}
+	for _, o := range append(components.SharedObjs(), components.InstanceObjs()...) {
+		if isCustomResourceDefinition(o) || isDeployment(o) {
+			pending = append(pending, o)
+		}
+	}
+
+	err := i.pollImmediateWaiter(waitProviderInterval, timeout, func() (bool, error) {
+		c, err := i.proxy.NewClient()
+		if err != nil {
+			// Nb. we are ignoring the error so the pollImmediateWaiter will execute another retry
+			return false, nil
+		}
+
+		notReady := []unstructured.Unstructured{}
+		for _, o := range pending {
+			ready, err := isObjReady(c, o)
+			if err != nil {
+				log.V(5).Info("Failed to check if ready", append(logf.UnstructuredToValues(o), "Error", err.Error())...)
+			}
+			if !ready {
+				notReady = append(notReady, o)
+				continue
+			}
+			log.V(1).Info("Ready", logf.UnstructuredToValues(o)...)
+		}
+		pending = notReady
+		return len(pending) == 0, nil
+	})
+	if err != nil {
+		names := make([]string, 0, len(pending))
+		for _, o := range pending {
+			names = append(names, o.GetKind()+"/"+o.GetName())
+		}
+		return errors.Wrapf(err, "failed to wait for the %s provider to be ready, the following objects are not ready: %s", components.ManifestLabel(), strings.Join(names, ", "))
+	}
+
+	log.Info("Provider is available", "Provider", components.ManifestLabel())
+	return nil
+}
+
+// isObjReady returns true if the CRD is Established or if the Deployment is Available with all the replicas updated.
+func isObjReady(c client.Client, o unstructured.Unstructured) (bool, error) {
+	key := client.ObjectKey{Namespace: o.GetNamespace(), Name: o.GetName()}
+
+	if isCustomResourceDefinition(o) {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := c.Get(ctx, key, crd); err != nil {
+			return false, err
+		}
+		for _, condition := range crd.Status.Conditions {
+			if condition.Type == apiextensionsv1.Established && condition.Status == apiextensionsv1.ConditionTrue {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := c.Get(ctx, key, deployment); err != nil {
+		return false, err
+	}
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return false, nil
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	if deployment.Status.UpdatedReplicas < replicas || deployment.Status.AvailableReplicas < replicas {
+		return false, nil
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentAvailable && condition.Status == corev1.ConditionTrue {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isCustomResourceDefinition(o unstructured.Unstructured) bool {
+	return o.GroupVersionKind().GroupKind() == apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinition").GroupKind()
+}
+
+func isDeployment(o unstructured.Unstructured) bool {
+	return o.GroupVersionKind().GroupKind() == appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind()
+}
+
+func installComponentsAndUpdateInventory(components repository.Components, providerComponents ComponentsClient, providerInventory InventoryClient, createOptions CreateOptions) error {
+	if err := installComponents(components, providerComponents, providerInventory, createOptions); err != nil {
+		return err
+	}
+
+	log := logf.Log
+	log.V(1).Info("Creating inventory entry", "Provider", components.ManifestLabel(), "Version", components.Version(), "TargetNamespace", components.TargetNamespace())
+	return providerInventory.Create(components.InventoryObject())
+}
+
+// installComponents installs the provider components, without creating the inventory entry for the provider.
+func installComponents(components repository.Components, providerComponents ComponentsClient, providerInventory InventoryClient, createOptions CreateOptions) error {
 	log := logf.Log
 	log.Info("Installing", "Provider", components.ManifestLabel(), "Version", components.Version(), "TargetNamespace", components.TargetNamespace())
 
@@ -101,26 +301,26 @@ func installComponentsAndUpdateInventory(components repository.Components, provi
 		log.V(1).Info("Creating shared objects", "Provider", components.ManifestLabel(), "Version", components.Version())
 		// TODO: currently shared components overrides existing shared components. As a future improvement we should
 		//  consider if to delete (preserving CRDs) before installing so there will be no left-overs in case the list of resources changes
-		if err := providerComponents.Create(components.SharedObjs()); err != nil {
+		if err := providerComponents.Create(components.SharedObjs(), createOptions); err != nil {
 			return err
 		}
 	} else {
 		log.V(1).Info("Shared objects already up to date", "Provider", components.ManifestLabel())
 	}
 
-	// Then always install the instance specific objects and the then inventory item for the provider
+	// Then always install the instance specific objects.
 
 	log.V(1).Info("Creating instance objects", "Provider", components.ManifestLabel(), "Version", components.Version(), "TargetNamespace", components.TargetNamespace())
-	if err := providerComponents.Create(components.InstanceObjs()); err != nil {
+	if err := providerComponents.Create(components.InstanceObjs(), createOptions); err != nil {
 		return err
 	}
 
-	log.V(1).Info("Creating inventory entry", "Provider", components.ManifestLabel(), "Version", components.Version(), "TargetNamespace", components.TargetNamespace())
-	if err := providerInventory.Create(inventoryObject); err != nil {
-		return err
-	}
-
-	return nil
+	// Finally garbage collect objects left over in the provider namespace by previous installations, e.g. objects
+	// removed or renamed by the new version of the provider or created by a failed install.
+	return providerComponents.DeleteStale(DeleteStaleOptions{
+		Provider:  inventoryObject,
+		Providers: providerList.Items,
+	})
 }
 
 // shouldInstallSharedComponents checks if it is required to install shared components for a provider.
@@ -202,7 +402,21 @@ func (i *providerInstaller) Validate() error {
 			return err
 		}
 		if providerContract != managementGroupContract {
-			return errors.Errorf("installing provider %q can lead to a non functioning management cluster: the target version for the provider supports the %s API Version of Cluster API (contract), while the management group is using %s", components.ManifestLabel(), providerContract, managementGroupContract)
+			return errors.Wrapf(messages.New(messages.ContractMismatch, components.ManifestLabel(), providerContract, managementGroupContract), "installing provider %q can lead to a non functioning management cluster", components.ManifestLabel())
+		}
+
+		// Checks the Kubernetes version of the management cluster is supported by the provider.
+		if err := validateKubernetesCompatibility(i.proxy, provider, provider.Version); err != nil {
+			return errors.Wrapf(err, "installing provider %q can lead to a non functioning management cluster", components.ManifestLabel())
+		}
+
+		// Warns if the version of the provider is deprecated or no longer supported.
+		metadata, err := i.getProviderMetadata(provider)
+		if err != nil {
+			return err
+		}
+		if warning := repository.SupportWarning(components.ManifestLabel(), metadata, provider.Version, time.Now()); warning != "" {
+			logf.Log.Info("Warning: " + warning)
 		}
 	}
 	return nil
@@ -218,17 +432,7 @@ func (i *providerInstaller) getProviderContract(providerInstanceContracts map[st
 	// Otherwise get the contract for the providers instance.
 
 	// Gets the providers metadata.
-	configRepository, err := i.configClient.Providers().Get(provider.ProviderName, provider.GetProviderType())
-	if err != nil {
-		return "", err
-	}
-
-	providerRepository, err := i.repositoryClientFactory(configRepository, i.configClient)
-	if err != nil {
-		return "", err
-	}
-
-	latestMetadata, err := providerRepository.Metadata(provider.Version).Get()
+	latestMetadata, err := i.getProviderMetadata(provider)
 	if err != nil {
 		return "", err
 	}
@@ -248,6 +452,21 @@ func (i *providerInstaller) getProviderContract(providerInstanceContracts map[st
 	return releaseSeries.Contract, nil
 }
 
+// getProviderMetadata returns the metadata of the version of a provider instance.
+func (i *providerInstaller) getProviderMetadata(provider clusterctlv1.Provider) (*clusterctlv1.Metadata, error) {
+	configRepository, err := i.configClient.Providers().Get(provider.ProviderName, provider.GetProviderType())
+	if err != nil {
+		return nil, err
+	}
+
+	providerRepository, err := i.repositoryClientFactory(configRepository, i.configClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return providerRepository.Metadata(provider.Version).Get()
+}
+
 // simulateInstall adds a provider to the list of providers in a cluster (without installing it).
 func simulateInstall(providerList *clusterctlv1.ProviderList, components repository.Components) (*clusterctlv1.ProviderList, error) {
 	provider := components.InventoryObject()
@@ -284,12 +503,15 @@ func (i *providerInstaller) Images() []string {
 	return ret.List()
 }
 
-func newProviderInstaller(configClient config.Client, repositoryClientFactory RepositoryClientFactory, proxy Proxy, providerMetadata InventoryClient, providerComponents ComponentsClient) *providerInstaller {
+func newProviderInstaller(configClient config.Client, repositoryClientFactory RepositoryClientFactory, proxy Proxy, providerMetadata InventoryClient, providerComponents ComponentsClient, namespaceClient NamespaceClient, pollImmediateWaiter PollImmediateWaiter) *providerInstaller {
 	return &providerInstaller{
 		configClient:            configClient,
 		repositoryClientFactory: repositoryClientFactory,
 		proxy:                   proxy,
 		providerComponents:      providerComponents,
 		providerInventory:       providerMetadata,
+		namespaceClient:         namespaceClient,
+		pollImmediateWaiter:     pollImmediateWaiter,
+		probeWebhookService:     newWebhookServiceProber(proxy),
 	}
 }
@@ -18,13 +18,23 @@ package cluster
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/scheme"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
 )
 
@@ -81,9 +91,10 @@ func Test_providerInstaller_Validate(t *testing.T) {
 		installQueue []repository.Components
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		wantErr bool
+		name      string
+		fields    fields
+		wantErr   bool
+		wantErrIs error
 	}{
 		{
 			name: "install core + infra1 on an empty cluster",
@@ -188,7 +199,8 @@ func Test_providerInstaller_Validate(t *testing.T) {
 					newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra1-system", ""),
 				},
 			},
-			wantErr: true,
+			wantErr:   true,
+			wantErrIs: messages.ErrContractMismatch,
 		},
 	}
 
@@ -211,6 +223,9 @@ func Test_providerInstaller_Validate(t *testing.T) {
 			err := i.Validate()
 			if tt.wantErr {
 				g.Expect(err).To(HaveOccurred())
+				if tt.wantErrIs != nil {
+					g.Expect(errors.Is(err, tt.wantErrIs)).To(BeTrue())
+				}
 			} else {
 				g.Expect(err).NotTo(HaveOccurred())
 			}
@@ -218,19 +233,108 @@ func Test_providerInstaller_Validate(t *testing.T) {
 	}
 }
 
+func Test_providerInstaller_waitForProvider(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		TypeMeta:   metav1.TypeMeta{APIVersion: apiextensionsv1.SchemeGroupVersion.String(), Kind: "CustomResourceDefinition"},
+		ObjectMeta: metav1.ObjectMeta{Name: "infra1machines.infrastructure.cluster.x-k8s.io"},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{
+			Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionTrue}},
+		},
+	}
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "infra1-system", Name: "infra1-controller-manager"},
+	}
+	availableDeployment := deployment.DeepCopy()
+	availableDeployment.Status = appsv1.DeploymentStatus{
+		UpdatedReplicas:   1,
+		AvailableReplicas: 1,
+		Conditions:        []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}},
+	}
+
+	toUnstructured := func(g *WithT, objs ...runtime.Object) []unstructured.Unstructured {
+		ret := []unstructured.Unstructured{}
+		for _, o := range objs {
+			u := unstructured.Unstructured{}
+			g.Expect(scheme.Scheme.Convert(o, &u, nil)).To(Succeed())
+			ret = append(ret, u)
+		}
+		return ret
+	}
+
+	tests := []struct {
+		name    string
+		objs    []runtime.Object
+		wantErr string
+	}{
+		{
+			name: "CRDs established and deployments available",
+			objs: []runtime.Object{crd, availableDeployment},
+		},
+		{
+			name:    "deployments not available",
+			objs:    []runtime.Object{crd, deployment},
+			wantErr: "Deployment/infra1-controller-manager",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			components := newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra1-system", "").(*fakeComponents)
+			components.sharedObjs = toUnstructured(g, crd)
+			components.instanceObjs = toUnstructured(g, deployment)
+
+			i := &providerInstaller{
+				proxy: test.NewFakeProxy().WithObjs(tt.objs...),
+				// Checks the condition only once.
+				pollImmediateWaiter: func(interval, timeout time.Duration, condition wait.ConditionFunc) error {
+					done, err := condition()
+					if err != nil {
+						return err
+					}
+					if !done {
+						return wait.ErrWaitTimeout
+					}
+					return nil
+				},
+			}
+
+			err := i.waitForProvider(components, time.Minute)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				g.Expect(err.Error()).NotTo(ContainSubstring("CustomResourceDefinition/"))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
+
 type fakeComponents struct {
 	config.Provider
 	inventoryObject clusterctlv1.Provider
+	instanceObjs    []unstructured.Unstructured
+	sharedObjs      []unstructured.Unstructured
 }
 
 func (c *fakeComponents) Version() string {
 	panic("not implemented")
 }
 
+func (c *fakeComponents) Digest() string {
+	panic("not implemented")
+}
+
 func (c *fakeComponents) Variables() []string {
 	panic("not implemented")
 }
 
+func (c *fakeComponents) VariableMap() map[string]*string {
+	panic("not implemented")
+}
+
 func (c *fakeComponents) Images() []string {
 	panic("not implemented")
 }
@@ -243,16 +347,20 @@ func (c *fakeComponents) WatchingNamespace() string {
 	panic("not implemented")
 }
 
+func (c *fakeComponents) FeatureGates() map[string]bool {
+	panic("not implemented")
+}
+
 func (c *fakeComponents) InventoryObject() clusterctlv1.Provider {
 	return c.inventoryObject
 }
 
 func (c *fakeComponents) InstanceObjs() []unstructured.Unstructured {
-	panic("not implemented")
+	return c.instanceObjs
 }
 
 func (c *fakeComponents) SharedObjs() []unstructured.Unstructured {
-	panic("not implemented")
+	return c.sharedObjs
 }
 
 func (c *fakeComponents) Yaml() ([]byte, error) {
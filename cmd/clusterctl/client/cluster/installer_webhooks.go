@@ -0,0 +1,177 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+)
+
+const (
+	waitWebhookInterval = 2 * time.Second
+
+	// defaultWebhookServicePort is the port used by the API server for calling webhook services, if not specified.
+	defaultWebhookServicePort = 443
+)
+
+// webhookService identifies a service called by the API server for a mutating or validating webhook.
+type webhookService struct {
+	Namespace string
+	Name      string
+	Port      int64
+}
+
+func (s webhookService) String() string {
+	return fmt.Sprintf("%s/%s:%d", s.Namespace, s.Name, s.Port)
+}
+
+// webhookServiceProber checks if a webhook service can be reached through the API server, returning an error if not.
+type webhookServiceProber func(service webhookService) error
+
+// newWebhookServiceProber returns a webhookServiceProber calling the webhook service through the API server service proxy,
+// so the same network path used by the API server for calling the webhook is checked.
+func newWebhookServiceProber(proxy Proxy) webhookServiceProber {
+	return func(service webhookService) error {
+		config, err := proxy.GetConfig()
+		if err != nil {
+			return err
+		}
+		cs, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return errors.Wrap(err, "failed to create the client-go client")
+		}
+
+		_, err = cs.CoreV1().Services(service.Namespace).ProxyGet("https", service.Name, strconv.FormatInt(service.Port, 10), "/", nil).DoRaw()
+		if err == nil {
+			return nil
+		}
+		// Any response from the webhook server, e.g. 404 Not Found for the root path, proves the service is reachable,
+		// while the API server replies with 503 Service Unavailable if the service has no endpoints or the connection is refused.
+		// Nb. 403 Forbidden is considered as reachable too, because the webhook can't be probed without the services/proxy permission.
+		if status, ok := err.(apierrors.APIStatus); ok && status.Status().Code < http.StatusInternalServerError {
+			return nil
+		}
+		return err
+	}
+}
+
+// webhookServices returns the services called by the mutating and validating webhooks of a provider.
+func webhookServices(components repository.Components) ([]webhookService, error) {
+	return admissionWebhookServices(append(components.SharedObjs(), components.InstanceObjs()...))
+}
+
+// admissionWebhookServices returns the services called by the mutating and validating webhook configurations in objs.
+func admissionWebhookServices(objs []unstructured.Unstructured) ([]webhookService, error) {
+	services := map[webhookService]struct{}{}
+	for _, o := range objs {
+		if !isWebhookConfiguration(o) {
+			continue
+		}
+
+		webhooks, _, err := unstructured.NestedSlice(o.Object, "webhooks")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get webhooks from %s %s", o.GetKind(), o.GetName())
+		}
+		for _, w := range webhooks {
+			webhook, ok := w.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			service, ok, err := unstructured.NestedMap(webhook, "clientConfig", "service")
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get the webhook service from %s %s", o.GetKind(), o.GetName())
+			}
+			// Webhooks called by URL are not probed.
+			if !ok {
+				continue
+			}
+
+			s := webhookService{Port: defaultWebhookServicePort}
+			s.Namespace, _, _ = unstructured.NestedString(service, "namespace")
+			s.Name, _, _ = unstructured.NestedString(service, "name")
+			if port, ok, _ := unstructured.NestedInt64(service, "port"); ok {
+				s.Port = port
+			}
+			services[s] = struct{}{}
+		}
+	}
+	return sortedWebhookServices(services), nil
+}
+
+// sortedWebhookServices returns a set of webhook services as a sorted slice.
+func sortedWebhookServices(services map[webhookService]struct{}) []webhookService {
+	ret := make([]webhookService, 0, len(services))
+	for s := range services {
+		ret = append(ret, s)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].String() < ret[j].String() })
+	return ret
+}
+
+// waitForWebhooks waits for the services of the mutating and validating webhooks of a provider to be reachable
+// through the API server, so the objects created afterwards are not rejected because the webhooks can't be called.
+func (i *providerInstaller) waitForWebhooks(components repository.Components, timeout time.Duration) error {
+	pending, err := webhookServices(components)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	log := logf.Log
+	log.Info("Waiting for provider webhooks to be reachable", "Provider", components.ManifestLabel(), "Timeout", timeout.String())
+
+	err = i.pollImmediateWaiter(waitWebhookInterval, timeout, func() (bool, error) {
+		notReady := []webhookService{}
+		for _, s := range pending {
+			if err := i.probeWebhookService(s); err != nil {
+				// Nb. we are ignoring the error so the pollImmediateWaiter will execute another retry
+				log.V(5).Info("Webhook service not reachable", "Service", s.String(), "Error", err.Error())
+				notReady = append(notReady, s)
+				continue
+			}
+			log.V(1).Info("Webhook service reachable", "Service", s.String())
+		}
+		pending = notReady
+		return len(pending) == 0, nil
+	})
+	if err != nil {
+		names := make([]string, 0, len(pending))
+		for _, s := range pending {
+			names = append(names, s.String())
+		}
+		return errors.Wrapf(err, "failed to wait for the webhooks of the %s provider to be reachable, the following services are not reachable: %s", components.ManifestLabel(), strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func isWebhookConfiguration(o unstructured.Unstructured) bool {
+	return o.GroupVersionKind().Group == "admissionregistration.k8s.io" &&
+		(o.GetKind() == "MutatingWebhookConfiguration" || o.GetKind() == "ValidatingWebhookConfiguration")
+}
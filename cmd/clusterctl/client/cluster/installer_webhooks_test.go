@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+func webhookConfiguration(kind, name string, services ...map[string]interface{}) unstructured.Unstructured {
+	webhooks := []interface{}{}
+	for _, s := range services {
+		webhooks = append(webhooks, map[string]interface{}{
+			"name":         name,
+			"clientConfig": map[string]interface{}{"service": s},
+		})
+	}
+	webhooks = append(webhooks, map[string]interface{}{
+		"name":         "url",
+		"clientConfig": map[string]interface{}{"url": "https://example.com/validate"},
+	})
+	return unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "admissionregistration.k8s.io/v1beta1",
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name},
+			"webhooks":   webhooks,
+		},
+	}
+}
+
+func Test_webhookServices(t *testing.T) {
+	g := NewWithT(t)
+
+	components := newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra1-system", "").(*fakeComponents)
+	components.sharedObjs = []unstructured.Unstructured{
+		webhookConfiguration("MutatingWebhookConfiguration", "mutating",
+			map[string]interface{}{"namespace": "capi-webhook-system", "name": "infra1-webhook-service"},
+		),
+		webhookConfiguration("ValidatingWebhookConfiguration", "validating",
+			map[string]interface{}{"namespace": "capi-webhook-system", "name": "infra1-webhook-service"},
+			map[string]interface{}{"namespace": "capi-webhook-system", "name": "other-webhook-service", "port": int64(9443)},
+		),
+	}
+
+	got, err := webhookServices(components)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal([]webhookService{
+		{Namespace: "capi-webhook-system", Name: "infra1-webhook-service", Port: 443},
+		{Namespace: "capi-webhook-system", Name: "other-webhook-service", Port: 9443},
+	}))
+}
+
+func Test_providerInstaller_waitForWebhooks(t *testing.T) {
+	service := map[string]interface{}{"namespace": "capi-webhook-system", "name": "infra1-webhook-service"}
+
+	tests := []struct {
+		name       string
+		objs       []unstructured.Unstructured
+		reachable  bool
+		wantProbes int
+		wantErr    string
+	}{
+		{
+			name:       "webhook services reachable",
+			objs:       []unstructured.Unstructured{webhookConfiguration("ValidatingWebhookConfiguration", "validating", service)},
+			reachable:  true,
+			wantProbes: 1,
+		},
+		{
+			name:       "webhook services not reachable",
+			objs:       []unstructured.Unstructured{webhookConfiguration("ValidatingWebhookConfiguration", "validating", service)},
+			wantProbes: 1,
+			wantErr:    "capi-webhook-system/infra1-webhook-service:443",
+		},
+		{
+			name:       "provider without webhooks",
+			wantProbes: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			components := newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra1-system", "").(*fakeComponents)
+			components.sharedObjs = tt.objs
+
+			probes := 0
+			i := &providerInstaller{
+				// Checks the condition only once.
+				pollImmediateWaiter: func(interval, timeout time.Duration, condition wait.ConditionFunc) error {
+					done, err := condition()
+					if err != nil {
+						return err
+					}
+					if !done {
+						return wait.ErrWaitTimeout
+					}
+					return nil
+				},
+				probeWebhookService: func(service webhookService) error {
+					probes++
+					if !tt.reachable {
+						return errors.New("connection refused")
+					}
+					return nil
+				},
+			}
+
+			err := i.waitForWebhooks(components, time.Minute)
+			g.Expect(probes).To(Equal(tt.wantProbes))
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package cluster
 
 import (
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
@@ -69,6 +70,14 @@ type InventoryClient interface {
 
 	// GetManagementGroups returns the list of management groups defined in the management cluster.
 	GetManagementGroups() (ManagementGroupList, error)
+
+	// GetProviderInstances returns all the instances of a given provider, e.g. the AWS provider installed both in the
+	// capa-system1 and in the capa-system2 namespace, sorted by namespace.
+	GetProviderInstances(provider string, providerType clusterctlv1.ProviderType) ([]clusterctlv1.Provider, error)
+
+	// GetVersionSkews returns the providers with instances running different versions, e.g. the AWS provider v0.5.4 in
+	// capa-system1 and the AWS provider v0.5.5 in capa-system2.
+	GetVersionSkews() ([]VersionSkew, error)
 }
 
 // inventoryClient implements InventoryClient.
@@ -343,3 +352,25 @@ func (p *inventoryClient) GetDefaultProviderNamespace(provider string, providerT
 	// There is no provider or more than one namespace for this provider; in both cases, a default provider namespace cannot be decided.
 	return "", nil
 }
+
+func (p *inventoryClient) GetProviderInstances(provider string, providerType clusterctlv1.ProviderType) ([]clusterctlv1.Provider, error) {
+	providerList, err := p.List()
+	if err != nil {
+		return nil, err
+	}
+
+	instances := providerList.FilterByProviderNameAndType(provider, providerType)
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Namespace < instances[j].Namespace
+	})
+	return instances, nil
+}
+
+func (p *inventoryClient) GetVersionSkews() ([]VersionSkew, error) {
+	providerList, err := p.List()
+	if err != nil {
+		return nil, err
+	}
+
+	return deriveVersionSkews(providerList)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+// VersionSkew defines a provider installed in more than one namespace, e.g. the AWS provider in capa-system1 and
+// capa-system2, with instances running different versions.
+// Nb. all the instances of a provider share the same CRDs, which are installed at the version of the newest instance,
+// so the older instances are running against CRDs that could be not fully supported.
+type VersionSkew struct {
+	ProviderName string
+	Type         clusterctlv1.ProviderType
+
+	// Instances are the instances of the provider, sorted by version and namespace.
+	Instances []clusterctlv1.Provider
+}
+
+// LatestVersion returns the newest version among the instances of the provider.
+func (s *VersionSkew) LatestVersion() string {
+	return s.Instances[len(s.Instances)-1].Version
+}
+
+// Outdated returns the instances of the provider running a version older than LatestVersion.
+func (s *VersionSkew) Outdated() []clusterctlv1.Provider {
+	var ret []clusterctlv1.Provider
+	for _, instance := range s.Instances {
+		if instance.Version != s.LatestVersion() {
+			ret = append(ret, instance)
+		}
+	}
+	return ret
+}
+
+// Includes returns true if the given provider instance is part of the version skew.
+func (s *VersionSkew) Includes(provider clusterctlv1.Provider) bool {
+	for _, instance := range s.Instances {
+		if instance.Equals(provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterVersionSkews returns the version skews including at least one of the providers in the management group.
+func (mg *ManagementGroup) FilterVersionSkews(skews []VersionSkew) []VersionSkew {
+	var ret []VersionSkew
+	for _, skew := range skews {
+		for _, provider := range mg.Providers {
+			if skew.Includes(provider) {
+				ret = append(ret, skew)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// deriveVersionSkews derives the version skews from a list of providers.
+func deriveVersionSkews(providerList *clusterctlv1.ProviderList) ([]VersionSkew, error) {
+	// Groups the instances by provider.
+	type providerKey struct {
+		name         string
+		providerType clusterctlv1.ProviderType
+	}
+	var keys []providerKey
+	instances := map[providerKey][]clusterctlv1.Provider{}
+	for _, p := range providerList.Items {
+		key := providerKey{name: p.ProviderName, providerType: p.GetProviderType()}
+		if _, ok := instances[key]; !ok {
+			keys = append(keys, key)
+		}
+		instances[key] = append(instances[key], p)
+	}
+
+	// Creates a version skew for each provider with instances running different versions.
+	skews := []VersionSkew{}
+	for _, key := range keys {
+		skew := VersionSkew{
+			ProviderName: key.name,
+			Type:         key.providerType,
+			Instances:    instances[key],
+		}
+		if err := sortInstancesByVersion(skew.Instances); err != nil {
+			return nil, err
+		}
+		if skew.Instances[0].Version == skew.LatestVersion() {
+			continue
+		}
+		skews = append(skews, skew)
+	}
+
+	// Sorts the version skews consistently (by Type, ProviderName).
+	sort.Slice(skews, func(i, j int) bool {
+		if skews[i].Type.Order() == skews[j].Type.Order() {
+			return skews[i].ProviderName < skews[j].ProviderName
+		}
+		return skews[i].Type.Order() < skews[j].Type.Order()
+	})
+	return skews, nil
+}
+
+// sortInstancesByVersion sorts the instances of a provider by version and namespace.
+func sortInstancesByVersion(instances []clusterctlv1.Provider) error {
+	versions := make(map[string]*version.Version, len(instances))
+	for _, instance := range instances {
+		v, err := version.ParseSemantic(instance.Version)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse version for the %s provider", instance.InstanceName())
+		}
+		versions[instance.InstanceName()] = v
+	}
+
+	sort.SliceStable(instances, func(i, j int) bool {
+		vi, vj := versions[instances[i].InstanceName()], versions[instances[j].InstanceName()]
+		if vi.LessThan(vj) || vj.LessThan(vi) {
+			return vi.LessThan(vj)
+		}
+		return instances[i].Namespace < instances[j].Namespace
+	})
+	return nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_deriveVersionSkews(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []clusterctlv1.Provider
+		want      []VersionSkew
+		wantErr   bool
+	}{
+		{
+			name: "no skew if all the instances run the same version",
+			providers: []clusterctlv1.Provider{
+				fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""),
+				fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system1", "ns1"),
+				fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system2", "ns2"),
+			},
+			want: []VersionSkew{},
+		},
+		{
+			name: "skew if the instances run different versions",
+			providers: []clusterctlv1.Provider{
+				fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""),
+				fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.10", "infra-system1", "ns1"),
+				fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.9", "infra-system2", "ns2"),
+				fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.9", "infra-system3", "ns3"),
+			},
+			want: []VersionSkew{
+				{
+					ProviderName: "infra",
+					Type:         clusterctlv1.InfrastructureProviderType,
+					Instances: []clusterctlv1.Provider{
+						fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.9", "infra-system2", "ns2"),
+						fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.9", "infra-system3", "ns3"),
+						fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.10", "infra-system1", "ns1"),
+					},
+				},
+			},
+		},
+		{
+			name: "providers with the same name but different types are not compared",
+			providers: []clusterctlv1.Provider{
+				fakeProvider("kubeadm", clusterctlv1.BootstrapProviderType, "v1.0.0", "bootstrap-system", ""),
+				fakeProvider("kubeadm", clusterctlv1.ControlPlaneProviderType, "v1.0.1", "control-plane-system", ""),
+			},
+			want: []VersionSkew{},
+		},
+		{
+			name: "fails for invalid versions",
+			providers: []clusterctlv1.Provider{
+				fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "foo", "infra-system1", "ns1"),
+				fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system2", "ns2"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := deriveVersionSkews(&clusterctlv1.ProviderList{Items: tt.providers})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestVersionSkew_Outdated(t *testing.T) {
+	g := NewWithT(t)
+
+	skew := VersionSkew{
+		ProviderName: "infra",
+		Type:         clusterctlv1.InfrastructureProviderType,
+		Instances: []clusterctlv1.Provider{
+			fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system2", "ns2"),
+			fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.1", "infra-system1", "ns1"),
+		},
+	}
+
+	g.Expect(skew.LatestVersion()).To(Equal("v2.0.1"))
+	g.Expect(skew.Outdated()).To(ConsistOf(skew.Instances[0]))
+}
+
+func TestManagementGroup_FilterVersionSkews(t *testing.T) {
+	g := NewWithT(t)
+
+	infra1 := fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.1", "infra-system1", "ns1")
+	infra2 := fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system2", "ns2")
+	bootstrap1 := fakeProvider("bootstrap", clusterctlv1.BootstrapProviderType, "v1.0.1", "bootstrap-system1", "ns1")
+	bootstrap3 := fakeProvider("bootstrap", clusterctlv1.BootstrapProviderType, "v1.0.0", "bootstrap-system3", "ns3")
+
+	skews := []VersionSkew{
+		{ProviderName: "bootstrap", Type: clusterctlv1.BootstrapProviderType, Instances: []clusterctlv1.Provider{bootstrap3, bootstrap1}},
+		{ProviderName: "infra", Type: clusterctlv1.InfrastructureProviderType, Instances: []clusterctlv1.Provider{infra2, infra1}},
+	}
+
+	managementGroup := ManagementGroup{
+		CoreProvider: fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system2", "ns2"),
+		Providers: []clusterctlv1.Provider{
+			fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system2", "ns2"),
+			infra2,
+		},
+	}
+	g.Expect(managementGroup.FilterVersionSkews(skews)).To(Equal(skews[1:]))
+}
+
+func Test_inventoryClient_GetProviderInstances(t *testing.T) {
+	g := NewWithT(t)
+
+	proxy := test.NewFakeProxy().
+		WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system2", "ns2").
+		WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.1", "infra-system1", "ns1").
+		WithProviderInventory("other", clusterctlv1.InfrastructureProviderType, "v3.0.0", "other-system", "")
+	p := newInventoryClient(proxy, fakePollImmediateWaiter)
+
+	got, err := p.GetProviderInstances("infra", clusterctlv1.InfrastructureProviderType)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(HaveLen(2))
+	g.Expect(got[0].InstanceName()).To(Equal("infra-system1/infrastructure-infra"))
+	g.Expect(got[1].InstanceName()).To(Equal("infra-system2/infrastructure-infra"))
+
+	skews, err := p.GetVersionSkews()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(skews).To(HaveLen(1))
+	g.Expect(skews[0].ProviderName).To(Equal("infra"))
+	g.Expect(skews[0].LatestVersion()).To(Equal("v2.0.1"))
+}
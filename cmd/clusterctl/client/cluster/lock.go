@@ -0,0 +1,240 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/utils/pointer"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// OperationLockName is the name of the Lease used by clusterctl for preventing concurrent operations
+	// against a management cluster.
+	OperationLockName = "clusterctl-lock"
+
+	// OperationLockNamespace is the namespace hosting the operation lock Lease.
+	// Nb. kube-system is used because it exists in every cluster, so locking does not require creating a namespace.
+	OperationLockNamespace = "kube-system"
+
+	// OperationLockAnnotation is the annotation documenting the operation holding the lock.
+	OperationLockAnnotation = "clusterctl.cluster.x-k8s.io/operation"
+
+	// operationLockDuration is the time after which a lock that has not been renewed is considered stale, e.g.
+	// because the clusterctl process holding it was killed.
+	operationLockDuration = 2 * time.Minute
+
+	// operationLockRenewInterval is the interval at which a held lock is renewed.
+	operationLockRenewInterval = 30 * time.Second
+)
+
+// operationLockHolder identifies the current clusterctl process as the holder of an operation lock.
+var operationLockHolder = func() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s_%d_%s", hostname, os.Getpid(), rand.String(5))
+}()
+
+// ReleaseFunc releases an operation lock.
+type ReleaseFunc func() error
+
+// OperationLockClient has methods to prevent concurrent clusterctl operations against a management cluster.
+type OperationLockClient interface {
+	// Acquire acquires the lock of the management cluster for the given operation, and keeps renewing it until
+	// the returned ReleaseFunc is called. Acquire fails if the lock is held by another operation, unless the lock is
+	// stale, i.e. it was not renewed for a while, or force is set.
+	Acquire(operation AuditOperation, force bool) (ReleaseFunc, error)
+}
+
+// operationLockClient implements OperationLockClient.
+type operationLockClient struct {
+	proxy Proxy
+}
+
+// ensure operationLockClient implements OperationLockClient.
+var _ OperationLockClient = &operationLockClient{}
+
+// newOperationLockClient returns an operationLockClient.
+func newOperationLockClient(proxy Proxy) *operationLockClient {
+	return &operationLockClient{
+		proxy: proxy,
+	}
+}
+
+func (l *operationLockClient) Acquire(operation AuditOperation, force bool) (ReleaseFunc, error) {
+	log := logf.Log
+
+	c, err := l.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	now := metav1.NewMicroTime(time.Now())
+	exists := true
+	lease := &coordinationv1.Lease{}
+	key := client.ObjectKey{Namespace: OperationLockNamespace, Name: OperationLockName}
+	if err := c.Get(ctx, key, lease); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "failed to get the %s/%s Lease", OperationLockNamespace, OperationLockName)
+		}
+		exists = false
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: OperationLockNamespace,
+				Name:      OperationLockName,
+				Labels: map[string]string{
+					clusterctlv1.ClusterctlLabelName: "",
+				},
+			},
+		}
+	}
+
+	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != "" && !isOperationLockStale(lease, now.Time) {
+		if !force {
+			since := "unknown"
+			if lease.Spec.AcquireTime != nil {
+				since = lease.Spec.AcquireTime.Format(time.RFC3339)
+			}
+			return nil, errors.Errorf("the management cluster is locked by the %s operation executed by %s since %s; wait for it to complete, or force the operation if the lock is stale",
+				lease.Annotations[OperationLockAnnotation], *lease.Spec.HolderIdentity, since)
+		}
+		log.Info("Forcing the operation lock", "Operation", lease.Annotations[OperationLockAnnotation], "Holder", *lease.Spec.HolderIdentity)
+	}
+
+	if lease.Annotations == nil {
+		lease.Annotations = map[string]string{}
+	}
+	lease.Annotations[OperationLockAnnotation] = string(operation)
+	lease.Spec.HolderIdentity = pointer.StringPtr(operationLockHolder)
+	lease.Spec.LeaseDurationSeconds = pointer.Int32Ptr(int32(operationLockDuration.Seconds()))
+	lease.Spec.AcquireTime = &now
+	lease.Spec.RenewTime = &now
+
+	// Nb. Create and Update fail if another clusterctl process acquired the lock concurrently, because the Lease
+	// already exists or its resourceVersion changed.
+	if exists {
+		err = c.Update(ctx, lease, client.FieldOwner(FieldManager))
+	} else {
+		err = c.Create(ctx, lease, client.FieldOwner(FieldManager))
+	}
+	if err != nil {
+		if apierrors.IsAlreadyExists(err) || apierrors.IsConflict(err) {
+			return nil, errors.New("the management cluster has been locked by another operation; wait for it to complete")
+		}
+		return nil, errors.Wrapf(err, "failed to acquire the %s/%s Lease", OperationLockNamespace, OperationLockName)
+	}
+	log.V(1).Info("Acquired the operation lock", "Operation", operation)
+
+	stop := make(chan struct{})
+	go l.renew(stop)
+
+	return func() error {
+		close(stop)
+		return l.release()
+	}, nil
+}
+
+// renew renews the lock held by the current process until the stop channel is closed.
+func (l *operationLockClient) renew(stop <-chan struct{}) {
+	log := logf.Log
+
+	ticker := time.NewTicker(operationLockRenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := l.renewOnce(); err != nil {
+				log.V(1).Info("Failed to renew the operation lock", "Cause", err.Error())
+			}
+		}
+	}
+}
+
+func (l *operationLockClient) renewOnce() error {
+	c, err := l.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	lease := &coordinationv1.Lease{}
+	key := client.ObjectKey{Namespace: OperationLockNamespace, Name: OperationLockName}
+	if err := c.Get(ctx, key, lease); err != nil {
+		return errors.Wrapf(err, "failed to get the %s/%s Lease", OperationLockNamespace, OperationLockName)
+	}
+	if !isOperationLockHeld(lease) {
+		return errors.New("the operation lock has been taken over by another operation")
+	}
+
+	now := metav1.NewMicroTime(time.Now())
+	lease.Spec.RenewTime = &now
+	if err := c.Update(ctx, lease, client.FieldOwner(FieldManager)); err != nil {
+		return errors.Wrapf(err, "failed to update the %s/%s Lease", OperationLockNamespace, OperationLockName)
+	}
+	return nil
+}
+
+// release deletes the lock, if it is still held by the current process.
+func (l *operationLockClient) release() error {
+	c, err := l.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	lease := &coordinationv1.Lease{}
+	key := client.ObjectKey{Namespace: OperationLockNamespace, Name: OperationLockName}
+	if err := c.Get(ctx, key, lease); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get the %s/%s Lease", OperationLockNamespace, OperationLockName)
+	}
+	if !isOperationLockHeld(lease) {
+		return nil
+	}
+
+	if err := c.Delete(ctx, lease, client.Preconditions{ResourceVersion: &lease.ResourceVersion}); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete the %s/%s Lease", OperationLockNamespace, OperationLockName)
+	}
+	return nil
+}
+
+// isOperationLockHeld returns true if the lock is held by the current process.
+func isOperationLockHeld(lease *coordinationv1.Lease) bool {
+	return lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == operationLockHolder
+}
+
+// isOperationLockStale returns true if the lock has not been renewed within its duration.
+func isOperationLockStale(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second).Before(now)
+}
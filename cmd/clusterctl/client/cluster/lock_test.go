@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_operationLockClient_Acquire(t *testing.T) {
+	lease := func(holder string, renewTime time.Time) *coordinationv1.Lease {
+		acquireTime := metav1.NewMicroTime(renewTime)
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   OperationLockNamespace,
+				Name:        OperationLockName,
+				Annotations: map[string]string{OperationLockAnnotation: string(AuditMoveOperation)},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       pointer.StringPtr(holder),
+				LeaseDurationSeconds: pointer.Int32Ptr(int32(operationLockDuration.Seconds())),
+				AcquireTime:          &acquireTime,
+				RenewTime:            &acquireTime,
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		objs    []runtime.Object
+		force   bool
+		wantErr bool
+	}{
+		{
+			name: "acquires the lock if there is no lock",
+		},
+		{
+			name: "acquires the lock if the lock has been released",
+			objs: []runtime.Object{lease("", time.Now())},
+		},
+		{
+			name: "acquires the lock if the lock is stale",
+			objs: []runtime.Object{lease("other", time.Now().Add(-2*operationLockDuration))},
+		},
+		{
+			name:    "fails if the lock is held by another operation",
+			objs:    []runtime.Object{lease("other", time.Now())},
+			wantErr: true,
+		},
+		{
+			name:  "acquires the lock held by another operation if forced",
+			objs:  []runtime.Object{lease("other", time.Now())},
+			force: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			l := newOperationLockClient(proxy)
+
+			release, err := l.Acquire(AuditInitOperation, tt.force)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			c, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			got := &coordinationv1.Lease{}
+			key := client.ObjectKey{Namespace: OperationLockNamespace, Name: OperationLockName}
+			g.Expect(c.Get(ctx, key, got)).To(Succeed())
+			g.Expect(*got.Spec.HolderIdentity).To(Equal(operationLockHolder))
+			g.Expect(got.Annotations).To(HaveKeyWithValue(OperationLockAnnotation, string(AuditInitOperation)))
+
+			// Another operation can not acquire the lock until it is released.
+			_, err = l.Acquire(AuditDeleteOperation, false)
+			g.Expect(err).To(HaveOccurred())
+
+			g.Expect(release()).To(Succeed())
+			err = c.Get(ctx, key, &coordinationv1.Lease{})
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	}
+}
+
+func Test_isOperationLockStale(t *testing.T) {
+	now := time.Now()
+	renewTime := metav1.NewMicroTime(now.Add(-time.Minute))
+
+	tests := []struct {
+		name  string
+		lease *coordinationv1.Lease
+		want  bool
+	}{
+		{
+			name: "lock renewed within its duration",
+			lease: &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{
+				RenewTime:            &renewTime,
+				LeaseDurationSeconds: pointer.Int32Ptr(120),
+			}},
+			want: false,
+		},
+		{
+			name: "lock not renewed within its duration",
+			lease: &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{
+				RenewTime:            &renewTime,
+				LeaseDurationSeconds: pointer.Int32Ptr(30),
+			}},
+			want: true,
+		},
+		{
+			name:  "lock never renewed",
+			lease: &coordinationv1.Lease{},
+			want:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(isOperationLockStale(tt.lease, now)).To(Equal(tt.want))
+		})
+	}
+}
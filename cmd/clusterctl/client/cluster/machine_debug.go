@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MachineSSHEndpoint describes how to reach a Machine over SSH, as published by its infrastructure provider.
+type MachineSSHEndpoint struct {
+	// Endpoint is the SSH endpoint published in the status.sshEndpoint field of the infrastructure machine.
+	Endpoint clusterv1.SSHEndpoint
+
+	// Command is the ssh command line for reaching the Machine.
+	Command string
+}
+
+// MachineDebugClient has methods to help users debugging the Machines of a workload cluster.
+type MachineDebugClient interface {
+	// GetSSHEndpoint returns how to reach a Machine over SSH, as published by its infrastructure provider in the
+	// status.sshEndpoint field of the infrastructure machine; nil is returned if the infrastructure provider does
+	// not publish it.
+	GetSSHEndpoint(namespace, name string) (*MachineSSHEndpoint, error)
+}
+
+// machineDebugClient implements MachineDebugClient.
+type machineDebugClient struct {
+	proxy Proxy
+}
+
+// ensure machineDebugClient implements MachineDebugClient.
+var _ MachineDebugClient = &machineDebugClient{}
+
+// newMachineDebugClient returns a machineDebugClient.
+func newMachineDebugClient(proxy Proxy) *machineDebugClient {
+	return &machineDebugClient{
+		proxy: proxy,
+	}
+}
+
+func (d *machineDebugClient) GetSSHEndpoint(namespace, name string) (*MachineSSHEndpoint, error) {
+	c, err := d.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	machine := &clusterv1.Machine{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, machine); err != nil {
+		return nil, errors.Wrapf(err, "failed to get Machine %s/%s", namespace, name)
+	}
+
+	ref := machine.Spec.InfrastructureRef
+	infraMachine := &unstructured.Unstructured{}
+	infraMachine.SetAPIVersion(ref.APIVersion)
+	infraMachine.SetKind(ref.Kind)
+	infraMachineKey := client.ObjectKey{Namespace: namespace, Name: ref.Name}
+	if err := c.Get(ctx, infraMachineKey, infraMachine); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the %s %s for Machine %s/%s", ref.Kind, ref.Name, namespace, name)
+	}
+
+	endpoint := clusterv1.SSHEndpoint{}
+	err = util.UnstructuredUnmarshalField(infraMachine, &endpoint, "status", "sshEndpoint")
+	switch {
+	case err == util.ErrUnstructuredFieldNotFound:
+		return nil, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "failed to retrieve the SSH endpoint from the %s %s for Machine %s/%s", ref.Kind, ref.Name, namespace, name)
+	case endpoint.Host == "":
+		return nil, errors.Errorf("the SSH endpoint published by the %s %s for Machine %s/%s has no host", ref.Kind, ref.Name, namespace, name)
+	}
+
+	return &MachineSSHEndpoint{
+		Endpoint: endpoint,
+		Command:  sshCommand(endpoint),
+	}, nil
+}
+
+// sshCommand returns the ssh command line for reaching an SSHEndpoint.
+func sshCommand(endpoint clusterv1.SSHEndpoint) string {
+	args := []string{"ssh"}
+	if endpoint.ProxyJump != "" {
+		args = append(args, "-J", endpoint.ProxyJump)
+	}
+	if endpoint.Port != 0 && endpoint.Port != 22 {
+		args = append(args, "-p", fmt.Sprintf("%d", endpoint.Port))
+	}
+	target := endpoint.Host
+	if endpoint.User != "" {
+		target = fmt.Sprintf("%s@%s", endpoint.User, endpoint.Host)
+	}
+	args = append(args, target)
+	return strings.Join(args, " ")
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_machineDebugClient_GetSSHEndpoint(t *testing.T) {
+	machine := &clusterv1.Machine{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Machine",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "m1",
+		},
+		Spec: clusterv1.MachineSpec{
+			InfrastructureRef: corev1.ObjectReference{
+				APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+				Kind:       "DebugInfrastructureMachine",
+				Name:       "m1",
+			},
+		},
+	}
+	infraMachine := func(status map[string]interface{}) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+			"kind":       "DebugInfrastructureMachine",
+			"metadata": map[string]interface{}{
+				"namespace": "ns1",
+				"name":      "m1",
+			},
+		}}
+		if status != nil {
+			u.Object["status"] = status
+		}
+		return u
+	}
+
+	tests := []struct {
+		name    string
+		objs    []runtime.Object
+		want    *MachineSSHEndpoint
+		wantErr bool
+	}{
+		{
+			name: "returns the SSH endpoint published by the infrastructure machine",
+			objs: []runtime.Object{machine, infraMachine(map[string]interface{}{
+				"sshEndpoint": map[string]interface{}{
+					"host":      "10.0.0.1",
+					"port":      int64(2222),
+					"user":      "capi",
+					"proxyJump": "ubuntu@bastion.example.com",
+				},
+			})},
+			want: &MachineSSHEndpoint{
+				Endpoint: clusterv1.SSHEndpoint{Host: "10.0.0.1", Port: 2222, User: "capi", ProxyJump: "ubuntu@bastion.example.com"},
+				Command:  "ssh -J ubuntu@bastion.example.com -p 2222 capi@10.0.0.1",
+			},
+		},
+		{
+			name: "returns the SSH endpoint with only the host",
+			objs: []runtime.Object{machine, infraMachine(map[string]interface{}{
+				"sshEndpoint": map[string]interface{}{
+					"host": "10.0.0.1",
+				},
+			})},
+			want: &MachineSSHEndpoint{
+				Endpoint: clusterv1.SSHEndpoint{Host: "10.0.0.1"},
+				Command:  "ssh 10.0.0.1",
+			},
+		},
+		{
+			name: "returns nil if the infrastructure machine does not publish the SSH endpoint",
+			objs: []runtime.Object{machine, infraMachine(nil)},
+			want: nil,
+		},
+		{
+			name: "fails if the SSH endpoint has no host",
+			objs: []runtime.Object{machine, infraMachine(map[string]interface{}{
+				"sshEndpoint": map[string]interface{}{
+					"user": "capi",
+				},
+			})},
+			wantErr: true,
+		},
+		{
+			name:    "fails if the Machine does not exist",
+			objs:    []runtime.Object{infraMachine(nil)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			d := newMachineDebugClient(test.NewFakeProxy().WithObjs(tt.objs...))
+			got, err := d.GetSSHEndpoint("ns1", "m1")
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
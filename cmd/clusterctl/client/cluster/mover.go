@@ -17,6 +17,7 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/pkg/errors"
@@ -30,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/version"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/cluster-api/util/trace"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -37,6 +39,21 @@ import (
 type ObjectMover interface {
 	// Move moves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a target management cluster.
 	Move(namespace string, toCluster Client) error
+
+	// MoveCluster moves a Cluster and all the objects in its object graph to a target management cluster, while
+	// other Clusters in the same namespace are left in place.
+	MoveCluster(namespace, clusterName string, toCluster Client) error
+
+	// Sync copies the Cluster API objects existing in a namespace (or only the object graph of a Cluster, if clusterName
+	// is not empty) to a target management cluster, without pausing or deleting them in the source management cluster;
+	// the copies are paused in the target management cluster. Sync can be run repeatedly, copying only the objects changed
+	// since the last sync, and it is completed by a move performing the final cutover.
+	Sync(namespace, clusterName string, toCluster Client) error
+
+	// Plan returns the objects a move of the Cluster API objects existing in a namespace (or from all the namespaces if
+	// empty) would move, and the objects that would stay in the source management cluster, without changing anything;
+	// if clusterName is not empty, the plan is restricted to moving the object graph of that Cluster.
+	Plan(namespace, clusterName string) (*MovePlan, error)
 }
 
 // objectMover implements the ObjectMover interface.
@@ -49,29 +66,24 @@ type objectMover struct {
 var _ ObjectMover = &objectMover{}
 
 func (o *objectMover) Move(namespace string, toCluster Client) error {
-	log := logf.Log
-	log.Info("Performing move...")
+	return o.moveObjects(namespace, "", toCluster)
+}
 
-	objectGraph := newObjectGraph(o.fromProxy)
+func (o *objectMover) MoveCluster(namespace, clusterName string, toCluster Client) error {
+	return o.moveObjects(namespace, clusterName, toCluster)
+}
 
-	// checks that all the required providers in place in the target cluster.
-	if err := o.checkTargetProviders(namespace, toCluster.ProviderInventory()); err != nil {
-		return err
-	}
+// moveObjects moves the objects in a namespace (or in all the namespaces if empty), optionally restricting the move
+// to the object graph rooted at a single Cluster.
+func (o *objectMover) moveObjects(namespace, clusterName string, toCluster Client) error {
+	log := logf.Log
+	log.Info("Performing move...")
 
-	// Gets all the types defines by the CRDs installed by clusterctl plus the ConfigMap/Secret core types.
-	types, err := objectGraph.getDiscoveryTypes()
+	objectGraph, err := o.getObjectGraph(namespace, clusterName, toCluster)
 	if err != nil {
 		return err
 	}
 
-	// Discovery the object graph for the selected types:
-	// - Nodes are defined the Kubernetes objects (Clusters, Machines etc.) identified during the discovery process.
-	// - Edges are derived by the OwnerReferences between nodes.
-	if err := objectGraph.Discovery(namespace, types); err != nil {
-		return err
-	}
-
 	// Checks if Cluster API has already completed the provisioning of the infrastructure for the objects involved in the move operation.
 	// This is required because if the infrastructure is provisioned, then we can reasonably assume that the objects we are moving are
 	// not currently waiting for long-running reconciliation loops, and so we can safely rely on the pause field on the Cluster object
@@ -89,6 +101,39 @@ func (o *objectMover) Move(namespace string, toCluster Client) error {
 	return nil
 }
 
+// getObjectGraph discovers the object graph of the objects in a namespace (or in all the namespaces if empty), optionally
+// restricted to the object graph rooted at a single Cluster, after checking the providers in the target management cluster.
+func (o *objectMover) getObjectGraph(namespace, clusterName string, toCluster Client) (*objectGraph, error) {
+	objectGraph := newObjectGraph(o.fromProxy)
+
+	// checks that all the required providers in place in the target cluster.
+	if err := o.checkTargetProviders(namespace, toCluster.ProviderInventory()); err != nil {
+		return nil, err
+	}
+
+	// Gets all the types defines by the CRDs installed by clusterctl plus the ConfigMap/Secret core types.
+	types, err := objectGraph.getDiscoveryTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	// Discovery the object graph for the selected types:
+	// - Nodes are defined the Kubernetes objects (Clusters, Machines etc.) identified during the discovery process.
+	// - Edges are derived by the OwnerReferences between nodes.
+	if err := objectGraph.Discovery(namespace, types); err != nil {
+		return nil, err
+	}
+
+	// If moving a single Cluster, removes from the object graph all the objects not belonging to it.
+	if clusterName != "" {
+		if err := objectGraph.filterCluster(namespace, clusterName); err != nil {
+			return nil, err
+		}
+	}
+
+	return objectGraph, nil
+}
+
 func newObjectMover(fromProxy Proxy, fromProviderInventory InventoryClient) *objectMover {
 	return &objectMover{
 		fromProxy:             fromProxy,
@@ -186,12 +231,18 @@ func getMachineObj(proxy Proxy, machine *node, machineObj *clusterv1.Machine) er
 }
 
 // Move moves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a target management cluster
-func (o *objectMover) move(graph *objectGraph, toProxy Proxy) error {
+func (o *objectMover) move(graph *objectGraph, toProxy Proxy) (reterr error) {
 	log := logf.Log
 
 	clusters := graph.getClusters()
 	log.Info("Moving Cluster API objects", "Clusters", len(clusters))
 
+	ctx, span := trace.Start(context.Background(), "clusterctl.move", trace.Int("clusters", len(clusters)), trace.Int("objects", len(graph.uidToNode)))
+	defer func() {
+		span.RecordError(reterr)
+		span.End()
+	}()
+
 	// Sets the pause field on the Cluster object in the source management cluster, so the controllers stop reconciling it.
 	log.V(1).Info("Pausing the source cluster")
 	if err := setClusterPause(o.fromProxy, clusters, true); err != nil {
@@ -214,15 +265,32 @@ func (o *objectMover) move(graph *objectGraph, toProxy Proxy) error {
 	// Create all objects group by group, ensuring all the ownerReferences are re-created.
 	log.Info("Creating objects in the target cluster")
 	for groupIndex := 0; groupIndex < len(moveSequence.groups); groupIndex++ {
-		if err := o.createGroup(moveSequence.getGroup(groupIndex), toProxy); err != nil {
+		group := moveSequence.getGroup(groupIndex)
+		_, groupSpan := trace.Start(ctx, "clusterctl.move.createGroup", trace.Int("group", groupIndex), trace.Int("objects", len(group)))
+		err := o.createGroup(group, toProxy)
+		groupSpan.RecordError(err)
+		groupSpan.End()
+		if err != nil {
 			return err
 		}
 	}
 
+	// Delete the objects copied by a previous sync that were deleted from the source cluster since then, before
+	// deleting the source objects and unpausing the target cluster, so the stale copies are never reconciled.
+	log.V(1).Info("Pruning the objects deleted since the last sync from the target cluster")
+	if err := o.pruneTargetObjects(graph, toProxy); err != nil {
+		return err
+	}
+
 	// Delete all objects group by group in reverse order.
 	log.Info("Deleting objects from the source cluster")
 	for groupIndex := len(moveSequence.groups) - 1; groupIndex >= 0; groupIndex-- {
-		if err := o.deleteGroup(moveSequence.getGroup(groupIndex)); err != nil {
+		group := moveSequence.getGroup(groupIndex)
+		_, groupSpan := trace.Start(ctx, "clusterctl.move.deleteGroup", trace.Int("group", groupIndex), trace.Int("objects", len(group)))
+		err := o.deleteGroup(group)
+		groupSpan.RecordError(err)
+		groupSpan.End()
+		if err != nil {
 			return err
 		}
 	}
@@ -471,53 +539,21 @@ func (o *objectMover) createTargetObject(nodeToCreate *node, toProxy Proxy) erro
 	log := logf.Log
 	log.V(1).Info("Creating", nodeToCreate.identity.Kind, nodeToCreate.identity.Name, "Namespace", nodeToCreate.identity.Namespace)
 
-	cFrom, err := o.fromProxy.NewClient()
+	// Get the source object
+	obj, err := o.getSourceObject(nodeToCreate)
 	if err != nil {
 		return err
 	}
-
-	// Get the source object
-	obj := &unstructured.Unstructured{}
-	obj.SetAPIVersion(nodeToCreate.identity.APIVersion)
-	obj.SetKind(nodeToCreate.identity.Kind)
 	objKey := client.ObjectKey{
 		Namespace: nodeToCreate.identity.Namespace,
 		Name:      nodeToCreate.identity.Name,
 	}
 
-	if err := cFrom.Get(ctx, objKey, obj); err != nil {
-		return errors.Wrapf(err, "error reading %q %s/%s",
-			obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
-	}
-
 	// New objects cannot have a specified resource version. Clear it out.
 	obj.SetResourceVersion("")
 
-	// Removes current OwnerReferences
-	obj.SetOwnerReferences(nil)
-
 	// Recreate all the OwnerReferences using the newUID of the owner nodes.
-	if len(nodeToCreate.owners) > 0 {
-		ownerRefs := []metav1.OwnerReference{}
-		for ownerNode := range nodeToCreate.owners {
-			ownerRef := metav1.OwnerReference{
-				APIVersion: ownerNode.identity.APIVersion,
-				Kind:       ownerNode.identity.Kind,
-				Name:       ownerNode.identity.Name,
-				UID:        ownerNode.newUID, // Use the owner's newUID read from the target management cluster (instead of the UID read during discovery).
-			}
-
-			// Restores the attributes of the OwnerReference.
-			if attributes, ok := nodeToCreate.owners[ownerNode]; ok {
-				ownerRef.Controller = attributes.Controller
-				ownerRef.BlockOwnerDeletion = attributes.BlockOwnerDeletion
-			}
-
-			ownerRefs = append(ownerRefs, ownerRef)
-		}
-		obj.SetOwnerReferences(ownerRefs)
-
-	}
+	setTargetOwnerReferences(obj, nodeToCreate)
 
 	// Creates the targetObj into the target management cluster.
 	cTo, err := toProxy.NewClient()
@@ -558,6 +594,58 @@ func (o *objectMover) createTargetObject(nodeToCreate *node, toProxy Proxy) erro
 	return nil
 }
 
+// getSourceObject reads the Kubernetes object corresponding to the object graph node from the source management cluster.
+func (o *objectMover) getSourceObject(n *node) (*unstructured.Unstructured, error) {
+	cFrom, err := o.fromProxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(n.identity.APIVersion)
+	obj.SetKind(n.identity.Kind)
+	objKey := client.ObjectKey{
+		Namespace: n.identity.Namespace,
+		Name:      n.identity.Name,
+	}
+
+	if err := cFrom.Get(ctx, objKey, obj); err != nil {
+		return nil, errors.Wrapf(err, "error reading %q %s/%s",
+			obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+	}
+	return obj, nil
+}
+
+// setTargetOwnerReferences replaces the OwnerReferences of an object read from the source management cluster with
+// the OwnerReferences to the owner nodes in the target management cluster.
+func setTargetOwnerReferences(obj *unstructured.Unstructured, n *node) {
+	// Removes current OwnerReferences
+	obj.SetOwnerReferences(nil)
+
+	if len(n.owners) == 0 {
+		return
+	}
+
+	ownerRefs := []metav1.OwnerReference{}
+	for ownerNode := range n.owners {
+		ownerRef := metav1.OwnerReference{
+			APIVersion: ownerNode.identity.APIVersion,
+			Kind:       ownerNode.identity.Kind,
+			Name:       ownerNode.identity.Name,
+			UID:        ownerNode.newUID, // Use the owner's newUID read from the target management cluster (instead of the UID read during discovery).
+		}
+
+		// Restores the attributes of the OwnerReference.
+		if attributes, ok := n.owners[ownerNode]; ok {
+			ownerRef.Controller = attributes.Controller
+			ownerRef.BlockOwnerDeletion = attributes.BlockOwnerDeletion
+		}
+
+		ownerRefs = append(ownerRefs, ownerRef)
+	}
+	obj.SetOwnerReferences(ownerRefs)
+}
+
 // deleteGroup deletes all the Kubernetes objects from the source management cluster corresponding to the object graph nodes in a moveGroup.
 func (o *objectMover) deleteGroup(group moveGroup) error {
 	deleteSourceObjectBackoff := newWriteBackoff()
@@ -0,0 +1,228 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// MovePlan describes the objects a move would move to the target management cluster and the objects that would stay
+// in the source management cluster, with the reason for each of them, in a form suitable for being serialized to JSON.
+type MovePlan struct {
+	// Namespace where the objects are read from; if empty, the objects are read from all the namespaces.
+	Namespace string `json:"namespace,omitempty"`
+
+	// ClusterName is the name of the Cluster being moved; if empty, all the Clusters are moved.
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// Groups of objects, sorted by namespace and kind.
+	Groups []MovePlanGroup `json:"groups"`
+
+	// Blockers lists the reasons why the move would fail, e.g. Clusters still provisioning the infrastructure;
+	// it is empty if the move can be performed.
+	Blockers []string `json:"blockers,omitempty"`
+}
+
+// MovePlanGroup is a group of objects of the same kind and namespace in a MovePlan.
+type MovePlanGroup struct {
+	// Namespace of the objects.
+	Namespace string `json:"namespace,omitempty"`
+
+	// APIVersion of the objects.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the objects.
+	Kind string `json:"kind"`
+
+	// Objects in the group, sorted by name.
+	Objects []MovePlanObject `json:"objects"`
+}
+
+// MovePlanObject is an object in a MovePlan.
+type MovePlanObject struct {
+	// Name of the object.
+	Name string `json:"name"`
+
+	// Move is true if the object would be moved to the target management cluster.
+	Move bool `json:"move"`
+
+	// Reason explains why the object would be moved or why it would stay in the source management cluster.
+	Reason string `json:"reason"`
+}
+
+// Count returns the number of objects that would be moved and the number of objects that would stay.
+func (p *MovePlan) Count() (move, stay int) {
+	for _, g := range p.Groups {
+		for _, o := range g.Objects {
+			if o.Move {
+				move++
+				continue
+			}
+			stay++
+		}
+	}
+	return move, stay
+}
+
+func (o *objectMover) Plan(namespace, clusterName string) (*MovePlan, error) {
+	objectGraph := newObjectGraph(o.fromProxy)
+
+	// Gets all the types defined by the CRDs installed by clusterctl plus the ConfigMap/Secret core types.
+	types, err := objectGraph.getDiscoveryTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	// Discovery the object graph for the selected types.
+	if err := objectGraph.Discovery(namespace, types); err != nil {
+		return nil, err
+	}
+
+	plan, err := planMove(objectGraph, namespace, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Checks the same preconditions of move on the objects being moved, recording the errors as blockers.
+	if clusterName != "" {
+		plan.Blockers = append(plan.Blockers, errorMessages(objectGraph.filterCluster(namespace, clusterName))...)
+	}
+	plan.Blockers = append(plan.Blockers, errorMessages(o.checkProvisioningCompleted(objectGraph))...)
+
+	return plan, nil
+}
+
+// planMove returns the MovePlan for the nodes of an objectGraph, optionally restricting the move to the objects
+// belonging to the Cluster with the given name.
+func planMove(graph *objectGraph, namespace, clusterName string) (*MovePlan, error) {
+	if clusterName != "" {
+		found := false
+		for _, c := range graph.getClusters() {
+			if c.identity.Namespace == namespace && c.identity.Name == clusterName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("failed to find Cluster %s/%s", namespace, clusterName)
+		}
+	}
+
+	groups := map[string]*MovePlanGroup{}
+	for _, n := range graph.getNodes() {
+		key := strings.Join([]string{n.identity.Namespace, n.identity.APIVersion, n.identity.Kind}, "/")
+		group, ok := groups[key]
+		if !ok {
+			group = &MovePlanGroup{
+				Namespace:  n.identity.Namespace,
+				APIVersion: n.identity.APIVersion,
+				Kind:       n.identity.Kind,
+			}
+			groups[key] = group
+		}
+		move, reason := planNode(n, clusterName)
+		group.Objects = append(group.Objects, MovePlanObject{
+			Name:   n.identity.Name,
+			Move:   move,
+			Reason: reason,
+		})
+	}
+
+	plan := &MovePlan{
+		Namespace:   namespace,
+		ClusterName: clusterName,
+		Groups:      make([]MovePlanGroup, 0, len(groups)),
+	}
+	for _, group := range groups {
+		sort.Slice(group.Objects, func(i, j int) bool {
+			return group.Objects[i].Name < group.Objects[j].Name
+		})
+		plan.Groups = append(plan.Groups, *group)
+	}
+	sort.Slice(plan.Groups, func(i, j int) bool {
+		a, b := plan.Groups[i], plan.Groups[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.APIVersion < b.APIVersion
+	})
+	return plan, nil
+}
+
+// planNode returns if a node would be moved, and why, according to the same rules used for defining the move sequence.
+func planNode(n *node, clusterName string) (bool, string) {
+	if n.virtual {
+		return false, "the object does not exist, it is only referenced by an ownerReference"
+	}
+
+	clusters := sets.NewString()
+	for c := range n.tenantClusters {
+		clusters.Insert(c.identity.Name)
+	}
+	crss := sets.NewString()
+	for crs := range n.tenantCRSs {
+		crss.Insert(crs.identity.Name)
+	}
+
+	if clusterName == "" {
+		switch {
+		case clusters.Len() > 0:
+			return true, fmt.Sprintf("belongs to Cluster %s", strings.Join(clusters.List(), ", "))
+		case crss.Len() > 0:
+			return true, fmt.Sprintf("belongs to ClusterResourceSet %s", strings.Join(crss.List(), ", "))
+		default:
+			return false, "does not belong to any Cluster or ClusterResourceSet"
+		}
+	}
+
+	switch {
+	case clusters.Has(clusterName) && clusters.Len() > 1:
+		return false, fmt.Sprintf("is shared with other Clusters (%s), so the Cluster can't be moved alone", strings.Join(clusters.Delete(clusterName).List(), ", "))
+	case clusters.Has(clusterName):
+		return true, fmt.Sprintf("belongs to Cluster %s", clusterName)
+	case clusters.Len() > 0:
+		return false, fmt.Sprintf("belongs to other Clusters (%s)", strings.Join(clusters.List(), ", "))
+	case crss.Len() > 0:
+		return false, fmt.Sprintf("belongs to ClusterResourceSet %s, which is moved only when moving all the Clusters", strings.Join(crss.List(), ", "))
+	default:
+		return false, "does not belong to any Cluster or ClusterResourceSet"
+	}
+}
+
+// errorMessages returns the messages of an error, flattening aggregates.
+func errorMessages(err error) []string {
+	if err == nil {
+		return nil
+	}
+	var messages []string
+	if agg, ok := err.(kerrors.Aggregate); ok {
+		for _, e := range kerrors.Flatten(agg).Errors() {
+			messages = append(messages, e.Error())
+		}
+		return messages
+	}
+	return []string{err.Error()}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_planMove(t *testing.T) {
+	sharedInfrastructureTemplate := test.NewFakeInfrastructureTemplate("shared")
+	objs := []runtime.Object{sharedInfrastructureTemplate}
+	objs = append(objs, test.NewFakeCluster("ns1", "foo").
+		WithMachineSets(
+			test.NewFakeMachineSet("foo-ms1").WithInfrastructureTemplate(sharedInfrastructureTemplate),
+		).Objs()...)
+	objs = append(objs, test.NewFakeCluster("ns1", "bar").
+		WithMachineSets(
+			test.NewFakeMachineSet("bar-ms1").WithInfrastructureTemplate(sharedInfrastructureTemplate),
+		).Objs()...)
+	objs = append(objs, &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "unrelated"},
+	})
+
+	// planned returns the action and reason for an object in the plan.
+	planned := func(plan *MovePlan, kind, name string) *MovePlanObject {
+		for _, g := range plan.Groups {
+			if g.Kind != kind {
+				continue
+			}
+			for i := range g.Objects {
+				if g.Objects[i].Name == name {
+					return &g.Objects[i]
+				}
+			}
+		}
+		return nil
+	}
+
+	t.Run("moving all the Clusters", func(t *testing.T) {
+		g := NewWithT(t)
+
+		graph, err := getDetachedObjectGraphWihObjs(objs)
+		g.Expect(err).NotTo(HaveOccurred())
+		graph.setSoftOwnership()
+		graph.setClusterTenants()
+		graph.setCRSTenants()
+
+		plan, err := planMove(graph, "ns1", "")
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(planned(plan, "Cluster", "foo")).To(Equal(&MovePlanObject{Name: "foo", Move: true, Reason: "belongs to Cluster foo"}))
+		g.Expect(planned(plan, "Secret", "foo-kubeconfig")).To(Equal(&MovePlanObject{Name: "foo-kubeconfig", Move: true, Reason: "belongs to Cluster foo"}))
+		g.Expect(planned(plan, "GenericInfrastructureMachineTemplate", "shared")).To(Equal(&MovePlanObject{Name: "shared", Move: true, Reason: "belongs to Cluster bar, foo"}))
+		g.Expect(planned(plan, "Secret", "unrelated")).To(Equal(&MovePlanObject{Name: "unrelated", Move: false, Reason: "does not belong to any Cluster or ClusterResourceSet"}))
+
+		move, stay := plan.Count()
+		g.Expect(stay).To(Equal(1))
+		g.Expect(move).To(Equal(len(graph.getNodesWithTenants())))
+
+		// Groups are sorted by namespace and kind.
+		for i := 1; i < len(plan.Groups); i++ {
+			a, b := plan.Groups[i-1], plan.Groups[i]
+			g.Expect(a.Namespace < b.Namespace || a.Namespace == b.Namespace && a.Kind <= b.Kind).To(BeTrue())
+		}
+	})
+
+	t.Run("moving a single Cluster", func(t *testing.T) {
+		g := NewWithT(t)
+
+		graph, err := getDetachedObjectGraphWihObjs(objs)
+		g.Expect(err).NotTo(HaveOccurred())
+		graph.setSoftOwnership()
+		graph.setClusterTenants()
+		graph.setCRSTenants()
+
+		plan, err := planMove(graph, "ns1", "foo")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(plan.ClusterName).To(Equal("foo"))
+
+		g.Expect(planned(plan, "Cluster", "foo").Move).To(BeTrue())
+		g.Expect(planned(plan, "MachineSet", "foo-ms1").Move).To(BeTrue())
+		g.Expect(planned(plan, "Cluster", "bar")).To(Equal(&MovePlanObject{Name: "bar", Move: false, Reason: "belongs to other Clusters (bar)"}))
+		g.Expect(planned(plan, "GenericInfrastructureMachineTemplate", "shared")).To(Equal(&MovePlanObject{Name: "shared", Move: false, Reason: "is shared with other Clusters (bar), so the Cluster can't be moved alone"}))
+
+		// The same graph can't be moved, because of the shared object.
+		g.Expect(errorMessages(graph.filterCluster("ns1", "foo"))).To(HaveLen(1))
+	})
+
+	t.Run("fails if the Cluster does not exist", func(t *testing.T) {
+		g := NewWithT(t)
+
+		graph, err := getDetachedObjectGraphWihObjs(objs)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		_, err = planMove(graph, "ns1", "baz")
+		g.Expect(err).To(HaveOccurred())
+	})
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SyncedResourceVersionAnnotation is set by sync on the objects in the target management cluster, recording the
+	// resource version of the corresponding object in the source management cluster at the time of the last sync.
+	SyncedResourceVersionAnnotation = "clusterctl.cluster.x-k8s.io/synced-resource-version"
+)
+
+func (o *objectMover) Sync(namespace, clusterName string, toCluster Client) error {
+	log := logf.Log
+	log.Info("Performing sync...")
+
+	objectGraph, err := o.getObjectGraph(namespace, clusterName, toCluster)
+	if err != nil {
+		return err
+	}
+
+	// Nb. The provisioning is not required to be completed, because the objects in the source management cluster
+	// are not paused; the provisioning continues in the source management cluster until the final move.
+	if err := o.sync(objectGraph, toCluster.Proxy()); err != nil {
+		return err
+	}
+
+	return o.pruneTargetObjects(objectGraph, toCluster.Proxy())
+}
+
+// sync copies all the objects in the object graph to a target management cluster, creating them or updating the
+// ones changed since the last sync; the Cluster objects are paused in the target management cluster.
+func (o *objectMover) sync(graph *objectGraph, toProxy Proxy) error {
+	log := logf.Log
+
+	clusters := graph.getClusters()
+	log.Info("Syncing Cluster API objects", "Clusters", len(clusters))
+
+	// Ensure all the expected target namespaces are in place before creating objects.
+	log.V(1).Info("Creating target namespaces, if missing")
+	if err := o.ensureNamespaces(graph, toProxy); err != nil {
+		return err
+	}
+
+	// Sync all objects group by group, following the move sequence, so the OwnerReferences can be re-created
+	// with the UIDs of the owners in the target management cluster.
+	moveSequence := getMoveSequence(graph)
+
+	log.Info("Syncing objects in the target cluster")
+	for groupIndex := 0; groupIndex < len(moveSequence.groups); groupIndex++ {
+		if err := o.syncGroup(moveSequence.getGroup(groupIndex), toProxy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncGroup syncs all the Kubernetes objects corresponding to the object graph nodes in a moveGroup into the target management cluster.
+func (o *objectMover) syncGroup(group moveGroup, toProxy Proxy) error {
+	syncTargetObjectBackoff := newWriteBackoff()
+	errList := []error{}
+	for i := range group {
+		nodeToSync := group[i]
+
+		// Nb. The operation is wrapped in a retry loop to make sync more resilient to unexpected conditions.
+		err := retryWithExponentialBackoff(syncTargetObjectBackoff, func() error {
+			return o.syncTargetObject(nodeToSync, toProxy)
+		})
+		if err != nil {
+			errList = append(errList, err)
+		}
+	}
+
+	return kerrors.NewAggregate(errList)
+}
+
+// syncTargetObject creates or updates the Kubernetes object in the target management cluster corresponding to the
+// object graph node; objects not changed in the source management cluster since the last sync, and whose owners
+// were not re-created in the target management cluster, are skipped.
+func (o *objectMover) syncTargetObject(nodeToSync *node, toProxy Proxy) error {
+	log := logf.Log
+
+	obj, err := o.getSourceObject(nodeToSync)
+	if err != nil {
+		return err
+	}
+	objKey := client.ObjectKey{
+		Namespace: nodeToSync.identity.Namespace,
+		Name:      nodeToSync.identity.Name,
+	}
+	sourceResourceVersion := obj.GetResourceVersion()
+
+	// Recreate all the OwnerReferences using the newUID of the owner nodes.
+	setTargetOwnerReferences(obj, nodeToSync)
+
+	cTo, err := toProxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	existingTargetObj := &unstructured.Unstructured{}
+	existingTargetObj.SetAPIVersion(obj.GetAPIVersion())
+	existingTargetObj.SetKind(obj.GetKind())
+	if err := cTo.Get(ctx, objKey, existingTargetObj); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "error reading %q %s/%s",
+				existingTargetObj.GroupVersionKind(), existingTargetObj.GetNamespace(), existingTargetObj.GetName())
+		}
+		existingTargetObj = nil
+	}
+
+	if existingTargetObj != nil &&
+		existingTargetObj.GetAnnotations()[SyncedResourceVersionAnnotation] == sourceResourceVersion &&
+		sameOwnerReferences(existingTargetObj.GetOwnerReferences(), obj.GetOwnerReferences()) {
+		log.V(5).Info("Object not changed since the last sync, skipping", nodeToSync.identity.Kind, nodeToSync.identity.Name, "Namespace", nodeToSync.identity.Namespace)
+		nodeToSync.newUID = existingTargetObj.GetUID()
+		return nil
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[SyncedResourceVersionAnnotation] = sourceResourceVersion
+	obj.SetAnnotations(annotations)
+
+	// Pauses the Clusters in the target management cluster, so the controllers do not reconcile the copies
+	// of the objects still managed in the source management cluster.
+	if obj.GroupVersionKind().GroupKind() == clusterv1.GroupVersion.WithKind("Cluster").GroupKind() {
+		if err := unstructured.SetNestedField(obj.Object, true, "spec", "paused"); err != nil {
+			return errors.Wrapf(err, "error pausing %q %s/%s",
+				obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+		}
+	}
+
+	if existingTargetObj == nil {
+		log.V(1).Info("Creating", nodeToSync.identity.Kind, nodeToSync.identity.Name, "Namespace", nodeToSync.identity.Namespace)
+
+		// New objects cannot have a specified resource version. Clear it out.
+		obj.SetResourceVersion("")
+		if err := cTo.Create(ctx, obj); err != nil {
+			return errors.Wrapf(err, "error creating %q %s/%s",
+				obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+		}
+	} else {
+		log.V(1).Info("Updating", nodeToSync.identity.Kind, nodeToSync.identity.Name, "Namespace", nodeToSync.identity.Namespace)
+
+		// Preserve the UID and use the resource version of the object in the target management cluster.
+		obj.SetUID(existingTargetObj.GetUID())
+		obj.SetResourceVersion(existingTargetObj.GetResourceVersion())
+		if err := cTo.Update(ctx, obj); err != nil {
+			return errors.Wrapf(err, "error updating %q %s/%s",
+				obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+		}
+	}
+
+	// Stores the newUID assigned to the object in the target management cluster.
+	nodeToSync.newUID = obj.GetUID()
+
+	return nil
+}
+
+// pruneTargetObjects deletes from the target management cluster the objects copied by a previous sync that no longer
+// exist in the source management cluster, e.g. Machines replaced by a rollout. The objects are searched within the
+// scope of the discovery of the object graph; when syncing or moving a single Cluster, only the objects with the
+// cluster name label of the Cluster are considered.
+func (o *objectMover) pruneTargetObjects(graph *objectGraph, toProxy Proxy) error {
+	log := logf.Log
+
+	selectors := []client.ListOption{}
+	if graph.namespace != "" {
+		selectors = append(selectors, client.InNamespace(graph.namespace))
+	}
+	if graph.clusterName != "" {
+		selectors = append(selectors, client.MatchingLabels{clusterv1.ClusterLabelName: graph.clusterName})
+	}
+
+	sourceObjs := sets.NewString()
+	for _, n := range graph.uidToNode {
+		sourceObjs.Insert(objKeyString(n.identity.GroupVersionKind().GroupKind().String(), n.identity.Namespace, n.identity.Name))
+	}
+
+	cTo, err := toProxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	readBackoff := newReadBackoff()
+	deleteBackoff := newWriteBackoff()
+	errList := []error{}
+	for i := range graph.discoveryTypes {
+		typeMeta := graph.discoveryTypes[i]
+		objList := new(unstructured.UnstructuredList)
+		if err := retryWithExponentialBackoff(readBackoff, func() error {
+			return getObjList(toProxy, typeMeta, selectors, objList)
+		}); err != nil {
+			return err
+		}
+
+		for j := range objList.Items {
+			obj := &objList.Items[j]
+			if _, ok := obj.GetAnnotations()[SyncedResourceVersionAnnotation]; !ok {
+				continue
+			}
+			if sourceObjs.Has(objKeyString(obj.GroupVersionKind().GroupKind().String(), obj.GetNamespace(), obj.GetName())) {
+				continue
+			}
+
+			log.V(1).Info("Deleting", obj.GetKind(), obj.GetName(), "Namespace", obj.GetNamespace())
+			if err := retryWithExponentialBackoff(deleteBackoff, func() error {
+				// Nb. The finalizers are removed, because the objects are paused in the target management cluster.
+				if len(obj.GetFinalizers()) > 0 {
+					if err := cTo.Patch(ctx, obj, removeFinalizersPatch); err != nil && !apierrors.IsNotFound(err) {
+						return errors.Wrapf(err, "error removing finalizers from %q %s/%s",
+							obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+					}
+				}
+				if err := cTo.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+					return errors.Wrapf(err, "error deleting %q %s/%s",
+						obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+				}
+				return nil
+			}); err != nil {
+				errList = append(errList, err)
+			}
+		}
+	}
+
+	return kerrors.NewAggregate(errList)
+}
+
+func objKeyString(groupKind, namespace, name string) string {
+	return fmt.Sprintf("%s, %s/%s", groupKind, namespace, name)
+}
+
+// sameOwnerReferences returns true if the two lists contain the same OwnerReferences, regardless of the order.
+func sameOwnerReferences(a, b []metav1.OwnerReference) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	byUID := func(refs []metav1.OwnerReference) []metav1.OwnerReference {
+		sorted := append([]metav1.OwnerReference{}, refs...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].UID < sorted[j].UID })
+		return sorted
+	}
+	sortedA, sortedB := byUID(a), byUID(b)
+	for i := range sortedA {
+		if sortedA[i].UID != sortedB[i].UID ||
+			sortedA[i].Kind != sortedB[i].Kind ||
+			sortedA[i].Name != sortedB[i].Name ||
+			!boolPtrEqual(sortedA[i].Controller, sortedB[i].Controller) ||
+			!boolPtrEqual(sortedA[i].BlockOwnerDeletion, sortedB[i].BlockOwnerDeletion) {
+			return false
+		}
+	}
+	return true
+}
+
+func boolPtrEqual(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
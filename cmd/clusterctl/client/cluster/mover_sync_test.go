@@ -0,0 +1,234 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_objectMover_sync(t *testing.T) {
+	// NB. we are testing sync using the same set of moveTests used for move.
+	for _, tt := range moveTests {
+		if tt.wantErr {
+			continue
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			// Create an objectGraph bound a source cluster with all the CRDs for the types involved in the test.
+			graph := getObjectGraphWithObjs(tt.fields.objs)
+
+			// Get all the types to be considered for discovery
+			discoveryTypes, err := getFakeDiscoveryTypes(graph)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			// trigger discovery the content of the source cluster
+			g.Expect(graph.Discovery("ns1", discoveryTypes)).To(Succeed())
+
+			// gets a fakeProxy to an empty cluster with all the required CRDs
+			toProxy := getFakeProxyWithCRDs()
+
+			mover := objectMover{
+				fromProxy: graph.proxy,
+			}
+
+			g.Expect(mover.sync(graph, toProxy)).To(Succeed())
+
+			csFrom, err := graph.proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			csTo, err := toProxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			getObj := func(c client.Client, n *node) *unstructured.Unstructured {
+				o := &unstructured.Unstructured{}
+				o.SetAPIVersion(n.identity.APIVersion)
+				o.SetKind(n.identity.Kind)
+				g.Expect(c.Get(ctx, client.ObjectKey{Namespace: n.identity.Namespace, Name: n.identity.Name}, o)).To(Succeed())
+				return o
+			}
+
+			targetResourceVersions := map[*node]string{}
+			for _, n := range graph.uidToNode {
+				// objects are preserved in the source cluster and copied to the target cluster
+				oFrom := getObj(csFrom, n)
+				oTo := getObj(csTo, n)
+				targetResourceVersions[n] = oTo.GetResourceVersion()
+
+				g.Expect(oTo.GetAnnotations()).To(HaveKeyWithValue(SyncedResourceVersionAnnotation, oFrom.GetResourceVersion()))
+				g.Expect(oTo.GetResourceVersion()).NotTo(BeEmpty())
+				g.Expect(oTo.GetUID()).To(Equal(n.newUID))
+
+				// OwnerReferences use the UIDs of the owners in the target cluster
+				ownerUIDs := []types.UID{}
+				for _, ref := range oTo.GetOwnerReferences() {
+					ownerUIDs = append(ownerUIDs, ref.UID)
+				}
+				wantOwnerUIDs := []types.UID{}
+				for ownerNode := range n.owners {
+					wantOwnerUIDs = append(wantOwnerUIDs, ownerNode.newUID)
+				}
+				g.Expect(ownerUIDs).To(ConsistOf(wantOwnerUIDs))
+
+				// Clusters are paused in the target cluster only
+				if n.identity.GroupVersionKind().GroupKind() == clusterv1.GroupVersion.WithKind("Cluster").GroupKind() {
+					paused, _, _ := unstructured.NestedBool(oFrom.Object, "spec", "paused")
+					g.Expect(paused).To(BeFalse())
+					paused, _, _ = unstructured.NestedBool(oTo.Object, "spec", "paused")
+					g.Expect(paused).To(BeTrue())
+				}
+			}
+
+			// Change an object in the source cluster, then sync again.
+			clusters := graph.getClusters()
+			g.Expect(clusters).NotTo(BeEmpty())
+			changed := getObj(csFrom, clusters[0])
+			changed.SetLabels(map[string]string{"changed": "true"})
+			g.Expect(csFrom.Update(ctx, changed)).To(Succeed())
+
+			g.Expect(mover.sync(graph, toProxy)).To(Succeed())
+
+			for _, n := range graph.uidToNode {
+				oTo := getObj(csTo, n)
+				if n == clusters[0] {
+					// the changed object is updated in the target cluster, preserving the UID
+					g.Expect(oTo.GetLabels()).To(HaveKeyWithValue("changed", "true"))
+					g.Expect(oTo.GetUID()).To(Equal(n.newUID))
+					continue
+				}
+				// the other objects are skipped
+				g.Expect(oTo.GetResourceVersion()).To(Equal(targetResourceVersions[n]))
+			}
+		})
+	}
+}
+
+func Test_objectMover_pruneTargetObjects(t *testing.T) {
+	g := NewWithT(t)
+
+	// the source cluster, where the Machine m2 was deleted since the last sync
+	graph := getObjectGraphWithObjs(test.NewFakeCluster("ns1", "cluster1").
+		WithMachines(
+			test.NewFakeMachine("m1"),
+		).Objs())
+
+	discoveryTypes, err := getFakeDiscoveryTypes(graph)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(graph.Discovery("ns1", discoveryTypes)).To(Succeed())
+
+	// the target cluster, with the objects copied by the last sync and a Cluster not copied by sync
+	toProxy := getFakeProxyWithCRDs()
+	for _, o := range test.NewFakeCluster("ns1", "cluster1").
+		WithMachines(
+			test.NewFakeMachine("m1"),
+			test.NewFakeMachine("m2"),
+		).Objs() {
+		accessor, err := meta.Accessor(o)
+		g.Expect(err).NotTo(HaveOccurred())
+		accessor.SetAnnotations(map[string]string{SyncedResourceVersionAnnotation: "1"})
+		// finalizers must not block pruning
+		accessor.SetFinalizers([]string{"test"})
+		toProxy.WithObjs(o)
+	}
+	for _, o := range test.NewFakeCluster("ns1", "other").Objs() {
+		toProxy.WithObjs(o)
+	}
+
+	mover := objectMover{
+		fromProxy: graph.proxy,
+	}
+	g.Expect(mover.pruneTargetObjects(graph, toProxy)).To(Succeed())
+
+	csTo, err := toProxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	getObj := func(apiVersion, kind, name string) error {
+		o := &unstructured.Unstructured{}
+		o.SetAPIVersion(apiVersion)
+		o.SetKind(kind)
+		return csTo.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: name}, o)
+	}
+
+	// the Machine deleted from the source cluster is deleted from the target cluster
+	g.Expect(apierrors.IsNotFound(getObj(clusterv1.GroupVersion.String(), "Machine", "m2"))).To(BeTrue())
+
+	// the objects existing in the source cluster are preserved
+	for _, n := range graph.uidToNode {
+		g.Expect(getObj(n.identity.APIVersion, n.identity.Kind, n.identity.Name)).To(Succeed())
+	}
+
+	// the objects not copied by sync are preserved
+	g.Expect(getObj(clusterv1.GroupVersion.String(), "Cluster", "other")).To(Succeed())
+}
+
+func Test_objectMover_move_afterSync(t *testing.T) {
+	g := NewWithT(t)
+
+	graph := getObjectGraphWithObjs(test.NewFakeCluster("ns1", "cluster1").
+		WithMachines(
+			test.NewFakeMachine("m1"),
+			test.NewFakeMachine("m2"),
+		).Objs())
+	discoveryTypes, err := getFakeDiscoveryTypes(graph)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(graph.Discovery("ns1", discoveryTypes)).To(Succeed())
+
+	toProxy := getFakeProxyWithCRDs()
+	mover := objectMover{
+		fromProxy: graph.proxy,
+	}
+	g.Expect(mover.sync(graph, toProxy)).To(Succeed())
+
+	getObj := func(c client.Client, kind, name string) (*unstructured.Unstructured, error) {
+		o := &unstructured.Unstructured{}
+		o.SetAPIVersion(clusterv1.GroupVersion.String())
+		o.SetKind(kind)
+		return o, c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: name}, o)
+	}
+
+	// the Machine m2 is deleted from the source cluster between the sync and the final move
+	graph = getObjectGraphWithObjs(test.NewFakeCluster("ns1", "cluster1").
+		WithMachines(
+			test.NewFakeMachine("m1"),
+		).Objs())
+	g.Expect(graph.Discovery("ns1", discoveryTypes)).To(Succeed())
+	mover.fromProxy = graph.proxy
+	g.Expect(mover.move(graph, toProxy)).To(Succeed())
+
+	csTo, err := toProxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// the stale copy of m2 is deleted from the target cluster before the Cluster is unpaused
+	_, err = getObj(csTo, "Machine", "m2")
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	_, err = getObj(csTo, "Machine", "m1")
+	g.Expect(err).NotTo(HaveOccurred())
+	cluster, err := getObj(csTo, "Cluster", "cluster1")
+	g.Expect(err).NotTo(HaveOccurred())
+	paused, _, _ := unstructured.NestedBool(cluster.Object, "spec", "paused")
+	g.Expect(paused).To(BeFalse())
+}
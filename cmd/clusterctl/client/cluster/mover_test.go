@@ -18,6 +18,7 @@ package cluster
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
@@ -500,6 +501,75 @@ func Test_objectMover_move(t *testing.T) {
 	}
 }
 
+func Test_objectMover_move_Scale(t *testing.T) {
+	g := NewWithT(t)
+
+	scale := test.NewFakeScale().
+		WithNamespaces(2).
+		WithClustersPerNamespace(5).
+		WithControlPlaneMachines(3).
+		WithMachineDeployments(2, 5)
+	graph := getObjectGraphWithObjs(scale.Objs())
+
+	discoveryTypes, err := getFakeDiscoveryTypes(graph)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(graph.Discovery("ns1", discoveryTypes)).To(Succeed())
+	g.Expect(graph.getClusters()).To(HaveLen(5))
+
+	toProxy := getFakeProxyWithCRDs()
+	mover := objectMover{
+		fromProxy: graph.proxy,
+	}
+	g.Expect(mover.move(graph, toProxy)).To(Succeed())
+
+	csTo, err := toProxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// All the Machines in the namespace are created in the target cluster.
+	machines := 0
+	for _, node := range graph.getNodes() {
+		if node.identity.Kind != "Machine" {
+			continue
+		}
+		machines++
+		m := &unstructured.Unstructured{}
+		m.SetAPIVersion(node.identity.APIVersion)
+		m.SetKind(node.identity.Kind)
+		g.Expect(csTo.Get(ctx, client.ObjectKey{Namespace: node.identity.Namespace, Name: node.identity.Name}, m)).To(Succeed())
+	}
+	g.Expect(machines).To(Equal(scale.Machines() / 2))
+}
+
+// Benchmark_objectMover_move measures the move of many Clusters from a management cluster with a latency of 1ms
+// for each call to the API server.
+func Benchmark_objectMover_move(b *testing.B) {
+	scale := test.NewFakeScale().
+		WithClustersPerNamespace(20).
+		WithControlPlaneMachines(3).
+		WithMachineDeployments(2, 10)
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		graph := newObjectGraph(getFakeProxyWithCRDs().WithObjs(scale.Objs()...).WithLatency(time.Millisecond))
+		toProxy := getFakeProxyWithCRDs().WithLatency(time.Millisecond)
+		discoveryTypes, err := getFakeDiscoveryTypes(graph)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		if err := graph.Discovery("ns1", discoveryTypes); err != nil {
+			b.Fatal(err)
+		}
+		mover := objectMover{
+			fromProxy: graph.proxy,
+		}
+		if err := mover.move(graph, toProxy); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func Test_objectMover_checkProvisioningCompleted(t *testing.T) {
 	type fields struct {
 		objs []runtime.Object
@@ -0,0 +1,121 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NamespaceOptions defines the labels and annotations applied to the namespaces created by clusterctl, e.g.
+// the pod-security.kubernetes.io labels or the PodSecurityPolicy related annotations required by the cluster.
+type NamespaceOptions struct {
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// NamespaceClient has methods to work with namespaces in the management cluster.
+type NamespaceClient interface {
+	// Check checks if the namespace exists, returning a meaningful error in case the current user is not allowed
+	// to read it.
+	Check(name string) (bool, error)
+
+	// Ensure creates the namespace, if it does not exist, with the clusterctl label and the labels and annotations
+	// defined in the options; existing namespaces are not changed.
+	Ensure(name string, options NamespaceOptions) error
+}
+
+// namespaceClient implements NamespaceClient.
+type namespaceClient struct {
+	proxy Proxy
+}
+
+// ensure namespaceClient implements NamespaceClient.
+var _ NamespaceClient = &namespaceClient{}
+
+// newNamespaceClient returns a namespaceClient.
+func newNamespaceClient(proxy Proxy) *namespaceClient {
+	return &namespaceClient{
+		proxy: proxy,
+	}
+}
+
+func (n *namespaceClient) Check(name string) (bool, error) {
+	c, err := n.proxy.NewClient()
+	if err != nil {
+		return false, err
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, namespace); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if apierrors.IsForbidden(err) {
+			return false, errors.Errorf("the current user is not allowed to get the namespace %q in the management cluster; please check the permissions or use another target namespace", name)
+		}
+		return false, errors.Wrapf(err, "failed to get the namespace %q", name)
+	}
+	return true, nil
+}
+
+func (n *namespaceClient) Ensure(name string, options NamespaceOptions) error {
+	log := logf.Log
+
+	exists, err := n.Check(name)
+	if err != nil || exists {
+		return err
+	}
+
+	c, err := n.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				clusterctlv1.ClusterctlLabelName: "",
+			},
+			Annotations: map[string]string{},
+		},
+	}
+	for k, v := range options.Labels {
+		namespace.Labels[k] = v
+	}
+	for k, v := range options.Annotations {
+		namespace.Annotations[k] = v
+	}
+
+	log.V(1).Info("Creating", "Namespace", name)
+	if err := c.Create(ctx, namespace, client.FieldOwner(FieldManager)); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+		if apierrors.IsForbidden(err) {
+			return errors.Errorf("the current user is not allowed to create the namespace %q in the management cluster; please create it or use another target namespace", name)
+		}
+		return errors.Wrapf(err, "failed to create the namespace %q", name)
+	}
+	return nil
+}
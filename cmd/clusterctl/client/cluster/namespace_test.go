@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_namespaceClient_Check(t *testing.T) {
+	g := NewWithT(t)
+
+	proxy := test.NewFakeProxy().WithObjs(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "existing"}})
+	n := newNamespaceClient(proxy)
+
+	exists, err := n.Check("existing")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(exists).To(BeTrue())
+
+	exists, err = n.Check("missing")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(exists).To(BeFalse())
+}
+
+func Test_namespaceClient_Ensure(t *testing.T) {
+	existing := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "existing",
+			Labels: map[string]string{"foo": "bar"},
+		},
+	}
+	options := NamespaceOptions{
+		Labels:      map[string]string{"pod-security.kubernetes.io/enforce": "privileged"},
+		Annotations: map[string]string{"seccomp.security.alpha.kubernetes.io/allowedProfileNames": "*"},
+	}
+
+	tests := []struct {
+		name            string
+		namespace       string
+		wantLabels      map[string]string
+		wantAnnotations map[string]string
+	}{
+		{
+			name:      "creates a missing namespace with the clusterctl label, the labels and the annotations",
+			namespace: "missing",
+			wantLabels: map[string]string{
+				clusterctlv1.ClusterctlLabelName:     "",
+				"pod-security.kubernetes.io/enforce": "privileged",
+			},
+			wantAnnotations: options.Annotations,
+		},
+		{
+			name:       "does not change an existing namespace",
+			namespace:  "existing",
+			wantLabels: existing.Labels,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			proxy := test.NewFakeProxy().WithObjs(existing.DeepCopy())
+			n := newNamespaceClient(proxy)
+
+			g.Expect(n.Ensure(tt.namespace, options)).To(Succeed())
+
+			c, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+			got := &corev1.Namespace{}
+			g.Expect(c.Get(ctx, client.ObjectKey{Name: tt.namespace}, got)).To(Succeed())
+			g.Expect(got.Labels).To(Equal(tt.wantLabels))
+			if tt.wantAnnotations == nil {
+				g.Expect(got.Annotations).To(BeEmpty())
+			} else {
+				g.Expect(got.Annotations).To(Equal(tt.wantAnnotations))
+			}
+		})
+	}
+}
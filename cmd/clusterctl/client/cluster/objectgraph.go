@@ -24,10 +24,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
 	addonsv1alpha3 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
 	secretutil "sigs.k8s.io/cluster-api/util/secret"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -63,6 +65,17 @@ type node struct {
 	// tenantCRSs define the list of ClusterResourceSet which are tenant for the node, no matter if the node has a direct OwnerReference to the ClusterResourceSet or if
 	// the node is linked to a ClusterResourceSet indirectly in the OwnerReference chain.
 	tenantCRSs map[*node]empty
+
+	// status stores the version and the status of the object, if any; it is used only when exporting the object graph.
+	status nodeStatus
+}
+
+// nodeStatus defines the version and the status of an object in the Kubernetes object graph.
+type nodeStatus struct {
+	version    string
+	phase      string
+	ready      *bool
+	conditions clusterv1.Conditions
 }
 
 // markObserved marks the fact that a node was observed as a concrete object.
@@ -92,6 +105,12 @@ func (n *node) isSoftOwnedBy(other *node) bool {
 type objectGraph struct {
 	proxy     Proxy
 	uidToNode map[types.UID]*node
+
+	// discoveryTypes, namespace and clusterName record the scope of the discovery, e.g. for pruning from a target
+	// management cluster the objects copied by sync that no longer exist in the object graph.
+	discoveryTypes []metav1.TypeMeta
+	namespace      string
+	clusterName    string
 }
 
 func newObjectGraph(proxy Proxy) *objectGraph {
@@ -150,6 +169,7 @@ func (o *objectGraph) objToNode(obj *unstructured.Unstructured) *node {
 	existingNode, found := o.uidToNode[obj.GetUID()]
 	if found {
 		existingNode.markObserved()
+		existingNode.status = objToNodeStatus(obj)
 		return existingNode
 	}
 
@@ -166,12 +186,30 @@ func (o *objectGraph) objToNode(obj *unstructured.Unstructured) *node {
 		tenantClusters: make(map[*node]empty),
 		tenantCRSs:     make(map[*node]empty),
 		virtual:        false,
+		status:         objToNodeStatus(obj),
 	}
 
 	o.uidToNode[newNode.identity.UID] = newNode
 	return newNode
 }
 
+// objToNodeStatus returns the version and the status of the Kubernetes object received in input, if any.
+// Nb. MachineDeployments and MachinePools define the version in the Machine template.
+func objToNodeStatus(obj *unstructured.Unstructured) nodeStatus {
+	status := nodeStatus{}
+	if version, ok, _ := unstructured.NestedString(obj.Object, "spec", "version"); ok {
+		status.version = version
+	} else if version, ok, _ := unstructured.NestedString(obj.Object, "spec", "template", "spec", "version"); ok {
+		status.version = version
+	}
+	status.phase, _, _ = unstructured.NestedString(obj.Object, "status", "phase")
+	if ready, ok, _ := unstructured.NestedBool(obj.Object, "status", "ready"); ok {
+		status.ready = &ready
+	}
+	_ = util.UnstructuredUnmarshalField(obj, &status.conditions, "status", "conditions")
+	return status
+}
+
 // getDiscoveryTypes returns the list of TypeMeta to be considered for the the move discovery phase.
 // This list includes all the types defines by the CRDs installed by clusterctl and the ConfigMap/Secret core types.
 func (o *objectGraph) getDiscoveryTypes() ([]metav1.TypeMeta, error) {
@@ -225,6 +263,9 @@ func (o *objectGraph) Discovery(namespace string, types []metav1.TypeMeta) error
 	log := logf.Log
 	log.Info("Discovering Cluster API objects")
 
+	o.discoveryTypes = types
+	o.namespace = namespace
+
 	selectors := []client.ListOption{}
 	if namespace != "" {
 		selectors = append(selectors, client.InNamespace(namespace))
@@ -349,6 +390,55 @@ func (o *objectGraph) getMachines() []*node {
 	return machines
 }
 
+// filterCluster removes from the graph all the nodes not belonging to the given Cluster, so only the object graph
+// rooted at the Cluster is moved. It returns an error if objects belonging to the Cluster are shared with other Clusters,
+// because moving them would break the other Clusters.
+// Nb. ClusterResourceSets are not part of the object graph rooted at a Cluster, so they are not moved; the
+// ownerReferences to objects not moved are not re-created in the target cluster.
+func (o *objectGraph) filterCluster(namespace, name string) error {
+	var cluster *node
+	for _, c := range o.getClusters() {
+		if c.identity.Namespace == namespace && c.identity.Name == name {
+			cluster = c
+			break
+		}
+	}
+	if cluster == nil {
+		return errors.Errorf("failed to find Cluster %s/%s", namespace, name)
+	}
+
+	errList := []error{}
+	for uid, n := range o.uidToNode {
+		if _, ok := n.tenantClusters[cluster]; !ok {
+			delete(o.uidToNode, uid)
+			continue
+		}
+		if len(n.tenantClusters) > 1 {
+			errList = append(errList, errors.Errorf("cannot move %q %s/%s because it is shared with other Clusters", n.identity.GroupVersionKind(), n.identity.Namespace, n.identity.Name))
+		}
+	}
+	if len(errList) > 0 {
+		return kerrors.NewAggregate(errList)
+	}
+
+	// Removes the links to the nodes not moved.
+	for _, n := range o.uidToNode {
+		for owner := range n.owners {
+			if _, ok := o.uidToNode[owner.identity.UID]; !ok {
+				delete(n.owners, owner)
+			}
+		}
+		for owner := range n.softOwners {
+			if _, ok := o.uidToNode[owner.identity.UID]; !ok {
+				delete(n.softOwners, owner)
+			}
+		}
+		n.tenantCRSs = map[*node]empty{}
+	}
+	o.clusterName = name
+	return nil
+}
+
 // setSoftOwnership searches for soft ownership relations such as secrets linked to the cluster by a naming convention (without any explicit OwnerReference).
 func (o *objectGraph) setSoftOwnership() {
 	clusters := o.getClusters()
@@ -0,0 +1,208 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+// ObjectGraph is the graph of the Cluster API objects existing in a management cluster and of their ownership
+// relations, in a form suitable for being serialized to JSON, e.g. for rendering the graph in a UI.
+type ObjectGraph struct {
+	// Nodes of the graph, sorted by namespace, kind and name.
+	Nodes []ObjectGraphNode `json:"nodes"`
+}
+
+// ObjectGraphNode is an object in the ObjectGraph.
+type ObjectGraphNode struct {
+	// Object is a reference to the object.
+	Object corev1.ObjectReference `json:"object"`
+
+	// Owners lists the owners of the object, as defined by its ownerReferences.
+	Owners []ObjectGraphOwner `json:"owners,omitempty"`
+
+	// SoftOwners lists the UIDs of the objects owning this object without an explicit ownerReference, e.g. the Cluster
+	// owning a Secret via a naming convention.
+	SoftOwners []types.UID `json:"softOwners,omitempty"`
+
+	// Clusters lists the UIDs of the Clusters this object belongs to, directly or via its owners.
+	Clusters []types.UID `json:"clusters,omitempty"`
+
+	// Virtual is true if the object is referenced by an ownerReference, but it does not exist.
+	Virtual bool `json:"virtual,omitempty"`
+
+	// Version is the Kubernetes version of the object, if any, e.g. for Machines or KubeadmControlPlanes.
+	Version string `json:"version,omitempty"`
+
+	// Phase is the phase of the object, if any.
+	Phase string `json:"phase,omitempty"`
+
+	// Ready is the ready status of the object, if any.
+	Ready *bool `json:"ready,omitempty"`
+
+	// Conditions of the object, if any.
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// ObjectGraphOwner is an owner of an ObjectGraphNode.
+type ObjectGraphOwner struct {
+	// UID of the owner.
+	UID types.UID `json:"uid"`
+
+	// Controller is true if the owner is the managing controller of the object.
+	Controller bool `json:"controller,omitempty"`
+}
+
+// ExportObjectGraphOptions defines the options for exporting the ObjectGraph.
+type ExportObjectGraphOptions struct {
+	// Namespace where the objects are read from; if empty, the objects are read from all the namespaces.
+	Namespace string
+
+	// ClusterName limits the graph to the objects belonging to the Cluster with the given name; it requires Namespace
+	// to be set. If empty, the objects of all the Clusters are exported.
+	ClusterName string
+}
+
+// ObjectGraphClient has methods to export the graph of the Cluster API objects existing in a management cluster.
+type ObjectGraphClient interface {
+	// Export returns the graph of the Cluster API objects, including the ownership relations, the versions and the
+	// status of each object.
+	Export(options ExportObjectGraphOptions) (*ObjectGraph, error)
+}
+
+// objectGraphClient implements ObjectGraphClient.
+type objectGraphClient struct {
+	proxy Proxy
+}
+
+// ensure objectGraphClient implements ObjectGraphClient.
+var _ ObjectGraphClient = &objectGraphClient{}
+
+// newObjectGraphClient returns an objectGraphClient.
+func newObjectGraphClient(proxy Proxy) *objectGraphClient {
+	return &objectGraphClient{
+		proxy: proxy,
+	}
+}
+
+func (g *objectGraphClient) Export(options ExportObjectGraphOptions) (*ObjectGraph, error) {
+	if options.ClusterName != "" && options.Namespace == "" {
+		return nil, errors.New("a namespace is required for exporting the objects of a Cluster")
+	}
+
+	objectGraph := newObjectGraph(g.proxy)
+
+	// Gets all the types defined by the CRDs installed by clusterctl plus the ConfigMap/Secret core types.
+	discoveryTypes, err := objectGraph.getDiscoveryTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	// Discovery the object graph for the selected types, including the tenant relations with Clusters.
+	if err := objectGraph.Discovery(options.Namespace, discoveryTypes); err != nil {
+		return nil, err
+	}
+
+	return exportObjectGraph(objectGraph, options.ClusterName)
+}
+
+// exportObjectGraph returns the ObjectGraph for the nodes of an objectGraph, or for the nodes belonging to the
+// Cluster with the given name, if any; links to the nodes not exported are dropped.
+func exportObjectGraph(objectGraph *objectGraph, clusterName string) (*ObjectGraph, error) {
+	exported := map[*node]empty{}
+	for _, n := range objectGraph.getNodes() {
+		if clusterName == "" || belongsToClusterNamed(n, clusterName) {
+			exported[n] = empty{}
+		}
+	}
+	if clusterName != "" && len(exported) == 0 {
+		return nil, errors.Errorf("failed to find Cluster %q", clusterName)
+	}
+
+	ret := &ObjectGraph{
+		Nodes: make([]ObjectGraphNode, 0, len(exported)),
+	}
+	for n := range exported {
+		exportedNode := ObjectGraphNode{
+			Object:     n.identity,
+			Virtual:    n.virtual,
+			Version:    n.status.version,
+			Phase:      n.status.phase,
+			Ready:      n.status.ready,
+			Conditions: n.status.conditions,
+		}
+		for owner, attributes := range n.owners {
+			if _, ok := exported[owner]; !ok {
+				continue
+			}
+			exportedNode.Owners = append(exportedNode.Owners, ObjectGraphOwner{
+				UID:        owner.identity.UID,
+				Controller: attributes.Controller != nil && *attributes.Controller,
+			})
+		}
+		sort.Slice(exportedNode.Owners, func(i, j int) bool {
+			return exportedNode.Owners[i].UID < exportedNode.Owners[j].UID
+		})
+		for owner := range n.softOwners {
+			if _, ok := exported[owner]; ok {
+				exportedNode.SoftOwners = append(exportedNode.SoftOwners, owner.identity.UID)
+			}
+		}
+		sortUIDs(exportedNode.SoftOwners)
+		for cluster := range n.tenantClusters {
+			if _, ok := exported[cluster]; ok {
+				exportedNode.Clusters = append(exportedNode.Clusters, cluster.identity.UID)
+			}
+		}
+		sortUIDs(exportedNode.Clusters)
+		ret.Nodes = append(ret.Nodes, exportedNode)
+	}
+
+	sort.Slice(ret.Nodes, func(i, j int) bool {
+		a, b := ret.Nodes[i].Object, ret.Nodes[j].Object
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return ret, nil
+}
+
+// belongsToClusterNamed returns true if the node belongs to a Cluster with the given name.
+// Nb. the objects of a Cluster live in the namespace of the Cluster, so the namespace is already filtered during discovery.
+func belongsToClusterNamed(n *node, clusterName string) bool {
+	for cluster := range n.tenantClusters {
+		if cluster.identity.Name == clusterName {
+			return true
+		}
+	}
+	return false
+}
+
+func sortUIDs(uids []types.UID) {
+	sort.Slice(uids, func(i, j int) bool {
+		return uids[i] < uids[j]
+	})
+}
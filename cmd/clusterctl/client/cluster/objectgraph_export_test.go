@@ -0,0 +1,171 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+func Test_exportObjectGraph(t *testing.T) {
+	cluster := func(name string) *clusterv1.Cluster {
+		return &clusterv1.Cluster{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "Cluster",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      name,
+				UID:       types.UID(name),
+			},
+			Status: clusterv1.ClusterStatus{
+				Phase: string(clusterv1.ClusterPhaseProvisioned),
+				Conditions: clusterv1.Conditions{
+					{Type: clusterv1.ReadyCondition, Status: corev1.ConditionTrue},
+				},
+			},
+		}
+	}
+	machine := func(name, clusterName string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "Machine",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      name,
+				UID:       types.UID(name),
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: clusterv1.GroupVersion.String(),
+						Kind:       "Cluster",
+						Name:       clusterName,
+						UID:        types.UID(clusterName),
+						Controller: pointer.BoolPtr(true),
+					},
+				},
+			},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: clusterName,
+				Version:     pointer.StringPtr("v1.18.2"),
+			},
+			Status: clusterv1.MachineStatus{
+				Phase: string(clusterv1.MachinePhaseRunning),
+			},
+		}
+	}
+	clusterNode := func(name string) ObjectGraphNode {
+		return ObjectGraphNode{
+			Object: corev1.ObjectReference{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "Cluster",
+				Namespace:  "ns1",
+				Name:       name,
+				UID:        types.UID(name),
+			},
+			Clusters: []types.UID{types.UID(name)},
+			Phase:    string(clusterv1.ClusterPhaseProvisioned),
+			Conditions: clusterv1.Conditions{
+				{Type: clusterv1.ReadyCondition, Status: corev1.ConditionTrue},
+			},
+		}
+	}
+	machineNode := func(name, clusterName string) ObjectGraphNode {
+		return ObjectGraphNode{
+			Object: corev1.ObjectReference{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "Machine",
+				Namespace:  "ns1",
+				Name:       name,
+				UID:        types.UID(name),
+			},
+			Owners:   []ObjectGraphOwner{{UID: types.UID(clusterName), Controller: true}},
+			Clusters: []types.UID{types.UID(clusterName)},
+			Version:  "v1.18.2",
+			Phase:    string(clusterv1.MachinePhaseRunning),
+		}
+	}
+
+	objs := []runtime.Object{
+		cluster("cluster1"),
+		machine("machine1", "cluster1"),
+		cluster("cluster2"),
+		machine("machine2", "cluster2"),
+	}
+
+	tests := []struct {
+		name        string
+		clusterName string
+		want        *ObjectGraph
+		wantErr     bool
+	}{
+		{
+			name: "exports all the objects",
+			want: &ObjectGraph{
+				Nodes: []ObjectGraphNode{
+					clusterNode("cluster1"),
+					clusterNode("cluster2"),
+					machineNode("machine1", "cluster1"),
+					machineNode("machine2", "cluster2"),
+				},
+			},
+		},
+		{
+			name:        "exports the objects of a Cluster",
+			clusterName: "cluster2",
+			want: &ObjectGraph{
+				Nodes: []ObjectGraphNode{
+					clusterNode("cluster2"),
+					machineNode("machine2", "cluster2"),
+				},
+			},
+		},
+		{
+			name:        "fails if the Cluster does not exist",
+			clusterName: "cluster3",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			graph, err := getDetachedObjectGraphWihObjs(objs)
+			g.Expect(err).NotTo(HaveOccurred())
+			graph.setSoftOwnership()
+			graph.setClusterTenants()
+
+			got, err := exportObjectGraph(graph, tt.clusterName)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
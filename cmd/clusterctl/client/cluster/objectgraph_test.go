@@ -1478,6 +1478,109 @@ func Test_objectGraph_setClusterTenants(t *testing.T) {
 	}
 }
 
+func Test_objectGraph_filterCluster(t *testing.T) {
+	tests := []struct {
+		name        string
+		objs        func() []runtime.Object
+		clusterName string
+		wantNodes   []string
+		wantErr     bool
+	}{
+		{
+			name: "Keeps only the objects belonging to the selected cluster",
+			objs: func() []runtime.Object {
+				objs := []runtime.Object{}
+				objs = append(objs, test.NewFakeCluster("ns1", "foo").Objs()...)
+				objs = append(objs, test.NewFakeCluster("ns1", "bar").Objs()...)
+				return objs
+			},
+			clusterName: "foo",
+			wantNodes: []string{
+				"cluster.x-k8s.io/v1alpha3, Kind=Cluster, ns1/foo",
+				"infrastructure.cluster.x-k8s.io/v1alpha3, Kind=GenericInfrastructureCluster, ns1/foo",
+				"/v1, Kind=Secret, ns1/foo-ca",
+				"/v1, Kind=Secret, ns1/foo-kubeconfig",
+			},
+		},
+		{
+			name: "Keeps the ClusterResourceSetBinding of the cluster but not the ClusterResourceSet",
+			objs: func() []runtime.Object {
+				objs := []runtime.Object{}
+				objs = append(objs, test.NewFakeCluster("ns1", "foo").Objs()...)
+				objs = append(objs, test.NewFakeClusterResourceSet("ns1", "crs1").
+					WithSecret("resource-s1").
+					ApplyToCluster(test.SelectClusterObj(objs, "ns1", "foo")).
+					Objs()...)
+				return objs
+			},
+			clusterName: "foo",
+			wantNodes: []string{
+				"cluster.x-k8s.io/v1alpha3, Kind=Cluster, ns1/foo",
+				"infrastructure.cluster.x-k8s.io/v1alpha3, Kind=GenericInfrastructureCluster, ns1/foo",
+				"/v1, Kind=Secret, ns1/foo-ca",
+				"/v1, Kind=Secret, ns1/foo-kubeconfig",
+				"addons.cluster.x-k8s.io/v1alpha3, Kind=ClusterResourceSetBinding, ns1/foo",
+			},
+		},
+		{
+			name: "Fails if an object is shared with other clusters",
+			objs: func() []runtime.Object {
+				sharedInfrastructureTemplate := test.NewFakeInfrastructureTemplate("shared")
+				objs := []runtime.Object{
+					sharedInfrastructureTemplate,
+				}
+				objs = append(objs, test.NewFakeCluster("ns1", "foo").
+					WithMachineSets(
+						test.NewFakeMachineSet("foo-ms1").WithInfrastructureTemplate(sharedInfrastructureTemplate),
+					).Objs()...)
+				objs = append(objs, test.NewFakeCluster("ns1", "bar").
+					WithMachineSets(
+						test.NewFakeMachineSet("bar-ms1").WithInfrastructureTemplate(sharedInfrastructureTemplate),
+					).Objs()...)
+				return objs
+			},
+			clusterName: "foo",
+			wantErr:     true,
+		},
+		{
+			name: "Fails if the cluster does not exist",
+			objs: func() []runtime.Object {
+				return test.NewFakeCluster("ns1", "foo").Objs()
+			},
+			clusterName: "bar",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gb, err := getDetachedObjectGraphWihObjs(tt.objs())
+			g.Expect(err).NotTo(HaveOccurred())
+
+			gb.setSoftOwnership()
+			gb.setClusterTenants()
+			gb.setCRSTenants()
+
+			err = gb.filterCluster("ns1", tt.clusterName)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			gotNodes := []string{}
+			for _, node := range gb.getNodesWithTenants() {
+				gotNodes = append(gotNodes, string(node.identity.UID))
+				for owner := range node.owners {
+					g.Expect(gb.uidToNode).To(HaveKey(owner.identity.UID))
+				}
+			}
+			g.Expect(gotNodes).To(ConsistOf(tt.wantNodes))
+		})
+	}
+}
+
 func Test_objectGraph_setCRSTenants(t *testing.T) {
 	type fields struct {
 		objs []runtime.Object
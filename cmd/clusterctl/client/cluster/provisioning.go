@@ -0,0 +1,191 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	kcfg "sigs.k8s.io/cluster-api/util/kubeconfig"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultClusterProvisioningTimeout is the default time to wait for a Cluster to be provisioned.
+	DefaultClusterProvisioningTimeout = 30 * time.Minute
+
+	waitClusterProvisioningInterval = 10 * time.Second
+)
+
+// ClusterProvisioningClient has methods to provision workload clusters from the objects of a cluster template.
+type ClusterProvisioningClient interface {
+	// Apply applies the objects to the management cluster using server-side apply, so the objects are created if they
+	// do not exist, and updated otherwise; fields changed by other field managers are reported as conflicts.
+	// Apply returns the references to the applied objects.
+	Apply(objs []unstructured.Unstructured) ([]corev1.ObjectReference, error)
+
+	// WaitForProvisioned waits for the Cluster to reach the Provisioned phase; an error is returned if the Cluster
+	// reports a failure, if it is being deleted, or if it is not provisioned within the timeout.
+	// If timeout is zero, DefaultClusterProvisioningTimeout is used.
+	WaitForProvisioned(namespace, name string, timeout time.Duration) error
+
+	// WaitForControlPlaneInitialized waits for the control plane of the Cluster to be initialized, i.e. for the
+	// API server of the workload cluster to be reachable; an error is returned if the Cluster reports a failure,
+	// if it is being deleted, or if its control plane is not initialized within the timeout.
+	// If timeout is zero, DefaultClusterProvisioningTimeout is used.
+	WaitForControlPlaneInitialized(namespace, name string, timeout time.Duration) error
+
+	// GetKubeconfig returns the kubeconfig for accessing the workload cluster, as generated by Cluster API.
+	GetKubeconfig(namespace, name string) ([]byte, error)
+}
+
+// clusterProvisioningClient implements ClusterProvisioningClient.
+type clusterProvisioningClient struct {
+	proxy               Proxy
+	pollImmediateWaiter PollImmediateWaiter
+}
+
+// ensure clusterProvisioningClient implements ClusterProvisioningClient.
+var _ ClusterProvisioningClient = &clusterProvisioningClient{}
+
+// newClusterProvisioningClient returns a clusterProvisioningClient.
+func newClusterProvisioningClient(proxy Proxy, pollImmediateWaiter PollImmediateWaiter) *clusterProvisioningClient {
+	return &clusterProvisioningClient{
+		proxy:               proxy,
+		pollImmediateWaiter: pollImmediateWaiter,
+	}
+}
+
+func (p *clusterProvisioningClient) Apply(objs []unstructured.Unstructured) ([]corev1.ObjectReference, error) {
+	log := logf.Log
+
+	c, err := p.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]corev1.ObjectReference, 0, len(objs))
+	for i := range objs {
+		obj := objs[i].DeepCopy()
+		obj.SetResourceVersion("")
+		obj.SetManagedFields(nil)
+
+		log.V(5).Info("Applying", logf.UnstructuredToValues(*obj)...)
+		if err := c.Patch(ctx, obj, client.Apply, client.FieldOwner(FieldManager)); err != nil {
+			if apierrors.IsConflict(err) {
+				return refs, errors.Wrapf(err, "failed to apply %s %s/%s: the object has fields managed by other field managers", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+			}
+			return refs, errors.Wrapf(err, "failed to apply %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		}
+
+		refs = append(refs, corev1.ObjectReference{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+			UID:        obj.GetUID(),
+		})
+	}
+	return refs, nil
+}
+
+func (p *clusterProvisioningClient) WaitForProvisioned(namespace, name string, timeout time.Duration) error {
+	log := logf.Log
+	log.Info("Waiting for the Cluster to be provisioned", "Cluster", name, "Namespace", namespace)
+	return p.waitForCluster(namespace, name, fmt.Sprintf("the Cluster %s/%s to be provisioned", namespace, name), timeout, func(cluster *clusterv1.Cluster) bool {
+		return clusterv1.ClusterPhase(cluster.Status.Phase) == clusterv1.ClusterPhaseProvisioned
+	})
+}
+
+func (p *clusterProvisioningClient) WaitForControlPlaneInitialized(namespace, name string, timeout time.Duration) error {
+	log := logf.Log
+	log.Info("Waiting for the control plane of the Cluster to be initialized", "Cluster", name, "Namespace", namespace)
+	return p.waitForCluster(namespace, name, fmt.Sprintf("the control plane of the Cluster %s/%s to be initialized", namespace, name), timeout, func(cluster *clusterv1.Cluster) bool {
+		return cluster.Status.ControlPlaneInitialized
+	})
+}
+
+// waitForCluster waits for the Cluster to satisfy the given condition, failing fast if the Cluster reports a failure
+// or if it is being deleted; waitingFor describes the condition in the error returned on timeout.
+func (p *clusterProvisioningClient) waitForCluster(namespace, name, waitingFor string, timeout time.Duration, condition func(*clusterv1.Cluster) bool) error {
+	c, err := p.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	if timeout == 0 {
+		timeout = DefaultClusterProvisioningTimeout
+	}
+
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+	var failure error
+	if err := p.pollImmediateWaiter(waitClusterProvisioningInterval, timeout, func() (bool, error) {
+		cluster := &clusterv1.Cluster{}
+		if err := c.Get(ctx, key, cluster); err != nil {
+			return false, nil
+		}
+		switch {
+		case cluster.Status.FailureReason != nil || cluster.Status.FailureMessage != nil:
+			failure = errors.Errorf("the Cluster %s/%s failed to provision: %s", namespace, name, clusterFailure(cluster))
+			return false, failure
+		case !cluster.DeletionTimestamp.IsZero():
+			failure = errors.Errorf("the Cluster %s/%s is being deleted", namespace, name)
+			return false, failure
+		}
+		return condition(cluster), nil
+	}); err != nil {
+		if failure != nil {
+			return failure
+		}
+		return errors.Wrapf(err, "failed to wait for %s", waitingFor)
+	}
+	return nil
+}
+
+func (p *clusterProvisioningClient) GetKubeconfig(namespace, name string) ([]byte, error) {
+	c, err := p.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := kcfg.FromSecret(ctx, c, client.ObjectKey{Namespace: namespace, Name: name})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the kubeconfig for the Cluster %s/%s", namespace, name)
+	}
+	return data, nil
+}
+
+// clusterFailure returns a description of the failure reported by the Cluster.
+func clusterFailure(cluster *clusterv1.Cluster) string {
+	var reason, message string
+	if cluster.Status.FailureReason != nil {
+		reason = string(*cluster.Status.FailureReason)
+	}
+	if cluster.Status.FailureMessage != nil {
+		message = *cluster.Status.FailureMessage
+	}
+	if reason != "" && message != "" {
+		return reason + ": " + message
+	}
+	return reason + message
+}
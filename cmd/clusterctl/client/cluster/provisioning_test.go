@@ -0,0 +1,240 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_clusterProvisioningClient_Apply(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": clusterv1.GroupVersion.String(),
+		"kind":       "Cluster",
+		"metadata": map[string]interface{}{
+			"namespace": "ns1",
+			"name":      "cluster1",
+		},
+	}}
+	configMap := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"namespace": "ns1",
+			"name":      "cm1",
+		},
+		"data": map[string]interface{}{
+			"foo": "bar",
+		},
+	}}
+
+	proxy := test.NewFakeProxy()
+	p := newClusterProvisioningClient(proxy, fakePollImmediateWaiter)
+
+	refs, err := p.Apply([]unstructured.Unstructured{cluster, configMap})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(refs).To(HaveLen(2))
+	g.Expect(refs[0]).To(Equal(corev1.ObjectReference{
+		APIVersion: clusterv1.GroupVersion.String(),
+		Kind:       "Cluster",
+		Namespace:  "ns1",
+		Name:       "cluster1",
+		UID:        refs[0].UID,
+	}))
+	g.Expect(refs[1].Kind).To(Equal("ConfigMap"))
+
+	c, err := proxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "cluster1"}, &clusterv1.Cluster{})).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "cm1"}, &corev1.ConfigMap{})).To(Succeed())
+
+	// Applying the same objects again succeeds.
+	_, err = p.Apply([]unstructured.Unstructured{cluster, configMap})
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func Test_clusterProvisioningClient_WaitForProvisioned(t *testing.T) {
+	cluster := func(phase clusterv1.ClusterPhase, failed bool) *clusterv1.Cluster {
+		c := &clusterv1.Cluster{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "Cluster",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      "cluster1",
+			},
+		}
+		c.Status.SetTypedPhase(phase)
+		if failed {
+			reason := capierrors.InvalidConfigurationClusterError
+			c.Status.FailureReason = &reason
+			c.Status.FailureMessage = pointer.StringPtr("invalid configuration")
+		}
+		return c
+	}
+
+	// Nb. the condition is checked once, and the wait times out if it is not met.
+	pollImmediateWaiter := func(interval, timeout time.Duration, condition wait.ConditionFunc) error {
+		done, err := condition()
+		if err != nil {
+			return err
+		}
+		if !done {
+			return errors.New("timed out")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name    string
+		objs    []runtime.Object
+		wantErr bool
+	}{
+		{
+			name: "returns when the Cluster is provisioned",
+			objs: []runtime.Object{cluster(clusterv1.ClusterPhaseProvisioned, false)},
+		},
+		{
+			name:    "fails if the Cluster is not provisioned within the timeout",
+			objs:    []runtime.Object{cluster(clusterv1.ClusterPhaseProvisioning, false)},
+			wantErr: true,
+		},
+		{
+			name:    "fails if the Cluster reports a failure",
+			objs:    []runtime.Object{cluster(clusterv1.ClusterPhaseFailed, true)},
+			wantErr: true,
+		},
+		{
+			name:    "fails if the Cluster does not exist within the timeout",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			p := newClusterProvisioningClient(test.NewFakeProxy().WithObjs(tt.objs...), pollImmediateWaiter)
+			err := p.WaitForProvisioned("ns1", "cluster1", time.Minute)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
+
+func Test_clusterProvisioningClient_WaitForControlPlaneInitialized(t *testing.T) {
+	cluster := func(initialized bool) *clusterv1.Cluster {
+		c := &clusterv1.Cluster{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "Cluster",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      "cluster1",
+			},
+		}
+		c.Status.ControlPlaneInitialized = initialized
+		return c
+	}
+
+	// Nb. the condition is checked once, and the wait times out if it is not met.
+	pollImmediateWaiter := func(interval, timeout time.Duration, condition wait.ConditionFunc) error {
+		done, err := condition()
+		if err != nil {
+			return err
+		}
+		if !done {
+			return errors.New("timed out")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name    string
+		objs    []runtime.Object
+		wantErr bool
+	}{
+		{
+			name: "returns when the control plane is initialized",
+			objs: []runtime.Object{cluster(true)},
+		},
+		{
+			name:    "fails if the control plane is not initialized within the timeout",
+			objs:    []runtime.Object{cluster(false)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			p := newClusterProvisioningClient(test.NewFakeProxy().WithObjs(tt.objs...), pollImmediateWaiter)
+			err := p.WaitForControlPlaneInitialized("ns1", "cluster1", time.Minute)
+			if tt.wantErr {
+				g.Expect(err).To(MatchError(ContainSubstring("failed to wait for the control plane of the Cluster ns1/cluster1 to be initialized")))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
+
+func Test_clusterProvisioningClient_GetKubeconfig(t *testing.T) {
+	g := NewWithT(t)
+
+	kubeconfigSecret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "cluster1-kubeconfig",
+		},
+		Data: map[string][]byte{
+			"value": []byte("kubeconfig"),
+		},
+	}
+
+	p := newClusterProvisioningClient(test.NewFakeProxy().WithObjs(kubeconfigSecret), nil)
+	got, err := p.GetKubeconfig("ns1", "cluster1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal([]byte("kubeconfig")))
+
+	_, err = p.GetKubeconfig("ns1", "cluster2")
+	g.Expect(err).To(HaveOccurred())
+}
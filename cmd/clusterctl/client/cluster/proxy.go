@@ -29,6 +29,8 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/scheme"
 	"sigs.k8s.io/cluster-api/cmd/version"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -76,18 +78,12 @@ func (k *proxy) CurrentNamespace() (string, error) {
 }
 
 func (k *proxy) ValidateKubernetesVersion() error {
-	config, err := k.GetConfig()
+	serverVersion, err := k.GetServerVersion()
 	if err != nil {
 		return err
 	}
 
-	client := discovery.NewDiscoveryClientForConfigOrDie(config)
-	serverVersion, err := client.ServerVersion()
-	if err != nil {
-		return errors.Wrap(err, "failed to retrieve server version")
-	}
-
-	compver, err := utilversion.MustParseGeneric(serverVersion.String()).Compare(minimumKubernetesVersion)
+	compver, err := serverVersion.Compare(minimumKubernetesVersion)
 	if err != nil {
 		return errors.Wrap(err, "failed to parse and compare server version")
 	}
@@ -99,6 +95,25 @@ func (k *proxy) ValidateKubernetesVersion() error {
 	return nil
 }
 
+func (k *proxy) GetServerVersion() (*utilversion.Version, error) {
+	config, err := k.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client := discovery.NewDiscoveryClientForConfigOrDie(config)
+	serverVersion, err := client.ServerVersion()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve server version")
+	}
+
+	v, err := utilversion.ParseGeneric(serverVersion.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse server version %q", serverVersion.String())
+	}
+	return v, nil
+}
+
 // GetConfig returns the config for a kubernetes client.
 func (k *proxy) GetConfig() (*rest.Config, error) {
 	config, err := k.configLoadingRules.Load()
@@ -106,16 +121,21 @@ func (k *proxy) GetConfig() (*rest.Config, error) {
 		return nil, errors.Wrap(err, "failed to load Kubeconfig")
 	}
 
-	configOverrides := &clientcmd.ConfigOverrides{
-		CurrentContext: k.kubeconfig.Context,
-		Timeout:        k.timeout.String(),
-	}
-	restConfig, err := clientcmd.NewDefaultClientConfig(*config, configOverrides).ClientConfig()
-	if err != nil {
-		if strings.HasPrefix(err.Error(), "invalid configuration:") {
-			return nil, errors.New(strings.Replace(err.Error(), "invalid configuration:", "invalid kubeconfig file; clusterctl requires a valid kubeconfig file to connect to the management cluster:", 1))
+	var restConfig *rest.Config
+	if inClusterConfig, ok := k.inClusterConfig(config); ok {
+		restConfig = inClusterConfig
+	} else {
+		configOverrides := &clientcmd.ConfigOverrides{
+			CurrentContext: k.kubeconfig.Context,
+			Timeout:        k.timeout.String(),
+		}
+		restConfig, err = clientcmd.NewDefaultClientConfig(*config, configOverrides).ClientConfig()
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "invalid configuration:") {
+				return nil, messages.Wrap(errors.New(strings.TrimSpace(strings.TrimPrefix(err.Error(), "invalid configuration:"))), messages.InvalidKubeconfig)
+			}
+			return nil, err
 		}
-		return nil, err
 	}
 	restConfig.UserAgent = fmt.Sprintf("clusterctl/%s (%s)", version.Get().GitVersion, version.Get().Platform)
 
@@ -126,6 +146,20 @@ func (k *proxy) GetConfig() (*rest.Config, error) {
 	return restConfig, nil
 }
 
+// inClusterConfig returns the in-cluster config when clusterctl runs in a Pod without a kubeconfig, e.g. when
+// executed in the management cluster in remote execution mode.
+func (k *proxy) inClusterConfig(config *clientcmdapi.Config) (*rest.Config, bool) {
+	if k.kubeconfig.Path != "" || !clientcmdapi.IsConfigEmpty(config) {
+		return nil, false
+	}
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, false
+	}
+	restConfig.Timeout = k.timeout
+	return restConfig, true
+}
+
 func (k *proxy) NewClient() (client.Client, error) {
 	config, err := k.GetConfig()
 	if err != nil {
@@ -143,7 +177,7 @@ func (k *proxy) NewClient() (client.Client, error) {
 		}
 		return nil
 	}); err != nil {
-		return nil, errors.Wrap(err, "failed to connect to the management cluster")
+		return nil, messages.Wrap(err, messages.ManagementClusterUnreachable)
 	}
 
 	return c, nil
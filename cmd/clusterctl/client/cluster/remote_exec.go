@@ -0,0 +1,445 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"io"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/pointer"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RemoteExecLabelName is the label set on the Jobs, and on their Pods, executing clusterctl in the management cluster.
+	RemoteExecLabelName = "clusterctl.cluster.x-k8s.io/remote-exec"
+
+	// DefaultRemoteExecNamespace is the default namespace for the Jobs executing clusterctl in the management cluster.
+	DefaultRemoteExecNamespace = "clusterctl-system"
+
+	// DefaultRemoteExecTimeout is the default maximum time the Jobs executing clusterctl are allowed to run.
+	DefaultRemoteExecTimeout = 30 * time.Minute
+
+	remoteExecContainerName   = "clusterctl"
+	remoteExecConfigMountPath = "/etc/clusterctl"
+	remoteExecConfigKey       = "clusterctl.yaml"
+	remoteExecPollInterval    = 5 * time.Second
+	remoteExecDeleteTimeout   = 2 * time.Minute
+)
+
+// RemoteExecOptions defines the options for executing clusterctl in the management cluster.
+type RemoteExecOptions struct {
+	// Image is the container image providing the clusterctl binary as entrypoint.
+	Image string
+
+	// Namespace where the Job executing clusterctl is created; it defaults to DefaultRemoteExecNamespace.
+	// If a ClusterRole is defined, the namespace is created if it does not exist, and it is not deleted once the Job
+	// completes; otherwise it must exist, as the ServiceAccount does.
+	Namespace string
+
+	// ServiceAccount is the name of an existing ServiceAccount, in the namespace of the Job, the Job runs with; it must
+	// be granted the permissions required by the clusterctl args. Either ServiceAccount or ClusterRole is required.
+	ServiceAccount string
+
+	// ClusterRole is the name of an existing ClusterRole granted to the Job, through a ServiceAccount and a
+	// ClusterRoleBinding created for the Job and deleted once the Job completes. Either ServiceAccount or
+	// ClusterRole is required.
+	ClusterRole string
+
+	// Args are the clusterctl args, e.g. init --infrastructure aws.
+	Args []string
+
+	// Config is the content of the clusterctl configuration file to be used by the Job, if any.
+	Config []byte
+
+	// Timeout defines the maximum time the Job is allowed to run; it defaults to DefaultRemoteExecTimeout.
+	Timeout time.Duration
+
+	// Output is where the logs of the Job are written once it completes.
+	Output io.Writer
+}
+
+// RemoteExecClient has methods to execute clusterctl in the management cluster, for the environments where the
+// management cluster can't be reached from the operator workstation for long running operations.
+type RemoteExecClient interface {
+	// Run executes clusterctl in a Job in the management cluster, waits for the Job to complete without keeping
+	// a connection open, writes the logs of the Job to the output, and then deletes the Job.
+	Run(options RemoteExecOptions) error
+}
+
+// podLogsGetter returns the logs of a Pod.
+type podLogsGetter func(namespace, name string) ([]byte, error)
+
+// remoteExecClient implements RemoteExecClient.
+type remoteExecClient struct {
+	proxy               Proxy
+	pollImmediateWaiter PollImmediateWaiter
+	getPodLogs          podLogsGetter
+}
+
+// ensure remoteExecClient implements RemoteExecClient.
+var _ RemoteExecClient = &remoteExecClient{}
+
+// newRemoteExecClient returns a remoteExecClient.
+func newRemoteExecClient(proxy Proxy, pollImmediateWaiter PollImmediateWaiter) *remoteExecClient {
+	return &remoteExecClient{
+		proxy:               proxy,
+		pollImmediateWaiter: pollImmediateWaiter,
+		getPodLogs: func(namespace, name string) ([]byte, error) {
+			config, err := proxy.GetConfig()
+			if err != nil {
+				return nil, err
+			}
+			cs, err := kubernetes.NewForConfig(config)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create the client-go client")
+			}
+			return cs.CoreV1().Pods(namespace).GetLogs(name, &corev1.PodLogOptions{Container: remoteExecContainerName}).DoRaw()
+		},
+	}
+}
+
+func (r *remoteExecClient) Run(options RemoteExecOptions) (reterr error) {
+	log := logf.Log
+
+	if options.Image == "" {
+		return errors.New("the image providing clusterctl is required for executing clusterctl in the management cluster")
+	}
+	if len(options.Args) == 0 {
+		return errors.New("the clusterctl args are required for executing clusterctl in the management cluster")
+	}
+	if (options.ServiceAccount == "") == (options.ClusterRole == "") {
+		return errors.New("either a ServiceAccount or a ClusterRole is required for executing clusterctl in the management cluster")
+	}
+	if options.Namespace == "" {
+		options.Namespace = DefaultRemoteExecNamespace
+	}
+	if options.Timeout == 0 {
+		options.Timeout = DefaultRemoteExecTimeout
+	}
+
+	c, err := r.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	name := "clusterctl-" + util.RandomString(6)
+	serviceAccount, created, err := r.getServiceAccount(c, name, options)
+	if err != nil {
+		return err
+	}
+	// The objects created for the Job, i.e. the permissions granted to it and the Secret with the clusterctl
+	// configuration, are deleted as soon as the Job is gone, or if the Job can't be created; they are left only
+	// if the Job could still be running.
+	jobGone := true
+	defer func() {
+		if !jobGone {
+			return
+		}
+		if err := r.deleteObjects(c, created); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	if secret := remoteExecConfigSecret(name, options); secret != nil {
+		if err := c.Create(ctx, secret); err != nil {
+			return errors.Wrapf(err, "failed to create the Secret %s/%s with the clusterctl configuration", secret.Namespace, secret.Name)
+		}
+		created = append(created, secret)
+	}
+
+	job := remoteExecJob(name, serviceAccount, options)
+	log.Info("Executing clusterctl in the management cluster", "Job", name, "Namespace", options.Namespace, "ServiceAccount", serviceAccount)
+	if err := c.Create(ctx, job); err != nil {
+		return errors.Wrapf(err, "failed to create the Job %s/%s", job.Namespace, job.Name)
+	}
+
+	// Wait for the Job to complete with periodic short requests, so no connection is kept open for the whole operation.
+	err = r.pollImmediateWaiter(remoteExecPollInterval, options.Timeout+time.Minute, func() (bool, error) {
+		if err := c.Get(ctx, client.ObjectKey{Namespace: job.Namespace, Name: job.Name}, job); err != nil {
+			log.V(5).Info("Failed to get the Job, retrying", "Job", job.Name, "Error", err.Error())
+			return false, nil
+		}
+		return job.Status.Succeeded > 0 || job.Status.Failed > 0, nil
+	})
+	if err != nil {
+		// The Job may still be running, so it is deleted, and its Pods terminated, before revoking its permissions.
+		if deleteErr := r.deleteJob(c, job, true); deleteErr != nil {
+			jobGone = false
+			return kerrors.NewAggregate([]error{
+				errors.Wrapf(err, "failed to wait for the Job %s/%s to complete", job.Namespace, job.Name),
+				errors.Wrapf(deleteErr, "the Job and the objects created for it, labeled %s=%s, are left in the management cluster", RemoteExecLabelName, name),
+			})
+		}
+		return errors.Wrapf(err, "failed to wait for the Job %s/%s to complete; the Job has been deleted", job.Namespace, job.Name)
+	}
+
+	if err := r.writeLogs(c, job, options.Output); err != nil {
+		return errors.Wrapf(err, "failed to get the logs of the Job %s/%s; the Job is left in the management cluster for inspection", job.Namespace, job.Name)
+	}
+
+	if err := r.deleteJob(c, job, false); err != nil {
+		return err
+	}
+
+	if job.Status.Succeeded == 0 {
+		return errors.Errorf("the execution of clusterctl in the management cluster failed, see the Job logs above")
+	}
+	return nil
+}
+
+// getServiceAccount returns the name of the ServiceAccount the Job runs with. If a ClusterRole is defined, it creates
+// the namespace of the Job if it does not exist, a ServiceAccount for the Job and a ClusterRoleBinding granting it the
+// ClusterRole, and returns the ServiceAccount and the ClusterRoleBinding so they can be deleted once the Job is gone;
+// nothing is created otherwise, because an existing ServiceAccount implies an existing namespace.
+func (r *remoteExecClient) getServiceAccount(c client.Client, name string, options RemoteExecOptions) (string, []runtimeObject, error) {
+	if options.ServiceAccount != "" {
+		if err := c.Get(ctx, client.ObjectKey{Namespace: options.Namespace, Name: options.ServiceAccount}, &corev1.ServiceAccount{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return "", nil, errors.Errorf("the ServiceAccount %s/%s for executing clusterctl in the management cluster does not exist", options.Namespace, options.ServiceAccount)
+			}
+			return "", nil, errors.Wrapf(err, "failed to get the ServiceAccount %s/%s", options.Namespace, options.ServiceAccount)
+		}
+		return options.ServiceAccount, nil, nil
+	}
+
+	if err := c.Get(ctx, client.ObjectKey{Name: options.ClusterRole}, &rbacv1.ClusterRole{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil, errors.Errorf("the ClusterRole %s for executing clusterctl in the management cluster does not exist", options.ClusterRole)
+		}
+		return "", nil, errors.Wrapf(err, "failed to get the ClusterRole %s", options.ClusterRole)
+	}
+
+	if err := r.ensureNamespace(c, options.Namespace); err != nil {
+		return "", nil, err
+	}
+
+	labels := map[string]string{clusterctlv1.ClusterctlLabelName: "", RemoteExecLabelName: name}
+	objs := []runtimeObject{
+		&corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: options.Namespace,
+				Name:      name,
+				Labels:    labels,
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   options.Namespace + "-" + name,
+				Labels: labels,
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     options.ClusterRole,
+			},
+			Subjects: []rbacv1.Subject{
+				{
+					Kind:      rbacv1.ServiceAccountKind,
+					Namespace: options.Namespace,
+					Name:      name,
+				},
+			},
+		},
+	}
+
+	var created []runtimeObject
+	for _, o := range objs {
+		if err := c.Create(ctx, o); err != nil {
+			return "", nil, kerrors.NewAggregate([]error{
+				errors.Wrapf(err, "failed to create the %T %s for executing clusterctl in the management cluster", o, o.GetName()),
+				r.deleteObjects(c, created),
+			})
+		}
+		created = append(created, o)
+	}
+	return name, created, nil
+}
+
+// ensureNamespace creates the namespace of the Job if it does not exist. The namespace is not deleted once the Job
+// completes, because other Jobs executing clusterctl may use it.
+func (r *remoteExecClient) ensureNamespace(c client.Client, namespace string) error {
+	err := c.Get(ctx, client.ObjectKey{Name: namespace}, &corev1.Namespace{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get the namespace %s", namespace)
+	}
+
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   namespace,
+			Labels: map[string]string{clusterctlv1.ClusterctlLabelName: ""},
+		},
+	}
+	if err := c.Create(ctx, ns); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create the namespace %s for executing clusterctl in the management cluster", namespace)
+	}
+	return nil
+}
+
+// deleteObjects deletes the objects created for a Job, i.e. the ServiceAccount, the ClusterRoleBinding and the Secret
+// with the clusterctl configuration.
+func (r *remoteExecClient) deleteObjects(c client.Client, objs []runtimeObject) error {
+	var errs []error
+	for _, o := range objs {
+		if err := c.Delete(ctx, o); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, errors.Wrapf(err, "failed to delete the %T %s", o, o.GetName()))
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// runtimeObject is an object that can be created with a controller runtime client.
+type runtimeObject interface {
+	metav1.Object
+	runtime.Object
+}
+
+// writeLogs writes the logs of the Pods of a Job to the output.
+func (r *remoteExecClient) writeLogs(c client.Client, job *batchv1.Job, output io.Writer) error {
+	if output == nil {
+		return nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{RemoteExecLabelName: job.Name}); err != nil {
+		return errors.Wrap(err, "failed to list the Pods of the Job")
+	}
+	for _, pod := range pods.Items {
+		logs, err := r.getPodLogs(pod.Namespace, pod.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := output.Write(logs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteJob deletes a Job with its Pods. If wait is true, the Pods of the Job may still be running, so the Pods are
+// deleted first and deleteJob waits for the Job to be gone.
+func (r *remoteExecClient) deleteJob(c client.Client, job *batchv1.Job, wait bool) error {
+	propagation := metav1.DeletePropagationBackground
+	if wait {
+		propagation = metav1.DeletePropagationForeground
+	}
+	if err := c.Delete(ctx, job, client.PropagationPolicy(propagation)); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete the Job %s/%s", job.Namespace, job.Name)
+	}
+	if !wait {
+		return nil
+	}
+
+	err := r.pollImmediateWaiter(remoteExecPollInterval, remoteExecDeleteTimeout, func() (bool, error) {
+		if err := c.Get(ctx, client.ObjectKey{Namespace: job.Namespace, Name: job.Name}, &batchv1.Job{}); err != nil {
+			return apierrors.IsNotFound(err), nil
+		}
+		return false, nil
+	})
+	return errors.Wrapf(err, "failed to wait for the Job %s/%s to be deleted", job.Namespace, job.Name)
+}
+
+// remoteExecConfigSecret returns the Secret with the clusterctl configuration for a Job, if any.
+func remoteExecConfigSecret(name string, options RemoteExecOptions) *corev1.Secret {
+	if len(options.Config) == 0 {
+		return nil
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: options.Namespace,
+			Name:      name,
+			Labels:    map[string]string{clusterctlv1.ClusterctlLabelName: "", RemoteExecLabelName: name},
+		},
+		Data: map[string][]byte{
+			remoteExecConfigKey: options.Config,
+		},
+	}
+}
+
+// remoteExecJob returns the Job executing clusterctl with the given options.
+func remoteExecJob(name, serviceAccount string, options RemoteExecOptions) *batchv1.Job {
+	labels := map[string]string{clusterctlv1.ClusterctlLabelName: "", RemoteExecLabelName: name}
+
+	args := options.Args
+	podSpec := corev1.PodSpec{
+		ServiceAccountName: serviceAccount,
+		RestartPolicy:      corev1.RestartPolicyNever,
+		Containers: []corev1.Container{
+			{
+				Name:  remoteExecContainerName,
+				Image: options.Image,
+			},
+		},
+	}
+	if len(options.Config) > 0 {
+		args = append(append([]string{}, args...), "--config", path.Join(remoteExecConfigMountPath, remoteExecConfigKey))
+		podSpec.Volumes = []corev1.Volume{
+			{
+				Name: "config",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: name},
+				},
+			},
+		}
+		podSpec.Containers[0].VolumeMounts = []corev1.VolumeMount{
+			{
+				Name:      "config",
+				MountPath: remoteExecConfigMountPath,
+				ReadOnly:  true,
+			},
+		}
+	}
+	podSpec.Containers[0].Args = args
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: options.Namespace,
+			Name:      name,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			// clusterctl operations are not retried automatically, so the user can check what happened in case of failures.
+			BackoffLimit: pointer.Int32Ptr(0),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+	if options.Timeout > 0 {
+		job.Spec.ActiveDeadlineSeconds = pointer.Int64Ptr(int64(options.Timeout.Seconds()))
+	}
+	return job
+}
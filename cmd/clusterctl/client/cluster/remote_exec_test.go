@@ -0,0 +1,303 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_remoteExecClient_Run(t *testing.T) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: DefaultRemoteExecNamespace}}
+	serviceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: DefaultRemoteExecNamespace, Name: "clusterctl"}}
+	clusterRole := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "clusterctl-init"}}
+
+	tests := []struct {
+		name               string
+		objs               []runtime.Object
+		options            RemoteExecOptions
+		jobFailed          bool
+		jobTimeout         bool
+		wantArgs           []string
+		wantServiceAccount string
+		wantClusterRole    string
+		wantSecret         bool
+		wantNamespace      bool
+		wantErr            bool
+	}{
+		{
+			name: "executes clusterctl in a Job with an existing ServiceAccount",
+			objs: []runtime.Object{namespace, serviceAccount},
+			options: RemoteExecOptions{
+				Image:          "clusterctl:dev",
+				ServiceAccount: "clusterctl",
+				Args:           []string{"init", "--infrastructure", "aws"},
+			},
+			wantArgs:           []string{"init", "--infrastructure", "aws"},
+			wantServiceAccount: "clusterctl",
+		},
+		{
+			name: "executes clusterctl in a Job granted a ClusterRole",
+			objs: []runtime.Object{namespace, clusterRole},
+			options: RemoteExecOptions{
+				Image:       "clusterctl:dev",
+				ClusterRole: "clusterctl-init",
+				Args:        []string{"init", "--infrastructure", "aws"},
+			},
+			wantArgs:        []string{"init", "--infrastructure", "aws"},
+			wantClusterRole: "clusterctl-init",
+		},
+		{
+			name: "copies the clusterctl configuration to the Job",
+			objs: []runtime.Object{namespace, serviceAccount},
+			options: RemoteExecOptions{
+				Image:          "clusterctl:dev",
+				ServiceAccount: "clusterctl",
+				Args:           []string{"init"},
+				Config:         []byte("providers: []"),
+			},
+			wantArgs:           []string{"init", "--config", "/etc/clusterctl/clusterctl.yaml"},
+			wantServiceAccount: "clusterctl",
+			wantSecret:         true,
+		},
+		{
+			name: "fails if the Job fails, revoking the ClusterRole",
+			objs: []runtime.Object{namespace, clusterRole},
+			options: RemoteExecOptions{
+				Image:       "clusterctl:dev",
+				ClusterRole: "clusterctl-init",
+				Args:        []string{"init"},
+			},
+			jobFailed:       true,
+			wantArgs:        []string{"init"},
+			wantClusterRole: "clusterctl-init",
+			wantErr:         true,
+		},
+		{
+			name: "deletes the Job if waiting for it fails, revoking the ClusterRole and deleting the configuration",
+			objs: []runtime.Object{namespace, clusterRole},
+			options: RemoteExecOptions{
+				Image:       "clusterctl:dev",
+				ClusterRole: "clusterctl-init",
+				Args:        []string{"init"},
+				Config:      []byte("providers: []"),
+			},
+			jobTimeout: true,
+			wantErr:    true,
+		},
+		{
+			name: "creates the namespace if it does not exist",
+			objs: []runtime.Object{clusterRole},
+			options: RemoteExecOptions{
+				Image:       "clusterctl:dev",
+				ClusterRole: "clusterctl-init",
+				Args:        []string{"init", "--infrastructure", "aws"},
+			},
+			wantArgs:        []string{"init", "--infrastructure", "aws"},
+			wantClusterRole: "clusterctl-init",
+			wantNamespace:   true,
+		},
+		{
+			name: "fails without image",
+			objs: []runtime.Object{namespace, serviceAccount},
+			options: RemoteExecOptions{
+				ServiceAccount: "clusterctl",
+				Args:           []string{"init"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails without ServiceAccount and ClusterRole",
+			objs: []runtime.Object{namespace, serviceAccount, clusterRole},
+			options: RemoteExecOptions{
+				Image: "clusterctl:dev",
+				Args:  []string{"init"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails with both ServiceAccount and ClusterRole",
+			objs: []runtime.Object{namespace, serviceAccount, clusterRole},
+			options: RemoteExecOptions{
+				Image:          "clusterctl:dev",
+				ServiceAccount: "clusterctl",
+				ClusterRole:    "clusterctl-init",
+				Args:           []string{"init"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if the ServiceAccount does not exist",
+			objs: []runtime.Object{namespace},
+			options: RemoteExecOptions{
+				Image:          "clusterctl:dev",
+				ServiceAccount: "clusterctl",
+				Args:           []string{"init"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if the ClusterRole does not exist",
+			objs: []runtime.Object{namespace},
+			options: RemoteExecOptions{
+				Image:       "clusterctl:dev",
+				ClusterRole: "clusterctl-init",
+				Args:        []string{"init"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			c, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			// Emulates the Job controller, completing the Job and creating its Pod.
+			var gotJob *batchv1.Job
+			var gotSecret bool
+			var gotBindings []rbacv1.ClusterRoleBinding
+			var waits int
+			waiter := func(interval, timeout time.Duration, condition wait.ConditionFunc) error {
+				// After the first wait, clusterctl waits for the Job to be deleted.
+				waits++
+				if waits > 1 {
+					return wait.PollImmediate(time.Millisecond, time.Second, condition)
+				}
+
+				jobs := &batchv1.JobList{}
+				g.Expect(c.List(ctx, jobs)).To(Succeed())
+				g.Expect(jobs.Items).To(HaveLen(1))
+				gotJob = jobs.Items[0].DeepCopy()
+
+				secrets := &corev1.SecretList{}
+				g.Expect(c.List(ctx, secrets, client.MatchingLabels{RemoteExecLabelName: gotJob.Name})).To(Succeed())
+				gotSecret = len(secrets.Items) == 1
+
+				bindings := &rbacv1.ClusterRoleBindingList{}
+				g.Expect(c.List(ctx, bindings)).To(Succeed())
+				gotBindings = bindings.Items
+
+				if tt.jobTimeout {
+					return wait.ErrWaitTimeout
+				}
+
+				job := &jobs.Items[0]
+				if tt.jobFailed {
+					job.Status.Failed = 1
+				} else {
+					job.Status.Succeeded = 1
+				}
+				g.Expect(c.Update(ctx, job)).To(Succeed())
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: job.Namespace,
+						Name:      job.Name + "-abcde",
+						Labels:    map[string]string{RemoteExecLabelName: job.Name},
+					},
+				}
+				g.Expect(c.Create(ctx, pod)).To(Succeed())
+				return wait.PollImmediate(time.Millisecond, time.Second, condition)
+			}
+
+			r := newRemoteExecClient(proxy, waiter)
+			r.getPodLogs = func(namespace, name string) ([]byte, error) {
+				return []byte("logs of " + name), nil
+			}
+			output := &bytes.Buffer{}
+			tt.options.Output = output
+
+			err = r.Run(tt.options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			// Nothing is left in the management cluster but the objects provided by the user, and the namespace of the Job.
+			var wantServiceAccounts, wantNamespaces int
+			for _, o := range tt.objs {
+				switch o.(type) {
+				case *corev1.ServiceAccount:
+					wantServiceAccounts++
+				case *corev1.Namespace:
+					wantNamespaces++
+				}
+			}
+			serviceAccounts := &corev1.ServiceAccountList{}
+			g.Expect(c.List(ctx, serviceAccounts)).To(Succeed())
+			g.Expect(serviceAccounts.Items).To(HaveLen(wantServiceAccounts))
+			namespaces := &corev1.NamespaceList{}
+			g.Expect(c.List(ctx, namespaces)).To(Succeed())
+			if tt.wantNamespace {
+				wantNamespaces++
+			}
+			g.Expect(namespaces.Items).To(HaveLen(wantNamespaces))
+			bindings := &rbacv1.ClusterRoleBindingList{}
+			g.Expect(c.List(ctx, bindings)).To(Succeed())
+			g.Expect(bindings.Items).To(BeEmpty())
+			jobs := &batchv1.JobList{}
+			g.Expect(c.List(ctx, jobs)).To(Succeed())
+			g.Expect(jobs.Items).To(BeEmpty())
+			secrets := &corev1.SecretList{}
+			g.Expect(c.List(ctx, secrets)).To(Succeed())
+			g.Expect(secrets.Items).To(BeEmpty())
+
+			if tt.jobTimeout {
+				g.Expect(gotJob).NotTo(BeNil())
+				g.Expect(gotSecret).To(BeTrue())
+				g.Expect(gotBindings).To(HaveLen(1))
+			}
+
+			if tt.wantArgs == nil {
+				return
+			}
+
+			g.Expect(gotJob.Namespace).To(Equal(DefaultRemoteExecNamespace))
+			g.Expect(gotJob.Spec.Template.Spec.Containers[0].Image).To(Equal(tt.options.Image))
+			g.Expect(gotJob.Spec.Template.Spec.Containers[0].Args).To(Equal(tt.wantArgs))
+			g.Expect(gotSecret).To(Equal(tt.wantSecret))
+			g.Expect(output.String()).To(Equal("logs of " + gotJob.Name + "-abcde"))
+
+			if tt.wantServiceAccount != "" {
+				g.Expect(gotJob.Spec.Template.Spec.ServiceAccountName).To(Equal(tt.wantServiceAccount))
+				g.Expect(gotBindings).To(BeEmpty())
+			}
+			if tt.wantClusterRole != "" {
+				// The Job runs with a ServiceAccount of its own, bound to the ClusterRole while the Job runs.
+				g.Expect(gotJob.Spec.Template.Spec.ServiceAccountName).To(Equal(gotJob.Name))
+				g.Expect(gotBindings).To(HaveLen(1))
+				g.Expect(gotBindings[0].RoleRef.Name).To(Equal(tt.wantClusterRole))
+				g.Expect(gotBindings[0].Subjects).To(ConsistOf(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: gotJob.Namespace, Name: gotJob.Name}))
+			}
+		})
+	}
+}
@@ -29,6 +29,8 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
 	yaml "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
@@ -42,6 +44,14 @@ type TemplateClient interface {
 
 	// GetFromURL returns a workload cluster template from the given URL.
 	GetFromURL(templateURL, targetNamespace string, listVariablesOnly bool) (repository.Template, error)
+
+	// GetContentFromURL returns the raw content of the file at the given URL, without any template processing.
+	GetContentFromURL(fileURL string) ([]byte, error)
+
+	// Validate performs a server-side dry-run of the template objects against the management cluster, so objects not
+	// matching the CRD schemas (e.g. because of wrong variable values) are detected before applying the template.
+	// The returned error aggregates the validation errors for each object.
+	Validate(objs []unstructured.Unstructured) error
 }
 
 // templateClient implements TemplateClient.
@@ -108,6 +118,25 @@ func (t *templateClient) GetFromConfigMap(configMapNamespace, configMapName, con
 	})
 }
 
+func (t *templateClient) Validate(objs []unstructured.Unstructured) error {
+	c, err := t.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	// Nb. server-side apply is used so objects already existing in the management cluster are validated
+	// as well, instead of failing with an already exists error.
+	errList := []error{}
+	for i := range objs {
+		obj := objs[i].DeepCopy()
+		obj.SetResourceVersion("")
+		if err := c.Patch(ctx, obj, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership, client.DryRunAll); err != nil {
+			errList = append(errList, errors.Wrapf(err, "%s %s/%s is not valid", obj.GetKind(), obj.GetNamespace(), obj.GetName()))
+		}
+	}
+	return kerrors.NewAggregate(errList)
+}
+
 func (t *templateClient) GetFromURL(templateURL, targetNamespace string, listVariablesOnly bool) (repository.Template, error) {
 	if templateURL == "" {
 		return nil, errors.New("invalid GetFromURL operation: missing templateURL value")
@@ -127,6 +156,18 @@ func (t *templateClient) GetFromURL(templateURL, targetNamespace string, listVar
 	})
 }
 
+func (t *templateClient) GetContentFromURL(fileURL string) ([]byte, error) {
+	if fileURL == "" {
+		return nil, errors.New("invalid GetContentFromURL operation: missing fileURL value")
+	}
+
+	content, err := t.getURLContent(fileURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid GetContentFromURL operation")
+	}
+	return content, nil
+}
+
 func (t *templateClient) getURLContent(templateURL string) ([]byte, error) {
 	rURL, err := url.Parse(templateURL)
 	if err != nil {
@@ -17,6 +17,7 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
@@ -29,12 +30,17 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
 	yaml "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var template = `apiVersion: cluster.x-k8s.io/v1alpha3
@@ -380,3 +386,82 @@ func mustParseURL(rawURL string) *url.URL {
 	}
 	return rURL
 }
+
+// rejectingProxy is a Proxy whose clients reject patches for objects of the given kind, emulating the management
+// cluster refusing objects not matching the CRD schemas.
+type rejectingProxy struct {
+	*test.FakeProxy
+	kind string
+}
+
+func (p *rejectingProxy) NewClient() (client.Client, error) {
+	c, err := p.FakeProxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	return &rejectingClient{Client: c, kind: p.kind}, nil
+}
+
+type rejectingClient struct {
+	client.Client
+	kind string
+}
+
+func (c *rejectingClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if obj.GetObjectKind().GroupVersionKind().Kind == c.kind {
+		return errors.New("schema validation failed")
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func Test_templateClient_Validate(t *testing.T) {
+	configMap := unstructured.Unstructured{}
+	configMap.SetAPIVersion("v1")
+	configMap.SetKind("ConfigMap")
+	configMap.SetNamespace("ns1")
+	configMap.SetName("my-template-config")
+
+	foo := unstructured.Unstructured{}
+	foo.SetAPIVersion("foo.example.com/v1")
+	foo.SetKind("Foo")
+	foo.SetNamespace("ns1")
+	foo.SetName("my-foo")
+
+	tests := []struct {
+		name       string
+		objs       []unstructured.Unstructured
+		wantErrors int
+	}{
+		{
+			name:       "pass for objects matching the management cluster schema",
+			objs:       []unstructured.Unstructured{configMap},
+			wantErrors: 0,
+		},
+		{
+			name:       "returns an error for each object not matching the management cluster schema",
+			objs:       []unstructured.Unstructured{configMap, foo, foo},
+			wantErrors: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			proxy := &rejectingProxy{FakeProxy: test.NewFakeProxy(), kind: "Foo"}
+			tc := newTemplateClient(TemplateClientInput{proxy, nil, nil})
+
+			err := tc.Validate(tt.objs)
+			if tt.wantErrors == 0 {
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.(kerrors.Aggregate).Errors()).To(HaveLen(tt.wantErrors))
+			}
+
+			// Validation is a dry-run, so no objects are created.
+			c, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "my-template-config"}, &corev1.ConfigMap{})).NotTo(Succeed())
+		})
+	}
+}
@@ -17,11 +17,15 @@ limitations under the License.
 package cluster
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/version"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
 )
@@ -33,13 +37,27 @@ type ProviderUpgrader interface {
 	// - For each management group, an upgrade plan will be generated for each API Version of Cluster API (contract) available, e.g.
 	//   - Upgrade to the latest version in the the v1alpha2 series: ....
 	//   - Upgrade to the latest version in the the v1alpha3 series: ....
+	// If the management group must cross more than one contract for reaching the target contract, the plan
+	// includes the intermediate steps, e.g. v1alpha2 --> v1alpha3 --> v1alpha4.
 	Plan() ([]UpgradePlan, error)
 
 	// ApplyPlan executes an upgrade following an UpgradePlan generated by clusterctl.
-	ApplyPlan(coreProvider clusterctlv1.Provider, clusterAPIVersion string) error
+	// If the management group must cross more than one contract for reaching the target contract, the providers
+	// are upgraded to the latest version of each intermediate contract first.
+	// If a previous upgrade of the management group to the same API Version of Cluster API (contract), or to one
+	// of the intermediate contracts, was interrupted, it is resumed.
+	ApplyPlan(coreProvider clusterctlv1.Provider, clusterAPIVersion string, options UpgradeOptions) error
 
 	// ApplyCustomPlan plan executes an upgrade using the UpgradeItems provided by the user.
-	ApplyCustomPlan(coreProvider clusterctlv1.Provider, providersToUpgrade ...UpgradeItem) error
+	// If a previous upgrade of the management group including the same UpgradeItems was interrupted, it is resumed.
+	ApplyCustomPlan(coreProvider clusterctlv1.Provider, options UpgradeOptions, providersToUpgrade ...UpgradeItem) error
+}
+
+// UpgradeOptions carries the options supported by ApplyPlan and ApplyCustomPlan.
+type UpgradeOptions struct {
+	// Force performs the upgrade even if the existing Cluster API objects use fields or annotations that are
+	// removed in the target API Version of Cluster API (contract).
+	Force bool
 }
 
 // UpgradePlan defines a list of possible upgrade targets for a management group.
@@ -47,6 +65,34 @@ type UpgradePlan struct {
 	Contract     string
 	CoreProvider clusterctlv1.Provider
 	Providers    []UpgradeItem
+
+	// IntermediateSteps are the upgrades to the intermediate API Version of Cluster API (contract) required before
+	// upgrading to Contract, in order; it is empty if the management group can upgrade to Contract directly.
+	IntermediateSteps []UpgradeStep
+
+	// VersionSkews are the providers in the management group with instances running different versions, including
+	// the instances in other management groups.
+	VersionSkews []VersionSkew
+
+	// WebhookDowntimes are the estimated times the webhooks of the providers are not available during the upgrade,
+	// for the providers with webhooks being upgraded to Contract; if the plan has IntermediateSteps, the webhooks are
+	// not available at each step.
+	WebhookDowntimes []WebhookDowntime
+
+	// CertManager defines the cert-manager version installed in the management cluster and the version clusterctl is
+	// configured to install; it is the same for all the management groups.
+	CertManager CertManagerUpgradePlan
+
+	// Warnings report the current and the next versions of the providers which, according to the provider metadata,
+	// are deprecated or no longer supported.
+	Warnings []string
+}
+
+// UpgradeStep defines the upgrade targets of the providers in a management group for an intermediate API Version
+// of Cluster API (contract).
+type UpgradeStep struct {
+	Contract  string
+	Providers []UpgradeItem
 }
 
 // UpgradeRef returns a string identifying the upgrade plan; this string is derived by the core provider which is
@@ -77,6 +123,7 @@ func (u *UpgradeItem) UpgradeRef() string {
 }
 
 type providerUpgrader struct {
+	proxy                   Proxy
 	configClient            config.Client
 	repositoryClientFactory RepositoryClientFactory
 	providerInventory       InventoryClient
@@ -94,6 +141,12 @@ func (u *providerUpgrader) Plan() ([]UpgradePlan, error) {
 		return nil, err
 	}
 
+	// Gets the providers with instances running different versions, e.g. because only some of the instances were upgraded.
+	versionSkews, err := u.providerInventory.GetVersionSkews()
+	if err != nil {
+		return nil, err
+	}
+
 	var ret []UpgradePlan
 	for _, managementGroup := range managementGroups {
 		// The core provider is driving all the plan logic for each management group, because all the providers
@@ -132,6 +185,41 @@ func (u *providerUpgrader) Plan() ([]UpgradePlan, error) {
 				continue
 			}
 
+			// If the management group must cross more than one contract, adds the intermediate steps to the plan;
+			// as above, the plan is dropped if at least one provider can't upgrade to an intermediate contract.
+			// e.g. v1alpha2 --> v1alpha4 requires all the providers in the management group to upgrade to v1alpha3 first.
+			path := coreUpgradeInfo.getUpgradePath(contract)
+			partial := false
+			for _, intermediateContract := range path[:len(path)-1] {
+				intermediatePlan, err := u.getUpgradePlan(managementGroup, intermediateContract)
+				if err != nil {
+					return nil, err
+				}
+				if intermediatePlan.isPartialUpgrade() {
+					partial = true
+					break
+				}
+				upgradePlan.IntermediateSteps = append(upgradePlan.IntermediateSteps, UpgradeStep{
+					Contract:  intermediatePlan.Contract,
+					Providers: intermediatePlan.Providers,
+				})
+			}
+			if partial {
+				continue
+			}
+
+			upgradePlan.VersionSkews = managementGroup.FilterVersionSkews(versionSkews)
+
+			upgradePlan.WebhookDowntimes, err = u.getWebhookDowntimes(upgradePlan.Providers)
+			if err != nil {
+				return nil, err
+			}
+
+			upgradePlan.Warnings, err = u.getSupportWarnings(upgradePlan.Providers)
+			if err != nil {
+				return nil, err
+			}
+
 			ret = append(ret, *upgradePlan)
 		}
 	}
@@ -139,30 +227,144 @@ func (u *providerUpgrader) Plan() ([]UpgradePlan, error) {
 	return ret, nil
 }
 
-func (u *providerUpgrader) ApplyPlan(coreProvider clusterctlv1.Provider, contract string) error {
+// getSupportWarnings returns a warning for each current or next version of the providers in the upgrade items which,
+// according to the latest provider metadata, is deprecated or no longer supported.
+func (u *providerUpgrader) getSupportWarnings(upgradeItems []UpgradeItem) ([]string, error) {
+	now := time.Now()
+
+	var ret []string
+	for _, item := range upgradeItems {
+		upgradeInfo, err := u.getUpgradeInfo(item.Provider)
+		if err != nil {
+			return nil, err
+		}
+
+		if warning := repository.SupportWarning(item.InstanceName(), upgradeInfo.metadata, item.Version, now); warning != "" {
+			ret = append(ret, warning)
+		}
+		if item.NextVersion == "" {
+			continue
+		}
+		if warning := repository.SupportWarning(item.InstanceName(), upgradeInfo.metadata, item.NextVersion, now); warning != "" {
+			ret = append(ret, warning)
+		}
+	}
+	return ret, nil
+}
+
+func (u *providerUpgrader) ApplyPlan(coreProvider clusterctlv1.Provider, contract string, options UpgradeOptions) error {
 	log := logf.Log
 	log.Info("Performing upgrade...")
 
+	// If a previous upgrade of the management group was interrupted, resume it.
+	// Nb. this happens before retrieving the management group, because the inventory could be half-migrated.
+	progress, err := u.getUpgradeProgress(coreProvider)
+	if err != nil {
+		return err
+	}
+	if progress != nil {
+		if progress.Contract != contract {
+			// If the interrupted upgrade was a step toward the target contract, resume it and then complete the remaining steps.
+			isIntermediate, err := u.isIntermediateContract(coreProvider, progress.Contract, contract)
+			if err != nil {
+				return err
+			}
+			if !isIntermediate {
+				return errors.Errorf("unable to complete that upgrade: an upgrade of the %s management group to %s was interrupted, please complete it before upgrading to %s", coreProvider.InstanceName(), progress.Contract, contract)
+			}
+		}
+		if err := u.resumeUpgrade(progress); err != nil {
+			return err
+		}
+		if progress.Contract == contract {
+			return nil
+		}
+	}
+
 	// Retrieves the management group.
 	managementGroup, err := u.getManagementGroup(coreProvider)
 	if err != nil {
 		return err
 	}
 
-	// Gets the upgrade plan for the selected management group/API Version of Cluster API (contract).
-	upgradePlan, err := u.getUpgradePlan(*managementGroup, contract)
+	// Identifies the API Version of Cluster API (contract) the management group should go through for reaching
+	// the target contract (Nb. the core provider is driving the entire management group).
+	coreUpgradeInfo, err := u.getUpgradeInfo(managementGroup.CoreProvider)
 	if err != nil {
 		return err
 	}
+	path := coreUpgradeInfo.getUpgradePath(contract)
 
-	// Do the upgrade
-	return u.doUpgrade(upgradePlan)
+	for i, stepContract := range path {
+		// Retrieves the management group again after each step, because the versions of the providers are changed.
+		if i > 0 {
+			managementGroup, err = u.getManagementGroup(coreProvider)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Gets the upgrade plan for the selected management group/API Version of Cluster API (contract).
+		upgradePlan, err := u.getUpgradePlan(*managementGroup, stepContract)
+		if err != nil {
+			return err
+		}
+
+		// All the providers in a management group are required to change contract at the same time, so
+		// an intermediate step can't leave any provider behind.
+		if stepContract != contract && upgradePlan.isPartialUpgrade() {
+			return errors.Errorf("unable to complete that upgrade: not all the providers in the %s management group have a release supporting the %s API Version of Cluster API (contract), which is required for upgrading to %s", coreProvider.InstanceName(), stepContract, contract)
+		}
+
+		if len(path) > 1 {
+			log.Info("Upgrading the management group", "ManagementGroup", coreProvider.InstanceName(), "Contract", stepContract, "Step", fmt.Sprintf("%d/%d", i+1, len(path)))
+		}
+
+		// Do the upgrade
+		if err := u.doUpgrade(upgradePlan, options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isIntermediateContract returns true if upgrading the management group to the target API Version of Cluster API (contract)
+// goes through the given contract.
+func (u *providerUpgrader) isIntermediateContract(coreProvider clusterctlv1.Provider, contract, targetContract string) (bool, error) {
+	coreUpgradeInfo, err := u.getUpgradeInfo(coreProvider)
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	for _, c := range coreUpgradeInfo.getContractsForUpgrade() {
+		switch c {
+		case contract:
+			found = true
+		case targetContract:
+			return found, nil
+		}
+	}
+	return false, nil
 }
 
-func (u *providerUpgrader) ApplyCustomPlan(coreProvider clusterctlv1.Provider, upgradeItems ...UpgradeItem) error {
+func (u *providerUpgrader) ApplyCustomPlan(coreProvider clusterctlv1.Provider, options UpgradeOptions, upgradeItems ...UpgradeItem) error {
 	log := logf.Log
 	log.Info("Performing upgrade...")
 
+	// If a previous upgrade of the management group was interrupted, resume it.
+	// Nb. this happens before creating the custom plan, because the inventory could be half-migrated.
+	progress, err := u.getUpgradeProgress(coreProvider)
+	if err != nil {
+		return err
+	}
+	if progress != nil {
+		if !progress.matchesPlan(upgradeItems) {
+			return errors.Errorf("unable to complete that upgrade: an upgrade of the %s management group was interrupted, please complete it using the same providers and versions", coreProvider.InstanceName())
+		}
+		return u.resumeUpgrade(progress)
+	}
+
 	// Create a custom upgrade plan from the upgrade items, taking care of ensuring all the providers in a management
 	// group are consistent with the API Version of Cluster API (contract).
 	upgradePlan, err := u.createCustomPlan(coreProvider, upgradeItems)
@@ -171,7 +373,7 @@ func (u *providerUpgrader) ApplyCustomPlan(coreProvider clusterctlv1.Provider, u
 	}
 
 	// Do the upgrade
-	return u.doUpgrade(upgradePlan)
+	return u.doUpgrade(upgradePlan, options)
 }
 
 // getUpgradePlan returns the upgrade plan for a specific managementGroup/contract
@@ -266,7 +468,7 @@ func (u *providerUpgrader) createCustomPlan(coreProvider clusterctlv1.Provider,
 		}
 
 		if contract != targetContract {
-			return nil, errors.Errorf("unable to complete that upgrade: the target version for the provider %s supports the %s API Version of Cluster API (contract), while the management group is using %s", upgradeItem.InstanceName(), contract, targetContract)
+			return nil, errors.Wrap(messages.New(messages.ContractMismatch, upgradeItem.InstanceName(), contract, targetContract), "unable to complete that upgrade")
 		}
 
 		upgradePlan.Providers = append(upgradePlan.Providers, upgradeItem)
@@ -287,7 +489,7 @@ func (u *providerUpgrader) createCustomPlan(coreProvider clusterctlv1.Provider,
 		}
 
 		if contract != targetContract {
-			return nil, errors.Errorf("unable to complete that upgrade: the provider %s supports the %s API Version of Cluster API (contract), while the management group is being updated to %s. Please include the %[1]s provider in the upgrade", provider.InstanceName(), contract, targetContract)
+			return nil, errors.Wrapf(messages.New(messages.ContractMismatch, provider.InstanceName(), contract, targetContract), "unable to complete that upgrade, please include the %s provider in the upgrade", provider.InstanceName())
 		}
 	}
 	return upgradePlan, nil
@@ -329,6 +531,7 @@ func (u *providerUpgrader) getUpgradeComponents(provider UpgradeItem) (repositor
 		Version:           provider.NextVersion,
 		TargetNamespace:   provider.Namespace,
 		WatchingNamespace: provider.WatchedNamespace,
+		FeatureGates:      provider.FeatureGates,
 	}
 	components, err := providerRepository.Components().Get(options)
 	if err != nil {
@@ -337,16 +540,70 @@ func (u *providerUpgrader) getUpgradeComponents(provider UpgradeItem) (repositor
 	return components, nil
 }
 
-func (u *providerUpgrader) doUpgrade(upgradePlan *UpgradePlan) error {
+func (u *providerUpgrader) doUpgrade(upgradePlan *UpgradePlan, options UpgradeOptions) error {
 	log := logf.Log
-	log.Info("Performing upgrade...")
 
+	// Checks the existing Cluster API objects can be converted to the target API Version of Cluster API (contract),
+	// so the upgrade does not leave behind objects that can not be read anymore.
+	report, err := checkUpgradeCompatibility(u.proxy, upgradePlan.Contract)
+	if err != nil {
+		return err
+	}
+	if len(report.Issues) > 0 {
+		if !options.Force {
+			return errors.Errorf("unable to complete that upgrade: %s\nPlease fix the objects before upgrading, or force the upgrade", report)
+		}
+		log.Info(fmt.Sprintf("Warning: forcing the upgrade; %s", report))
+	}
+
+	// Checks the Kubernetes version of the management cluster is supported by the target versions of the providers.
 	for _, upgradeItem := range upgradePlan.Providers {
-		// If there is not a specified next version, skip it (we are already up-to-date).
 		if upgradeItem.NextVersion == "" {
 			continue
 		}
+		if err := validateKubernetesCompatibility(u.proxy, upgradeItem.Provider, upgradeItem.NextVersion); err != nil {
+			return errors.Wrapf(err, "upgrading provider %q can lead to a non functioning management cluster", upgradeItem.InstanceName())
+		}
+	}
+
+	// Records the upgrade plan in the management cluster before changing anything, so the upgrade can be resumed if interrupted.
+	progress := newUpgradeProgress(upgradePlan)
+	if err := u.saveUpgradeProgress(progress); err != nil {
+		return err
+	}
+
+	return u.runUpgrade(progress)
+}
+
+func (u *providerUpgrader) resumeUpgrade(progress *upgradeProgress) error {
+	log := logf.Log
+	log.Info("Resuming interrupted upgrade", "ManagementGroup", progress.CoreProvider.InstanceName(), "Contract", progress.Contract)
+
+	return u.runUpgrade(progress)
+}
+
+// runUpgrade upgrades the providers, recording the progress in the management cluster after each step and
+// skipping the steps already completed by an interrupted upgrade.
+func (u *providerUpgrader) runUpgrade(progress *upgradeProgress) error {
+	log := logf.Log
+
+	for i := range progress.Items {
+		item := &progress.Items[i]
+
+		// If there is not a specified next version, skip it (we are already up-to-date).
+		if item.NextVersion == "" {
+			continue
+		}
+
+		// If the provider is already upgraded, skip it.
+		if item.Step == upgradeStepInventoryUpdated {
+			continue
+		}
 
+		upgradeItem := UpgradeItem{
+			Provider:    item.Provider,
+			NextVersion: item.NextVersion,
+		}
 		log.Info("Upgrading", "Provider", upgradeItem.InstanceName(), "CurrentVersion", upgradeItem.Version, "TargetVersion", upgradeItem.NextVersion)
 
 		// Gets the provider components for the target version.
@@ -356,24 +613,64 @@ func (u *providerUpgrader) doUpgrade(upgradePlan *UpgradePlan) error {
 		}
 
 		// Delete the provider, preserving CRD and namespace.
-		if err := u.providerComponents.Delete(DeleteOptions{
-			Provider:         upgradeItem.Provider,
-			IncludeNamespace: false,
-			IncludeCRDs:      false,
-		}); err != nil {
-			return err
+		if item.Step < upgradeStepComponentsDeleted {
+			if err := u.providerComponents.Delete(DeleteOptions{
+				Provider:         upgradeItem.Provider,
+				IncludeNamespace: false,
+				IncludeCRDs:      false,
+			}); err != nil {
+				return err
+			}
+			if err := u.setUpgradeStep(progress, item, upgradeStepComponentsDeleted); err != nil {
+				return err
+			}
 		}
 
 		// Install the new version of the provider components.
-		if err := installComponentsAndUpdateInventory(components, u.providerComponents, u.providerInventory); err != nil {
+		// Nb. On upgrade, clusterctl forces ownership of the fields managed by other field managers, so the
+		// provider components are reconciled to the state defined by the new version.
+		if item.Step < upgradeStepComponentsApplied {
+			if err := installComponents(components, u.providerComponents, u.providerInventory, CreateOptions{ForceOwnership: true}); err != nil {
+				return err
+			}
+			if err := u.setUpgradeStep(progress, item, upgradeStepComponentsApplied); err != nil {
+				return err
+			}
+		}
+
+		// Migrate the objects persisted in old versions to the storage version of the new CRDs, before
+		// recording the upgrade in the inventory; this protects from data loss when old versions are dropped by a later release.
+		if item.Step < upgradeStepStorageVersionMigrated {
+			if err := u.migrateStorageVersions(components); err != nil {
+				return err
+			}
+			if err := u.setUpgradeStep(progress, item, upgradeStepStorageVersionMigrated); err != nil {
+				return err
+			}
+		}
+
+		// Update the inventory entry for the provider to the new version.
+		if err := u.providerInventory.Create(components.InventoryObject()); err != nil {
+			return err
+		}
+		if err := u.setUpgradeStep(progress, item, upgradeStepInventoryUpdated); err != nil {
 			return err
 		}
 	}
-	return nil
+
+	// The upgrade is completed, so the progress is not required anymore.
+	return u.deleteUpgradeProgress(progress)
+}
+
+// setUpgradeStep records that a step of the upgrade of a provider is completed.
+func (u *providerUpgrader) setUpgradeStep(progress *upgradeProgress, item *upgradeProgressItem, step upgradeStep) error {
+	item.Step = step
+	return u.saveUpgradeProgress(progress)
 }
 
-func newProviderUpgrader(configClient config.Client, repositoryClientFactory RepositoryClientFactory, providerInventory InventoryClient, providerComponents ComponentsClient) *providerUpgrader {
+func newProviderUpgrader(proxy Proxy, configClient config.Client, repositoryClientFactory RepositoryClientFactory, providerInventory InventoryClient, providerComponents ComponentsClient) *providerUpgrader {
 	return &providerUpgrader{
+		proxy:                   proxy,
 		configClient:            configClient,
 		repositoryClientFactory: repositoryClientFactory,
 		providerInventory:       providerInventory,
@@ -0,0 +1,169 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+// objectCompatibilityRule identifies a field or an annotation of the Cluster API objects that is deprecated or
+// removed in a target API Version of Cluster API (contract), and thus prevents the objects from being converted.
+type objectCompatibilityRule struct {
+	// Kind of the objects the rule applies to; objects are read using the cluster.x-k8s.io API version
+	// currently served by the management cluster.
+	Kind string
+
+	// Field is the path of the field that can not be converted, e.g. spec.bootstrap.data.
+	// +optional
+	Field []string
+
+	// Annotation is the annotation that can not be converted.
+	// +optional
+	Annotation string
+
+	// Message explains how to fix the objects before upgrading.
+	Message string
+}
+
+// upgradeCompatibilityRules are the rules checked before upgrading a management group to a target API Version of
+// Cluster API (contract).
+// Nb. when deprecating a field or an annotation of the Cluster API objects, please extend the rules for the
+// contract removing it.
+var upgradeCompatibilityRules = map[string][]objectCompatibilityRule{
+	"v1alpha4": {
+		{
+			Kind:    "Machine",
+			Field:   []string{"spec", "bootstrap", "data"},
+			Message: "spec.bootstrap.data is removed, use spec.bootstrap.dataSecretName instead",
+		},
+		{
+			Kind:    "MachineSet",
+			Field:   []string{"spec", "template", "spec", "bootstrap", "data"},
+			Message: "spec.template.spec.bootstrap.data is removed, use spec.template.spec.bootstrap.dataSecretName instead",
+		},
+		{
+			Kind:    "MachineDeployment",
+			Field:   []string{"spec", "template", "spec", "bootstrap", "data"},
+			Message: "spec.template.spec.bootstrap.data is removed, use spec.template.spec.bootstrap.dataSecretName instead",
+		},
+	},
+}
+
+// UpgradeCompatibilityIssue describes a Cluster API object that can not be converted to the target API Version
+// of Cluster API (contract).
+type UpgradeCompatibilityIssue struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Message   string
+}
+
+// UpgradeCompatibilityReport is the result of the pre-upgrade compatibility check of the Cluster API objects
+// existing in a management cluster.
+type UpgradeCompatibilityReport struct {
+	// Contract is the target API Version of Cluster API (contract) the objects are checked against.
+	Contract string
+
+	// Issues are the objects that can not be converted to the target contract.
+	Issues []UpgradeCompatibilityIssue
+}
+
+// String returns a human readable description of the report.
+func (r *UpgradeCompatibilityReport) String() string {
+	lines := make([]string, 0, len(r.Issues))
+	for _, i := range r.Issues {
+		lines = append(lines, fmt.Sprintf("- %s %s/%s: %s", i.Kind, i.Namespace, i.Name, i.Message))
+	}
+	return fmt.Sprintf("%d object(s) can not be converted to the %s API Version of Cluster API (contract):\n%s", len(r.Issues), r.Contract, strings.Join(lines, "\n"))
+}
+
+// checkUpgradeCompatibility scans the Cluster API objects existing in the management cluster for fields and
+// annotations removed in the target API Version of Cluster API (contract).
+func checkUpgradeCompatibility(proxy Proxy, contract string) (*UpgradeCompatibilityReport, error) {
+	report := &UpgradeCompatibilityReport{Contract: contract}
+
+	rules := upgradeCompatibilityRules[contract]
+	if len(rules) == 0 {
+		return report, nil
+	}
+
+	c, err := proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		objList := &unstructured.UnstructuredList{}
+		objList.SetAPIVersion(clusterv1.GroupVersion.String())
+		objList.SetKind(rule.Kind + "List")
+		if err := c.List(ctx, objList); err != nil {
+			// Skip kinds not installed in the management cluster.
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to list %s objects", rule.Kind)
+		}
+
+		for i := range objList.Items {
+			obj := &objList.Items[i]
+			if !rule.matches(obj) {
+				continue
+			}
+			report.Issues = append(report.Issues, UpgradeCompatibilityIssue{
+				Kind:      rule.Kind,
+				Namespace: obj.GetNamespace(),
+				Name:      obj.GetName(),
+				Message:   rule.Message,
+			})
+		}
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return report, nil
+}
+
+// matches returns true if the object uses the field or the annotation identified by the rule.
+func (r objectCompatibilityRule) matches(obj *unstructured.Unstructured) bool {
+	if r.Annotation != "" {
+		if _, ok := obj.GetAnnotations()[r.Annotation]; ok {
+			return true
+		}
+	}
+	if len(r.Field) > 0 {
+		if v, ok, _ := unstructured.NestedFieldNoCopy(obj.Object, r.Field...); ok && v != nil {
+			return true
+		}
+	}
+	return false
+}
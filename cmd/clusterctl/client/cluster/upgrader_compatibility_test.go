@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_checkUpgradeCompatibility(t *testing.T) {
+	machine := func(name string, data *string, annotations map[string]string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "Machine",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "ns1",
+				Name:        name,
+				Annotations: annotations,
+			},
+			Spec: clusterv1.MachineSpec{
+				Bootstrap: clusterv1.Bootstrap{Data: data},
+			},
+		}
+	}
+
+	rules := map[string][]objectCompatibilityRule{
+		"v1alpha4": {
+			{Kind: "Machine", Field: []string{"spec", "bootstrap", "data"}, Message: "data is removed"},
+			{Kind: "Machine", Annotation: "removed-annotation", Message: "removed-annotation is removed"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		objs     []runtime.Object
+		contract string
+		want     []UpgradeCompatibilityIssue
+	}{
+		{
+			name: "reports the objects using removed fields and annotations",
+			objs: []runtime.Object{
+				machine("m3", pointer.StringPtr("data"), nil),
+				machine("m2", nil, nil),
+				machine("m1", nil, map[string]string{"removed-annotation": ""}),
+			},
+			contract: "v1alpha4",
+			want: []UpgradeCompatibilityIssue{
+				{Kind: "Machine", Namespace: "ns1", Name: "m1", Message: "removed-annotation is removed"},
+				{Kind: "Machine", Namespace: "ns1", Name: "m3", Message: "data is removed"},
+			},
+		},
+		{
+			name: "reports nothing if the objects are compatible",
+			objs: []runtime.Object{
+				machine("m1", nil, nil),
+			},
+			contract: "v1alpha4",
+			want:     nil,
+		},
+		{
+			name: "reports nothing if there are no rules for the contract",
+			objs: []runtime.Object{
+				machine("m1", pointer.StringPtr("data"), nil),
+			},
+			contract: "v1alpha3",
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			defer func(r map[string][]objectCompatibilityRule) { upgradeCompatibilityRules = r }(upgradeCompatibilityRules)
+			upgradeCompatibilityRules = rules
+
+			got, err := checkUpgradeCompatibility(test.NewFakeProxy().WithObjs(tt.objs...), tt.contract)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.Contract).To(Equal(tt.contract))
+			g.Expect(got.Issues).To(Equal(tt.want))
+		})
+	}
+}
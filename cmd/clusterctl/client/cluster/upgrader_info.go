@@ -144,17 +144,43 @@ func newUpgradeInfo(metadata *clusterctlv1.Metadata, currentVersion *version.Ver
 // getContractsForUpgrade return the list of API Version of Cluster API (contract) version available for a provider upgrade. e.g.
 // - If the current version of the provider support v1alpha3 contract (the latest), it returns v1alpha3
 // - If the current version of the provider support v1alpha3 contract but there is also the v1alpha4 contract available, it returns v1alpha3, v1alpha4
+// Nb. contracts are returned in the order of the release series linked to them.
 func (i *upgradeInfo) getContractsForUpgrade() []string {
-	contractsForUpgrade := sets.NewString()
+	contractsForUpgrade := []string{}
+	contracts := sets.NewString()
 	for _, releaseSeries := range i.metadata.ReleaseSeries {
 		// Drop the release series if older than the current version, because not relevant for upgrade.
 		if i.currentVersion.Major() > releaseSeries.Major || (i.currentVersion.Major() == releaseSeries.Major && i.currentVersion.Minor() > releaseSeries.Minor) {
 			continue
 		}
-		contractsForUpgrade.Insert(releaseSeries.Contract)
+		if contracts.Has(releaseSeries.Contract) {
+			continue
+		}
+		contracts.Insert(releaseSeries.Contract)
+		contractsForUpgrade = append(contractsForUpgrade, releaseSeries.Contract)
+	}
+
+	return contractsForUpgrade
+}
+
+// getUpgradePath returns the list of API Version of Cluster API (contract) a provider should go through when upgrading
+// to the target contract, target contract included. e.g.
+// - If the current version of the provider supports v1alpha2 contract and the target is v1alpha4, it returns v1alpha3, v1alpha4
+// - If the current version of the provider supports the target contract, it returns the target contract only
+func (i *upgradeInfo) getUpgradePath(contract string) []string {
+	path := []string{}
+	for _, c := range i.getContractsForUpgrade() {
+		if c == i.currentContract {
+			continue
+		}
+		path = append(path, c)
+		if c == contract {
+			return path
+		}
 	}
 
-	return contractsForUpgrade.List()
+	// The target contract is the current one, or it is not available for upgrades.
+	return []string{contract}
 }
 
 // getLatestNextVersion returns the next available version for a provider within the target API Version of Cluster API (contract).
@@ -241,6 +241,57 @@ func Test_upgradeInfo_getContractsForUpgrade(t *testing.T) {
 	}
 }
 
+func Test_upgradeInfo_getUpgradePath(t *testing.T) {
+	metadata := &clusterctlv1.Metadata{ // metadata defining release series linked to three contracts
+		ReleaseSeries: []clusterctlv1.ReleaseSeries{
+			{Major: 0, Minor: 3, Contract: "v1alpha4"},
+			{Major: 0, Minor: 1, Contract: "v1alpha2"},
+			{Major: 0, Minor: 2, Contract: "v1alpha3"},
+		},
+	}
+	tests := []struct {
+		name           string
+		currentVersion string
+		contract       string
+		want           []string
+	}{
+		{
+			name:           "Upgrade within the current contract",
+			currentVersion: "v0.1.1",
+			contract:       "v1alpha2",
+			want:           []string{"v1alpha2"},
+		},
+		{
+			name:           "Upgrade to the next contract",
+			currentVersion: "v0.1.1",
+			contract:       "v1alpha3",
+			want:           []string{"v1alpha3"},
+		},
+		{
+			name:           "Upgrade across two contracts",
+			currentVersion: "v0.1.1",
+			contract:       "v1alpha4",
+			want:           []string{"v1alpha3", "v1alpha4"},
+		},
+		{
+			name:           "Upgrade to a contract not available for upgrades",
+			currentVersion: "v0.2.1",
+			contract:       "v1alpha2",
+			want:           []string{"v1alpha2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			upgradeInfo := newUpgradeInfo(metadata.DeepCopy(), version.MustParseSemantic(tt.currentVersion), nil)
+
+			got := upgradeInfo.getUpgradePath(tt.contract)
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
 func Test_upgradeInfo_getLatestNextVersion(t *testing.T) {
 	type field struct {
 		currentVersion string
@@ -0,0 +1,233 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// UpgradeProgressConfigMapName is the name of the ConfigMap where clusterctl records the progress of an upgrade.
+	// The ConfigMap is created in the namespace of the core provider of the management group being upgraded, and
+	// it is deleted when the upgrade completes.
+	UpgradeProgressConfigMapName = "clusterctl-upgrade-progress"
+
+	// upgradeProgressKey is the ConfigMap key hosting the upgrade progress.
+	upgradeProgressKey = "progress"
+)
+
+// upgradeStep defines the last step completed while upgrading a provider.
+type upgradeStep int
+
+const (
+	// upgradeStepPending is the step of a provider not yet processed.
+	upgradeStepPending upgradeStep = iota
+
+	// upgradeStepComponentsDeleted is the step of a provider whose old components are deleted.
+	upgradeStepComponentsDeleted
+
+	// upgradeStepComponentsApplied is the step of a provider whose new components are applied.
+	upgradeStepComponentsApplied
+
+	// upgradeStepStorageVersionMigrated is the step of a provider whose objects are persisted in the storage version
+	// of the new CRDs.
+	upgradeStepStorageVersionMigrated
+
+	// upgradeStepInventoryUpdated is the step of a provider whose inventory entry is updated to the new version,
+	// and thus is the last step of a provider upgrade.
+	upgradeStepInventoryUpdated
+)
+
+// upgradeProgress records the progress of an upgrade, so an interrupted upgrade can be resumed.
+type upgradeProgress struct {
+	// Contract is the API Version of Cluster API (contract) the management group is upgraded to.
+	Contract string `json:"contract"`
+
+	// CoreProvider of the management group being upgraded.
+	CoreProvider clusterctlv1.Provider `json:"coreProvider"`
+
+	// Items defines the progress for each provider in the upgrade plan.
+	Items []upgradeProgressItem `json:"items"`
+}
+
+// upgradeProgressItem records the progress of the upgrade of a provider.
+type upgradeProgressItem struct {
+	// Provider being upgraded, as it was in the inventory before the upgrade.
+	Provider clusterctlv1.Provider `json:"provider"`
+
+	// NextVersion is the version the provider is upgraded to.
+	NextVersion string `json:"nextVersion"`
+
+	// Step is the last step completed.
+	Step upgradeStep `json:"step"`
+}
+
+// newUpgradeProgress returns the upgradeProgress for an UpgradePlan, with all the providers pending.
+func newUpgradeProgress(upgradePlan *UpgradePlan) *upgradeProgress {
+	progress := &upgradeProgress{
+		Contract:     upgradePlan.Contract,
+		CoreProvider: progressProvider(upgradePlan.CoreProvider),
+	}
+	for _, upgradeItem := range upgradePlan.Providers {
+		progress.Items = append(progress.Items, upgradeProgressItem{
+			Provider:    progressProvider(upgradeItem.Provider),
+			NextVersion: upgradeItem.NextVersion,
+		})
+	}
+	return progress
+}
+
+// progressProvider returns a copy of the provider without the fields assigned by the API server,
+// so it can be used for re-creating the inventory entry when resuming an upgrade.
+func progressProvider(provider clusterctlv1.Provider) clusterctlv1.Provider {
+	return clusterctlv1.Provider{
+		TypeMeta: provider.TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: provider.Namespace,
+			Name:      provider.Name,
+			Labels:    provider.Labels,
+		},
+		ProviderName:     provider.ProviderName,
+		Type:             provider.Type,
+		Version:          provider.Version,
+		WatchedNamespace: provider.WatchedNamespace,
+	}
+}
+
+// matchesPlan checks if an interrupted upgrade can be resumed for the given upgrade items; this happens
+// when each upgrade item is part of the recorded upgrade with the same target version.
+func (p *upgradeProgress) matchesPlan(upgradeItems []UpgradeItem) bool {
+	for _, upgradeItem := range upgradeItems {
+		found := false
+		for _, item := range p.Items {
+			if item.Provider.InstanceName() == upgradeItem.InstanceName() && item.NextVersion == upgradeItem.NextVersion {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// getUpgradeProgress returns the progress of an interrupted upgrade for the management group, if any.
+func (u *providerUpgrader) getUpgradeProgress(coreProvider clusterctlv1.Provider) (*upgradeProgress, error) {
+	c, err := u.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	cm := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: coreProvider.Namespace, Name: UpgradeProgressConfigMapName}
+	if err := c.Get(ctx, key, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get the %s/%s ConfigMap", key.Namespace, key.Name)
+	}
+
+	progress := &upgradeProgress{}
+	if err := json.Unmarshal([]byte(cm.Data[upgradeProgressKey]), progress); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the upgrade progress from the %s/%s ConfigMap", key.Namespace, key.Name)
+	}
+
+	// Nb. the ConfigMap is in the namespace of the core provider, so it could belong to another core provider instance
+	// only if the inventory was tampered with.
+	if progress.CoreProvider.InstanceName() != coreProvider.InstanceName() {
+		return nil, errors.Errorf("the %s/%s ConfigMap records an upgrade for the %s management group", key.Namespace, key.Name, progress.CoreProvider.InstanceName())
+	}
+	return progress, nil
+}
+
+// saveUpgradeProgress records the progress of an upgrade in the management cluster.
+func (u *providerUpgrader) saveUpgradeProgress(progress *upgradeProgress) error {
+	value, err := json.Marshal(progress)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the upgrade progress")
+	}
+
+	return retryWithExponentialBackoff(newWriteBackoff(), func() error {
+		c, err := u.proxy.NewClient()
+		if err != nil {
+			return err
+		}
+
+		cm := &corev1.ConfigMap{}
+		key := client.ObjectKey{Namespace: progress.CoreProvider.Namespace, Name: UpgradeProgressConfigMapName}
+		if err := c.Get(ctx, key, cm); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return errors.Wrapf(err, "failed to get the %s/%s ConfigMap", key.Namespace, key.Name)
+			}
+			// Nb. the ConfigMap does not have the provider label, so it is preserved when the core provider
+			// components are deleted during the upgrade.
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: key.Namespace,
+					Name:      key.Name,
+					Labels: map[string]string{
+						clusterctlv1.ClusterctlLabelName: "",
+					},
+				},
+				Data: map[string]string{
+					upgradeProgressKey: string(value),
+				},
+			}
+			if err := c.Create(ctx, cm); err != nil {
+				return errors.Wrapf(err, "failed to create the %s/%s ConfigMap", key.Namespace, key.Name)
+			}
+			return nil
+		}
+
+		cm.Data = map[string]string{
+			upgradeProgressKey: string(value),
+		}
+		if err := c.Update(ctx, cm); err != nil {
+			return errors.Wrapf(err, "failed to update the %s/%s ConfigMap", key.Namespace, key.Name)
+		}
+		return nil
+	})
+}
+
+// deleteUpgradeProgress removes the progress of a completed upgrade from the management cluster.
+func (u *providerUpgrader) deleteUpgradeProgress(progress *upgradeProgress) error {
+	return retryWithExponentialBackoff(newWriteBackoff(), func() error {
+		c, err := u.proxy.NewClient()
+		if err != nil {
+			return err
+		}
+
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: progress.CoreProvider.Namespace,
+				Name:      UpgradeProgressConfigMapName,
+			},
+		}
+		if err := c.Delete(ctx, cm); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete the %s/%s ConfigMap", cm.Namespace, cm.Name)
+		}
+		return nil
+	})
+}
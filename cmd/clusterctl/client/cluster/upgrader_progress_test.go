@@ -0,0 +1,199 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeComponentsClient is a ComponentsClient recording the operations executed, optionally failing on Create.
+type fakeComponentsClient struct {
+	deleted   []string
+	created   int
+	createErr error
+}
+
+func (f *fakeComponentsClient) Create(objs []unstructured.Unstructured, options CreateOptions) error {
+	if f.createErr != nil {
+		return f.createErr
+	}
+	f.created++
+	return nil
+}
+
+func (f *fakeComponentsClient) Delete(options DeleteOptions) error {
+	f.deleted = append(f.deleted, options.Provider.InstanceName())
+	return nil
+}
+
+func (f *fakeComponentsClient) PlanDelete(options DeleteOptions) (*ProviderDeletePlan, error) {
+	return &ProviderDeletePlan{Provider: options.Provider.InstanceName()}, nil
+}
+
+func (f *fakeComponentsClient) DeleteStale(options DeleteStaleOptions) error {
+	return nil
+}
+
+var upgradeComponentsYAML = []byte("apiVersion: v1\n" +
+	"kind: Pod\n" +
+	"metadata:\n" +
+	"  name: manager")
+
+func newTestProviderUpgrader(proxy Proxy, components ComponentsClient) *providerUpgrader {
+	configClient, _ := config.New("", config.InjectReader(test.NewFakeReader().
+		WithProvider("cluster-api", clusterctlv1.CoreProviderType, "https://somewhere.com").
+		WithProvider("infra", clusterctlv1.InfrastructureProviderType, "https://somewhere.com")))
+
+	repositories := map[string]repository.Repository{
+		"cluster-api": test.NewFakeRepository().
+			WithPaths("root", "components.yaml").
+			WithVersions("v1.0.0", "v1.0.1").
+			WithMetadata("v1.0.1", &clusterctlv1.Metadata{
+				ReleaseSeries: []clusterctlv1.ReleaseSeries{
+					{Major: 1, Minor: 0, Contract: "v1alpha3"},
+				},
+			}).
+			WithFile("v1.0.1", "components.yaml", upgradeComponentsYAML),
+		"infrastructure-infra": test.NewFakeRepository().
+			WithPaths("root", "components.yaml").
+			WithVersions("v2.0.0", "v2.0.1").
+			WithMetadata("v2.0.1", &clusterctlv1.Metadata{
+				ReleaseSeries: []clusterctlv1.ReleaseSeries{
+					{Major: 2, Minor: 0, Contract: "v1alpha3"},
+				},
+			}).
+			WithFile("v2.0.1", "components.yaml", upgradeComponentsYAML),
+	}
+
+	return newProviderUpgrader(
+		proxy,
+		configClient,
+		func(provider config.Provider, configClient config.Client, options ...repository.Option) (repository.Client, error) {
+			return repository.New(provider, configClient, repository.InjectRepository(repositories[provider.ManifestLabel()]))
+		},
+		newInventoryClient(proxy, nil),
+		components,
+	)
+}
+
+func Test_providerUpgrader_ApplyCustomPlan_RecordsProgress(t *testing.T) {
+	g := NewWithT(t)
+
+	proxy := test.NewFakeProxy().
+		WithProviderInventory("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", "").
+		WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", "")
+	components := &fakeComponentsClient{createErr: errors.New("failed to apply")}
+	u := newTestProviderUpgrader(proxy, components)
+
+	coreProvider := fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "", "cluster-api-system", "")
+	infraItem := UpgradeItem{
+		Provider:    fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+		NextVersion: "v2.0.1",
+	}
+
+	// The upgrade fails after the old components are deleted, and the progress records it.
+	g.Expect(u.ApplyCustomPlan(coreProvider, UpgradeOptions{}, infraItem)).ToNot(Succeed())
+	g.Expect(components.deleted).To(Equal([]string{infraItem.InstanceName()}))
+
+	progress, err := u.getUpgradeProgress(coreProvider)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(progress).NotTo(BeNil())
+	g.Expect(progress.Items).To(HaveLen(1))
+	g.Expect(progress.Items[0].Step).To(Equal(upgradeStepComponentsDeleted))
+	g.Expect(progress.Items[0].Provider.Version).To(Equal("v2.0.0"))
+
+	// A different upgrade is rejected until the interrupted one is completed.
+	otherItem := infraItem
+	otherItem.NextVersion = "v2.0.0"
+	g.Expect(u.ApplyCustomPlan(coreProvider, UpgradeOptions{}, otherItem)).ToNot(Succeed())
+
+	// Resuming the upgrade skips the delete step, applies the components and updates the inventory.
+	components.createErr = nil
+	g.Expect(u.ApplyCustomPlan(coreProvider, UpgradeOptions{}, infraItem)).To(Succeed())
+	g.Expect(components.deleted).To(HaveLen(1))
+	g.Expect(components.created).To(BeNumerically(">", 0))
+
+	providers, err := u.providerInventory.List()
+	g.Expect(err).NotTo(HaveOccurred())
+	versions := map[string]string{}
+	for _, p := range providers.Items {
+		versions[p.InstanceName()] = p.Version
+	}
+	g.Expect(versions).To(HaveKeyWithValue(infraItem.InstanceName(), "v2.0.1"))
+
+	// The progress is removed once the upgrade completes.
+	c, err := proxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+	err = c.Get(ctx, client.ObjectKey{Namespace: "cluster-api-system", Name: UpgradeProgressConfigMapName}, &corev1.ConfigMap{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+}
+
+func Test_upgradeProgress_matchesPlan(t *testing.T) {
+	progress := &upgradeProgress{
+		Items: []upgradeProgressItem{
+			{Provider: fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""), NextVersion: "v1.0.1"},
+			{Provider: fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""), NextVersion: "v2.0.1"},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		upgradeItems []UpgradeItem
+		want         bool
+	}{
+		{
+			name: "match if all the items are part of the upgrade",
+			upgradeItems: []UpgradeItem{
+				{Provider: fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "", "infra-system", ""), NextVersion: "v2.0.1"},
+			},
+			want: true,
+		},
+		{
+			name: "does not match if the target version is different",
+			upgradeItems: []UpgradeItem{
+				{Provider: fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "", "infra-system", ""), NextVersion: "v2.0.2"},
+			},
+			want: false,
+		},
+		{
+			name: "does not match if the provider is not part of the upgrade",
+			upgradeItems: []UpgradeItem{
+				{Provider: fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "", "other-system", ""), NextVersion: "v2.0.1"},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(progress.matchesPlan(tt.upgradeItems)).To(Equal(tt.want))
+		})
+	}
+}
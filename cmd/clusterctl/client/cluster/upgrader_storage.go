@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/pkg/errors"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// storageVersionMigrationPageSize is the number of objects read at once when migrating the objects of a CRD.
+const storageVersionMigrationPageSize = 100
+
+// migrateStorageVersions rewrites the objects of the provider CRDs that are still persisted in versions other than the
+// current storage version, e.g. after an upgrade changing the storage version; this protects from data loss
+// when the old versions are dropped by a later release.
+func (u *providerUpgrader) migrateStorageVersions(components repository.Components) error {
+	crdGroupKind := apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinition").GroupKind()
+	for _, o := range components.SharedObjs() {
+		if o.GroupVersionKind().GroupKind() != crdGroupKind {
+			continue
+		}
+
+		crd, err := u.getStorageVersionMigration(o.GetName())
+		if err != nil {
+			return err
+		}
+		if crd == nil {
+			continue
+		}
+
+		if err := u.migrateStorageVersion(crd); err != nil {
+			return errors.Wrapf(err, "failed to migrate the objects of the %q CustomResourceDefinition to the storage version", crd.Name)
+		}
+	}
+	return nil
+}
+
+// getStorageVersionMigration returns a CRD if some of its objects could be persisted in versions other than the
+// storage version, as reported by status.storedVersions; otherwise it returns nil.
+func (u *providerUpgrader) getStorageVersionMigration(name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+	c, err := u.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, crd); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the %q CustomResourceDefinition", name)
+	}
+
+	storageVersion := getStorageVersion(crd)
+	if storageVersion == "" {
+		return nil, errors.Errorf("the %q CustomResourceDefinition does not define a storage version", name)
+	}
+	if len(crd.Status.StoredVersions) == 1 && crd.Status.StoredVersions[0] == storageVersion {
+		return nil, nil
+	}
+	return crd, nil
+}
+
+// migrateStorageVersion rewrites all the objects of a CRD, so the API server persists them in the storage version,
+// and then removes the old versions from the CRD status.storedVersions.
+func (u *providerUpgrader) migrateStorageVersion(crd *apiextensionsv1.CustomResourceDefinition) error {
+	log := logf.Log
+
+	storageVersion := getStorageVersion(crd)
+	log.Info("Migrating objects to the storage version", "CustomResourceDefinition", crd.Name, "StoredVersions", crd.Status.StoredVersions, "StorageVersion", storageVersion)
+
+	gvk := schema.GroupVersionKind{
+		Group:   crd.Spec.Group,
+		Version: storageVersion,
+		Kind:    crd.Spec.Names.ListKind,
+	}
+
+	return retryWithExponentialBackoff(newWriteBackoff(), func() error {
+		c, err := u.proxy.NewClient()
+		if err != nil {
+			return err
+		}
+
+		migrated := 0
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk)
+		for {
+			if err := c.List(ctx, list, client.Limit(storageVersionMigrationPageSize), client.Continue(list.GetContinue())); err != nil {
+				return errors.Wrapf(err, "failed to list %q objects", crd.Spec.Names.Kind)
+			}
+
+			for i := range list.Items {
+				// An update without changes is enough for the API server to persist the object in the storage version.
+				// Nb. If the object was changed or deleted in the meantime, it is already persisted in the storage version or gone.
+				if err := c.Update(ctx, &list.Items[i]); err != nil && !apierrors.IsConflict(err) && !apierrors.IsNotFound(err) {
+					return errors.Wrapf(err, "failed to migrate %s %s/%s", crd.Spec.Names.Kind, list.Items[i].GetNamespace(), list.Items[i].GetName())
+				}
+				migrated++
+			}
+
+			if list.GetContinue() == "" {
+				break
+			}
+		}
+
+		// All the objects are now persisted in the storage version, so the old versions can be removed from the CRD status.
+		current := &apiextensionsv1.CustomResourceDefinition{}
+		if err := c.Get(ctx, client.ObjectKey{Name: crd.Name}, current); err != nil {
+			return errors.Wrapf(err, "failed to get the %q CustomResourceDefinition", crd.Name)
+		}
+		if getStorageVersion(current) != storageVersion {
+			return errors.Errorf("the storage version of the %q CustomResourceDefinition changed during the migration", crd.Name)
+		}
+		current.Status.StoredVersions = []string{storageVersion}
+		if err := c.Status().Update(ctx, current); err != nil {
+			return errors.Wrapf(err, "failed to update the stored versions of the %q CustomResourceDefinition", crd.Name)
+		}
+
+		log.V(1).Info("Objects migrated to the storage version", "CustomResourceDefinition", crd.Name, "Count", migrated)
+		return nil
+	})
+}
+
+// getStorageVersion returns the storage version of a CRD.
+func getStorageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			return v.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func providersCRD(storedVersions ...string) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiextensionsv1.SchemeGroupVersion.String(),
+			Kind:       "CustomResourceDefinition",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "providers.clusterctl.cluster.x-k8s.io",
+		},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: clusterctlv1.GroupVersion.Group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Kind:     "Provider",
+				ListKind: "ProviderList",
+			},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha2", Served: true},
+				{Name: clusterctlv1.GroupVersion.Version, Served: true, Storage: true},
+			},
+		},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{
+			StoredVersions: storedVersions,
+		},
+	}
+}
+
+func Test_providerUpgrader_getStorageVersionMigration(t *testing.T) {
+	tests := []struct {
+		name          string
+		crd           *apiextensionsv1.CustomResourceDefinition
+		wantMigration bool
+		wantErr       bool
+	}{
+		{
+			name:          "no migration if objects are stored only in the storage version",
+			crd:           providersCRD("v1alpha3"),
+			wantMigration: false,
+		},
+		{
+			name:          "migration if objects can be stored in an old version",
+			crd:           providersCRD("v1alpha2", "v1alpha3"),
+			wantMigration: true,
+		},
+		{
+			name: "fails if the CRD does not define a storage version",
+			crd: func() *apiextensionsv1.CustomResourceDefinition {
+				crd := providersCRD("v1alpha2")
+				crd.Spec.Versions[1].Storage = false
+				return crd
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			u := &providerUpgrader{proxy: test.NewFakeProxy().WithObjs(tt.crd)}
+			got, err := u.getStorageVersionMigration(tt.crd.Name)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got != nil).To(Equal(tt.wantMigration))
+		})
+	}
+}
+
+func Test_providerUpgrader_migrateStorageVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	proxy := test.NewFakeProxy().
+		WithObjs(providersCRD("v1alpha2", "v1alpha3")).
+		WithProviderInventory("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", "").
+		WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", "")
+	u := &providerUpgrader{proxy: proxy}
+
+	crd, err := u.getStorageVersionMigration("providers.clusterctl.cluster.x-k8s.io")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(crd).NotTo(BeNil())
+
+	c, err := proxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+	before := &clusterctlv1.ProviderList{}
+	g.Expect(c.List(ctx, before)).To(Succeed())
+
+	g.Expect(u.migrateStorageVersion(crd)).To(Succeed())
+
+	// All the objects are rewritten.
+	for i := range before.Items {
+		after := &unstructured.Unstructured{}
+		after.SetGroupVersionKind(clusterctlv1.GroupVersion.WithKind("Provider"))
+		g.Expect(c.Get(ctx, client.ObjectKey{Namespace: before.Items[i].Namespace, Name: before.Items[i].Name}, after)).To(Succeed())
+		g.Expect(after.GetResourceVersion()).NotTo(Equal(before.Items[i].ResourceVersion))
+	}
+
+	// Only the storage version is recorded as stored version.
+	updated := &apiextensionsv1.CustomResourceDefinition{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: crd.Name}, updated)).To(Succeed())
+	g.Expect(updated.Status.StoredVersions).To(Equal([]string{"v1alpha3"}))
+
+	// Once migrated, no further migrations are required.
+	crd, err = u.getStorageVersionMigration("providers.clusterctl.cluster.x-k8s.io")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(crd).To(BeNil())
+}
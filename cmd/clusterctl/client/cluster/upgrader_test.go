@@ -478,6 +478,205 @@ func Test_providerUpgrader_Plan(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Single Management group, no multi-tenancy, upgrade across two contracts",
+			fields: fields{
+				// config for two providers
+				reader: test.NewFakeReader().
+					WithProvider("cluster-api", clusterctlv1.CoreProviderType, "https://somewhere.com").
+					WithProvider("infra", clusterctlv1.InfrastructureProviderType, "https://somewhere.com"),
+				// two provider repositories, each with a new version for the v1alpha3 contract and a new version for the v1alpha4 contract
+				repository: map[string]repository.Repository{
+					"cluster-api": test.NewFakeRepository().
+						WithVersions("v1.0.0", "v2.0.0", "v3.0.0").
+						WithMetadata("v3.0.0", &clusterctlv1.Metadata{
+							ReleaseSeries: []clusterctlv1.ReleaseSeries{
+								{Major: 1, Minor: 0, Contract: "v1alpha2"},
+								{Major: 2, Minor: 0, Contract: "v1alpha3"},
+								{Major: 3, Minor: 0, Contract: "v1alpha4"},
+							},
+						}),
+					"infrastructure-infra": test.NewFakeRepository().
+						WithVersions("v2.0.0", "v3.0.0", "v4.0.0").
+						WithMetadata("v4.0.0", &clusterctlv1.Metadata{
+							ReleaseSeries: []clusterctlv1.ReleaseSeries{
+								{Major: 2, Minor: 0, Contract: "v1alpha2"},
+								{Major: 3, Minor: 0, Contract: "v1alpha3"},
+								{Major: 4, Minor: 0, Contract: "v1alpha4"},
+							},
+						}),
+				},
+				// two providers existing in the cluster, in the v1alpha2 contract
+				proxy: test.NewFakeProxy().
+					WithProviderInventory("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", "").
+					WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+			},
+			want: []UpgradePlan{
+				{ // one upgrade plan for the current v1alpha2 contract, with no new releases
+					Contract:     "v1alpha2",
+					CoreProvider: fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""),
+					Providers: []UpgradeItem{
+						{
+							Provider:    fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""),
+							NextVersion: "",
+						},
+						{
+							Provider:    fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+							NextVersion: "",
+						},
+					},
+				},
+				{ // one upgrade plan with the latest releases in the v1alpha3 contract
+					Contract:     "v1alpha3",
+					CoreProvider: fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""),
+					Providers: []UpgradeItem{
+						{
+							Provider:    fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""),
+							NextVersion: "v2.0.0",
+						},
+						{
+							Provider:    fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+							NextVersion: "v3.0.0",
+						},
+					},
+				},
+				{ // one upgrade plan with the latest releases in the v1alpha4 contract, going through the v1alpha3 contract
+					Contract:     "v1alpha4",
+					CoreProvider: fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""),
+					Providers: []UpgradeItem{
+						{
+							Provider:    fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""),
+							NextVersion: "v3.0.0",
+						},
+						{
+							Provider:    fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+							NextVersion: "v4.0.0",
+						},
+					},
+					IntermediateSteps: []UpgradeStep{
+						{
+							Contract: "v1alpha3",
+							Providers: []UpgradeItem{
+								{
+									Provider:    fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""),
+									NextVersion: "v2.0.0",
+								},
+								{
+									Provider:    fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+									NextVersion: "v3.0.0",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Single Management group, no multi-tenancy, upgrade across two contracts but a provider has no release for the intermediate contract",
+			fields: fields{
+				// config for two providers
+				reader: test.NewFakeReader().
+					WithProvider("cluster-api", clusterctlv1.CoreProviderType, "https://somewhere.com").
+					WithProvider("infra", clusterctlv1.InfrastructureProviderType, "https://somewhere.com"),
+				repository: map[string]repository.Repository{
+					"cluster-api": test.NewFakeRepository().
+						WithVersions("v1.0.0", "v2.0.0", "v3.0.0").
+						WithMetadata("v3.0.0", &clusterctlv1.Metadata{
+							ReleaseSeries: []clusterctlv1.ReleaseSeries{
+								{Major: 1, Minor: 0, Contract: "v1alpha2"},
+								{Major: 2, Minor: 0, Contract: "v1alpha3"},
+								{Major: 3, Minor: 0, Contract: "v1alpha4"},
+							},
+						}),
+					"infrastructure-infra": test.NewFakeRepository().
+						WithVersions("v2.0.0", "v4.0.0"). // no v1alpha3 release available for the infra provider
+						WithMetadata("v4.0.0", &clusterctlv1.Metadata{
+							ReleaseSeries: []clusterctlv1.ReleaseSeries{
+								{Major: 2, Minor: 0, Contract: "v1alpha2"},
+								{Major: 4, Minor: 0, Contract: "v1alpha4"},
+							},
+						}),
+				},
+				// two providers existing in the cluster, in the v1alpha2 contract
+				proxy: test.NewFakeProxy().
+					WithProviderInventory("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", "").
+					WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+			},
+			want: []UpgradePlan{
+				{ // one upgrade plan for the current v1alpha2 contract, with no new releases
+					Contract:     "v1alpha2",
+					CoreProvider: fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""),
+					Providers: []UpgradeItem{
+						{
+							Provider:    fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""),
+							NextVersion: "",
+						},
+						{
+							Provider:    fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+							NextVersion: "",
+						},
+					},
+				},
+				// the upgrade plans for the v1alpha3 and the v1alpha4 contract should be dropped because the infra provider
+				// can't upgrade to the v1alpha3 contract
+			},
+			wantErr: false,
+		},
+		{
+			name: "Single Management group, warns about deprecated and end of life versions",
+			fields: fields{
+				// config for two providers
+				reader: test.NewFakeReader().
+					WithProvider("cluster-api", clusterctlv1.CoreProviderType, "https://somewhere.com").
+					WithProvider("infra", clusterctlv1.InfrastructureProviderType, "https://somewhere.com"),
+				// two provider repositories, the current release series of the core provider reached its end of life
+				// and the next release series of the infra provider is deprecated
+				repository: map[string]repository.Repository{
+					"cluster-api": test.NewFakeRepository().
+						WithVersions("v1.0.0", "v1.1.0").
+						WithMetadata("v1.1.0", &clusterctlv1.Metadata{
+							ReleaseSeries: []clusterctlv1.ReleaseSeries{
+								{Major: 1, Minor: 0, Contract: "v1alpha3", Deprecated: true, EndOfLife: "2000-01-01"},
+								{Major: 1, Minor: 1, Contract: "v1alpha3"},
+							},
+						}),
+					"infrastructure-infra": test.NewFakeRepository().
+						WithVersions("v2.0.0", "v2.0.1").
+						WithMetadata("v2.0.1", &clusterctlv1.Metadata{
+							ReleaseSeries: []clusterctlv1.ReleaseSeries{
+								{Major: 2, Minor: 0, Contract: "v1alpha3", Deprecated: true},
+							},
+						}),
+				},
+				// two providers existing in the cluster
+				proxy: test.NewFakeProxy().
+					WithProviderInventory("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", "").
+					WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+			},
+			want: []UpgradePlan{
+				{ // one upgrade plan with the latest releases the v1alpha3 contract
+					Contract:     "v1alpha3",
+					CoreProvider: fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""),
+					Providers: []UpgradeItem{
+						{
+							Provider:    fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", ""),
+							NextVersion: "v1.1.0",
+						},
+						{
+							Provider:    fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+							NextVersion: "v2.0.1",
+						},
+					},
+					Warnings: []string{
+						"cluster-api-system/cluster-api v1.0.0 is no longer supported: the v1.0 release series reached its end of life on 2000-01-01",
+						"infra-system/infrastructure-infra v2.0.0 is deprecated: the v2.0 release series is deprecated",
+						"infra-system/infrastructure-infra v2.0.1 is deprecated: the v2.0 release series is deprecated",
+					},
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -486,6 +685,7 @@ func Test_providerUpgrader_Plan(t *testing.T) {
 			configClient, _ := config.New("", config.InjectReader(tt.fields.reader))
 
 			u := &providerUpgrader{
+				proxy:        tt.fields.proxy,
 				configClient: configClient,
 				repositoryClientFactory: func(provider config.Provider, configClient config.Client, options ...repository.Option) (repository.Client, error) {
 					return repository.New(provider, configClient, repository.InjectRepository(tt.fields.repository[provider.ManifestLabel()]))
@@ -792,3 +992,73 @@ func Test_providerUpgrader_createCustomPlan(t *testing.T) {
 		})
 	}
 }
+
+func Test_providerUpgrader_ApplyPlan_AcrossContracts(t *testing.T) {
+	g := NewWithT(t)
+
+	configClient, _ := config.New("", config.InjectReader(test.NewFakeReader().
+		WithProvider("cluster-api", clusterctlv1.CoreProviderType, "https://somewhere.com").
+		WithProvider("infra", clusterctlv1.InfrastructureProviderType, "https://somewhere.com")))
+
+	// two provider repositories, each with a release for the v1alpha2, v1alpha3 and v1alpha4 contract
+	repositories := map[string]repository.Repository{
+		"cluster-api": test.NewFakeRepository().
+			WithPaths("root", "components.yaml").
+			WithVersions("v1.0.0", "v2.0.0", "v3.0.0").
+			WithMetadata("v3.0.0", &clusterctlv1.Metadata{
+				ReleaseSeries: []clusterctlv1.ReleaseSeries{
+					{Major: 1, Minor: 0, Contract: "v1alpha2"},
+					{Major: 2, Minor: 0, Contract: "v1alpha3"},
+					{Major: 3, Minor: 0, Contract: "v1alpha4"},
+				},
+			}).
+			WithFile("v2.0.0", "components.yaml", upgradeComponentsYAML).
+			WithFile("v3.0.0", "components.yaml", upgradeComponentsYAML),
+		"infrastructure-infra": test.NewFakeRepository().
+			WithPaths("root", "components.yaml").
+			WithVersions("v2.0.0", "v3.0.0", "v4.0.0").
+			WithMetadata("v4.0.0", &clusterctlv1.Metadata{
+				ReleaseSeries: []clusterctlv1.ReleaseSeries{
+					{Major: 2, Minor: 0, Contract: "v1alpha2"},
+					{Major: 3, Minor: 0, Contract: "v1alpha3"},
+					{Major: 4, Minor: 0, Contract: "v1alpha4"},
+				},
+			}).
+			WithFile("v3.0.0", "components.yaml", upgradeComponentsYAML).
+			WithFile("v4.0.0", "components.yaml", upgradeComponentsYAML),
+	}
+
+	// two providers existing in the cluster, in the v1alpha2 contract
+	proxy := test.NewFakeProxy().
+		WithProviderInventory("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", "").
+		WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", "")
+	components := &fakeComponentsClient{}
+
+	u := newProviderUpgrader(
+		proxy,
+		configClient,
+		func(provider config.Provider, configClient config.Client, options ...repository.Option) (repository.Client, error) {
+			return repository.New(provider, configClient, repository.InjectRepository(repositories[provider.ManifestLabel()]))
+		},
+		newInventoryClient(proxy, nil),
+		components,
+	)
+
+	coreProvider := fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system", "")
+	g.Expect(u.ApplyPlan(coreProvider, "v1alpha4", UpgradeOptions{})).To(Succeed())
+
+	// Each provider is upgraded to the v1alpha3 contract first, and then to the v1alpha4 contract.
+	g.Expect(components.deleted).To(Equal([]string{
+		"cluster-api-system/cluster-api", "infra-system/infrastructure-infra",
+		"cluster-api-system/cluster-api", "infra-system/infrastructure-infra",
+	}))
+
+	providers, err := u.providerInventory.List()
+	g.Expect(err).NotTo(HaveOccurred())
+	versions := map[string]string{}
+	for _, p := range providers.Items {
+		versions[p.InstanceName()] = p.Version
+	}
+	g.Expect(versions).To(HaveKeyWithValue("cluster-api-system/cluster-api", "v3.0.0"))
+	g.Expect(versions).To(HaveKeyWithValue("infra-system/infrastructure-infra", "v4.0.0"))
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultWebhookStartupTime is the startup time of a webhook server assumed when there are no ready webhook Pods
+	// to measure it from.
+	defaultWebhookStartupTime = 30 * time.Second
+
+	// webhookComponentsReplaceTime is the time assumed for deleting the current provider components and creating the
+	// new ones during an upgrade, before the new webhook Pods are created.
+	webhookComponentsReplaceTime = 10 * time.Second
+)
+
+// WebhookDowntime is an estimate of the time the webhooks of a provider are not available during its upgrade, i.e.
+// from the deletion of the current provider components to the new webhook servers being ready; in the meantime, the
+// API server rejects the creation and update of the objects validated, defaulted or converted by the webhooks.
+type WebhookDowntime struct {
+	// Provider being upgraded.
+	Provider clusterctlv1.Provider
+
+	// Services called by the admission and conversion webhooks of the provider, in the namespace/name:port form.
+	Services []string
+
+	// Estimate of the downtime, based on the time the current webhook Pods took to become ready.
+	Estimate time.Duration
+}
+
+// getWebhookDowntimes returns the estimated webhook downtime for each provider in the upgrade items with a next
+// version and with admission or conversion webhooks.
+func (u *providerUpgrader) getWebhookDowntimes(upgradeItems []UpgradeItem) ([]WebhookDowntime, error) {
+	c, err := u.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []WebhookDowntime
+	for _, item := range upgradeItems {
+		if item.NextVersion == "" {
+			continue
+		}
+
+		services, err := providerWebhookServices(c, item.Provider)
+		if err != nil {
+			return nil, err
+		}
+		if len(services) == 0 {
+			continue
+		}
+
+		downtime := WebhookDowntime{
+			Provider: item.Provider,
+		}
+		startup := time.Duration(0)
+		for _, s := range services {
+			downtime.Services = append(downtime.Services, s.String())
+			t, err := webhookStartupTime(c, s)
+			if err != nil {
+				return nil, err
+			}
+			if t > startup {
+				startup = t
+			}
+		}
+		downtime.Estimate = webhookComponentsReplaceTime + startup
+		ret = append(ret, downtime)
+	}
+	return ret, nil
+}
+
+// providerWebhookServices returns the services called by the admission webhooks and by the conversion webhooks of
+// the CRDs of a provider installed in the cluster.
+func providerWebhookServices(c client.Client, provider clusterctlv1.Provider) ([]webhookService, error) {
+	selector := client.MatchingLabels{clusterv1.ProviderLabelName: provider.ManifestLabel()}
+
+	var objs []unstructured.Unstructured
+	for _, kind := range []string{"MutatingWebhookConfigurationList", "ValidatingWebhookConfigurationList"} {
+		list := &unstructured.UnstructuredList{}
+		list.SetAPIVersion("admissionregistration.k8s.io/v1beta1")
+		list.SetKind(kind)
+		if err := c.List(ctx, list, selector); err != nil {
+			return nil, errors.Wrapf(err, "failed to list the %s of the %s provider", kind, provider.InstanceName())
+		}
+		objs = append(objs, list.Items...)
+	}
+	admission, err := admissionWebhookServices(objs)
+	if err != nil {
+		return nil, err
+	}
+
+	services := map[webhookService]struct{}{}
+	for _, s := range admission {
+		services[s] = struct{}{}
+	}
+
+	crds := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crds, selector); err != nil {
+		return nil, errors.Wrapf(err, "failed to list the CRDs of the %s provider", provider.InstanceName())
+	}
+	for _, crd := range crds.Items {
+		conversion := crd.Spec.Conversion
+		if conversion == nil || conversion.Strategy != apiextensionsv1.WebhookConverter || conversion.Webhook == nil ||
+			conversion.Webhook.ClientConfig == nil || conversion.Webhook.ClientConfig.Service == nil {
+			continue
+		}
+		service := conversion.Webhook.ClientConfig.Service
+		s := webhookService{Namespace: service.Namespace, Name: service.Name, Port: defaultWebhookServicePort}
+		if service.Port != nil {
+			s.Port = int64(*service.Port)
+		}
+		services[s] = struct{}{}
+	}
+
+	return sortedWebhookServices(services), nil
+}
+
+// webhookStartupTime returns the longest time the Pods backing a webhook service took from their creation to being
+// ready, or defaultWebhookStartupTime if there are no ready Pods.
+func webhookStartupTime(c client.Client, s webhookService) (time.Duration, error) {
+	service := &corev1.Service{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: s.Name}, service); err != nil {
+		if apierrors.IsNotFound(err) {
+			return defaultWebhookStartupTime, nil
+		}
+		return 0, errors.Wrapf(err, "failed to get the webhook Service %s/%s", s.Namespace, s.Name)
+	}
+	if len(service.Spec.Selector) == 0 {
+		return defaultWebhookStartupTime, nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(s.Namespace), client.MatchingLabels(service.Spec.Selector)); err != nil {
+		return 0, errors.Wrapf(err, "failed to list the Pods of the webhook Service %s/%s", s.Namespace, s.Name)
+	}
+
+	startup := time.Duration(0)
+	for _, pod := range pods.Items {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type != corev1.PodReady || condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			if t := condition.LastTransitionTime.Sub(pod.CreationTimestamp.Time); t > startup {
+				startup = t
+			}
+		}
+	}
+	if startup == 0 {
+		return defaultWebhookStartupTime, nil
+	}
+	return startup, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_providerUpgrader_getWebhookDowntimes(t *testing.T) {
+	g := NewWithT(t)
+
+	core := fakeProvider("cluster-api", clusterctlv1.CoreProviderType, "v0.3.0", "capi-system", "")
+	bootstrap := fakeProvider("kubeadm", clusterctlv1.BootstrapProviderType, "v0.3.0", "capi-kubeadm-bootstrap-system", "")
+	infra := fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v0.3.0", "infra-system", "")
+
+	created := metav1.NewTime(time.Now().Add(-time.Hour))
+	webhookPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "capi-webhook-system",
+			Name:              "capi-controller-manager-1",
+			Labels:            map[string]string{"control-plane": "capi-controller-manager"},
+			CreationTimestamp: created,
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(created.Add(20 * time.Second))},
+			},
+		},
+	}
+	webhookService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "capi-webhook-system", Name: "capi-webhook-service"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"control-plane": "capi-controller-manager"},
+		},
+	}
+	coreWebhooks := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "capi-validating-webhook-configuration",
+			Labels: map[string]string{clusterv1.ProviderLabelName: core.ManifestLabel()},
+		},
+		Webhooks: []admissionregistrationv1beta1.ValidatingWebhook{
+			{
+				Name: "validation.cluster.cluster.x-k8s.io",
+				ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{
+					Service: &admissionregistrationv1beta1.ServiceReference{Namespace: "capi-webhook-system", Name: "capi-webhook-service"},
+				},
+			},
+		},
+	}
+	infraPort := int32(9443)
+	infraCRD := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "infraclusters.infrastructure.cluster.x-k8s.io",
+			Labels: map[string]string{clusterv1.ProviderLabelName: infra.ManifestLabel()},
+		},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Conversion: &apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.WebhookConverter,
+				Webhook: &apiextensionsv1.WebhookConversion{
+					ClientConfig: &apiextensionsv1.WebhookClientConfig{
+						Service: &apiextensionsv1.ServiceReference{Namespace: "infra-system", Name: "infra-webhook-service", Port: &infraPort},
+					},
+				},
+			},
+		},
+	}
+
+	proxy := test.NewFakeProxy().WithObjs(webhookPod, webhookService, coreWebhooks, infraCRD)
+	u := newProviderUpgrader(proxy, nil, nil, nil, nil)
+
+	got, err := u.getWebhookDowntimes([]UpgradeItem{
+		{Provider: core, NextVersion: "v0.3.1"},
+		// Providers without webhooks are ignored.
+		{Provider: bootstrap, NextVersion: "v0.3.1"},
+		{Provider: infra, NextVersion: "v0.3.1"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal([]WebhookDowntime{
+		{
+			Provider: core,
+			Services: []string{"capi-webhook-system/capi-webhook-service:443"},
+			Estimate: webhookComponentsReplaceTime + 20*time.Second,
+		},
+		{
+			// The webhook service does not exist, so the default startup time is assumed.
+			Provider: infra,
+			Services: []string{"infra-system/infra-webhook-service:9443"},
+			Estimate: webhookComponentsReplaceTime + defaultWebhookStartupTime,
+		},
+	}))
+
+	// Providers not being upgraded are ignored.
+	got, err = u.getWebhookDowntimes([]UpgradeItem{{Provider: core}})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(BeEmpty())
+}
@@ -0,0 +1,325 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// maxWorkerVersionSkew is the maximum number of minor versions the kubelets can be older than the control plane,
+	// according to the Kubernetes version skew policy.
+	maxWorkerVersionSkew = 2
+
+	// DefaultWorkloadClusterUpgradeTimeout is the default time to wait for the upgrade of the control plane
+	// or of a MachineDeployment to complete.
+	DefaultWorkloadClusterUpgradeTimeout = 30 * time.Minute
+
+	waitWorkloadClusterUpgradeInterval = 10 * time.Second
+)
+
+// WorkloadClusterUpgradeItem defines an object of a workload cluster to be upgraded to a new Kubernetes version.
+type WorkloadClusterUpgradeItem struct {
+	// Kind of the object, e.g. KubeadmControlPlane or MachineDeployment.
+	Kind string
+
+	// Name of the object.
+	Name string
+
+	// CurrentVersion is the Kubernetes version of the object before the upgrade.
+	CurrentVersion string
+
+	// NextVersion is the Kubernetes version the object is upgraded to.
+	NextVersion string
+}
+
+// WorkloadClusterUpgradePlan defines the objects of a workload cluster to be upgraded to a new Kubernetes version,
+// in order: first the control plane, then the MachineDeployments.
+type WorkloadClusterUpgradePlan struct {
+	// Namespace of the Cluster.
+	Namespace string
+
+	// ClusterName is the name of the Cluster.
+	ClusterName string
+
+	// KubernetesVersion is the Kubernetes version the Cluster is upgraded to.
+	KubernetesVersion string
+
+	// ControlPlane to be upgraded, if any.
+	ControlPlane *WorkloadClusterUpgradeItem
+
+	// MachineDeployments to be upgraded.
+	MachineDeployments []WorkloadClusterUpgradeItem
+}
+
+// IsEmpty returns true if the plan has nothing to upgrade.
+func (p *WorkloadClusterUpgradePlan) IsEmpty() bool {
+	return p.ControlPlane == nil && len(p.MachineDeployments) == 0
+}
+
+// WorkloadClusterUpgradeOptions carries the options for applying a WorkloadClusterUpgradePlan.
+type WorkloadClusterUpgradeOptions struct {
+	// WaitForCompletion instructs the upgrader to wait for the MachineDeployments to complete the upgrade.
+	// Nb. The upgrader always waits for the control plane to complete the upgrade before upgrading the
+	// MachineDeployments, so the version skew policy is honored.
+	WaitForCompletion bool
+
+	// Timeout for the upgrade of the control plane and of each MachineDeployment to complete.
+	// If unspecified, DefaultWorkloadClusterUpgradeTimeout is used.
+	Timeout time.Duration
+}
+
+// WorkloadClusterUpgrader defines methods for upgrading the Kubernetes version of the workload clusters
+// using a KubeadmControlPlane.
+type WorkloadClusterUpgrader interface {
+	// Plan returns the objects of a workload cluster to be upgraded to a Kubernetes version, checking that
+	// the upgrade honors the Kubernetes version skew policy.
+	Plan(namespace, clusterName, kubernetesVersion string) (*WorkloadClusterUpgradePlan, error)
+
+	// Apply upgrades the objects in the plan, first the control plane, then the MachineDeployments.
+	Apply(plan *WorkloadClusterUpgradePlan, options WorkloadClusterUpgradeOptions) error
+}
+
+// workloadClusterUpgrader implements WorkloadClusterUpgrader.
+type workloadClusterUpgrader struct {
+	proxy               Proxy
+	pollImmediateWaiter PollImmediateWaiter
+}
+
+var _ WorkloadClusterUpgrader = &workloadClusterUpgrader{}
+
+func newWorkloadClusterUpgrader(proxy Proxy, pollImmediateWaiter PollImmediateWaiter) *workloadClusterUpgrader {
+	return &workloadClusterUpgrader{
+		proxy:               proxy,
+		pollImmediateWaiter: pollImmediateWaiter,
+	}
+}
+
+func (u *workloadClusterUpgrader) Plan(namespace, clusterName, kubernetesVersion string) (*WorkloadClusterUpgradePlan, error) {
+	targetVersion, err := version.ParseSemantic(kubernetesVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid Kubernetes version %q", kubernetesVersion)
+	}
+
+	c, err := u.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.TODO()
+	cluster := &clusterv1.Cluster{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: clusterName}, cluster); err != nil {
+		return nil, errors.Wrapf(err, "failed to get Cluster %s/%s", namespace, clusterName)
+	}
+
+	plan := &WorkloadClusterUpgradePlan{
+		Namespace:         namespace,
+		ClusterName:       clusterName,
+		KubernetesVersion: kubernetesVersion,
+	}
+
+	// Checks the control plane.
+	kcp, err := getKubeadmControlPlane(c, cluster)
+	if err != nil {
+		return nil, err
+	}
+	controlPlaneVersion, err := version.ParseSemantic(kcp.Spec.Version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid Kubernetes version %q for KubeadmControlPlane %s/%s", kcp.Spec.Version, kcp.Namespace, kcp.Name)
+	}
+	if targetVersion.LessThan(controlPlaneVersion) {
+		return nil, errors.Errorf("the KubeadmControlPlane %s/%s is at version %s; downgrades are not supported", kcp.Namespace, kcp.Name, kcp.Spec.Version)
+	}
+	if targetVersion.Major() != controlPlaneVersion.Major() || targetVersion.Minor() > controlPlaneVersion.Minor()+1 {
+		return nil, errors.Errorf("the KubeadmControlPlane %s/%s is at version %s; the control plane can be upgraded only one minor version at a time", kcp.Namespace, kcp.Name, kcp.Spec.Version)
+	}
+	if controlPlaneVersion.LessThan(targetVersion) {
+		plan.ControlPlane = &WorkloadClusterUpgradeItem{
+			Kind:           "KubeadmControlPlane",
+			Name:           kcp.Name,
+			CurrentVersion: kcp.Spec.Version,
+			NextVersion:    kubernetesVersion,
+		}
+	}
+
+	// Checks the MachineDeployments; the kubelets must not be newer than the control plane, nor older than
+	// the version skew policy allows while the control plane is upgraded.
+	machineDeployments := &clusterv1.MachineDeploymentList{}
+	if err := c.List(ctx, machineDeployments, client.InNamespace(namespace), client.MatchingLabels{clusterv1.ClusterLabelName: clusterName}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list MachineDeployments for Cluster %s/%s", namespace, clusterName)
+	}
+	sort.Slice(machineDeployments.Items, func(i, j int) bool {
+		return machineDeployments.Items[i].Name < machineDeployments.Items[j].Name
+	})
+	for _, md := range machineDeployments.Items {
+		if md.Spec.Template.Spec.Version == nil {
+			logf.Log.Info("Skipping MachineDeployment without a Kubernetes version", "MachineDeployment", md.Name)
+			continue
+		}
+		mdVersion, err := version.ParseSemantic(*md.Spec.Template.Spec.Version)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid Kubernetes version %q for MachineDeployment %s/%s", *md.Spec.Template.Spec.Version, md.Namespace, md.Name)
+		}
+		if targetVersion.LessThan(mdVersion) {
+			return nil, errors.Errorf("the MachineDeployment %s/%s is at version %s; downgrades are not supported", md.Namespace, md.Name, *md.Spec.Template.Spec.Version)
+		}
+		if targetVersion.Major() != mdVersion.Major() || targetVersion.Minor() > mdVersion.Minor()+maxWorkerVersionSkew {
+			return nil, errors.Errorf("the MachineDeployment %s/%s is at version %s; upgrading the control plane to %s violates the version skew policy, upgrade the MachineDeployment first", md.Namespace, md.Name, *md.Spec.Template.Spec.Version, kubernetesVersion)
+		}
+		if mdVersion.LessThan(targetVersion) {
+			plan.MachineDeployments = append(plan.MachineDeployments, WorkloadClusterUpgradeItem{
+				Kind:           "MachineDeployment",
+				Name:           md.Name,
+				CurrentVersion: *md.Spec.Template.Spec.Version,
+				NextVersion:    kubernetesVersion,
+			})
+		}
+	}
+
+	return plan, nil
+}
+
+func (u *workloadClusterUpgrader) Apply(plan *WorkloadClusterUpgradePlan, options WorkloadClusterUpgradeOptions) error {
+	log := logf.Log
+
+	c, err := u.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = DefaultWorkloadClusterUpgradeTimeout
+	}
+
+	ctx := context.TODO()
+	if plan.ControlPlane != nil {
+		log.Info("Upgrading the control plane", "KubeadmControlPlane", plan.ControlPlane.Name, "Version", plan.ControlPlane.NextVersion)
+		key := client.ObjectKey{Namespace: plan.Namespace, Name: plan.ControlPlane.Name}
+		kcp := &controlplanev1.KubeadmControlPlane{}
+		if err := c.Get(ctx, key, kcp); err != nil {
+			return errors.Wrapf(err, "failed to get KubeadmControlPlane %s/%s", key.Namespace, key.Name)
+		}
+		patch := client.MergeFrom(kcp.DeepCopy())
+		kcp.Spec.Version = plan.ControlPlane.NextVersion
+		if err := c.Patch(ctx, kcp, patch); err != nil {
+			return errors.Wrapf(err, "failed to patch KubeadmControlPlane %s/%s", key.Namespace, key.Name)
+		}
+
+		// The control plane must complete the upgrade before the kubelets are upgraded.
+		if len(plan.MachineDeployments) > 0 || options.WaitForCompletion {
+			log.Info("Waiting for the control plane upgrade to complete", "KubeadmControlPlane", plan.ControlPlane.Name)
+			if err := u.pollImmediateWaiter(waitWorkloadClusterUpgradeInterval, timeout, func() (bool, error) {
+				kcp := &controlplanev1.KubeadmControlPlane{}
+				if err := c.Get(ctx, key, kcp); err != nil {
+					return false, nil
+				}
+				return isKubeadmControlPlaneUpgraded(kcp), nil
+			}); err != nil {
+				return errors.Wrapf(err, "failed to wait for the upgrade of KubeadmControlPlane %s/%s", key.Namespace, key.Name)
+			}
+		}
+	}
+
+	for _, item := range plan.MachineDeployments {
+		log.Info("Upgrading the MachineDeployment", "MachineDeployment", item.Name, "Version", item.NextVersion)
+		key := client.ObjectKey{Namespace: plan.Namespace, Name: item.Name}
+		md := &clusterv1.MachineDeployment{}
+		if err := c.Get(ctx, key, md); err != nil {
+			return errors.Wrapf(err, "failed to get MachineDeployment %s/%s", key.Namespace, key.Name)
+		}
+		patch := client.MergeFrom(md.DeepCopy())
+		nextVersion := item.NextVersion
+		md.Spec.Template.Spec.Version = &nextVersion
+		if err := c.Patch(ctx, md, patch); err != nil {
+			return errors.Wrapf(err, "failed to patch MachineDeployment %s/%s", key.Namespace, key.Name)
+		}
+	}
+
+	if !options.WaitForCompletion {
+		return nil
+	}
+	for _, item := range plan.MachineDeployments {
+		log.Info("Waiting for the MachineDeployment upgrade to complete", "MachineDeployment", item.Name)
+		key := client.ObjectKey{Namespace: plan.Namespace, Name: item.Name}
+		if err := u.pollImmediateWaiter(waitWorkloadClusterUpgradeInterval, timeout, func() (bool, error) {
+			md := &clusterv1.MachineDeployment{}
+			if err := c.Get(ctx, key, md); err != nil {
+				return false, nil
+			}
+			return isMachineDeploymentUpgraded(md), nil
+		}); err != nil {
+			return errors.Wrapf(err, "failed to wait for the upgrade of MachineDeployment %s/%s", key.Namespace, key.Name)
+		}
+	}
+	return nil
+}
+
+// getKubeadmControlPlane returns the KubeadmControlPlane referenced by the Cluster.
+func getKubeadmControlPlane(c client.Client, cluster *clusterv1.Cluster) (*controlplanev1.KubeadmControlPlane, error) {
+	ref := cluster.Spec.ControlPlaneRef
+	if ref == nil || ref.Kind != "KubeadmControlPlane" {
+		return nil, errors.Errorf("the Cluster %s/%s does not use a KubeadmControlPlane; only KubeadmControlPlane based clusters can be upgraded", cluster.Namespace, cluster.Name)
+	}
+
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = cluster.Namespace
+	}
+	kcp := &controlplanev1.KubeadmControlPlane{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: ref.Name}, kcp); err != nil {
+		return nil, errors.Wrapf(err, "failed to get KubeadmControlPlane %s/%s", namespace, ref.Name)
+	}
+	return kcp, nil
+}
+
+// isKubeadmControlPlaneUpgraded returns true if all the control plane machines are up to date and ready.
+func isKubeadmControlPlaneUpgraded(kcp *controlplanev1.KubeadmControlPlane) bool {
+	if kcp.Status.ObservedGeneration < kcp.Generation {
+		return false
+	}
+	replicas := int32(1)
+	if kcp.Spec.Replicas != nil {
+		replicas = *kcp.Spec.Replicas
+	}
+	return kcp.Status.Replicas == replicas &&
+		kcp.Status.UpdatedReplicas == replicas &&
+		kcp.Status.ReadyReplicas == replicas
+}
+
+// isMachineDeploymentUpgraded returns true if all the machines of the MachineDeployment are up to date and available.
+func isMachineDeploymentUpgraded(md *clusterv1.MachineDeployment) bool {
+	if md.Status.ObservedGeneration < md.Generation {
+		return false
+	}
+	replicas := int32(1)
+	if md.Spec.Replicas != nil {
+		replicas = *md.Spec.Replicas
+	}
+	return md.Status.Replicas == replicas &&
+		md.Status.UpdatedReplicas == replicas &&
+		md.Status.AvailableReplicas == replicas
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_workloadClusterUpgrader_Plan(t *testing.T) {
+	tests := []struct {
+		name              string
+		objs              []runtime.Object
+		kubernetesVersion string
+		want              *WorkloadClusterUpgradePlan
+		wantErr           bool
+	}{
+		{
+			name:              "Upgrades the control plane and the MachineDeployments",
+			objs:              workloadClusterObjs("v1.17.3", "v1.17.3", "v1.16.0"),
+			kubernetesVersion: "v1.18.2",
+			want: &WorkloadClusterUpgradePlan{
+				Namespace:         "ns1",
+				ClusterName:       "cluster1",
+				KubernetesVersion: "v1.18.2",
+				ControlPlane:      &WorkloadClusterUpgradeItem{Kind: "KubeadmControlPlane", Name: "cluster1-control-plane", CurrentVersion: "v1.17.3", NextVersion: "v1.18.2"},
+				MachineDeployments: []WorkloadClusterUpgradeItem{
+					{Kind: "MachineDeployment", Name: "md0", CurrentVersion: "v1.17.3", NextVersion: "v1.18.2"},
+					{Kind: "MachineDeployment", Name: "md1", CurrentVersion: "v1.16.0", NextVersion: "v1.18.2"},
+				},
+			},
+		},
+		{
+			name:              "Upgrades only the MachineDeployments if the control plane is already upgraded",
+			objs:              workloadClusterObjs("v1.18.2", "v1.18.2", "v1.17.3"),
+			kubernetesVersion: "v1.18.2",
+			want: &WorkloadClusterUpgradePlan{
+				Namespace:         "ns1",
+				ClusterName:       "cluster1",
+				KubernetesVersion: "v1.18.2",
+				MachineDeployments: []WorkloadClusterUpgradeItem{
+					{Kind: "MachineDeployment", Name: "md1", CurrentVersion: "v1.17.3", NextVersion: "v1.18.2"},
+				},
+			},
+		},
+		{
+			name:              "Fails if the control plane skips a minor version",
+			objs:              workloadClusterObjs("v1.16.0", "v1.16.0", "v1.16.0"),
+			kubernetesVersion: "v1.18.2",
+			wantErr:           true,
+		},
+		{
+			name:              "Fails if the MachineDeployments violate the version skew policy",
+			objs:              workloadClusterObjs("v1.17.3", "v1.17.3", "v1.15.0"),
+			kubernetesVersion: "v1.18.2",
+			wantErr:           true,
+		},
+		{
+			name:              "Fails for downgrades",
+			objs:              workloadClusterObjs("v1.18.2", "v1.18.2", "v1.18.2"),
+			kubernetesVersion: "v1.17.3",
+			wantErr:           true,
+		},
+		{
+			name:              "Fails for clusters without a KubeadmControlPlane",
+			objs:              []runtime.Object{&clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "cluster1"}}},
+			kubernetesVersion: "v1.18.2",
+			wantErr:           true,
+		},
+		{
+			name:              "Fails for invalid versions",
+			objs:              workloadClusterObjs("v1.17.3", "v1.17.3", "v1.17.3"),
+			kubernetesVersion: "latest",
+			wantErr:           true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			u := newWorkloadClusterUpgrader(test.NewFakeProxy().WithObjs(tt.objs...), fakePollImmediateWaiter)
+			got, err := u.Plan("ns1", "cluster1", tt.kubernetesVersion)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_workloadClusterUpgrader_Apply(t *testing.T) {
+	g := NewWithT(t)
+
+	proxy := test.NewFakeProxy().WithObjs(workloadClusterObjs("v1.17.3", "v1.17.3", "v1.17.3")...)
+
+	// records the objects being waited for; the fake client does not roll out machines, so the objects
+	// are reported as up to date.
+	var waits []string
+	pollImmediateWaiter := func(interval, timeout time.Duration, condition wait.ConditionFunc) error {
+		done, err := condition()
+		if err != nil {
+			return err
+		}
+		if !done {
+			return errors.New("timed out")
+		}
+		waits = append(waits, timeout.String())
+		return nil
+	}
+
+	u := newWorkloadClusterUpgrader(proxy, pollImmediateWaiter)
+	plan, err := u.Plan("ns1", "cluster1", "v1.18.2")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(u.Apply(plan, WorkloadClusterUpgradeOptions{WaitForCompletion: true, Timeout: time.Minute})).To(Succeed())
+	g.Expect(waits).To(HaveLen(3))
+
+	c, err := proxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	kcp := &controlplanev1.KubeadmControlPlane{}
+	g.Expect(c.Get(context.TODO(), client.ObjectKey{Namespace: "ns1", Name: "cluster1-control-plane"}, kcp)).To(Succeed())
+	g.Expect(kcp.Spec.Version).To(Equal("v1.18.2"))
+
+	for _, name := range []string{"md0", "md1"} {
+		md := &clusterv1.MachineDeployment{}
+		g.Expect(c.Get(context.TODO(), client.ObjectKey{Namespace: "ns1", Name: name}, md)).To(Succeed())
+		g.Expect(md.Spec.Template.Spec.Version).To(Equal(pointer.StringPtr("v1.18.2")))
+	}
+}
+
+func Test_isKubeadmControlPlaneUpgraded(t *testing.T) {
+	g := NewWithT(t)
+
+	kcp := &controlplanev1.KubeadmControlPlane{
+		Spec:   controlplanev1.KubeadmControlPlaneSpec{Replicas: pointer.Int32Ptr(3)},
+		Status: controlplanev1.KubeadmControlPlaneStatus{Replicas: 4, UpdatedReplicas: 1, ReadyReplicas: 4},
+	}
+	g.Expect(isKubeadmControlPlaneUpgraded(kcp)).To(BeFalse())
+
+	kcp.Status = controlplanev1.KubeadmControlPlaneStatus{Replicas: 3, UpdatedReplicas: 3, ReadyReplicas: 3}
+	g.Expect(isKubeadmControlPlaneUpgraded(kcp)).To(BeTrue())
+
+	kcp.Generation = 2
+	kcp.Status.ObservedGeneration = 1
+	g.Expect(isKubeadmControlPlaneUpgraded(kcp)).To(BeFalse())
+}
+
+// workloadClusterObjs returns a Cluster with a KubeadmControlPlane and two MachineDeployments, all up to date
+// with the given Kubernetes versions.
+func workloadClusterObjs(controlPlaneVersion, md0Version, md1Version string) []runtime.Object {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "cluster1"},
+		Spec: clusterv1.ClusterSpec{
+			ControlPlaneRef: &corev1.ObjectReference{
+				APIVersion: controlplanev1.GroupVersion.String(),
+				Kind:       "KubeadmControlPlane",
+				Name:       "cluster1-control-plane",
+			},
+		},
+	}
+	kcp := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "cluster1-control-plane"},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{
+			Replicas: pointer.Int32Ptr(3),
+			Version:  controlPlaneVersion,
+		},
+		Status: controlplanev1.KubeadmControlPlaneStatus{Replicas: 3, UpdatedReplicas: 3, ReadyReplicas: 3},
+	}
+	objs := []runtime.Object{cluster, kcp}
+	for name, version := range map[string]string{"md0": md0Version, "md1": md1Version} {
+		objs = append(objs, &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      name,
+				Labels:    map[string]string{clusterv1.ClusterLabelName: "cluster1"},
+			},
+			Spec: clusterv1.MachineDeploymentSpec{
+				ClusterName: "cluster1",
+				Replicas:    pointer.Int32Ptr(2),
+				Template: clusterv1.MachineTemplateSpec{
+					Spec: clusterv1.MachineSpec{
+						ClusterName: "cluster1",
+						Version:     pointer.StringPtr(version),
+					},
+				},
+			},
+			Status: clusterv1.MachineDeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+		})
+	}
+	return objs
+}
@@ -25,6 +25,19 @@ import (
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
 )
 
+// ErrOperationNotConfirmed is returned by destructive operations when the Confirm func of the operation options
+// does not confirm the action.
+var ErrOperationNotConfirmed = errors.New("operation not confirmed")
+
+// confirmAction asks the Confirm func of an operation to approve the action; a nil Confirm func approves any action,
+// so library users are not blocked by default.
+func confirmAction(confirm func(action string) bool, action string) error {
+	if confirm == nil || confirm(action) {
+		return nil
+	}
+	return errors.Wrap(ErrOperationNotConfirmed, action)
+}
+
 // getComponentsByName is a utility method that returns components
 // for a given provider with options including targetNamespace, and watchingNamespace.
 func (c *clusterctlClient) getComponentsByName(provider string, providerType clusterctlv1.ProviderType, options repository.ComponentsOptions) (repository.Components, error) {
@@ -52,6 +52,7 @@ func (c *clusterctlClient) GetProviderComponents(provider string, providerType c
 		Version:           options.Version,
 		TargetNamespace:   options.TargetNamespace,
 		WatchingNamespace: options.WatchingNamespace,
+		FeatureGates:      options.FeatureGates,
 		SkipVariables:     options.SkipVariables,
 	}
 	components, err := c.getComponentsByName(provider, providerType, inputOptions)
@@ -156,11 +157,35 @@ type GetClusterTemplateOptions struct {
 	// without executing any further processing.
 	ListVariablesOnly bool
 
+	// ValidateWithDryRun sets the GetClusterTemplate method to validate the template objects with a server-side dry-run
+	// against the management cluster, so objects not matching the CRD schemas are reported before applying the template.
+	// This option is ignored when ListVariablesOnly is set.
+	ValidateWithDryRun bool
+
+	// CreateNamespace sets the GetClusterTemplate method to create the target namespace in the management cluster,
+	// if it does not exist, so the template can be applied right away. The namespace is checked before validating
+	// the template objects in any case, so a missing namespace is reported with a meaningful error.
+	// This option is ignored when ListVariablesOnly is set.
+	CreateNamespace bool
+
+	// Patches to be applied, in order, to the workload cluster template objects after the template is rendered,
+	// so provider templates can be customized without maintaining a copy of them.
+	// This option is ignored when ListVariablesOnly is set.
+	Patches []TemplatePatch
+
 	// YamlProcessor defines the yaml processor to use for the cluster
 	// template processing. If not defined, SimpleProcessor will be used.
 	YamlProcessor Processor
 }
 
+const (
+	// StrategicMergeTemplatePatch identifies a TemplatePatch defined as a partial object merged into the template objects.
+	StrategicMergeTemplatePatch = repository.StrategicMergeTemplatePatch
+
+	// JSON6902TemplatePatch identifies a TemplatePatch defined as a list of JSON patch operations (RFC 6902).
+	JSON6902TemplatePatch = repository.JSON6902TemplatePatch
+)
+
 // numSources return the number of template sources currently set on a GetClusterTemplateOptions.
 func (o *GetClusterTemplateOptions) numSources() int {
 	numSources := 0
@@ -247,6 +272,58 @@ func (c *clusterctlClient) GetClusterTemplate(options GetClusterTemplateOptions)
 	}
 
 	// Gets the workload cluster template from the selected source
+	template, err := c.getTemplateFromSource(cluster, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Applies the user provided patches to the template objects.
+	if len(options.Patches) > 0 && !options.ListVariablesOnly {
+		patches := make([]repository.TemplatePatch, len(options.Patches))
+		for i := range options.Patches {
+			patches[i] = repository.TemplatePatch(options.Patches[i])
+		}
+		template, err = repository.PatchTemplate(template, patches)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// If the template is going to be validated or applied, checks the target namespace and creates it if requested.
+	if (options.ValidateWithDryRun || options.CreateNamespace) && !options.ListVariablesOnly {
+		if err := ensureTargetNamespace(cluster, options.TargetNamespace, options.CreateNamespace); err != nil {
+			return nil, err
+		}
+	}
+
+	// If requested, validates the template objects against the management cluster.
+	if options.ValidateWithDryRun && !options.ListVariablesOnly {
+		if err := cluster.Template().Validate(template.Objs()); err != nil {
+			return nil, errors.Wrap(err, "failed to validate the workload cluster template")
+		}
+	}
+
+	return template, nil
+}
+
+// ensureTargetNamespace checks that the target namespace exists in the management cluster, creating it if requested.
+func ensureTargetNamespace(clusterClient cluster.Client, targetNamespace string, create bool) error {
+	if create {
+		return clusterClient.Namespaces().Ensure(targetNamespace, cluster.NamespaceOptions{})
+	}
+
+	exists, err := clusterClient.Namespaces().Check(targetNamespace)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.Errorf("the target namespace %q does not exist in the management cluster; please create it or use the create namespace option", targetNamespace)
+	}
+	return nil
+}
+
+// getTemplateFromSource returns a workload cluster template from the source selected in the options.
+func (c *clusterctlClient) getTemplateFromSource(cluster cluster.Client, options GetClusterTemplateOptions) (Template, error) {
 	if options.ProviderRepositorySource != nil {
 		return c.getTemplateFromRepository(cluster, options)
 	}
@@ -34,18 +34,25 @@ type Client interface {
 
 	// ImageMeta provide access to to image meta configurations.
 	ImageMeta() ImageMetaClient
+
+	// VariableValidations returns the validation rules for the variables defined in the clusterctl configuration file, if any.
+	VariableValidations() ([]VariableValidation, error)
+
+	// ComponentsVerification returns the configuration for verifying the provider components read from the provider repositories.
+	ComponentsVerification() (*ComponentsVerification, error)
 }
 
 // configClient implements Client.
 type configClient struct {
-	reader Reader
+	reader    Reader
+	providers []Provider
 }
 
 // ensure configClient implements Client.
 var _ Client = &configClient{}
 
 func (c *configClient) Providers() ProvidersClient {
-	return newProvidersClient(c.reader)
+	return newProvidersClient(c.reader, c.providers...)
 }
 
 func (c *configClient) Variables() VariablesClient {
@@ -56,6 +63,14 @@ func (c *configClient) ImageMeta() ImageMetaClient {
 	return newImageMetaClient(c.reader)
 }
 
+func (c *configClient) VariableValidations() ([]VariableValidation, error) {
+	return getVariableValidations(c.reader)
+}
+
+func (c *configClient) ComponentsVerification() (*ComponentsVerification, error) {
+	return getComponentsVerification(c.reader)
+}
+
 // Option is a configuration option supplied to New
 type Option func(*configClient)
 
@@ -66,6 +81,16 @@ func InjectReader(reader Reader) Option {
 	}
 }
 
+// InjectProviders allows to add provider configurations to the ones hard-coded in clusterctl, e.g. when
+// clusterctl is extended by a vendor; Provider is an interface, so custom implementations can be injected.
+// In case of conflict, injected providers override the hard-coded configurations, while the
+// user-defined provider configurations read from the clusterctl configuration file override the injected ones.
+func InjectProviders(providers ...Provider) Option {
+	return func(c *configClient) {
+		c.providers = append(c.providers, providers...)
+	}
+}
+
 // New returns a Client for interacting with the clusterctl configuration.
 func New(path string, options ...Option) (Client, error) {
 	return newConfigClient(path, options...)
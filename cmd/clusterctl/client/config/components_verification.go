@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	// ComponentsVerificationConfigKey defines the name of the top level config key for the verification of the provider components.
+	ComponentsVerificationConfigKey = "componentsVerification"
+
+	// DefaultChecksumsFile is the default name of the release asset listing the sha256 checksums of the other release assets.
+	DefaultChecksumsFile = "checksums.txt"
+)
+
+// ComponentsVerificationPolicy defines what happens when the provider components can't be verified.
+type ComponentsVerificationPolicy string
+
+const (
+	// NoneVerificationPolicy skips the verification of the provider components; this is the default.
+	NoneVerificationPolicy ComponentsVerificationPolicy = "none"
+
+	// WarnVerificationPolicy logs a warning when the provider components can't be verified.
+	WarnVerificationPolicy ComponentsVerificationPolicy = "warn"
+
+	// EnforceVerificationPolicy fails when the provider components can't be verified.
+	EnforceVerificationPolicy ComponentsVerificationPolicy = "enforce"
+)
+
+// ComponentsVerification defines how the provider components read from the provider repositories are verified
+// against the checksums, and optionally the signatures, published alongside the release assets.
+type ComponentsVerification struct {
+	// Policy defines what happens when the provider components can't be verified.
+	Policy ComponentsVerificationPolicy `json:"policy,omitempty"`
+
+	// ChecksumsFile is the name of the release asset listing the sha256 checksums of the other release assets,
+	// in the format generated by sha256sum; it defaults to DefaultChecksumsFile.
+	ChecksumsFile string `json:"checksumsFile,omitempty"`
+
+	// PublicKeys are the paths of the PEM encoded public keys used for verifying the signature of the checksums file,
+	// by provider, e.g. infrastructure-aws. The signature is read from the release asset with the name of the checksums
+	// file and the .sig suffix, as generated by cosign sign-blob. If there is no key for a provider, only the checksums
+	// are verified.
+	PublicKeys map[string]string `json:"publicKeys,omitempty"`
+}
+
+// Enabled returns true if the provider components must be verified.
+func (v *ComponentsVerification) Enabled() bool {
+	return v.Policy != "" && v.Policy != NoneVerificationPolicy
+}
+
+// getComponentsVerification returns the components verification configuration read from the configuration.
+func getComponentsVerification(reader Reader) (*ComponentsVerification, error) {
+	verification := &ComponentsVerification{}
+	if err := reader.UnmarshalKey(ComponentsVerificationConfigKey, verification); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the components verification configuration")
+	}
+
+	switch verification.Policy {
+	case "", NoneVerificationPolicy, WarnVerificationPolicy, EnforceVerificationPolicy:
+	default:
+		return nil, errors.Errorf("invalid components verification policy %q: supported policies are [%s, %s, %s]", verification.Policy, NoneVerificationPolicy, WarnVerificationPolicy, EnforceVerificationPolicy)
+	}
+
+	if verification.ChecksumsFile == "" {
+		verification.ChecksumsFile = DefaultChecksumsFile
+	}
+	return verification, nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_getComponentsVerification(t *testing.T) {
+	tests := []struct {
+		name    string
+		reader  Reader
+		want    *ComponentsVerification
+		wantErr bool
+	}{
+		{
+			name:   "defaults if not configured",
+			reader: test.NewFakeReader(),
+			want: &ComponentsVerification{
+				ChecksumsFile: DefaultChecksumsFile,
+			},
+		},
+		{
+			name: "reads the configuration",
+			reader: test.NewFakeReader().WithVar(ComponentsVerificationConfigKey, `
+policy: enforce
+checksumsFile: SHA256SUMS
+publicKeys:
+  infrastructure-aws: /keys/cosign.pub
+`),
+			want: &ComponentsVerification{
+				Policy:        EnforceVerificationPolicy,
+				ChecksumsFile: "SHA256SUMS",
+				PublicKeys:    map[string]string{"infrastructure-aws": "/keys/cosign.pub"},
+			},
+		},
+		{
+			name:    "fails for an invalid policy",
+			reader:  test.NewFakeReader().WithVar(ComponentsVerificationConfigKey, "policy: strict"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := getComponentsVerification(tt.reader)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(got.Enabled()).To(Equal(tt.want.Policy == EnforceVerificationPolicy || tt.want.Policy == WarnVerificationPolicy))
+		})
+	}
+}
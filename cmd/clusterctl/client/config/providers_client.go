@@ -24,6 +24,7 @@ import (
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/util/validation"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
 )
 
 const (
@@ -52,9 +53,10 @@ const (
 
 // ProvidersClient has methods to work with provider configurations.
 type ProvidersClient interface {
-	// List returns all the provider configurations, including provider configurations hard-coded in clusterctl
-	// and user-defined provider configurations read from the clusterctl configuration file.
-	// In case of conflict, user-defined provider override the hard-coded configurations.
+	// List returns all the provider configurations, including provider configurations hard-coded in clusterctl,
+	// provider configurations injected when creating the client and user-defined provider configurations read
+	// from the clusterctl configuration file.
+	// In case of conflict, user-defined provider override the injected ones, which override the hard-coded configurations.
 	List() ([]Provider, error)
 
 	// Get returns the configuration for the provider with a given name/type.
@@ -64,15 +66,17 @@ type ProvidersClient interface {
 
 // providersClient implements ProvidersClient.
 type providersClient struct {
-	reader Reader
+	reader    Reader
+	providers []Provider
 }
 
 // ensure providersClient implements ProvidersClient.
 var _ ProvidersClient = &providersClient{}
 
-func newProvidersClient(reader Reader) *providersClient {
+func newProvidersClient(reader Reader, providers ...Provider) *providersClient {
 	return &providersClient{
-		reader: reader,
+		reader:    reader,
+		providers: providers,
 	}
 }
 
@@ -161,6 +165,15 @@ func (p *providersClient) List() ([]Provider, error) {
 	// Creates a maps with all the defaults provider configurations
 	providers := p.defaults()
 
+	// Merges the injected provider configurations with hard-coded configurations, handling conflicts
+	// (injected take precedence on hard-coded)
+	for _, provider := range p.providers {
+		if err := validateProvider(provider); err != nil {
+			return nil, errors.Wrapf(err, "error validating configuration for the injected %s with name %s", provider.Type(), provider.Name())
+		}
+		providers = mergeProvider(providers, provider)
+	}
+
 	// Gets user defined provider configurations, validate them, and merges with
 	// hard-coded configurations handling conflicts (user defined take precedence on hard-coded)
 
@@ -175,17 +188,7 @@ func (p *providersClient) List() ([]Provider, error) {
 			return nil, errors.Wrapf(err, "error validating configuration for the %s with name %s. Please fix the providers value in clusterctl configuration file", provider.Type(), provider.Name())
 		}
 
-		override := false
-		for i := range providers {
-			if providers[i].SameAs(provider) {
-				providers[i] = provider
-				override = true
-			}
-		}
-
-		if !override {
-			providers = append(providers, provider)
-		}
+		providers = mergeProvider(providers, provider)
 	}
 
 	// ensure provider configurations are consistently sorted
@@ -196,6 +199,22 @@ func (p *providersClient) List() ([]Provider, error) {
 	return providers, nil
 }
 
+// mergeProvider adds a provider configuration to the list, overriding the configuration with the same name/type, if any.
+func mergeProvider(providers []Provider, provider Provider) []Provider {
+	override := false
+	for i := range providers {
+		if providers[i].SameAs(provider) {
+			providers[i] = provider
+			override = true
+		}
+	}
+
+	if !override {
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
 func (p *providersClient) Get(name string, providerType clusterctlv1.ProviderType) (Provider, error) {
 	l, err := p.List()
 	if err != nil {
@@ -209,7 +228,7 @@ func (p *providersClient) Get(name string, providerType clusterctlv1.ProviderTyp
 		}
 	}
 
-	return nil, errors.Errorf("failed to get configuration for the %s with name %s. Please check the provider name and/or add configuration for new providers using the .clusterctl config file", providerType, name)
+	return nil, messages.New(messages.ProviderNotConfigured, providerType, name)
 }
 
 func validateProvider(r Provider) error {
@@ -44,8 +44,13 @@ func Test_providers_List(t *testing.T) {
 	defaultsWithOverride := append([]Provider{}, defaults...)
 	defaultsWithOverride[0] = NewProvider(defaults[0].Name(), "https://zzz/infrastructure-components.yaml", defaults[0].Type())
 
+	injectedOverride := NewProvider(defaults[0].Name(), "https://injected/infrastructure-components.yaml", defaults[0].Type())
+	defaultsWithInjectedOverride := append([]Provider{}, defaults...)
+	defaultsWithInjectedOverride[0] = injectedOverride
+
 	type fields struct {
 		configGetter Reader
+		providers    []Provider
 	}
 	tests := []struct {
 		name    string
@@ -89,6 +94,48 @@ func Test_providers_List(t *testing.T) {
 			want:    defaultsWithOverride,
 			wantErr: false,
 		},
+		{
+			name: "Returns injected provider configurations",
+			fields: fields{
+				configGetter: test.NewFakeReader(),
+				providers:    []Provider{NewProvider("zzz", "https://zzz/infrastructure-components.yaml", "InfrastructureProvider")},
+			},
+			want:    defaultsAndZZZ,
+			wantErr: false,
+		},
+		{
+			name: "Injected provider configurations override defaults",
+			fields: fields{
+				configGetter: test.NewFakeReader(),
+				providers:    []Provider{injectedOverride},
+			},
+			want:    defaultsWithInjectedOverride,
+			wantErr: false,
+		},
+		{
+			name: "User defined provider configurations override injected provider configurations",
+			fields: fields{
+				configGetter: test.NewFakeReader().
+					WithVar(
+						ProvidersConfigKey,
+						fmt.Sprintf("- name: \"%s\"\n", defaults[0].Name())+
+							"  url: \"https://zzz/infrastructure-components.yaml\"\n"+
+							fmt.Sprintf("  type: \"%s\"\n", defaults[0].Type()),
+					),
+				providers: []Provider{injectedOverride},
+			},
+			want:    defaultsWithOverride,
+			wantErr: false,
+		},
+		{
+			name: "Fails for invalid injected provider configurations",
+			fields: fields{
+				configGetter: test.NewFakeReader(),
+				providers:    []Provider{NewProvider("", "", "")},
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name: "Fails for invalid user defined provider configurations",
 			fields: fields{
@@ -120,9 +167,7 @@ func Test_providers_List(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
 
-			p := &providersClient{
-				reader: tt.fields.configGetter,
-			}
+			p := newProvidersClient(tt.fields.configGetter, tt.fields.providers...)
 			got, err := p.List()
 			if tt.wantErr {
 				g.Expect(err).To(HaveOccurred())
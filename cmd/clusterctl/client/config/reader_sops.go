@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SecretsFileVariable defines a variable hosting the path of a file with additional variables, e.g. credentials;
+	// the file, as well as the clusterctl configuration file, can be encrypted with SOPS.
+	SecretsFileVariable = "secrets-file"
+
+	// sopsMetadataKey is the key hosting the SOPS metadata in encrypted files.
+	sopsMetadataKey = "sops"
+
+	// sopsEncryptedPrefix is the prefix of the values encrypted with SOPS.
+	sopsEncryptedPrefix = "ENC["
+)
+
+// SecretsDecrypter decrypts a file encrypted with SOPS, returning the decrypted content in YAML format.
+type SecretsDecrypter func(path string) ([]byte, error)
+
+// InjectSecretsDecrypter allows to override the function used for decrypting files encrypted with SOPS.
+func InjectSecretsDecrypter(decrypter SecretsDecrypter) viperReaderOption {
+	return func(vr *viperReader) {
+		vr.decrypter = decrypter
+	}
+}
+
+// sopsDecrypt decrypts a file using the sops binary; sops reads the age keys from the SOPS_AGE_KEY_FILE
+// environment variable or from its default location, e.g. $HOME/.config/sops/age/keys.txt.
+func sopsDecrypt(path string) ([]byte, error) {
+	sops, err := exec.LookPath("sops")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the sops binary, which is required for decrypting files encrypted with SOPS")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(sops, "--decrypt", "--output-type", "yaml", path) //nolint:gosec
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decrypt %q with sops: %s", path, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// readSecrets decrypts the clusterctl configuration file if encrypted with SOPS, and then merges the variables
+// defined in the secrets file, if any.
+// Nb. if decryption fails, e.g. because no key is available, the encrypted values are preserved, and an error is
+// returned only when reading them.
+func (v *viperReader) readSecrets() error {
+	log := logf.Log
+
+	if viper.InConfig(sopsMetadataKey) {
+		path := viper.ConfigFileUsed()
+		decrypted, err := v.decrypter(path)
+		if err != nil {
+			log.Info("Warning: unable to decrypt the clusterctl configuration file, the encrypted variables can not be used", "File", path, "Error", err.Error())
+		} else {
+			viper.SetConfigType("yaml")
+			if err := viper.ReadConfig(bytes.NewReader(decrypted)); err != nil {
+				return errors.Wrapf(err, "failed to read the decrypted clusterctl configuration file %q", path)
+			}
+		}
+	}
+
+	path := viper.GetString(SecretsFileVariable)
+	if path == "" {
+		return nil
+	}
+	if !filepath.IsAbs(path) && viper.ConfigFileUsed() != "" {
+		path = filepath.Join(filepath.Dir(viper.ConfigFileUsed()), path)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the secrets file %q", path)
+	}
+
+	secrets := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &secrets); err != nil {
+		return errors.Wrapf(err, "failed to parse the secrets file %q", path)
+	}
+	if _, ok := secrets[sopsMetadataKey]; ok {
+		decrypted, err := v.decrypter(path)
+		if err != nil {
+			log.Info("Warning: unable to decrypt the secrets file, the encrypted variables can not be used", "File", path, "Error", err.Error())
+		} else {
+			content = decrypted
+		}
+	}
+
+	viper.SetConfigType("yaml")
+	if err := viper.MergeConfig(bytes.NewReader(content)); err != nil {
+		return errors.Wrapf(err, "failed to read the secrets file %q", path)
+	}
+	log.V(5).Info("Using secrets", "File", path)
+	return nil
+}
+
+// isEncrypted returns true if the value is encrypted with SOPS.
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, sopsEncryptedPrefix)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+const encryptedValue = "ENC[AES256_GCM,data:Tr7o=,iv:1=,tag:2=,type:str]"
+
+func Test_viperReader_readSecrets(t *testing.T) {
+	g := NewWithT(t)
+
+	dir, err := ioutil.TempDir("", "clusterctl")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	sopsMetadata := "sops:\n  age:\n  - recipient: age1xyz\n"
+
+	// a config file encrypted with SOPS
+	encryptedConfigFile := filepath.Join(dir, "clusterctl-encrypted.yaml")
+	g.Expect(ioutil.WriteFile(encryptedConfigFile, []byte("plain: plain\nsecret: "+encryptedValue+"\n"+sopsMetadata), 0600)).To(Succeed())
+
+	// a plain config file referencing a secrets file encrypted with SOPS
+	secretsFile := filepath.Join(dir, "secrets.yaml")
+	g.Expect(ioutil.WriteFile(secretsFile, []byte("secret: "+encryptedValue+"\n"+sopsMetadata), 0600)).To(Succeed())
+	configFile := filepath.Join(dir, "clusterctl.yaml")
+	g.Expect(ioutil.WriteFile(configFile, []byte("plain: plain\nsecrets-file: secrets.yaml\n"), 0600)).To(Succeed())
+
+	// a plain config file referencing a plain secrets file
+	plainSecretsFile := filepath.Join(dir, "plain-secrets.yaml")
+	g.Expect(ioutil.WriteFile(plainSecretsFile, []byte("secret: not-encrypted\n"), 0600)).To(Succeed())
+	configFileWithPlainSecrets := filepath.Join(dir, "clusterctl-plain-secrets.yaml")
+	g.Expect(ioutil.WriteFile(configFileWithPlainSecrets, []byte("plain: plain\nsecrets-file: "+plainSecretsFile+"\n"), 0600)).To(Succeed())
+
+	// a config file referencing a secrets file that does not exist
+	configFileWithMissingSecrets := filepath.Join(dir, "clusterctl-missing-secrets.yaml")
+	g.Expect(ioutil.WriteFile(configFileWithMissingSecrets, []byte("secrets-file: do-not-exist.yaml\n"), 0600)).To(Succeed())
+
+	decrypter := func(path string) ([]byte, error) {
+		switch path {
+		case encryptedConfigFile:
+			return []byte("plain: plain\nsecret: decrypted-config\n"), nil
+		case secretsFile:
+			return []byte("secret: decrypted-secrets\n"), nil
+		}
+		return nil, errors.Errorf("unexpected file %q", path)
+	}
+	failingDecrypter := func(path string) ([]byte, error) {
+		return nil, errors.New("no key available")
+	}
+
+	tests := []struct {
+		name        string
+		configFile  string
+		decrypter   SecretsDecrypter
+		want        string
+		wantInitErr bool
+		wantGetErr  bool
+	}{
+		{
+			name:       "decrypts the config file",
+			configFile: encryptedConfigFile,
+			decrypter:  decrypter,
+			want:       "decrypted-config",
+		},
+		{
+			name:       "decrypts the secrets file",
+			configFile: configFile,
+			decrypter:  decrypter,
+			want:       "decrypted-secrets",
+		},
+		{
+			name:       "reads a plain secrets file",
+			configFile: configFileWithPlainSecrets,
+			decrypter:  failingDecrypter,
+			want:       "not-encrypted",
+		},
+		{
+			name:       "returns error when reading a value of the config file that can't be decrypted",
+			configFile: encryptedConfigFile,
+			decrypter:  failingDecrypter,
+			wantGetErr: true,
+		},
+		{
+			name:       "returns error when reading a value of the secrets file that can't be decrypted",
+			configFile: configFile,
+			decrypter:  failingDecrypter,
+			wantGetErr: true,
+		},
+		{
+			name:        "returns error if the secrets file does not exist",
+			configFile:  configFileWithMissingSecrets,
+			decrypter:   decrypter,
+			wantInitErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := NewWithT(t)
+
+			reader := newViperReader(InjectConfigPaths([]string{dir}), InjectSecretsDecrypter(tt.decrypter))
+			err := reader.Init(tt.configFile)
+			if tt.wantInitErr {
+				gs.Expect(err).To(HaveOccurred())
+				return
+			}
+			gs.Expect(err).NotTo(HaveOccurred())
+
+			variables := newVariablesClient(reader)
+
+			plain, err := variables.Get("plain")
+			gs.Expect(err).NotTo(HaveOccurred())
+			gs.Expect(plain).To(Equal("plain"))
+
+			got, err := variables.Get("secret")
+			if tt.wantGetErr {
+				gs.Expect(err).To(HaveOccurred())
+				return
+			}
+			gs.Expect(err).NotTo(HaveOccurred())
+			gs.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
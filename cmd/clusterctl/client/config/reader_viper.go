@@ -22,9 +22,9 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
 )
 
@@ -39,6 +39,7 @@ const (
 // and from a clusterctl config file.
 type viperReader struct {
 	configPaths []string
+	decrypter   SecretsDecrypter
 }
 
 type viperReaderOption func(*viperReader)
@@ -53,6 +54,7 @@ func InjectConfigPaths(configPaths []string) viperReaderOption {
 func newViperReader(opts ...viperReaderOption) Reader {
 	vr := &viperReader{
 		configPaths: []string{filepath.Join(homedir.HomeDir(), ConfigFolder)},
+		decrypter:   sopsDecrypt,
 	}
 	for _, o := range opts {
 		o(vr)
@@ -86,7 +88,7 @@ func (v *viperReader) Init(path string) error {
 			// since there is no default config to read from, just skip
 			// reading in config
 			log.V(5).Info("No default config file available")
-			return nil
+			return v.readSecrets()
 		}
 		// Configure viper for reading .cluster-api/clusterctl{.extension} in home directory
 		viper.SetConfigName(ConfigName)
@@ -99,12 +101,12 @@ func (v *viperReader) Init(path string) error {
 		return err
 	}
 	log.V(5).Info("Using configuration", "File", viper.ConfigFileUsed())
-	return nil
+	return v.readSecrets()
 }
 
 func (v *viperReader) Get(key string) (string, error) {
 	if viper.Get(key) == nil {
-		return "", errors.Errorf("Failed to get value for variable %q. Please set the variable value using os env variables or using the .clusterctl config file", key)
+		return "", messages.New(messages.VariableNotSet, key)
 	}
 	return viper.GetString(key), nil
 }
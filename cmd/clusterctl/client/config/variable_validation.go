@@ -0,0 +1,150 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// VariableValidationsConfigKey defines the name of the top level config key for variable validation rules.
+	VariableValidationsConfigKey = "variableValidations"
+
+	// CIDRVariableFormat requires the variable value to be a CIDR, e.g. 192.168.0.0/16.
+	CIDRVariableFormat = "cidr"
+
+	// IPVariableFormat requires the variable value to be an IP address, e.g. 192.168.0.1.
+	IPVariableFormat = "ip"
+)
+
+// VariableValidation defines the rules a variable value must satisfy.
+type VariableValidation struct {
+	// Name of the variable the rules apply to.
+	Name string `json:"name,omitempty"`
+
+	// Description of the variable; it is not used for validating the value, but it is reported in the variables schema.
+	Description string `json:"description,omitempty"`
+
+	// Required rejects templates using the variable when a value is not set, even if the template defines a default value.
+	Required bool `json:"required,omitempty"`
+
+	// Pattern is a regular expression the value must match.
+	Pattern string `json:"pattern,omitempty"`
+
+	// Enum is the list of the allowed values.
+	Enum []string `json:"enum,omitempty"`
+
+	// Format of the value; supported formats are cidr and ip.
+	Format string `json:"format,omitempty"`
+}
+
+// validate checks a variable value against the validation rules; set is false if the value is not defined.
+func (v *VariableValidation) validate(value string, set bool) error {
+	if !set {
+		if v.Required {
+			return errors.Errorf("value for variable %q is required", v.Name)
+		}
+		// Nb. if the variable is not required, the template default value is used.
+		return nil
+	}
+
+	if v.Pattern != "" {
+		re, err := regexp.Compile(v.Pattern)
+		if err != nil {
+			return errors.Wrapf(err, "invalid pattern for variable %q", v.Name)
+		}
+		if !re.MatchString(value) {
+			return errors.Errorf("invalid value %q for variable %q: must match the pattern %q", value, v.Name, v.Pattern)
+		}
+	}
+
+	if len(v.Enum) > 0 {
+		allowed := false
+		for _, e := range v.Enum {
+			if e == value {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return errors.Errorf("invalid value %q for variable %q: must be one of [%s]", value, v.Name, strings.Join(v.Enum, ", "))
+		}
+	}
+
+	switch v.Format {
+	case "":
+	case CIDRVariableFormat:
+		if _, _, err := net.ParseCIDR(value); err != nil {
+			return errors.Errorf("invalid value %q for variable %q: must be a valid CIDR, e.g. 192.168.0.0/16", value, v.Name)
+		}
+	case IPVariableFormat:
+		if net.ParseIP(value) == nil {
+			return errors.Errorf("invalid value %q for variable %q: must be a valid IP address, e.g. 192.168.0.1", value, v.Name)
+		}
+	default:
+		return errors.Errorf("invalid format %q for variable %q: supported formats are [%s, %s]", v.Format, v.Name, CIDRVariableFormat, IPVariableFormat)
+	}
+
+	return nil
+}
+
+// getVariableValidations returns the validation rules read from the configuration.
+func getVariableValidations(reader Reader) ([]VariableValidation, error) {
+	var validations []VariableValidation
+	if err := reader.UnmarshalKey(VariableValidationsConfigKey, &validations); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal variable validation rules")
+	}
+	return validations, nil
+}
+
+// validateVariables checks the values of the given variables against the validation rules read from the configuration.
+func validateVariables(reader Reader, names []string) error {
+	validations, err := getVariableValidations(reader)
+	if err != nil {
+		return err
+	}
+	if len(validations) == 0 {
+		return nil
+	}
+
+	validationByName := map[string]*VariableValidation{}
+	for i := range validations {
+		validationByName[validations[i].Name] = &validations[i]
+	}
+
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+
+	var errList []error
+	for _, name := range sorted {
+		v, ok := validationByName[name]
+		if !ok {
+			continue
+		}
+		value, err := reader.Get(name)
+		if err := v.validate(value, err == nil); err != nil {
+			errList = append(errList, err)
+		}
+	}
+	return kerrors.NewAggregate(errList)
+}
@@ -16,6 +16,10 @@ limitations under the License.
 
 package config
 
+import (
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
+)
+
 const (
 	// GitHubTokenVariable defines a variable hosting the GitHub access token
 	GitHubTokenVariable = "github-token"
@@ -26,11 +30,17 @@ type VariablesClient interface {
 	// Get returns a variable value. If the variable is not defined an error is returned.
 	// In case the same variable is defined both within the environment variables and clusterctl configuration file,
 	// the environment variables value takes precedence.
+	// Values encrypted with SOPS are decrypted when reading the clusterctl configuration file; if a value can't be
+	// decrypted, e.g. because no key is available, an error is returned.
 	Get(key string) (string, error)
 
 	// Set allows to set an explicit override for a config value.
 	// e.g. It is used to set an override from a flag value over environment/config file variables.
 	Set(key, values string)
+
+	// Validate checks the values of the given variables against the validation rules defined in the clusterctl
+	// configuration file, if any, and returns an error listing all the violations.
+	Validate(names []string) error
 }
 
 // variablesClient implements VariablesClient.
@@ -48,9 +58,20 @@ func newVariablesClient(reader Reader) *variablesClient {
 }
 
 func (p *variablesClient) Get(key string) (string, error) {
-	return p.reader.Get(key)
+	value, err := p.reader.Get(key)
+	if err != nil {
+		return "", err
+	}
+	if isEncrypted(value) {
+		return "", messages.New(messages.VariableEncrypted, key)
+	}
+	return value, nil
 }
 
 func (p *variablesClient) Set(key, value string) {
 	p.reader.Set(key, value)
 }
+
+func (p *variablesClient) Validate(names []string) error {
+	return validateVariables(p.reader, names)
+}
@@ -31,7 +31,9 @@ var _ Reader = &test.FakeReader{}
 var _ VariablesClient = &test.FakeVariableClient{}
 
 func Test_variables_Get(t *testing.T) {
-	reader := test.NewFakeReader().WithVar("foo", "bar")
+	reader := test.NewFakeReader().
+		WithVar("foo", "bar").
+		WithVar("encrypted", "ENC[AES256_GCM,data:Tr7o=,iv:1=,tag:2=,type:str]")
 
 	type args struct {
 		key string
@@ -57,6 +59,13 @@ func Test_variables_Get(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Returns error if the variable is encrypted with SOPS",
+			args: args{
+				key: "encrypted",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -76,3 +85,97 @@ func Test_variables_Get(t *testing.T) {
 		})
 	}
 }
+
+func Test_variables_Validate(t *testing.T) {
+	validations := `
+- name: POD_CIDR
+  format: cidr
+- name: CONTROL_PLANE_ENDPOINT_IP
+  format: ip
+- name: KUBERNETES_VERSION
+  required: true
+  pattern: "^v[0-9]+\\.[0-9]+\\.[0-9]+$"
+- name: WORKER_MACHINE_TYPE
+  enum: [small, large]
+`
+
+	tests := []struct {
+		name        string
+		vars        map[string]string
+		names       []string
+		wantErr     bool
+		wantErrText []string
+	}{
+		{
+			name: "pass if all the values are valid",
+			vars: map[string]string{
+				"POD_CIDR":                  "192.168.0.0/16",
+				"CONTROL_PLANE_ENDPOINT_IP": "10.0.0.1",
+				"KUBERNETES_VERSION":        "v1.18.2",
+				"WORKER_MACHINE_TYPE":       "small",
+			},
+			names:   []string{"POD_CIDR", "CONTROL_PLANE_ENDPOINT_IP", "KUBERNETES_VERSION", "WORKER_MACHINE_TYPE"},
+			wantErr: false,
+		},
+		{
+			name:    "pass if optional variables are not set",
+			vars:    map[string]string{"KUBERNETES_VERSION": "v1.18.2"},
+			names:   []string{"POD_CIDR", "KUBERNETES_VERSION", "WORKER_MACHINE_TYPE"},
+			wantErr: false,
+		},
+		{
+			name:    "ignore variables not used by the template",
+			vars:    map[string]string{"POD_CIDR": "invalid"},
+			names:   []string{"SERVICE_CIDR"},
+			wantErr: false,
+		},
+		{
+			name:        "fail if a required variable is not set",
+			vars:        map[string]string{},
+			names:       []string{"KUBERNETES_VERSION"},
+			wantErr:     true,
+			wantErrText: []string{`value for variable "KUBERNETES_VERSION" is required`},
+		},
+		{
+			name: "fail reporting all the invalid values",
+			vars: map[string]string{
+				"POD_CIDR":                  "192.168.0.0/33",
+				"CONTROL_PLANE_ENDPOINT_IP": "10.0.0",
+				"KUBERNETES_VERSION":        "1.18.2",
+				"WORKER_MACHINE_TYPE":       "medium",
+			},
+			names:   []string{"POD_CIDR", "CONTROL_PLANE_ENDPOINT_IP", "KUBERNETES_VERSION", "WORKER_MACHINE_TYPE"},
+			wantErr: true,
+			wantErrText: []string{
+				`invalid value "192.168.0.0/33" for variable "POD_CIDR": must be a valid CIDR`,
+				`invalid value "10.0.0" for variable "CONTROL_PLANE_ENDPOINT_IP": must be a valid IP address`,
+				`invalid value "1.18.2" for variable "KUBERNETES_VERSION": must match the pattern`,
+				`invalid value "medium" for variable "WORKER_MACHINE_TYPE": must be one of [small, large]`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			reader := test.NewFakeReader().WithVar(VariableValidationsConfigKey, validations)
+			for k, v := range tt.vars {
+				reader.WithVar(k, v)
+			}
+
+			p := &variablesClient{
+				reader: reader,
+			}
+			err := p.Validate(tt.names)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				for _, text := range tt.wantErrText {
+					g.Expect(err.Error()).To(ContainSubstring(text))
+				}
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
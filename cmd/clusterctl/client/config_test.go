@@ -558,6 +558,59 @@ func Test_clusterctlClient_GetClusterTemplate(t *testing.T) {
 				yaml:            templateYAML("ns1", "test"), // original template modified with target namespace and variable replacement
 			},
 		},
+		{
+			name: "URL source - with patches",
+			args: args{
+				options: GetClusterTemplateOptions{
+					Kubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					URLSource: &URLSourceOptions{
+						URL: path,
+					},
+					ClusterName:              "test",
+					TargetNamespace:          "ns1",
+					ControlPlaneMachineCount: pointer.Int64Ptr(1),
+					Patches: []TemplatePatch{
+						{
+							Type:  StrategicMergeTemplatePatch,
+							Patch: []byte("apiVersion: v1\nkind: Cluster\nmetadata:\n  name: test\n  labels:\n    env: prod"),
+						},
+					},
+				},
+			},
+			want: templateValues{
+				variables:       []string{"CLUSTER_NAME"}, // variable detected
+				targetNamespace: "ns1",
+				yaml: []byte("apiVersion: v1\n" + // original template modified with target namespace, variable replacement and patches
+					"kind: Cluster\n" +
+					"metadata:\n" +
+					"  labels:\n" +
+					"    env: prod\n" +
+					"  name: test\n" +
+					"  namespace: ns1"),
+			},
+		},
+		{
+			name: "URL source - fails if a patch does not match any object",
+			args: args{
+				options: GetClusterTemplateOptions{
+					Kubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					URLSource: &URLSourceOptions{
+						URL: path,
+					},
+					ClusterName:              "test",
+					TargetNamespace:          "ns1",
+					ControlPlaneMachineCount: pointer.Int64Ptr(1),
+					Patches: []TemplatePatch{
+						{
+							Type:  JSON6902TemplatePatch,
+							Name:  "another-cluster",
+							Patch: []byte(`[{"op": "remove", "path": "/metadata/labels"}]`),
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "ConfigMap source - pass",
 			args: args{
@@ -601,6 +654,65 @@ func Test_clusterctlClient_GetClusterTemplate(t *testing.T) {
 	}
 }
 
+func Test_clusterctlClient_GetClusterTemplate_TargetNamespace(t *testing.T) {
+	g := NewWithT(t)
+
+	rawTemplate := templateYAML("ns3", "${ CLUSTER_NAME }")
+
+	tmpDir, err := ioutil.TempDir("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "cluster-template.yaml")
+	g.Expect(ioutil.WriteFile(path, rawTemplate, 0600)).To(Succeed())
+
+	tests := []struct {
+		name            string
+		createNamespace bool
+		validate        bool
+		wantErr         bool
+	}{
+		{
+			name:            "creates the target namespace if requested",
+			createNamespace: true,
+		},
+		{
+			name:     "fails if the target namespace does not exist when validating the template",
+			validate: true,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			config1 := newFakeConfig()
+			cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1)
+			client := newFakeClient(config1).WithCluster(cluster1)
+
+			_, err := client.GetClusterTemplate(GetClusterTemplateOptions{
+				Kubeconfig:               Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+				URLSource:                &URLSourceOptions{URL: path},
+				ClusterName:              "test",
+				TargetNamespace:          "ns4",
+				ControlPlaneMachineCount: pointer.Int64Ptr(1),
+				CreateNamespace:          tt.createNamespace,
+				ValidateWithDryRun:       tt.validate,
+			})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(`the target namespace "ns4" does not exist`))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			exists, err := cluster1.Namespaces().Check("ns4")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(exists).To(BeTrue())
+		})
+	}
+}
+
 func Test_clusterctlClient_ProcessYAML(t *testing.T) {
 	g := NewWithT(t)
 	template := `v1: ${VAR1:=default1}
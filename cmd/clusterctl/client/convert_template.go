@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/pkg/errors"
+	yaml "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
+)
+
+// TemplateFormat defines the format of a workload cluster template.
+type TemplateFormat string
+
+const (
+	// EnvsubstTemplateFormat is a single YAML file using ${VAR} variables, as processed by clusterctl.
+	EnvsubstTemplateFormat TemplateFormat = "envsubst"
+
+	// KustomizeTemplateFormat is a kustomize base with the default values of the variables, and a kustomization
+	// patching the base.
+	KustomizeTemplateFormat TemplateFormat = "kustomize"
+
+	// HelmTemplateFormat is a Helm chart reading the variables from the chart values.
+	HelmTemplateFormat TemplateFormat = "helm"
+)
+
+// ConvertTemplateOptions carries the options supported by ConvertTemplate.
+type ConvertTemplateOptions struct {
+	// Files contains the files of the template to convert, keyed by their path relative to the template root,
+	// e.g. kustomization.yaml and base/cluster-template.yaml for a kustomization. A template in the envsubst format
+	// must be a single file.
+	Files map[string][]byte
+
+	// From defines the format of the template to convert.
+	From TemplateFormat
+
+	// To defines the format of the converted template.
+	To TemplateFormat
+
+	// ChartName defines the name of the chart generated when converting to the helm format.
+	// If unspecified, cluster-template is used.
+	ChartName string
+}
+
+// ConvertTemplate converts a workload cluster template between the envsubst, kustomize and helm formats, and
+// returns the files of the converted template, keyed by their path relative to the template root.
+func (c *clusterctlClient) ConvertTemplate(options ConvertTemplateOptions) (map[string][]byte, error) {
+	if options.From == options.To {
+		return nil, errors.Errorf("the template is already in the %s format", options.To)
+	}
+
+	var template []byte
+	var err error
+	switch options.From {
+	case EnvsubstTemplateFormat:
+		if len(options.Files) != 1 {
+			return nil, errors.Errorf("a template in the %s format must be a single file", EnvsubstTemplateFormat)
+		}
+		for _, content := range options.Files {
+			template = content
+		}
+	case KustomizeTemplateFormat:
+		template, err = yaml.ConvertFromKustomize(options.Files)
+	case HelmTemplateFormat:
+		template, err = yaml.ConvertFromHelm(options.Files)
+	default:
+		return nil, errors.Errorf("invalid template format %q", options.From)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert the template from the %s format", options.From)
+	}
+
+	var files map[string][]byte
+	switch options.To {
+	case EnvsubstTemplateFormat:
+		files = map[string][]byte{yaml.ConvertedTemplateFile: template}
+	case KustomizeTemplateFormat:
+		files, err = yaml.ConvertToKustomize(template)
+	case HelmTemplateFormat:
+		files, err = yaml.ConvertToHelm(template, options.ChartName)
+	default:
+		return nil, errors.Errorf("invalid template format %q", options.To)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert the template to the %s format", options.To)
+	}
+	return files, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_clusterctlClient_ConvertTemplate(t *testing.T) {
+	template := []byte("kind: ConfigMap\nmetadata:\n  name: ${NAME:=foo}")
+
+	tests := []struct {
+		name      string
+		options   ConvertTemplateOptions
+		wantFiles []string
+		wantErr   bool
+	}{
+		{
+			name: "converts to kustomize",
+			options: ConvertTemplateOptions{
+				Files: map[string][]byte{"template.yaml": template},
+				From:  EnvsubstTemplateFormat,
+				To:    KustomizeTemplateFormat,
+			},
+			wantFiles: []string{"kustomization.yaml", "base/kustomization.yaml", "base/cluster-template.yaml"},
+		},
+		{
+			name: "converts to helm",
+			options: ConvertTemplateOptions{
+				Files: map[string][]byte{"template.yaml": template},
+				From:  EnvsubstTemplateFormat,
+				To:    HelmTemplateFormat,
+			},
+			wantFiles: []string{"Chart.yaml", "values.yaml", "templates/cluster-template.yaml"},
+		},
+		{
+			name: "converts from helm",
+			options: ConvertTemplateOptions{
+				Files: map[string][]byte{"templates/template.yaml": []byte("name: {{ .Values.NAME }}")},
+				From:  HelmTemplateFormat,
+				To:    EnvsubstTemplateFormat,
+			},
+			wantFiles: []string{"cluster-template.yaml"},
+		},
+		{
+			name: "fails if the formats are the same",
+			options: ConvertTemplateOptions{
+				Files: map[string][]byte{"template.yaml": template},
+				From:  EnvsubstTemplateFormat,
+				To:    EnvsubstTemplateFormat,
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if an envsubst template has many files",
+			options: ConvertTemplateOptions{
+				Files: map[string][]byte{"a.yaml": template, "b.yaml": template},
+				From:  EnvsubstTemplateFormat,
+				To:    HelmTemplateFormat,
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails for invalid formats",
+			options: ConvertTemplateOptions{
+				Files: map[string][]byte{"template.yaml": template},
+				From:  EnvsubstTemplateFormat,
+				To:    "jsonnet",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := newFakeClient(newFakeConfig())
+			got, err := c.ConvertTemplate(tt.options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(HaveLen(len(tt.wantFiles)))
+			for _, f := range tt.wantFiles {
+				g.Expect(got).To(HaveKey(f))
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+// RotateCredentialsOptions carries the options supported by RotateCredentials.
+type RotateCredentialsOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Provider whose credentials are rotated, in the form [namespace/]type-name, e.g. infrastructure-aws or
+	// capa-system/infrastructure-aws; the namespace is required only if there are many instances of the provider.
+	Provider string
+
+	// SecretName is the name of the Secret in the provider namespace holding the credentials. If unspecified,
+	// the only Opaque Secret of the provider components is used.
+	SecretName string
+
+	// Data are the new values of the credentials; keys not included in Data are left unchanged.
+	Data map[string][]byte
+
+	// Timeout is the time the provider Deployments are given to become available with the new credentials.
+	// If unspecified, it defaults to 5 minutes.
+	Timeout time.Duration
+
+	// Rollback restores the previous credentials if the provider Deployments do not become available with the
+	// new credentials.
+	Rollback bool
+
+	// ForceLock forces the operation even if the management cluster is locked by another clusterctl operation,
+	// e.g. because the lock has been left behind by an operation that failed without releasing it.
+	ForceLock bool
+}
+
+// RotateCredentials updates the credentials Secret of a provider, restarts the provider Deployments and verifies
+// they become available with the new credentials, so the provider uses the new credentials as soon as possible,
+// e.g. when the previous credentials are being revoked.
+func (c *clusterctlClient) RotateCredentials(options RotateCredentialsOptions) error {
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return err
+	}
+
+	// Prevents other clusterctl operations, e.g. an upgrade restarting the same Deployments, while rotating.
+	unlock, err := lockOperation(clusterClient, cluster.AuditRotateCredentialsOperation, options.ForceLock)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	provider, err := getInstalledProvider(clusterClient, options.Provider)
+	if err != nil {
+		return err
+	}
+
+	secretName, err := clusterClient.Credentials().Rotate(cluster.RotateCredentialsOptions{
+		Provider:   provider,
+		SecretName: options.SecretName,
+		Data:       options.Data,
+		Timeout:    options.Timeout,
+		Rollback:   options.Rollback,
+	})
+
+	// Records the operation in the audit log of the management cluster, including failed rotations.
+	if secretName != "" {
+		result := "succeeded"
+		if err != nil {
+			result = "failed"
+		}
+		recordOperation(clusterClient, cluster.AuditRotateCredentialsOperation, []string{cluster.AuditProviderRef(provider, provider.Version)}, map[string]string{
+			"secret": secretName,
+			"result": result,
+		})
+	}
+	return err
+}
+
+// getInstalledProvider returns the provider in the inventory matching a [namespace/]type-name reference.
+func getInstalledProvider(clusterClient cluster.Client, ref string) (clusterctlv1.Provider, error) {
+	namespace, manifestLabel := "", ref
+	if i := strings.Index(ref, "/"); i >= 0 {
+		namespace, manifestLabel = ref[:i], ref[i+1:]
+	}
+	if name, _ := parseManifestLabel(manifestLabel); name == "" {
+		return clusterctlv1.Provider{}, errors.Errorf("invalid provider %q, it must be in the form [namespace/]type-name, e.g. infrastructure-aws", ref)
+	}
+
+	providerList, err := clusterClient.ProviderInventory().List()
+	if err != nil {
+		return clusterctlv1.Provider{}, err
+	}
+
+	var found []clusterctlv1.Provider
+	for _, p := range providerList.Items {
+		if p.ManifestLabel() != manifestLabel {
+			continue
+		}
+		if namespace != "" && p.Namespace != namespace {
+			continue
+		}
+		found = append(found, p)
+	}
+	switch len(found) {
+	case 0:
+		return clusterctlv1.Provider{}, errors.Errorf("failed to find the %q provider in the management cluster", ref)
+	case 1:
+		return found[0], nil
+	default:
+		return clusterctlv1.Provider{}, errors.Errorf("there are many instances of the %q provider, please specify the provider namespace, e.g. %s", ref, found[0].InstanceName())
+	}
+}
@@ -17,8 +17,13 @@ limitations under the License.
 package client
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 )
@@ -58,22 +63,52 @@ type DeleteOptions struct {
 	// IncludeCRDs forces the deletion of the provider's CRDs (and of all the related objects).
 	// By Extension, this forces the deletion of all the resources shared among provider instances, like e.g. web-hooks.
 	IncludeCRDs bool
+
+	// Confirm is called with a description of the action before deleting the providers; if it returns false,
+	// the deletion is aborted with ErrOperationNotConfirmed. If unspecified, the deletion is always performed.
+	Confirm func(action string) bool
+
+	// ForceLock forces the operation even if the management cluster is locked by another clusterctl operation,
+	// e.g. because the lock has been left behind by an operation that failed without releasing it.
+	ForceLock bool
+
+	// DryRun returns the objects that would be deleted without changing the management cluster.
+	DryRun bool
 }
 
-func (c *clusterctlClient) Delete(options DeleteOptions) error {
+// DeletePlan describes the objects deleted by Delete, so the scope of the deletion can be reviewed before performing it.
+type DeletePlan struct {
+	// Providers are the provider instances being deleted, with their components.
+	Providers []ProviderDeletePlan `json:"providers"`
+
+	// Warnings lists the consequences of the deletion going beyond the components of the providers being deleted,
+	// e.g. the deletion of CRDs shared with other instances of the same provider.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+func (c *clusterctlClient) Delete(options DeleteOptions) (*DeletePlan, error) {
 	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: options.Kubeconfig})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
-		return err
+	if !options.DryRun {
+		// Prevents other clusterctl operations from changing the management cluster while deleting.
+		unlock, err := lockOperation(clusterClient, cluster.AuditDeleteOperation, options.ForceLock)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
+		if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
+			return nil, err
+		}
 	}
 
 	// Get the list of installed providers.
 	installedProviders, err := clusterClient.ProviderInventory().List()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Prepare the list of providers to delete.
@@ -93,7 +128,7 @@ func (c *clusterctlClient) Delete(options DeleteOptions) error {
 			// Parse the abbreviated syntax for name[:version]
 			name, _, err := parseProviderName(provider.Name)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			// If the namespace where the provider is installed is not provided, try to detect it
@@ -101,13 +136,13 @@ func (c *clusterctlClient) Delete(options DeleteOptions) error {
 			if provider.Namespace == "" {
 				provider.Namespace, err = clusterClient.ProviderInventory().GetDefaultProviderNamespace(provider.ProviderName, provider.GetProviderType())
 				if err != nil {
-					return err
+					return nil, err
 				}
 
 				// if there are more instance of a providers, it is not possible to get a default namespace for the provider,
 				// so we should return and ask for it.
 				if provider.Namespace == "" {
-					return errors.Errorf("Unable to find default namespace for the %q provider. Please specify the provider's namespace", name)
+					return nil, errors.Errorf("Unable to find default namespace for the %q provider. Please specify the provider's namespace", name)
 				}
 			}
 
@@ -130,14 +165,98 @@ func (c *clusterctlClient) Delete(options DeleteOptions) error {
 		}
 	}
 
+	// Prepare the list of objects to delete.
+	plan := &DeletePlan{
+		Providers: make([]ProviderDeletePlan, 0, len(providersToDelete)),
+		Warnings:  deleteWarnings(providersToDelete, installedProviders.Items, options),
+	}
+	for _, provider := range providersToDelete {
+		providerPlan, err := clusterClient.ProviderComponents().PlanDelete(cluster.DeleteOptions{Provider: provider, IncludeNamespace: options.IncludeNamespace, IncludeCRDs: options.IncludeCRDs})
+		if err != nil {
+			return nil, err
+		}
+		plan.Providers = append(plan.Providers, ProviderDeletePlan(*providerPlan))
+	}
+
+	if options.DryRun {
+		return plan, nil
+	}
+
+	// Asks for confirmation before deleting the selected providers.
+	if err := confirmAction(options.Confirm, deleteAction(providersToDelete, options)); err != nil {
+		return nil, err
+	}
+
 	// Delete the selected providers
+	deleted := make([]string, 0, len(providersToDelete))
 	for _, provider := range providersToDelete {
 		if err := clusterClient.ProviderComponents().Delete(cluster.DeleteOptions{Provider: provider, IncludeNamespace: options.IncludeNamespace, IncludeCRDs: options.IncludeCRDs}); err != nil {
-			return err
+			return nil, err
 		}
+		deleted = append(deleted, cluster.AuditProviderRef(provider, provider.Version))
 	}
 
-	return nil
+	// Records the operation in the audit log of the management cluster.
+	recordOperation(clusterClient, cluster.AuditDeleteOperation, deleted, map[string]string{
+		"includeNamespace": strconv.FormatBool(options.IncludeNamespace),
+		"includeCRDs":      strconv.FormatBool(options.IncludeCRDs),
+	})
+
+	return plan, nil
+}
+
+// deleteWarnings returns the consequences of deleting the providers going beyond their own components.
+func deleteWarnings(providersToDelete, installedProviders []clusterctlv1.Provider, options DeleteOptions) []string {
+	var warnings []string
+
+	deleting := sets.NewString()
+	for _, provider := range providersToDelete {
+		deleting.Insert(provider.InstanceName())
+	}
+
+	if options.IncludeNamespace {
+		namespaces := sets.NewString()
+		for _, provider := range providersToDelete {
+			namespaces.Insert(provider.Namespace)
+		}
+		for _, namespace := range namespaces.List() {
+			warnings = append(warnings, fmt.Sprintf("The namespace %s is deleted with all the contained objects, including the objects not installed by clusterctl", namespace))
+		}
+	}
+
+	if !options.IncludeCRDs {
+		return warnings
+	}
+	for _, provider := range providersToDelete {
+		// CRDs and webhooks are shared among all the instances of a provider, so deleting them affects the instances not being deleted too.
+		others := sets.NewString()
+		for _, installed := range installedProviders {
+			if installed.ManifestLabel() == provider.ManifestLabel() && !deleting.Has(installed.InstanceName()) {
+				others.Insert(installed.Namespace)
+			}
+		}
+		if others.Len() > 0 {
+			warnings = append(warnings, fmt.Sprintf("The CRDs and the webhooks of the %s provider are shared with the instances in the %s namespaces; deleting them deletes all the objects of the provider's kinds, including the objects managed by those instances",
+				provider.ManifestLabel(), strings.Join(others.List(), ", ")))
+		}
+	}
+	return warnings
+}
+
+// deleteAction returns a description of the deletion of the providers.
+func deleteAction(providers []clusterctlv1.Provider, options DeleteOptions) string {
+	names := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		names = append(names, provider.InstanceName())
+	}
+	action := fmt.Sprintf("Delete the providers %s", strings.Join(names, ", "))
+	if options.IncludeNamespace {
+		action += ", including the namespaces where the providers are hosted and all the contained objects"
+	}
+	if options.IncludeCRDs {
+		action += ", including the provider's CRDs and all the related objects"
+	}
+	return action
 }
 
 func appendProviders(list []clusterctlv1.Provider, providerType clusterctlv1.ProviderType, names ...string) []clusterctlv1.Provider {
@@ -21,10 +21,15 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func Test_clusterctlClient_Delete(t *testing.T) {
@@ -39,6 +44,7 @@ func Test_clusterctlClient_Delete(t *testing.T) {
 		fields        fields
 		args          args
 		wantProviders sets.String
+		wantAction    string
 		wantErr       bool
 	}{
 		{
@@ -83,6 +89,39 @@ func Test_clusterctlClient_Delete(t *testing.T) {
 			wantProviders: sets.NewString(capiProviderConfig.Name()),
 			wantErr:       false,
 		},
+		{
+			name: "Delete single provider when confirmed",
+			fields: fields{
+				client: fakeClusterForDelete(),
+			},
+			args: args{
+				options: DeleteOptions{
+					Kubeconfig:         Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					IncludeCRDs:        true,
+					Namespace:          "capbpk-system",
+					BootstrapProviders: []string{bootstrapProviderConfig.Name()},
+					Confirm:            func(string) bool { return true },
+				},
+			},
+			wantProviders: sets.NewString(capiProviderConfig.Name()),
+			wantAction:    "Delete the providers capbpk-system/bootstrap-kubeadm, including the provider's CRDs and all the related objects",
+			wantErr:       false,
+		},
+		{
+			name: "Do not delete providers if the deletion is not confirmed",
+			fields: fields{
+				client: fakeClusterForDelete(),
+			},
+			args: args{
+				options: DeleteOptions{
+					Kubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					DeleteAll:  true,
+					Confirm:    func(string) bool { return false },
+				},
+			},
+			wantProviders: sets.NewString(capiProviderConfig.Name(), clusterctlv1.ManifestLabel(bootstrapProviderConfig.Name(), bootstrapProviderConfig.Type())),
+			wantErr:       true,
+		},
 		{
 			name: "Delete single provider auto-detect namespace",
 			fields: fields{
@@ -109,12 +148,26 @@ func Test_clusterctlClient_Delete(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
 
-			err := tt.fields.client.Delete(tt.args.options)
+			gotAction := ""
+			if confirm := tt.args.options.Confirm; confirm != nil {
+				tt.args.options.Confirm = func(action string) bool {
+					gotAction = action
+					return confirm(action)
+				}
+			}
+
+			_, err := tt.fields.client.Delete(tt.args.options)
 			if tt.wantErr {
 				g.Expect(err).To(HaveOccurred())
-				return
+				if tt.args.options.Confirm != nil {
+					g.Expect(errors.Cause(err)).To(Equal(ErrOperationNotConfirmed))
+				}
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			if tt.wantAction != "" {
+				g.Expect(gotAction).To(Equal(tt.wantAction))
 			}
-			g.Expect(err).NotTo(HaveOccurred())
 
 			input := cluster.Kubeconfig(tt.args.options.Kubeconfig)
 			proxy := tt.fields.client.clusters[input].Proxy()
@@ -134,6 +187,51 @@ func Test_clusterctlClient_Delete(t *testing.T) {
 	}
 }
 
+func Test_clusterctlClient_Delete_DryRun(t *testing.T) {
+	g := NewWithT(t)
+
+	client := fakeClusterForDelete()
+	input := cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}
+	fakeProxy := client.clusters[input].(*fakeClusterClient).fakeProxy
+	// A second instance of the bootstrap provider, sharing the CRDs with the instance being deleted.
+	fakeProxy.WithProviderInventory(bootstrapProviderConfig.Name(), bootstrapProviderConfig.Type(), "v1.0.0", "capbpk-system-2", "")
+	fakeProxy.WithObjs(&appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "capbpk-system",
+			Name:      "manager",
+			Labels:    map[string]string{clusterv1.ProviderLabelName: "bootstrap-kubeadm"},
+		},
+	})
+
+	plan, err := client.Delete(DeleteOptions{
+		Kubeconfig:         Kubeconfig(input),
+		IncludeNamespace:   true,
+		IncludeCRDs:        true,
+		Namespace:          "capbpk-system",
+		BootstrapProviders: []string{bootstrapProviderConfig.Name()},
+		Confirm:            func(string) bool { return false },
+		DryRun:             true,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(plan.Providers).To(HaveLen(1))
+	g.Expect(plan.Providers[0].Provider).To(Equal("capbpk-system/bootstrap-kubeadm"))
+	g.Expect(plan.Providers[0].Resources).To(ContainElement(cluster.DeletePlanObject{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "capbpk-system", Name: "manager"}))
+	g.Expect(plan.Warnings).To(ConsistOf(
+		"The namespace capbpk-system is deleted with all the contained objects, including the objects not installed by clusterctl",
+		"The CRDs and the webhooks of the bootstrap-kubeadm provider are shared with the instances in the capbpk-system-2 namespaces; deleting them deletes all the objects of the provider's kinds, including the objects managed by those instances",
+	))
+
+	// Nothing is deleted, and the deletion is not confirmed.
+	c, err := fakeProxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+	gotProviders := &clusterctlv1.ProviderList{}
+	g.Expect(c.List(context.Background(), gotProviders)).To(Succeed())
+	g.Expect(gotProviders.Items).To(HaveLen(3))
+	g.Expect(c.Get(context.Background(), ctrlclient.ObjectKey{Namespace: "capbpk-system", Name: "manager"}, &appsv1.Deployment{})).To(Succeed())
+}
+
 // clusterctl client for a management cluster with capi and bootstrap provider
 func fakeClusterForDelete() *fakeClient {
 	config1 := newFakeConfig().
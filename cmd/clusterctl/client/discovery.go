@@ -0,0 +1,177 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+// ListProviderNamesOptions carries the options supported by ListProviderNames.
+type ListProviderNamesOptions struct {
+	// ProviderType returns only the names of the providers of the given type; if empty, the names of the
+	// providers of all the types are returned.
+	ProviderType clusterctlv1.ProviderType
+}
+
+// ListProviderVersionsOptions carries the options supported by ListProviderVersions.
+type ListProviderVersionsOptions struct {
+	// Provider is the name of the provider, as defined in the clusterctl configuration.
+	Provider string
+
+	// ProviderType is the type of the provider.
+	ProviderType clusterctlv1.ProviderType
+}
+
+// ListFlavorsOptions carries the options supported by ListFlavors.
+type ListFlavorsOptions struct {
+	// InfrastructureProvider to read the flavors from, using the name[:version] syntax; if the version is not
+	// specified, the default version of the provider repository is used.
+	InfrastructureProvider string
+}
+
+// ListNamespacesOptions carries the options supported by ListNamespaces.
+type ListNamespacesOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+}
+
+// ListProviderNames returns the sorted names of the providers defined in the clusterctl configuration.
+func (c *clusterctlClient) ListProviderNames(options ListProviderNamesOptions) ([]string, error) {
+	providers, err := c.configClient.Providers().List()
+	if err != nil {
+		return nil, err
+	}
+
+	names := sets.NewString()
+	for _, p := range providers {
+		if options.ProviderType != "" && p.Type() != options.ProviderType {
+			continue
+		}
+		names.Insert(p.Name())
+	}
+	return names.List(), nil
+}
+
+// ListProviderVersions returns the versions available in the repository of a provider, newest first; the versions
+// that are not semantic versions are reported last.
+func (c *clusterctlClient) ListProviderVersions(options ListProviderVersionsOptions) ([]string, error) {
+	if options.Provider == "" {
+		return nil, errors.New("provider name is required")
+	}
+	if options.ProviderType == "" {
+		return nil, errors.New("provider type is required")
+	}
+
+	providerConfig, err := c.configClient.Providers().Get(options.Provider, options.ProviderType)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := c.repositoryClientFactory(RepositoryClientFactoryInput{provider: providerConfig})
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := repo.GetVersions()
+	if err != nil {
+		return nil, err
+	}
+	sortVersionsNewestFirst(versions)
+	return versions, nil
+}
+
+// ListFlavors returns the sorted flavors of the workload cluster templates published by an infrastructure provider;
+// the default template, which has no flavor, is not reported.
+func (c *clusterctlClient) ListFlavors(options ListFlavorsOptions) ([]string, error) {
+	if options.InfrastructureProvider == "" {
+		return nil, errors.New("infrastructure provider name is required")
+	}
+
+	name, version, err := parseProviderName(options.InfrastructureProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	providerConfig, err := c.configClient.Providers().Get(name, clusterctlv1.InfrastructureProviderType)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := c.listProviderTemplates(providerConfig, version)
+	if err != nil {
+		return nil, err
+	}
+
+	flavors := sets.NewString()
+	for _, t := range templates {
+		if t.Flavor != "" {
+			flavors.Insert(t.Flavor)
+		}
+	}
+	return flavors.List(), nil
+}
+
+// ListNamespaces returns the sorted names of the namespaces existing in the management cluster.
+func (c *clusterctlClient) ListNamespaces(options ListNamespacesOptions) ([]string, error) {
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := clusterClient.Proxy().NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	namespaceList := &corev1.NamespaceList{}
+	if err := cs.List(context.Background(), namespaceList); err != nil {
+		return nil, errors.Wrap(err, "failed to list namespaces")
+	}
+
+	names := make([]string, 0, len(namespaceList.Items))
+	for _, ns := range namespaceList.Items {
+		names = append(names, ns.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// sortVersionsNewestFirst sorts semantic versions from the newest to the oldest, keeping the other versions last.
+func sortVersionsNewestFirst(versions []string) {
+	parsed := make(map[string]*version.Version, len(versions))
+	for _, v := range versions {
+		if sv, err := version.ParseSemantic(v); err == nil {
+			parsed[v] = sv
+		}
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		vi, vj := parsed[versions[i]], parsed[versions[j]]
+		if vi == nil || vj == nil {
+			return vi != nil && vj == nil
+		}
+		return vj.LessThan(vi)
+	})
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"sort"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+func Test_clusterctlClient_Discovery(t *testing.T) {
+	kubeadmBootstrap := config.NewProvider("kubeadm", "url", clusterctlv1.BootstrapProviderType)
+	kubeadmControlPlane := config.NewProvider("kubeadm", "url", clusterctlv1.ControlPlaneProviderType)
+	infra1Config := config.NewProvider("infra1", "url", clusterctlv1.InfrastructureProviderType)
+
+	config1 := newFakeConfig().
+		WithProvider(kubeadmBootstrap).
+		WithProvider(kubeadmControlPlane).
+		WithProvider(infra1Config)
+
+	repository1 := newFakeRepository(infra1Config, config1).
+		WithPaths("root", "components").
+		WithDefaultVersion("v1.1.0").
+		WithVersions("v1.0.0", "v1.1.0", "latest", "v1.1.0-rc.1", "v0.9.0").
+		WithMetadata("v1.1.0", &clusterctlv1.Metadata{
+			ReleaseSeries: []clusterctlv1.ReleaseSeries{
+				{Major: 1, Minor: 1, Contract: "v1alpha3"},
+			},
+			Templates: []clusterctlv1.TemplateMetadata{
+				{Description: "Default cluster"},
+				{Flavor: "ha", Description: "Cluster with 3 control plane machines"},
+				{Flavor: "gpu", Description: "Cluster with GPU worker nodes"},
+			},
+		}).
+		WithMetadata("v1.0.0", &clusterctlv1.Metadata{
+			ReleaseSeries: []clusterctlv1.ReleaseSeries{
+				{Major: 1, Minor: 0, Contract: "v1alpha3"},
+			},
+			Templates: []clusterctlv1.TemplateMetadata{
+				{Flavor: "ha", Description: "Cluster with 3 control plane machines"},
+			},
+		})
+
+	cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1).
+		WithObjs(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns2"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1"}},
+		)
+
+	client := newFakeClient(config1).
+		WithCluster(cluster1).
+		WithRepository(repository1)
+
+	t.Run("ListProviderNames", func(t *testing.T) {
+		g := NewWithT(t)
+
+		got, err := client.ListProviderNames(ListProviderNamesOptions{})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(got).To(ContainElements("infra1", "kubeadm"))
+		g.Expect(sort.StringsAreSorted(got)).To(BeTrue())
+		g.Expect(sets.NewString(got...).Len()).To(Equal(len(got)))
+
+		got, err = client.ListProviderNames(ListProviderNamesOptions{ProviderType: clusterctlv1.InfrastructureProviderType})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(got).To(ContainElement("infra1"))
+		g.Expect(got).NotTo(ContainElement("kubeadm"))
+	})
+
+	t.Run("ListProviderVersions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		got, err := client.ListProviderVersions(ListProviderVersionsOptions{Provider: "infra1", ProviderType: clusterctlv1.InfrastructureProviderType})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(got).To(Equal([]string{"v1.1.0", "v1.1.0-rc.1", "v1.0.0", "v0.9.0", "latest"}))
+
+		_, err = client.ListProviderVersions(ListProviderVersionsOptions{Provider: "infra1"})
+		g.Expect(err).To(HaveOccurred())
+
+		_, err = client.ListProviderVersions(ListProviderVersionsOptions{Provider: "infra1", ProviderType: clusterctlv1.BootstrapProviderType})
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("ListFlavors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		got, err := client.ListFlavors(ListFlavorsOptions{InfrastructureProvider: "infra1"})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(got).To(Equal([]string{"gpu", "ha"}))
+
+		got, err = client.ListFlavors(ListFlavorsOptions{InfrastructureProvider: "infra1:v1.0.0"})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(got).To(Equal([]string{"ha"}))
+
+		_, err = client.ListFlavors(ListFlavorsOptions{InfrastructureProvider: "kubeadm"})
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("ListNamespaces", func(t *testing.T) {
+		g := NewWithT(t)
+
+		got, err := client.ListNamespaces(ListNamespacesOptions{Kubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(got).To(Equal([]string{"ns1", "ns2"}))
+	})
+}
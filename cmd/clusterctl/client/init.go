@@ -18,6 +18,7 @@ package client
 
 import (
 	"sort"
+	"time"
 
 	"github.com/pkg/errors"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
@@ -58,9 +59,48 @@ type InitOptions struct {
 	// If unspecified, the providers watches for Cluster API objects across all namespaces.
 	WatchingNamespace string
 
+	// NamespaceLabels defines additional labels for the target namespaces created by Init, e.g. the
+	// pod-security.kubernetes.io labels required by the cluster. Existing namespaces are not changed.
+	NamespaceLabels map[string]string
+
+	// NamespaceAnnotations defines additional annotations for the target namespaces created by Init, e.g. the
+	// PodSecurityPolicy related annotations required by the cluster. Existing namespaces are not changed.
+	NamespaceAnnotations map[string]string
+
+	// FeatureGates defines the feature gates to be set on the provider controllers, e.g. MachinePool=true.
+	// Each feature gate is set only on the providers supporting it, and it is recorded in the provider inventory,
+	// so it is preserved across upgrades.
+	FeatureGates map[string]bool
+
+	// WaitProviders instructs Init to wait for the CRDs of each provider to be Established and for its Deployments
+	// to be Available before returning, instead of returning as soon as the provider components are applied.
+	WaitProviders bool
+
+	// WaitProviderTimeout defines the maximum time to wait for each provider to be ready when WaitProviders is set.
+	// If unspecified, it defaults to 5 minutes.
+	WaitProviderTimeout time.Duration
+
+	// SpecFile is the path of a YAML file declaring the providers, versions, namespaces and variables to be used for
+	// initializing the management cluster (see InitSpec). It can not be used together with the provider and namespace options.
+	SpecFile string
+
+	// LockFile is the path of the file pinning the versions of the providers, and the digests of their components
+	// (see ProvidersLock). If the file does not exist, it is created with the versions resolved by Init; otherwise,
+	// the providers without an explicit version are installed at the locked versions, and Init fails if a locked
+	// provider is requested at a different version or if its components do not match the locked digest.
+	// The providers not in the file are added to it. The LockFile is used only by Init and InitImages.
+	LockFile string
+
+	// RefreshLock resolves the provider versions again, ignoring the versions in the LockFile, and updates the LockFile.
+	RefreshLock bool
+
 	// LogUsageInstructions instructs the init command to print the usage instructions in case of first run.
 	LogUsageInstructions bool
 
+	// ForceLock forces the operation even if the management cluster is locked by another clusterctl operation,
+	// e.g. because the lock has been left behind by an operation that failed without releasing it.
+	ForceLock bool
+
 	// skipVariables skips variable parsing in the provider components yaml.
 	// It is set to true for listing images of provider components.
 	skipVariables bool
@@ -71,13 +111,25 @@ func (c *clusterctlClient) Init(options InitOptions) ([]Components, error) {
 	log := logf.Log
 
 	// gets access to the management cluster
-	cluster, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: options.Kubeconfig})
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// prevents other clusterctl operations from changing the management cluster while init is executed.
+	unlock, err := lockOperation(clusterClient, cluster.AuditInitOperation, options.ForceLock)
 	if err != nil {
 		return nil, err
 	}
+	defer unlock()
 
 	// ensure the custom resource definitions required by clusterctl are in place
-	if err := cluster.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
+	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
+		return nil, err
+	}
+
+	// reads the providers, namespaces and variables from the spec file, if any.
+	if err := c.applyInitSpecFile(&options); err != nil {
 		return nil, err
 	}
 
@@ -85,11 +137,17 @@ func (c *clusterctlClient) Init(options InitOptions) ([]Components, error) {
 	// if not we consider this the first time init is executed, and thus we enforce the installation of a core provider,
 	// a bootstrap provider and a control-plane provider (if not already explicitly requested by the user)
 	log.Info("Fetching providers")
-	firstRun := c.addDefaultProviders(cluster, &options)
+	firstRun := c.addDefaultProviders(clusterClient, &options)
+
+	// reads the provider versions pinned by the lock file, if any.
+	lock, err := loadInitLock(options)
+	if err != nil {
+		return nil, err
+	}
 
 	// create an installer service, add the requested providers to the install queue and then perform validation
 	// of the target state of the management cluster before starting the installation.
-	installer, err := c.setupInstaller(cluster, options)
+	installer, err := c.setupInstaller(clusterClient, options, lock)
 	if err != nil {
 		return nil, err
 	}
@@ -105,15 +163,33 @@ func (c *clusterctlClient) Init(options InitOptions) ([]Components, error) {
 	}
 
 	// Before installing the providers, ensure the cert-manager Webhook is in place.
-	if err := cluster.CertManager().EnsureWebhook(); err != nil {
+	if err := clusterClient.CertManager().EnsureWebhook(); err != nil {
 		return nil, err
 	}
 
-	components, err := installer.Install()
+	// Installs the providers, creating the target namespaces that do not exist, and eventually waits for them to be ready.
+	components, err := installer.Install(cluster.InstallOptions{
+		Namespace: cluster.NamespaceOptions{
+			Labels:      options.NamespaceLabels,
+			Annotations: options.NamespaceAnnotations,
+		},
+		WaitProviders:       options.WaitProviders,
+		WaitProviderTimeout: options.WaitProviderTimeout,
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	// Records the operation in the audit log of the management cluster.
+	recordInitOperation(clusterClient, components)
+
+	// Pins the installed provider versions in the lock file, if any.
+	if lock != nil {
+		if err := lock.Write(options.LockFile); err != nil {
+			return nil, err
+		}
+	}
+
 	// If this is the firstRun, then log the usage instructions.
 	if firstRun && options.LogUsageInstructions {
 		log.Info("")
@@ -141,6 +217,11 @@ func (c *clusterctlClient) InitImages(options InitOptions) ([]string, error) {
 		return nil, err
 	}
 
+	// reads the providers, namespaces and variables from the spec file, if any.
+	if err := c.applyInitSpecFile(&options); err != nil {
+		return nil, err
+	}
+
 	// checks if the cluster already contains a Core provider.
 	// if not we consider this the first time init is executed, and thus we enforce the installation of a core provider,
 	// a bootstrap provider and a control-plane provider (if not already explicitly requested by the user)
@@ -149,9 +230,15 @@ func (c *clusterctlClient) InitImages(options InitOptions) ([]string, error) {
 	// skip variable parsing when listing images
 	options.skipVariables = true
 
+	// reads the provider versions pinned by the lock file, if any; the lock file is not updated.
+	lock, err := loadInitLock(options)
+	if err != nil {
+		return nil, err
+	}
+
 	// create an installer service, add the requested providers to the install queue and then perform validation
 	// of the target state of the management cluster before starting the installation.
-	installer, err := c.setupInstaller(cluster, options)
+	installer, err := c.setupInstaller(cluster, options, lock)
 	if err != nil {
 		return nil, err
 	}
@@ -169,13 +256,16 @@ func (c *clusterctlClient) InitImages(options InitOptions) ([]string, error) {
 	return images, nil
 }
 
-func (c *clusterctlClient) setupInstaller(cluster cluster.Client, options InitOptions) (cluster.ProviderInstaller, error) {
+func (c *clusterctlClient) setupInstaller(cluster cluster.Client, options InitOptions, lock *ProvidersLock) (cluster.ProviderInstaller, error) {
 	installer := cluster.ProviderInstaller()
 
 	addOptions := addToInstallerOptions{
 		installer:         installer,
+		lock:              lock,
+		refreshLock:       options.RefreshLock,
 		targetNamespace:   options.TargetNamespace,
 		watchingNamespace: options.WatchingNamespace,
+		featureGates:      options.FeatureGates,
 		skipVariables:     options.skipVariables,
 	}
 
@@ -200,6 +290,18 @@ func (c *clusterctlClient) setupInstaller(cluster cluster.Client, options InitOp
 	return installer, nil
 }
 
+// loadInitLock reads the lock file, if any; when refreshing the lock, the providers installed by Init are locked
+// again, while the locks of the other providers are preserved.
+func loadInitLock(options InitOptions) (*ProvidersLock, error) {
+	if options.LockFile == "" {
+		if options.RefreshLock {
+			return nil, errors.New("the lock can not be refreshed without a lock file")
+		}
+		return nil, nil
+	}
+	return LoadProvidersLock(options.LockFile)
+}
+
 func (c *clusterctlClient) addDefaultProviders(cluster cluster.Client, options *InitOptions) bool {
 	firstRun := false
 	// Check if there is already a core provider installed in the cluster
@@ -226,8 +328,11 @@ func (c *clusterctlClient) addDefaultProviders(cluster cluster.Client, options *
 
 type addToInstallerOptions struct {
 	installer         cluster.ProviderInstaller
+	lock              *ProvidersLock
+	refreshLock       bool
 	targetNamespace   string
 	watchingNamespace string
+	featureGates      map[string]bool
 	skipVariables     bool
 }
 
@@ -244,9 +349,17 @@ func (c *clusterctlClient) addToInstaller(options addToInstallerOptions, provide
 		componentsOptions := repository.ComponentsOptions{
 			TargetNamespace:   options.targetNamespace,
 			WatchingNamespace: options.watchingNamespace,
+			FeatureGates:      options.featureGates,
 			SkipVariables:     options.skipVariables,
 		}
-		components, err := c.getComponentsByName(provider, providerType, componentsOptions)
+
+		// Reads the locked version of the provider, if any.
+		lockedProvider, digest, err := lockedProviderName(options.lock, options.refreshLock, provider, providerType)
+		if err != nil {
+			return err
+		}
+
+		components, err := c.getComponentsByName(lockedProvider, providerType, componentsOptions)
 		if err != nil {
 			return errors.Wrapf(err, "failed to get provider components for the %q provider", provider)
 		}
@@ -255,6 +368,18 @@ func (c *clusterctlClient) addToInstaller(options addToInstallerOptions, provide
 			return errors.Errorf("can't use %q provider as an %q, it is a %q", provider, providerType, components.Type())
 		}
 
+		if digest != "" && components.Digest() != digest {
+			return errors.Errorf("the components of the %q provider version %s do not match the digest in the lock file, %s instead of %s", provider, components.Version(), components.Digest(), digest)
+		}
+		if options.lock != nil {
+			options.lock.Set(LockedProvider{
+				Name:    components.Name(),
+				Type:    providerType,
+				Version: components.Version(),
+				Digest:  components.Digest(),
+			})
+		}
+
 		options.installer.Add(components)
 	}
 	return nil
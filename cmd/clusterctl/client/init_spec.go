@@ -0,0 +1,190 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/yaml"
+)
+
+// InitSpec declares the providers, versions, namespaces and variables used for initializing a management cluster,
+// so the operation can be stored in a file, reviewed and repeated.
+type InitSpec struct {
+	// CoreProvider to add to the management cluster. If unspecified, the cluster-api core provider's latest release is used.
+	CoreProvider *InitSpecProvider `json:"core,omitempty"`
+
+	// BootstrapProviders to add to the management cluster. If unspecified, the kubeadm bootstrap provider's latest release is used.
+	BootstrapProviders []InitSpecProvider `json:"bootstrap,omitempty"`
+
+	// ControlPlaneProviders to add to the management cluster. If unspecified, the kubeadm control plane provider's latest release is used.
+	ControlPlaneProviders []InitSpecProvider `json:"controlPlane,omitempty"`
+
+	// InfrastructureProviders to add to the management cluster.
+	InfrastructureProviders []InitSpecProvider `json:"infrastructure,omitempty"`
+
+	// TargetNamespace defines the namespace where the providers should be deployed. If unspecified, each provider
+	// will be installed in a provider's default namespace.
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+
+	// WatchingNamespace defines the namespace the providers should watch to reconcile Cluster API objects.
+	// If unspecified, the providers watches for Cluster API objects across all namespaces.
+	WatchingNamespace string `json:"watchingNamespace,omitempty"`
+
+	// NamespaceLabels defines additional labels for the target namespaces created by clusterctl,
+	// e.g. pod-security.kubernetes.io/enforce: privileged.
+	NamespaceLabels map[string]string `json:"namespaceLabels,omitempty"`
+
+	// NamespaceAnnotations defines additional annotations for the target namespaces created by clusterctl.
+	NamespaceAnnotations map[string]string `json:"namespaceAnnotations,omitempty"`
+
+	// FeatureGates defines the feature gates to be set on the provider controllers supporting them, e.g. MachinePool: true.
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+
+	// Variables to be used for processing the provider components; they override the values from the environment
+	// variables and from the clusterctl configuration file.
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// InitSpecProvider defines a provider in an InitSpec.
+type InitSpecProvider struct {
+	// Name of the provider (e.g. aws). The '-' value can be used for opting-out from the automatic installation
+	// of the bootstrap and control plane providers.
+	Name string `json:"name"`
+
+	// Version of the provider (e.g. v0.5.0). If unspecified, the provider's latest release is used.
+	Version string `json:"version,omitempty"`
+}
+
+// String returns the provider in the name[:version] form.
+func (p InitSpecProvider) String() string {
+	if p.Version == "" {
+		return p.Name
+	}
+	return p.Name + ":" + p.Version
+}
+
+// LoadInitSpec reads an InitSpec from a YAML file.
+func LoadInitSpec(path string) (*InitSpec, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the init spec file %q", path)
+	}
+
+	spec := &InitSpec{}
+	if err := yaml.UnmarshalStrict(content, spec); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the init spec file %q", path)
+	}
+	return spec, nil
+}
+
+// applyInitSpecFile reads the InitSpec from options.SpecFile, if any, sets the providers and the namespaces in the
+// options, and sets the variable overrides.
+func (c *clusterctlClient) applyInitSpecFile(options *InitOptions) error {
+	if options.SpecFile == "" {
+		return nil
+	}
+
+	if options.CoreProvider != "" ||
+		len(options.BootstrapProviders) > 0 ||
+		len(options.ControlPlaneProviders) > 0 ||
+		len(options.InfrastructureProviders) > 0 ||
+		options.TargetNamespace != "" ||
+		options.WatchingNamespace != "" ||
+		len(options.NamespaceLabels) > 0 ||
+		len(options.NamespaceAnnotations) > 0 ||
+		len(options.FeatureGates) > 0 {
+		return errors.New("the spec file can not be used together with the provider and namespace options")
+	}
+
+	spec, err := LoadInitSpec(options.SpecFile)
+	if err != nil {
+		return err
+	}
+
+	if spec.CoreProvider != nil {
+		options.CoreProvider = spec.CoreProvider.String()
+	}
+	options.BootstrapProviders = initSpecProviderNames(spec.BootstrapProviders)
+	options.ControlPlaneProviders = initSpecProviderNames(spec.ControlPlaneProviders)
+	options.InfrastructureProviders = initSpecProviderNames(spec.InfrastructureProviders)
+	options.TargetNamespace = spec.TargetNamespace
+	options.WatchingNamespace = spec.WatchingNamespace
+	options.NamespaceLabels = spec.NamespaceLabels
+	options.NamespaceAnnotations = spec.NamespaceAnnotations
+	options.FeatureGates = spec.FeatureGates
+
+	for k, v := range spec.Variables {
+		c.configClient.Variables().Set(k, v)
+	}
+	return nil
+}
+
+func initSpecProviderNames(providers []InitSpecProvider) []string {
+	var names []string
+	for _, p := range providers {
+		names = append(names, p.String())
+	}
+	return names
+}
+
+// initSpecUpgradeItems returns the upgrade items for the providers in the management group to the versions declared
+// in the InitSpec; providers without a version, or already at the declared version, are skipped.
+func initSpecUpgradeItems(spec *InitSpec, managementGroup *cluster.ManagementGroup) ([]cluster.UpgradeItem, error) {
+	upgradeItems := []cluster.UpgradeItem{}
+	addUpgradeItems := func(providerType clusterctlv1.ProviderType, specProviders ...InitSpecProvider) error {
+		for _, specProvider := range specProviders {
+			if specProvider.Name == NoopProvider || specProvider.Version == "" {
+				continue
+			}
+
+			found := false
+			for _, p := range managementGroup.Providers {
+				if p.ProviderName != specProvider.Name || p.GetProviderType() != providerType {
+					continue
+				}
+				found = true
+				if p.Version != specProvider.Version {
+					upgradeItems = append(upgradeItems, cluster.UpgradeItem{Provider: p, NextVersion: specProvider.Version})
+				}
+			}
+			if !found {
+				return errors.Errorf("the %s %q is not part of the management group %s", providerType, specProvider.Name, managementGroup.CoreProvider.InstanceName())
+			}
+		}
+		return nil
+	}
+
+	if spec.CoreProvider != nil {
+		if err := addUpgradeItems(clusterctlv1.CoreProviderType, *spec.CoreProvider); err != nil {
+			return nil, err
+		}
+	}
+	if err := addUpgradeItems(clusterctlv1.BootstrapProviderType, spec.BootstrapProviders...); err != nil {
+		return nil, err
+	}
+	if err := addUpgradeItems(clusterctlv1.ControlPlaneProviderType, spec.ControlPlaneProviders...); err != nil {
+		return nil, err
+	}
+	if err := addUpgradeItems(clusterctlv1.InfrastructureProviderType, spec.InfrastructureProviders...); err != nil {
+		return nil, err
+	}
+	return upgradeItems, nil
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+func writeInitSpec(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "init-spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "spec.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func Test_LoadInitSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *InitSpec
+		wantErr bool
+	}{
+		{
+			name: "read a spec",
+			content: `core:
+  name: cluster-api
+  version: v1.0.0
+bootstrap:
+- name: "-"
+infrastructure:
+- name: infra
+  version: v3.0.0
+targetNamespace: ns
+variables:
+  SOME_VARIABLE: value
+`,
+			want: &InitSpec{
+				CoreProvider:            &InitSpecProvider{Name: "cluster-api", Version: "v1.0.0"},
+				BootstrapProviders:      []InitSpecProvider{{Name: "-"}},
+				InfrastructureProviders: []InitSpecProvider{{Name: "infra", Version: "v3.0.0"}},
+				TargetNamespace:         "ns",
+				Variables:               map[string]string{"SOME_VARIABLE": "value"},
+			},
+		},
+		{
+			name:    "fails for unknown fields",
+			content: "providers:\n- name: infra\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			path, cleanup := writeInitSpec(t, tt.content)
+			defer cleanup()
+
+			got, err := LoadInitSpec(path)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_clusterctlClient_Init_SpecFile(t *testing.T) {
+	g := NewWithT(t)
+
+	// the config does not have the value for SOME_VARIABLE, which is provided by the spec.
+	fconfig := fakeConfig([]config.Provider{capiProviderConfig, bootstrapProviderConfig, controlPlaneProviderConfig, infraProviderConfig}, nil)
+	frepositories := fakeRepositories(fconfig, nil)
+	fcluster := fakeCluster(fconfig, frepositories, newFakeCertManagerClient(nil, nil))
+	fclient := fakeClusterCtlClient(fconfig, frepositories, []*fakeClusterClient{fcluster})
+
+	path, cleanup := writeInitSpec(t, `core:
+  name: cluster-api
+  version: v1.0.0
+controlPlane:
+- name: "-"
+infrastructure:
+- name: infra
+  version: v3.0.0
+targetNamespace: nsx
+variables:
+  SOME_VARIABLE: value
+`)
+	defer cleanup()
+
+	// the spec file can not be used together with the provider options.
+	_, err := fclient.Init(InitOptions{
+		Kubeconfig:              Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+		InfrastructureProviders: []string{"infra"},
+		SpecFile:                path,
+	})
+	g.Expect(err).To(HaveOccurred())
+
+	got, err := fclient.Init(InitOptions{
+		Kubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+		SpecFile:   path,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(got).To(HaveLen(3))
+	for i, want := range []struct {
+		provider Provider
+		version  string
+	}{
+		{provider: capiProviderConfig, version: "v1.0.0"},
+		{provider: bootstrapProviderConfig, version: "v2.0.0"},
+		{provider: infraProviderConfig, version: "v3.0.0"},
+	} {
+		g.Expect(got[i].Name()).To(Equal(want.provider.Name()))
+		g.Expect(got[i].Type()).To(Equal(want.provider.Type()))
+		g.Expect(got[i].Version()).To(Equal(want.version))
+		g.Expect(got[i].TargetNamespace()).To(Equal("nsx"))
+	}
+}
+
+func Test_clusterctlClient_ApplyUpgrade_SpecFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		spec         string
+		wantVersions map[string]string
+		wantErr      bool
+	}{
+		{
+			name: "upgrade the providers to the versions in the spec",
+			spec: `core:
+  name: cluster-api
+  version: v1.0.0
+infrastructure:
+- name: infra
+  version: v2.0.1
+`,
+			wantVersions: map[string]string{
+				"cluster-api": "v1.0.0",
+				"infra":       "v2.0.1",
+			},
+		},
+		{
+			name: "fails if a provider is not installed",
+			spec: `infrastructure:
+- name: other
+  version: v2.0.1
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			path, cleanup := writeInitSpec(t, tt.spec)
+			defer cleanup()
+
+			client := fakeClientForUpgrade() // core v1.0.0 (v1.0.1 available), infra v2.0.0 (v2.0.1 available)
+			err := client.ApplyUpgrade(ApplyUpgradeOptions{
+				Kubeconfig:      Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+				ManagementGroup: "cluster-api-system/cluster-api",
+				SpecFile:        path,
+			})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			proxy := client.clusters[cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}].Proxy()
+			c, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			gotProviders := &clusterctlv1.ProviderList{}
+			g.Expect(c.List(context.Background(), gotProviders)).To(Succeed())
+
+			gotVersions := map[string]string{}
+			for _, p := range gotProviders.Items {
+				gotVersions[p.ProviderName] = p.Version
+			}
+			g.Expect(gotVersions).To(Equal(tt.wantVersions))
+		})
+	}
+}
@@ -17,17 +17,23 @@ limitations under the License.
 package client
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
 
+	corev1 "k8s.io/api/core/v1"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
 	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func Test_clusterctlClient_InitImages(t *testing.T) {
@@ -568,6 +574,102 @@ func setupCluster(providers []Provider, certManagerClient cluster.CertManagerCli
 }
 
 // clusterctl client for an empty management cluster (with repository setup for capi, bootstrap and infra provider)
+func Test_clusterctlClient_Init_NamespaceOptions(t *testing.T) {
+	g := NewWithT(t)
+
+	client := fakeEmptyCluster()
+
+	_, err := client.Init(InitOptions{
+		Kubeconfig:              Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+		InfrastructureProviders: []string{"infra"},
+		TargetNamespace:         "capi-providers",
+		NamespaceLabels:         map[string]string{"pod-security.kubernetes.io/enforce": "privileged"},
+		NamespaceAnnotations:    map[string]string{"owner": "platform-team"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	proxy := client.clusters[cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}].Proxy()
+	c, err := proxy.NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	namespace := &corev1.Namespace{}
+	g.Expect(c.Get(context.Background(), ctrlclient.ObjectKey{Name: "capi-providers"}, namespace)).To(Succeed())
+	g.Expect(namespace.Labels).To(HaveKeyWithValue(clusterctlv1.ClusterctlLabelName, ""))
+	g.Expect(namespace.Labels).To(HaveKeyWithValue("pod-security.kubernetes.io/enforce", "privileged"))
+	g.Expect(namespace.Annotations).To(HaveKeyWithValue("owner", "platform-team"))
+}
+
+func Test_clusterctlClient_Init_LockFile(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir, err := ioutil.TempDir("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	lockFile := filepath.Join(tmpDir, "clusterctl-lock.yaml")
+	options := InitOptions{
+		Kubeconfig:              Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+		InfrastructureProviders: []string{"infra"},
+		LockFile:                lockFile,
+	}
+
+	// The first init creates the lock file with the versions resolved by the provider repositories.
+	_, err = fakeEmptyCluster().Init(options)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	lock, err := LoadProvidersLock(lockFile)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(lock.Providers).To(HaveLen(4))
+	infra := lock.Get("infra", clusterctlv1.InfrastructureProviderType)
+	g.Expect(infra).NotTo(BeNil())
+	g.Expect(infra.Version).To(Equal("v3.0.0"))
+	g.Expect(infra.Digest).To(HavePrefix("sha256:"))
+	g.Expect(lock.Get(config.ClusterAPIProviderName, clusterctlv1.CoreProviderType).Version).To(Equal("v1.0.0"))
+
+	// The next inits use the locked versions instead of the versions resolved by the provider repositories.
+	infra.Version = "v3.1.0"
+	g.Expect(lock.Write(lockFile)).To(Succeed())
+
+	components, err := fakeEmptyCluster().Init(options)
+	g.Expect(err).NotTo(HaveOccurred())
+	for _, c := range components {
+		if c.Type() == clusterctlv1.InfrastructureProviderType {
+			g.Expect(c.Version()).To(Equal("v3.1.0"))
+		}
+	}
+
+	// A locked provider can't be installed at a different version, unless the lock is refreshed.
+	options.InfrastructureProviders = []string{"infra:v3.0.0"}
+	_, err = fakeEmptyCluster().Init(options)
+	g.Expect(err).To(HaveOccurred())
+
+	options.RefreshLock = true
+	_, err = fakeEmptyCluster().Init(options)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	lock, err = LoadProvidersLock(lockFile)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(lock.Providers).To(HaveLen(4))
+	g.Expect(lock.Get("infra", clusterctlv1.InfrastructureProviderType).Version).To(Equal("v3.0.0"))
+
+	// The components must match the locked digest.
+	lock.Get("infra", clusterctlv1.InfrastructureProviderType).Digest = "sha256:0000"
+	g.Expect(lock.Write(lockFile)).To(Succeed())
+
+	options.InfrastructureProviders = []string{"infra"}
+	options.RefreshLock = false
+	_, err = fakeEmptyCluster().Init(options)
+	g.Expect(err).To(HaveOccurred())
+
+	// The lock can't be refreshed without a lock file.
+	_, err = fakeEmptyCluster().Init(InitOptions{
+		Kubeconfig:              Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+		InfrastructureProviders: []string{"infra"},
+		RefreshLock:             true,
+	})
+	g.Expect(err).To(HaveOccurred())
+}
+
 func fakeEmptyCluster() *fakeClient {
 	// create a config variables client which contains the value for the
 	// variable required
@@ -0,0 +1,40 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+)
+
+// lockOperation acquires the operation lock of the management cluster, so other clusterctl processes can not
+// change the management cluster while the operation is executed; the returned function releases the lock.
+// Nb. Failing to release the lock does not make the operation fail, because a lock that is no longer renewed
+// becomes stale and it is ignored by the next operations.
+func lockOperation(clusterClient cluster.Client, operation cluster.AuditOperation, force bool) (func(), error) {
+	log := logf.Log
+
+	release, err := clusterClient.OperationLock().Acquire(operation, force)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := release(); err != nil {
+			log.V(1).Info("Failed to release the operation lock", "Operation", operation, "Cause", err.Error())
+		}
+	}, nil
+}
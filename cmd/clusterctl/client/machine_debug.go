@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/pkg/errors"
+)
+
+// GetMachineSSHEndpointOptions carries the options supported by GetMachineSSHEndpoint.
+type GetMachineSSHEndpointOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the Machine exists. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// MachineName is the name of the Machine to reach.
+	MachineName string
+}
+
+func (c *clusterctlClient) GetMachineSSHEndpoint(options GetMachineSSHEndpointOptions) (*MachineSSHEndpoint, error) {
+	if options.MachineName == "" {
+		return nil, errors.New("the name of the Machine is required")
+	}
+
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	endpoint, err := clusterClient.MachineDebug().GetSSHEndpoint(options.Namespace, options.MachineName)
+	if err != nil || endpoint == nil {
+		return nil, err
+	}
+	return (*MachineSSHEndpoint)(endpoint), nil
+}
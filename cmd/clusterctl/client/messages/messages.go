@@ -0,0 +1,237 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package messages implements the catalog of the messages used by clusterctl for reporting errors to the users.
+// Each message defines, in addition to the description of the error, a remediation hint and a link to the
+// documentation, so the errors returned by the config, repository and cluster packages are actionable and consistent.
+//
+// The package also defines the errors library consumers can branch on, e.g.
+//
+//	if errors.Is(err, messages.ErrVersionNotFound) { ... }
+//
+//	var missing messages.ErrVariableMissing
+//	if errors.As(err, &missing) { ... missing.Name ... }
+package messages
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ID identifies a message in the catalog.
+type ID string
+
+const (
+	// ProviderNotConfigured is reported when a provider is not defined in the clusterctl configuration.
+	ProviderNotConfigured = ID("ProviderNotConfigured")
+
+	// VariableNotSet is reported when a variable required by a template or by the provider components is not set.
+	VariableNotSet = ID("VariableNotSet")
+
+	// VariableEncrypted is reported when a variable encrypted with SOPS can't be decrypted.
+	VariableEncrypted = ID("VariableEncrypted")
+
+	// GitHubRateLimit is reported when the rate limit of the GitHub API is reached.
+	GitHubRateLimit = ID("GitHubRateLimit")
+
+	// GitHubSecondaryRateLimit is reported when the secondary rate limit of the GitHub API is triggered.
+	GitHubSecondaryRateLimit = ID("GitHubSecondaryRateLimit")
+
+	// InvalidKubeconfig is reported when the kubeconfig of the management cluster is not valid.
+	InvalidKubeconfig = ID("InvalidKubeconfig")
+
+	// ManagementClusterUnreachable is reported when clusterctl can't connect to the management cluster.
+	ManagementClusterUnreachable = ID("ManagementClusterUnreachable")
+
+	// VersionNotFound is reported when a release of a provider does not exist in the provider repository.
+	VersionNotFound = ID("VersionNotFound")
+
+	// ContractMismatch is reported when a provider supports an API Version of Cluster API (contract) different
+	// from the one used by the management group.
+	ContractMismatch = ID("ContractMismatch")
+)
+
+var (
+	// ErrProviderNotFound matches, using errors.Is, the errors reported when a provider is not defined in the
+	// clusterctl configuration.
+	ErrProviderNotFound = errors.New("provider not found")
+
+	// ErrVersionNotFound matches, using errors.Is, the errors reported when a release of a provider does not exist
+	// in the provider repository.
+	ErrVersionNotFound = errors.New("version not found")
+
+	// ErrContractMismatch matches, using errors.Is, the errors reported when a provider supports an API Version of
+	// Cluster API (contract) different from the one used by the management group.
+	ErrContractMismatch = errors.New("contract mismatch")
+)
+
+// sentinels maps the messages to the errors they match using errors.Is.
+var sentinels = map[ID]error{
+	ProviderNotConfigured: ErrProviderNotFound,
+	VersionNotFound:       ErrVersionNotFound,
+	ContractMismatch:      ErrContractMismatch,
+}
+
+// ErrVariableMissing can be extracted, using errors.As, from the errors reported when a variable required by a
+// template or by the provider components is not set.
+type ErrVariableMissing struct {
+	// Name of the variable; if more than one variable is missing, the first one in alphabetical order.
+	Name string
+}
+
+// Error implements error.
+func (e ErrVariableMissing) Error() string {
+	return fmt.Sprintf(catalog[VariableNotSet].Format, e.Name)
+}
+
+const docsURL = "https://cluster-api.sigs.k8s.io"
+
+// Message defines an entry of the catalog.
+type Message struct {
+	// Format of the description of the error, with fmt verbs for the arguments of the error.
+	Format string
+
+	// Hint describes how to fix the error.
+	Hint string
+
+	// DocsURL is a link to the documentation relevant for the error, if any.
+	DocsURL string
+}
+
+// catalog defines the messages used by clusterctl.
+var catalog = map[ID]Message{
+	ProviderNotConfigured: {
+		Format:  "failed to get configuration for the %s with name %s",
+		Hint:    "Please check the provider name and/or add configuration for new providers using the .clusterctl config file",
+		DocsURL: docsURL + "/clusterctl/configuration.html#provider-repositories",
+	},
+	VariableNotSet: {
+		Format:  "Failed to get value for variable %q",
+		Hint:    "Please set the variable value using os env variables or using the .clusterctl config file",
+		DocsURL: docsURL + "/clusterctl/configuration.html#variables",
+	},
+	VariableEncrypted: {
+		Format:  "Failed to get value for variable %q: the value is encrypted with SOPS, but it was not possible to decrypt it",
+		Hint:    "Please make the age key available, e.g. using the SOPS_AGE_KEY_FILE environment variable",
+		DocsURL: docsURL + "/clusterctl/configuration.html#encrypted-variables",
+	},
+	GitHubRateLimit: {
+		Format:  "rate limit for github api has been reached, it will be reset at %s",
+		Hint:    "Please wait or get a personal API token and assign it to the GITHUB_TOKEN environment variable",
+		DocsURL: docsURL + "/clusterctl/configuration.html#github-api",
+	},
+	GitHubSecondaryRateLimit: {
+		Format:  "the github api secondary rate limit has been triggered. Please retry after %s",
+		Hint:    "Please wait before retrying, or use a personal API token assigned to the GITHUB_TOKEN environment variable",
+		DocsURL: docsURL + "/clusterctl/configuration.html#github-api",
+	},
+	InvalidKubeconfig: {
+		Format:  "invalid kubeconfig file; clusterctl requires a valid kubeconfig file to connect to the management cluster",
+		Hint:    "Please check the kubeconfig file and context, e.g. using the --kubeconfig and --kubeconfig-context flags",
+		DocsURL: docsURL + "/clusterctl/commands/init.html#defining-the-management-cluster",
+	},
+	ManagementClusterUnreachable: {
+		Format:  "failed to connect to the management cluster",
+		Hint:    "Please check the management cluster is running and the kubeconfig points to it, e.g. using kubectl cluster-info",
+		DocsURL: docsURL + "/clusterctl/commands/init.html#defining-the-management-cluster",
+	},
+	VersionNotFound: {
+		Format:  "failed to get release %s of the %s repository",
+		Hint:    "Please check the version exists in the provider repository, e.g. using clusterctl upgrade plan for the versions available",
+		DocsURL: docsURL + "/clusterctl/configuration.html#provider-repositories",
+	},
+	ContractMismatch: {
+		Format:  "the provider %s supports the %s API Version of Cluster API (contract), while the management group is using %s",
+		Hint:    "Please use a version of the provider supporting the same API Version of Cluster API (contract) of the management group",
+		DocsURL: docsURL + "/clusterctl/provider-contract.html#metadata-yaml",
+	},
+}
+
+// Error is an error defined in the message catalog, optionally wrapping the error that caused it.
+type Error struct {
+	ID   ID
+	Args []interface{}
+
+	cause error
+}
+
+// New returns an error for the given message.
+func New(id ID, args ...interface{}) error {
+	return errors.WithStack(&Error{ID: id, Args: args})
+}
+
+// Wrap returns an error for the given message, wrapping the error that caused it.
+func Wrap(err error, id ID, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return errors.WithStack(&Error{ID: id, Args: args, cause: err})
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	msg := fmt.Sprintf(catalog[e.ID].Format, e.Args...)
+	if e.cause != nil {
+		return msg + ": " + e.cause.Error()
+	}
+	return msg
+}
+
+// Cause returns the error that caused the error, if any.
+func (e *Error) Cause() error {
+	return e.cause
+}
+
+// Unwrap returns the error that caused the error, if any.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is returns true if target is the sentinel error matching the message, e.g. ErrVersionNotFound.
+func (e *Error) Is(target error) bool {
+	sentinel, ok := sentinels[e.ID]
+	return ok && sentinel == target
+}
+
+// As sets target and returns true if target is an *ErrVariableMissing and the message reports a variable not set.
+func (e *Error) As(target interface{}) bool {
+	missing, ok := target.(*ErrVariableMissing)
+	if !ok || e.ID != VariableNotSet || len(e.Args) == 0 {
+		return false
+	}
+	missing.Name = fmt.Sprint(e.Args[0])
+	return true
+}
+
+// Hint returns the description of how to fix the error.
+func (e *Error) Hint() string {
+	return catalog[e.ID].Hint
+}
+
+// DocsURL returns the link to the documentation relevant for the error, if any.
+func (e *Error) DocsURL() string {
+	return catalog[e.ID].DocsURL
+}
+
+// Find returns the outermost error defined in the message catalog in the chain of errors, if any.
+func Find(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messages
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/pkg/errors"
+)
+
+func TestCatalog(t *testing.T) {
+	g := NewWithT(t)
+
+	for id, m := range catalog {
+		g.Expect(m.Format).NotTo(BeEmpty(), "message %s has no format", id)
+		g.Expect(m.Hint).NotTo(BeEmpty(), "message %s has no hint", id)
+		if m.DocsURL != "" {
+			g.Expect(strings.HasPrefix(m.DocsURL, docsURL)).To(BeTrue(), "message %s has an invalid docs URL", id)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantMsg   string
+		wantFound bool
+		wantID    ID
+	}{
+		{
+			name:      "message without a cause",
+			err:       New(VariableNotSet, "FOO"),
+			wantMsg:   `Failed to get value for variable "FOO"`,
+			wantFound: true,
+			wantID:    VariableNotSet,
+		},
+		{
+			name:      "message with a cause",
+			err:       Wrap(errors.New("connection refused"), ManagementClusterUnreachable),
+			wantMsg:   "failed to connect to the management cluster: connection refused",
+			wantFound: true,
+			wantID:    ManagementClusterUnreachable,
+		},
+		{
+			name:      "message wrapped by other errors",
+			err:       errors.Wrap(New(ProviderNotConfigured, "InfrastructureProvider", "foo"), "failed to init"),
+			wantMsg:   "failed to init: failed to get configuration for the InfrastructureProvider with name foo",
+			wantFound: true,
+			wantID:    ProviderNotConfigured,
+		},
+		{
+			name:      "errors not in the catalog",
+			err:       errors.New("boom"),
+			wantMsg:   "boom",
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.err.Error()).To(Equal(tt.wantMsg))
+
+			e, found := Find(tt.err)
+			g.Expect(found).To(Equal(tt.wantFound))
+			if !tt.wantFound {
+				return
+			}
+			g.Expect(e.ID).To(Equal(tt.wantID))
+			g.Expect(e.Hint()).To(Equal(catalog[tt.wantID].Hint))
+			g.Expect(e.DocsURL()).To(Equal(catalog[tt.wantID].DocsURL))
+		})
+	}
+}
+
+func TestWrapNil(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(Wrap(nil, ManagementClusterUnreachable)).To(BeNil())
+}
+
+func TestSentinels(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		sentinel error
+		want     bool
+	}{
+		{
+			name:     "provider not configured matches ErrProviderNotFound",
+			err:      errors.Wrap(New(ProviderNotConfigured, "InfrastructureProvider", "foo"), "failed to init"),
+			sentinel: ErrProviderNotFound,
+			want:     true,
+		},
+		{
+			name:     "version not found matches ErrVersionNotFound",
+			err:      Wrap(errors.New("404 Not Found"), VersionNotFound, "v1.0.0", "o/r"),
+			sentinel: ErrVersionNotFound,
+			want:     true,
+		},
+		{
+			name:     "contract mismatch matches ErrContractMismatch",
+			err:      errors.Wrap(New(ContractMismatch, "infra", "v1alpha4", "v1alpha3"), "unable to complete that upgrade"),
+			sentinel: ErrContractMismatch,
+			want:     true,
+		},
+		{
+			name:     "messages do not match other sentinels",
+			err:      New(VersionNotFound, "v1.0.0", "o/r"),
+			sentinel: ErrProviderNotFound,
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(errors.Is(tt.err, tt.sentinel)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestErrVariableMissing(t *testing.T) {
+	g := NewWithT(t)
+
+	var missing ErrVariableMissing
+	g.Expect(errors.As(errors.Wrap(New(VariableNotSet, "FOO"), "failed to get components"), &missing)).To(BeTrue())
+	g.Expect(missing.Name).To(Equal("FOO"))
+	g.Expect(missing.Error()).To(Equal(`Failed to get value for variable "FOO"`))
+
+	g.Expect(errors.As(New(VariableEncrypted, "FOO"), &missing)).To(BeFalse())
+}
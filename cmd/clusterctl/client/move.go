@@ -16,6 +16,12 @@ limitations under the License.
 
 package client
 
+import (
+	"fmt"
+
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
 // MoveOptions carries the options supported by move.
 type MoveOptions struct {
 	// FromKubeconfig defines the kubeconfig to use for accessing the source management cluster. If empty,
@@ -29,6 +35,23 @@ type MoveOptions struct {
 	// Namespace where the objects describing the workload cluster exists. If unspecified, the current
 	// namespace will be used.
 	Namespace string
+
+	// ClusterName restricts the move to the Cluster with the given name and all the objects in its object graph,
+	// while other Clusters in the namespace are left in place. If unspecified, all the Clusters in the namespace are moved.
+	ClusterName string
+
+	// Confirm is called with a description of the action before moving the objects; if it returns false,
+	// the move is aborted with ErrOperationNotConfirmed. If unspecified, the move is always performed.
+	Confirm func(action string) bool
+
+	// ForceLock forces the operation even if the management cluster is locked by another clusterctl operation,
+	// e.g. because the lock has been left behind by an operation that failed without releasing it.
+	ForceLock bool
+
+	// Sync copies the objects to the target management cluster without pausing or deleting them in the source management
+	// cluster, while the copies are paused in the target management cluster. Sync can be run repeatedly, copying only the
+	// objects changed since the last sync, for phased migrations; the final cutover is performed by a move without Sync.
+	Sync bool
 }
 
 func (c *clusterctlClient) Move(options MoveOptions) error {
@@ -38,6 +61,13 @@ func (c *clusterctlClient) Move(options MoveOptions) error {
 		return err
 	}
 
+	// Prevents other clusterctl operations from changing the source management cluster while moving.
+	unlockFrom, err := lockOperation(fromCluster, cluster.AuditMoveOperation, options.ForceLock)
+	if err != nil {
+		return err
+	}
+	defer unlockFrom()
+
 	// Ensures the custom resource definitions required by clusterctl are in place.
 	if err := fromCluster.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
 		return err
@@ -49,6 +79,13 @@ func (c *clusterctlClient) Move(options MoveOptions) error {
 		return err
 	}
 
+	// Prevents other clusterctl operations from changing the target management cluster while moving.
+	unlockTo, err := lockOperation(toCluster, cluster.AuditMoveOperation, options.ForceLock)
+	if err != nil {
+		return err
+	}
+	defer unlockTo()
+
 	// Ensures the custom resource definitions required by clusterctl are in place
 	if err := toCluster.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
 		return err
@@ -63,9 +100,91 @@ func (c *clusterctlClient) Move(options MoveOptions) error {
 		options.Namespace = currentNamespace
 	}
 
-	if err := fromCluster.ObjectMover().Move(options.Namespace, toCluster); err != nil {
+	action := fmt.Sprintf("Move the Cluster API objects in the %q namespace from the %q to the %q management cluster", options.Namespace, fromCluster.Kubeconfig().Path, toCluster.Kubeconfig().Path)
+	if options.ClusterName != "" {
+		action = fmt.Sprintf("Move the %s/%s Cluster and all its dependencies from the %q to the %q management cluster", options.Namespace, options.ClusterName, fromCluster.Kubeconfig().Path, toCluster.Kubeconfig().Path)
+	}
+	if options.Sync {
+		action = fmt.Sprintf("Sync the Cluster API objects in the %q namespace from the %q to the %q management cluster, without pausing or deleting them in the source management cluster", options.Namespace, fromCluster.Kubeconfig().Path, toCluster.Kubeconfig().Path)
+		if options.ClusterName != "" {
+			action = fmt.Sprintf("Sync the %s/%s Cluster and all its dependencies from the %q to the %q management cluster, without pausing or deleting them in the source management cluster", options.Namespace, options.ClusterName, fromCluster.Kubeconfig().Path, toCluster.Kubeconfig().Path)
+		}
+	}
+	if err := confirmAction(options.Confirm, action); err != nil {
 		return err
 	}
 
+	if options.Sync {
+		if err := fromCluster.ObjectMover().Sync(options.Namespace, options.ClusterName, toCluster); err != nil {
+			return err
+		}
+	} else if options.ClusterName != "" {
+		if err := fromCluster.ObjectMover().MoveCluster(options.Namespace, options.ClusterName, toCluster); err != nil {
+			return err
+		}
+	} else {
+		if err := fromCluster.ObjectMover().Move(options.Namespace, toCluster); err != nil {
+			return err
+		}
+	}
+
+	// Records the operation in the audit log of both the source and the target management cluster.
+	fromDetails := map[string]string{
+		"namespace": options.Namespace,
+		"to":        managementClusterRef(toCluster),
+	}
+	toDetails := map[string]string{
+		"namespace": options.Namespace,
+		"from":      managementClusterRef(fromCluster),
+	}
+	if options.ClusterName != "" {
+		fromDetails["cluster"] = options.ClusterName
+		toDetails["cluster"] = options.ClusterName
+	}
+	if options.Sync {
+		fromDetails["sync"] = "true"
+		toDetails["sync"] = "true"
+	}
+	recordOperation(fromCluster, cluster.AuditMoveOperation, nil, fromDetails)
+	recordOperation(toCluster, cluster.AuditMoveOperation, nil, toDetails)
+
 	return nil
 }
+
+// PlanMoveOptions carries the options supported by PlanMove.
+type PlanMoveOptions struct {
+	// FromKubeconfig defines the kubeconfig to use for accessing the source management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	FromKubeconfig Kubeconfig
+
+	// Namespace where the objects describing the workload cluster exists. If unspecified, the current
+	// namespace will be used.
+	Namespace string
+
+	// ClusterName restricts the plan to moving the Cluster with the given name and all the objects in its object graph.
+	// If unspecified, the plan is for moving all the Clusters in the namespace.
+	ClusterName string
+}
+
+func (c *clusterctlClient) PlanMove(options PlanMoveOptions) (*MovePlan, error) {
+	// Get the client for interacting with the source management cluster.
+	fromCluster, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: options.FromKubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := fromCluster.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	plan, err := fromCluster.ObjectMover().Plan(options.Namespace, options.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+	return (*MovePlan)(plan), nil
+}
@@ -51,6 +51,34 @@ func Test_clusterctlClient_Move(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "does not return error when moving a single cluster",
+			fields: fields{
+				client: fakeClientForMove(), // core v1.0.0 (v1.0.1 available), infra v2.0.0 (v2.0.1 available)
+			},
+			args: args{
+				options: MoveOptions{
+					FromKubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					ToKubeconfig:   Kubeconfig{Path: "kubeconfig", Context: "worker-context"},
+					ClusterName:    "foo",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "returns an error if the move is not confirmed",
+			fields: fields{
+				client: fakeClientForMove(), // core v1.0.0 (v1.0.1 available), infra v2.0.0 (v2.0.1 available)
+			},
+			args: args{
+				options: MoveOptions{
+					FromKubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					ToKubeconfig:   Kubeconfig{Path: "kubeconfig", Context: "worker-context"},
+					Confirm:        func(string) bool { return false },
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "returns an error if from cluster client is not found",
 			fields: fields{
@@ -125,3 +153,18 @@ type fakeObjectMover struct {
 func (f *fakeObjectMover) Move(namespace string, toCluster cluster.Client) error {
 	return f.moveErr
 }
+
+func (f *fakeObjectMover) MoveCluster(namespace, clusterName string, toCluster cluster.Client) error {
+	return f.moveErr
+}
+
+func (f *fakeObjectMover) Sync(namespace, clusterName string, toCluster cluster.Client) error {
+	return f.moveErr
+}
+
+func (f *fakeObjectMover) Plan(namespace, clusterName string) (*cluster.MovePlan, error) {
+	if f.moveErr != nil {
+		return nil, f.moveErr
+	}
+	return &cluster.MovePlan{Namespace: namespace, ClusterName: clusterName}, nil
+}
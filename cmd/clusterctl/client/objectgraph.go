@@ -0,0 +1,61 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+// ExportObjectGraphOptions carries the options supported by ExportObjectGraph.
+type ExportObjectGraphOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the objects are read from. If unspecified, the objects are read from all the namespaces.
+	Namespace string
+
+	// ClusterName limits the graph to the objects belonging to the Cluster with the given name. If unspecified,
+	// the objects of all the Clusters are exported. If ClusterName is set and Namespace is not, the current
+	// namespace will be used.
+	ClusterName string
+}
+
+func (c *clusterctlClient) ExportObjectGraph(options ExportObjectGraphOptions) (*ObjectGraph, error) {
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// If the graph is limited to a Cluster and the option specifying the Namespace is empty, try to detect it.
+	if options.ClusterName != "" && options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	graph, err := clusterClient.ObjectGraph().Export(cluster.ExportObjectGraphOptions{
+		Namespace:   options.Namespace,
+		ClusterName: options.ClusterName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return (*ObjectGraph)(graph), nil
+}
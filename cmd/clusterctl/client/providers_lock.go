@@ -0,0 +1,128 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/yaml"
+)
+
+// ProvidersLock pins the versions of the providers resolved by Init, and the digests of their components, so
+// the same provider releases are used in every environment, even if new releases are published upstream.
+type ProvidersLock struct {
+	// Providers are the locked providers, sorted by type and name.
+	Providers []LockedProvider `json:"providers"`
+}
+
+// LockedProvider defines a provider in a ProvidersLock.
+type LockedProvider struct {
+	// Name of the provider (e.g. aws).
+	Name string `json:"name"`
+
+	// Type of the provider (e.g. InfrastructureProvider).
+	Type clusterctlv1.ProviderType `json:"type"`
+
+	// Version the provider is locked to (e.g. v0.5.0); this is the version the provider repository resolved,
+	// e.g. the latest release, when the provider has been locked.
+	Version string `json:"version"`
+
+	// Digest of the provider components YAML, in the sha256:<hex> form.
+	Digest string `json:"digest"`
+}
+
+// LoadProvidersLock reads a ProvidersLock from a YAML file; if the file does not exist, an empty lock is returned.
+func LoadProvidersLock(path string) (*ProvidersLock, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ProvidersLock{}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read the lock file %q", path)
+	}
+
+	lock := &ProvidersLock{}
+	if err := yaml.UnmarshalStrict(content, lock); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the lock file %q", path)
+	}
+	return lock, nil
+}
+
+// Write writes the ProvidersLock to a YAML file.
+func (l *ProvidersLock) Write(path string) error {
+	sort.Slice(l.Providers, func(i, j int) bool {
+		if l.Providers[i].Type != l.Providers[j].Type {
+			return l.Providers[i].Type < l.Providers[j].Type
+		}
+		return l.Providers[i].Name < l.Providers[j].Name
+	})
+
+	content, err := yaml.Marshal(l)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the providers lock")
+	}
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the lock file %q", path)
+	}
+	return nil
+}
+
+// Get returns the locked provider with the given name and type, or nil if the provider is not locked.
+func (l *ProvidersLock) Get(name string, providerType clusterctlv1.ProviderType) *LockedProvider {
+	for i := range l.Providers {
+		if l.Providers[i].Name == name && l.Providers[i].Type == providerType {
+			return &l.Providers[i]
+		}
+	}
+	return nil
+}
+
+// Set locks a provider, replacing the existing lock for the provider with the same name and type, if any.
+func (l *ProvidersLock) Set(provider LockedProvider) {
+	if p := l.Get(provider.Name, provider.Type); p != nil {
+		*p = provider
+		return
+	}
+	l.Providers = append(l.Providers, provider)
+}
+
+// lockedProviderName returns the provider to read, in the name[:version] form, and the expected digest of its
+// components, according to the lock; if the provider is not locked, or the lock is refreshed, the provider is
+// returned unchanged and the digest is empty.
+func lockedProviderName(lock *ProvidersLock, refresh bool, provider string, providerType clusterctlv1.ProviderType) (string, string, error) {
+	if lock == nil || refresh {
+		return provider, "", nil
+	}
+
+	name, version, err := parseProviderName(provider)
+	if err != nil {
+		return "", "", err
+	}
+
+	locked := lock.Get(name, providerType)
+	if locked == nil {
+		return provider, "", nil
+	}
+	if version != "" && version != locked.Version {
+		return "", "", errors.Errorf("the %s %q is locked to version %s, refresh the lock for using version %s", providerType, name, locked.Version, version)
+	}
+	return name + ":" + locked.Version, locked.Digest, nil
+}
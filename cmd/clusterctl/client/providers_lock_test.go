@@ -0,0 +1,135 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+func TestProvidersLock(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir, err := ioutil.TempDir("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "clusterctl-lock.yaml")
+
+	lock, err := LoadProvidersLock(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(lock.Providers).To(BeEmpty())
+
+	lock.Set(LockedProvider{Name: "infra", Type: clusterctlv1.InfrastructureProviderType, Version: "v1.0.0", Digest: "sha256:1"})
+	lock.Set(LockedProvider{Name: "cluster-api", Type: clusterctlv1.CoreProviderType, Version: "v0.3.0", Digest: "sha256:2"})
+	lock.Set(LockedProvider{Name: "infra", Type: clusterctlv1.InfrastructureProviderType, Version: "v1.1.0", Digest: "sha256:3"})
+	g.Expect(lock.Write(path)).To(Succeed())
+
+	lock, err = LoadProvidersLock(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(lock.Providers).To(Equal([]LockedProvider{
+		{Name: "cluster-api", Type: clusterctlv1.CoreProviderType, Version: "v0.3.0", Digest: "sha256:2"},
+		{Name: "infra", Type: clusterctlv1.InfrastructureProviderType, Version: "v1.1.0", Digest: "sha256:3"},
+	}))
+	g.Expect(lock.Get("infra", clusterctlv1.BootstrapProviderType)).To(BeNil())
+
+	g.Expect(ioutil.WriteFile(path, []byte("providers: {}"), 0600)).To(Succeed())
+	_, err = LoadProvidersLock(path)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_lockedProviderName(t *testing.T) {
+	lock := &ProvidersLock{Providers: []LockedProvider{
+		{Name: "infra", Type: clusterctlv1.InfrastructureProviderType, Version: "v1.0.0", Digest: "sha256:1"},
+	}}
+
+	tests := []struct {
+		name         string
+		lock         *ProvidersLock
+		refresh      bool
+		provider     string
+		providerType clusterctlv1.ProviderType
+		wantProvider string
+		wantDigest   string
+		wantErr      bool
+	}{
+		{
+			name:         "no lock",
+			provider:     "infra",
+			providerType: clusterctlv1.InfrastructureProviderType,
+			wantProvider: "infra",
+		},
+		{
+			name:         "provider not locked",
+			lock:         lock,
+			provider:     "infra",
+			providerType: clusterctlv1.BootstrapProviderType,
+			wantProvider: "infra",
+		},
+		{
+			name:         "locked provider without version",
+			lock:         lock,
+			provider:     "infra",
+			providerType: clusterctlv1.InfrastructureProviderType,
+			wantProvider: "infra:v1.0.0",
+			wantDigest:   "sha256:1",
+		},
+		{
+			name:         "locked provider with the locked version",
+			lock:         lock,
+			provider:     "infra:v1.0.0",
+			providerType: clusterctlv1.InfrastructureProviderType,
+			wantProvider: "infra:v1.0.0",
+			wantDigest:   "sha256:1",
+		},
+		{
+			name:         "locked provider with another version",
+			lock:         lock,
+			provider:     "infra:v1.1.0",
+			providerType: clusterctlv1.InfrastructureProviderType,
+			wantErr:      true,
+		},
+		{
+			name:         "locked provider with another version, refreshing the lock",
+			lock:         lock,
+			refresh:      true,
+			provider:     "infra:v1.1.0",
+			providerType: clusterctlv1.InfrastructureProviderType,
+			wantProvider: "infra:v1.1.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			provider, digest, err := lockedProviderName(tt.lock, tt.refresh, tt.provider, tt.providerType)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(provider).To(Equal(tt.wantProvider))
+			g.Expect(digest).To(Equal(tt.wantDigest))
+		})
+	}
+}
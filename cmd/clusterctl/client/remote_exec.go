@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+// RemoteExecOptions carries the options supported by RemoteExec.
+type RemoteExecOptions struct {
+	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
+	// default rules for kubeconfig discovery will be used.
+	Kubeconfig Kubeconfig
+
+	// Image is the container image providing the clusterctl binary as entrypoint.
+	Image string
+
+	// Namespace where the Job executing clusterctl is created. If unspecified, the clusterctl-system namespace is used.
+	// If a ClusterRole is defined, the namespace is created if it does not exist; otherwise it must exist.
+	Namespace string
+
+	// ServiceAccount is the name of an existing ServiceAccount, in the namespace of the Job, the Job runs with.
+	// Either ServiceAccount or ClusterRole is required.
+	ServiceAccount string
+
+	// ClusterRole is the name of an existing ClusterRole granted to the Job for the duration of the execution.
+	// Either ServiceAccount or ClusterRole is required.
+	ClusterRole string
+
+	// Args are the clusterctl args to be executed in the management cluster, e.g. init --infrastructure aws.
+	// The args must not include the kubeconfig flags, because clusterctl uses the in-cluster config.
+	Args []string
+
+	// ConfigFile is the path of the clusterctl configuration file to be used in the management cluster, if any.
+	ConfigFile string
+
+	// Timeout defines the maximum time clusterctl is allowed to run in the management cluster.
+	// If unspecified, it defaults to 30 minutes.
+	Timeout time.Duration
+
+	// Output is where the output of clusterctl is written once the execution completes.
+	Output io.Writer
+}
+
+func (c *clusterctlClient) RemoteExec(options RemoteExecOptions) error {
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return err
+	}
+
+	var config []byte
+	if options.ConfigFile != "" {
+		config, err = ioutil.ReadFile(options.ConfigFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the clusterctl configuration file %q", options.ConfigFile)
+		}
+	}
+
+	return clusterClient.RemoteExec().Run(cluster.RemoteExecOptions{
+		Image:          options.Image,
+		Namespace:      options.Namespace,
+		ServiceAccount: options.ServiceAccount,
+		ClusterRole:    options.ClusterRole,
+		Args:           options.Args,
+		Config:         config,
+		Timeout:        options.Timeout,
+		Output:         options.Output,
+	})
+}
@@ -23,6 +23,7 @@ import (
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	yaml "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
 )
 
 // Client is used to interact with provider repositories.
@@ -32,6 +33,9 @@ import (
 type Client interface {
 	config.Provider
 
+	// DefaultVersion returns the default provider version returned by the repository, e.g. the latest release.
+	DefaultVersion() string
+
 	// GetVersion return the list of versions that are available in a provider repository
 	GetVersions() ([]string, error)
 
@@ -57,8 +61,34 @@ type repositoryClient struct {
 // ensure repositoryClient implements Client.
 var _ Client = &repositoryClient{}
 
+func (c *repositoryClient) DefaultVersion() string {
+	return c.repository.DefaultVersion()
+}
+
 func (c *repositoryClient) GetVersions() ([]string, error) {
-	return c.repository.GetVersions()
+	versions, err := c.repository.GetVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	// Adds the versions available only in the overrides folder, e.g. versions built locally by the provider developers.
+	overrideVersions, err := getLocalOverrideVersions(c.configClient.Variables(), c.Provider)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range overrideVersions {
+		found := false
+		for _, v := range versions {
+			if v == o {
+				found = true
+				break
+			}
+		}
+		if !found {
+			versions = append(versions, o)
+		}
+	}
+	return versions, nil
 }
 
 func (c *repositoryClient) Components() ComponentsClient {
@@ -115,7 +145,15 @@ func newRepositoryClient(provider config.Provider, configClient config.Client, o
 	if client.repository == nil {
 		r, err := repositoryFactory(provider, configClient.Variables())
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to get repository client for the %s with name %s", provider.Type(), provider.Name())
+			// if the provider repository can't be reached but there are versions of the provider in the overrides
+			// folder, use them, so developers can work with locally built manifests e.g. before the first release.
+			o, oErr := newOverridesRepository(provider, configClient.Variables())
+			if oErr != nil || o == nil {
+				return nil, errors.Wrapf(err, "failed to get repository client for the %s with name %s", provider.Type(), provider.Name())
+			}
+			logf.Log.Info("Using the overrides folder only", "Provider", provider.ManifestLabel(), "Reason", err.Error())
+			client.repository = o
+			return client, nil
 		}
 		client.repository = r
 	}
@@ -167,6 +205,32 @@ func repositoryFactory(providerConfig config.Provider, configVariablesClient con
 		return repo, err
 	}
 
+	// if the url is an AWS S3 bucket
+	if rURL.Scheme == s3Scheme {
+		store, err := newS3Store(configVariablesClient)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating the S3 client")
+		}
+		repo, err := newBucketRepository(providerConfig, configVariablesClient, store)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating the S3 repository client")
+		}
+		return repo, err
+	}
+
+	// if the url is a Google Cloud Storage bucket
+	if rURL.Scheme == gcsScheme {
+		store, err := newGCSStore()
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating the Cloud Storage client")
+		}
+		repo, err := newBucketRepository(providerConfig, configVariablesClient, store)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating the Cloud Storage repository client")
+		}
+		return repo, err
+	}
+
 	// if the url is a local filesystem repository
 	if rURL.Scheme == "file" || rURL.Scheme == "" {
 		repo, err := newLocalRepository(providerConfig, configVariablesClient)
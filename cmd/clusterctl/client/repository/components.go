@@ -17,6 +17,8 @@ limitations under the License.
 package repository
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
 
@@ -25,12 +27,14 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	yaml "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/scheme"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/util"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
 	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
 )
 
@@ -43,11 +47,15 @@ const (
 	mutatingWebhookConfigurationKind   = "MutatingWebhookConfiguration"
 	customResourceDefinitionKind       = "CustomResourceDefinition"
 	deploymentKind                     = "Deployment"
+	certificateKind                    = "Certificate"
+
+	certManagerInjectCAFromAnnotation = "cert-manager.io/inject-ca-from"
 
 	WebhookNamespaceName = "capi-webhook-system"
 
 	controllerContainerName = "manager"
 	namespaceArgPrefix      = "--namespace="
+	featureGatesArgPrefix   = "--feature-gates="
 )
 
 // Components wraps a YAML file that defines the provider components
@@ -57,8 +65,11 @@ const (
 // 1. Checks for all the variables in the component YAML file and replace with corresponding config values
 // 2. Ensure all the provider components are deployed in the target namespace (apply only to namespaced objects)
 // 3. Ensure all the ClusterRoleBinding which are referencing namespaced objects have the name prefixed with the namespace name
-// 4. Set the watching namespace for the provider controller
-// 5. Adds labels to all the components in order to allow easy identification of the provider objects
+// 4. Ensure all the references to objects in the default target namespace, e.g. webhook services, cert-manager certificates
+//    and RBAC subjects, refer to the target namespace
+// 5. Set the watching namespace for the provider controller
+// 6. Set the feature gates for the provider controller
+// 7. Adds labels to all the components in order to allow easy identification of the provider objects
 type Components interface {
 	// configuration of the provider the provider components belongs to.
 	config.Provider
@@ -66,10 +77,19 @@ type Components interface {
 	// Version of the provider.
 	Version() string
 
+	// Digest of the raw component YAML, in the sha256:<hex> form; it identifies the content of a provider release,
+	// so it can be pinned.
+	Digest() string
+
 	// Variables required by the provider components.
 	// This value is derived by the component YAML.
 	Variables() []string
 
+	// VariableMap returns the variables required by the provider components, with their default value
+	// or nil if the variable has no default value.
+	// This value is derived by the component YAML.
+	VariableMap() map[string]*string
+
 	// Images required to install the provider components.
 	// This value is derived by the component YAML.
 	Images() []string
@@ -84,6 +104,11 @@ type Components interface {
 	// during the creation of the Components object.
 	WatchingNamespace() string
 
+	// FeatureGates defines the feature gates of the provider controller overridden by clusterctl.
+	// By default the feature gates are derived by the component YAML, but it is possible to override them
+	// during the creation of the Components object.
+	FeatureGates() map[string]bool
+
 	// InventoryObject returns the clusterctl inventory object representing the provider that will be
 	// generated by this components.
 	InventoryObject() clusterctlv1.Provider
@@ -102,10 +127,13 @@ type Components interface {
 type components struct {
 	config.Provider
 	version           string
+	digest            string
 	variables         []string
+	variableMap       map[string]*string
 	images            []string
 	targetNamespace   string
 	watchingNamespace string
+	featureGates      map[string]bool
 	instanceObjs      []unstructured.Unstructured
 	sharedObjs        []unstructured.Unstructured
 }
@@ -117,10 +145,18 @@ func (c *components) Version() string {
 	return c.version
 }
 
+func (c *components) Digest() string {
+	return c.digest
+}
+
 func (c *components) Variables() []string {
 	return c.variables
 }
 
+func (c *components) VariableMap() map[string]*string {
+	return c.variableMap
+}
+
 func (c *components) Images() []string {
 	return c.images
 }
@@ -133,8 +169,12 @@ func (c *components) WatchingNamespace() string {
 	return c.watchingNamespace
 }
 
+func (c *components) FeatureGates() map[string]bool {
+	return c.featureGates
+}
+
 func (c *components) InventoryObject() clusterctlv1.Provider {
-	labels := getCommonLabels(c.Provider)
+	labels := getCommonLabels(c.Provider, c.version, c.targetNamespace)
 	labels[clusterctlv1.ClusterctlCoreLabelName] = "inventory"
 
 	return clusterctlv1.Provider{
@@ -151,6 +191,7 @@ func (c *components) InventoryObject() clusterctlv1.Provider {
 		Type:             string(c.Type()),
 		Version:          c.version,
 		WatchedNamespace: c.watchingNamespace,
+		FeatureGates:     c.featureGates,
 	}
 }
 
@@ -176,6 +217,9 @@ type ComponentsOptions struct {
 	Version           string
 	TargetNamespace   string
 	WatchingNamespace string
+	// FeatureGates to be set on the provider controller; feature gates not supported by the provider, i.e. not
+	// defined in the --feature-gates arg of its controller, are ignored.
+	FeatureGates map[string]bool
 	// Allows for skipping variable replacement in the component YAML
 	SkipVariables bool
 }
@@ -198,7 +242,8 @@ type ComponentsInput struct {
 // 3. Ensure all the provider components are deployed in the target namespace (apply only to namespaced objects)
 // 4. Ensure all the ClusterRoleBinding which are referencing namespaced objects have the name prefixed with the namespace name
 // 5. Set the watching namespace for the provider controller
-// 6. Adds labels to all the components in order to allow easy identification of the provider objects
+// 6. Set the feature gates for the provider controller
+// 7. Adds labels to all the components in order to allow easy identification of the provider objects
 func NewComponents(input ComponentsInput) (*components, error) {
 
 	variables, err := input.Processor.GetVariables(input.RawYaml)
@@ -206,6 +251,11 @@ func NewComponents(input ComponentsInput) (*components, error) {
 		return nil, err
 	}
 
+	variableMap, err := input.Processor.GetVariableMap(input.RawYaml)
+	if err != nil {
+		return nil, err
+	}
+
 	processedYaml := input.RawYaml
 	if !input.Options.SkipVariables {
 		processedYaml, err = input.Processor.Process(input.RawYaml, input.ConfigClient.Variables().Get)
@@ -214,6 +264,18 @@ func NewComponents(input ComponentsInput) (*components, error) {
 		}
 	}
 
+	// Validate the objects against the schemas of their types, so provider packaging errors are reported before
+	// applying the components to the cluster; this requires the variables to be substituted.
+	if !input.Options.SkipVariables {
+		warnings, err := validateComponents(processedYaml)
+		for _, w := range warnings {
+			logf.Log.Info("Warning: the provider components could be rejected by the API server", "Provider", input.Provider.ManifestLabel(), "Reason", w)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid components of the %q provider", input.Provider.ManifestLabel())
+		}
+	}
+
 	// Transform the yaml in a list of objects, so following transformation can work on typed objects (instead of working on a string/slice of bytes)
 	objs, err := utilyaml.ToUnstructured(processedYaml)
 	if err != nil {
@@ -275,6 +337,19 @@ func NewComponents(input ComponentsInput) (*components, error) {
 		return nil, errors.Wrap(err, "failed to fix ClusterRoleBinding names")
 	}
 
+	// ensures all the references to objects in the default target namespace, e.g. the services used by webhooks, the
+	// certificates injected by cert-manager or RBAC subjects, refer to the targetNamespace.
+	// Nb. Shared objects are processed as well, because e.g. webhook configurations and CRDs could refer to services
+	// hosted in the provider namespace.
+	instanceObjs, err = fixNamespacedReferences(instanceObjs, defaultTargetNamespace, input.Options.TargetNamespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fix namespaced references")
+	}
+	sharedObjs, err = fixNamespacedReferences(sharedObjs, defaultTargetNamespace, input.Options.TargetNamespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fix namespaced references in shared objects")
+	}
+
 	// inspect the list of objects for the default watching namespace
 	// the default watching namespace is the namespace the controller is set for watching in the component yaml read from the repository, if any
 	defaultWatchingNamespace, err := inspectWatchNamespace(instanceObjs)
@@ -290,27 +365,42 @@ func NewComponents(input ComponentsInput) (*components, error) {
 		}
 	}
 
+	// set the requested feature gates supported by the provider controller, if any
+	instanceObjs, featureGates, err := fixFeatureGates(instanceObjs, input.Options.FeatureGates)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to set feature gates")
+	}
+
 	// Add common labels to both the obj groups.
-	instanceObjs = addCommonLabels(instanceObjs, input.Provider)
-	sharedObjs = addCommonLabels(sharedObjs, input.Provider)
+	instanceObjs = addCommonLabels(instanceObjs, input.Provider, input.Options.Version, input.Options.TargetNamespace)
+	sharedObjs = addCommonLabels(sharedObjs, input.Provider, input.Options.Version, input.Options.TargetNamespace)
 
 	// Add an identifying label to shared components so next invocation of init, clusterctl delete and clusterctl upgrade can act accordingly.
 	// Additionally, the capi-webhook-system namespace gets detached from any provider, so we prevent that deleting
-	// a provider can delete all the web-hooks.
+	// a provider can delete all the web-hooks, and shared components get detached from the instance that installed them.
 	sharedObjs = fixSharedLabels(sharedObjs)
 
 	return &components{
 		Provider:          input.Provider,
 		version:           input.Options.Version,
+		digest:            componentsDigest(input.RawYaml),
 		variables:         variables,
+		variableMap:       variableMap,
 		images:            images,
 		targetNamespace:   input.Options.TargetNamespace,
 		watchingNamespace: input.Options.WatchingNamespace,
+		featureGates:      featureGates,
 		instanceObjs:      instanceObjs,
 		sharedObjs:        sharedObjs,
 	}, nil
 }
 
+// componentsDigest returns the digest of a raw component YAML.
+func componentsDigest(rawYaml []byte) string {
+	sum := sha256.Sum256(rawYaml)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
 // splitInstanceAndSharedResources divides the objects contained in the component yaml into two sets, instance specific objects
 // and objects shared across many instances.
 func splitInstanceAndSharedResources(objs []unstructured.Unstructured) (instanceObjs []unstructured.Unstructured, sharedObjs []unstructured.Unstructured) {
@@ -569,19 +659,161 @@ func fixWatchNamespace(objs []unstructured.Unstructured, watchingNamespace strin
 	return objs, nil
 }
 
+// fixFeatureGates sets the requested feature gates in the --feature-gates arg of the provider controller, and
+// returns the feature gates actually set. Only the feature gates already defined in the arg are set, so a feature gate
+// can be requested for all the providers without breaking the controllers that do not support it.
+func fixFeatureGates(objs []unstructured.Unstructured, featureGates map[string]bool) ([]unstructured.Unstructured, map[string]bool, error) {
+	if len(featureGates) == 0 {
+		return objs, nil, nil
+	}
+
+	applied := map[string]bool{}
+	for i := range objs {
+		o := objs[i]
+		if o.GetKind() != deploymentKind {
+			continue
+		}
+
+		// Convert Unstructured into a typed object
+		d := &appsv1.Deployment{}
+		if err := scheme.Scheme.Convert(&o, d, nil); err != nil {
+			return nil, nil, err
+		}
+
+		// look for the --feature-gates command arg of the container with name "manager"
+		for j, c := range d.Spec.Template.Spec.Containers {
+			if c.Name != controllerContainerName {
+				continue
+			}
+			for k, a := range c.Args {
+				if !strings.HasPrefix(a, featureGatesArgPrefix) {
+					continue
+				}
+
+				gates := strings.Split(strings.TrimPrefix(a, featureGatesArgPrefix), ",")
+				for l, gate := range gates {
+					name := strings.TrimSpace(strings.SplitN(gate, "=", 2)[0])
+					if value, ok := featureGates[name]; ok {
+						gates[l] = fmt.Sprintf("%s=%t", name, value)
+						applied[name] = value
+					}
+				}
+				c.Args[k] = featureGatesArgPrefix + strings.Join(gates, ",")
+			}
+			d.Spec.Template.Spec.Containers[j] = c
+		}
+
+		// Convert Deployment back to Unstructured
+		if err := scheme.Scheme.Convert(d, &o, nil); err != nil {
+			return nil, nil, err
+		}
+		objs[i] = o
+	}
+
+	if len(applied) == 0 {
+		return objs, nil, nil
+	}
+	return objs, applied, nil
+}
+
+// fixNamespacedReferences ensures all the references to objects in the defaultTargetNamespace refer to the targetNamespace;
+// this applies to webhook and CRD conversion webhook services, cert-manager inject-ca-from annotations, cert-manager
+// Certificate DNS names and RBAC subjects.
+// Nb. references to objects in other namespaces, e.g. capi-webhook-system, are not affected.
+func fixNamespacedReferences(objs []unstructured.Unstructured, defaultTargetNamespace, targetNamespace string) ([]unstructured.Unstructured, error) {
+	if defaultTargetNamespace == "" || defaultTargetNamespace == targetNamespace {
+		return objs, nil
+	}
+
+	for i := range objs {
+		o := &objs[i]
+
+		annotations := o.GetAnnotations()
+		if ref, ok := annotations[certManagerInjectCAFromAnnotation]; ok && strings.HasPrefix(ref, defaultTargetNamespace+"/") {
+			annotations[certManagerInjectCAFromAnnotation] = targetNamespace + strings.TrimPrefix(ref, defaultTargetNamespace)
+			o.SetAnnotations(annotations)
+		}
+
+		var err error
+		switch o.GetKind() {
+		case validatingWebhookConfigurationKind, mutatingWebhookConfigurationKind:
+			err = fixNestedSliceNamespaces(o.Object, []string{"webhooks"}, []string{"clientConfig", "service", "namespace"}, defaultTargetNamespace, targetNamespace)
+		case customResourceDefinitionKind:
+			// apiextensions.k8s.io/v1 and apiextensions.k8s.io/v1beta1 store the conversion webhook service in different fields.
+			if err = fixNestedNamespace(o.Object, []string{"spec", "conversion", "webhook", "clientConfig", "service", "namespace"}, defaultTargetNamespace, targetNamespace); err != nil {
+				break
+			}
+			err = fixNestedNamespace(o.Object, []string{"spec", "conversion", "webhookClientConfig", "service", "namespace"}, defaultTargetNamespace, targetNamespace)
+		case certificateKind:
+			err = fixCertificateDNSNames(o.Object, defaultTargetNamespace, targetNamespace)
+		case clusterRoleBindingKind, roleBindingKind:
+			err = fixNestedSliceNamespaces(o.Object, []string{"subjects"}, []string{"namespace"}, defaultTargetNamespace, targetNamespace)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to fix namespaced references in %s %s", o.GetKind(), o.GetName())
+		}
+	}
+	return objs, nil
+}
+
+// fixNestedNamespace replaces the namespace stored in the given field, if it is equal to defaultTargetNamespace.
+func fixNestedNamespace(obj map[string]interface{}, fields []string, defaultTargetNamespace, targetNamespace string) error {
+	namespace, ok, err := unstructured.NestedString(obj, fields...)
+	if err != nil || !ok || namespace != defaultTargetNamespace {
+		return err
+	}
+	return unstructured.SetNestedField(obj, targetNamespace, fields...)
+}
+
+// fixNestedSliceNamespaces replaces the namespace stored in the given field of each item of a slice, if it is equal to defaultTargetNamespace.
+func fixNestedSliceNamespaces(obj map[string]interface{}, sliceFields, fields []string, defaultTargetNamespace, targetNamespace string) error {
+	items, ok, err := unstructured.NestedSlice(obj, sliceFields...)
+	if err != nil || !ok {
+		return err
+	}
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("invalid %s item: expected an object", strings.Join(sliceFields, "."))
+		}
+		if err := fixNestedNamespace(m, fields, defaultTargetNamespace, targetNamespace); err != nil {
+			return err
+		}
+	}
+	return unstructured.SetNestedSlice(obj, items, sliceFields...)
+}
+
+// fixCertificateDNSNames replaces the namespace in the service DNS names of a cert-manager Certificate,
+// e.g. from webhook-service.default-namespace.svc to webhook-service.target-namespace.svc.
+func fixCertificateDNSNames(obj map[string]interface{}, defaultTargetNamespace, targetNamespace string) error {
+	dnsNames, ok, err := unstructured.NestedStringSlice(obj, "spec", "dnsNames")
+	if err != nil || !ok {
+		return err
+	}
+	oldSuffix := fmt.Sprintf(".%s.svc", defaultTargetNamespace)
+	newSuffix := fmt.Sprintf(".%s.svc", targetNamespace)
+	for i, name := range dnsNames {
+		if strings.HasSuffix(name, oldSuffix) || strings.Contains(name, oldSuffix+".") {
+			dnsNames[i] = strings.Replace(name, oldSuffix, newSuffix, 1)
+		}
+	}
+	return unstructured.SetNestedStringSlice(obj, dnsNames, "spec", "dnsNames")
+}
+
 func remove(slice []string, i int) []string {
 	copy(slice[i:], slice[i+1:])
 	return slice[:len(slice)-1]
 }
 
-// addCommonLabels ensures all the provider components have a consistent set of labels
-func addCommonLabels(objs []unstructured.Unstructured, provider config.Provider) []unstructured.Unstructured {
+// addCommonLabels ensures all the provider components have a consistent set of labels, identifying
+// the provider, the provider version and the provider instance.
+func addCommonLabels(objs []unstructured.Unstructured, provider config.Provider, version, targetNamespace string) []unstructured.Unstructured {
 	for _, o := range objs {
 		labels := o.GetLabels()
 		if labels == nil {
 			labels = map[string]string{}
 		}
-		for k, v := range getCommonLabels(provider) {
+		for k, v := range getCommonLabels(provider, version, targetNamespace) {
 			labels[k] = v
 		}
 		o.SetLabels(labels)
@@ -590,11 +822,28 @@ func addCommonLabels(objs []unstructured.Unstructured, provider config.Provider)
 	return objs
 }
 
-func getCommonLabels(provider config.Provider) map[string]string {
+func getCommonLabels(provider config.Provider, version, targetNamespace string) map[string]string {
 	return map[string]string{
-		clusterctlv1.ClusterctlLabelName: "",
-		clusterv1.ProviderLabelName:      provider.ManifestLabel(),
+		clusterctlv1.ClusterctlLabelName:                "",
+		clusterv1.ProviderLabelName:                     provider.ManifestLabel(),
+		clusterctlv1.ClusterctlProviderVersionLabelName: VersionLabelValue(version),
+		clusterctlv1.ClusterctlInstanceLabelName:        targetNamespace,
+	}
+}
+
+// VersionLabelValue returns the value of the clusterctl.cluster.x-k8s.io/provider-version label for a provider version;
+// characters not allowed in label values, e.g. the + of the semver build metadata, are replaced by _.
+func VersionLabelValue(version string) string {
+	value := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, version)
+	if len(value) > validation.LabelValueMaxLength {
+		value = value[:validation.LabelValueMaxLength]
 	}
+	return strings.Trim(value, "-_.")
 }
 
 // fixSharedLabels ensures all the shared components have an identifying label so next invocation of init, clusterctl delete
@@ -604,6 +853,9 @@ func fixSharedLabels(objs []unstructured.Unstructured) []unstructured.Unstructur
 		labels := o.GetLabels()
 		labels[clusterctlv1.ClusterctlResourceLifecyleLabelName] = string(clusterctlv1.ResourceLifecycleShared)
 
+		// shared components do not belong to the instance that installed them, so removing the ClusterctlInstanceLabelName label.
+		delete(labels, clusterctlv1.ClusterctlInstanceLabelName)
+
 		// the capi-webhook-system namespace is shared among many providers, so removing the ProviderLabelName label.
 		if o.GetKind() == namespaceKind && o.GetName() == WebhookNamespaceName {
 			delete(labels, clusterv1.ProviderLabelName)
@@ -79,6 +79,22 @@ func (f *componentsClient) Get(options ComponentsOptions) (Components, error) {
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to read %q from provider's repository %q", path, f.provider.ManifestLabel())
 		}
+
+		// Verify the component YAML against the checksums published alongside the release assets, if required.
+		verification, err := f.configClient.ComponentsVerification()
+		if err != nil {
+			return nil, err
+		}
+		if verification.Enabled() {
+			if err := verifyComponents(f.repository, f.provider, verification, options.Version, path, file); err != nil {
+				if verification.Policy == config.EnforceVerificationPolicy {
+					return nil, errors.Wrapf(err, "failed to verify %q from provider's repository %q", path, f.provider.ManifestLabel())
+				}
+				log.Info("Warning: failed to verify the provider components", "File", path, "Provider", f.provider.ManifestLabel(), "Version", options.Version, "Reason", err.Error())
+			} else {
+				log.V(3).Info("Verified", "File", path, "Provider", f.provider.ManifestLabel(), "Version", options.Version)
+			}
+		}
 	} else {
 		log.Info("Using", "Override", path, "Provider", f.provider.ManifestLabel(), "Version", options.Version)
 	}
@@ -22,7 +22,9 @@ import (
 
 	. "github.com/onsi/gomega"
 
+	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
@@ -631,6 +633,199 @@ func Test_fixRBAC(t *testing.T) {
 	}
 }
 
+func Test_fixNamespacedReferences(t *testing.T) {
+	webhookConfiguration := func(kind string, namespaces ...string) unstructured.Unstructured {
+		webhooks := []interface{}{}
+		for _, n := range namespaces {
+			webhooks = append(webhooks, map[string]interface{}{
+				"name": "webhook",
+				"clientConfig": map[string]interface{}{
+					"service": map[string]interface{}{
+						"name":      "webhook-service",
+						"namespace": n,
+					},
+				},
+			})
+		}
+		return unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind":       kind,
+				"apiVersion": "admissionregistration.k8s.io/v1beta1",
+				"metadata": map[string]interface{}{
+					"name": "foo",
+					"annotations": map[string]interface{}{
+						"cert-manager.io/inject-ca-from": fmt.Sprintf("%s/serving-cert", namespaces[0]),
+					},
+				},
+				"webhooks": webhooks,
+			},
+		}
+	}
+	crd := func(namespace string) unstructured.Unstructured {
+		return unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind":       "CustomResourceDefinition",
+				"apiVersion": "apiextensions.k8s.io/v1beta1",
+				"metadata": map[string]interface{}{
+					"name": "foos.bar",
+				},
+				"spec": map[string]interface{}{
+					"conversion": map[string]interface{}{
+						"strategy": "Webhook",
+						"webhookClientConfig": map[string]interface{}{
+							"service": map[string]interface{}{
+								"name":      "webhook-service",
+								"namespace": namespace,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	certificate := func(namespace string) unstructured.Unstructured {
+		return unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind":       "Certificate",
+				"apiVersion": "cert-manager.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name": "serving-cert",
+				},
+				"spec": map[string]interface{}{
+					"dnsNames": []interface{}{
+						fmt.Sprintf("webhook-service.%s.svc", namespace),
+						fmt.Sprintf("webhook-service.%s.svc.cluster.local", namespace),
+					},
+				},
+			},
+		}
+	}
+	roleBinding := func(namespace string) unstructured.Unstructured {
+		return unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind":       "RoleBinding",
+				"apiVersion": "rbac.authorization.k8s.io/v1",
+				"metadata": map[string]interface{}{
+					"name":      "foo",
+					"namespace": "capi-webhook-system",
+				},
+				"subjects": []interface{}{
+					map[string]interface{}{
+						"kind":      "ServiceAccount",
+						"name":      "default",
+						"namespace": namespace,
+					},
+				},
+			},
+		}
+	}
+
+	type args struct {
+		objs                   []unstructured.Unstructured
+		defaultTargetNamespace string
+		targetNamespace        string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []unstructured.Unstructured
+		wantErr bool
+	}{
+		{
+			name: "references to the default target namespace get fixed",
+			args: args{
+				objs: []unstructured.Unstructured{
+					webhookConfiguration("ValidatingWebhookConfiguration", "default", "default"),
+					webhookConfiguration("MutatingWebhookConfiguration", "default"),
+					crd("default"),
+					certificate("default"),
+					roleBinding("default"),
+				},
+				defaultTargetNamespace: "default",
+				targetNamespace:        "target",
+			},
+			want: []unstructured.Unstructured{
+				webhookConfiguration("ValidatingWebhookConfiguration", "target", "target"),
+				webhookConfiguration("MutatingWebhookConfiguration", "target"),
+				crd("target"),
+				certificate("target"),
+				roleBinding("target"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "references to other namespaces are not changed",
+			args: args{
+				objs: []unstructured.Unstructured{
+					webhookConfiguration("ValidatingWebhookConfiguration", "capi-webhook-system"),
+					crd("capi-webhook-system"),
+					certificate("capi-webhook-system"),
+					roleBinding("capi-webhook-system"),
+				},
+				defaultTargetNamespace: "default",
+				targetNamespace:        "target",
+			},
+			want: []unstructured.Unstructured{
+				webhookConfiguration("ValidatingWebhookConfiguration", "capi-webhook-system"),
+				crd("capi-webhook-system"),
+				certificate("capi-webhook-system"),
+				roleBinding("capi-webhook-system"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "nothing changes if the target namespace is the default target namespace",
+			args: args{
+				objs: []unstructured.Unstructured{
+					webhookConfiguration("ValidatingWebhookConfiguration", "default"),
+					certificate("default"),
+				},
+				defaultTargetNamespace: "default",
+				targetNamespace:        "default",
+			},
+			want: []unstructured.Unstructured{
+				webhookConfiguration("ValidatingWebhookConfiguration", "default"),
+				certificate("default"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "fails for invalid webhooks",
+			args: args{
+				objs: []unstructured.Unstructured{
+					{
+						Object: map[string]interface{}{
+							"kind":       "ValidatingWebhookConfiguration",
+							"apiVersion": "admissionregistration.k8s.io/v1beta1",
+							"metadata": map[string]interface{}{
+								"name": "foo",
+							},
+							"webhooks": []interface{}{"invalid"},
+						},
+					},
+				},
+				defaultTargetNamespace: "default",
+				targetNamespace:        "target",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := fixNamespacedReferences(tt.args.objs, tt.args.defaultTargetNamespace, tt.args.targetNamespace)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
 func fakeDeployment(watchNamespace string) unstructured.Unstructured {
 	args := []string{}
 	if watchNamespace != "" {
@@ -779,11 +974,71 @@ func Test_fixWatchNamespace(t *testing.T) {
 	}
 }
 
+func Test_fixFeatureGates(t *testing.T) {
+	fakeDeploymentWithArgs := func(args ...string) unstructured.Unstructured {
+		o := fakeDeployment("")
+		o.Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]map[string]interface{})[0]["args"] = args
+		return o
+	}
+
+	tests := []struct {
+		name             string
+		objs             []unstructured.Unstructured
+		featureGates     map[string]bool
+		wantArgs         []string
+		wantFeatureGates map[string]bool
+	}{
+		{
+			name:             "no changes if no feature gates are requested",
+			objs:             []unstructured.Unstructured{fakeDeploymentWithArgs("--feature-gates=MachinePool=false")},
+			featureGates:     nil,
+			wantArgs:         []string{"--feature-gates=MachinePool=false"},
+			wantFeatureGates: nil,
+		},
+		{
+			name:             "set the feature gates supported by the provider",
+			objs:             []unstructured.Unstructured{fakeDeploymentWithArgs("--metrics-addr=:8080", "--feature-gates=MachinePool=false,ClusterResourceSet=false")},
+			featureGates:     map[string]bool{"MachinePool": true},
+			wantArgs:         []string{"--metrics-addr=:8080", "--feature-gates=MachinePool=true,ClusterResourceSet=false"},
+			wantFeatureGates: map[string]bool{"MachinePool": true},
+		},
+		{
+			name:             "ignore the feature gates not supported by the provider",
+			objs:             []unstructured.Unstructured{fakeDeploymentWithArgs("--feature-gates=MachinePool=true")},
+			featureGates:     map[string]bool{"MachinePool": false, "ClusterResourceSet": true},
+			wantArgs:         []string{"--feature-gates=MachinePool=false"},
+			wantFeatureGates: map[string]bool{"MachinePool": false},
+		},
+		{
+			name:             "ignore providers without feature gates",
+			objs:             []unstructured.Unstructured{fakeDeploymentWithArgs("--metrics-addr=:8080")},
+			featureGates:     map[string]bool{"MachinePool": true},
+			wantArgs:         []string{"--metrics-addr=:8080"},
+			wantFeatureGates: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, gotFeatureGates, err := fixFeatureGates(tt.objs, tt.featureGates)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(gotFeatureGates).To(Equal(tt.wantFeatureGates))
+
+			d := &appsv1.Deployment{}
+			g.Expect(scheme.Scheme.Convert(&got[0], d, nil)).To(Succeed())
+			g.Expect(d.Spec.Template.Spec.Containers[0].Args).To(Equal(tt.wantArgs))
+		})
+	}
+}
+
 func Test_addCommonLabels(t *testing.T) {
 	type args struct {
-		objs         []unstructured.Unstructured
-		name         string
-		providerType clusterctlv1.ProviderType
+		objs            []unstructured.Unstructured
+		name            string
+		providerType    clusterctlv1.ProviderType
+		version         string
+		targetNamespace string
 	}
 	tests := []struct {
 		name string
@@ -800,8 +1055,41 @@ func Test_addCommonLabels(t *testing.T) {
 						},
 					},
 				},
-				name:         "provider",
-				providerType: clusterctlv1.InfrastructureProviderType,
+				name:            "provider",
+				providerType:    clusterctlv1.InfrastructureProviderType,
+				version:         "v1.0.0",
+				targetNamespace: "ns1",
+			},
+			want: []unstructured.Unstructured{
+				{
+					Object: map[string]interface{}{
+						"kind": "ClusterRole",
+						"metadata": map[string]interface{}{
+							"labels": map[string]interface{}{
+								clusterctlv1.ClusterctlLabelName:                "",
+								clusterv1.ProviderLabelName:                     "infrastructure-provider",
+								clusterctlv1.ClusterctlProviderVersionLabelName: "v1.0.0",
+								clusterctlv1.ClusterctlInstanceLabelName:        "ns1",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "the version label is sanitized",
+			args: args{
+				objs: []unstructured.Unstructured{
+					{
+						Object: map[string]interface{}{
+							"kind": "ClusterRole",
+						},
+					},
+				},
+				name:            "provider",
+				providerType:    clusterctlv1.InfrastructureProviderType,
+				version:         "v1.0.0-rc.1+build.5",
+				targetNamespace: "ns1",
 			},
 			want: []unstructured.Unstructured{
 				{
@@ -809,8 +1097,10 @@ func Test_addCommonLabels(t *testing.T) {
 						"kind": "ClusterRole",
 						"metadata": map[string]interface{}{
 							"labels": map[string]interface{}{
-								clusterctlv1.ClusterctlLabelName: "",
-								clusterv1.ProviderLabelName:      "infrastructure-provider",
+								clusterctlv1.ClusterctlLabelName:                "",
+								clusterv1.ProviderLabelName:                     "infrastructure-provider",
+								clusterctlv1.ClusterctlProviderVersionLabelName: "v1.0.0-rc.1_build.5",
+								clusterctlv1.ClusterctlInstanceLabelName:        "ns1",
 							},
 						},
 					},
@@ -822,7 +1112,7 @@ func Test_addCommonLabels(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
 
-			got := addCommonLabels(tt.args.objs, config.NewProvider(tt.args.name, "", tt.args.providerType))
+			got := addCommonLabels(tt.args.objs, config.NewProvider(tt.args.name, "", tt.args.providerType), tt.args.version, tt.args.targetNamespace)
 			g.Expect(got).To(Equal(tt.want))
 		})
 	}
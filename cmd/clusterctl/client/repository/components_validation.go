@@ -0,0 +1,384 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// componentsDocument is an object of a component YAML, with the line where its YAML document starts.
+type componentsDocument struct {
+	line int
+	obj  unstructured.Unstructured
+}
+
+// crdSchema is the schema of a version of a CustomResourceDefinition.
+type crdSchema struct {
+	schema *apiextensionsv1.JSONSchemaProps
+
+	// prune is true if the API server prunes the fields not defined in the schema.
+	prune bool
+}
+
+// validateComponents validates the objects of a component YAML, before applying them to the cluster, so provider
+// packaging errors are reported with the line of the invalid objects. The custom resources are validated against the
+// schemas of their CustomResourceDefinitions, if defined in the same component YAML, and the violations are returned
+// as errors; the other custom resources are not validated.
+// The objects of the built-in types, and of the types known by clusterctl, are decoded strictly, but unknown fields
+// and values of the wrong type are returned as warnings, because the types compiled into clusterctl can be older than
+// the API server of the management cluster, which could accept these objects.
+func validateComponents(rawYaml []byte) ([]string, error) {
+	docs, err := splitComponentsDocuments(rawYaml)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	schemas, errs := crdSchemas(docs)
+	for _, d := range docs {
+		gvk := d.obj.GroupVersionKind()
+		if s, ok := schemas[gvk]; ok {
+			for _, fieldErr := range validateCustomResource(d.obj, s) {
+				errs = append(errs, errors.Errorf("line %d: %s %s: %v", d.line, d.obj.GetKind(), objectName(d.obj), fieldErr))
+			}
+		} else if scheme.Scheme.Recognizes(gvk) {
+			if err := validateKnownObject(d.obj); err != nil {
+				warnings = append(warnings, fmt.Sprintf("line %d: %s %s: %v", d.line, d.obj.GetKind(), objectName(d.obj), err))
+			}
+		}
+	}
+	return warnings, kerrors.NewAggregate(errs)
+}
+
+// splitComponentsDocuments parses the YAML documents of a component YAML, recording the line where each document
+// starts, i.e. its first line that is not empty or a comment; empty documents are ignored.
+func splitComponentsDocuments(rawYaml []byte) ([]componentsDocument, error) {
+	var (
+		docs  []componentsDocument
+		doc   bytes.Buffer
+		start int
+	)
+	flush := func() error {
+		defer func() {
+			doc.Reset()
+			start = 0
+		}()
+		if start == 0 {
+			return nil
+		}
+		var m map[string]interface{}
+		if err := yaml.Unmarshal(doc.Bytes(), &m); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal the yaml document at line %d", start)
+		}
+		if m != nil {
+			docs = append(docs, componentsDocument{line: start, obj: unstructured.Unstructured{Object: m}})
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(rawYaml))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(rawYaml)+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.HasPrefix(text, "---") && strings.TrimSpace(text[3:]) == "" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if trimmed := strings.TrimSpace(text); start == 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			start = line
+		}
+		doc.WriteString(text)
+		doc.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read yaml")
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// crdSchemas returns the schemas of the versions of the CustomResourceDefinitions in the component YAML.
+func crdSchemas(docs []componentsDocument) (map[schema.GroupVersionKind]crdSchema, []error) {
+	schemas := map[schema.GroupVersionKind]crdSchema{}
+	var errs []error
+	for _, d := range docs {
+		if d.obj.GetKind() != customResourceDefinitionKind || d.obj.GroupVersionKind().Group != apiextensionsv1.GroupName {
+			continue
+		}
+
+		group, _, _ := unstructured.NestedString(d.obj.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(d.obj.Object, "spec", "names", "kind")
+
+		// The fields not defined in the schema are pruned by the API server for apiextensions.k8s.io/v1 CRDs,
+		// and for apiextensions.k8s.io/v1beta1 CRDs only if preserveUnknownFields is false.
+		prune := true
+		if d.obj.GroupVersionKind().Version == "v1beta1" {
+			preserve, found, _ := unstructured.NestedBool(d.obj.Object, "spec", "preserveUnknownFields")
+			prune = found && !preserve
+		}
+
+		// apiextensions.k8s.io/v1beta1 CRDs can define a schema for all the versions.
+		commonSchema, _, _ := unstructured.NestedMap(d.obj.Object, "spec", "validation", "openAPIV3Schema")
+		versions, _, _ := unstructured.NestedSlice(d.obj.Object, "spec", "versions")
+		if len(versions) == 0 {
+			if version, _, _ := unstructured.NestedString(d.obj.Object, "spec", "version"); version != "" {
+				versions = []interface{}{map[string]interface{}{"name": version}}
+			}
+		}
+
+		for _, v := range versions {
+			version, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(version, "name")
+			rawSchema, found, _ := unstructured.NestedMap(version, "schema", "openAPIV3Schema")
+			if !found {
+				rawSchema = commonSchema
+			}
+			if rawSchema == nil {
+				continue
+			}
+
+			props := &apiextensionsv1.JSONSchemaProps{}
+			if err := convertViaJSON(rawSchema, props); err != nil {
+				errs = append(errs, errors.Errorf("line %d: %s %s: invalid schema of version %q: %v", d.line, d.obj.GetKind(), d.obj.GetName(), name, err))
+				continue
+			}
+			schemas[schema.GroupVersionKind{Group: group, Version: name, Kind: kind}] = crdSchema{schema: props, prune: prune}
+		}
+	}
+	return schemas, errs
+}
+
+// validateKnownObject decodes an object into its type, reporting unknown fields and values of the wrong type.
+func validateKnownObject(obj unstructured.Unstructured) error {
+	typed, err := scheme.Scheme.New(obj.GroupVersionKind())
+	if err != nil {
+		return err
+	}
+	return convertViaJSON(obj.Object, typed)
+}
+
+// convertViaJSON converts a value to the given type, failing on unknown fields.
+func convertViaJSON(in interface{}, out interface{}) error {
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(out)
+}
+
+// validateCustomResource validates a custom resource against the schema of its CustomResourceDefinition; apiVersion,
+// kind and metadata are not validated.
+func validateCustomResource(obj unstructured.Unstructured, s crdSchema) field.ErrorList {
+	content := map[string]interface{}{}
+	for k, v := range obj.Object {
+		if k != "apiVersion" && k != "kind" && k != "metadata" {
+			content[k] = v
+		}
+	}
+	return validateSchema(nil, content, s.schema, s.prune)
+}
+
+// validateSchema validates a value against a structural schema; null values are not validated, because they
+// are dropped by the API server.
+func validateSchema(path *field.Path, value interface{}, s *apiextensionsv1.JSONSchemaProps, prune bool) field.ErrorList {
+	if value == nil || s == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	if len(s.Enum) > 0 && !isEnumValue(value, s.Enum) {
+		var allowed []string
+		for _, e := range s.Enum {
+			var v interface{}
+			if err := json.Unmarshal(e.Raw, &v); err == nil {
+				if str, ok := v.(string); ok {
+					allowed = append(allowed, str)
+					continue
+				}
+			}
+			allowed = append(allowed, string(e.Raw))
+		}
+		errs = append(errs, field.NotSupported(path, value, allowed))
+	}
+
+	if s.XIntOrString {
+		if _, ok := value.(string); !ok && !isInteger(value) {
+			errs = append(errs, field.Invalid(path, value, "must be an integer or a string"))
+		}
+		return errs
+	}
+
+	switch s.Type {
+	case "object":
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return append(errs, field.Invalid(path, value, "must be an object"))
+		}
+		for _, r := range s.Required {
+			if _, ok := m[r]; !ok {
+				errs = append(errs, field.Required(path.Child(r), ""))
+			}
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, ok := s.Properties[k]; ok {
+				errs = append(errs, validateSchema(path.Child(k), m[k], &p, prune)...)
+				continue
+			}
+			if s.AdditionalProperties != nil {
+				if s.AdditionalProperties.Schema != nil {
+					errs = append(errs, validateSchema(path.Key(k), m[k], s.AdditionalProperties.Schema, prune)...)
+				}
+				continue
+			}
+			if s.XEmbeddedResource && (k == "apiVersion" || k == "kind" || k == "metadata") {
+				continue
+			}
+			if prune && (s.XPreserveUnknownFields == nil || !*s.XPreserveUnknownFields) {
+				errs = append(errs, field.Forbidden(path.Child(k), "unknown field, it would be dropped by the API server"))
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return append(errs, field.Invalid(path, value, "must be an array"))
+		}
+		if s.MinItems != nil && int64(len(items)) < *s.MinItems {
+			errs = append(errs, field.Invalid(path, len(items), fmt.Sprintf("must have at least %d items", *s.MinItems)))
+		}
+		if s.MaxItems != nil && int64(len(items)) > *s.MaxItems {
+			errs = append(errs, field.TooMany(path, len(items), int(*s.MaxItems)))
+		}
+		if s.Items != nil && s.Items.Schema != nil {
+			for i, item := range items {
+				errs = append(errs, validateSchema(path.Index(i), item, s.Items.Schema, prune)...)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return append(errs, field.Invalid(path, value, "must be a string"))
+		}
+		length := int64(utf8.RuneCountInString(str))
+		if s.MinLength != nil && length < *s.MinLength {
+			errs = append(errs, field.Invalid(path, str, fmt.Sprintf("must be at least %d characters long", *s.MinLength)))
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			errs = append(errs, field.TooLong(path, str, int(*s.MaxLength)))
+		}
+		// Nb. patterns that are not valid Go regular expressions are ignored.
+		if re, err := regexp.Compile(s.Pattern); s.Pattern != "" && err == nil && !re.MatchString(str) {
+			errs = append(errs, field.Invalid(path, str, fmt.Sprintf("must match the pattern %q", s.Pattern)))
+		}
+	case "integer", "number":
+		n, ok := toFloat(value)
+		if !ok || (s.Type == "integer" && !isInteger(value)) {
+			return append(errs, field.Invalid(path, value, fmt.Sprintf("must be an %s", s.Type)))
+		}
+		if s.Minimum != nil && (n < *s.Minimum || (s.ExclusiveMinimum && n == *s.Minimum)) {
+			errs = append(errs, field.Invalid(path, value, boundMessage("greater", s.ExclusiveMinimum, *s.Minimum)))
+		}
+		if s.Maximum != nil && (n > *s.Maximum || (s.ExclusiveMaximum && n == *s.Maximum)) {
+			errs = append(errs, field.Invalid(path, value, boundMessage("less", s.ExclusiveMaximum, *s.Maximum)))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return append(errs, field.Invalid(path, value, "must be a boolean"))
+		}
+	}
+	return errs
+}
+
+func boundMessage(comparison string, exclusive bool, bound float64) string {
+	if exclusive {
+		return fmt.Sprintf("must be %s than %v", comparison, bound)
+	}
+	return fmt.Sprintf("must be %s than or equal to %v", comparison, bound)
+}
+
+// toFloat returns the value of a number, as decoded from YAML or JSON.
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// isInteger returns true if the value is a number without a fractional part.
+func isInteger(value interface{}) bool {
+	n, ok := toFloat(value)
+	return ok && n == float64(int64(n))
+}
+
+// isEnumValue returns true if the value is one of the enum values; numbers are compared by value.
+func isEnumValue(value interface{}, enum []apiextensionsv1.JSON) bool {
+	if n, ok := toFloat(value); ok {
+		value = n
+	}
+	for _, e := range enum {
+		var v interface{}
+		if err := json.Unmarshal(e.Raw, &v); err != nil {
+			continue
+		}
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func objectName(obj unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
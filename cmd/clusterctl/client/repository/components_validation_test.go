@@ -0,0 +1,235 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const validationTestCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: foos.infrastructure.cluster.x-k8s.io
+spec:
+  group: infrastructure.cluster.x-k8s.io
+  names:
+    kind: Foo
+    plural: foos
+  scope: Namespaced
+  versions:
+  - name: v1alpha3
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required:
+            - size
+            properties:
+              size:
+                type: integer
+                minimum: 1
+              mode:
+                type: string
+                enum:
+                - fast
+                - slow
+              port:
+                x-kubernetes-int-or-string: true
+              tags:
+                type: object
+                additionalProperties:
+                  type: string
+`
+
+const validationTestV1beta1CRD = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: bars.infrastructure.cluster.x-k8s.io
+spec:
+  group: infrastructure.cluster.x-k8s.io
+  names:
+    kind: Bar
+    plural: bars
+  scope: Namespaced
+  version: v1alpha3
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          properties:
+            size:
+              type: integer
+`
+
+func Test_validateComponents(t *testing.T) {
+	tests := []struct {
+		name         string
+		yaml         string
+		wantErrs     []string
+		wantWarnings []string
+	}{
+		{
+			name: "valid objects",
+			yaml: validationTestCRD + `---
+apiVersion: infrastructure.cluster.x-k8s.io/v1alpha3
+kind: Foo
+metadata:
+  name: foo
+  namespace: ns1
+spec:
+  size: 3
+  mode: fast
+  port: http
+  tags:
+    team: a
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: manager
+  namespace: ns1
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: manager
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: manager:v1.0.0
+---
+apiVersion: cert-manager.io/v1alpha2
+kind: Issuer
+metadata:
+  name: issuer
+spec:
+  unknown: true
+`,
+		},
+		{
+			name: "invalid objects of the built-in types are reported as warnings",
+			yaml: `# the manager
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: manager
+  namespace: ns1
+spec:
+  replicas: two
+---
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: webhook
+  namespace: ns1
+spec:
+  prots:
+  - port: 443
+`,
+			wantWarnings: []string{
+				"line 2: Deployment ns1/manager:",
+				`line 11: Service ns1/webhook: json: unknown field "prots"`,
+			},
+		},
+		{
+			name: "invalid custom resources",
+			yaml: validationTestCRD + `---
+apiVersion: infrastructure.cluster.x-k8s.io/v1alpha3
+kind: Foo
+metadata:
+  name: foo
+  namespace: ns1
+spec:
+  mode: medium
+  port: true
+  tags:
+    team: 1
+  unknown: x
+---
+apiVersion: infrastructure.cluster.x-k8s.io/v1alpha3
+kind: Foo
+metadata:
+  name: foo2
+spec:
+  size: 0
+`,
+			wantErrs: []string{
+				"line 39: Foo ns1/foo: spec.size: Required value",
+				`line 39: Foo ns1/foo: spec.mode: Unsupported value: "medium": supported values: "fast", "slow"`,
+				"line 39: Foo ns1/foo: spec.port: Invalid value: true: must be an integer or a string",
+				`line 39: Foo ns1/foo: spec.tags[team]: Invalid value: 1: must be a string`,
+				"line 39: Foo ns1/foo: spec.unknown: Forbidden: unknown field",
+				"line 51: Foo foo2: spec.size: Invalid value: 0: must be greater than or equal to 1",
+			},
+		},
+		{
+			name: "unknown fields are not reported if they are preserved",
+			yaml: validationTestV1beta1CRD + `---
+apiVersion: infrastructure.cluster.x-k8s.io/v1alpha3
+kind: Bar
+metadata:
+  name: bar
+spec:
+  size: 1
+  unknown: x
+`,
+		},
+		{
+			name: "invalid custom resources of apiextensions.k8s.io/v1beta1 CRDs",
+			yaml: validationTestV1beta1CRD + `---
+apiVersion: infrastructure.cluster.x-k8s.io/v1alpha3
+kind: Bar
+metadata:
+  name: bar
+spec:
+  size: "1"
+`,
+			wantErrs: []string{
+				`Bar bar: spec.size: Invalid value: "1": must be an integer`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			warnings, err := validateComponents([]byte(tt.yaml))
+			g.Expect(warnings).To(HaveLen(len(tt.wantWarnings)))
+			for i, want := range tt.wantWarnings {
+				g.Expect(warnings[i]).To(HavePrefix(want))
+			}
+			if len(tt.wantErrs) == 0 {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			for _, want := range tt.wantErrs {
+				g.Expect(err.Error()).To(ContainSubstring(want))
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+// signatureFileSuffix is the suffix of the release asset containing the signature of the checksums file.
+const signatureFileSuffix = ".sig"
+
+// verifyComponents verifies the provider components file against the checksums published alongside the release
+// assets of the given version, and, if a public key is configured for the provider, verifies the signature of the
+// checksums file.
+func verifyComponents(repository Repository, provider config.Provider, verification *config.ComponentsVerification, version, filePath string, file []byte) error {
+	checksums, err := repository.GetFile(version, verification.ChecksumsFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the checksums file %q", verification.ChecksumsFile)
+	}
+
+	if keyPath, ok := verification.PublicKeys[provider.ManifestLabel()]; ok {
+		signature, err := repository.GetFile(version, verification.ChecksumsFile+signatureFileSuffix)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the signature of the checksums file %q", verification.ChecksumsFile)
+		}
+		key, err := ioutil.ReadFile(keyPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the public key %q", keyPath)
+		}
+		if err := verifySignature(key, checksums, signature); err != nil {
+			return errors.Wrapf(err, "failed to verify the signature of the checksums file %q", verification.ChecksumsFile)
+		}
+	}
+
+	name := path.Base(filePath)
+	want, err := getChecksum(checksums, name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the checksum of %q from %q", name, verification.ChecksumsFile)
+	}
+	got := sha256.Sum256(file)
+	if hex.EncodeToString(got[:]) != want {
+		return errors.Errorf("the sha256 checksum of %q does not match the checksum in %q", name, verification.ChecksumsFile)
+	}
+	return nil
+}
+
+// getChecksum returns the checksum of a file from a checksums file in the format generated by sha256sum,
+// e.g. "<checksum>  infrastructure-components.yaml".
+func getChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum prefixes the file name with * when reading in binary mode.
+		if strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.Errorf("checksum not found")
+}
+
+// verifySignature verifies a signature of the content, as generated by cosign sign-blob, with a PEM encoded public key;
+// ECDSA, Ed25519 and RSA (PKCS #1 v1.5) keys are supported. The signature can be base64 encoded.
+func verifySignature(key, content, signature []byte) error {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return errors.New("invalid public key: not PEM encoded")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return errors.Wrap(err, "invalid public key")
+	}
+
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil {
+		signature = decoded
+	}
+
+	digest := sha256.Sum256(content)
+	switch k := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		var sig struct {
+			R, S *big.Int
+		}
+		if _, err := asn1.Unmarshal(signature, &sig); err != nil {
+			return errors.Wrap(err, "invalid ECDSA signature")
+		}
+		if !ecdsa.Verify(k, digest[:], sig.R, sig.S) {
+			return errors.New("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, content, signature) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("invalid signature")
+		}
+	default:
+		return errors.Errorf("unsupported public key type %T", publicKey)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_verifyComponents(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir, err := ioutil.TempDir("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	components := []byte("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: capa-system\n")
+	sum := sha256.Sum256(components)
+	checksums := []byte(fmt.Sprintf("%s  metadata.yaml\n%s *components.yaml\n", hex.EncodeToString(make([]byte, 32)), hex.EncodeToString(sum[:])))
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(HaveOccurred())
+	publicKey, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	g.Expect(err).NotTo(HaveOccurred())
+	keyPath := filepath.Join(tmpDir, "cosign.pub")
+	g.Expect(ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}), 0600)).To(Succeed())
+
+	digest := sha256.Sum256(checksums)
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest[:])
+	g.Expect(err).NotTo(HaveOccurred())
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	g.Expect(err).NotTo(HaveOccurred())
+	encodedSignature := []byte(base64.StdEncoding.EncodeToString(signature))
+
+	p1 := config.NewProvider("p1", "", clusterctlv1.InfrastructureProviderType)
+	withKey := &config.ComponentsVerification{
+		ChecksumsFile: config.DefaultChecksumsFile,
+		PublicKeys:    map[string]string{p1.ManifestLabel(): keyPath},
+	}
+	withoutKey := &config.ComponentsVerification{
+		ChecksumsFile: config.DefaultChecksumsFile,
+	}
+
+	newRepository := func() *test.FakeRepository {
+		return test.NewFakeRepository().
+			WithPaths("root", "components.yaml").
+			WithDefaultVersion("v1.0.0")
+	}
+
+	tests := []struct {
+		name         string
+		repository   Repository
+		verification *config.ComponentsVerification
+		file         []byte
+		wantErr      bool
+	}{
+		{
+			name:         "pass with a matching checksum",
+			repository:   newRepository().WithFile("v1.0.0", config.DefaultChecksumsFile, checksums),
+			verification: withoutKey,
+			file:         components,
+		},
+		{
+			name:         "pass with a matching checksum and a valid signature",
+			repository:   newRepository().WithFile("v1.0.0", config.DefaultChecksumsFile, checksums).WithFile("v1.0.0", config.DefaultChecksumsFile+".sig", encodedSignature),
+			verification: withKey,
+			file:         components,
+		},
+		{
+			name:         "pass with a raw signature",
+			repository:   newRepository().WithFile("v1.0.0", config.DefaultChecksumsFile, checksums).WithFile("v1.0.0", config.DefaultChecksumsFile+".sig", signature),
+			verification: withKey,
+			file:         components,
+		},
+		{
+			name:         "fails if the checksums file does not exist",
+			repository:   newRepository(),
+			verification: withoutKey,
+			file:         components,
+			wantErr:      true,
+		},
+		{
+			name:         "fails if the checksum does not match",
+			repository:   newRepository().WithFile("v1.0.0", config.DefaultChecksumsFile, checksums),
+			verification: withoutKey,
+			file:         []byte("tampered"),
+			wantErr:      true,
+		},
+		{
+			name:         "fails if the checksum is missing",
+			repository:   newRepository().WithFile("v1.0.0", config.DefaultChecksumsFile, []byte("0000  metadata.yaml\n")),
+			verification: withoutKey,
+			file:         components,
+			wantErr:      true,
+		},
+		{
+			name:         "fails if the signature does not exist",
+			repository:   newRepository().WithFile("v1.0.0", config.DefaultChecksumsFile, checksums),
+			verification: withKey,
+			file:         components,
+			wantErr:      true,
+		},
+		{
+			name:         "fails if the signature is not valid",
+			repository:   newRepository().WithFile("v1.0.0", config.DefaultChecksumsFile, []byte(string(checksums)+"\n")).WithFile("v1.0.0", config.DefaultChecksumsFile+".sig", encodedSignature),
+			verification: withKey,
+			file:         components,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := verifyComponents(tt.repository, p1, tt.verification, "v1.0.0", "components.yaml", tt.file)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
+
+func Test_componentsClient_Get_Verification(t *testing.T) {
+	p1 := config.NewProvider("p1", "", clusterctlv1.BootstrapProviderType)
+
+	repository := test.NewFakeRepository().
+		WithPaths("root", "components.yaml").
+		WithDefaultVersion("v1.0.0").
+		WithFile("v1.0.0", "components.yaml", namespaceYaml).
+		WithFile("v1.0.0", config.DefaultChecksumsFile, []byte("0000  components.yaml\n"))
+
+	tests := []struct {
+		name    string
+		policy  config.ComponentsVerificationPolicy
+		wantErr bool
+	}{
+		{
+			name:   "verification is skipped by default",
+			policy: "",
+		},
+		{
+			name:   "verification failures are ignored with the warn policy",
+			policy: config.WarnVerificationPolicy,
+		},
+		{
+			name:    "verification failures are errors with the enforce policy",
+			policy:  config.EnforceVerificationPolicy,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			configClient, err := config.New("", config.InjectReader(test.NewFakeReader().WithVar(config.ComponentsVerificationConfigKey, fmt.Sprintf("policy: %q", tt.policy))))
+			g.Expect(err).NotTo(HaveOccurred())
+
+			f := newComponentsClient(p1, repository, configClient)
+			_, err = f.Get(ComponentsOptions{Version: "v1.0.0"})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
@@ -95,15 +95,43 @@ func (f *metadataClient) Get() (*clusterctlv1.Metadata, error) {
 	}
 
 	//TODO: consider if to add metadata validation (TBD)
+	// Nb. an invalid end of life only disables the support warnings for the release series, so it is not an error.
+	if err := validateReleaseSeriesSupport(obj); err != nil {
+		log.Info("Warning: "+err.Error(), "File", name, "Provider", f.provider.ManifestLabel())
+	}
+
+	// Providers often add the metadata.yaml file only in recent releases, thus listing only the recent release series;
+	// so, if there are embedded metadata for the provider, add the release series missing in the repository metadata
+	// in order to support e.g. upgrades from older releases.
+	if embedded := f.getEmbeddedMetadata(); embedded != nil {
+		mergeReleaseSeries(obj, embedded)
+	}
 
 	return obj, nil
 }
 
+// mergeReleaseSeries adds to the metadata the release series defined in the fallback metadata but not in the metadata itself.
+// Nb. Release series already defined in the metadata always take precedence.
+func mergeReleaseSeries(metadata, fallback *clusterctlv1.Metadata) {
+	for _, fallbackSeries := range fallback.ReleaseSeries {
+		found := false
+		for _, series := range metadata.ReleaseSeries {
+			if series.Major == fallbackSeries.Major && series.Minor == fallbackSeries.Minor {
+				found = true
+				break
+			}
+		}
+		if !found {
+			metadata.ReleaseSeries = append(metadata.ReleaseSeries, fallbackSeries)
+		}
+	}
+}
+
 func (f *metadataClient) getEmbeddedMetadata() *clusterctlv1.Metadata {
 	// clusterctl includes hard-coded metadata for cluster-API providers developed as a SIG-cluster-lifecycle project in order to
 	// provide an option for simplifying the release process/the repository management of those projects.
 	// Embedding metadata in clusterctl is optional, and the metadata.yaml file on the provider repository will always take precedence
-	// on the embedded one.
+	// on the embedded one; embedded release series are used only if not defined in the metadata.yaml file.
 
 	// if you are a developer of a SIG-cluster-lifecycle project, you can send a PR to extend the following list.
 	switch f.provider.Type() {
@@ -93,6 +93,33 @@ func Test_metadataClient_Get(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Pass with embedded metadata release series not defined in the metadata file",
+			fields: fields{
+				provider: config.NewProvider(config.ClusterAPIProviderName, "", clusterctlv1.CoreProviderType),
+				version:  "v1.0.0",
+				repository: test.NewFakeRepository().
+					WithPaths("root", "").
+					WithDefaultVersion("v1.0.0").
+					WithFile("v1.0.0", "metadata.yaml", []byte("apiVersion: clusterctl.cluster.x-k8s.io/v1alpha3\n"+
+						"kind: Metadata\n"+
+						"releaseSeries:\n"+
+						"- major: 0\n"+
+						"  minor: 3\n"+
+						"  contract: v1alpha4\n")), // metadata file overriding the 0.3 release series and without the 0.2 release series
+			},
+			want: &clusterctlv1.Metadata{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: clusterctlv1.GroupVersion.String(),
+					Kind:       "Metadata",
+				},
+				ReleaseSeries: []clusterctlv1.ReleaseSeries{
+					{Major: 0, Minor: 3, Contract: "v1alpha4"},
+					{Major: 0, Minor: 2, Contract: "v1alpha2"},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "Fails if the file does not exists",
 			fields: fields{
@@ -118,6 +145,38 @@ func Test_metadataClient_Get(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "Pass if the end of life of a release series isn't a valid date",
+			fields: fields{
+				provider: config.NewProvider("p1", "", clusterctlv1.CoreProviderType),
+				version:  "v1.0.0",
+				repository: test.NewFakeRepository().
+					WithPaths("root", "").
+					WithDefaultVersion("v1.0.0").
+					WithFile("v1.0.0", "metadata.yaml", []byte("apiVersion: clusterctl.cluster.x-k8s.io/v1alpha3\n"+
+						"kind: Metadata\n"+
+						"releaseSeries:\n"+
+						"- major: 1\n"+
+						"  minor: 2\n"+
+						"  contract: v1alpha3\n"+
+						"  endOfLife: 01/02/2020\n")),
+			},
+			want: &clusterctlv1.Metadata{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "clusterctl.cluster.x-k8s.io/v1alpha3",
+					Kind:       "Metadata",
+				},
+				ReleaseSeries: []clusterctlv1.ReleaseSeries{
+					{
+						Major:     1,
+						Minor:     2,
+						Contract:  "v1alpha3",
+						EndOfLife: "01/02/2020",
+					},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "Fails if the file isn't a valid metadata",
 			fields: fields{
@@ -19,10 +19,13 @@ package repository
 import (
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/client-go/util/homedir"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 )
@@ -65,20 +68,25 @@ func newOverride(o *newOverrideInput) Overrider {
 // Path returns the fully formed path to the file within the specified
 // overrides config.
 func (o *overrides) Path() string {
-	basepath := filepath.Join(homedir.HomeDir(), config.ConfigFolder, overrideFolder)
-	f, err := o.configVariablesClient.Get(overrideFolderKey)
-	if err == nil && len(strings.TrimSpace(f)) != 0 {
-		basepath = f
-	}
-
 	return filepath.Join(
-		basepath,
+		overridesBasePath(o.configVariablesClient),
 		o.providerLabel,
 		o.version,
 		o.filePath,
 	)
 }
 
+// overridesBasePath returns the path of the overrides folder, that is $HOME/.cluster-api/overrides
+// unless a different folder is specified in the clusterctl config.
+func overridesBasePath(configVariablesClient config.VariablesClient) string {
+	basepath := filepath.Join(homedir.HomeDir(), config.ConfigFolder, overrideFolder)
+	f, err := configVariablesClient.Get(overrideFolderKey)
+	if err == nil && len(strings.TrimSpace(f)) != 0 {
+		basepath = f
+	}
+	return basepath
+}
+
 // getLocalOverride return local override file from the config folder, if it exists.
 // This is required for development purposes, but it can be used also in production as a workaround for problems on the official repositories
 func getLocalOverride(info *newOverrideInput) ([]byte, error) {
@@ -101,3 +109,102 @@ func getLocalOverride(info *newOverrideInput) ([]byte, error) {
 	// blocks for any other error
 	return nil, err
 }
+
+// getLocalOverrideVersions returns the provider versions available in the overrides folder, sorted according
+// to semantic version ordering; folders with names that are not valid semantic versions are ignored.
+func getLocalOverrideVersions(configVariablesClient config.VariablesClient, provider config.Provider) ([]string, error) {
+	providerPath := filepath.Join(overridesBasePath(configVariablesClient), provider.ManifestLabel())
+	files, err := ioutil.ReadDir(providerPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to list local overrides for %s", providerPath)
+	}
+
+	versions := []string{}
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+		if _, err := version.ParseSemantic(f.Name()); err != nil {
+			continue
+		}
+		versions = append(versions, f.Name())
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return version.MustParseSemantic(versions[i]).LessThan(version.MustParseSemantic(versions[j]))
+	})
+	return versions, nil
+}
+
+// overridesRepository is a Repository serving only the provider versions available in the overrides folder.
+// It is used when the provider repository cannot be reached, so developers can keep iterating on locally
+// built manifests.
+type overridesRepository struct {
+	configVariablesClient config.VariablesClient
+	provider              config.Provider
+	versions              []string
+	componentsPath        string
+}
+
+var _ Repository = &overridesRepository{}
+
+// newOverridesRepository returns an overridesRepository for a provider, or nil if there are no
+// versions of the provider in the overrides folder.
+func newOverridesRepository(provider config.Provider, configVariablesClient config.VariablesClient) (*overridesRepository, error) {
+	versions, err := getLocalOverrideVersions(configVariablesClient, provider)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, nil
+	}
+
+	return &overridesRepository{
+		configVariablesClient: configVariablesClient,
+		provider:              provider,
+		versions:              versions,
+		componentsPath:        path.Base(provider.URL()),
+	}, nil
+}
+
+// DefaultVersion returns the latest version available in the overrides folder.
+func (r *overridesRepository) DefaultVersion() string {
+	return r.versions[len(r.versions)-1]
+}
+
+// RootPath returns the empty string as files are stored directly in the version folders.
+func (r *overridesRepository) RootPath() string {
+	return ""
+}
+
+// ComponentsPath returns the components file name, derived from the provider URL.
+func (r *overridesRepository) ComponentsPath() string {
+	return r.componentsPath
+}
+
+// GetFile returns a file for a given provider version from the overrides folder.
+func (r *overridesRepository) GetFile(version, path string) ([]byte, error) {
+	if version == "" {
+		version = r.DefaultVersion()
+	}
+	file, err := getLocalOverride(&newOverrideInput{
+		configVariablesClient: r.configVariablesClient,
+		provider:              r.provider,
+		version:               version,
+		filePath:              path,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, errors.Errorf("file %q for version %s does not exist in the overrides folder", path, version)
+	}
+	return file, nil
+}
+
+// GetVersions returns the versions available in the overrides folder.
+func (r *overridesRepository) GetVersions() ([]string, error) {
+	return r.versions, nil
+}
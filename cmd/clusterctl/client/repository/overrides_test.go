@@ -106,3 +106,78 @@ func TestGetLocalOverrides(t *testing.T) {
 		g.Expect(err).ToNot(HaveOccurred())
 	})
 }
+
+func TestGetLocalOverrideVersions(t *testing.T) {
+	g := NewWithT(t)
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	createLocalTestProviderFile(t, tmpDir, "infrastructure-myinfra/v1.10.0/infra-comp.yaml", "")
+	createLocalTestProviderFile(t, tmpDir, "infrastructure-myinfra/v1.2.0/infra-comp.yaml", "")
+	createLocalTestProviderFile(t, tmpDir, "infrastructure-myinfra/dev/infra-comp.yaml", "")
+
+	configVarClient := test.NewFakeVariableClient().WithVar(overrideFolderKey, tmpDir)
+
+	versions, err := getLocalOverrideVersions(configVarClient, config.NewProvider("myinfra", "", clusterctlv1.InfrastructureProviderType))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(versions).To(Equal([]string{"v1.2.0", "v1.10.0"}))
+
+	versions, err = getLocalOverrideVersions(configVarClient, config.NewProvider("other", "", clusterctlv1.InfrastructureProviderType))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(versions).To(BeEmpty())
+}
+
+func TestRepositoryClientWithOverrides(t *testing.T) {
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	createLocalTestProviderFile(t, tmpDir, "infrastructure-myinfra/v1.0.0/infra-comp.yaml", "foo: v1.0.0")
+	createLocalTestProviderFile(t, tmpDir, "infrastructure-myinfra/v1.1.0-dev/infra-comp.yaml", "foo: v1.1.0-dev")
+
+	configClient, err := config.New("", config.InjectReader(test.NewFakeReader().WithVar(overrideFolderKey, tmpDir)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("adds the versions in the overrides folder to the repository versions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		repository := test.NewFakeRepository().
+			WithPaths("root", "infra-comp.yaml").
+			WithDefaultVersion("v1.0.0").
+			WithVersions("v0.9.0", "v1.0.0")
+
+		provider := config.NewProvider("myinfra", "https://github.com/myorg/myrepo/releases/latest/infra-comp.yaml", clusterctlv1.InfrastructureProviderType)
+		c, err := newRepositoryClient(provider, configClient, InjectRepository(repository))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		versions, err := c.GetVersions()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(versions).To(ConsistOf("v0.9.0", "v1.0.0", "v1.1.0-dev"))
+	})
+
+	t.Run("uses the overrides folder if the provider repository can't be reached", func(t *testing.T) {
+		g := NewWithT(t)
+
+		provider := config.NewProvider("myinfra", "ftp://example.com/infra-comp.yaml", clusterctlv1.InfrastructureProviderType)
+		c, err := newRepositoryClient(provider, configClient)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(c.repository.DefaultVersion()).To(Equal("v1.1.0-dev"))
+		g.Expect(c.repository.ComponentsPath()).To(Equal("infra-comp.yaml"))
+
+		file, err := c.repository.GetFile("", c.repository.ComponentsPath())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(file)).To(Equal("foo: v1.1.0-dev"))
+
+		_, err = c.repository.GetFile("v1.0.0", "cluster-template.yaml")
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("fails if the provider repository can't be reached and there are no overrides", func(t *testing.T) {
+		g := NewWithT(t)
+
+		provider := config.NewProvider("other", "ftp://example.com/infra-comp.yaml", clusterctlv1.InfrastructureProviderType)
+		_, err := newRepositoryClient(provider, configClient)
+		g.Expect(err).To(HaveOccurred())
+	})
+}
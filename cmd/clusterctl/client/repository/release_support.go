@@ -0,0 +1,71 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+// endOfLifeLayout is the layout of the end of life dates of the release series in the provider metadata.
+const endOfLifeLayout = "2006-01-02"
+
+// SupportWarning returns a warning if, according to the provider metadata, the release series of a version of the
+// provider is deprecated or has reached its end of life at the given time; it returns an empty string otherwise.
+func SupportWarning(providerLabel string, metadata *clusterctlv1.Metadata, providerVersion string, now time.Time) string {
+	v, err := version.ParseSemantic(providerVersion)
+	if err != nil {
+		return ""
+	}
+	releaseSeries := metadata.GetReleaseSeriesForVersion(v)
+	if releaseSeries == nil {
+		return ""
+	}
+
+	name := fmt.Sprintf("v%d.%d", releaseSeries.Major, releaseSeries.Minor)
+	if releaseSeries.EndOfLife != "" {
+		// Nb. invalid dates are reported when reading the metadata, and otherwise ignored.
+		if endOfLife, err := time.Parse(endOfLifeLayout, releaseSeries.EndOfLife); err == nil && !now.Before(endOfLife) {
+			return fmt.Sprintf("%s %s is no longer supported: the %s release series reached its end of life on %s", providerLabel, providerVersion, name, releaseSeries.EndOfLife)
+		}
+	}
+	if releaseSeries.Deprecated {
+		warning := fmt.Sprintf("%s %s is deprecated: the %s release series is deprecated", providerLabel, providerVersion, name)
+		if releaseSeries.EndOfLife != "" {
+			warning += fmt.Sprintf(" and reaches its end of life on %s", releaseSeries.EndOfLife)
+		}
+		return warning
+	}
+	return ""
+}
+
+// validateReleaseSeriesSupport checks the end of life dates of the release series in the provider metadata.
+func validateReleaseSeriesSupport(metadata *clusterctlv1.Metadata) error {
+	for _, releaseSeries := range metadata.ReleaseSeries {
+		if releaseSeries.EndOfLife == "" {
+			continue
+		}
+		if _, err := time.Parse(endOfLifeLayout, releaseSeries.EndOfLife); err != nil {
+			return errors.Errorf("invalid end of life %q for the v%d.%d release series: the date must be in the YYYY-MM-DD format", releaseSeries.EndOfLife, releaseSeries.Major, releaseSeries.Minor)
+		}
+	}
+	return nil
+}
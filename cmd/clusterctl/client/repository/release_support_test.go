@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+func TestSupportWarning(t *testing.T) {
+	metadata := &clusterctlv1.Metadata{
+		ReleaseSeries: []clusterctlv1.ReleaseSeries{
+			{Major: 0, Minor: 2, Contract: "v1alpha2", Deprecated: true, EndOfLife: "2020-06-01"},
+			{Major: 0, Minor: 3, Contract: "v1alpha3", Deprecated: true},
+			{Major: 0, Minor: 4, Contract: "v1alpha3"},
+		},
+	}
+	now := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		version string
+		now     time.Time
+		want    string
+	}{
+		{
+			name:    "deprecated release series with an end of life",
+			version: "v0.2.5",
+			now:     now,
+			want:    "infra v0.2.5 is deprecated: the v0.2 release series is deprecated and reaches its end of life on 2020-06-01",
+		},
+		{
+			name:    "release series at its end of life",
+			version: "v0.2.5",
+			now:     time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+			want:    "infra v0.2.5 is no longer supported: the v0.2 release series reached its end of life on 2020-06-01",
+		},
+		{
+			name:    "deprecated release series",
+			version: "v0.3.0",
+			now:     now,
+			want:    "infra v0.3.0 is deprecated: the v0.3 release series is deprecated",
+		},
+		{
+			name:    "supported release series",
+			version: "v0.4.1",
+			now:     now,
+			want:    "",
+		},
+		{
+			name:    "version not matching any release series",
+			version: "v0.5.0",
+			now:     now,
+			want:    "",
+		},
+		{
+			name:    "invalid version",
+			version: "latest",
+			now:     now,
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(SupportWarning("infra", metadata, tt.version, tt.now)).To(Equal(tt.want))
+		})
+	}
+}
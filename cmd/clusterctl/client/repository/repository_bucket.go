@@ -0,0 +1,194 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+const (
+	s3Scheme                 = "s3"
+	gcsScheme                = "gs"
+	bucketLatestReleaseLabel = "latest"
+)
+
+// objectStore defines the operations used by bucketRepository for reading from an object storage service.
+type objectStore interface {
+	// ListPrefixes returns the names of the prefixes directly under the given prefix, e.g. the versions
+	// stored under {basepath}/, without the trailing delimiter.
+	ListPrefixes(ctx context.Context, bucket, prefix string) ([]string, error)
+
+	// GetObject returns the content of an object.
+	GetObject(ctx context.Context, bucket, key string) ([]byte, error)
+}
+
+// bucketRepository provides support for providers hosted on object storage buckets, e.g. AWS S3 or Google Cloud Storage.
+// As part of the provider object, the URL is expected to point to the components yaml in the bucket.
+// To support different versions, the objects must adhere to the following layout:
+// {s3|gs}://{bucket}/{basepath}/{version}/{components.yaml}
+//
+// (1): {version} must obey the syntax and semantics of the "Semantic Versioning"
+// specification (http://semver.org/); however, "latest" is also an acceptable value.
+//
+// Concrete example:
+// s3://my-org-releases/infrastructure-aws/latest/infrastructure-components.yaml
+// bucket: my-org-releases
+// basepath: infrastructure-aws
+// version: v0.5.4 (whatever latest resolve to)
+// components.yaml: infrastructure-components.yaml
+type bucketRepository struct {
+	providerConfig        config.Provider
+	configVariablesClient config.VariablesClient
+	store                 objectStore
+	bucket                string
+	basepath              string
+	defaultVersion        string
+	componentsPath        string
+}
+
+var _ Repository = &bucketRepository{}
+
+// DefaultVersion returns the default version for the bucket repository.
+func (r *bucketRepository) DefaultVersion() string {
+	return r.defaultVersion
+}
+
+// RootPath returns the empty string as files are stored directly under the version prefixes.
+func (r *bucketRepository) RootPath() string {
+	return ""
+}
+
+// ComponentsPath returns the path to the components file for the bucket repository.
+func (r *bucketRepository) ComponentsPath() string {
+	return r.componentsPath
+}
+
+// GetFile returns a file for a given provider version.
+func (r *bucketRepository) GetFile(version, fileName string) ([]byte, error) {
+	var err error
+
+	if version == bucketLatestReleaseLabel {
+		version, err = r.getLatestRelease()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the latest release")
+		}
+	} else if version == "" {
+		version = r.defaultVersion
+	}
+
+	key := path.Join(r.basepath, version, fileName)
+	content, err := r.store.GetObject(context.TODO(), r.bucket, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read file %q from bucket %q", key, r.bucket)
+	}
+	return content, nil
+}
+
+// GetVersions returns the list of versions that are available in the bucket repository.
+func (r *bucketRepository) GetVersions() ([]string, error) {
+	prefix := ""
+	if r.basepath != "" {
+		prefix = r.basepath + "/"
+	}
+	prefixes, err := r.store.ListPrefixes(context.TODO(), r.bucket, prefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list versions in bucket %q", r.bucket)
+	}
+
+	versions := []string{}
+	for _, p := range prefixes {
+		if _, err := version.ParseSemantic(p); err != nil {
+			// discard prefixes that are not a valid semantic versions (the user can point explicitly to such releases)
+			continue
+		}
+		versions = append(versions, p)
+	}
+	return versions, nil
+}
+
+// newBucketRepository returns a new bucketRepository reading from the given object store.
+func newBucketRepository(providerConfig config.Provider, configVariablesClient config.VariablesClient, store objectStore) (*bucketRepository, error) {
+	rURL, err := url.Parse(providerConfig.URL())
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid url")
+	}
+
+	// Extracts bucket, basepath, version and componentsPath from the url
+	// NB. format is {s3|gs}://{bucket}/{basepath}/{version}/{components.yaml}
+	bucket := rURL.Host
+	urlSplit := strings.Split(strings.Trim(rURL.Path, "/"), "/")
+	if bucket == "" || len(urlSplit) < 2 {
+		return nil, errors.Errorf("invalid url: a bucket url should be in the form %s://{bucket}/{basepath}/{version}/{components.yaml}", rURL.Scheme)
+	}
+
+	componentsPath := urlSplit[len(urlSplit)-1]
+	defaultVersion := urlSplit[len(urlSplit)-2]
+	if defaultVersion != bucketLatestReleaseLabel {
+		if _, err := version.ParseSemantic(defaultVersion); err != nil {
+			return nil, errors.Errorf("invalid version: %q. Version must obey the syntax and semantics of the \"Semantic Versioning\" specification (http://semver.org/) and path format {basepath}/{version}/{components.yaml}", defaultVersion)
+		}
+	}
+
+	repo := &bucketRepository{
+		providerConfig:        providerConfig,
+		configVariablesClient: configVariablesClient,
+		store:                 store,
+		bucket:                bucket,
+		basepath:              strings.Join(urlSplit[:len(urlSplit)-2], "/"),
+		defaultVersion:        defaultVersion,
+		componentsPath:        componentsPath,
+	}
+
+	if defaultVersion == bucketLatestReleaseLabel {
+		repo.defaultVersion, err = repo.getLatestRelease()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get latest version")
+		}
+	}
+	return repo, nil
+}
+
+// getLatestRelease returns the latest release for the bucket repository, according to semantic version ordering.
+func (r *bucketRepository) getLatestRelease() (string, error) {
+	versions, err := r.GetVersions()
+	if err != nil {
+		return "", err
+	}
+	var latestTag string
+	var latestReleaseVersion *version.Version
+	for _, v := range versions {
+		sv, err := version.ParseSemantic(v)
+		if err != nil {
+			continue
+		}
+		if latestReleaseVersion == nil || latestReleaseVersion.LessThan(sv) {
+			latestTag = v
+			latestReleaseVersion = sv
+		}
+	}
+	if latestTag == "" {
+		return "", errors.New("failed to find releases tagged with a valid semantic version number")
+	}
+	return latestTag, nil
+}
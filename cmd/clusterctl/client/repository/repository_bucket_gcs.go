@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/google"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+)
+
+const (
+	gcsDefaultEndpoint = "https://storage.googleapis.com"
+	gcsReadOnlyScope   = "https://www.googleapis.com/auth/devstorage.read_only"
+
+	googleApplicationCredentialsVariable = "GOOGLE_APPLICATION_CREDENTIALS"
+)
+
+// gcsStore implements objectStore for Google Cloud Storage, using the Cloud Storage JSON API.
+type gcsStore struct {
+	httpClient *http.Client
+	endpoint   string
+}
+
+var _ objectStore = &gcsStore{}
+
+// newGCSStore returns a gcsStore using the Google Application Default Credentials; if no credentials are found,
+// requests are sent anonymously, e.g. for public buckets. Failing to load the credentials file set with the
+// GOOGLE_APPLICATION_CREDENTIALS environment variable is an error.
+func newGCSStore() (*gcsStore, error) {
+	httpClient, err := google.DefaultClient(context.TODO(), gcsReadOnlyScope)
+	if err != nil {
+		if f := os.Getenv(googleApplicationCredentialsVariable); f != "" {
+			return nil, errors.Wrapf(err, "failed to load the Google Cloud credentials from %s=%q", googleApplicationCredentialsVariable, f)
+		}
+		logf.Log.Info("No Google Cloud credentials found, sending anonymous requests to Cloud Storage", "Reason", err.Error())
+		httpClient = http.DefaultClient
+	}
+	return &gcsStore{
+		httpClient: httpClient,
+		endpoint:   gcsDefaultEndpoint,
+	}, nil
+}
+
+// gcsListObjectsResult is the subset of the objects list response used by gcsStore.
+type gcsListObjectsResult struct {
+	Prefixes      []string `json:"prefixes"`
+	NextPageToken string   `json:"nextPageToken"`
+}
+
+// ListPrefixes returns the names of the prefixes directly under the given prefix.
+func (s *gcsStore) ListPrefixes(ctx context.Context, bucket, prefix string) ([]string, error) {
+	prefixes := []string{}
+	pageToken := ""
+	for {
+		query := url.Values{}
+		query.Set("delimiter", "/")
+		query.Set("prefix", prefix)
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+
+		body, err := s.do(ctx, fmt.Sprintf("%s/storage/v1/b/%s/o?%s", s.endpoint, url.PathEscape(bucket), query.Encode()))
+		if err != nil {
+			return nil, err
+		}
+
+		result := &gcsListObjectsResult{}
+		if err := json.Unmarshal(body, result); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode the list of objects in bucket %q", bucket)
+		}
+		for _, p := range result.Prefixes {
+			prefixes = append(prefixes, strings.TrimSuffix(strings.TrimPrefix(p, prefix), "/"))
+		}
+
+		if result.NextPageToken == "" {
+			return prefixes, nil
+		}
+		pageToken = result.NextPageToken
+	}
+}
+
+// GetObject returns the content of an object.
+func (s *gcsStore) GetObject(ctx context.Context, bucket, key string) ([]byte, error) {
+	return s.do(ctx, fmt.Sprintf("%s/storage/v1/b/%s/o/%s?alt=media", s.endpoint, url.PathEscape(bucket), url.PathEscape(key)))
+}
+
+// do sends a GET request and returns the response body.
+func (s *gcsStore) do(ctx context.Context, rawurl string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawurl, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the Cloud Storage request")
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send the Cloud Storage request")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the Cloud Storage response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Cloud Storage request %s failed with status %s", req.URL.Path, resp.Status)
+	}
+	return body, nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+)
+
+const (
+	awsAccessKeyIDVariable     = "AWS_ACCESS_KEY_ID"
+	awsSecretAccessKeyVariable = "AWS_SECRET_ACCESS_KEY"
+	awsSessionTokenVariable    = "AWS_SESSION_TOKEN"
+	awsRegionVariable          = "AWS_REGION"
+	awsDefaultRegionVariable   = "AWS_DEFAULT_REGION"
+	awsProfileVariable         = "AWS_PROFILE"
+	awsS3EndpointVariable      = "AWS_S3_ENDPOINT"
+
+	awsDefaultRegion = "us-east-1"
+)
+
+// s3Store implements objectStore for AWS S3 and S3 compatible services.
+type s3Store struct {
+	client *s3.S3
+}
+
+var _ objectStore = &s3Store{}
+
+// newS3Store returns an s3Store using the ambient AWS configuration, resolved with the default credential chain of
+// the AWS SDK, e.g. environment variables, shared credentials and config files (including SSO profiles), web identity
+// tokens, and the ECS and EC2 instance roles. The AWS variables can be set in the clusterctl configuration file too.
+// If no credentials are found, requests are sent anonymously, e.g. for public buckets.
+func newS3Store(configVariablesClient config.VariablesClient) (*s3Store, error) {
+	options := session.Options{SharedConfigState: session.SharedConfigEnable}
+	if profile, err := configVariablesClient.Get(awsProfileVariable); err == nil && profile != "" {
+		options.Profile = profile
+	}
+	for _, v := range []string{awsRegionVariable, awsDefaultRegionVariable} {
+		if region, err := configVariablesClient.Get(v); err == nil && region != "" {
+			options.Config.Region = aws.String(region)
+			break
+		}
+	}
+	if endpoint, err := configVariablesClient.Get(awsS3EndpointVariable); err == nil && endpoint != "" {
+		// Custom endpoints, e.g. S3 compatible services, are used with path style URLs.
+		options.Config.Endpoint = aws.String(strings.TrimSuffix(endpoint, "/"))
+		options.Config.S3ForcePathStyle = aws.Bool(true)
+	}
+	accessKeyID, _ := configVariablesClient.Get(awsAccessKeyIDVariable)
+	secretAccessKey, _ := configVariablesClient.Get(awsSecretAccessKeyVariable)
+	if accessKeyID != "" && secretAccessKey != "" {
+		sessionToken, _ := configVariablesClient.Get(awsSessionTokenVariable)
+		options.Config.Credentials = credentials.NewStaticCredentials(accessKeyID, secretAccessKey, sessionToken)
+	}
+
+	sess, err := session.NewSessionWithOptions(options)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the AWS configuration")
+	}
+	if aws.StringValue(sess.Config.Region) == "" {
+		sess.Config.Region = aws.String(awsDefaultRegion)
+	}
+
+	// Fall back to anonymous requests only if no credential source is configured; failing to load the credentials
+	// of a configured source, e.g. an expired SSO session, is an error.
+	if _, err := sess.Config.Credentials.Get(); err != nil {
+		if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != "NoCredentialProviders" {
+			return nil, errors.Wrap(err, "failed to get the AWS credentials")
+		}
+		logf.Log.Info("No AWS credentials found, sending anonymous requests to S3")
+		sess.Config.Credentials = credentials.AnonymousCredentials
+	}
+
+	return &s3Store{client: s3.New(sess)}, nil
+}
+
+// ListPrefixes returns the names of the prefixes directly under the given prefix.
+func (s *s3Store) ListPrefixes(ctx context.Context, bucket, prefix string) ([]string, error) {
+	prefixes := []string{}
+	input := &s3.ListObjectsV2Input{
+		Bucket:    aws.String(bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	}
+	err := s.client.ListObjectsV2PagesWithContext(ctx, input, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, p := range page.CommonPrefixes {
+			prefixes = append(prefixes, strings.TrimSuffix(strings.TrimPrefix(aws.StringValue(p.Prefix), prefix), "/"))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the objects in bucket %q", bucket)
+	}
+	return prefixes, nil
+}
+
+// GetObject returns the content of an object.
+func (s *s3Store) GetObject(ctx context.Context, bucket, key string) ([]byte, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the object %q in bucket %q", key, bucket)
+	}
+	defer out.Body.Close()
+
+	body, err := ioutil.ReadAll(out.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the object %q in bucket %q", key, bucket)
+	}
+	return body, nil
+}
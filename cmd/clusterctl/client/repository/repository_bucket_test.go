@@ -0,0 +1,272 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+// fakeObjectStore is an in memory objectStore.
+type fakeObjectStore struct {
+	objects map[string]string
+}
+
+func (s *fakeObjectStore) ListPrefixes(_ context.Context, bucket, prefix string) ([]string, error) {
+	prefixes := map[string]bool{}
+	for k := range s.objects {
+		key := strings.TrimPrefix(k, bucket+"/")
+		if !strings.HasPrefix(k, bucket+"/") || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if parts := strings.SplitN(strings.TrimPrefix(key, prefix), "/", 2); len(parts) == 2 {
+			prefixes[parts[0]] = true
+		}
+	}
+	ret := []string{}
+	for p := range prefixes {
+		ret = append(ret, p)
+	}
+	return ret, nil
+}
+
+func (s *fakeObjectStore) GetObject(_ context.Context, bucket, key string) ([]byte, error) {
+	content, ok := s.objects[bucket+"/"+key]
+	if !ok {
+		return nil, errors.Errorf("object %s not found", key)
+	}
+	return []byte(content), nil
+}
+
+func Test_newBucketRepository(t *testing.T) {
+	store := &fakeObjectStore{
+		objects: map[string]string{
+			"releases/infrastructure-foo/v1.0.0/components.yaml": "v1.0.0",
+			"releases/infrastructure-foo/v1.2.0/components.yaml": "v1.2.0",
+			"releases/infrastructure-foo/dev/components.yaml":    "dev",
+		},
+	}
+
+	tests := []struct {
+		name               string
+		url                string
+		wantBasepath       string
+		wantDefaultVersion string
+		wantComponentsPath string
+		wantErr            bool
+	}{
+		{
+			name:               "Resolves latest to the most recent version",
+			url:                "s3://releases/infrastructure-foo/latest/components.yaml",
+			wantBasepath:       "infrastructure-foo",
+			wantDefaultVersion: "v1.2.0",
+			wantComponentsPath: "components.yaml",
+		},
+		{
+			name:               "Uses the version in the url",
+			url:                "gs://releases/infrastructure-foo/v1.0.0/components.yaml",
+			wantBasepath:       "infrastructure-foo",
+			wantDefaultVersion: "v1.0.0",
+			wantComponentsPath: "components.yaml",
+		},
+		{
+			name:    "Fails for urls without a version",
+			url:     "s3://releases/components.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "Fails for invalid versions",
+			url:     "s3://releases/infrastructure-foo/dev/components.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "Fails if there are no versions",
+			url:     "s3://releases/infrastructure-bar/latest/components.yaml",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			provider := config.NewProvider("foo", tt.url, clusterctlv1.InfrastructureProviderType)
+			got, err := newBucketRepository(provider, test.NewFakeVariableClient(), store)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.bucket).To(Equal("releases"))
+			g.Expect(got.basepath).To(Equal(tt.wantBasepath))
+			g.Expect(got.DefaultVersion()).To(Equal(tt.wantDefaultVersion))
+			g.Expect(got.ComponentsPath()).To(Equal(tt.wantComponentsPath))
+
+			versions, err := got.GetVersions()
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(versions).To(ConsistOf("v1.0.0", "v1.2.0"))
+
+			content, err := got.GetFile("", got.ComponentsPath())
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(content)).To(Equal(tt.wantDefaultVersion))
+
+			content, err = got.GetFile("dev", got.ComponentsPath())
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(content)).To(Equal("dev"))
+		})
+	}
+}
+
+func Test_s3Store(t *testing.T) {
+	g := NewWithT(t)
+
+	var authorizations []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/releases/", func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/releases/":
+			g.Expect(r.URL.Query().Get("list-type")).To(Equal("2"))
+			g.Expect(r.URL.Query().Get("prefix")).To(Equal("infrastructure-foo/"))
+			if r.URL.Query().Get("continuation-token") == "" {
+				fmt.Fprint(w, `<ListBucketResult><CommonPrefixes><Prefix>infrastructure-foo/v1.0.0/</Prefix></CommonPrefixes><IsTruncated>true</IsTruncated><NextContinuationToken>next</NextContinuationToken></ListBucketResult>`)
+				return
+			}
+			fmt.Fprint(w, `<ListBucketResult><CommonPrefixes><Prefix>infrastructure-foo/v1.1.0/</Prefix></CommonPrefixes><IsTruncated>false</IsTruncated></ListBucketResult>`)
+		case "/releases/infrastructure-foo/v1.1.0/components.yaml":
+			fmt.Fprint(w, "content")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	s, err := newS3Store(test.NewFakeVariableClient().
+		WithVar(awsS3EndpointVariable, server.URL).
+		WithVar(awsAccessKeyIDVariable, "key").
+		WithVar(awsSecretAccessKeyVariable, "secret"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	prefixes, err := s.ListPrefixes(context.TODO(), "releases", "infrastructure-foo/")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(prefixes).To(Equal([]string{"v1.0.0", "v1.1.0"}))
+
+	content, err := s.GetObject(context.TODO(), "releases", "infrastructure-foo/v1.1.0/components.yaml")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal("content"))
+
+	_, err = s.GetObject(context.TODO(), "releases", "infrastructure-foo/v1.1.0/missing.yaml")
+	g.Expect(err).To(HaveOccurred())
+
+	g.Expect(authorizations).To(HaveLen(4))
+	for _, a := range authorizations {
+		g.Expect(a).To(HavePrefix("AWS4-HMAC-SHA256 Credential=key/"))
+	}
+}
+
+func Test_s3Store_dottedBucket(t *testing.T) {
+	g := NewWithT(t)
+
+	s, err := newS3Store(test.NewFakeVariableClient().
+		WithVar(awsRegionVariable, "eu-west-1").
+		WithVar(awsAccessKeyIDVariable, "key").
+		WithVar(awsSecretAccessKeyVariable, "secret"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Virtual-hosted style URLs are used for AWS, except for bucket names with dots, which are not valid host names for TLS.
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{Bucket: aws.String("releases"), Key: aws.String("components.yaml")})
+	g.Expect(req.Build()).To(Succeed())
+	g.Expect(req.HTTPRequest.URL.Host).To(Equal("releases.s3.eu-west-1.amazonaws.com"))
+	g.Expect(req.HTTPRequest.URL.Path).To(Equal("/components.yaml"))
+
+	req, _ = s.client.GetObjectRequest(&s3.GetObjectInput{Bucket: aws.String("releases.example.com"), Key: aws.String("components.yaml")})
+	g.Expect(req.Build()).To(Succeed())
+	g.Expect(req.HTTPRequest.URL.Host).To(Equal("s3.eu-west-1.amazonaws.com"))
+	g.Expect(req.HTTPRequest.URL.Path).To(Equal("/releases.example.com/components.yaml"))
+}
+
+func Test_gcsStore(t *testing.T) {
+	g := NewWithT(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/storage/v1/b/releases/o", func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Query().Get("prefix")).To(Equal("infrastructure-foo/"))
+		if r.URL.Query().Get("pageToken") == "" {
+			fmt.Fprint(w, `{"prefixes": ["infrastructure-foo/v1.0.0/"], "nextPageToken": "next"}`)
+			return
+		}
+		fmt.Fprint(w, `{"prefixes": ["infrastructure-foo/v1.1.0/"]}`)
+	})
+	mux.HandleFunc("/storage/v1/b/releases/o/", func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Query().Get("alt")).To(Equal("media"))
+		if strings.TrimPrefix(r.URL.EscapedPath(), "/storage/v1/b/releases/o/") != url.PathEscape("infrastructure-foo/v1.1.0/components.yaml") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "content")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	s := &gcsStore{httpClient: http.DefaultClient, endpoint: server.URL}
+
+	prefixes, err := s.ListPrefixes(context.TODO(), "releases", "infrastructure-foo/")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(prefixes).To(Equal([]string{"v1.0.0", "v1.1.0"}))
+
+	content, err := s.GetObject(context.TODO(), "releases", "infrastructure-foo/v1.1.0/components.yaml")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal("content"))
+
+	_, err = s.GetObject(context.TODO(), "releases", "infrastructure-foo/v1.1.0/missing.yaml")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_newGCSStore_invalidCredentials(t *testing.T) {
+	g := NewWithT(t)
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	previous, set := os.LookupEnv(googleApplicationCredentialsVariable)
+	defer func() {
+		if set {
+			os.Setenv(googleApplicationCredentialsVariable, previous)
+		} else {
+			os.Unsetenv(googleApplicationCredentialsVariable)
+		}
+	}()
+
+	// A configured credentials file that can't be loaded is an error, rather than a fallback to anonymous requests.
+	g.Expect(os.Setenv(googleApplicationCredentialsVariable, filepath.Join(tmpDir, "missing.json"))).To(Succeed())
+	_, err := newGCSStore()
+	g.Expect(err).To(HaveOccurred())
+}
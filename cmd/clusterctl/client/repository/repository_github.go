@@ -19,17 +19,21 @@ package repository
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/github"
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
 	"k8s.io/apimachinery/pkg/util/version"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
 )
 
 const (
@@ -37,6 +41,10 @@ const (
 	githubDomain             = "github.com"
 	githubReleaseRepository  = "releases"
 	githubLatestReleaseLabel = "latest"
+
+	// githubListReleasesPerPage is the number of releases per page when listing the releases of a repository,
+	// which is the maximum allowed by the GitHub API.
+	githubListReleasesPerPage = 100
 )
 
 var (
@@ -181,7 +189,47 @@ func (g *gitHubRepository) getClient() *github.Client {
 	if g.injectClient != nil {
 		return g.injectClient
 	}
-	return github.NewClient(g.authenticatingHTTPClient)
+	return github.NewClient(g.getHTTPClient())
+}
+
+// getHTTPClient returns the http client used by the github API client; the responses are cached on disk and
+// revalidated using ETags, so repeated calls do not count against the GitHub API rate limit.
+func (g *gitHubRepository) getHTTPClient() *http.Client {
+	transport := http.DefaultTransport
+	if g.authenticatingHTTPClient != nil && g.authenticatingHTTPClient.Transport != nil {
+		transport = g.authenticatingHTTPClient.Transport
+	}
+	return &http.Client{
+		Transport: &etagCachingTransport{
+			transport: transport,
+			folder:    githubCachePath(g.configVariablesClient),
+		},
+	}
+}
+
+// callGitHub invokes a github API call; if the call fails because the GitHub token is not valid (e.g. it is expired),
+// the call is retried without authentication, which works for public repositories at the cost of a lower rate limit.
+func (g *gitHubRepository) callGitHub(call func(client *github.Client) error) error {
+	err := call(g.getClient())
+	if err == nil || g.authenticatingHTTPClient == nil || !isGitHubUnauthorized(err) {
+		return err
+	}
+
+	// Nb. the anonymous client is used also for all the following calls.
+	g.authenticatingHTTPClient = nil
+	return call(g.getClient())
+}
+
+// isGitHubUnauthorized returns true if the error is a 401 Unauthorized response from the GitHub API.
+func isGitHubUnauthorized(err error) bool {
+	errResp, ok := err.(*github.ErrorResponse)
+	return ok && errResp.Response != nil && errResp.Response.StatusCode == http.StatusUnauthorized
+}
+
+// isGitHubNotFound returns true if the error is a 404 Not Found response from the GitHub API.
+func isGitHubNotFound(err error) bool {
+	errResp, ok := err.(*github.ErrorResponse)
+	return ok && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound
 }
 
 // setClientToken sets authenticatingHTTPClient field of gitHubRepository struct
@@ -192,39 +240,75 @@ func (g *gitHubRepository) setClientToken(token string) {
 	g.authenticatingHTTPClient = oauth2.NewClient(context.TODO(), ts)
 }
 
-// getVersions returns all the release versions for a github repository
+// getVersions returns all the release versions for a github repository, sorted by semantic version.
 func (g *gitHubRepository) getVersions() ([]string, error) {
 	cacheID := fmt.Sprintf("%s/%s", g.owner, g.repository)
 	if versions, ok := cacheVersions[cacheID]; ok {
 		return versions, nil
 	}
 
-	client := g.getClient()
-
-	// get all the releases
-	// NB. currently Github API does not support result ordering, so it not possible to limit results
-	releases, _, err := client.Repositories.ListReleases(context.TODO(), g.owner, g.repository, nil)
+	releases, err := g.listReleases()
 	if err != nil {
 		return nil, g.handleGithubErr(err, "failed to get the list of releases")
 	}
-	versions := []string{}
+
+	type semanticTag struct {
+		tag     string
+		version *version.Version
+	}
+	tags := []semanticTag{}
+	seen := map[string]bool{}
 	for _, r := range releases {
-		r := r // pin
-		if r.TagName == nil {
+		if r.TagName == nil || r.GetDraft() || seen[*r.TagName] {
 			continue
 		}
 		tagName := *r.TagName
-		if _, err := version.ParseSemantic(tagName); err != nil {
+		sv, err := version.ParseSemantic(tagName)
+		if err != nil {
 			// Discard releases with tags that are not a valid semantic versions (the user can point explicitly to such releases).
 			continue
 		}
-		versions = append(versions, tagName)
+		seen[tagName] = true
+		tags = append(tags, semanticTag{tag: tagName, version: sv})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].version.LessThan(tags[j].version) })
+
+	versions := make([]string, 0, len(tags))
+	for _, t := range tags {
+		versions = append(versions, t.tag)
 	}
 
 	cacheVersions[cacheID] = versions
 	return versions, nil
 }
 
+// listReleases returns all the releases of a github repository, following the pagination of the GitHub API.
+// NB. the GitHub API does not support result ordering, so all the pages are read; each page is cached on disk by the
+// http client and revalidated using ETags, so listing the releases again does not count against the rate limit.
+func (g *gitHubRepository) listReleases() ([]*github.RepositoryRelease, error) {
+	var releases []*github.RepositoryRelease
+	opts := &github.ListOptions{PerPage: githubListReleasesPerPage}
+	for {
+		var (
+			page     []*github.RepositoryRelease
+			response *github.Response
+		)
+		err := g.callGitHub(func(client *github.Client) (err error) {
+			page, response, err = client.Repositories.ListReleases(context.TODO(), g.owner, g.repository, opts)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, page...)
+
+		if response == nil || response.NextPage == 0 {
+			return releases, nil
+		}
+		opts.Page = response.NextPage
+	}
+}
+
 // getLatestRelease returns the latest release for a github repository, according to
 // semantic version order of the release tag name.
 func (g *gitHubRepository) getLatestRelease() (string, error) {
@@ -262,15 +346,20 @@ func (g *gitHubRepository) getReleaseByTag(tag string) (*github.RepositoryReleas
 		return release, nil
 	}
 
-	client := g.getClient()
-
-	release, _, err := client.Repositories.GetReleaseByTag(context.TODO(), g.owner, g.repository, tag)
+	var release *github.RepositoryRelease
+	err := g.callGitHub(func(client *github.Client) (err error) {
+		release, _, err = client.Repositories.GetReleaseByTag(context.TODO(), g.owner, g.repository, tag)
+		return err
+	})
+	if isGitHubNotFound(err) {
+		return nil, messages.Wrap(err, messages.VersionNotFound, tag, g.owner+"/"+g.repository)
+	}
 	if err != nil {
 		return nil, g.handleGithubErr(err, "failed to read release %q", tag)
 	}
 
 	if release == nil {
-		return nil, errors.Errorf("failed to get release %q", tag)
+		return nil, messages.New(messages.VersionNotFound, tag, g.owner+"/"+g.repository)
 	}
 
 	cacheReleases[cacheID] = release
@@ -284,7 +373,6 @@ func (g *gitHubRepository) downloadFilesFromRelease(release *github.RepositoryRe
 		return content, nil
 	}
 
-	client := g.getClient()
 	absoluteFileName := filepath.Join(g.rootPath, fileName)
 
 	// search for the file into the release assets, retrieving the asset id
@@ -299,7 +387,12 @@ func (g *gitHubRepository) downloadFilesFromRelease(release *github.RepositoryRe
 		return nil, errors.Errorf("failed to get file %q from %q release", fileName, *release.TagName)
 	}
 
-	reader, redirect, err := client.Repositories.DownloadReleaseAsset(context.TODO(), g.owner, g.repository, *assetID)
+	var reader io.ReadCloser
+	var redirect string
+	err := g.callGitHub(func(client *github.Client) (err error) {
+		reader, redirect, err = client.Repositories.DownloadReleaseAsset(context.TODO(), g.owner, g.repository, *assetID)
+		return err
+	})
 	if err != nil {
 		return nil, g.handleGithubErr(err, "failed to download file %q from %q release", *release.TagName, fileName)
 	}
@@ -324,8 +417,15 @@ func (g *gitHubRepository) downloadFilesFromRelease(release *github.RepositoryRe
 
 // handleGithubErr wraps error messages
 func (g *gitHubRepository) handleGithubErr(err error, message string, args ...interface{}) error {
-	if _, ok := err.(*github.RateLimitError); ok {
-		return errors.New("rate limit for github api has been reached. Please wait one hour or get a personal API tokens a assign it to the GITHUB_TOKEN environment variable")
+	switch e := err.(type) {
+	case *github.RateLimitError:
+		return messages.New(messages.GitHubRateLimit, e.Rate.Reset.Time.Format(time.RFC3339))
+	case *github.AbuseRateLimitError:
+		retryAfter := "a few minutes"
+		if e.RetryAfter != nil {
+			retryAfter = e.RetryAfter.String()
+		}
+		return messages.New(messages.GitHubSecondaryRateLimit, retryAfter)
 	}
 	return errors.Wrapf(err, message, args...)
 }
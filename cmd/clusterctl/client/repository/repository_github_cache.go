@@ -0,0 +1,151 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+const (
+	githubCacheFolder    = "cache/github"
+	githubCacheFolderKey = "githubCacheFolder"
+)
+
+// githubCachePath returns the folder where the responses of the GitHub API are cached, which defaults to
+// $HOME/.cluster-api/cache/github and can be changed using the githubCacheFolder variable.
+func githubCachePath(configVariablesClient config.VariablesClient) string {
+	if f, err := configVariablesClient.Get(githubCacheFolderKey); err == nil && len(strings.TrimSpace(f)) != 0 {
+		return f
+	}
+	return filepath.Join(homedir.HomeDir(), config.ConfigFolder, filepath.FromSlash(githubCacheFolder))
+}
+
+// etagCachingTransport is an http.RoundTripper caching on disk the responses to GET requests that have an ETag,
+// and revalidating them with conditional requests using the If-None-Match header; GitHub does not count the requests
+// answered with 304 Not Modified against the rate limit.
+// Nb. The cache is best effort: errors reading or writing the cache are ignored.
+type etagCachingTransport struct {
+	transport http.RoundTripper
+	folder    string
+}
+
+// etagCacheEntry is a response stored in the cache.
+type etagCacheEntry struct {
+	ETag   string      `json:"etag"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+var _ http.RoundTripper = &etagCachingTransport{}
+
+func (t *etagCachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.transport.RoundTrip(req)
+	}
+
+	key := t.cacheKey(req)
+	cached := t.read(key)
+	if cached != nil {
+		// Nb. RoundTrippers should not modify the request.
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// If the cached response is still valid, return it; the headers reporting the rate limit status are taken
+	// from the actual response.
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		header := cached.Header.Clone()
+		for k, v := range resp.Header {
+			if strings.HasPrefix(k, "X-Ratelimit-") {
+				header[k] = v
+			}
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(cached.Body)),
+			ContentLength: int64(len(cached.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	t.write(key, &etagCacheEntry{ETag: etag, Header: resp.Header, Body: body})
+	return resp, nil
+}
+
+// cacheKey returns the name of the cache file for a request; the Accept header is part of the key because
+// GitHub returns different representations of the same URL, e.g. the metadata or the content of a release asset.
+func (t *etagCachingTransport) cacheKey(req *http.Request) string {
+	h := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept")))
+	return hex.EncodeToString(h[:])
+}
+
+func (t *etagCachingTransport) read(key string) *etagCacheEntry {
+	content, err := ioutil.ReadFile(filepath.Join(t.folder, key))
+	if err != nil {
+		return nil
+	}
+	entry := &etagCacheEntry{}
+	if err := json.Unmarshal(content, entry); err != nil || entry.ETag == "" {
+		return nil
+	}
+	return entry
+}
+
+func (t *etagCachingTransport) write(key string, entry *etagCacheEntry) {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(t.folder, 0700); err != nil {
+		return
+	}
+	_ = ioutil.WriteFile(filepath.Join(t.folder, key), content, 0600)
+}
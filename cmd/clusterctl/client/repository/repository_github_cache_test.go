@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_etagCachingTransport_RoundTrip(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir, err := ioutil.TempDir("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	// setup a server returning 304 Not Modified when the request has the current ETag
+	requests := 0
+	content := "v1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		etag := fmt.Sprintf("%q", content)
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", 60-requests))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, content)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &etagCachingTransport{
+			transport: http.DefaultTransport,
+			folder:    tmpDir,
+		},
+	}
+
+	get := func() (*http.Response, string) {
+		resp, err := client.Get(server.URL)
+		g.Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		g.Expect(err).NotTo(HaveOccurred())
+		return resp, string(body)
+	}
+
+	// the first response is stored in the cache
+	resp, body := get()
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	g.Expect(body).To(Equal("v1"))
+	files, err := ioutil.ReadDir(tmpDir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(HaveLen(1))
+
+	// the second response is served from the cache, with the current rate limit status
+	resp, body = get()
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	g.Expect(body).To(Equal("v1"))
+	g.Expect(resp.Header.Get("X-RateLimit-Remaining")).To(Equal("58"))
+
+	// when the content changes, the new response replaces the cached one
+	content = "v2"
+	resp, body = get()
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	g.Expect(body).To(Equal("v2"))
+	g.Expect(requests).To(Equal(3))
+}
+
+func Test_etagCachingTransport_ignoresInvalidCache(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir, err := ioutil.TempDir("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Header.Get("If-None-Match")).To(BeEmpty())
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "v1")
+	}))
+	defer server.Close()
+
+	transport := &etagCachingTransport{
+		transport: http.DefaultTransport,
+		folder:    tmpDir,
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ioutil.WriteFile(filepath.Join(tmpDir, transport.cacheKey(req)), []byte("not json"), 0600)).To(Succeed())
+
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(body)).To(Equal("v1"))
+}
+
+func Test_githubCachePath(t *testing.T) {
+	g := NewWithT(t)
+
+	configVariablesClient := test.NewFakeVariableClient()
+	g.Expect(githubCachePath(configVariablesClient)).To(HaveSuffix(filepath.Join(".cluster-api", "cache", "github")))
+
+	configVariablesClient.WithVar(githubCacheFolderKey, "/tmp/github")
+	g.Expect(githubCachePath(configVariablesClient)).To(Equal("/tmp/github"))
+}
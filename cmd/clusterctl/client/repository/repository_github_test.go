@@ -17,16 +17,20 @@ limitations under the License.
 package repository
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 
 	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
 	"k8s.io/utils/pointer"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
 )
 
@@ -242,6 +246,25 @@ func Test_gitHubRepository_getVersions(t *testing.T) {
 		fmt.Fprint(w, `]`)
 	})
 
+	// setup an handler for returning fake releases in two pages, not sorted by version
+	mux.HandleFunc("/repos/o/paged/releases", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		g := NewWithT(t)
+		g.Expect(r.URL.Query().Get("per_page")).To(Equal("100"))
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[`)
+			fmt.Fprint(w, `{"id":3, "tag_name": "v0.4.0"},`)
+			fmt.Fprint(w, `{"id":4, "tag_name": "v0.4.3", "draft": true}`) // draft
+			fmt.Fprint(w, `]`)
+			return
+		}
+		w.Header().Set("Link", `<https://api.github.com/repos/o/paged/releases?per_page=100&page=2>; rel="next", <https://api.github.com/repos/o/paged/releases?per_page=100&page=2>; rel="last"`)
+		fmt.Fprint(w, `[`)
+		fmt.Fprint(w, `{"id":1, "tag_name": "v0.10.0"},`)
+		fmt.Fprint(w, `{"id":2, "tag_name": "v0.9.1"}`)
+		fmt.Fprint(w, `]`)
+	})
+
 	configVariablesClient := test.NewFakeVariableClient()
 
 	type field struct {
@@ -261,6 +284,14 @@ func Test_gitHubRepository_getVersions(t *testing.T) {
 			want:    []string{"v0.4.0", "v0.4.1", "v0.4.2", "v0.4.3-alpha"},
 			wantErr: false,
 		},
+		{
+			name: "Get versions from all the pages, sorted by semantic version",
+			field: field{
+				providerConfig: config.NewProvider("test", "https://github.com/o/paged/releases/v0.4.0/path", clusterctlv1.CoreProviderType),
+			},
+			want:    []string{"v0.4.0", "v0.9.1", "v0.10.0"},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -278,7 +309,7 @@ func Test_gitHubRepository_getVersions(t *testing.T) {
 			}
 			g.Expect(err).NotTo(HaveOccurred())
 
-			g.Expect(got).To(ConsistOf(tt.want))
+			g.Expect(got).To(Equal(tt.want))
 		})
 	}
 }
@@ -405,6 +436,7 @@ func Test_gitHubRepository_getReleaseByTag(t *testing.T) {
 			got, err := gRepo.getReleaseByTag(tt.args.tag)
 			if tt.wantErr {
 				g.Expect(err).To(HaveOccurred())
+				g.Expect(errors.Is(err, messages.ErrVersionNotFound)).To(BeTrue())
 				return
 			}
 			g.Expect(err).NotTo(HaveOccurred())
@@ -521,6 +553,107 @@ func Test_gitHubRepository_downloadFilesFromRelease(t *testing.T) {
 	}
 }
 
+func Test_gitHubRepository_callGitHub(t *testing.T) {
+	client, mux, teardown := test.NewFakeGitHub()
+	defer teardown()
+
+	// setup an handler rejecting the first request, like GitHub does for an expired token
+	requests := 0
+	mux.HandleFunc("/repos/o/r/releases/tags/v0.4.1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message": "Bad credentials"}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":13, "tag_name": "v0.4.1"}`)
+	})
+
+	tests := []struct {
+		name                     string
+		authenticatingHTTPClient *http.Client
+		wantErr                  bool
+	}{
+		{
+			name:                     "Retries without authentication if the token is not valid",
+			authenticatingHTTPClient: &http.Client{},
+			wantErr:                  false,
+		},
+		{
+			name:                     "Fails if there is no token",
+			authenticatingHTTPClient: nil,
+			wantErr:                  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			requests = 0
+			gRepo := &gitHubRepository{
+				configVariablesClient:    test.NewFakeVariableClient(),
+				authenticatingHTTPClient: tt.authenticatingHTTPClient,
+				owner:                    "o",
+				repository:               "r",
+				injectClient:             client,
+			}
+
+			var release *github.RepositoryRelease
+			err := gRepo.callGitHub(func(client *github.Client) (err error) {
+				release, _, err = client.Repositories.GetReleaseByTag(context.TODO(), "o", "r", "v0.4.1")
+				return err
+			})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(requests).To(Equal(1))
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(release.TagName).To(Equal(pointer.StringPtr("v0.4.1")))
+			g.Expect(requests).To(Equal(2))
+			g.Expect(gRepo.authenticatingHTTPClient).To(BeNil())
+		})
+	}
+}
+
+func Test_gitHubRepository_handleGithubErr(t *testing.T) {
+	reset := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	retryAfter := 30 * time.Second
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "Rate limit errors report the reset time",
+			err:  &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: reset}}},
+			want: "it will be reset at 2020-05-04T10:00:00Z",
+		},
+		{
+			name: "Abuse rate limit errors report when to retry",
+			err:  &github.AbuseRateLimitError{RetryAfter: &retryAfter},
+			want: "Please retry after 30s",
+		},
+		{
+			name: "Other errors are wrapped",
+			err:  errors.New("boom"),
+			want: "failed to read release \"foo\": boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gRepo := &gitHubRepository{}
+			err := gRepo.handleGithubErr(tt.err, "failed to read release %q", "foo")
+			g.Expect(err.Error()).To(ContainSubstring(tt.want))
+		})
+	}
+}
+
 func testMethod(t *testing.T, r *http.Request, want string) {
 	if got := r.Method; got != want {
 		t.Errorf("Request method: %v, want %v", got, want)
@@ -27,6 +27,7 @@ import (
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/util/version"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
 )
 
 // localRepository provides support for providers located on the local filesystem.
@@ -93,7 +94,12 @@ func (r *localRepository) GetFile(version, fileName string) ([]byte, error) {
 		version = r.defaultVersion
 	}
 
-	absolutePath := filepath.Join(r.basepath, r.providerLabel, version, r.RootPath(), fileName)
+	versionPath := filepath.Join(r.basepath, r.providerLabel, version)
+	if _, err := os.Stat(versionPath); os.IsNotExist(err) {
+		return nil, messages.Wrap(err, messages.VersionNotFound, version, filepath.Join(r.basepath, r.providerLabel))
+	}
+
+	absolutePath := filepath.Join(versionPath, r.RootPath(), fileName)
 
 	f, err := os.Stat(absolutePath)
 	if err != nil {
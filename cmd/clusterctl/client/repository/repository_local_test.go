@@ -24,8 +24,10 @@ import (
 
 	. "github.com/onsi/gomega"
 
+	"github.com/pkg/errors"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
 )
 
@@ -200,11 +202,12 @@ func Test_localRepository_GetFile(t *testing.T) {
 		contents string
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    want
-		wantErr bool
+		name      string
+		fields    fields
+		args      args
+		want      want
+		wantErr   bool
+		wantErrIs error
 	}{
 		{
 			name: "Get file from release directory",
@@ -251,6 +254,19 @@ func Test_localRepository_GetFile(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Fails if the release directory does not exist",
+			fields: fields{
+				provider:              p2,
+				configVariablesClient: test.NewFakeVariableClient(),
+			},
+			args: args{
+				version:  "v2.0.0",
+				fileName: "bootstrap-components.yaml",
+			},
+			wantErr:   true,
+			wantErrIs: messages.ErrVersionNotFound,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -262,6 +278,9 @@ func Test_localRepository_GetFile(t *testing.T) {
 			got, err := r.GetFile(tt.args.version, tt.args.fileName)
 			if tt.wantErr {
 				g.Expect(err).To(HaveOccurred())
+				if tt.wantErrIs != nil {
+					g.Expect(errors.Is(err, tt.wantErrIs)).To(BeTrue())
+				}
 				return
 			}
 
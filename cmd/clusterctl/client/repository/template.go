@@ -34,6 +34,11 @@ type Template interface {
 	// This value is derived by the template YAML.
 	Variables() []string
 
+	// VariableMap returns the variables required by the template, with their default value
+	// or nil if the variable has no default value.
+	// This value is derived by the template YAML.
+	VariableMap() map[string]*string
+
 	// TargetNamespace where the template objects will be installed.
 	TargetNamespace() string
 
@@ -47,6 +52,7 @@ type Template interface {
 // template implements Template.
 type template struct {
 	variables       []string
+	variableMap     map[string]*string
 	targetNamespace string
 	objs            []unstructured.Unstructured
 }
@@ -58,6 +64,10 @@ func (t *template) Variables() []string {
 	return t.variables
 }
 
+func (t *template) VariableMap() map[string]*string {
+	return t.variableMap
+}
+
 func (t *template) TargetNamespace() string {
 	return t.targetNamespace
 }
@@ -85,13 +95,24 @@ func NewTemplate(input TemplateInput) (*template, error) {
 		return nil, err
 	}
 
+	variableMap, err := input.Processor.GetVariableMap(input.RawArtifact)
+	if err != nil {
+		return nil, err
+	}
+
 	if input.ListVariablesOnly {
 		return &template{
 			variables:       variables,
+			variableMap:     variableMap,
 			targetNamespace: input.TargetNamespace,
 		}, nil
 	}
 
+	// Rejects invalid variable values before processing, so broken templates are not generated.
+	if err := input.ConfigVariablesClient.Validate(variables); err != nil {
+		return nil, err
+	}
+
 	processedYaml, err := input.Processor.Process(input.RawArtifact, input.ConfigVariablesClient.Get)
 	if err != nil {
 		return nil, err
@@ -110,6 +131,7 @@ func NewTemplate(input TemplateInput) (*template, error) {
 
 	return &template{
 		variables:       variables,
+		variableMap:     variableMap,
 		targetNamespace: input.TargetNamespace,
 		objs:            objs,
 	}, nil
@@ -0,0 +1,190 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// TemplatePatchType defines the format of a TemplatePatch.
+type TemplatePatchType string
+
+const (
+	// StrategicMergeTemplatePatch is a partial object merged into the template objects. Strategic merge semantics
+	// are used for Kubernetes built-in types, while JSON merge patch semantics (RFC 7386) are used for all the other
+	// types (e.g. Cluster API or provider custom resources), as kubectl does.
+	StrategicMergeTemplatePatch TemplatePatchType = "StrategicMerge"
+
+	// JSON6902TemplatePatch is a list of JSON patch operations (RFC 6902) applied to the template objects.
+	JSON6902TemplatePatch TemplatePatchType = "JSON6902"
+)
+
+// TemplatePatch defines a patch to be applied to the objects of a workload cluster template after the template is rendered.
+type TemplatePatch struct {
+	// Type of the patch. If unspecified, StrategicMergeTemplatePatch will be used.
+	Type TemplatePatchType
+
+	// Patch defines the patch document, in YAML or JSON.
+	Patch []byte
+
+	// Group, Version, Kind and Name select the template objects the patch applies to; an empty value matches any object.
+	// For strategic merge patches, if all the selectors are empty, they are inferred from the apiVersion, kind and
+	// metadata.name of the patch document.
+	Group   string
+	Version string
+	Kind    string
+	Name    string
+}
+
+// matches returns true if the object is selected by the patch.
+func (p *TemplatePatch) matches(obj unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	if p.Group != "" && p.Group != gvk.Group {
+		return false
+	}
+	if p.Version != "" && p.Version != gvk.Version {
+		return false
+	}
+	if p.Kind != "" && p.Kind != gvk.Kind {
+		return false
+	}
+	if p.Name != "" && p.Name != obj.GetName() {
+		return false
+	}
+	return true
+}
+
+// PatchTemplate returns a copy of the template with the patches applied, in order, to the template objects.
+// Each patch is required to match at least one object, so typos in the selectors are not silently ignored.
+func PatchTemplate(t Template, patches []TemplatePatch) (Template, error) {
+	objs := make([]unstructured.Unstructured, len(t.Objs()))
+	for i := range t.Objs() {
+		objs[i] = *t.Objs()[i].DeepCopy()
+	}
+
+	for i := range patches {
+		if err := applyTemplatePatch(objs, patches[i]); err != nil {
+			return nil, errors.Wrapf(err, "failed to apply patch #%d to the workload cluster template", i+1)
+		}
+	}
+
+	// Ensures patches did not move objects out of the target namespace.
+	objs = fixTargetNamespace(objs, t.TargetNamespace())
+
+	return &template{
+		variables:       t.Variables(),
+		targetNamespace: t.TargetNamespace(),
+		objs:            objs,
+	}, nil
+}
+
+// applyTemplatePatch applies a patch to all the matching objects.
+func applyTemplatePatch(objs []unstructured.Unstructured, patch TemplatePatch) error {
+	patchJSON, err := yaml.YAMLToJSON(patch.Patch)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the patch")
+	}
+
+	var apply func(obj unstructured.Unstructured, objJSON []byte) ([]byte, error)
+	switch patch.Type {
+	case JSON6902TemplatePatch:
+		ops, err := jsonpatch.DecodePatch(patchJSON)
+		if err != nil {
+			return errors.Wrap(err, "failed to decode the JSON 6902 patch")
+		}
+		apply = func(_ unstructured.Unstructured, objJSON []byte) ([]byte, error) {
+			return ops.Apply(objJSON)
+		}
+	case StrategicMergeTemplatePatch, "":
+		if patch.Group == "" && patch.Version == "" && patch.Kind == "" && patch.Name == "" {
+			if err := setSelectorsFromPatch(&patch, patchJSON); err != nil {
+				return err
+			}
+		}
+		apply = func(obj unstructured.Unstructured, objJSON []byte) ([]byte, error) {
+			return strategicMergePatch(obj.GroupVersionKind(), objJSON, patchJSON)
+		}
+	default:
+		return errors.Errorf("invalid patch type %q. Valid values are %q and %q", patch.Type, StrategicMergeTemplatePatch, JSON6902TemplatePatch)
+	}
+
+	matched := false
+	for i := range objs {
+		if !patch.matches(objs[i]) {
+			continue
+		}
+		matched = true
+
+		objJSON, err := objs[i].MarshalJSON()
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal %s %q", objs[i].GetKind(), objs[i].GetName())
+		}
+		patchedJSON, err := apply(objs[i], objJSON)
+		if err != nil {
+			return errors.Wrapf(err, "failed to patch %s %q", objs[i].GetKind(), objs[i].GetName())
+		}
+		patched := unstructured.Unstructured{}
+		if err := patched.UnmarshalJSON(patchedJSON); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal the patched %s %q", objs[i].GetKind(), objs[i].GetName())
+		}
+		objs[i] = patched
+	}
+
+	if !matched {
+		return errors.Errorf("no objects in the template match group %q, version %q, kind %q and name %q", patch.Group, patch.Version, patch.Kind, patch.Name)
+	}
+	return nil
+}
+
+// setSelectorsFromPatch sets the patch selectors from the apiVersion, kind and metadata.name of the patch document.
+func setSelectorsFromPatch(patch *TemplatePatch, patchJSON []byte) error {
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal(patchJSON, &obj.Object); err != nil {
+		return errors.Wrap(err, "failed to decode the strategic merge patch")
+	}
+	if obj.GetAPIVersion() == "" || obj.GetKind() == "" || obj.GetName() == "" {
+		return errors.New("a strategic merge patch without selectors must define apiVersion, kind and metadata.name")
+	}
+
+	gv, err := schema.ParseGroupVersion(obj.GetAPIVersion())
+	if err != nil {
+		return errors.Wrapf(err, "invalid apiVersion %q in the strategic merge patch", obj.GetAPIVersion())
+	}
+	patch.Group = gv.Group
+	patch.Version = gv.Version
+	patch.Kind = obj.GetKind()
+	patch.Name = obj.GetName()
+	return nil
+}
+
+// strategicMergePatch applies a strategic merge patch for Kubernetes built-in types, and a JSON merge patch
+// for all the other types, because the patch strategies are known only for the types registered in the client-go scheme.
+func strategicMergePatch(gvk schema.GroupVersionKind, objJSON, patchJSON []byte) ([]byte, error) {
+	dataStruct, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return jsonpatch.MergePatch(objJSON, patchJSON)
+	}
+	return strategicpatch.StrategicMergePatch(objJSON, patchJSON, dataStruct)
+}
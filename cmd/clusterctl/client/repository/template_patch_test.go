@@ -0,0 +1,227 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+)
+
+var templateToPatchYaml = []byte(`apiVersion: infrastructure.cluster.x-k8s.io/v1alpha3
+kind: DockerMachineTemplate
+metadata:
+  name: md-0
+  namespace: ns1
+spec:
+  template:
+    spec:
+      extraMounts:
+      - containerPath: /var/run/docker.sock
+        hostPath: /var/run/docker.sock
+---
+apiVersion: infrastructure.cluster.x-k8s.io/v1alpha3
+kind: DockerMachineTemplate
+metadata:
+  name: control-plane
+  namespace: ns1
+spec:
+  template:
+    spec: {}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: ns1
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: manager:v1
+      - name: proxy
+        image: proxy:v1
+`)
+
+func TestPatchTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		patches []TemplatePatch
+		want    func(g *WithT, objs []unstructured.Unstructured)
+		wantErr bool
+	}{
+		{
+			name: "strategic merge patch selected by the patch content uses merge patch semantics for custom resources",
+			patches: []TemplatePatch{
+				{
+					Patch: []byte(`apiVersion: infrastructure.cluster.x-k8s.io/v1alpha3
+kind: DockerMachineTemplate
+metadata:
+  name: md-0
+  labels:
+    team: blue
+spec:
+  template:
+    spec:
+      extraMounts: []
+`),
+				},
+			},
+			want: func(g *WithT, objs []unstructured.Unstructured) {
+				g.Expect(objs[0].GetLabels()).To(Equal(map[string]string{"team": "blue"}))
+				mounts, _, _ := unstructured.NestedSlice(objs[0].Object, "spec", "template", "spec", "extraMounts")
+				g.Expect(mounts).To(BeEmpty())
+				g.Expect(objs[1].GetLabels()).To(BeEmpty())
+			},
+		},
+		{
+			name: "strategic merge patch uses strategic merge semantics for built-in types",
+			patches: []TemplatePatch{
+				{
+					Type: StrategicMergeTemplatePatch,
+					Patch: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: manager:v2
+`),
+				},
+			},
+			want: func(g *WithT, objs []unstructured.Unstructured) {
+				containers, _, _ := unstructured.NestedSlice(objs[2].Object, "spec", "template", "spec", "containers")
+				g.Expect(containers).To(HaveLen(2))
+				g.Expect(containers[0]).To(HaveKeyWithValue("image", "manager:v2"))
+				g.Expect(containers[1]).To(HaveKeyWithValue("image", "proxy:v1"))
+			},
+		},
+		{
+			name: "JSON 6902 patch applies to all the objects matching the selectors",
+			patches: []TemplatePatch{
+				{
+					Type:  JSON6902TemplatePatch,
+					Kind:  "DockerMachineTemplate",
+					Patch: []byte(`[{"op": "add", "path": "/metadata/annotations", "value": {"owner": "me"}}]`),
+				},
+			},
+			want: func(g *WithT, objs []unstructured.Unstructured) {
+				g.Expect(objs[0].GetAnnotations()).To(Equal(map[string]string{"owner": "me"}))
+				g.Expect(objs[1].GetAnnotations()).To(Equal(map[string]string{"owner": "me"}))
+				g.Expect(objs[2].GetAnnotations()).To(BeEmpty())
+			},
+		},
+		{
+			name: "patches are applied in order",
+			patches: []TemplatePatch{
+				{
+					Type:  JSON6902TemplatePatch,
+					Name:  "control-plane",
+					Patch: []byte("- op: add\n  path: /metadata/labels\n  value:\n    a: b\n"),
+				},
+				{
+					Type:  JSON6902TemplatePatch,
+					Name:  "control-plane",
+					Patch: []byte("- op: replace\n  path: /metadata/labels/a\n  value: c\n"),
+				},
+			},
+			want: func(g *WithT, objs []unstructured.Unstructured) {
+				g.Expect(objs[1].GetLabels()).To(Equal(map[string]string{"a": "c"}))
+			},
+		},
+		{
+			name: "fails if the patch does not match any object",
+			patches: []TemplatePatch{
+				{
+					Type:  JSON6902TemplatePatch,
+					Kind:  "AWSMachineTemplate",
+					Patch: []byte(`[]`),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if a strategic merge patch has no selectors and does not identify an object",
+			patches: []TemplatePatch{
+				{
+					Patch: []byte("metadata:\n  labels:\n    a: b\n"),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if a JSON 6902 patch operation fails",
+			patches: []TemplatePatch{
+				{
+					Type:  JSON6902TemplatePatch,
+					Name:  "md-0",
+					Patch: []byte(`[{"op": "test", "path": "/metadata/name", "value": "md-1"}]`),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails for invalid patch types",
+			patches: []TemplatePatch{
+				{
+					Type:  "Kustomize",
+					Patch: []byte(`{}`),
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			objs, err := utilyaml.ToUnstructured(templateToPatchYaml)
+			g.Expect(err).NotTo(HaveOccurred())
+			original := &template{
+				variables:       []string{"FOO"},
+				targetNamespace: "ns1",
+				objs:            objs,
+			}
+
+			got, err := PatchTemplate(original, tt.patches)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			g.Expect(got.Variables()).To(Equal(original.Variables()))
+			g.Expect(got.TargetNamespace()).To(Equal(original.TargetNamespace()))
+			g.Expect(got.Objs()).To(HaveLen(len(objs)))
+			for _, o := range got.Objs() {
+				g.Expect(o.GetNamespace()).To(Equal("ns1"))
+			}
+			tt.want(g, got.Objs())
+
+			// The original template is not modified.
+			g.Expect(original.Objs()[0].GetLabels()).To(BeEmpty())
+			g.Expect(original.Objs()[1].GetLabels()).To(BeEmpty())
+		})
+	}
+}
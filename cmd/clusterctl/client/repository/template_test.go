@@ -84,6 +84,17 @@ func Test_newTemplate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "fails if a variable value does not satisfy the validation rules",
+			args: args{
+				rawYaml:               templateMapYaml,
+				configVariablesClient: validatingVariablesClient(variableName, "invalid"),
+				processor:             yaml.NewSimpleProcessor(),
+				targetNamespace:       "ns1",
+				listVariablesOnly:     false,
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -116,3 +127,12 @@ func Test_newTemplate(t *testing.T) {
 		})
 	}
 }
+
+// validatingVariablesClient returns a VariablesClient requiring the given variable to be a valid CIDR.
+func validatingVariablesClient(name, value string) config.VariablesClient {
+	reader := test.NewFakeReader().
+		WithVar(name, value).
+		WithVar(config.VariableValidationsConfigKey, fmt.Sprintf("- name: %s\n  format: %s", name, config.CIDRVariableFormat))
+	configClient, _ := config.New("", config.InjectReader(reader))
+	return configClient.Variables()
+}
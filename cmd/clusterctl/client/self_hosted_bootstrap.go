@@ -0,0 +1,228 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/bootstrap"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+)
+
+// DefaultBootstrapClusterName is the name of the bootstrap cluster if not specified otherwise.
+const DefaultBootstrapClusterName = "clusterctl-bootstrap"
+
+// CreateBootstrapClusterOptions carries the options supported by CreateBootstrapCluster.
+type CreateBootstrapClusterOptions struct {
+	// Provider creates the bootstrap cluster. If unspecified, a local kind cluster is created using the kind CLI.
+	Provider BootstrapClusterProvider
+
+	// Name of the bootstrap cluster. If unspecified, DefaultBootstrapClusterName is used.
+	Name string
+
+	// KubeconfigPath is the path the kubeconfig of the bootstrap cluster is written to. If unspecified,
+	// the kubeconfig is written to a new temporary directory.
+	KubeconfigPath string
+}
+
+// DeleteBootstrapClusterOptions carries the options supported by DeleteBootstrapCluster.
+type DeleteBootstrapClusterOptions struct {
+	// Provider deletes the bootstrap cluster. If unspecified, the local kind cluster is deleted using the kind CLI.
+	Provider BootstrapClusterProvider
+
+	// Name of the bootstrap cluster. If unspecified, DefaultBootstrapClusterName is used.
+	Name string
+}
+
+// SelfHostedBootstrapOptions carries the options supported by SelfHostedBootstrap.
+type SelfHostedBootstrapOptions struct {
+	// BootstrapCluster defines the temporary bootstrap cluster.
+	BootstrapCluster CreateBootstrapClusterOptions
+
+	// InitOptions defines the providers to be installed both in the bootstrap cluster and in the self-hosted
+	// management cluster; Kubeconfig is ignored, and the providers are always waited for.
+	InitOptions InitOptions
+
+	// ClusterTemplate defines the workload cluster template for the self-hosted management cluster, which is
+	// applied to the bootstrap cluster; Kubeconfig is ignored, and ClusterName is required.
+	ClusterTemplate GetClusterTemplateOptions
+
+	// KubeconfigPath is the path the kubeconfig of the self-hosted management cluster is written to. If unspecified,
+	// the kubeconfig is written to "<ClusterName>.kubeconfig" in the current directory.
+	KubeconfigPath string
+
+	// BeforeInit is called, if defined, once the control plane of the self-hosted management cluster is initialized
+	// and before installing the providers, e.g. for installing the CNI plugin required by the provider Pods.
+	BeforeInit func(kubeconfig Kubeconfig) error
+
+	// WaitTimeout is the time to wait for the self-hosted management cluster to be provisioned and for its control
+	// plane to be initialized. If unspecified, cluster.DefaultClusterProvisioningTimeout is used.
+	WaitTimeout time.Duration
+
+	// KeepBootstrapCluster instructs SelfHostedBootstrap not to delete the bootstrap cluster once the self-hosted
+	// management cluster is ready, e.g. for troubleshooting.
+	KeepBootstrapCluster bool
+}
+
+func (c *clusterctlClient) CreateBootstrapCluster(options CreateBootstrapClusterOptions) (Kubeconfig, error) {
+	name := bootstrapClusterName(options.Name)
+
+	kubeconfigPath := options.KubeconfigPath
+	if kubeconfigPath == "" {
+		dir, err := ioutil.TempDir("", name)
+		if err != nil {
+			return Kubeconfig{}, errors.Wrap(err, "failed to create a temporary directory for the kubeconfig of the bootstrap cluster")
+		}
+		kubeconfigPath = filepath.Join(dir, "kubeconfig")
+	}
+
+	if err := bootstrapClusterProvider(options.Provider).Create(name, kubeconfigPath); err != nil {
+		return Kubeconfig{}, errors.Wrapf(err, "failed to create the bootstrap cluster %q", name)
+	}
+	return Kubeconfig{Path: kubeconfigPath}, nil
+}
+
+func (c *clusterctlClient) DeleteBootstrapCluster(options DeleteBootstrapClusterOptions) error {
+	name := bootstrapClusterName(options.Name)
+
+	if err := bootstrapClusterProvider(options.Provider).Delete(name); err != nil {
+		return errors.Wrapf(err, "failed to delete the bootstrap cluster %q", name)
+	}
+	return nil
+}
+
+func (c *clusterctlClient) SelfHostedBootstrap(options SelfHostedBootstrapOptions) (Kubeconfig, error) {
+	log := logf.Log
+
+	clusterName := options.ClusterTemplate.ClusterName
+	if clusterName == "" {
+		return Kubeconfig{}, errors.New("the name of the self-hosted management cluster is required")
+	}
+	kubeconfigPath := options.KubeconfigPath
+	if kubeconfigPath == "" {
+		kubeconfigPath = fmt.Sprintf("%s.kubeconfig", clusterName)
+	}
+
+	// Creates the bootstrap cluster and installs the providers.
+	bootstrapKubeconfig, err := c.CreateBootstrapCluster(options.BootstrapCluster)
+	if err != nil {
+		return Kubeconfig{}, err
+	}
+	deleteOptions := DeleteBootstrapClusterOptions{
+		Provider: options.BootstrapCluster.Provider,
+		Name:     options.BootstrapCluster.Name,
+	}
+
+	// The bootstrap cluster is deleted when done, unless requested otherwise, or on failures happening before applying
+	// the workload cluster template; after, it is kept because it could still manage the infrastructure of the
+	// self-hosted management cluster.
+	keepBootstrapCluster := false
+	defer func() {
+		if keepBootstrapCluster {
+			log.Info("The bootstrap cluster was kept", "Name", bootstrapClusterName(deleteOptions.Name), "Kubeconfig", bootstrapKubeconfig.Path)
+			return
+		}
+		if err := c.DeleteBootstrapCluster(deleteOptions); err != nil {
+			log.Info("Warning: failed to delete the bootstrap cluster", "Name", bootstrapClusterName(deleteOptions.Name), "Error", err.Error())
+		}
+	}()
+
+	initOptions := options.InitOptions
+	initOptions.Kubeconfig = bootstrapKubeconfig
+	initOptions.WaitProviders = true
+	if _, err := c.Init(initOptions); err != nil {
+		return Kubeconfig{}, errors.Wrap(err, "failed to initialize the bootstrap cluster")
+	}
+
+	// Creates the self-hosted management cluster from the bootstrap cluster.
+	templateOptions := options.ClusterTemplate
+	templateOptions.Kubeconfig = bootstrapKubeconfig
+	keepBootstrapCluster = true
+	if _, err := c.ApplyClusterTemplate(ApplyClusterTemplateOptions{
+		GetClusterTemplateOptions: templateOptions,
+		WaitForProvisioned:        true,
+		WaitTimeout:               options.WaitTimeout,
+	}); err != nil {
+		return Kubeconfig{}, errors.Wrap(err, "failed to create the self-hosted management cluster")
+	}
+
+	bootstrapCluster, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: bootstrapKubeconfig})
+	if err != nil {
+		return Kubeconfig{}, err
+	}
+	namespace := options.ClusterTemplate.TargetNamespace
+	if namespace == "" {
+		if namespace, err = bootstrapCluster.Proxy().CurrentNamespace(); err != nil {
+			return Kubeconfig{}, err
+		}
+	}
+	if err := bootstrapCluster.ClusterProvisioning().WaitForControlPlaneInitialized(namespace, clusterName, options.WaitTimeout); err != nil {
+		return Kubeconfig{}, err
+	}
+	data, err := bootstrapCluster.ClusterProvisioning().GetKubeconfig(namespace, clusterName)
+	if err != nil {
+		return Kubeconfig{}, err
+	}
+	if err := ioutil.WriteFile(kubeconfigPath, data, 0600); err != nil {
+		return Kubeconfig{}, errors.Wrapf(err, "failed to write the kubeconfig of the self-hosted management cluster to %q", kubeconfigPath)
+	}
+	kubeconfig := Kubeconfig{Path: kubeconfigPath}
+
+	// Installs the providers in the self-hosted management cluster, and moves the Cluster API objects to it.
+	if options.BeforeInit != nil {
+		if err := options.BeforeInit(kubeconfig); err != nil {
+			return Kubeconfig{}, err
+		}
+	}
+
+	initOptions.Kubeconfig = kubeconfig
+	if _, err := c.Init(initOptions); err != nil {
+		return Kubeconfig{}, errors.Wrap(err, "failed to initialize the self-hosted management cluster")
+	}
+
+	if err := c.Move(MoveOptions{
+		FromKubeconfig: bootstrapKubeconfig,
+		ToKubeconfig:   kubeconfig,
+		Namespace:      namespace,
+		ClusterName:    clusterName,
+	}); err != nil {
+		return Kubeconfig{}, errors.Wrap(err, "failed to move the Cluster API objects to the self-hosted management cluster")
+	}
+
+	keepBootstrapCluster = options.KeepBootstrapCluster
+	return kubeconfig, nil
+}
+
+// bootstrapClusterName returns the name of the bootstrap cluster, defaulting to DefaultBootstrapClusterName.
+func bootstrapClusterName(name string) string {
+	if name == "" {
+		return DefaultBootstrapClusterName
+	}
+	return name
+}
+
+// bootstrapClusterProvider returns the provider of the bootstrap cluster, defaulting to kind.
+func bootstrapClusterProvider(provider BootstrapClusterProvider) BootstrapClusterProvider {
+	if provider == nil {
+		return bootstrap.NewKindProvider()
+	}
+	return provider
+}
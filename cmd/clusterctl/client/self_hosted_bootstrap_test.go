@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// fakeBootstrapClusterProvider records the bootstrap clusters created and deleted.
+type fakeBootstrapClusterProvider struct {
+	created []string
+	deleted []string
+}
+
+func (f *fakeBootstrapClusterProvider) Create(name, kubeconfigPath string) error {
+	f.created = append(f.created, name+"="+kubeconfigPath)
+	return nil
+}
+
+func (f *fakeBootstrapClusterProvider) Delete(name string) error {
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+func Test_clusterctlClient_CreateDeleteBootstrapCluster(t *testing.T) {
+	g := NewWithT(t)
+
+	provider := &fakeBootstrapClusterProvider{}
+	client := newFakeClient(newFakeConfig())
+
+	kubeconfig, err := client.CreateBootstrapCluster(CreateBootstrapClusterOptions{
+		Provider:       provider,
+		KubeconfigPath: "bootstrap.kubeconfig",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(kubeconfig).To(Equal(Kubeconfig{Path: "bootstrap.kubeconfig"}))
+	g.Expect(provider.created).To(Equal([]string{DefaultBootstrapClusterName + "=bootstrap.kubeconfig"}))
+
+	g.Expect(client.DeleteBootstrapCluster(DeleteBootstrapClusterOptions{Provider: provider, Name: "bootstrap"})).To(Succeed())
+	g.Expect(provider.deleted).To(Equal([]string{"bootstrap"}))
+}
+
+func Test_clusterctlClient_SelfHostedBootstrap(t *testing.T) {
+	t.Run("fails without the name of the self-hosted management cluster", func(t *testing.T) {
+		g := NewWithT(t)
+
+		provider := &fakeBootstrapClusterProvider{}
+		client := newFakeClient(newFakeConfig())
+
+		_, err := client.SelfHostedBootstrap(SelfHostedBootstrapOptions{
+			BootstrapCluster: CreateBootstrapClusterOptions{Provider: provider},
+		})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(provider.created).To(BeEmpty())
+	})
+
+	t.Run("deletes the bootstrap cluster if it can't be initialized", func(t *testing.T) {
+		g := NewWithT(t)
+
+		provider := &fakeBootstrapClusterProvider{}
+		// Nb. the fake client has no cluster for the bootstrap kubeconfig, so initializing the bootstrap cluster fails.
+		client := newFakeClient(newFakeConfig())
+
+		_, err := client.SelfHostedBootstrap(SelfHostedBootstrapOptions{
+			BootstrapCluster: CreateBootstrapClusterOptions{
+				Provider:       provider,
+				Name:           "bootstrap",
+				KubeconfigPath: "bootstrap.kubeconfig",
+			},
+			ClusterTemplate: GetClusterTemplateOptions{ClusterName: "management"},
+		})
+		g.Expect(err).To(MatchError(ContainSubstring("failed to initialize the bootstrap cluster")))
+		g.Expect(provider.created).To(Equal([]string{"bootstrap=bootstrap.kubeconfig"}))
+		g.Expect(provider.deleted).To(Equal([]string{"bootstrap"}))
+	})
+}
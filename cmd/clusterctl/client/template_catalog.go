@@ -0,0 +1,305 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/yaml"
+)
+
+// ListClusterTemplatesOptions carries the options supported by ListClusterTemplates.
+type ListClusterTemplatesOptions struct {
+	// InfrastructureProviders to read the workload cluster templates from, using the name[:version] syntax.
+	// If empty, all the infrastructure providers in the clusterctl configuration are used, at their default version.
+	InfrastructureProviders []string
+
+	// Catalogs defines additional catalog files, read from a local path or a GitHub URL, listing workload
+	// cluster templates published out of the provider repositories.
+	Catalogs []string
+
+	// KubernetesVersion returns only the templates supporting the given Kubernetes version.
+	KubernetesVersion string
+
+	// Flavor returns only the templates with the given flavor.
+	Flavor string
+
+	// Tags returns only the templates having all the given tags.
+	Tags []string
+}
+
+// ClusterTemplateInfo describes a workload cluster template available in the template catalog.
+type ClusterTemplateInfo struct {
+	clusterctlv1.TemplateMetadata
+
+	// InfrastructureProvider the template is designed for.
+	InfrastructureProvider string
+
+	// Version of the infrastructure provider repository the template is read from; empty for the templates
+	// read from a catalog file.
+	Version string
+
+	// URL of the template, for the templates read from a catalog file; it can be used with URLSourceOptions.
+	URL string
+
+	// Catalog is the catalog file the template is read from; empty for the templates read from a provider repository.
+	Catalog string
+}
+
+// templateCatalog defines the content of a catalog file.
+type templateCatalog struct {
+	Templates []templateCatalogEntry `json:"templates"`
+}
+
+// templateCatalogEntry defines a workload cluster template listed in a catalog file.
+type templateCatalogEntry struct {
+	clusterctlv1.TemplateMetadata `json:",inline"`
+
+	// InfrastructureProvider is the name of the infrastructure provider the template is designed for.
+	InfrastructureProvider string `json:"infrastructureProvider"`
+
+	// URL of the template.
+	URL string `json:"url"`
+}
+
+// ListClusterTemplates returns the workload cluster templates published by the infrastructure providers and
+// by the additional catalogs, filtered according to the options.
+//
+// The templates of a provider are listed in the templates section of the metadata.yaml file of its repository;
+// if the section is missing, only the default cluster-template.yaml file is reported, if it exists.
+// Providers that can't be read are skipped, unless they are explicitly requested in the options.
+func (c *clusterctlClient) ListClusterTemplates(options ListClusterTemplatesOptions) ([]ClusterTemplateInfo, error) {
+	log := logf.Log
+
+	filter, err := newTemplateFilter(options)
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []ClusterTemplateInfo
+	if len(options.InfrastructureProviders) > 0 {
+		for _, p := range options.InfrastructureProviders {
+			name, version, err := parseProviderName(p)
+			if err != nil {
+				return nil, err
+			}
+			provider, err := c.configClient.Providers().Get(name, clusterctlv1.InfrastructureProviderType)
+			if err != nil {
+				return nil, err
+			}
+			providerTemplates, err := c.listProviderTemplates(provider, version)
+			if err != nil {
+				return nil, err
+			}
+			templates = append(templates, providerTemplates...)
+		}
+	} else {
+		providers, err := c.configClient.Providers().List()
+		if err != nil {
+			return nil, err
+		}
+		for _, provider := range providers {
+			if provider.Type() != clusterctlv1.InfrastructureProviderType {
+				continue
+			}
+			providerTemplates, err := c.listProviderTemplates(provider, "")
+			if err != nil {
+				log.Info("Skipping the templates of the provider", "Provider", provider.ManifestLabel(), "Reason", err.Error())
+				continue
+			}
+			templates = append(templates, providerTemplates...)
+		}
+	}
+
+	if len(options.Catalogs) > 0 {
+		// Reading the catalog files does not require to connect to the cluster; however, the template client
+		// exposing the functions for reading files from an URL is available on the cluster client only.
+		cluster, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: Kubeconfig{}})
+		if err != nil {
+			return nil, err
+		}
+		for _, catalog := range options.Catalogs {
+			content, err := cluster.Template().GetContentFromURL(catalog)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read the template catalog %q", catalog)
+			}
+			catalogTemplates, err := parseTemplateCatalog(catalog, content)
+			if err != nil {
+				return nil, err
+			}
+			templates = append(templates, catalogTemplates...)
+		}
+	}
+
+	filtered := []ClusterTemplateInfo{}
+	for _, t := range templates {
+		if filter.matches(t) {
+			filtered = append(filtered, t)
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if filtered[i].InfrastructureProvider != filtered[j].InfrastructureProvider {
+			return filtered[i].InfrastructureProvider < filtered[j].InfrastructureProvider
+		}
+		return filtered[i].Flavor < filtered[j].Flavor
+	})
+	return filtered, nil
+}
+
+// listProviderTemplates returns the templates published in the repository of an infrastructure provider;
+// if the version is empty, the default version of the repository is used.
+func (c *clusterctlClient) listProviderTemplates(provider Provider, version string) ([]ClusterTemplateInfo, error) {
+	repo, err := c.repositoryClientFactory(RepositoryClientFactoryInput{provider: provider})
+	if err != nil {
+		return nil, err
+	}
+
+	if version == "" {
+		version = repo.DefaultVersion()
+	}
+	if version == "" {
+		return nil, errors.Errorf("failed to identify the default version for the provider %q. Please specify a version", provider.Name())
+	}
+
+	metadata, err := repo.Metadata(version).Get()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := metadata.Templates
+	if len(entries) == 0 {
+		// If the provider does not list its templates, report the default template only, if it exists.
+		if _, err := repo.Templates(version).Get("", "default", true); err != nil {
+			return nil, nil
+		}
+		entries = []clusterctlv1.TemplateMetadata{{}}
+	}
+
+	templates := make([]ClusterTemplateInfo, 0, len(entries))
+	for _, e := range entries {
+		templates = append(templates, ClusterTemplateInfo{
+			TemplateMetadata:       e,
+			InfrastructureProvider: provider.Name(),
+			Version:                version,
+		})
+	}
+	return templates, nil
+}
+
+// parseTemplateCatalog returns the templates listed in a catalog file.
+func parseTemplateCatalog(catalog string, content []byte) ([]ClusterTemplateInfo, error) {
+	c := &templateCatalog{}
+	if err := yaml.Unmarshal(content, c); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the template catalog %q", catalog)
+	}
+
+	templates := make([]ClusterTemplateInfo, 0, len(c.Templates))
+	for i, e := range c.Templates {
+		if e.InfrastructureProvider == "" || e.URL == "" {
+			return nil, errors.Errorf("invalid template catalog %q: the infrastructureProvider and url fields of template %d are required", catalog, i)
+		}
+		templates = append(templates, ClusterTemplateInfo{
+			TemplateMetadata:       e.TemplateMetadata,
+			InfrastructureProvider: e.InfrastructureProvider,
+			URL:                    e.URL,
+			Catalog:                catalog,
+		})
+	}
+	return templates, nil
+}
+
+// templateFilter selects the templates matching the ListClusterTemplatesOptions.
+type templateFilter struct {
+	kubernetesVersion *version.Version
+	flavor            string
+	tags              []string
+}
+
+func newTemplateFilter(options ListClusterTemplatesOptions) (*templateFilter, error) {
+	f := &templateFilter{
+		flavor: options.Flavor,
+		tags:   options.Tags,
+	}
+	if options.KubernetesVersion != "" {
+		v, err := version.ParseGeneric(options.KubernetesVersion)
+		if err != nil {
+			return nil, errors.Errorf("invalid KubernetesVersion %q. Please use a semantic version number", options.KubernetesVersion)
+		}
+		f.kubernetesVersion = v
+	}
+	return f, nil
+}
+
+// matches returns true if the template matches all the filter criteria.
+func (f *templateFilter) matches(t ClusterTemplateInfo) bool {
+	if f.flavor != "" && t.Flavor != f.flavor {
+		return false
+	}
+
+	for _, tag := range f.tags {
+		found := false
+		for _, tt := range t.Tags {
+			if tt == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.kubernetesVersion != nil {
+		// Kubernetes versions are compared at the minor version level; templates with an invalid
+		// version range are not matched.
+		if t.MinKubernetesVersion != "" {
+			min, err := version.ParseGeneric(t.MinKubernetesVersion)
+			if err != nil || compareMinor(f.kubernetesVersion, min) < 0 {
+				return false
+			}
+		}
+		if t.MaxKubernetesVersion != "" {
+			max, err := version.ParseGeneric(t.MaxKubernetesVersion)
+			if err != nil || compareMinor(f.kubernetesVersion, max) > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// compareMinor compares two versions ignoring the patch and the pre-release/build parts.
+func compareMinor(a, b *version.Version) int {
+	switch {
+	case a.Major() != b.Major():
+		if a.Major() < b.Major() {
+			return -1
+		}
+		return 1
+	case a.Minor() != b.Minor():
+		if a.Minor() < b.Minor() {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
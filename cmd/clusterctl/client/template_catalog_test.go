@@ -0,0 +1,208 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+func Test_clusterctlClient_ListClusterTemplates(t *testing.T) {
+	g := NewWithT(t)
+
+	catalog := []byte(`templates:
+- infrastructureProvider: infra2
+  flavor: windows
+  description: Cluster with Windows worker nodes
+  minKubernetesVersion: v1.18
+  tags: [windows]
+  url: https://github.com/org/repo/blob/master/cluster-template-windows.yaml
+`)
+	tmpDir, err := ioutil.TempDir("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	catalogPath := filepath.Join(tmpDir, "catalog.yaml")
+	g.Expect(ioutil.WriteFile(catalogPath, catalog, 0600)).To(Succeed())
+
+	infra1Config := config.NewProvider("infra1", "url", clusterctlv1.InfrastructureProviderType)
+	infra2Config := config.NewProvider("infra2", "url", clusterctlv1.InfrastructureProviderType)
+
+	config1 := newFakeConfig().
+		WithProvider(infra1Config).
+		WithProvider(infra2Config)
+
+	// infra1 lists its templates in the metadata.yaml file.
+	repository1 := newFakeRepository(infra1Config, config1).
+		WithPaths("root", "components").
+		WithDefaultVersion("v1.1.0").
+		WithMetadata("v1.1.0", &clusterctlv1.Metadata{
+			ReleaseSeries: []clusterctlv1.ReleaseSeries{
+				{Major: 1, Minor: 1, Contract: "v1alpha3"},
+			},
+			Templates: []clusterctlv1.TemplateMetadata{
+				{Description: "Default cluster", MinKubernetesVersion: "v1.16", MaxKubernetesVersion: "v1.18"},
+				{Flavor: "ha", Description: "Cluster with 3 control plane machines", MinKubernetesVersion: "v1.17", Tags: []string{"ha"}},
+			},
+		})
+
+	// infra2 publishes only the default template.
+	repository2 := newFakeRepository(infra2Config, config1).
+		WithPaths("root", "components").
+		WithDefaultVersion("v2.0.0").
+		WithMetadata("v2.0.0", &clusterctlv1.Metadata{
+			ReleaseSeries: []clusterctlv1.ReleaseSeries{
+				{Major: 2, Minor: 0, Contract: "v1alpha3"},
+			},
+		}).
+		WithFile("v2.0.0", "cluster-template.yaml", templateYAML("ns1", "${ CLUSTER_NAME }"))
+
+	cluster1 := newFakeCluster(cluster.Kubeconfig{}, config1)
+
+	client := newFakeClient(config1).
+		WithCluster(cluster1).
+		WithRepository(repository1).
+		WithRepository(repository2)
+
+	type template struct {
+		provider string
+		version  string
+		flavor   string
+		catalog  string
+	}
+
+	tests := []struct {
+		name    string
+		options ListClusterTemplatesOptions
+		want    []template
+		wantErr bool
+	}{
+		{
+			name:    "all the templates from all the providers",
+			options: ListClusterTemplatesOptions{},
+			want: []template{
+				{provider: "infra1", version: "v1.1.0"},
+				{provider: "infra1", version: "v1.1.0", flavor: "ha"},
+				{provider: "infra2", version: "v2.0.0"},
+			},
+		},
+		{
+			name: "templates from a provider at a given version",
+			options: ListClusterTemplatesOptions{
+				InfrastructureProviders: []string{"infra2:v2.0.0"},
+			},
+			want: []template{
+				{provider: "infra2", version: "v2.0.0"},
+			},
+		},
+		{
+			name: "templates from additional catalogs",
+			options: ListClusterTemplatesOptions{
+				InfrastructureProviders: []string{"infra2"},
+				Catalogs:                []string{catalogPath},
+			},
+			want: []template{
+				{provider: "infra2", version: "v2.0.0"},
+				{provider: "infra2", flavor: "windows", catalog: catalogPath},
+			},
+		},
+		{
+			name: "templates supporting a Kubernetes version",
+			options: ListClusterTemplatesOptions{
+				InfrastructureProviders: []string{"infra1"},
+				Catalogs:                []string{catalogPath},
+				KubernetesVersion:       "v1.19.1",
+			},
+			want: []template{
+				{provider: "infra1", version: "v1.1.0", flavor: "ha"},
+				{provider: "infra2", flavor: "windows", catalog: catalogPath},
+			},
+		},
+		{
+			name: "templates with a flavor and tags",
+			options: ListClusterTemplatesOptions{
+				InfrastructureProviders: []string{"infra1"},
+				Flavor:                  "ha",
+				Tags:                    []string{"ha"},
+			},
+			want: []template{
+				{provider: "infra1", version: "v1.1.0", flavor: "ha"},
+			},
+		},
+		{
+			name: "no templates with tags not matching",
+			options: ListClusterTemplatesOptions{
+				InfrastructureProviders: []string{"infra1"},
+				Tags:                    []string{"ha", "windows"},
+			},
+			want: []template{},
+		},
+		{
+			name: "fails for an unknown provider",
+			options: ListClusterTemplatesOptions{
+				InfrastructureProviders: []string{"unknown"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails for an invalid Kubernetes version",
+			options: ListClusterTemplatesOptions{
+				KubernetesVersion: "invalid",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := client.ListClusterTemplates(tt.options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			gotTemplates := []template{}
+			for _, t := range got {
+				gotTemplates = append(gotTemplates, template{provider: t.InfrastructureProvider, version: t.Version, flavor: t.Flavor, catalog: t.Catalog})
+			}
+			g.Expect(gotTemplates).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_parseTemplateCatalog(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := parseTemplateCatalog("catalog.yaml", []byte(`templates:
+- flavor: no-provider
+  url: https://github.com/org/repo/blob/master/cluster-template.yaml
+`))
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = parseTemplateCatalog("catalog.yaml", []byte("templates: foo"))
+	g.Expect(err).To(HaveOccurred())
+}
@@ -17,12 +17,14 @@ limitations under the License.
 package client
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
 )
 
 // PlanUpgradeOptions carries the options supported by upgrade plan.
@@ -48,13 +50,23 @@ func (c *clusterctlClient) PlanUpgrade(options PlanUpgradeOptions) ([]UpgradePla
 		return nil, err
 	}
 
+	certManagerPlan, err := cluster.CertManager().PlanUpgrade()
+	if err != nil {
+		return nil, err
+	}
+
 	// UpgradePlan is an alias for cluster.UpgradePlan; this makes the conversion
 	aliasUpgradePlan := make([]UpgradePlan, len(upgradePlans))
 	for i, plan := range upgradePlans {
 		aliasUpgradePlan[i] = UpgradePlan{
-			Contract:     plan.Contract,
-			CoreProvider: plan.CoreProvider,
-			Providers:    plan.Providers,
+			Contract:          plan.Contract,
+			CoreProvider:      plan.CoreProvider,
+			Providers:         plan.Providers,
+			IntermediateSteps: plan.IntermediateSteps,
+			VersionSkews:      plan.VersionSkews,
+			WebhookDowntimes:  plan.WebhookDowntimes,
+			CertManager:       certManagerPlan,
+			Warnings:          plan.Warnings,
 		}
 	}
 
@@ -85,6 +97,22 @@ type ApplyUpgradeOptions struct {
 
 	// InfrastructureProviders instance and versions (e.g. capa-system/aws:v0.5.0) to upgrade to. This field can be used as alternative to Contract.
 	InfrastructureProviders []string
+
+	// SpecFile is the path of an init spec file (see InitSpec); the providers of the management group are upgraded
+	// to the versions declared in the spec. This field can be used as alternative to Contract and to the provider fields.
+	SpecFile string
+
+	// Confirm is called with a description of the action before upgrading the providers; if it returns false,
+	// the upgrade is aborted with ErrOperationNotConfirmed. If unspecified, the upgrade is always performed.
+	Confirm func(action string) bool
+
+	// ForceLock forces the operation even if the management cluster is locked by another clusterctl operation,
+	// e.g. because the lock has been left behind by an operation that failed without releasing it.
+	ForceLock bool
+
+	// Force forces the upgrade even if the pre-upgrade compatibility check reports existing Cluster API objects
+	// using fields or annotations that are removed in the target API Version of Cluster API (contract).
+	Force bool
 }
 
 func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
@@ -94,6 +122,13 @@ func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
 		return err
 	}
 
+	// Prevents other clusterctl operations from changing the management cluster while upgrading.
+	unlock, err := lockOperation(clusterClient, cluster.AuditUpgradeOperation, options.ForceLock)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Ensures the custom resource definitions required by clusterctl are in place.
 	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
 		return err
@@ -113,6 +148,14 @@ func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
 		len(options.ControlPlaneProviders) > 0 ||
 		len(options.InfrastructureProviders) > 0
 
+	// If the upgrade is declared in a spec file, upgrade the providers to the versions in the spec.
+	if options.SpecFile != "" {
+		if isCustomUpgrade || options.Contract != "" {
+			return errors.New("the spec file can not be used together with the contract and the provider options")
+		}
+		return c.applyUpgradeSpecFile(clusterClient, coreProvider, options)
+	}
+
 	// If we are upgrading a specific set of providers only, process the providers and call ApplyCustomPlan.
 	if isCustomUpgrade {
 		// Converts upgrade references back into an UpgradeItem.
@@ -137,22 +180,127 @@ func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
 			return err
 		}
 
+		if err := confirmAction(options.Confirm, upgradeAction(options.ManagementGroup, upgradeItems)); err != nil {
+			return err
+		}
+
 		// Execute the upgrade using the custom upgrade items
-		if err := clusterClient.ProviderUpgrader().ApplyCustomPlan(coreProvider, upgradeItems...); err != nil {
+		if err := clusterClient.ProviderUpgrader().ApplyCustomPlan(coreProvider, cluster.UpgradeOptions{Force: options.Force}, upgradeItems...); err != nil {
 			return err
 		}
 
+		// Records the operation in the audit log of the management cluster.
+		recordOperation(clusterClient, cluster.AuditUpgradeOperation, inventoryProviderRefs(clusterClient), map[string]string{
+			"managementGroup": options.ManagementGroup,
+		})
+
+		warnVersionSkews(clusterClient, coreProvider)
 		return nil
 	}
 
 	// Otherwise we are upgrading a whole management group according to a clusterctl generated upgrade plan.
-	if err := clusterClient.ProviderUpgrader().ApplyPlan(coreProvider, options.Contract); err != nil {
+	if err := confirmAction(options.Confirm, fmt.Sprintf("Upgrade the providers in the %s management group to the latest versions for the %s contract", options.ManagementGroup, options.Contract)); err != nil {
+		return err
+	}
+	if err := clusterClient.ProviderUpgrader().ApplyPlan(coreProvider, options.Contract, cluster.UpgradeOptions{Force: options.Force}); err != nil {
 		return err
 	}
 
+	// Records the operation in the audit log of the management cluster.
+	recordOperation(clusterClient, cluster.AuditUpgradeOperation, inventoryProviderRefs(clusterClient), map[string]string{
+		"managementGroup": options.ManagementGroup,
+		"contract":        options.Contract,
+	})
+
+	warnVersionSkews(clusterClient, coreProvider)
 	return nil
 }
 
+// applyUpgradeSpecFile upgrades the providers of the management group to the versions declared in the spec file.
+func (c *clusterctlClient) applyUpgradeSpecFile(clusterClient cluster.Client, coreProvider clusterctlv1.Provider, options ApplyUpgradeOptions) error {
+	log := logf.Log
+
+	spec, err := LoadInitSpec(options.SpecFile)
+	if err != nil {
+		return err
+	}
+
+	managementGroups, err := clusterClient.ProviderInventory().GetManagementGroups()
+	if err != nil {
+		return err
+	}
+	managementGroup := managementGroups.FindManagementGroupByProviderInstanceName(coreProvider.InstanceName())
+	if managementGroup == nil {
+		return errors.Errorf("unable to identify the %s management group", options.ManagementGroup)
+	}
+
+	upgradeItems, err := initSpecUpgradeItems(spec, managementGroup)
+	if err != nil {
+		return err
+	}
+	if len(upgradeItems) == 0 {
+		log.Info("All the providers are already at the versions declared in the spec file")
+		return nil
+	}
+
+	if err := confirmAction(options.Confirm, upgradeAction(options.ManagementGroup, upgradeItems)); err != nil {
+		return err
+	}
+
+	if err := clusterClient.ProviderUpgrader().ApplyCustomPlan(coreProvider, cluster.UpgradeOptions{Force: options.Force}, upgradeItems...); err != nil {
+		return err
+	}
+
+	// Records the operation in the audit log of the management cluster.
+	recordOperation(clusterClient, cluster.AuditUpgradeOperation, inventoryProviderRefs(clusterClient), map[string]string{
+		"managementGroup": options.ManagementGroup,
+	})
+
+	warnVersionSkews(clusterClient, coreProvider)
+	return nil
+}
+
+// warnVersionSkews warns about the providers in the management group with instances running different versions after
+// the upgrade, e.g. the instances of the same provider in other management groups which are not upgraded yet.
+// Nb. the upgrade is already completed, so errors are logged only.
+func warnVersionSkews(clusterClient cluster.Client, coreProvider clusterctlv1.Provider) {
+	log := logf.Log
+
+	managementGroups, err := clusterClient.ProviderInventory().GetManagementGroups()
+	if err != nil {
+		log.V(5).Info("Failed to check for provider version skews", "Error", err.Error())
+		return
+	}
+	managementGroup := managementGroups.FindManagementGroupByProviderInstanceName(coreProvider.InstanceName())
+	if managementGroup == nil {
+		return
+	}
+
+	skews, err := clusterClient.ProviderInventory().GetVersionSkews()
+	if err != nil {
+		log.V(5).Info("Failed to check for provider version skews", "Error", err.Error())
+		return
+	}
+
+	for _, skew := range managementGroup.FilterVersionSkews(skews) {
+		outdated := []string{}
+		for _, instance := range skew.Outdated() {
+			outdated = append(outdated, fmt.Sprintf("%s (%s)", instance.InstanceName(), instance.Version))
+		}
+		log.Info(fmt.Sprintf("Warning: the %s %s has instances running versions older than %s: %s; all the instances share the same CRDs, please upgrade them as well",
+			skew.ProviderName, skew.Type, skew.LatestVersion(), strings.Join(outdated, ", ")))
+	}
+}
+
+// upgradeAction returns a description of the upgrade of the providers.
+func upgradeAction(managementGroup string, upgradeItems []cluster.UpgradeItem) string {
+	items := make([]string, 0, len(upgradeItems))
+	for _, item := range upgradeItems {
+		items = append(items, fmt.Sprintf("%s to %s", item.Provider.InstanceName(), item.NextVersion))
+	}
+	return fmt.Sprintf("Upgrade the providers in the %s management group: %s", managementGroup, strings.Join(items, ", "))
+}
+
 func addUpgradeItems(upgradeItems []cluster.UpgradeItem, providerType clusterctlv1.ProviderType, providers ...string) ([]cluster.UpgradeItem, error) {
 	for _, upgradeReference := range providers {
 		providerUpgradeItem, err := parseUpgradeItem(upgradeReference, providerType)
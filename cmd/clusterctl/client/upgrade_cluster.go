@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+// UpgradeWorkloadClusterOptions carries the options supported by UpgradeWorkloadCluster.
+type UpgradeWorkloadClusterOptions struct {
+	// Kubeconfig to use for accessing the management cluster. If empty, default discovery rules apply.
+	Kubeconfig Kubeconfig
+
+	// Namespace where the Cluster exists. If unspecified, the current namespace will be used.
+	Namespace string
+
+	// ClusterName is the name of the Cluster to upgrade.
+	ClusterName string
+
+	// KubernetesVersion the Cluster should be upgraded to (e.g. v1.18.2).
+	KubernetesVersion string
+
+	// DryRun instructs UpgradeWorkloadCluster to return the upgrade plan without applying it.
+	DryRun bool
+
+	// WaitForCompletion instructs UpgradeWorkloadCluster to wait for the MachineDeployments to complete the upgrade;
+	// the control plane upgrade is always waited for before upgrading the MachineDeployments.
+	WaitForCompletion bool
+
+	// Timeout for the upgrade of the control plane and of each MachineDeployment to complete.
+	// If unspecified, 30 minutes are used.
+	Timeout time.Duration
+}
+
+func (c *clusterctlClient) UpgradeWorkloadCluster(options UpgradeWorkloadClusterOptions) (*WorkloadClusterUpgradePlan, error) {
+	if options.ClusterName == "" {
+		return nil, errors.New("the name of the Cluster to upgrade is required")
+	}
+	if options.KubernetesVersion == "" {
+		return nil, errors.New("the Kubernetes version to upgrade to is required")
+	}
+
+	// Get the client for interacting with the management cluster.
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	upgrader := clusterClient.WorkloadClusterUpgrader()
+	plan, err := upgrader.Plan(options.Namespace, options.ClusterName, options.KubernetesVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.DryRun || plan.IsEmpty() {
+		return (*WorkloadClusterUpgradePlan)(plan), nil
+	}
+
+	if err := upgrader.Apply(plan, cluster.WorkloadClusterUpgradeOptions{
+		WaitForCompletion: options.WaitForCompletion,
+		Timeout:           options.Timeout,
+	}); err != nil {
+		return nil, err
+	}
+
+	// Records the operation in the audit log of the management cluster.
+	recordOperation(clusterClient, cluster.AuditUpgradeOperation, nil, map[string]string{
+		"namespace":         options.Namespace,
+		"cluster":           options.ClusterName,
+		"kubernetesVersion": options.KubernetesVersion,
+	})
+
+	return (*WorkloadClusterUpgradePlan)(plan), nil
+}
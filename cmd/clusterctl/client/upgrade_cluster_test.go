@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_clusterctlClient_UpgradeWorkloadCluster(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     UpgradeWorkloadClusterOptions
+		wantVersion string
+		wantErr     bool
+	}{
+		{
+			name: "Returns the plan without upgrading for dry runs",
+			options: UpgradeWorkloadClusterOptions{
+				Kubeconfig:        Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+				ClusterName:       "cluster1",
+				KubernetesVersion: "v1.18.2",
+				DryRun:            true,
+			},
+			wantVersion: "v1.17.3",
+		},
+		{
+			name: "Upgrades the control plane",
+			options: UpgradeWorkloadClusterOptions{
+				Kubeconfig:        Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+				ClusterName:       "cluster1",
+				KubernetesVersion: "v1.18.2",
+			},
+			wantVersion: "v1.18.2",
+		},
+		{
+			name: "Fails if the Cluster name is missing",
+			options: UpgradeWorkloadClusterOptions{
+				Kubeconfig:        Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+				KubernetesVersion: "v1.18.2",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails if the Kubernetes version is missing",
+			options: UpgradeWorkloadClusterOptions{
+				Kubeconfig:  Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+				ClusterName: "cluster1",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			config1 := newFakeConfig()
+			cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1).
+				WithObjs(
+					&clusterv1.Cluster{
+						ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster1"},
+						Spec: clusterv1.ClusterSpec{
+							ControlPlaneRef: &corev1.ObjectReference{Kind: "KubeadmControlPlane", Name: "cluster1-control-plane"},
+						},
+					},
+					&controlplanev1.KubeadmControlPlane{
+						ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster1-control-plane"},
+						Spec: controlplanev1.KubeadmControlPlaneSpec{
+							Replicas: pointer.Int32Ptr(1),
+							Version:  "v1.17.3",
+						},
+					},
+				)
+			c := newFakeClient(config1).WithCluster(cluster1)
+
+			got, err := c.UpgradeWorkloadCluster(tt.options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.ControlPlane).ToNot(BeNil())
+			g.Expect(got.ControlPlane.NextVersion).To(Equal("v1.18.2"))
+
+			proxyClient, err := cluster1.Proxy().NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+			kcp := &controlplanev1.KubeadmControlPlane{}
+			g.Expect(proxyClient.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "cluster1-control-plane"}, kcp)).To(Succeed())
+			g.Expect(kcp.Spec.Version).To(Equal(tt.wantVersion))
+		})
+	}
+}
@@ -302,9 +302,11 @@ func fakeProvider(name string, providerType clusterctlv1.ProviderType, version,
 			Namespace: targetNamespace,
 			Name:      clusterctlv1.ManifestLabel(name, providerType),
 			Labels: map[string]string{
-				clusterctlv1.ClusterctlLabelName:     "",
-				clusterv1.ProviderLabelName:          clusterctlv1.ManifestLabel(name, providerType),
-				clusterctlv1.ClusterctlCoreLabelName: "inventory",
+				clusterctlv1.ClusterctlLabelName:                "",
+				clusterv1.ProviderLabelName:                     clusterctlv1.ManifestLabel(name, providerType),
+				clusterctlv1.ClusterctlCoreLabelName:            "inventory",
+				clusterctlv1.ClusterctlProviderVersionLabelName: version,
+				clusterctlv1.ClusterctlInstanceLabelName:        targetNamespace,
 			},
 		},
 		ProviderName:     name,
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"sort"
+
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+// jsonSchemaDraft07 is the JSON Schema dialect used for the variables schema.
+const jsonSchemaDraft07 = "http://json-schema.org/draft-07/schema#"
+
+// wellKnownVariableDescriptions are the descriptions of the variables clusterctl sets from the GetClusterTemplateOptions.
+var wellKnownVariableDescriptions = map[string]string{
+	"NAMESPACE":                   "The namespace where the workload cluster objects are created.",
+	"CLUSTER_NAME":                "The name of the workload cluster.",
+	"KUBERNETES_VERSION":          "The Kubernetes version of the workload cluster.",
+	"CONTROL_PLANE_MACHINE_COUNT": "The number of control plane machines.",
+	"WORKER_MACHINE_COUNT":        "The number of worker machines.",
+}
+
+// VariablesSchema is a JSON Schema (draft-07) describing the variables of a workload cluster template or of the
+// provider components, so the variable values can be validated by tools other than clusterctl, e.g. web frontends.
+// All the variables are strings, given that variables are substituted as text in the YAML.
+type VariablesSchema struct {
+	Schema               string                    `json:"$schema"`
+	Title                string                    `json:"title,omitempty"`
+	Type                 string                    `json:"type"`
+	Properties           map[string]VariableSchema `json:"properties"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties bool                      `json:"additionalProperties"`
+}
+
+// VariableSchema is the JSON Schema of a single variable.
+type VariableSchema struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Default     *string  `json:"default,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+
+	// Format is the format from the variable validation rules; given that cidr and ip are not formats defined
+	// by JSON Schema, it should be considered an annotation only.
+	Format string `json:"format,omitempty"`
+}
+
+func (c *clusterctlClient) GetClusterTemplateVariablesSchema(options GetClusterTemplateOptions) (*VariablesSchema, error) {
+	options.ListVariablesOnly = true
+	template, err := c.GetClusterTemplate(options)
+	if err != nil {
+		return nil, err
+	}
+	return c.newVariablesSchema("Workload cluster template variables", template.VariableMap())
+}
+
+func (c *clusterctlClient) GetProviderComponentsVariablesSchema(provider string, providerType clusterctlv1.ProviderType, options ComponentsOptions) (*VariablesSchema, error) {
+	options.SkipVariables = true
+	components, err := c.GetProviderComponents(provider, providerType, options)
+	if err != nil {
+		return nil, err
+	}
+	return c.newVariablesSchema(components.ManifestLabel()+" components variables", components.VariableMap())
+}
+
+// newVariablesSchema returns the schema for the given variables, as returned by the YAML processor, combined with the
+// variable validation rules read from the clusterctl configuration.
+// A variable is required if it has no default value in the YAML or if the validation rules require it.
+func (c *clusterctlClient) newVariablesSchema(title string, variables map[string]*string) (*VariablesSchema, error) {
+	validations, err := c.configClient.VariableValidations()
+	if err != nil {
+		return nil, err
+	}
+	validationByName := map[string]config.VariableValidation{}
+	for _, v := range validations {
+		validationByName[v.Name] = v
+	}
+
+	schema := &VariablesSchema{
+		Schema:     jsonSchemaDraft07,
+		Title:      title,
+		Type:       "object",
+		Properties: map[string]VariableSchema{},
+	}
+	for name, defaultValue := range variables {
+		property := VariableSchema{
+			Type:        "string",
+			Description: wellKnownVariableDescriptions[name],
+			Default:     defaultValue,
+		}
+		required := defaultValue == nil
+
+		if v, ok := validationByName[name]; ok {
+			if v.Description != "" {
+				property.Description = v.Description
+			}
+			property.Pattern = v.Pattern
+			property.Enum = v.Enum
+			property.Format = v.Format
+			required = required || v.Required
+		}
+
+		schema.Properties[name] = property
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+func Test_clusterctlClient_GetClusterTemplateVariablesSchema(t *testing.T) {
+	g := NewWithT(t)
+
+	rawTemplate := []byte("apiVersion: v1\n" +
+		"kind: Cluster\n" +
+		"metadata:\n" +
+		"  name: ${ CLUSTER_NAME }\n" +
+		"  namespace: ns1\n" +
+		"spec:\n" +
+		"  region: ${REGION:=us-east-1}\n" +
+		"  podCIDR: ${POD_CIDR}\n" +
+		"  size: ${SIZE:=small}\n")
+
+	config1 := newFakeConfig().
+		WithProvider(infraProviderConfig).
+		WithVar(config.VariableValidationsConfigKey, `
+- name: POD_CIDR
+  description: The CIDR of the pods network.
+  format: cidr
+- name: SIZE
+  enum: [small, large]
+  required: true
+`)
+
+	repository1 := newFakeRepository(infraProviderConfig, config1).
+		WithPaths("root", "components").
+		WithDefaultVersion("v3.0.0").
+		WithFile("v3.0.0", "cluster-template.yaml", rawTemplate)
+
+	cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1)
+
+	client := newFakeClient(config1).
+		WithCluster(cluster1).
+		WithRepository(repository1)
+
+	got, err := client.GetClusterTemplateVariablesSchema(GetClusterTemplateOptions{
+		Kubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+		ProviderRepositorySource: &ProviderRepositorySourceOptions{
+			InfrastructureProvider: "infra:v3.0.0",
+		},
+		ClusterName:              "test",
+		TargetNamespace:          "ns1",
+		ControlPlaneMachineCount: pointer.Int64Ptr(1),
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(got.Schema).To(Equal(jsonSchemaDraft07))
+	g.Expect(got.Type).To(Equal("object"))
+	g.Expect(got.Required).To(Equal([]string{"CLUSTER_NAME", "POD_CIDR", "SIZE"}))
+	g.Expect(got.Properties).To(Equal(map[string]VariableSchema{
+		"CLUSTER_NAME": {
+			Type:        "string",
+			Description: wellKnownVariableDescriptions["CLUSTER_NAME"],
+		},
+		"REGION": {
+			Type:    "string",
+			Default: pointer.StringPtr("us-east-1"),
+		},
+		"POD_CIDR": {
+			Type:        "string",
+			Description: "The CIDR of the pods network.",
+			Format:      config.CIDRVariableFormat,
+		},
+		"SIZE": {
+			Type:    "string",
+			Default: pointer.StringPtr("small"),
+			Enum:    []string{"small", "large"},
+		},
+	}))
+}
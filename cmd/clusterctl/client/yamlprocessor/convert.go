@@ -0,0 +1,534 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yamlprocessor
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/drone/envsubst"
+	"github.com/drone/envsubst/parse"
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ConvertedTemplateFile is the name of the template file generated by the conversions.
+	ConvertedTemplateFile = "cluster-template.yaml"
+
+	kustomizationFile = "kustomization.yaml"
+	kustomizeBaseDir  = "base"
+
+	helmChartFile        = "Chart.yaml"
+	helmValuesFile       = "values.yaml"
+	helmTemplatesDir     = "templates"
+	helmDefaultChartName = "cluster-template"
+)
+
+// kustomization defines the subset of the kustomization file supported by the conversions.
+type kustomization struct {
+	APIVersion string           `json:"apiVersion,omitempty"`
+	Kind       string           `json:"kind,omitempty"`
+	Resources  []string         `json:"resources,omitempty"`
+	Patches    []kustomizePatch `json:"patches,omitempty"`
+}
+
+// kustomizePatch defines a JSON 6902 patch, either inline or read from a file, applied to the target objects.
+type kustomizePatch struct {
+	Path   string           `json:"path,omitempty"`
+	Patch  string           `json:"patch,omitempty"`
+	Target *kustomizeTarget `json:"target,omitempty"`
+}
+
+// kustomizeTarget selects the objects a patch applies to.
+type kustomizeTarget struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+func (t *kustomizeTarget) matches(obj unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	return (t.Group == "" || t.Group == gvk.Group) &&
+		(t.Version == "" || t.Version == gvk.Version) &&
+		(t.Kind == "" || t.Kind == gvk.Kind) &&
+		(t.Name == "" || t.Name == obj.GetName()) &&
+		(t.Namespace == "" || t.Namespace == obj.GetNamespace())
+}
+
+type jsonPatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+// helmChart defines the subset of the Chart.yaml file generated by the conversions.
+type helmChart struct {
+	APIVersion  string `json:"apiVersion"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// ConvertToKustomize converts a template using ${VAR} variables into a kustomize base and a kustomization
+// patching the base. The base contains the template with each variable replaced by its default value, or by
+// a placeholder derived from the variable name (e.g. cluster-name for ${CLUSTER_NAME}), while the patches
+// restore the ${VAR} expressions, so the original template can be rebuilt with ConvertFromKustomize.
+// Variables must only be used in YAML values, and must not change the structure of the YAML documents.
+func ConvertToKustomize(rawTemplate []byte) (map[string][]byte, error) {
+	tmp := convertLegacyVars(string(rawTemplate))
+
+	variables, err := NewSimpleProcessor().GetVariableMap([]byte(tmp))
+	if err != nil {
+		return nil, err
+	}
+	base, err := envsubst.Eval(tmp, func(name string) string {
+		if v := variables[name]; v != nil {
+			return *v
+		}
+		return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	templateObjs, err := utilyaml.ToUnstructured([]byte(tmp))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the template")
+	}
+	baseObjs, err := utilyaml.ToUnstructured([]byte(base))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the template with default values")
+	}
+	if len(templateObjs) != len(baseObjs) {
+		return nil, errors.New("variables must not change the number of YAML documents in the template")
+	}
+
+	overlay := kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  []string{kustomizeBaseDir},
+	}
+	for i := range templateObjs {
+		var ops []jsonPatchOperation
+		if err := variableOperations("", templateObjs[i].Object, baseObjs[i].Object, &ops); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert the %s %q", baseObjs[i].GetKind(), baseObjs[i].GetName())
+		}
+		if len(ops) == 0 {
+			continue
+		}
+
+		patch, err := yaml.Marshal(ops)
+		if err != nil {
+			return nil, err
+		}
+		gvk := baseObjs[i].GroupVersionKind()
+		overlay.Patches = append(overlay.Patches, kustomizePatch{
+			Patch: string(patch),
+			Target: &kustomizeTarget{
+				Group:     gvk.Group,
+				Version:   gvk.Version,
+				Kind:      gvk.Kind,
+				Name:      baseObjs[i].GetName(),
+				Namespace: baseObjs[i].GetNamespace(),
+			},
+		})
+	}
+
+	overlayYaml, err := yaml.Marshal(overlay)
+	if err != nil {
+		return nil, err
+	}
+	baseKustomizationYaml, err := yaml.Marshal(kustomization{
+		APIVersion: overlay.APIVersion,
+		Kind:       overlay.Kind,
+		Resources:  []string{ConvertedTemplateFile},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		kustomizationFile: overlayYaml,
+		path.Join(kustomizeBaseDir, kustomizationFile):     baseKustomizationYaml,
+		path.Join(kustomizeBaseDir, ConvertedTemplateFile): []byte(base),
+	}, nil
+}
+
+// variableOperations walks the template and the base in parallel, and adds a replace operation for each
+// value of the template using variables.
+func variableOperations(jsonPath string, template, base interface{}, ops *[]jsonPatchOperation) error {
+	switch t := template.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok || len(b) != len(t) {
+			return errors.Errorf("variables must not change the structure of %q", jsonPath)
+		}
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if strings.Contains(k, "${") {
+				return errors.Errorf("variables can not be used in keys, found %q in %q", k, jsonPath)
+			}
+			bv, ok := b[k]
+			if !ok {
+				return errors.Errorf("variables must not change the structure of %q", jsonPath)
+			}
+			if err := variableOperations(jsonPath+"/"+escapeJSONPointer(k), t[k], bv, ops); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok || len(b) != len(t) {
+			return errors.Errorf("variables must not change the structure of %q", jsonPath)
+		}
+		for i := range t {
+			if err := variableOperations(fmt.Sprintf("%s/%d", jsonPath, i), t[i], b[i], ops); err != nil {
+				return err
+			}
+		}
+	case string:
+		if strings.Contains(t, "${") {
+			*ops = append(*ops, jsonPatchOperation{Op: "replace", Path: jsonPath, Value: t})
+		}
+	}
+	return nil
+}
+
+func escapeJSONPointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// ConvertFromKustomize rebuilds a template from a kustomization and its resources, keyed by their path
+// relative to the root kustomization.yaml file. Only resources and JSON 6902 patches with a target are
+// supported, e.g. the kustomizations generated by ConvertToKustomize.
+func ConvertFromKustomize(files map[string][]byte) ([]byte, error) {
+	objs, err := buildKustomization(files, ".")
+	if err != nil {
+		return nil, err
+	}
+	return utilyaml.FromUnstructured(objs)
+}
+
+func buildKustomization(files map[string][]byte, dir string) ([]unstructured.Unstructured, error) {
+	kustomizationPath := path.Join(dir, kustomizationFile)
+	raw, ok := files[kustomizationPath]
+	if !ok {
+		return nil, errors.Errorf("failed to find %s", kustomizationPath)
+	}
+	k := &kustomization{}
+	if err := yaml.UnmarshalStrict(raw, k); err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s, only resources and JSON 6902 patches are supported", kustomizationPath)
+	}
+
+	var objs []unstructured.Unstructured
+	for _, r := range k.Resources {
+		resourcePath := path.Join(dir, r)
+		if _, ok := files[path.Join(resourcePath, kustomizationFile)]; ok {
+			resourceObjs, err := buildKustomization(files, resourcePath)
+			if err != nil {
+				return nil, err
+			}
+			objs = append(objs, resourceObjs...)
+			continue
+		}
+
+		raw, ok := files[resourcePath]
+		if !ok {
+			return nil, errors.Errorf("failed to find the resource %s", resourcePath)
+		}
+		resourceObjs, err := utilyaml.ToUnstructured(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the resource %s", resourcePath)
+		}
+		objs = append(objs, resourceObjs...)
+	}
+
+	for i, p := range k.Patches {
+		rawPatch := []byte(p.Patch)
+		if p.Path != "" {
+			patchPath := path.Join(dir, p.Path)
+			if rawPatch, ok = files[patchPath]; !ok {
+				return nil, errors.Errorf("failed to find the patch %s", patchPath)
+			}
+		}
+		if err := applyKustomizePatch(objs, p.Target, rawPatch); err != nil {
+			return nil, errors.Wrapf(err, "failed to apply the patch %d of %s", i, kustomizationPath)
+		}
+	}
+	return objs, nil
+}
+
+func applyKustomizePatch(objs []unstructured.Unstructured, target *kustomizeTarget, rawPatch []byte) error {
+	if target == nil {
+		return errors.New("only JSON 6902 patches with a target are supported")
+	}
+	patchJSON, err := yaml.YAMLToJSON(rawPatch)
+	if err != nil {
+		return err
+	}
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return errors.Wrap(err, "only JSON 6902 patches with a target are supported")
+	}
+
+	matched := false
+	for i := range objs {
+		if !target.matches(objs[i]) {
+			continue
+		}
+		matched = true
+
+		objJSON, err := json.Marshal(objs[i].Object)
+		if err != nil {
+			return err
+		}
+		patchedJSON, err := patch.Apply(objJSON)
+		if err != nil {
+			return err
+		}
+		patched := map[string]interface{}{}
+		if err := json.Unmarshal(patchedJSON, &patched); err != nil {
+			return err
+		}
+		objs[i].Object = patched
+	}
+	if !matched {
+		return errors.New("the target does not match any object")
+	}
+	return nil
+}
+
+// helmValueNameRegEx defines the variable names that can be used as Helm values.
+var helmValueNameRegEx = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ConvertToHelm converts a template using ${VAR} variables into the skeleton of a Helm chart, where each
+// variable is read from the chart values; values.yaml contains the default value of each variable, if any.
+// Only variables and variables with a literal default value, e.g. ${VAR:=default}, are supported.
+func ConvertToHelm(rawTemplate []byte, chartName string) (map[string][]byte, error) {
+	tmp := convertLegacyVars(string(rawTemplate))
+	if chartName == "" {
+		chartName = helmDefaultChartName
+	}
+
+	variables, err := NewSimpleProcessor().GetVariableMap([]byte(tmp))
+	if err != nil {
+		return nil, err
+	}
+	t, err := parse.Parse(tmp)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	if err := writeHelmTemplate(&b, t.Root, variables); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for name, v := range variables {
+		values[name] = ""
+		if v != nil {
+			values[name] = *v
+		}
+	}
+	valuesYaml, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	chartYaml, err := yaml.Marshal(helmChart{
+		APIVersion:  "v2",
+		Name:        chartName,
+		Description: "A Helm chart generated by clusterctl from a cluster template.",
+		Version:     "0.1.0",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		helmChartFile:  chartYaml,
+		helmValuesFile: valuesYaml,
+		path.Join(helmTemplatesDir, ConvertedTemplateFile): []byte(b.String()),
+	}, nil
+}
+
+// helmTextEscaper escapes the Go template delimiters in the text of a template.
+var helmTextEscaper = strings.NewReplacer("{{", `{{ "{{" }}`, "}}", `{{ "}}" }}`)
+
+func writeHelmTemplate(b *strings.Builder, root parse.Node, variables map[string]*string) error {
+	switch v := root.(type) {
+	case *parse.ListNode:
+		for _, n := range v.Nodes {
+			if err := writeHelmTemplate(b, n, variables); err != nil {
+				return err
+			}
+		}
+	case *parse.TextNode:
+		b.WriteString(helmTextEscaper.Replace(v.Value))
+	case *parse.FuncNode:
+		action, err := helmAction(v, variables[v.Param])
+		if err != nil {
+			return err
+		}
+		b.WriteString(action)
+	}
+	return nil
+}
+
+func helmAction(n *parse.FuncNode, defaultValue *string) (string, error) {
+	if !helmValueNameRegEx.MatchString(n.Param) {
+		return "", errors.Errorf("variable %q can not be used as a Helm value", n.Param)
+	}
+	value := ".Values." + n.Param
+
+	switch n.Name {
+	case "":
+		if defaultValue != nil {
+			return fmt.Sprintf("{{ %s }}", value), nil
+		}
+		return fmt.Sprintf("{{ required %q %s }}", fmt.Sprintf("value for %s is not set", n.Param), value), nil
+	case "=", ":=", ":-", "-":
+		var d strings.Builder
+		for _, arg := range n.Args {
+			t, ok := arg.(*parse.TextNode)
+			if !ok {
+				return "", errors.Errorf("variable %q has a default value which is not a literal, this is not supported", n.Param)
+			}
+			d.WriteString(t.Value)
+		}
+		if defaultValue != nil && *defaultValue == d.String() {
+			return fmt.Sprintf("{{ %s }}", value), nil
+		}
+		return fmt.Sprintf("{{ %s | default %s }}", value, strconv.Quote(d.String())), nil
+	default:
+		return "", errors.Errorf("variable %q uses the %q substitution function, this is not supported", n.Param, n.Name)
+	}
+}
+
+// helmActionRegEx defines the regexp used for searching the escaped delimiters and the actions of a Helm template.
+var helmActionRegEx = regexp.MustCompile(`\{\{ "\{\{" \}\}|\{\{ "\}\}" \}\}|\{\{-?\s*(.*?)\s*-?\}\}`)
+
+var (
+	helmValueRegEx         = regexp.MustCompile(`^\.Values\.([A-Za-z_][A-Za-z0-9_]*)$`)
+	helmRequiredValueRegEx = regexp.MustCompile(`^required "(?:[^"\\]|\\.)*" \.Values\.([A-Za-z_][A-Za-z0-9_]*)$`)
+	helmDefaultValueRegEx  = regexp.MustCompile(`^\.Values\.([A-Za-z_][A-Za-z0-9_]*) \| default ("(?:[^"\\]|\\.)*")$`)
+)
+
+// ConvertFromHelm rebuilds a template from the files of a Helm chart, keyed by their path relative to the
+// chart root. Only templates reading values, eventually with a default value or marked as required, are
+// supported, e.g. the charts generated by ConvertToHelm.
+func ConvertFromHelm(files map[string][]byte) ([]byte, error) {
+	values := map[string]interface{}{}
+	if raw, ok := files[helmValuesFile]; ok {
+		if err := yaml.Unmarshal(raw, &values); err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", helmValuesFile)
+		}
+	}
+
+	var templatePaths []string
+	for p := range files {
+		if path.Dir(p) == helmTemplatesDir && (path.Ext(p) == ".yaml" || path.Ext(p) == ".yml") {
+			templatePaths = append(templatePaths, p)
+		}
+	}
+	if len(templatePaths) == 0 {
+		return nil, errors.Errorf("failed to find templates in the %s folder", helmTemplatesDir)
+	}
+	sort.Strings(templatePaths)
+
+	templates := make([][]byte, 0, len(templatePaths))
+	for _, p := range templatePaths {
+		template, err := convertHelmTemplate(string(files[p]), values)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert %s", p)
+		}
+		templates = append(templates, []byte(template))
+	}
+	return utilyaml.JoinYaml(templates...), nil
+}
+
+func convertHelmTemplate(template string, values map[string]interface{}) (string, error) {
+	var convertErr error
+	converted := helmActionRegEx.ReplaceAllStringFunc(template, func(action string) string {
+		switch action {
+		case `{{ "{{" }}`:
+			return "{{"
+		case `{{ "}}" }}`:
+			return "}}"
+		}
+
+		body := helmActionRegEx.FindStringSubmatch(action)[1]
+		if m := helmValueRegEx.FindStringSubmatch(body); m != nil {
+			d, err := helmDefaultValue(values, m[1])
+			if err != nil {
+				convertErr = err
+				return action
+			}
+			if d == "" {
+				return fmt.Sprintf("${%s}", m[1])
+			}
+			return fmt.Sprintf("${%s:=%s}", m[1], d)
+		}
+		if m := helmRequiredValueRegEx.FindStringSubmatch(body); m != nil {
+			return fmt.Sprintf("${%s}", m[1])
+		}
+		if m := helmDefaultValueRegEx.FindStringSubmatch(body); m != nil {
+			d, err := strconv.Unquote(m[2])
+			if err != nil || strings.Contains(d, "}") {
+				convertErr = errors.Errorf("the default value %s of %q can not be used in a variable", m[2], m[1])
+				return action
+			}
+			return fmt.Sprintf("${%s:=%s}", m[1], d)
+		}
+		convertErr = errors.Errorf("the %q action is not supported", action)
+		return action
+	})
+	if convertErr != nil {
+		return "", convertErr
+	}
+	return converted, nil
+}
+
+func helmDefaultValue(values map[string]interface{}, name string) (string, error) {
+	v, ok := values[name]
+	if !ok || v == nil {
+		return "", nil
+	}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return "", errors.Errorf("the value %q is not a scalar", name)
+	}
+	d := fmt.Sprint(v)
+	if strings.Contains(d, "}") {
+		return "", errors.Errorf("the value of %q can not be used as default value of a variable", name)
+	}
+	return d, nil
+}
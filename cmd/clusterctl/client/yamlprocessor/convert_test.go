@@ -0,0 +1,236 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yamlprocessor
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+)
+
+var convertTemplate = `apiVersion: cluster.x-k8s.io/v1alpha3
+kind: Cluster
+metadata:
+  name: ${CLUSTER_NAME}
+  namespace: ${NAMESPACE:=default}
+  annotations:
+    example.com/note: "{{ not a helm action }}"
+spec:
+  clusterNetwork:
+    pods:
+      cidrBlocks: ["${POD_CIDR:=192.168.0.0/16}"]
+---
+apiVersion: cluster.x-k8s.io/v1alpha3
+kind: MachineDeployment
+metadata:
+  name: ${CLUSTER_NAME}-md-0
+  namespace: ${NAMESPACE:=default}
+spec:
+  replicas: ${WORKER_MACHINE_COUNT:=3}`
+
+func TestConvertToKustomize(t *testing.T) {
+	g := NewWithT(t)
+
+	files, err := ConvertToKustomize([]byte(convertTemplate))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(HaveLen(3))
+
+	base, err := utilyaml.ToUnstructured(files["base/cluster-template.yaml"])
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(base).To(HaveLen(2))
+	g.Expect(base[0].GetName()).To(Equal("cluster-name"))
+	g.Expect(base[0].GetNamespace()).To(Equal("default"))
+	g.Expect(base[1].GetName()).To(Equal("cluster-name-md-0"))
+	g.Expect(base[1].Object["spec"]).To(HaveKeyWithValue("replicas", BeEquivalentTo(3)))
+
+	g.Expect(string(files["kustomization.yaml"])).To(ContainSubstring("name: cluster-name-md-0"))
+	g.Expect(string(files["kustomization.yaml"])).To(ContainSubstring("value: ${WORKER_MACHINE_COUNT:=3}"))
+
+	// Converting back returns the objects of the original template.
+	template, err := ConvertFromKustomize(files)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(template)).To(ContainSubstring("replicas: ${WORKER_MACHINE_COUNT:=3}"))
+
+	got, err := utilyaml.ToUnstructured(template)
+	g.Expect(err).NotTo(HaveOccurred())
+	want, err := utilyaml.ToUnstructured([]byte(convertTemplate))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(want))
+}
+
+func TestConvertToKustomize_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{
+			name:     "variables in keys",
+			template: "kind: ConfigMap\ndata:\n  ${KEY}: value",
+		},
+		{
+			name:     "variables generating YAML fields",
+			template: "kind: ConfigMap\nmetadata:\n  name: foo\n${EXTRA:=data: {}}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := ConvertToKustomize([]byte(tt.template))
+			g.Expect(err).To(HaveOccurred())
+		})
+	}
+}
+
+func TestConvertFromKustomize_Errors(t *testing.T) {
+	resource := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo")
+
+	tests := []struct {
+		name  string
+		files map[string][]byte
+	}{
+		{
+			name:  "missing kustomization",
+			files: map[string][]byte{"cm.yaml": resource},
+		},
+		{
+			name: "unsupported kustomization fields",
+			files: map[string][]byte{
+				"kustomization.yaml": []byte("resources: [cm.yaml]\nnamePrefix: bar-"),
+				"cm.yaml":            resource,
+			},
+		},
+		{
+			name: "strategic merge patches",
+			files: map[string][]byte{
+				"kustomization.yaml": []byte("resources: [cm.yaml]\npatches:\n- patch: |-\n    kind: ConfigMap\n    metadata:\n      name: foo"),
+				"cm.yaml":            resource,
+			},
+		},
+		{
+			name: "patches not matching any object",
+			files: map[string][]byte{
+				"kustomization.yaml": []byte("resources: [cm.yaml]\npatches:\n- target:\n    name: bar\n  patch: |-\n    - op: replace\n      path: /data\n      value: bar"),
+				"cm.yaml":            resource,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := ConvertFromKustomize(tt.files)
+			g.Expect(err).To(HaveOccurred())
+		})
+	}
+}
+
+func TestConvertToHelm(t *testing.T) {
+	g := NewWithT(t)
+
+	files, err := ConvertToHelm([]byte(convertTemplate), "foo")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(HaveLen(3))
+	g.Expect(string(files["Chart.yaml"])).To(ContainSubstring("name: foo"))
+	g.Expect(string(files["values.yaml"])).To(Equal("CLUSTER_NAME: \"\"\nNAMESPACE: default\nPOD_CIDR: 192.168.0.0/16\nWORKER_MACHINE_COUNT: \"3\"\n"))
+
+	template := string(files["templates/cluster-template.yaml"])
+	g.Expect(template).To(ContainSubstring(`name: {{ required "value for CLUSTER_NAME is not set" .Values.CLUSTER_NAME }}`))
+	g.Expect(template).To(ContainSubstring(`namespace: {{ .Values.NAMESPACE }}`))
+	g.Expect(template).To(ContainSubstring(`example.com/note: "{{ "{{" }} not a helm action {{ "}}" }}"`))
+
+	// Converting back returns the original template.
+	got, err := ConvertFromHelm(files)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(got)).To(Equal(convertTemplate))
+}
+
+func TestConvertToHelm_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{
+			name:     "unsupported substitution functions",
+			template: "name: ${CLUSTER_NAME,,}",
+		},
+		{
+			name:     "default values which are not literals",
+			template: "name: ${CLUSTER_NAME:=${NAME}}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := ConvertToHelm([]byte(tt.template), "")
+			g.Expect(err).To(HaveOccurred())
+		})
+	}
+}
+
+func TestConvertFromHelm(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string][]byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "values with defaults from values.yaml",
+			files: map[string][]byte{
+				"values.yaml":         []byte("replicas: 3\nname: \"\""),
+				"templates/foo.yaml":  []byte("name: {{ .Values.name }}\nreplicas: {{ .Values.replicas }}"),
+				"templates/notes.txt": []byte("ignored"),
+			},
+			want: "name: ${name}\nreplicas: ${replicas:=3}",
+		},
+		{
+			name: "values with inline defaults",
+			files: map[string][]byte{
+				"templates/foo.yaml": []byte(`name: {{ .Values.name | default "foo" }}`),
+			},
+			want: "name: ${name:=foo}",
+		},
+		{
+			name: "unsupported actions",
+			files: map[string][]byte{
+				"templates/foo.yaml": []byte(`name: {{ include "name" . }}`),
+			},
+			wantErr: true,
+		},
+		{
+			name:    "missing templates",
+			files:   map[string][]byte{"values.yaml": []byte("name: foo")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := ConvertFromHelm(tt.files)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(got)).To(Equal(tt.want))
+		})
+	}
+}
@@ -26,6 +26,11 @@ type Processor interface {
 	// list of variables that the template requires.
 	GetVariables([]byte) ([]string, error)
 
+	// GetVariableMap parses the template blob of bytes and provides a map of
+	// the variables that the template requires, with their default value or
+	// nil if the variable has no default value.
+	GetVariableMap([]byte) (map[string]*string, error)
+
 	// Process processes the template blob of bytes and will return the final
 	// yaml with values retrieved from the values getter
 	Process([]byte, func(string) (string, error)) ([]byte, error)
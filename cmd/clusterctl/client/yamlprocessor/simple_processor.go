@@ -24,6 +24,7 @@ import (
 
 	"github.com/drone/envsubst"
 	"github.com/drone/envsubst/parse"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
 )
 
 // SimpleProcessor is a yaml processor that uses envsubst to substitute values
@@ -68,6 +69,23 @@ func (tp *SimpleProcessor) GetVariables(rawArtifact []byte) ([]string, error) {
 	return varNames, nil
 }
 
+// GetVariableMap returns a map of the variables specified in the yaml, with
+// their default value or nil if the variable has no default value.
+// Nb. For defaults referencing other variables, e.g. ${var:=${other}}, only the
+// literal parts of the default value are returned.
+func (tp *SimpleProcessor) GetVariableMap(rawArtifact []byte) (map[string]*string, error) {
+	strArtifact := convertLegacyVars(string(rawArtifact))
+
+	t, err := parse.Parse(strArtifact)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := map[string]*string{}
+	traverseDefaults(t.Root, variables)
+	return variables, nil
+}
+
 // Process returns the final yaml with all the variables replaced with their
 // respective values. If there are variables without corresponding values, it
 // will return the raw yaml along with an error.
@@ -118,6 +136,18 @@ func (e *errMissingVariables) Error() string {
 	)
 }
 
+// As sets target and returns true if target is a *messages.ErrVariableMissing, so library consumers can get the
+// name of the first missing variable.
+func (e *errMissingVariables) As(target interface{}) bool {
+	missing, ok := target.(*messages.ErrVariableMissing)
+	if !ok || len(e.Missing) == 0 {
+		return false
+	}
+	sort.Strings(e.Missing)
+	missing.Name = e.Missing[0]
+	return true
+}
+
 // inspectVariables parses through the yaml and returns a map of the variable
 // names and if they have default values. It returns an error if it cannot
 // parse the yaml.
@@ -148,6 +178,33 @@ func traverse(root parse.Node, variables map[string]bool) {
 	}
 }
 
+// traverseDefaults recursively walks down the root node and tracks the variables
+// which are FuncNodes and their default values, if any.
+func traverseDefaults(root parse.Node, variables map[string]*string) {
+	switch v := root.(type) {
+	case *parse.ListNode:
+		for _, ln := range v.Nodes {
+			traverseDefaults(ln, variables)
+		}
+	case *parse.FuncNode:
+		if _, ok := variables[v.Param]; ok {
+			return
+		}
+		variables[v.Param] = nil
+		// if there are args, then the variable has a default value
+		if len(v.Args) > 0 {
+			var b strings.Builder
+			for _, arg := range v.Args {
+				if t, ok := arg.(*parse.TextNode); ok {
+					b.WriteString(t.Value)
+				}
+			}
+			value := b.String()
+			variables[v.Param] = &value
+		}
+	}
+}
+
 // legacyVariableRegEx defines the regexp used for searching variables inside a YAML.
 // It searches for variables with the format ${ VAR}, ${ VAR }, ${VAR }
 var legacyVariableRegEx = regexp.MustCompile(`(\${(\s+([A-Za-z0-9_$]+)\s+)})|(\${(\s+([A-Za-z0-9_$]+))})|(\${(([A-Za-z0-9_$]+)\s+)})`)
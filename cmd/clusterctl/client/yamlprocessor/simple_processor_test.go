@@ -19,7 +19,9 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
 )
 
@@ -99,6 +101,52 @@ func TestSimpleProcessor_GetVariables(t *testing.T) {
 	}
 }
 
+func TestSimpleProcessor_GetVariableMap(t *testing.T) {
+	def := func(s string) *string { return &s }
+
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]*string
+		wantErr bool
+	}{
+		{
+			name: "variables without default values",
+			data: "yaml with ${A} ${ B} ${A}",
+			want: map[string]*string{"A": nil, "B": nil},
+		},
+		{
+			name: "variables with default values",
+			data: "yaml with ${C:=default}\n${B}\n${A=foo-bar}",
+			want: map[string]*string{"A": def("foo-bar"), "B": nil, "C": def("default")},
+		},
+		{
+			name: "variables with empty default values",
+			data: "yaml with ${A:=}",
+			want: map[string]*string{"A": nil},
+		},
+		{
+			name:    "returns error for variables with regex metacharacters",
+			data:    "yaml with ${BA$R}\n${FOO}",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			p := NewSimpleProcessor()
+			actual, err := p.GetVariableMap([]byte(tt.data))
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(actual).To(Equal(tt.want))
+		})
+	}
+}
+
 func TestSimpleProcessor_Process(t *testing.T) {
 	type args struct {
 		yaml                  []byte
@@ -187,6 +235,10 @@ func TestSimpleProcessor_Process(t *testing.T) {
 					e, ok := err.(*errMissingVariables)
 					g.Expect(ok).To(BeTrue())
 					g.Expect(e.Missing).To(ConsistOf(tt.missingVariables))
+
+					var missing messages.ErrVariableMissing
+					g.Expect(errors.As(err, &missing)).To(BeTrue())
+					g.Expect(tt.missingVariables).To(ContainElement(missing.Name))
 				}
 				// we want to ensure that we keep returning the original yaml
 				// as per the intended behavior of Process
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+type adoptOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	dryRun            bool
+}
+
+var ao = &adoptOptions{}
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt",
+	Short: "Add the providers installed without clusterctl to the inventory of the management cluster.",
+	Long: LongDesc(`
+		Add the providers installed without clusterctl, e.g. with kubectl apply, to the inventory of the management cluster,
+		so they can be upgraded, moved and deleted using clusterctl.
+
+		Providers are detected from the Deployments with the cluster.x-k8s.io/provider label; the provider version is
+		inferred from the image tag of the manager container, and the watching namespace from its --namespace flag.`),
+
+	Example: Examples(`
+		# Lists the providers that can be adopted, without changing the management cluster.
+		clusterctl adopt --dry-run
+
+		# Adds the providers installed without clusterctl to the inventory of the management cluster.
+		clusterctl adopt`),
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdopt()
+	},
+}
+
+func init() {
+	adoptCmd.Flags().StringVar(&ao.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If empty, default discovery rules apply.")
+	adoptCmd.Flags().StringVar(&ao.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	adoptCmd.Flags().BoolVar(&ao.dryRun, "dry-run", false,
+		"List the providers to be adopted without changing the management cluster")
+
+	RootCmd.AddCommand(adoptCmd)
+}
+
+func runAdopt() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	providers, err := c.Adopt(client.AdoptOptions{
+		Kubeconfig: client.Kubeconfig{Path: ao.kubeconfig, Context: ao.kubeconfigContext},
+		DryRun:     ao.dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(providers) == 0 {
+		fmt.Println("There are no providers to be adopted.")
+		return nil
+	}
+
+	if ao.dryRun {
+		fmt.Println("The following providers can be adopted:")
+	} else {
+		fmt.Println("The following providers have been adopted:")
+	}
+	fmt.Println("")
+	w := tabwriter.NewWriter(os.Stdout, 10, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tNAMESPACE\tTYPE\tVERSION\tWATCHING NAMESPACE")
+	for _, p := range providers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", p.Name, p.Namespace, p.Type, p.Version, p.WatchedNamespace)
+	}
+	w.Flush()
+	return nil
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+const (
+	completionValuesProviders  = "providers"
+	completionValuesVersions   = "versions"
+	completionValuesFlavors    = "flavors"
+	completionValuesNamespaces = "namespaces"
+)
+
+// bashCompletionFunctions are the bash functions completing the flag values dynamically, using the completion
+// values command; the values of the flags already set, e.g. --infrastructure for --flavor, are read from flaghash.
+const bashCompletionFunctions = `
+__clusterctl_flag_value()
+{
+    echo "${flaghash[$1]:-${flaghash[$1=]}}"
+}
+
+# __clusterctl_complete_values completes the current word, after the given prefix, with the output of the completion values command.
+__clusterctl_complete_values()
+{
+    local prefix="$1" config out v
+    shift
+    config=$(__clusterctl_flag_value --config)
+    if [[ -n "${config}" ]]; then
+        set -- "$@" --config "${config}"
+    fi
+    out=$("${words[0]}" completion values "$@" 2>/dev/null) || return
+    COMPREPLY=( $(compgen -W "$(for v in ${out}; do echo "${prefix}${v}"; done)" -- "${cur}") )
+}
+
+# __clusterctl_complete_provider completes the last item of a comma separated list of providers, using the name[:version] syntax.
+__clusterctl_complete_provider()
+{
+    local prefix="" word="${cur}"
+    if [[ "${word}" == *,* ]]; then
+        prefix="${word%,*},"
+        word="${word##*,}"
+    fi
+    if [[ "${word}" == *:* ]]; then
+        __clusterctl_complete_values "${prefix}" versions --type "$1" "${word%%:*}"
+    else
+        __clusterctl_complete_values "${prefix}" providers --type "$1"
+    fi
+}
+
+__clusterctl_complete_core_provider()
+{
+    __clusterctl_complete_provider CoreProvider
+}
+
+__clusterctl_complete_bootstrap_provider()
+{
+    __clusterctl_complete_provider BootstrapProvider
+}
+
+__clusterctl_complete_control_plane_provider()
+{
+    __clusterctl_complete_provider ControlPlaneProvider
+}
+
+__clusterctl_complete_infrastructure_provider()
+{
+    __clusterctl_complete_provider InfrastructureProvider
+}
+
+__clusterctl_complete_flavor()
+{
+    __clusterctl_complete_values "" flavors "$(__clusterctl_flag_value --infrastructure)"
+}
+
+__clusterctl_complete_namespace()
+{
+    __clusterctl_complete_values "" namespaces --kubeconfig "$(__clusterctl_flag_value --kubeconfig)" --kubeconfig-context "$(__clusterctl_flag_value --kubeconfig-context)"
+}
+`
+
+// completionFlags defines the flags whose values are completed dynamically, and the bash function completing them.
+var completionFlags = []struct {
+	cmd      *cobra.Command
+	flag     string
+	function string
+}{
+	{initCmd, "core", "__clusterctl_complete_core_provider"},
+	{initCmd, "bootstrap", "__clusterctl_complete_bootstrap_provider"},
+	{initCmd, "control-plane", "__clusterctl_complete_control_plane_provider"},
+	{initCmd, "infrastructure", "__clusterctl_complete_infrastructure_provider"},
+	{configClusterClusterCmd, "infrastructure", "__clusterctl_complete_infrastructure_provider"},
+	{configClusterClusterCmd, "flavor", "__clusterctl_complete_flavor"},
+	{configClusterClusterCmd, "target-namespace", "__clusterctl_complete_namespace"},
+	{moveCmd, "namespace", "__clusterctl_complete_namespace"},
+}
+
+type completionValuesOptions struct {
+	providerType      string
+	kubeconfig        string
+	kubeconfigContext string
+}
+
+var cvo = &completionValuesOptions{}
+
+var completionCmd = &cobra.Command{
+	Use:       "completion [bash|zsh]",
+	Short:     "Output shell completion code for the specified shell (bash or zsh).",
+	ValidArgs: []string{"bash", "zsh"},
+	Args:      cobra.ExactValidArgs(1),
+	Long: LongDesc(`
+		Output shell completion code for the specified shell (bash or zsh).
+
+		With bash, the provider names and versions, the flavors and the namespaces are completed
+		dynamically, reading the clusterctl configuration, the provider repositories and the
+		management cluster; the bash-completion package is required.`),
+
+	Example: Examples(`
+		# Load the clusterctl completion code for bash into the current shell.
+		source <(clusterctl completion bash)
+
+		# Load the clusterctl completion code for zsh into the current shell.
+		source <(clusterctl completion zsh)`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCompletion(args[0], os.Stdout)
+	},
+}
+
+var completionValuesCmd = &cobra.Command{
+	Use:    "values [providers|versions PROVIDER|flavors PROVIDER|namespaces]",
+	Short:  "Print the values used for completing the flags, one per line.",
+	Hidden: true,
+	Args:   cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCompletionValues(args, os.Stdout)
+	},
+}
+
+func init() {
+	completionValuesCmd.Flags().StringVar(&cvo.providerType, "type", "",
+		"Type of the providers, e.g. InfrastructureProvider.")
+	completionValuesCmd.Flags().StringVar(&cvo.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig for the management cluster. If unspecified, default discovery rules apply.")
+	completionValuesCmd.Flags().StringVar(&cvo.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+
+	completionCmd.AddCommand(completionValuesCmd)
+	RootCmd.AddCommand(completionCmd)
+}
+
+func runCompletion(shell string, out io.Writer) error {
+	switch shell {
+	case "bash":
+		for _, f := range completionFlags {
+			if err := f.cmd.MarkFlagCustom(f.flag, f.function); err != nil {
+				return errors.Wrapf(err, "failed to set the completion function of the --%s flag of %q", f.flag, f.cmd.CommandPath())
+			}
+		}
+		RootCmd.BashCompletionFunction = bashCompletionFunctions
+		return RootCmd.GenBashCompletion(out)
+	case "zsh":
+		return RootCmd.GenZshCompletion(out)
+	}
+	return errors.Errorf("unsupported shell %q, please use bash or zsh", shell)
+}
+
+func runCompletionValues(args []string, out io.Writer) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	var values []string
+	switch kind := args[0]; kind {
+	case completionValuesProviders:
+		values, err = c.ListProviderNames(client.ListProviderNamesOptions{
+			ProviderType: clusterctlv1.ProviderType(cvo.providerType),
+		})
+	case completionValuesVersions:
+		if len(args) < 2 {
+			return errors.New("the provider name is required for completing versions")
+		}
+		var versions []string
+		versions, err = c.ListProviderVersions(client.ListProviderVersionsOptions{
+			Provider:     args[1],
+			ProviderType: clusterctlv1.ProviderType(cvo.providerType),
+		})
+		// Versions are completed using the name[:version] syntax.
+		for _, v := range versions {
+			values = append(values, fmt.Sprintf("%s:%s", args[1], v))
+		}
+	case completionValuesFlavors:
+		if len(args) < 2 || args[1] == "" {
+			return errors.New("the infrastructure provider is required for completing flavors")
+		}
+		values, err = c.ListFlavors(client.ListFlavorsOptions{
+			InfrastructureProvider: args[1],
+		})
+	case completionValuesNamespaces:
+		values, err = c.ListNamespaces(client.ListNamespacesOptions{
+			Kubeconfig: client.Kubeconfig{Path: cvo.kubeconfig, Context: cvo.kubeconfigContext},
+		})
+	default:
+		return errors.Errorf("invalid completion values %q, valid values are %s, %s, %s and %s", kind,
+			completionValuesProviders, completionValuesVersions, completionValuesFlavors, completionValuesNamespaces)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		fmt.Fprintln(out, v)
+	}
+	return nil
+}
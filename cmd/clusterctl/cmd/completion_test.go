@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_runCompletion(t *testing.T) {
+	t.Run("bash completes the flag values dynamically", func(t *testing.T) {
+		g := NewWithT(t)
+
+		buf := bytes.NewBufferString("")
+		g.Expect(runCompletion("bash", buf)).To(Succeed())
+
+		out := buf.String()
+		g.Expect(out).To(ContainSubstring("__clusterctl_complete_provider()"))
+		for _, f := range completionFlags {
+			g.Expect(out).To(ContainSubstring(`flags_completion+=("%s")`, f.function))
+		}
+	})
+
+	t.Run("zsh", func(t *testing.T) {
+		g := NewWithT(t)
+
+		buf := bytes.NewBufferString("")
+		g.Expect(runCompletion("zsh", buf)).To(Succeed())
+		g.Expect(buf.String()).To(HavePrefix("#compdef"))
+	})
+
+	t.Run("returns error for unsupported shells", func(t *testing.T) {
+		g := NewWithT(t)
+
+		buf := bytes.NewBufferString("")
+		g.Expect(runCompletion("fish", buf)).ToNot(Succeed())
+	})
+}
+
+func Test_runCompletionValues(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir, err := ioutil.TempDir("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "clusterctl.yaml")
+	g.Expect(ioutil.WriteFile(path, []byte(`providers:
+  - name: "my-infra-provider"
+    url: "https://github.com/myorg/myrepo/releases/latest/infrastructure-components.yaml"
+    type: "InfrastructureProvider"
+`), 0600)).To(Succeed())
+
+	defer func(c string) { cfgFile = c }(cfgFile)
+	cfgFile = path
+	defer func(o completionValuesOptions) { *cvo = o }(*cvo)
+
+	t.Run("prints the provider names of the given type", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cvo.providerType = "InfrastructureProvider"
+		buf := bytes.NewBufferString("")
+		g.Expect(runCompletionValues([]string{completionValuesProviders}, buf)).To(Succeed())
+
+		names := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		g.Expect(names).To(ContainElement("my-infra-provider"))
+		g.Expect(names).NotTo(ContainElement("kubeadm"))
+	})
+
+	t.Run("returns error for invalid values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		buf := bytes.NewBufferString("")
+		g.Expect(runCompletionValues([]string{"clusters"}, buf)).ToNot(Succeed())
+		g.Expect(runCompletionValues([]string{completionValuesVersions}, buf)).ToNot(Succeed())
+		g.Expect(runCompletionValues([]string{completionValuesFlavors}, buf)).ToNot(Succeed())
+	})
+}
@@ -18,11 +18,13 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
 )
 
 type configClusterOptions struct {
@@ -41,7 +43,11 @@ type configClusterOptions struct {
 	configMapName      string
 	configMapDataKey   string
 
-	listVariables bool
+	patchFiles []string
+
+	listVariables   bool
+	validate        bool
+	createNamespace bool
 }
 
 var cc = &configClusterOptions{}
@@ -84,7 +90,14 @@ var configClusterClusterCmd = &cobra.Command{
 		clusterctl config cluster my-cluster --from https://github.com/foo-org/foo-repository/blob/master/cluster-template.yaml
 
 		# Generates a configuration file for creating workload clusters using a template stored locally.
-		clusterctl config cluster my-cluster --from ~/workspace/cluster-template.yaml`),
+		clusterctl config cluster my-cluster --from ~/workspace/cluster-template.yaml
+
+		# Generates a configuration file for creating workload clusters, validating it against the management cluster.
+		clusterctl config cluster my-cluster --validate
+
+		# Generates a configuration file for creating workload clusters, applying the
+		# strategic merge patches defined in a local file to the template objects.
+		clusterctl config cluster my-cluster --patch-file ~/workspace/patches.yaml`),
 
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -126,9 +139,17 @@ func init() {
 	configClusterClusterCmd.Flags().StringVar(&cc.configMapDataKey, "from-config-map-key", "",
 		fmt.Sprintf("The ConfigMap.Data key where the workload cluster template is hosted. If unspecified, %q will be used", client.DefaultCustomTemplateConfigMapKey))
 
+	// flags for customizing the template
+	configClusterClusterCmd.Flags().StringSliceVar(&cc.patchFiles, "patch-file", nil,
+		"A file containing one or more strategic merge patches to be applied to the template objects; each patch selects the object to patch by apiVersion, kind and metadata.name. Can be repeated")
+
 	// other flags
 	configClusterClusterCmd.Flags().BoolVar(&cc.listVariables, "list-variables", false,
 		"Returns the list of variables expected by the template instead of the template yaml")
+	configClusterClusterCmd.Flags().BoolVar(&cc.validate, "validate", false,
+		"Validates the template objects with a server-side dry-run against the management cluster before returning the template yaml")
+	configClusterClusterCmd.Flags().BoolVar(&cc.createNamespace, "create-namespace", false,
+		"Creates the target namespace in the management cluster if it does not exist, so the template yaml can be applied right away")
 
 	configCmd.AddCommand(configClusterClusterCmd)
 }
@@ -140,11 +161,13 @@ func runGetClusterTemplate(cmd *cobra.Command, name string) error {
 	}
 
 	templateOptions := client.GetClusterTemplateOptions{
-		Kubeconfig:        client.Kubeconfig{Path: cc.kubeconfig, Context: cc.kubeconfigContext},
-		ClusterName:       name,
-		TargetNamespace:   cc.targetNamespace,
-		KubernetesVersion: cc.kubernetesVersion,
-		ListVariablesOnly: cc.listVariables,
+		Kubeconfig:         client.Kubeconfig{Path: cc.kubeconfig, Context: cc.kubeconfigContext},
+		ClusterName:        name,
+		TargetNamespace:    cc.targetNamespace,
+		KubernetesVersion:  cc.kubernetesVersion,
+		ListVariablesOnly:  cc.listVariables,
+		ValidateWithDryRun: cc.validate,
+		CreateNamespace:    cc.createNamespace,
 	}
 
 	if cmd.Flags().Changed("control-plane-machine-count") {
@@ -175,6 +198,14 @@ func runGetClusterTemplate(cmd *cobra.Command, name string) error {
 		}
 	}
 
+	for _, f := range cc.patchFiles {
+		patches, err := readTemplatePatchFile(f)
+		if err != nil {
+			return err
+		}
+		templateOptions.Patches = append(templateOptions.Patches, patches...)
+	}
+
 	template, err := c.GetClusterTemplate(templateOptions)
 	if err != nil {
 		return err
@@ -187,6 +218,32 @@ func runGetClusterTemplate(cmd *cobra.Command, name string) error {
 	return templateYAMLOutput(template)
 }
 
+// readTemplatePatchFile returns a strategic merge patch for each of the YAML documents in a file.
+func readTemplatePatchFile(path string) ([]client.TemplatePatch, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read patch file %q", path)
+	}
+
+	objs, err := utilyaml.ToUnstructured(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse patch file %q", path)
+	}
+
+	patches := make([]client.TemplatePatch, 0, len(objs))
+	for i := range objs {
+		patch, err := objs[i].MarshalJSON()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read patch %d from file %q", i+1, path)
+		}
+		patches = append(patches, client.TemplatePatch{
+			Type:  client.StrategicMergeTemplatePatch,
+			Patch: patch,
+		})
+	}
+	return patches, nil
+}
+
 func templateListVariablesOutput(template client.Template) error {
 	if len(template.Variables()) > 0 {
 		fmt.Println("Variables:")
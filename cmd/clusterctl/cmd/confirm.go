@@ -0,0 +1,112 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+// errNotTerminal is returned by destructive commands when the action cannot be confirmed interactively.
+var errNotTerminal = errors.New("stdin is not a terminal, use --yes to confirm the operation")
+
+// confirmPrompt asks the user to confirm the actions of a destructive command.
+type confirmPrompt struct {
+	assumeYes  bool
+	in         io.Reader
+	out        io.Writer
+	isTerminal func() bool
+
+	// err is the reason why the action could not be confirmed, e.g. stdin is not a terminal.
+	err error
+}
+
+// newConfirmPrompt returns a confirmPrompt reading the answers from stdin; if assumeYes is set, no confirmation is required.
+func newConfirmPrompt(assumeYes bool) *confirmPrompt {
+	return &confirmPrompt{
+		assumeYes:  assumeYes,
+		in:         os.Stdin,
+		out:        os.Stdout,
+		isTerminal: stdinIsTerminal,
+	}
+}
+
+// confirmFunc returns the func used by destructive commands for asking the user to confirm an action, or nil if
+// no confirmation is required.
+func (p *confirmPrompt) confirmFunc() func(action string) bool {
+	if p.assumeYes {
+		return nil
+	}
+	return func(action string) bool {
+		if !p.isTerminal() {
+			p.err = errNotTerminal
+			return false
+		}
+		confirmed, err := promptConfirm(p.in, p.out, action)
+		if err != nil {
+			p.err = err
+		}
+		return confirmed
+	}
+}
+
+// ignoreNotConfirmed returns nil if the operation was not executed because the user did not confirm it; if the
+// action could not be confirmed, e.g. because stdin is not a terminal or was closed, the error is returned instead, so
+// the command exits with a non-zero code.
+func (p *confirmPrompt) ignoreNotConfirmed(err error) error {
+	if errors.Cause(err) == client.ErrOperationNotConfirmed {
+		if p.err != nil {
+			return p.err
+		}
+		fmt.Fprintln(p.out, "Operation canceled")
+		return nil
+	}
+	return err
+}
+
+// promptConfirm prints the action and reads the user answer; only "y" or "yes" confirm the action.
+// An error is returned if no answer can be read, e.g. because the input was closed.
+func promptConfirm(in io.Reader, out io.Writer, action string) (bool, error) {
+	fmt.Fprintf(out, "%s.\nDo you want to continue? [y/N]: ", action)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(out)
+		return false, errors.Wrap(err, "failed to read the answer, use --yes to confirm the operation")
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// stdinIsTerminal returns true if stdin is a terminal, and not e.g. a pipe or a file.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
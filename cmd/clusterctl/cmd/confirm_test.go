@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+func Test_promptConfirm(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "confirms on y",
+			answer: "y\n",
+			want:   true,
+		},
+		{
+			name:   "confirms on yes, ignoring case and spaces",
+			answer: " Yes \n",
+			want:   true,
+		},
+		{
+			name:   "does not confirm on an empty answer",
+			answer: "\n",
+			want:   false,
+		},
+		{
+			name:   "does not confirm on no",
+			answer: "n\n",
+			want:   false,
+		},
+		{
+			name:    "fails when the input is closed",
+			answer:  "",
+			want:    false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			out := &bytes.Buffer{}
+			got, err := promptConfirm(strings.NewReader(tt.answer), out, "Delete the providers capi-system/cluster-api")
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(out.String()).To(HavePrefix("Delete the providers capi-system/cluster-api.\nDo you want to continue? [y/N]: "))
+		})
+	}
+}
+
+func Test_confirmPrompt(t *testing.T) {
+	notConfirmed := errors.Wrap(client.ErrOperationNotConfirmed, "Delete the providers capi-system/cluster-api")
+
+	tests := []struct {
+		name       string
+		answer     string
+		isTerminal bool
+		wantErr    error
+	}{
+		{
+			name:       "cancels the operation when the user does not confirm",
+			answer:     "n\n",
+			isTerminal: true,
+		},
+		{
+			name:       "fails when stdin is not a terminal",
+			answer:     "y\n",
+			isTerminal: false,
+			wantErr:    errNotTerminal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			out := &bytes.Buffer{}
+			p := &confirmPrompt{
+				in:         strings.NewReader(tt.answer),
+				out:        out,
+				isTerminal: func() bool { return tt.isTerminal },
+			}
+			g.Expect(p.confirmFunc()("Delete the providers capi-system/cluster-api")).To(BeFalse())
+			err := p.ignoreNotConfirmed(notConfirmed)
+			if tt.wantErr == nil {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(out.String()).To(HaveSuffix("Operation canceled\n"))
+				return
+			}
+			g.Expect(err).To(MatchError(tt.wantErr))
+		})
+	}
+
+	t.Run("fails when stdin is closed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		p := &confirmPrompt{
+			in:         strings.NewReader(""),
+			out:        &bytes.Buffer{},
+			isTerminal: func() bool { return true },
+		}
+		g.Expect(p.confirmFunc()("Delete the providers capi-system/cluster-api")).To(BeFalse())
+		g.Expect(p.ignoreNotConfirmed(notConfirmed)).To(HaveOccurred())
+	})
+
+	t.Run("does not ask for confirmation with --yes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(newConfirmPrompt(true).confirmFunc()).To(BeNil())
+		g.Expect(newConfirmPrompt(false).confirmFunc()).NotTo(BeNil())
+	})
+}
@@ -17,9 +17,15 @@ limitations under the License.
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 )
 
 type deleteOptions struct {
@@ -33,6 +39,9 @@ type deleteOptions struct {
 	includeNamespace        bool
 	includeCRDs             bool
 	deleteAll               bool
+	yes                     bool
+	forceLock               bool
+	dryRun                  bool
 }
 
 var dd = &deleteOptions{}
@@ -80,6 +89,10 @@ var deleteCmd = &cobra.Command{
 		# Cluster API Providers are orphaned and there might be ongoing costs incurred as a result of this.
 		clusterctl delete --infrastructure aws --include-namespace
 
+		# Shows the objects that would be deleted with the AWS provider and its CRDs, without deleting them;
+		# add -v 1 for listing every object instead of a summary.
+		clusterctl delete --infrastructure aws --include-crd --dry-run
+
 		# Reset the management cluster to its original state
 		# Important! As a consequence of this operation all the corresponding resources on target clouds
 		# are "orphaned" and thus there may be ongoing costs incurred as a result of this.
@@ -113,6 +126,12 @@ func init() {
 
 	deleteCmd.Flags().BoolVar(&dd.deleteAll, "all", false,
 		"Force deletion of all the providers")
+	deleteCmd.Flags().BoolVarP(&dd.yes, "yes", "y", false,
+		"Delete the providers without asking for confirmation")
+	deleteCmd.Flags().BoolVar(&dd.dryRun, "dry-run", false,
+		"Show the objects that would be deleted without deleting them. Use -v 1 or higher to list every object instead of a summary.")
+	deleteCmd.Flags().BoolVar(&dd.forceLock, "force-lock", false,
+		"Force the operation even if the management cluster is locked by another clusterctl operation, e.g. when the lock has been left behind by a failed operation.")
 
 	RootCmd.AddCommand(deleteCmd)
 }
@@ -136,7 +155,8 @@ func runDelete() error {
 		return errors.New("At least one of --core, --bootstrap, --control-plane, --infrastructure should be specified or the --all flag should be set")
 	}
 
-	if err := c.Delete(client.DeleteOptions{
+	prompt := newConfirmPrompt(dd.yes)
+	plan, err := c.Delete(client.DeleteOptions{
 		Kubeconfig:              client.Kubeconfig{Path: dd.kubeconfig, Context: dd.kubeconfigContext},
 		IncludeNamespace:        dd.includeNamespace,
 		IncludeCRDs:             dd.includeCRDs,
@@ -146,9 +166,52 @@ func runDelete() error {
 		InfrastructureProviders: dd.infrastructureProviders,
 		ControlPlaneProviders:   dd.controlPlaneProviders,
 		DeleteAll:               dd.deleteAll,
-	}); err != nil {
+		Confirm:                 prompt.confirmFunc(),
+		ForceLock:               dd.forceLock,
+		DryRun:                  dd.dryRun,
+	})
+	if err != nil {
+		return prompt.ignoreNotConfirmed(err)
+	}
+
+	if dd.dryRun {
+		return printDeletePlan(os.Stdout, plan, verbosity != nil && *verbosity >= 1)
+	}
+	return nil
+}
+
+// printDeletePlan prints the objects that would be deleted: a summary for each provider, or every object if verbose.
+func printDeletePlan(out io.Writer, plan *client.DeletePlan, verbose bool) error {
+	w := tabwriter.NewWriter(out, 10, 4, 3, ' ', 0)
+	if verbose {
+		fmt.Fprintln(w, "PROVIDER\tKIND\tNAMESPACE\tNAME")
+		for _, p := range plan.Providers {
+			for _, objs := range [][]cluster.DeletePlanObject{p.Namespaces, p.CustomResourceDefinitions, p.Webhooks, p.Resources} {
+				for _, o := range objs {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Provider, o.Kind, o.Namespace, o.Name)
+				}
+			}
+		}
+	} else {
+		fmt.Fprintln(w, "PROVIDER\tVERSION\tNAMESPACES\tCRDS\tWEBHOOKS\tRESOURCES")
+		for _, p := range plan.Providers {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\n", p.Provider, p.Version, len(p.Namespaces), len(p.CustomResourceDefinitions), len(p.Webhooks), len(p.Resources))
+		}
+	}
+	if err := w.Flush(); err != nil {
 		return err
 	}
 
+	count := 0
+	for i := range plan.Providers {
+		count += (*cluster.ProviderDeletePlan)(&plan.Providers[i]).Count()
+	}
+	fmt.Fprintf(out, "\n%d objects would be deleted.\n", count)
+	if len(plan.Warnings) > 0 {
+		fmt.Fprintln(out, "\nWarnings:")
+		for _, warning := range plan.Warnings {
+			fmt.Fprintf(out, "  - %s\n", warning)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,73 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+func Test_printDeletePlan(t *testing.T) {
+	plan := &client.DeletePlan{
+		Providers: []client.ProviderDeletePlan{
+			{
+				Provider: "capa-system/infrastructure-aws",
+				Version:  "v0.5.0",
+				CustomResourceDefinitions: []cluster.DeletePlanObject{
+					{APIVersion: "apiextensions.k8s.io/v1", Kind: "CustomResourceDefinition", Name: "awsclusters.infrastructure.cluster.x-k8s.io"},
+				},
+				Resources: []cluster.DeletePlanObject{
+					{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "capa-system", Name: "capa-controller-manager"},
+				},
+			},
+		},
+		Warnings: []string{"The CRDs and the webhooks of the infrastructure-aws provider are shared with the instances in the capa-system-2 namespaces"},
+	}
+
+	t.Run("prints a summary", func(t *testing.T) {
+		g := NewWithT(t)
+
+		buf := &bytes.Buffer{}
+		g.Expect(printDeletePlan(buf, plan, false)).To(Succeed())
+		g.Expect(buf.String()).To(Equal(`PROVIDER                         VERSION   NAMESPACES   CRDS      WEBHOOKS   RESOURCES
+capa-system/infrastructure-aws   v0.5.0    0            1         0          1
+
+2 objects would be deleted.
+
+Warnings:
+  - The CRDs and the webhooks of the infrastructure-aws provider are shared with the instances in the capa-system-2 namespaces
+`))
+	})
+
+	t.Run("prints every object if verbose", func(t *testing.T) {
+		g := NewWithT(t)
+
+		buf := &bytes.Buffer{}
+		g.Expect(printDeletePlan(buf, plan, true)).To(Succeed())
+		g.Expect(buf.String()).To(HavePrefix(`PROVIDER                         KIND                       NAMESPACE     NAME
+capa-system/infrastructure-aws   CustomResourceDefinition                 awsclusters.infrastructure.cluster.x-k8s.io
+capa-system/infrastructure-aws   Deployment                 capa-system   capa-controller-manager
+
+2 objects would be deleted.
+`))
+	})
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+type generateTemplateOptions struct {
+	from       string
+	fromFormat string
+	toFormat   string
+	outputDir  string
+	chartName  string
+}
+
+var gtOpts = &generateTemplateOptions{}
+
+var generateTemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Convert a cluster template between the envsubst, kustomize and helm formats",
+	Long: LongDesc(`
+		Convert a cluster template between the envsubst, kustomize and helm formats.
+
+		The envsubst format is a single yaml file using ${VAR} variables, as processed by clusterctl.
+		The kustomize format is a base with the default values of the variables, and a kustomization
+		patching the base with the variables. The helm format is a chart reading the variables from
+		the chart values.
+
+		Templates in the kustomize and helm formats are read from a directory, and only the kustomizations
+		and the charts generated by clusterctl, or following the same patterns, can be converted back.`),
+
+	Example: Examples(`
+		# Converts a local template into a kustomization.
+		clusterctl generate template --from ~/workspace/cluster-template.yaml --to-format kustomize --output-dir ./my-cluster
+
+		# Converts a local template into a Helm chart.
+		clusterctl generate template --from ~/workspace/cluster-template.yaml --to-format helm --chart-name my-cluster --output-dir ./my-cluster
+
+		# Converts a Helm chart back into a template, printing it to stdout.
+		clusterctl generate template --from ./my-cluster --from-format helm --to-format envsubst`),
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return generateTemplate(os.Stdout)
+	},
+}
+
+func init() {
+	generateTemplateCmd.Flags().StringVar(&gtOpts.from, "from", "",
+		"The file of a template in the envsubst format, or the directory of a template in the kustomize or helm format.")
+	generateTemplateCmd.Flags().StringVar(&gtOpts.fromFormat, "from-format", string(client.EnvsubstTemplateFormat),
+		"The format of the template to convert. One of envsubst, kustomize or helm.")
+	generateTemplateCmd.Flags().StringVar(&gtOpts.toFormat, "to-format", "",
+		"The format of the converted template. One of envsubst, kustomize or helm.")
+	generateTemplateCmd.Flags().StringVar(&gtOpts.outputDir, "output-dir", "",
+		"The directory where the files of the converted template are written. It can be omitted when converting to the envsubst format, and the template is printed to stdout.")
+	generateTemplateCmd.Flags().StringVar(&gtOpts.chartName, "chart-name", "",
+		"The name of the chart generated when converting to the helm format. If unspecified, cluster-template is used.")
+
+	generateCmd.AddCommand(generateTemplateCmd)
+}
+
+func generateTemplate(w io.Writer) error {
+	if gtOpts.from == "" {
+		return errors.New("please specify the template to convert using --from")
+	}
+	if gtOpts.toFormat == "" {
+		return errors.New("please specify the format of the converted template using --to-format")
+	}
+	if gtOpts.outputDir == "" && client.TemplateFormat(gtOpts.toFormat) != client.EnvsubstTemplateFormat {
+		return errors.Errorf("please specify the directory for the %s template using --output-dir", gtOpts.toFormat)
+	}
+
+	files, err := readTemplateFiles(gtOpts.from)
+	if err != nil {
+		return err
+	}
+
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+	converted, err := c.ConvertTemplate(client.ConvertTemplateOptions{
+		Files:     files,
+		From:      client.TemplateFormat(gtOpts.fromFormat),
+		To:        client.TemplateFormat(gtOpts.toFormat),
+		ChartName: gtOpts.chartName,
+	})
+	if err != nil {
+		return err
+	}
+
+	if gtOpts.outputDir == "" {
+		for _, content := range converted {
+			if _, err := fmt.Fprintln(w, string(content)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return writeTemplateFiles(w, gtOpts.outputDir, converted)
+}
+
+// readTemplateFiles reads a template file, or all the files in a template directory, keyed by their path
+// relative to the directory.
+func readTemplateFiles(from string) (map[string][]byte, error) {
+	info, err := os.Stat(from)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	if !info.IsDir() {
+		content, err := ioutil.ReadFile(from)
+		if err != nil {
+			return nil, err
+		}
+		files[filepath.Base(from)] = content
+		return files, nil
+	}
+
+	err = filepath.Walk(from, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the template files in %s", from)
+	}
+	return files, nil
+}
+
+// writeTemplateFiles writes the files of a template in the output directory.
+func writeTemplateFiles(w io.Writer, outputDir string, files map[string][]byte) error {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		path := filepath.Join(outputDir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return errors.Wrapf(err, "failed to create the directory for %s", path)
+		}
+		if err := ioutil.WriteFile(path, files[p], 0644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", path)
+		}
+		fmt.Fprintf(w, "Wrote %s\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_generateTemplate(t *testing.T) {
+	g := NewWithT(t)
+
+	template, cleanup := createTempFile(g, "kind: ConfigMap\nmetadata:\n  name: ${NAME:=foo}")
+	defer cleanup()
+
+	outputDir, err := ioutil.TempDir("", "clusterctl")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(outputDir)
+
+	// converts the template into a kustomization.
+	gtOpts = &generateTemplateOptions{from: template, fromFormat: "envsubst", toFormat: "kustomize", outputDir: outputDir}
+	buf := &bytes.Buffer{}
+	g.Expect(generateTemplate(buf)).To(Succeed())
+	g.Expect(filepath.Join(outputDir, "kustomization.yaml")).To(BeAnExistingFile())
+	g.Expect(filepath.Join(outputDir, "base", "kustomization.yaml")).To(BeAnExistingFile())
+	g.Expect(filepath.Join(outputDir, "base", "cluster-template.yaml")).To(BeAnExistingFile())
+
+	// converts the kustomization back into a template.
+	gtOpts = &generateTemplateOptions{from: outputDir, fromFormat: "kustomize", toFormat: "envsubst"}
+	buf = &bytes.Buffer{}
+	g.Expect(generateTemplate(buf)).To(Succeed())
+	g.Expect(buf.String()).To(Equal("kind: ConfigMap\nmetadata:\n  name: ${NAME:=foo}\n"))
+
+	// requires an output directory for the kustomize and helm formats.
+	gtOpts = &generateTemplateOptions{from: template, fromFormat: "envsubst", toFormat: "helm"}
+	g.Expect(generateTemplate(buf)).NotTo(Succeed())
+}
@@ -18,7 +18,10 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
 )
@@ -32,7 +35,16 @@ type initOptions struct {
 	infrastructureProviders []string
 	targetNamespace         string
 	watchingNamespace       string
+	namespaceLabels         map[string]string
+	namespaceAnnotations    map[string]string
+	featureGates            map[string]string
+	specFile                string
+	lockFile                string
+	refreshLock             bool
+	waitProviders           bool
+	waitProviderTimeout     time.Duration
 	listImages              bool
+	forceLock               bool
 }
 
 var initOpts = &initOptions{}
@@ -79,6 +91,22 @@ var initCmd = &cobra.Command{
 		# Initialize a management cluster with a custom watching namespace for the given provider.
 		clusterctl init --infrastructure aws --watching-namespace=foo
 
+		# Initialize a management cluster with labels for the target namespaces created by clusterctl.
+		clusterctl init --infrastructure aws --namespace-labels pod-security.kubernetes.io/enforce=privileged
+
+		# Initialize a management cluster and wait for the providers to be ready.
+		clusterctl init --infrastructure aws --wait-providers --wait-provider-timeout 10m
+
+		# Initialize a management cluster with the providers, versions, namespaces and variables declared in a spec file.
+		clusterctl init --spec-file init-spec.yaml
+
+		# Initialize a management cluster with the provider versions pinned in a lock file; if the lock file does
+		# not exist, it is created with the provider versions resolved by init, e.g. the latest releases.
+		clusterctl init --infrastructure aws --lock-file clusterctl-lock.yaml
+
+		# Initialize a management cluster with the latest provider releases, and update the lock file.
+		clusterctl init --infrastructure aws --lock-file clusterctl-lock.yaml --refresh-lock
+
 		# Lists the container images required for initializing the management cluster.
 		#
 		# Note: This command is a dry-run; it won't perform any action other than printing to screen.
@@ -106,6 +134,24 @@ func init() {
 		"The target namespace where the providers should be deployed. If unspecified, the provider components' default namespace is used.")
 	initCmd.Flags().StringVar(&initOpts.watchingNamespace, "watching-namespace", "",
 		"Namespace the providers should watch when reconciling objects. If unspecified, all namespaces are watched.")
+	initCmd.Flags().StringToStringVar(&initOpts.namespaceLabels, "namespace-labels", nil,
+		"Labels to be added to the target namespaces created by clusterctl (e.g. pod-security.kubernetes.io/enforce=privileged). Existing namespaces are not changed.")
+	initCmd.Flags().StringToStringVar(&initOpts.namespaceAnnotations, "namespace-annotations", nil,
+		"Annotations to be added to the target namespaces created by clusterctl. Existing namespaces are not changed.")
+	initCmd.Flags().StringToStringVar(&initOpts.featureGates, "feature-gates", nil,
+		"Feature gates to be set on the provider controllers supporting them (e.g. MachinePool=true). The feature gates are preserved when upgrading the providers.")
+	initCmd.Flags().StringVar(&initOpts.specFile, "spec-file", "",
+		"Path to a file declaring the providers, versions, namespaces and variables to be used for initializing the management cluster. It can not be used together with the provider and namespace flags.")
+	initCmd.Flags().StringVar(&initOpts.lockFile, "lock-file", "",
+		"Path to a file pinning the provider versions and the digests of their components installed by init; upgrades ignore it. If the file does not exist, it is created with the provider versions resolved by init.")
+	initCmd.Flags().BoolVar(&initOpts.refreshLock, "refresh-lock", false,
+		"Resolve the provider versions again, ignoring the versions pinned in the lock file, and update the lock file.")
+	initCmd.Flags().BoolVar(&initOpts.waitProviders, "wait-providers", false,
+		"Wait for the CRDs of each provider to be established and for its controllers to be available before returning.")
+	initCmd.Flags().DurationVar(&initOpts.waitProviderTimeout, "wait-provider-timeout", 5*time.Minute,
+		"The maximum time to wait for each provider to be ready when --wait-providers is set.")
+	initCmd.Flags().BoolVar(&initOpts.forceLock, "force-lock", false,
+		"Force the operation even if the management cluster is locked by another clusterctl operation, e.g. when the lock has been left behind by a failed operation.")
 
 	// TODO: Move this to a sub-command or similar, it shouldn't really be a flag.
 	initCmd.Flags().BoolVar(&initOpts.listImages, "list-images", false,
@@ -120,6 +166,11 @@ func runInit() error {
 		return err
 	}
 
+	featureGates, err := parseFeatureGates(initOpts.featureGates)
+	if err != nil {
+		return err
+	}
+
 	options := client.InitOptions{
 		Kubeconfig:              client.Kubeconfig{Path: initOpts.kubeconfig, Context: initOpts.kubeconfigContext},
 		CoreProvider:            initOpts.coreProvider,
@@ -128,7 +179,16 @@ func runInit() error {
 		InfrastructureProviders: initOpts.infrastructureProviders,
 		TargetNamespace:         initOpts.targetNamespace,
 		WatchingNamespace:       initOpts.watchingNamespace,
+		NamespaceLabels:         initOpts.namespaceLabels,
+		NamespaceAnnotations:    initOpts.namespaceAnnotations,
+		FeatureGates:            featureGates,
+		SpecFile:                initOpts.specFile,
+		LockFile:                initOpts.lockFile,
+		RefreshLock:             initOpts.refreshLock,
+		WaitProviders:           initOpts.waitProviders,
+		WaitProviderTimeout:     initOpts.waitProviderTimeout,
 		LogUsageInstructions:    true,
+		ForceLock:               initOpts.forceLock,
 	}
 
 	if initOpts.listImages {
@@ -148,3 +208,20 @@ func runInit() error {
 	}
 	return nil
 }
+
+// parseFeatureGates converts the values of the --feature-gates flag into booleans.
+func parseFeatureGates(gates map[string]string) (map[string]bool, error) {
+	if len(gates) == 0 {
+		return nil, nil
+	}
+
+	featureGates := map[string]bool{}
+	for name, value := range gates {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.Errorf("invalid value %q for the feature gate %q, it must be true or false", value, name)
+		}
+		featureGates[name] = enabled
+	}
+	return featureGates, nil
+}
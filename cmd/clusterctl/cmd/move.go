@@ -17,9 +17,28 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+const (
+	// MovePlanOutputText is an option used to print the move plan in text format.
+	MovePlanOutputText = "text"
+	// MovePlanOutputJSON is an option used to print the move plan in json format.
+	MovePlanOutputJSON = "json"
+)
+
+var (
+	// MovePlanOutputs is a list of valid move plan outputs.
+	MovePlanOutputs = []string{MovePlanOutputText, MovePlanOutputJSON}
 )
 
 type moveOptions struct {
@@ -28,6 +47,12 @@ type moveOptions struct {
 	toKubeconfig          string
 	toKubeconfigContext   string
 	namespace             string
+	clusterName           string
+	yes                   bool
+	forceLock             bool
+	sync                  bool
+	plan                  bool
+	output                string
 }
 
 var mo = &moveOptions{}
@@ -42,9 +67,22 @@ var moveCmd = &cobra.Command{
 
 	Example: Examples(`
 		Move Cluster API objects and all dependencies between management clusters.
-		clusterctl move --to-kubeconfig=target-kubeconfig.yaml`),
+		clusterctl move --to-kubeconfig=target-kubeconfig.yaml
+
+		Move only the my-cluster Cluster and all its dependencies, leaving other Clusters in the namespace in place.
+		clusterctl move --to-kubeconfig=target-kubeconfig.yaml --cluster-name=my-cluster
+
+		Copy the Cluster API objects changed since the last sync to the destination management cluster, without pausing
+		or deleting them in the source management cluster; a move without --sync performs the final cutover.
+		clusterctl move --to-kubeconfig=target-kubeconfig.yaml --sync
+
+		Print which objects would be moved and which would stay in the source management cluster, without moving anything.
+		clusterctl move --cluster-name=my-cluster --plan -o json`),
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if mo.plan {
+			return runMovePlan(os.Stdout)
+		}
 		return runMove()
 	},
 }
@@ -60,6 +98,20 @@ func init() {
 		"Context to be used within the kubeconfig file for the destination management cluster. If empty, current context will be used.")
 	moveCmd.Flags().StringVarP(&mo.namespace, "namespace", "n", "",
 		"The namespace where the workload cluster is hosted. If unspecified, the current context's namespace is used.")
+	moveCmd.Flags().StringVar(&mo.clusterName, "cluster-name", "",
+		"The name of the Cluster to move. If unspecified, all the Clusters in the namespace are moved.")
+	moveCmd.Flags().BoolVarP(&mo.yes, "yes", "y", false,
+		"Move the objects without asking for confirmation")
+	moveCmd.Flags().BoolVar(&mo.forceLock, "force-lock", false,
+		"Force the operation even if the management cluster is locked by another clusterctl operation, e.g. when the lock has been left behind by a failed operation.")
+
+	moveCmd.Flags().BoolVar(&mo.sync, "sync", false,
+		"Copy the objects changed since the last sync to the destination management cluster, without pausing or deleting them in the source management cluster.")
+
+	moveCmd.Flags().BoolVar(&mo.plan, "plan", false,
+		"Print the objects that would be moved and the objects that would stay in the source management cluster, with the reason for each of them, without moving anything.")
+	moveCmd.Flags().StringVarP(&mo.output, "output", "o", MovePlanOutputText,
+		fmt.Sprintf("Output format of the plan. Valid values: %v.", MovePlanOutputs))
 
 	RootCmd.AddCommand(moveCmd)
 }
@@ -74,12 +126,75 @@ func runMove() error {
 		return err
 	}
 
+	prompt := newConfirmPrompt(mo.yes)
 	if err := c.Move(client.MoveOptions{
 		FromKubeconfig: client.Kubeconfig{Path: mo.fromKubeconfig, Context: mo.fromKubeconfigContext},
 		ToKubeconfig:   client.Kubeconfig{Path: mo.toKubeconfig, Context: mo.toKubeconfigContext},
 		Namespace:      mo.namespace,
+		ClusterName:    mo.clusterName,
+		Confirm:        prompt.confirmFunc(),
+		ForceLock:      mo.forceLock,
+		Sync:           mo.sync,
 	}); err != nil {
+		return prompt.ignoreNotConfirmed(err)
+	}
+	return nil
+}
+
+func runMovePlan(out io.Writer) error {
+	if mo.output != MovePlanOutputText && mo.output != MovePlanOutputJSON {
+		return errors.Errorf("Invalid output format %q. Valid values: %v.", mo.output, MovePlanOutputs)
+	}
+
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	plan, err := c.PlanMove(client.PlanMoveOptions{
+		FromKubeconfig: client.Kubeconfig{Path: mo.fromKubeconfig, Context: mo.fromKubeconfigContext},
+		Namespace:      mo.namespace,
+		ClusterName:    mo.clusterName,
+	})
+	if err != nil {
+		return err
+	}
+	return printMovePlan(out, plan, mo.output)
+}
+
+// printMovePlan prints a move plan in the given output format.
+func printMovePlan(out io.Writer, plan *client.MovePlan, output string) error {
+	if output == MovePlanOutputJSON {
+		j, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the move plan")
+		}
+		fmt.Fprintln(out, string(j))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 10, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tKIND\tNAME\tACTION\tREASON")
+	for _, g := range plan.Groups {
+		for _, o := range g.Objects {
+			action := "stay"
+			if o.Move {
+				action = "move"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", g.Namespace, g.Kind, o.Name, action, o.Reason)
+		}
+	}
+	if err := w.Flush(); err != nil {
 		return err
 	}
+
+	move, stay := (*cluster.MovePlan)(plan).Count()
+	fmt.Fprintf(out, "\n%d objects would be moved, %d objects would stay in the source management cluster.\n", move, stay)
+	if len(plan.Blockers) > 0 {
+		fmt.Fprintln(out, "\nThe move would fail:")
+		for _, b := range plan.Blockers {
+			fmt.Fprintf(out, "  - %s\n", b)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+)
+
+func Test_printMovePlan(t *testing.T) {
+	plan := &client.MovePlan{
+		Namespace: "ns1",
+		Groups: []cluster.MovePlanGroup{
+			{
+				Namespace:  "ns1",
+				APIVersion: "cluster.x-k8s.io/v1alpha3",
+				Kind:       "Cluster",
+				Objects: []cluster.MovePlanObject{
+					{Name: "foo", Move: true, Reason: "belongs to Cluster foo"},
+				},
+			},
+			{
+				Namespace:  "ns1",
+				APIVersion: "v1",
+				Kind:       "Secret",
+				Objects: []cluster.MovePlanObject{
+					{Name: "unrelated", Move: false, Reason: "does not belong to any Cluster or ClusterResourceSet"},
+				},
+			},
+		},
+		Blockers: []string{"cannot start the move operation while the control plane is not yet initialized"},
+	}
+
+	t.Run("prints the plan in text format", func(t *testing.T) {
+		g := NewWithT(t)
+
+		buf := &bytes.Buffer{}
+		g.Expect(printMovePlan(buf, plan, MovePlanOutputText)).To(Succeed())
+		g.Expect(buf.String()).To(Equal(`NAMESPACE   KIND      NAME        ACTION    REASON
+ns1         Cluster   foo         move      belongs to Cluster foo
+ns1         Secret    unrelated   stay      does not belong to any Cluster or ClusterResourceSet
+
+1 objects would be moved, 1 objects would stay in the source management cluster.
+
+The move would fail:
+  - cannot start the move operation while the control plane is not yet initialized
+`))
+	})
+
+	t.Run("prints the plan in json format", func(t *testing.T) {
+		g := NewWithT(t)
+
+		buf := &bytes.Buffer{}
+		g.Expect(printMovePlan(buf, plan, MovePlanOutputJSON)).To(Succeed())
+
+		got := &client.MovePlan{}
+		g.Expect(json.Unmarshal(buf.Bytes(), got)).To(Succeed())
+		g.Expect(got).To(Equal(plan))
+	})
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// pluginPrefix is the prefix of the executables extending clusterctl with additional commands, e.g.
+// the clusterctl-foo executable on PATH is invoked when running clusterctl foo.
+const pluginPrefix = "clusterctl-"
+
+// pluginHandler is capable of finding and executing clusterctl plugins.
+type pluginHandler interface {
+	// Lookup returns the path of the plugin with the given name, if any.
+	Lookup(name string) (string, bool)
+
+	// Execute runs the plugin at the given path with the given arguments and environment.
+	Execute(path string, args, env []string) error
+}
+
+// defaultPluginHandler implements pluginHandler by looking for plugins on PATH.
+type defaultPluginHandler struct{}
+
+func (h *defaultPluginHandler) Lookup(name string) (string, bool) {
+	path, err := exec.LookPath(pluginPrefix + name)
+	if err != nil || path == "" {
+		return "", false
+	}
+	return path, true
+}
+
+func (h *defaultPluginHandler) Execute(path string, args, env []string) error {
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = env
+	return cmd.Run()
+}
+
+// handlePluginCommand looks for a plugin matching the command line arguments and executes it, passing it the
+// remaining arguments; the plugin with the longest name is preferred, e.g. for clusterctl foo bar the clusterctl-foo-bar
+// plugin is used if it exists, otherwise clusterctl-foo. It returns false if there are no matching plugins.
+func handlePluginCommand(h pluginHandler, args []string) (bool, error) {
+	var names []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		names = append(names, arg)
+	}
+
+	for i := len(names); i > 0; i-- {
+		path, found := h.Lookup(strings.Join(names[:i], "-"))
+		if !found {
+			continue
+		}
+		return true, h.Execute(path, args[i:], os.Environ())
+	}
+	return false, nil
+}
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Provides utilities for interacting with plugins.",
+	Long: LongDesc(`
+		Provides utilities for interacting with plugins.
+
+		Plugins are executables on PATH whose name starts with clusterctl-, and
+		provide additional clusterctl commands, e.g. the clusterctl-foo executable is invoked
+		when running clusterctl foo.`),
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all the clusterctl plugins on PATH.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plugins := listPlugins(filepath.SplitList(os.Getenv("PATH")))
+		if len(plugins) == 0 {
+			fmt.Println("No clusterctl plugins found on PATH")
+			return nil
+		}
+
+		for _, plugin := range plugins {
+			fmt.Println(plugin)
+			// Nb. a plugin is invoked only if the first argument does not match a clusterctl command.
+			name := strings.SplitN(strings.TrimPrefix(filepath.Base(plugin), pluginPrefix), "-", 2)[0]
+			if isBuiltinCommand(name) {
+				fmt.Printf("  - warning: %s is overshadowed by the clusterctl %s command\n", plugin, name)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginListCmd)
+	RootCmd.AddCommand(pluginCmd)
+}
+
+// listPlugins returns the path of the executables whose name starts with the plugin prefix in the given directories.
+// Nb. Plugins in a directory overshadow the plugins with the same name in the following directories, so they are skipped.
+func listPlugins(dirs []string) []string {
+	var plugins []string
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			if f.IsDir() || !strings.HasPrefix(f.Name(), pluginPrefix) || f.Mode()&0111 == 0 {
+				continue
+			}
+			if seen[f.Name()] {
+				continue
+			}
+			seen[f.Name()] = true
+			plugins = append(plugins, filepath.Join(dir, f.Name()))
+		}
+	}
+	return plugins
+}
+
+// isBuiltinCommand returns true if name is a clusterctl command.
+func isBuiltinCommand(name string) bool {
+	for _, c := range RootCmd.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// fakePluginHandler is a pluginHandler with a fixed set of plugins, recording the executed plugin.
+type fakePluginHandler struct {
+	plugins      map[string]string
+	executed     string
+	executedArgs []string
+}
+
+func (h *fakePluginHandler) Lookup(name string) (string, bool) {
+	path, ok := h.plugins[name]
+	return path, ok
+}
+
+func (h *fakePluginHandler) Execute(path string, args, env []string) error {
+	h.executed = path
+	h.executedArgs = args
+	return nil
+}
+
+func Test_handlePluginCommand(t *testing.T) {
+	plugins := map[string]string{
+		"foo":     "/bin/clusterctl-foo",
+		"foo-bar": "/bin/clusterctl-foo-bar",
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantFound    bool
+		wantExecuted string
+		wantArgs     []string
+	}{
+		{
+			name:         "executes the plugin matching the command",
+			args:         []string{"foo", "baz", "--flag"},
+			wantFound:    true,
+			wantExecuted: "/bin/clusterctl-foo",
+			wantArgs:     []string{"baz", "--flag"},
+		},
+		{
+			name:         "prefers the plugin with the longest name",
+			args:         []string{"foo", "bar", "baz"},
+			wantFound:    true,
+			wantExecuted: "/bin/clusterctl-foo-bar",
+			wantArgs:     []string{"baz"},
+		},
+		{
+			name:         "flags are not considered for the plugin name",
+			args:         []string{"foo", "--bar"},
+			wantFound:    true,
+			wantExecuted: "/bin/clusterctl-foo",
+			wantArgs:     []string{"--bar"},
+		},
+		{
+			name:      "returns false if there are no matching plugins",
+			args:      []string{"baz", "foo"},
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			h := &fakePluginHandler{plugins: plugins}
+			found, err := handlePluginCommand(h, tt.args)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(found).To(Equal(tt.wantFound))
+			g.Expect(h.executed).To(Equal(tt.wantExecuted))
+			if tt.wantFound {
+				g.Expect(h.executedArgs).To(Equal(tt.wantArgs))
+			}
+		})
+	}
+}
+
+func Test_listPlugins(t *testing.T) {
+	g := NewWithT(t)
+
+	dir1, err := ioutil.TempDir("", "clusterctl-plugins")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir1)
+	dir2, err := ioutil.TempDir("", "clusterctl-plugins")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir2)
+
+	g.Expect(ioutil.WriteFile(filepath.Join(dir1, "clusterctl-foo"), []byte("#!/bin/sh"), 0755)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir1, "clusterctl-not-executable"), []byte("#!/bin/sh"), 0644)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir1, "kubectl-foo"), []byte("#!/bin/sh"), 0755)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir2, "clusterctl-foo"), []byte("#!/bin/sh"), 0755)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir2, "clusterctl-bar"), []byte("#!/bin/sh"), 0755)).To(Succeed())
+
+	got := listPlugins([]string{dir1, dir2, filepath.Join(dir1, "does-not-exist")})
+	g.Expect(got).To(Equal([]string{
+		filepath.Join(dir1, "clusterctl-foo"),
+		filepath.Join(dir2, "clusterctl-bar"),
+	}))
+}
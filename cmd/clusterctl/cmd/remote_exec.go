@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+type remoteExecOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	image             string
+	namespace         string
+	serviceAccount    string
+	clusterRole       string
+	timeout           time.Duration
+}
+
+var reo = &remoteExecOptions{}
+
+var remoteExecCmd = &cobra.Command{
+	Use:   "remote-exec -- [clusterctl args]",
+	Short: "Execute clusterctl in a Job in the management cluster.",
+	Long: LongDesc(`
+		Execute clusterctl in a Job in the management cluster, and print its output once the Job completes.
+
+		This is useful in environments where the operators can access the management cluster with kubectl, but
+		long running operations can't be executed from their workstation, e.g. because of network restrictions;
+		clusterctl checks the status of the Job periodically, without keeping a connection open.
+
+		The image must provide the clusterctl binary as entrypoint; the Job runs with an existing ServiceAccount,
+		or it is granted an existing ClusterRole for the duration of the execution. The clusterctl configuration
+		file, if any, is copied to the Job. Variables are not copied from the environment, so they should be
+		defined in the configuration file.`),
+
+	Example: Examples(`
+		# Initializes the management cluster with the AWS infrastructure provider, executing clusterctl in the management cluster.
+		clusterctl remote-exec --image registry.example.com/clusterctl:v0.3.10 --service-account clusterctl -- init --infrastructure aws
+
+		# Upgrades the providers, granting the Job the clusterctl-upgrade ClusterRole only while it runs.
+		clusterctl remote-exec --image registry.example.com/clusterctl:v0.3.10 --cluster-role clusterctl-upgrade -- upgrade apply --management-group capi-system/cluster-api --contract v1alpha3`),
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRemoteExec(args)
+	},
+}
+
+func init() {
+	remoteExecCmd.Flags().StringVar(&reo.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If empty, default discovery rules apply.")
+	remoteExecCmd.Flags().StringVar(&reo.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	remoteExecCmd.Flags().StringVar(&reo.image, "image", "",
+		"The container image providing the clusterctl binary as entrypoint.")
+	remoteExecCmd.Flags().StringVarP(&reo.namespace, "namespace", "n", "",
+		"The namespace where the Job executing clusterctl is created. If unspecified, the clusterctl-system namespace is used.")
+	remoteExecCmd.Flags().StringVar(&reo.serviceAccount, "service-account", "",
+		"The existing ServiceAccount, in the namespace of the Job, the Job runs with.")
+	remoteExecCmd.Flags().StringVar(&reo.clusterRole, "cluster-role", "",
+		"The existing ClusterRole granted to the Job, with a ServiceAccount and a ClusterRoleBinding deleted once the Job completes.")
+	remoteExecCmd.Flags().DurationVar(&reo.timeout, "timeout", 30*time.Minute,
+		"The maximum time clusterctl is allowed to run in the management cluster.")
+
+	RootCmd.AddCommand(remoteExecCmd)
+}
+
+func runRemoteExec(args []string) error {
+	if reo.image == "" {
+		return errors.New("the --image flag is required")
+	}
+	if (reo.serviceAccount == "") == (reo.clusterRole == "") {
+		return errors.New("either the --service-account or the --cluster-role flag is required")
+	}
+
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	return c.RemoteExec(client.RemoteExecOptions{
+		Kubeconfig:     client.Kubeconfig{Path: reo.kubeconfig, Context: reo.kubeconfigContext},
+		Image:          reo.image,
+		Namespace:      reo.namespace,
+		ServiceAccount: reo.serviceAccount,
+		ClusterRole:    reo.clusterRole,
+		Args:           args,
+		ConfigFile:     remoteExecConfigFile(),
+		Timeout:        reo.timeout,
+		Output:         os.Stdout,
+	})
+}
+
+// remoteExecConfigFile returns the path of the clusterctl configuration file to be copied to the Job, if any.
+func remoteExecConfigFile() string {
+	if cfgFile != "" {
+		return cfgFile
+	}
+	defaultConfigFile := filepath.Join(homedir.HomeDir(), config.ConfigFolder, config.ConfigName+".yaml")
+	if _, err := os.Stat(defaultConfigFile); err != nil {
+		return ""
+	}
+	return defaultConfigFile
+}
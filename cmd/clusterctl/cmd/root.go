@@ -17,17 +17,22 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/messages"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/cluster-api/internal/tracing"
 )
 
 type stackTracer interface {
@@ -37,6 +42,9 @@ type stackTracer interface {
 var (
 	cfgFile   string
 	verbosity *int
+
+	// stopTracing exports the spans still queued before clusterctl exits.
+	stopTracing = func() {}
 )
 
 var RootCmd = &cobra.Command{
@@ -49,7 +57,25 @@ var RootCmd = &cobra.Command{
 }
 
 func Execute() {
-	if err := RootCmd.Execute(); err != nil {
+	// If the command line does not match a clusterctl command, look for a plugin providing it.
+	if len(os.Args) > 1 {
+		if _, _, err := RootCmd.Find(os.Args[1:]); err != nil {
+			if found, err := handlePluginCommand(&defaultPluginHandler{}, os.Args[1:]); found {
+				if err != nil {
+					if exitErr, ok := err.(*exec.ExitError); ok {
+						os.Exit(exitErr.ExitCode())
+					}
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				os.Exit(0)
+			}
+		}
+	}
+
+	err := RootCmd.Execute()
+	stopTracing()
+	if err != nil {
 		if verbosity != nil && *verbosity >= 5 {
 			if err, ok := err.(stackTracer); ok {
 				for _, f := range err.StackTrace() {
@@ -57,11 +83,26 @@ func Execute() {
 				}
 			}
 		}
+		printErrorHint(err)
 		// TODO: print cmd help if validation error
 		os.Exit(1)
 	}
 }
 
+// printErrorHint prints how to fix an error defined in the message catalog, if any.
+func printErrorHint(err error) {
+	e, ok := messages.Find(err)
+	if !ok {
+		return
+	}
+	if hint := e.Hint(); hint != "" {
+		fmt.Fprintf(os.Stderr, "Hint: %s\n", hint)
+	}
+	if url := e.DocsURL(); url != "" {
+		fmt.Fprintf(os.Stderr, "See %s for more details.\n", url)
+	}
+}
+
 func init() {
 	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 
@@ -92,6 +133,52 @@ func initConfig() {
 	}
 
 	logf.SetLogger(logf.NewLogger(logf.WithThreshold(verbosity)))
+
+	setupTracing()
+}
+
+// setupTracing exports the spans of the clusterctl operations to the OpenTelemetry collector set with the
+// OTEL_EXPORTER_OTLP_ENDPOINT variable, if any; with the highest verbosity, the spans are logged too.
+func setupTracing() {
+	options := tracing.Options{
+		ServiceName: "clusterctl",
+		LogSpans:    *verbosity >= 5,
+		Log:         logf.Log,
+	}
+	if configClient, err := config.New(cfgFile); err == nil {
+		if v, err := configClient.Variables().Get("OTEL_EXPORTER_OTLP_ENDPOINT"); err == nil {
+			options.Endpoint = v
+		}
+		if v, err := configClient.Variables().Get("OTEL_EXPORTER_OTLP_HEADERS"); err == nil {
+			options.Headers = parseHeaders(v)
+		}
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to setup tracing. err=%s\n", err.Error())
+		os.Exit(1)
+	}
+	stopTracing = func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to export the spans. err=%s\n", err.Error())
+		}
+	}
+}
+
+// parseHeaders parses a comma separated list of key=value headers, e.g. Authorization=Bearer <token>.
+func parseHeaders(s string) map[string]string {
+	headers := map[string]string{}
+	for _, h := range strings.Split(s, ",") {
+		kv := strings.SplitN(h, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			continue
+		}
+		headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return headers
 }
 
 const Indentation = `  `
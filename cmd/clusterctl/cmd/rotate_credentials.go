@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+type rotateCredentialsOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	secretName        string
+	fromLiteral       []string
+	fromFile          []string
+	timeout           time.Duration
+	rollback          bool
+	forceLock         bool
+}
+
+var rco = &rotateCredentialsOptions{}
+
+var rotateCredentialsCmd = &cobra.Command{
+	Use:   "rotate-credentials [namespace/]type-name",
+	Short: "Rotate the credentials used by a provider.",
+	Long: LongDesc(`
+		Rotate the credentials used by a provider, e.g. the infrastructure credentials used by an infrastructure provider.
+
+		The credentials Secret of the provider is updated with the new values, then the provider Deployments are
+		restarted, so the provider starts using the new credentials, and clusterctl waits for the Deployments
+		to become available with all their Pods running without restarts.
+
+		Keys of the Secret not included in the new values are left unchanged. If the provider has more than one
+		Opaque Secret, the name of the credentials Secret must be specified with --secret.`),
+
+	Example: Examples(`
+		# Rotates the credentials of the AWS infrastructure provider.
+		clusterctl rotate-credentials infrastructure-aws --from-file credentials=./aws-credentials
+
+		# Rotates the credentials of the AWS infrastructure provider installed in the capa-system namespace,
+		# restoring the previous credentials if the provider does not become available with the new ones.
+		clusterctl rotate-credentials capa-system/infrastructure-aws --from-file credentials=./aws-credentials --rollback`),
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRotateCredentials(args[0])
+	},
+}
+
+func init() {
+	rotateCredentialsCmd.Flags().StringVar(&rco.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If empty, default discovery rules apply.")
+	rotateCredentialsCmd.Flags().StringVar(&rco.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	rotateCredentialsCmd.Flags().StringVar(&rco.secretName, "secret", "",
+		"The name of the Secret holding the credentials in the provider namespace. If unspecified, the only Opaque Secret of the provider is used.")
+	rotateCredentialsCmd.Flags().StringArrayVar(&rco.fromLiteral, "from-literal", nil,
+		"A new value for a key of the credentials Secret, in the form key=value.")
+	rotateCredentialsCmd.Flags().StringArrayVar(&rco.fromFile, "from-file", nil,
+		"A file with the new value for a key of the credentials Secret, in the form key=path.")
+	rotateCredentialsCmd.Flags().DurationVar(&rco.timeout, "timeout", 5*time.Minute,
+		"The time the provider Deployments are given to become available with the new credentials.")
+	rotateCredentialsCmd.Flags().BoolVar(&rco.rollback, "rollback", false,
+		"Restore the previous credentials if the provider Deployments do not become available with the new credentials.")
+	rotateCredentialsCmd.Flags().BoolVar(&rco.forceLock, "force-lock", false,
+		"Rotate the credentials even if the management cluster is locked by another clusterctl operation.")
+
+	RootCmd.AddCommand(rotateCredentialsCmd)
+}
+
+func runRotateCredentials(provider string) error {
+	data, err := credentialsData(rco.fromLiteral, rco.fromFile)
+	if err != nil {
+		return err
+	}
+
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	return c.RotateCredentials(client.RotateCredentialsOptions{
+		Kubeconfig: client.Kubeconfig{Path: rco.kubeconfig, Context: rco.kubeconfigContext},
+		Provider:   provider,
+		SecretName: rco.secretName,
+		Data:       data,
+		Timeout:    rco.timeout,
+		Rollback:   rco.rollback,
+		ForceLock:  rco.forceLock,
+	})
+}
+
+// credentialsData returns the new values of the credentials from the key=value and key=path pairs.
+func credentialsData(fromLiteral, fromFile []string) (map[string][]byte, error) {
+	data := map[string][]byte{}
+	parsePair := func(pair, flag string) (string, string, error) {
+		i := strings.Index(pair, "=")
+		if i <= 0 {
+			return "", "", errors.Errorf("invalid --%s %q, it must be in the form key=value", flag, pair)
+		}
+		key := pair[:i]
+		if _, ok := data[key]; ok {
+			return "", "", errors.Errorf("the key %q is specified more than once", key)
+		}
+		return key, pair[i+1:], nil
+	}
+
+	for _, pair := range fromLiteral {
+		key, value, err := parsePair(pair, "from-literal")
+		if err != nil {
+			return nil, err
+		}
+		data[key] = []byte(value)
+	}
+	for _, pair := range fromFile {
+		key, path, err := parsePair(pair, "from-file")
+		if err != nil {
+			return nil, err
+		}
+		value, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the value of the key %q", key)
+		}
+		data[key] = value
+	}
+
+	if len(data) == 0 {
+		return nil, errors.New("at least one --from-literal or --from-file flag is required")
+	}
+	return data, nil
+}
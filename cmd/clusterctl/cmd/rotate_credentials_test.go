@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_credentialsData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clusterctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	credentialsFile := filepath.Join(dir, "credentials")
+	if err := ioutil.WriteFile(credentialsFile, []byte("[default]\nkey = value\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		fromLiteral []string
+		fromFile    []string
+		want        map[string][]byte
+		wantErr     bool
+	}{
+		{
+			name:        "literal and file values",
+			fromLiteral: []string{"region=us-east-1", "token=a=b"},
+			fromFile:    []string{"credentials=" + credentialsFile},
+			want: map[string][]byte{
+				"region":      []byte("us-east-1"),
+				"token":       []byte("a=b"),
+				"credentials": []byte("[default]\nkey = value\n"),
+			},
+		},
+		{
+			name:    "no values",
+			wantErr: true,
+		},
+		{
+			name:        "value without key",
+			fromLiteral: []string{"=value"},
+			wantErr:     true,
+		},
+		{
+			name:        "duplicate key",
+			fromLiteral: []string{"credentials=value"},
+			fromFile:    []string{"credentials=" + credentialsFile},
+			wantErr:     true,
+		},
+		{
+			name:     "missing file",
+			fromFile: []string{"credentials=" + filepath.Join(dir, "does-not-exist")},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := credentialsData(tt.fromLiteral, tt.fromFile)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
@@ -32,6 +32,10 @@ type upgradeApplyOptions struct {
 	bootstrapProviders      []string
 	controlPlaneProviders   []string
 	infrastructureProviders []string
+	specFile                string
+	yes                     bool
+	forceLock               bool
+	force                   bool
 }
 
 var ua = &upgradeApplyOptions{}
@@ -51,7 +55,10 @@ var upgradeApplyCmd = &cobra.Command{
 		clusterctl upgrade apply --management-group capi-system/cluster-api  --contract v1alpha3
 
 		# Upgrades only the capa-system/aws provider instance in the capi-system/cluster-api management group to the v0.5.0 version.
-		clusterctl upgrade apply --management-group capi-system/cluster-api  --infrastructure capa-system/aws:v0.5.0`),
+		clusterctl upgrade apply --management-group capi-system/cluster-api  --infrastructure capa-system/aws:v0.5.0
+
+		# Upgrades the providers in the capi-system/cluster-api management group to the versions declared in the init spec file.
+		clusterctl upgrade apply --management-group capi-system/cluster-api  --spec-file init-spec.yaml`),
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runUpgradeApply()
@@ -76,6 +83,14 @@ func init() {
 		"Bootstrap providers instance and versions (e.g. capi-kubeadm-bootstrap-system/kubeadm:v0.3.0) to upgrade to. This flag can be used as alternative to --contract.")
 	upgradeApplyCmd.Flags().StringSliceVarP(&ua.controlPlaneProviders, "control-plane", "c", nil,
 		"ControlPlane providers instance and versions (e.g. capi-kubeadm-control-plane-system/kubeadm:v0.3.0) to upgrade to. This flag can be used as alternative to --contract.")
+	upgradeApplyCmd.Flags().StringVar(&ua.specFile, "spec-file", "",
+		"Path to an init spec file declaring the provider versions to upgrade to. This flag can be used as alternative to --contract and to the provider flags.")
+	upgradeApplyCmd.Flags().BoolVarP(&ua.yes, "yes", "y", false,
+		"Upgrade the providers without asking for confirmation")
+	upgradeApplyCmd.Flags().BoolVar(&ua.forceLock, "force-lock", false,
+		"Force the operation even if the management cluster is locked by another clusterctl operation, e.g. when the lock has been left behind by a failed operation.")
+	upgradeApplyCmd.Flags().BoolVar(&ua.force, "force", false,
+		"Force the upgrade even if existing Cluster API objects use fields or annotations removed in the target API Version of Cluster API (contract).")
 }
 
 func runUpgradeApply() error {
@@ -93,6 +108,7 @@ func runUpgradeApply() error {
 		return errors.New("The --contract flag can't be used in combination with --core, --bootstrap, --control-plane, --infrastructure")
 	}
 
+	prompt := newConfirmPrompt(ua.yes)
 	if err := c.ApplyUpgrade(client.ApplyUpgradeOptions{
 		Kubeconfig:              client.Kubeconfig{Path: ua.kubeconfig, Context: ua.kubeconfigContext},
 		ManagementGroup:         ua.managementGroup,
@@ -101,8 +117,12 @@ func runUpgradeApply() error {
 		BootstrapProviders:      ua.bootstrapProviders,
 		ControlPlaneProviders:   ua.controlPlaneProviders,
 		InfrastructureProviders: ua.infrastructureProviders,
+		SpecFile:                ua.specFile,
+		Confirm:                 prompt.confirmFunc(),
+		ForceLock:               ua.forceLock,
+		Force:                   ua.force,
 	}); err != nil {
-		return err
+		return prompt.ignoreNotConfirmed(err)
 	}
 	return nil
 }
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+type upgradeClusterOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	namespace         string
+	kubernetesVersion string
+	dryRun            bool
+	wait              bool
+	timeout           time.Duration
+}
+
+var uc = &upgradeClusterOptions{}
+
+var upgradeClusterCmd = &cobra.Command{
+	Use:   "cluster NAME",
+	Short: "Upgrade the Kubernetes version of a workload cluster",
+	Long: LongDesc(`
+		Upgrade the Kubernetes version of a workload cluster using a KubeadmControlPlane.
+
+		The control plane is upgraded first, and the MachineDeployments are upgraded only after all the
+		control plane machines are up to date; the upgrade is rejected if it does not honor the Kubernetes
+		version skew policy, e.g. if the control plane should skip a minor version.`),
+
+	Example: Examples(`
+		# Shows the objects to be upgraded for upgrading the cluster my-cluster to Kubernetes v1.18.2.
+		clusterctl upgrade cluster my-cluster --kubernetes-version v1.18.2 --dry-run
+
+		# Upgrades the cluster my-cluster to Kubernetes v1.18.2 and waits for the upgrade to complete.
+		clusterctl upgrade cluster my-cluster --kubernetes-version v1.18.2 --wait`),
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runUpgradeCluster(args[0])
+	},
+}
+
+func init() {
+	upgradeClusterCmd.Flags().StringVar(&uc.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If empty, default discovery rules apply.")
+	upgradeClusterCmd.Flags().StringVar(&uc.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	upgradeClusterCmd.Flags().StringVarP(&uc.namespace, "namespace", "n", "",
+		"The namespace where the workload cluster is located. If unspecified, the current namespace will be used.")
+	upgradeClusterCmd.Flags().StringVar(&uc.kubernetesVersion, "kubernetes-version", "",
+		"The Kubernetes version the workload cluster should be upgraded to.")
+	upgradeClusterCmd.Flags().BoolVar(&uc.dryRun, "dry-run", false,
+		"Shows the objects to be upgraded without applying the upgrade.")
+	upgradeClusterCmd.Flags().BoolVar(&uc.wait, "wait", false,
+		"Waits for the MachineDeployments to complete the upgrade.")
+	upgradeClusterCmd.Flags().DurationVar(&uc.timeout, "timeout", 0,
+		"The time to wait for the upgrade of the control plane and of each MachineDeployment. If unspecified, 30 minutes are used.")
+}
+
+func runUpgradeCluster(name string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	plan, err := c.UpgradeWorkloadCluster(client.UpgradeWorkloadClusterOptions{
+		Kubeconfig:        client.Kubeconfig{Path: uc.kubeconfig, Context: uc.kubeconfigContext},
+		Namespace:         uc.namespace,
+		ClusterName:       name,
+		KubernetesVersion: uc.kubernetesVersion,
+		DryRun:            uc.dryRun,
+		WaitForCompletion: uc.wait,
+		Timeout:           uc.timeout,
+	})
+	if err != nil {
+		return err
+	}
+
+	if plan.ControlPlane == nil && len(plan.MachineDeployments) == 0 {
+		fmt.Printf("The cluster %s/%s is already at Kubernetes %s\n", plan.Namespace, plan.ClusterName, plan.KubernetesVersion)
+		return nil
+	}
+
+	fmt.Println("")
+	w := tabwriter.NewWriter(os.Stdout, 10, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tCURRENT VERSION\tNEXT VERSION")
+	if plan.ControlPlane != nil {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", plan.ControlPlane.Kind, plan.ControlPlane.Name, plan.ControlPlane.CurrentVersion, plan.ControlPlane.NextVersion)
+	}
+	for _, item := range plan.MachineDeployments {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.Kind, item.Name, item.CurrentVersion, item.NextVersion)
+	}
+	w.Flush()
+	fmt.Println("")
+
+	switch {
+	case uc.dryRun:
+		fmt.Println("You can now apply the upgrade by executing the same command without --dry-run")
+	case uc.wait:
+		fmt.Printf("The cluster %s/%s is upgraded to Kubernetes %s\n", plan.Namespace, plan.ClusterName, plan.KubernetesVersion)
+	default:
+		fmt.Printf("The upgrade of the cluster %s/%s to Kubernetes %s is in progress\n", plan.Namespace, plan.ClusterName, plan.KubernetesVersion)
+	}
+	return nil
+}
@@ -19,10 +19,13 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 )
 
 type upgradePlanOptions struct {
@@ -43,7 +46,11 @@ var upgradePlanCmd = &cobra.Command{
 
 		Then, for each provider in a management group, the following upgrade options are provided:
 		- The latest patch release for the current API Version of Cluster API (contract).
-		- The latest patch release for the next API Version of Cluster API (contract), if available.`),
+		- The latest patch release for the next API Version of Cluster API (contract), if available.
+
+		The plan also reports the cert-manager version clusterctl is configured to install, and an estimate of the time
+		the webhooks of each provider are not available during the upgrade, which can be used for scheduling the upgrade
+		of busy management clusters in a maintenance window.`),
 
 	Example: Examples(`
 		# Gets the recommended target versions for upgrading Cluster API providers.
@@ -82,6 +89,8 @@ func runUpgradePlan() error {
 		return nil
 	}
 
+	printCertManagerUpgradePlan(upgradePlans[0].CertManager)
+
 	for _, plan := range upgradePlans {
 		// ensure provider are sorted consistently (by Type, Name, Namespace).
 		sortUpgradeItems(plan)
@@ -102,6 +111,49 @@ func runUpgradePlan() error {
 		w.Flush()
 		fmt.Println("")
 
+		if len(plan.IntermediateSteps) > 0 {
+			fmt.Printf("The upgrade to %s goes through the following API Version of Cluster API (contract) first:\n", plan.Contract)
+			fmt.Println("")
+			w := tabwriter.NewWriter(os.Stdout, 10, 4, 3, ' ', 0)
+			fmt.Fprintln(w, "CONTRACT\tNAME\tNAMESPACE\tTYPE\tNEXT VERSION")
+			for _, step := range plan.IntermediateSteps {
+				// ensure provider are sorted consistently (by Type, Name, Namespace).
+				sortUpgradeItems(client.UpgradePlan{Providers: step.Providers})
+				for _, upgradeItem := range step.Providers {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", step.Contract, upgradeItem.Provider.Name, upgradeItem.Provider.Namespace, upgradeItem.Provider.Type, prettifyTargetVersion(upgradeItem.NextVersion))
+				}
+			}
+			w.Flush()
+			fmt.Println("")
+		}
+
+		if len(plan.VersionSkews) > 0 {
+			fmt.Println("The following providers have instances running different versions; all the instances of a provider share the same CRDs, so it is recommended to upgrade all of them:")
+			fmt.Println("")
+			w := tabwriter.NewWriter(os.Stdout, 10, 4, 3, ' ', 0)
+			fmt.Fprintln(w, "NAME\tNAMESPACE\tTYPE\tCURRENT VERSION")
+			for _, skew := range plan.VersionSkews {
+				for _, instance := range skew.Instances {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", instance.Name, instance.Namespace, instance.Type, instance.Version)
+				}
+			}
+			w.Flush()
+			fmt.Println("")
+		}
+
+		if len(plan.WebhookDowntimes) > 0 {
+			printWebhookDowntimes(plan)
+		}
+
+		if len(plan.Warnings) > 0 {
+			fmt.Println("The following provider versions are deprecated or no longer supported; please upgrade to a supported release:")
+			fmt.Println("")
+			for _, warning := range plan.Warnings {
+				fmt.Printf("   %s\n", warning)
+			}
+			fmt.Println("")
+		}
+
 		if upgradeAvailable {
 			fmt.Println("You can now apply the upgrade by executing the following command:")
 			fmt.Println("")
@@ -115,3 +167,38 @@ func runUpgradePlan() error {
 
 	return nil
 }
+
+func printCertManagerUpgradePlan(plan cluster.CertManagerUpgradePlan) {
+	target := plan.TargetVersion
+	if target == "" {
+		target = "the manifest configured with cert-manager-url"
+	}
+
+	fmt.Println("")
+	switch {
+	case plan.CurrentVersion == "":
+		fmt.Printf("cert-manager: the installed version can't be detected, clusterctl installs %s\n", target)
+	case plan.ShouldUpgrade():
+		fmt.Printf("cert-manager: %s installed, clusterctl installs %s; please upgrade cert-manager before the providers, clusterctl upgrade apply does not upgrade it\n", plan.CurrentVersion, target)
+	default:
+		fmt.Printf("cert-manager: %s installed, clusterctl installs %s\n", plan.CurrentVersion, target)
+	}
+}
+
+func printWebhookDowntimes(plan client.UpgradePlan) {
+	fmt.Println("The webhooks of the following providers are not available during their upgrade, and the API server rejects the changes to the objects they validate, default or convert in the meantime:")
+	fmt.Println("")
+	w := tabwriter.NewWriter(os.Stdout, 10, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tNAMESPACE\tTYPE\tWEBHOOK SERVICES\tESTIMATED DOWNTIME")
+	total := time.Duration(0)
+	for _, downtime := range plan.WebhookDowntimes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", downtime.Provider.Name, downtime.Provider.Namespace, downtime.Provider.Type, strings.Join(downtime.Services, ","), downtime.Estimate.Round(time.Second))
+		total += downtime.Estimate
+	}
+	w.Flush()
+	fmt.Println("")
+	// Nb. the providers are upgraded one at a time, once for each intermediate contract and once for the target contract.
+	total *= time.Duration(len(plan.IntermediateSteps) + 1)
+	fmt.Printf("The estimated total webhook downtime is %s; the estimates are based on the time the current webhook Pods took to become ready.\n", total.Round(time.Second))
+	fmt.Println("")
+}
@@ -94,7 +94,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _cmdClusterctlConfigManifestClusterctlApiYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x56\x3d\x93\xdb\x36\x13\xee\xf5\x2b\x76\xfc\x16\x6e\x5e\x51\x71\xdc\x24\xec\x32\x97\x14\x9e\x7c\x8c\xc6\x77\x73\x2e\x32\x29\x20\x60\x45\xc2\x07\x2e\x10\xec\x42\xb6\x92\xc9\x7f\xcf\x00\x20\x29\x52\xe7\x28\xd7\x84\x95\xb0\xdc\x8f\x47\xcf\xee\x3e\x84\x0a\xf6\x11\x23\x5b\x4f\x2d\xa8\x60\xf1\xb3\x20\xe5\x13\x37\x4f\xdf\x70\x63\xfd\xee\xf4\x66\xf3\x64\xc9\xb4\x70\x97\x58\xfc\xf0\x1e\xd9\xa7\xa8\xf1\x7b\x3c\x5a\xb2\x62\x3d\x6d\x06\x14\x65\x94\xa8\x76\x03\xa0\x88\xbc\xa8\x6c\xe6\x7c\x04\xd0\x9e\x24\x7a\xe7\x30\x6e\x3b\xa4\xe6\x29\x1d\xf0\x90\xac\x33\x18\x4b\xf2\xa9\xf4\xe9\xab\xe6\xeb\xe6\xdb\x0d\x80\x8e\x58\xc2\x1f\xec\x80\x2c\x6a\x08\x2d\x50\x72\x6e\x03\x40\x6a\xc0\x16\x42\xf4\x27\x6b\x30\x72\xa3\x5d\x62\xc1\xa8\xc5\x4d\x3f\x9b\xcf\xdb\x0a\x7a\xc3\x01\x75\xae\xdf\x45\x9f\x42\x0b\xb7\x5c\x6b\xe2\x09\xad\x12\xec\x7c\xb4\xd3\x79\x3b\x85\x6e\x55\xb0\xc5\x52\xb9\xd8\x8f\x28\x8a\xc9\x59\x96\x1f\x57\xe6\x9f\x2c\x4b\x79\x15\x5c\x8a\xca\x2d\x50\x17\x2b\x5b\xea\x92\x53\xf1\x62\xdf\x00\xb0\xf6\x01\x5b\xf8\x25\x83\x09\x4a\xa3\xd9\x00\x8c\xf4\x14\x30\x5b\x50\xc6\x14\xc2\x95\xdb\x47\x4b\x82\xf1\xce\xbb\x34\xd0\x0c\xf5\x23\x7b\xda\x2b\xe9\x5b\x68\xc2\x12\xdf\x44\xdd\xc3\x39\xe0\x68\x90\x73\xae\xc5\x12\x2d\x75\xcf\xa3\xe5\xe2\x58\x23\xf7\xeb\x74\xb7\xa3\x47\xd0\xab\x04\x8f\x2b\xdb\xed\xf8\x4f\x4a\x74\x8f\x66\x26\x62\x95\xe8\x43\x7e\x09\xd7\xef\x9e\x25\xac\xce\xa7\x37\xca\x85\x5e\xbd\xad\xa4\xeb\x1e\x07\xd5\x8e\x11\x3e\x20\x7d\xb7\x7f\xf7\xf8\xf6\x7e\x65\x06\x30\xc8\x3a\xda\x20\x65\x2a\xa7\xff\x0d\x26\x4f\x3b\x32\x28\x02\x24\x89\x67\xb0\x04\xd2\xe3\xdc\x3f\xb0\x74\x42\x12\x1f\xcf\xcd\x9c\x29\x44\x1f\x30\xca\x3c\x4b\xf5\x59\xec\xdb\xc2\x7a\x55\xf7\x75\x86\x56\xbd\xe6\xd2\xb9\xdc\x48\x2d\x9a\xf1\xdf\x80\x3f\x82\xf4\x96\x21\x62\x88\xc8\x48\x75\xf5\x56\x89\x21\x3b\x29\x02\x7f\xf8\x88\x5a\x1a\xb8\xc7\x98\xd3\x00\xf7\x3e\x39\x93\xf7\xf3\x84\x51\x20\xa2\xf6\x1d\xd9\x3f\xe6\xdc\x0c\xe2\x4b\x51\xa7\x04\xc7\x71\xbe\x3c\x65\xfe\x48\x39\x38\x29\x97\xf0\xff\xa0\xc8\xc0\xa0\xce\x10\x31\x57\x81\x44\x8b\x7c\xc5\x85\x1b\xf8\xd9\x47\x04\x4b\x47\xdf\x42\x2f\x12\xb8\xdd\xed\x3a\x2b\x93\xce\x68\x3f\x0c\x89\xac\x9c\x77\x45\x32\xec\x21\x89\x8f\xbc\x33\x78\x42\xb7\x63\xdb\x6d\x55\xd4\xbd\x15\xd4\x92\x22\xee\x54\xb0\xdb\x02\x9d\x8a\xd6\x34\x83\xf9\x5f\x1c\x95\x89\x5f\xaf\xb0\x3e\x9b\x8d\xfa\x94\x3d\xbe\xd1\x81\xbc\xd0\x60\x19\xd4\x18\x5a\xff\xc5\x85\xe8\x6c\xca\xec\xbc\xff\xe1\xfe\x01\xa6\xd2\xa5\x19\xd7\xec\x17\xde\x2f\x81\x7c\x69\x41\x26\xcc\xd2\x11\x63\x6d\xe2\x31\xfa\xa1\xe4\x44\x32\xc1\x5b\x92\x72\xd0\xce\x22\x5d\xd3\xcf\xe9\x30\x58\xc9\x7d\xff\x3d\x21\x4b\xee\x55\x03\x77\x45\x7c\xe1\x80\x90\x82\x51\x82\xa6\x81\x77\x04\x77\x6a\x40\x77\xa7\x18\xff\xf3\x06\x64\xa6\x79\x9b\x89\x7d\x59\x0b\x96\xdf\x8d\x6b\xe7\xca\xda\xe2\xc5\xb4\x69\x79\xf5\x6f\xf4\x6d\xbf\x70\x03\x4b\xc6\x66\x45\xaf\xcb\x93\x35\xa1\x2e\xcc\x65\x6f\x9b\x97\xe0\x2c\xe6\x7f\x2e\x99\x65\xf5\xaa\x54\x8e\x78\x56\x0a\xee\x11\x67\x7c\x0b\x2d\x9e\x9e\xa3\x8f\xa0\xca\xc7\x24\x87\x72\x0a\xc1\x47\x99\xd7\xe7\x25\x40\x4f\xff\x2a\x2c\x93\xa8\xac\xe1\x6a\x3f\x04\x4f\x48\x32\x65\x78\x11\x2d\xd7\x3a\x7d\xa3\xec\x87\x2b\xd7\x2f\x74\xa6\xda\x3f\xf5\x18\x71\x45\xdb\x15\x49\x97\xeb\x44\x5e\x4e\xcb\x15\x85\xa5\xae\x01\x7b\x04\x1c\x82\x9c\xd7\xc2\xbc\x0e\x98\xbc\xbf\x40\x7d\x9d\x38\xce\xca\xae\x9c\xbb\x60\xe2\x17\x90\xf1\x6c\x66\x39\x6f\xb8\x69\x41\x62\xaa\x6d\x66\xf1\x51\x75\xb8\xb4\xa4\xc3\xac\x58\x2d\xfc\xf9\xd7\x86\x45\x49\x2a\xdf\x0a\xa5\x35\x06\x19\xf9\x6a\x17\xd7\x8e\x57\xaf\x56\xb7\x8a\x72\xd4\x9e\xea\xb5\x80\x5b\xf8\xf5\xb7\x4d\x2d\x85\xe6\x71\xba\x3a\x64\xe3\xdf\x01\x00\x00\xff\xff\x5b\x42\x72\xee\xe5\x09\x00\x00")
+var _cmdClusterctlConfigManifestClusterctlApiYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\x03\xb5\x56\xc9\x8e\x1b\x37\x10\xbd\xeb\x2b\x0a\xce\xc1\x17\xab\x15\xc7\x97\xa4\x6f\xc1\x64\x81\x91\x05\x82\x67\x30\x3e\x04\x39\xb0\xc9\x52\x8b\x1e\x36\xc9\x70\x91\xad\x04\xf9\xf7\x14\xc9\xde\x35\x96\x75\x49\x43\x07\xb1\xc8\x7a\xf5\x58\xf5\x58\x24\xb3\xf2\x11\x9d\x97\x46\xd7\xc0\xac\xc4\x4f\x01\x75\x1a\xf9\xea\xe9\x5b\x5f\x49\xb3\x3b\xbd\xde\x3c\x49\x2d\x6a\xb8\x8b\x3e\x98\xee\x1d\x7a\x13\x1d\xc7\x1f\xf0\x20\xb5\x0c\xb4\x72\xd3\x61\x60\x82\x05\x56\x6f\x00\x98\xd6\x26\xb0\x64\xf6\x69\x08\xc0\x8d\x0e\xce\x28\x85\x6e\xdb\xa2\xae\x9e\x62\x83\x4d\x94\x4a\xa0\xcb\xe0\x43\xe8\xd3\xd7\xd5\x37\xd5\x77\xe4\xc1\x1d\x66\xf7\x07\xd9\xa1\x0f\xac\xb3\x35\xe8\xa8\x14\xcd\x68\xd6\x61\x0d\xd6\x99\x93\x24\x6f\x5f\x71\x45\x84\xd0\xf1\xa0\x86\xbf\xd5\xa7\x6d\x21\xbd\xf1\x16\x79\x8a\xdf\x3a\x13\x09\xe1\xda\xd2\x02\x3c\xb0\x65\x01\x5b\xe3\xe4\x30\xde\x0e\xae\x5b\xca\x4d\xb6\x94\x5c\xec\x7b\x16\xd9\xa4\xa4\x0f\xbf\x2c\xcc\xbf\x92\x25\x4f\x59\x15\x1d\x53\x33\xd6\xd9\xea\xa5\x6e\xa3\x62\x6e\xb2\x93\xd9\x73\x63\x69\x7f\xbf\x27\x32\x96\x71\x14\x64\xeb\xd3\x93\xc9\x6c\x81\x09\x91\x13\xce\xd4\xde\x49\x4d\xa4\xee\x8c\x8a\x9d\x1e\xa9\x7e\xf0\x46\xef\x59\x38\xd6\x50\xd9\x39\xbf\x21\x75\x0f\x67\x8b\xbd\x21\x9c\x53\x2c\x1f\x08\xa7\xbd\xf4\x0e\xd3\xc2\xe2\xb9\x5f\xc2\x5d\xf7\xee\x49\x2f\x00\x1e\x17\xb6\xeb\xfe\x1f\x59\xe0\x47\x14\x63\x22\x16\x40\xef\xd3\x24\xac\xe7\x2e\x00\xcb\xe2\xd3\x6b\xa6\xec\x91\xbd\x29\x49\x27\xd0\x8e\xd5\xbd\x07\xe5\x5a\x7f\xbf\x7f\xfb\xf8\xe6\x7e\x61\x06\x10\xe8\xb9\x93\x36\x64\x55\x0e\xfb\x26\x2b\xa9\x1d\x3d\xc9\x1b\x90\xf4\x7c\x06\xa9\x21\x1c\x71\xac\x1f\x8d\x4f\x34\x61\xdc\xb9\x1a\x91\x68\xce\xa2\x0b\xa3\x96\xca\xc7\xa6\xf3\x36\xb3\xae\xe2\xbe\x4c\xd4\xca\xaa\x31\x74\x0a\xd7\xa7\x16\x45\xbf\x1b\x30\x07\xb2\x4b\x0f\x0e\xad\x43\x4f\x0c\xf2\xd9\x59\x00\x43\x5a\x44\xbc\x4d\xf3\x01\x79\xa8\xe0\x1e\x5d\x82\x01\x7f\x34\x51\x89\x74\x3e\x69\x18\x08\x81\x9b\x56\xcb\xbf\x47\x6c\x8a\x68\x72\x50\x45\x67\xa2\x97\xf3\xf4\x65\xfd\x91\x12\xe1\xc4\x54\xc4\x57\x14\x40\x40\xc7\xce\x04\x93\xa2\x40\xd4\x33\xbc\xbc\xc4\x57\xf0\x9b\x71\x48\x8e\x07\x53\xc3\x31\x04\xeb\xeb\xdd\xae\x95\x61\xe8\x33\xdc\x74\x5d\xa4\x8e\x72\xde\xe5\x96\x21\x9b\x48\xe9\xf4\x3b\x81\x27\x54\x3b\x2f\xdb\x2d\x73\xfc\x28\x03\xa1\x47\x87\x3b\x4a\xe3\x36\x53\xd7\xb9\xd7\x54\x9d\xf8\xca\xf5\x9d\xc9\xbf\x5c\x70\xbd\xd0\x46\xf9\x0e\xd4\x66\x08\xe8\xe7\xb4\xb9\x65\x25\xe6\xc7\xec\xb9\x0a\x4e\xa0\x8d\x31\x0a\x99\xfe\x7c\x1d\x7f\x9a\x05\xa1\x9d\x0b\xc9\xf3\xbf\x94\xd5\x3e\x3e\xb4\xd9\x92\xeb\x88\xb0\x3a\xb6\xc3\x37\xf5\x50\xf0\x18\xa0\x39\xcf\x1a\xda\x2b\x30\x54\x3f\x27\x05\x6d\xaf\x68\x24\x67\xbb\x97\x8d\x58\x0b\xf5\x02\xb9\xb3\xa4\x27\x1d\x7c\xf5\x4c\xd2\x8a\x64\x66\x13\xb9\xf9\x5d\x91\x6d\xea\x82\x40\x72\x64\x7d\xbe\x0b\x99\x49\x9d\x03\xc7\x77\x3f\xde\x3f\xc0\x50\xaf\xac\xe0\xb5\x64\x73\xe4\xc9\xd1\x4f\xba\x4d\x2a\x23\x11\x51\x2e\xb2\xf2\x0f\xce\x74\x19\x13\xb5\xb0\x86\x64\x99\x07\x5c\x49\xf2\x5a\x81\xfa\xd8\x74\x32\xa4\xc3\xf2\x17\x65\x28\x24\x81\x57\x70\x97\x6f\x2c\x68\x10\xa2\xa5\x4b\x0c\x45\x05\x6f\x35\x59\x3b\x54\x77\xcc\xe3\xff\xae\xda\x94\x69\xbf\x4d\x89\xbd\x4d\xb7\xf3\xcb\xf6\x8b\xf5\x1a\xaa\x9e\xfa\xe5\x95\xba\xed\x67\xcb\x56\x32\x4d\x8d\x74\xad\xce\xea\x16\x9e\xd9\xfc\xf9\x90\xe9\x2e\x5a\x85\x4a\x1e\x17\xa1\xa8\xec\x38\xf2\x9b\x5d\x60\xe3\x31\x36\x8e\xd4\x96\x6e\xe0\xe4\xea\xa3\xb5\xc6\x85\xb1\xe7\xdc\x42\xf4\xf4\xc5\x6e\x3c\x74\xe2\x25\xdd\xf1\xe8\x0c\x08\x37\xa5\x65\x7d\xb9\x5d\x09\xfb\x7e\xb5\xf4\x99\xca\x14\xfb\xc7\x23\x3a\xbc\xb1\x7f\xd0\x89\xa1\x5f\x66\x41\xc4\x2a\x90\x07\xc0\xce\x86\xf3\xf2\x36\x5b\x3a\x0c\xab\x9f\x49\x7d\x51\x5c\xea\x6d\xc0\x94\x9a\x38\xf9\x1b\x92\x71\xa1\x59\x9f\x4e\x38\xbd\xa2\x82\x8b\xa5\xcc\xf4\xde\x74\xac\xc5\xb9\x25\x36\x63\x9b\xaf\xe1\x9f\x7f\x37\xf4\x44\x0c\x31\xb7\x67\xc6\x39\xda\xd0\xe7\xab\x9e\xbd\xd5\x5e\xbc\x58\x3c\xc5\xf2\x90\x36\x58\x9a\x3c\xa1\xfc\xf1\xe7\xa6\x84\x42\xf1\x38\xbc\xb7\x92\xf1\x3f\xbc\x06\xed\x84\x1a\x0b\x00\x00")
 
 func cmdClusterctlConfigManifestClusterctlApiYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -109,7 +109,7 @@ func cmdClusterctlConfigManifestClusterctlApiYaml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "cmd/clusterctl/config/manifest/clusterctl-api.yaml", size: 2533, mode: os.FileMode(420), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "cmd/clusterctl/config/manifest/clusterctl-api.yaml", size: 2842, mode: os.FileMode(420), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -49,6 +49,10 @@ func (fp *FakeProcessor) GetVariables(raw []byte) ([]string, error) {
 	return nil, fp.errGetVariables
 }
 
+func (fp *FakeProcessor) GetVariableMap(raw []byte) (map[string]*string, error) {
+	return nil, fp.errGetVariables
+}
+
 func (fp *FakeProcessor) Process(raw []byte, variablesGetter func(string) (string, error)) ([]byte, error) {
 	return nil, fp.errProcess
 }
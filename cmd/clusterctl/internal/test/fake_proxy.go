@@ -17,10 +17,23 @@ limitations under the License.
 package test
 
 import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
 	apiextensionslv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/version"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
@@ -28,6 +41,7 @@ import (
 	fakebootstrap "sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test/providers/bootstrap"
 	fakecontrolplane "sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test/providers/controlplane"
 	fakeinfrastructure "sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test/providers/infrastructure"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
 	addonsv1alpha3 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha3"
 	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -35,8 +49,10 @@ import (
 )
 
 type FakeProxy struct {
-	cs   client.Client
-	objs []runtime.Object
+	cs            client.Client
+	objs          []runtime.Object
+	serverVersion string
+	latency       time.Duration
 }
 
 var (
@@ -50,6 +66,7 @@ func init() {
 	_ = expv1.AddToScheme(FakeScheme)
 	_ = addonsv1alpha3.AddToScheme(FakeScheme)
 	_ = apiextensionslv1.AddToScheme(FakeScheme)
+	_ = controlplanev1.AddToScheme(FakeScheme)
 
 	_ = fakebootstrap.AddToScheme(FakeScheme)
 	_ = fakecontrolplane.AddToScheme(FakeScheme)
@@ -64,6 +81,14 @@ func (f *FakeProxy) ValidateKubernetesVersion() error {
 	return nil
 }
 
+// GetServerVersion returns the Kubernetes version set using WithServerVersion, or v1.18.0 by default.
+func (f *FakeProxy) GetServerVersion() (*version.Version, error) {
+	if f.serverVersion == "" {
+		return version.MustParseGeneric("v1.18.0"), nil
+	}
+	return version.ParseGeneric(f.serverVersion)
+}
+
 func (f *FakeProxy) GetConfig() (*rest.Config, error) {
 	return nil, nil
 }
@@ -72,13 +97,193 @@ func (f *FakeProxy) NewClient() (client.Client, error) {
 	if f.cs != nil {
 		return f.cs, nil
 	}
-	f.cs = fake.NewFakeClientWithScheme(FakeScheme, f.objs...)
+	f.cs = &fakeApplyClient{Client: fake.NewFakeClientWithScheme(FakeScheme, f.objs...)}
+	if f.latency > 0 {
+		f.cs = &latencyClient{Client: f.cs, latency: f.latency}
+	}
 
 	return f.cs, nil
 }
 
+// fakeApplyClient wraps the controller-runtime fake client, emulating server-side apply patches
+// that are not supported by the fake client.
+// Nb. field ownership is tracked per object instead of per field: applying an object with a different value for a
+// field that is already set is reported as a conflict, unless the object is managed only by the applying field manager
+// or ownership is forced. Objects created without server-side apply are considered managed by another field manager.
+type fakeApplyClient struct {
+	client.Client
+}
+
+func (c *fakeApplyClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+
+	// Dry-run, force and the field manager are the only patch options honored when emulating server-side apply.
+	patchOptions := &client.PatchOptions{}
+	patchOptions.ApplyOptions(opts)
+	var createOpts []client.CreateOption
+	var patchOpts []client.PatchOption
+	if len(patchOptions.DryRun) > 0 {
+		createOpts = append(createOpts, client.DryRunAll)
+		patchOpts = append(patchOpts, client.DryRunAll)
+	}
+	applyEntry := metav1.ManagedFieldsEntry{Manager: patchOptions.FieldManager, Operation: metav1.ManagedFieldsOperationApply}
+
+	current := obj.DeepCopyObject()
+	key := client.ObjectKey{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}
+	if err := c.Client.Get(ctx, key, current); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		accessor.SetManagedFields([]metav1.ManagedFieldsEntry{applyEntry})
+		return c.Client.Create(ctx, obj, createOpts...)
+	}
+
+	currentAccessor, err := meta.Accessor(current)
+	if err != nil {
+		return err
+	}
+
+	managedFields := []metav1.ManagedFieldsEntry{applyEntry}
+	if patchOptions.Force == nil || !*patchOptions.Force {
+		managedByOthers := len(currentAccessor.GetManagedFields()) == 0
+		for _, e := range currentAccessor.GetManagedFields() {
+			if e.Manager != applyEntry.Manager || e.Operation != applyEntry.Operation {
+				managedByOthers = true
+				managedFields = append(managedFields, e)
+			}
+		}
+		if managedByOthers {
+			changed, err := changedFields(current, obj)
+			if err != nil {
+				return err
+			}
+			if len(changed) > 0 {
+				gvk := obj.GetObjectKind().GroupVersionKind()
+				return apierrors.NewConflict(schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind)}, accessor.GetName(),
+					errors.Errorf("Apply failed with %d conflicts: conflicts with other field managers: %s", len(changed), strings.Join(changed, ", ")))
+			}
+		}
+	}
+
+	accessor.SetResourceVersion(currentAccessor.GetResourceVersion())
+	accessor.SetManagedFields(managedFields)
+	return c.Client.Patch(ctx, obj, client.Merge, patchOpts...)
+}
+
+// Create stores unstructured objects of the types known by the scheme as typed objects, as the API server does,
+// because the fake client can't list the unstructured objects stored for a typed kind.
+func (c *fakeApplyClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	typed, err := toTyped(obj)
+	if err != nil {
+		return err
+	}
+	if err := c.Client.Create(ctx, typed, opts...); err != nil {
+		return err
+	}
+	return fromTyped(typed, obj)
+}
+
+// Update stores unstructured objects of the types known by the scheme as typed objects, as Create does.
+func (c *fakeApplyClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	typed, err := toTyped(obj)
+	if err != nil {
+		return err
+	}
+	if err := c.Client.Update(ctx, typed, opts...); err != nil {
+		return err
+	}
+	return fromTyped(typed, obj)
+}
+
+// toTyped converts an unstructured object of a type known by the scheme to a typed object; other objects are
+// returned as they are.
+func toTyped(obj runtime.Object) (runtime.Object, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return obj, nil
+	}
+	typed, err := FakeScheme.New(u.GroupVersionKind())
+	if err != nil {
+		return obj, nil
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, typed); err != nil {
+		return nil, err
+	}
+	return typed, nil
+}
+
+// fromTyped copies a typed object returned by toTyped back to the original unstructured object, if any.
+func fromTyped(typed, obj runtime.Object) error {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok || typed == obj {
+		return nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typed)
+	if err != nil {
+		return err
+	}
+	gvk := u.GroupVersionKind()
+	u.Object = content
+	u.SetGroupVersionKind(gvk)
+	return nil
+}
+
+// changedFields returns the paths of the fields set in both the current and the applied object with different values.
+func changedFields(current, applied runtime.Object) ([]string, error) {
+	currentMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+	if err != nil {
+		return nil, err
+	}
+	appliedMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(applied)
+	if err != nil {
+		return nil, err
+	}
+
+	// Metadata fields set by the API server are not subject to conflicts.
+	for _, m := range []map[string]interface{}{currentMap, appliedMap} {
+		if metadata, ok := m["metadata"].(map[string]interface{}); ok {
+			for _, f := range []string{"resourceVersion", "managedFields", "creationTimestamp", "uid", "generation", "selfLink"} {
+				delete(metadata, f)
+			}
+		}
+		delete(m, "status")
+	}
+
+	changed := []string{}
+	var compare func(path string, current, applied map[string]interface{})
+	compare = func(path string, current, applied map[string]interface{}) {
+		for k, appliedValue := range applied {
+			currentValue, ok := current[k]
+			if !ok {
+				continue
+			}
+			currentChild, currentIsMap := currentValue.(map[string]interface{})
+			appliedChild, appliedIsMap := appliedValue.(map[string]interface{})
+			if currentIsMap && appliedIsMap {
+				compare(path+"."+k, currentChild, appliedChild)
+				continue
+			}
+			if !reflect.DeepEqual(currentValue, appliedValue) {
+				changed = append(changed, path+"."+k)
+			}
+		}
+	}
+	compare("", currentMap, appliedMap)
+	sort.Strings(changed)
+	return changed, nil
+}
+
 // ListResources returns all the resources known by the FakeProxy
 func (f *FakeProxy) ListResources(labels map[string]string, namespaces ...string) ([]unstructured.Unstructured, error) {
+	time.Sleep(f.latency)
+
 	var ret []unstructured.Unstructured //nolint
 	for _, o := range f.objs {
 		u := unstructured.Unstructured{}
@@ -124,6 +329,19 @@ func NewFakeProxy() *FakeProxy {
 	return &FakeProxy{}
 }
 
+// WithServerVersion sets the Kubernetes version of the fake management cluster.
+func (f *FakeProxy) WithServerVersion(serverVersion string) *FakeProxy {
+	f.serverVersion = serverVersion
+	return f
+}
+
+// WithLatency adds a fixed latency to each call to the fake management cluster, to simulate a remote API server
+// in performance tests.
+func (f *FakeProxy) WithLatency(latency time.Duration) *FakeProxy {
+	f.latency = latency
+	return f
+}
+
 func (f *FakeProxy) WithObjs(objs ...runtime.Object) *FakeProxy {
 	f.objs = append(f.objs, objs...)
 	return f
@@ -143,9 +361,11 @@ func (f *FakeProxy) WithProviderInventory(name string, providerType clusterctlv1
 			Namespace: targetNamespace,
 			Name:      clusterctlv1.ManifestLabel(name, providerType),
 			Labels: map[string]string{
-				clusterctlv1.ClusterctlLabelName:     "",
-				clusterv1.ProviderLabelName:          clusterctlv1.ManifestLabel(name, providerType),
-				clusterctlv1.ClusterctlCoreLabelName: "inventory",
+				clusterctlv1.ClusterctlLabelName:                "",
+				clusterv1.ProviderLabelName:                     clusterctlv1.ManifestLabel(name, providerType),
+				clusterctlv1.ClusterctlCoreLabelName:            "inventory",
+				clusterctlv1.ClusterctlProviderVersionLabelName: version,
+				clusterctlv1.ClusterctlInstanceLabelName:        targetNamespace,
 			},
 		},
 		ProviderName:     name,
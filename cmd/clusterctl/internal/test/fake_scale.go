@@ -0,0 +1,163 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FakeScale generates the objects of a management cluster with many Clusters, to be used in performance tests
+// together with a FakeProxy, optionally with latency injection (see FakeProxy.WithLatency).
+// Each Cluster has a control plane with the given number of Machines, and the given number of MachineDeployments,
+// each one with a MachineSet with the given number of Machines.
+type FakeScale struct {
+	namespaces                   int
+	clustersPerNamespace         int
+	controlPlaneMachines         int
+	machineDeploymentsPerCluster int
+	machinesPerMachineDeployment int
+}
+
+// NewFakeScale returns a FakeScale generating a single Cluster, with a control plane with one Machine and one
+// MachineDeployment with one Machine.
+func NewFakeScale() *FakeScale {
+	return &FakeScale{
+		namespaces:                   1,
+		clustersPerNamespace:         1,
+		controlPlaneMachines:         1,
+		machineDeploymentsPerCluster: 1,
+		machinesPerMachineDeployment: 1,
+	}
+}
+
+// WithNamespaces sets the number of namespaces the Clusters are created in; namespaces are named ns1, ns2, etc.
+func (f *FakeScale) WithNamespaces(n int) *FakeScale {
+	f.namespaces = n
+	return f
+}
+
+// WithClustersPerNamespace sets the number of Clusters in each namespace.
+func (f *FakeScale) WithClustersPerNamespace(n int) *FakeScale {
+	f.clustersPerNamespace = n
+	return f
+}
+
+// WithControlPlaneMachines sets the number of control plane Machines of each Cluster; if zero, the Clusters have no
+// control plane object.
+func (f *FakeScale) WithControlPlaneMachines(n int) *FakeScale {
+	f.controlPlaneMachines = n
+	return f
+}
+
+// WithMachineDeployments sets the number of MachineDeployments of each Cluster, and the number of Machines of each
+// MachineDeployment.
+func (f *FakeScale) WithMachineDeployments(machineDeployments, machinesPerMachineDeployment int) *FakeScale {
+	f.machineDeploymentsPerCluster = machineDeployments
+	f.machinesPerMachineDeployment = machinesPerMachineDeployment
+	return f
+}
+
+// Clusters returns the number of Clusters generated.
+func (f *FakeScale) Clusters() int {
+	return f.namespaces * f.clustersPerNamespace
+}
+
+// Machines returns the number of Machines generated.
+func (f *FakeScale) Machines() int {
+	return f.Clusters() * (f.controlPlaneMachines + f.machineDeploymentsPerCluster*f.machinesPerMachineDeployment)
+}
+
+// Objs returns all the objects of the Clusters.
+func (f *FakeScale) Objs() []runtime.Object {
+	var objs []runtime.Object
+	for n := 1; n <= f.namespaces; n++ {
+		namespace := fmt.Sprintf("ns%d", n)
+		for c := 1; c <= f.clustersPerNamespace; c++ {
+			objs = append(objs, f.cluster(namespace, fmt.Sprintf("cluster%d", c)).Objs()...)
+		}
+	}
+	return objs
+}
+
+// cluster returns a FakeCluster; names of the objects are prefixed with the Cluster name, so they are unique in the namespace.
+func (f *FakeScale) cluster(namespace, name string) *FakeCluster {
+	cluster := NewFakeCluster(namespace, name)
+
+	if f.controlPlaneMachines > 0 {
+		controlPlane := NewFakeControlPlane(fmt.Sprintf("%s-cp", name))
+		for m := 1; m <= f.controlPlaneMachines; m++ {
+			controlPlane.WithMachines(NewFakeMachine(fmt.Sprintf("%s-cp-m%d", name, m)))
+		}
+		cluster.WithControlPlane(controlPlane)
+	}
+
+	for d := 1; d <= f.machineDeploymentsPerCluster; d++ {
+		machineSet := NewFakeMachineSet(fmt.Sprintf("%s-md%d-ms", name, d))
+		for m := 1; m <= f.machinesPerMachineDeployment; m++ {
+			machineSet.WithMachines(NewFakeMachine(fmt.Sprintf("%s-md%d-m%d", name, d, m)))
+		}
+		cluster.WithMachineDeployments(NewFakeMachineDeployment(fmt.Sprintf("%s-md%d", name, d)).WithMachineSets(machineSet))
+	}
+
+	return cluster
+}
+
+// latencyClient wraps a client, adding a fixed latency to each call to simulate a remote API server.
+type latencyClient struct {
+	client.Client
+	latency time.Duration
+}
+
+func (c *latencyClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	time.Sleep(c.latency)
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *latencyClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	time.Sleep(c.latency)
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *latencyClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	time.Sleep(c.latency)
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *latencyClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	time.Sleep(c.latency)
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *latencyClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	time.Sleep(c.latency)
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *latencyClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	time.Sleep(c.latency)
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *latencyClient) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	time.Sleep(c.latency)
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
@@ -36,6 +36,10 @@ func (f FakeVariableClient) Set(key, value string) {
 	f.variables[key] = value
 }
 
+func (f FakeVariableClient) Validate(names []string) error {
+	return nil
+}
+
 func (f *FakeVariableClient) WithVar(key, value string) *FakeVariableClient {
 	f.variables[key] = value
 	return f
@@ -39,9 +39,12 @@ import (
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/predicates"
 	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/cluster-api/util/trace"
+	"sigs.k8s.io/cluster-api/util/tuning"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -69,6 +72,9 @@ type ClusterReconciler struct {
 	Client client.Client
 	Log    logr.Logger
 
+	// Tuning configures the maximum requeue delay and the per-namespace concurrency of the reconciliations.
+	Tuning tuning.Options
+
 	scheme          *runtime.Scheme
 	recorder        record.EventRecorder
 	externalTracker external.ObjectTracker
@@ -83,7 +89,7 @@ func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager, options controlle
 		).
 		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPaused(r.Log)).
-		Build(r)
+		Build(tuning.Wrap(r, r.Tuning))
 
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
@@ -99,7 +105,13 @@ func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager, options controlle
 
 func (r *ClusterReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
 	ctx := context.Background()
-	logger := r.Log.WithValues("cluster", req.Name, "namespace", req.Namespace)
+	ctx, span := trace.Start(ctx, "ClusterReconciler.Reconcile", trace.String(logutil.NamespaceKey, req.Namespace), trace.String(logutil.ClusterKey, req.Name))
+	defer func() {
+		span.RecordError(reterr)
+		span.End()
+	}()
+	logger := logutil.WithReconcileID(r.Log).WithValues(logutil.NamespaceKey, req.Namespace, logutil.ClusterKey, req.Name)
+	ctx = logutil.IntoContext(ctx, logger)
 
 	// Fetch the Cluster instance.
 	cluster := &clusterv1.Cluster{}
@@ -167,7 +179,7 @@ func (r *ClusterReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr e
 
 // reconcile handles cluster reconciliation.
 func (r *ClusterReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster) (ctrl.Result, error) {
-	logger := r.Log.WithValues("cluster", cluster.Name, "namespace", cluster.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForCluster(r.Log, cluster))
 
 	// Call the inner reconciliation methods.
 	reconciliationErrors := []error{
@@ -226,7 +238,7 @@ func (r *ClusterReconciler) reconcileMetrics(_ context.Context, cluster *cluster
 
 // reconcileDelete handles cluster deletion.
 func (r *ClusterReconciler) reconcileDelete(ctx context.Context, cluster *clusterv1.Cluster) (reconcile.Result, error) {
-	logger := r.Log.WithValues("cluster", cluster.Name, "namespace", cluster.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForCluster(r.Log, cluster))
 
 	descendants, err := r.listDescendants(ctx, cluster)
 	if err != nil {
@@ -480,7 +492,7 @@ func splitMachineList(list *clusterv1.MachineList) (*clusterv1.MachineList, *clu
 }
 
 func (r *ClusterReconciler) reconcileControlPlaneInitialized(ctx context.Context, cluster *clusterv1.Cluster) error {
-	logger := r.Log.WithValues("cluster", cluster.Name, "namespace", cluster.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForCluster(r.Log, cluster))
 
 	// Skip checking if the control plane is initialized when using a Control Plane Provider
 	if cluster.Spec.ControlPlaneRef != nil {
@@ -33,6 +33,7 @@ import (
 	"sigs.k8s.io/cluster-api/util/conditions"
 	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/secret"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -63,7 +64,7 @@ func (r *ClusterReconciler) reconcilePhase(_ context.Context, cluster *clusterv1
 
 // reconcileExternal handles generic unstructured objects referenced by a Cluster.
 func (r *ClusterReconciler) reconcileExternal(ctx context.Context, cluster *clusterv1.Cluster, ref *corev1.ObjectReference) (external.ReconcileOutput, error) {
-	logger := r.Log.WithValues("cluster", cluster.Name, "namespace", cluster.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForCluster(r.Log, cluster))
 
 	if err := utilconversion.ConvertReferenceAPIContract(ctx, r.Client, ref); err != nil {
 		return external.ReconcileOutput{}, err
@@ -135,7 +136,7 @@ func (r *ClusterReconciler) reconcileExternal(ctx context.Context, cluster *clus
 
 // reconcileInfrastructure reconciles the Spec.InfrastructureRef object on a Cluster.
 func (r *ClusterReconciler) reconcileInfrastructure(ctx context.Context, cluster *clusterv1.Cluster) error {
-	logger := r.Log.WithValues("cluster", cluster.Name, "namespace", cluster.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForCluster(r.Log, cluster))
 
 	if cluster.Spec.InfrastructureRef == nil {
 		return nil
@@ -252,7 +253,7 @@ func (r *ClusterReconciler) reconcileKubeconfig(ctx context.Context, cluster *cl
 		return nil
 	}
 
-	_, err := secret.Get(ctx, r.Client, util.ObjectKey(cluster), secret.Kubeconfig)
+	configSecret, err := secret.Get(ctx, r.Client, util.ObjectKey(cluster), secret.Kubeconfig)
 	switch {
 	case apierrors.IsNotFound(err):
 		if err := kubeconfig.CreateSecret(ctx, r.Client, cluster); err != nil {
@@ -263,9 +264,31 @@ func (r *ClusterReconciler) reconcileKubeconfig(ctx context.Context, cluster *cl
 			}
 			return err
 		}
+		return nil
 	case err != nil:
 		return errors.Wrapf(err, "failed to retrieve Kubeconfig Secret for Cluster %q in namespace %q", cluster.Name, cluster.Namespace)
 	}
 
+	// Only refresh the Kubeconfig generated by the Cluster controller, not the ones provided by the users.
+	if !util.IsOwnedByObject(configSecret, cluster) {
+		return nil
+	}
+
+	// Regenerate the Kubeconfig if the control plane endpoint was migrated or the cluster CA was rotated,
+	// so the users and the controllers don't keep using stale credentials.
+	needsRefresh, err := kubeconfig.NeedsRefresh(ctx, r.Client, configSecret, cluster.Spec.ControlPlaneEndpoint.String())
+	if err != nil {
+		if err == kubeconfig.ErrDependentCertificateNotFound {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to check Kubeconfig Secret for Cluster %q in namespace %q", cluster.Name, cluster.Namespace)
+	}
+	if needsRefresh {
+		logger := logutil.FromContext(ctx, logutil.ForCluster(r.Log, cluster))
+		logger.Info("Refreshing Kubeconfig Secret, the control plane endpoint or the cluster CA changed")
+		if err := kubeconfig.RefreshSecret(ctx, r.Client, configSecret, cluster.Spec.ControlPlaneEndpoint.String()); err != nil {
+			return errors.Wrapf(err, "failed to refresh Kubeconfig Secret for Cluster %q in namespace %q", cluster.Name, cluster.Namespace)
+		}
+	}
 	return nil
 }
@@ -31,6 +31,9 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controllers/external"
 	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/kubeconfig"
+	"sigs.k8s.io/cluster-api/util/secret"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -227,6 +230,47 @@ func TestClusterReconcilePhases(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("refresh kubeconfig", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		cluster := &clusterv1.Cluster{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Cluster",
+				APIVersion: clusterv1.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-cluster",
+				Namespace: "test",
+			},
+			Spec: clusterv1.ClusterSpec{
+				ControlPlaneEndpoint: clusterv1.APIEndpoint{
+					Host: "1.2.3.4",
+					Port: 8443,
+				},
+			},
+		}
+
+		certificates := secret.Certificates{&secret.Certificate{Purpose: secret.ClusterCA}}
+		g.Expect(certificates.Generate()).To(Succeed())
+		caSecret := certificates.GetByPurpose(secret.ClusterCA).AsSecret(util.ObjectKey(cluster), metav1.OwnerReference{})
+
+		r := &ClusterReconciler{
+			Client: fake.NewFakeClientWithScheme(scheme.Scheme, cluster, caSecret),
+			Log:    log.Log,
+			scheme: scheme.Scheme,
+		}
+		g.Expect(r.reconcileKubeconfig(context.Background(), cluster)).To(Succeed())
+
+		// Migrates the control plane endpoint.
+		cluster.Spec.ControlPlaneEndpoint.Host = "5.6.7.8"
+		g.Expect(r.reconcileKubeconfig(context.Background(), cluster)).To(Succeed())
+
+		configSecret, err := secret.Get(context.Background(), r.Client, util.ObjectKey(cluster), secret.Kubeconfig)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(kubeconfig.NeedsRefresh(context.Background(), r.Client, configSecret, "5.6.7.8:8443")).To(BeFalse())
+	})
 }
 
 func TestClusterReconciler_reconcilePhase(t *testing.T) {
@@ -0,0 +1,193 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	logutil "sigs.k8s.io/cluster-api/util/log"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+// maxNotReadyNodesInMessage is the maximum number of Node names listed in the NodesNetworkReady condition message.
+const maxNotReadyNodesInMessage = 5
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch;update;patch
+
+// ClusterNetworkReconciler periodically checks the Nodes of the workload clusters and reports in the NodesNetworkReady
+// condition of the Clusters if the network plugin (CNI) is ready on all of them, so higher-level automation knows when
+// workloads can be deployed.
+type ClusterNetworkReconciler struct {
+	Client  client.Client
+	Log     logr.Logger
+	Tracker *remote.ClusterCacheTracker
+
+	// ProbeInterval is the interval between checks; it defaults to DefaultClusterProbeInterval.
+	ProbeInterval time.Duration
+}
+
+func (r *ClusterNetworkReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	if r.ProbeInterval == 0 {
+		r.ProbeInterval = DefaultClusterProbeInterval
+	}
+
+	_, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.Cluster{}).
+		Named("clusternetwork").
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPaused(r.Log)).
+		Build(r)
+
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+	return nil
+}
+
+func (r *ClusterNetworkReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.Background()
+	logger := logutil.WithReconcileID(r.Log).WithValues(logutil.NamespaceKey, req.Namespace, logutil.ClusterKey, req.Name)
+	ctx = logutil.IntoContext(ctx, logger)
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Client.Get(ctx, req.NamespacedName, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the Cluster is paused, is being deleted, or if the control plane is not initialized yet.
+	if annotations.IsPaused(cluster, cluster) {
+		logger.V(4).Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+	if !cluster.DeletionTimestamp.IsZero() || !cluster.Status.ControlPlaneInitialized {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(cluster, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, cluster, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{clusterv1.NodesNetworkReadyCondition}}); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	nodes, err := r.listNodes(ctx, cluster)
+	if err != nil {
+		logger.V(2).Info("Failed to read the Nodes of the workload cluster", "error", err.Error())
+	}
+	setNodesNetworkReady(cluster, nodes, err)
+
+	return ctrl.Result{RequeueAfter: r.ProbeInterval}, nil
+}
+
+// listNodes returns the Nodes of the workload cluster; Nodes are read from the cache of the ClusterCacheTracker.
+func (r *ClusterNetworkReconciler) listNodes(ctx context.Context, cluster *clusterv1.Cluster) ([]corev1.Node, error) {
+	remoteClient, err := r.Tracker.GetClient(ctx, util.ObjectKey(cluster))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a client for the workload cluster")
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err := remoteClient.List(ctx, nodeList); err != nil {
+		return nil, errors.Wrap(err, "failed to list Nodes")
+	}
+	return nodeList.Items, nil
+}
+
+// setNodesNetworkReady sets the NodesNetworkReady condition given the Nodes of the workload cluster, or the error
+// occurred while reading them.
+func setNodesNetworkReady(cluster *clusterv1.Cluster, nodes []corev1.Node, err error) {
+	if err != nil {
+		conditions.MarkFalse(cluster, clusterv1.NodesNetworkReadyCondition, clusterv1.NodesProbeFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
+		return
+	}
+
+	if len(nodes) == 0 {
+		conditions.MarkFalse(cluster, clusterv1.NodesNetworkReadyCondition, clusterv1.WaitingForNodesReason, clusterv1.ConditionSeverityInfo, "")
+		return
+	}
+
+	var notReady []string
+	for i := range nodes {
+		if !isNodeNetworkReady(&nodes[i]) {
+			notReady = append(notReady, nodes[i].Name)
+		}
+	}
+	if len(notReady) == 0 {
+		conditions.MarkTrue(cluster, clusterv1.NodesNetworkReadyCondition)
+		return
+	}
+
+	sort.Strings(notReady)
+	names := notReady
+	if len(names) > maxNotReadyNodesInMessage {
+		names = append(names[:maxNotReadyNodesInMessage:maxNotReadyNodesInMessage], "...")
+	}
+	conditions.MarkFalse(cluster, clusterv1.NodesNetworkReadyCondition, clusterv1.NodesNetworkNotReadyReason, clusterv1.ConditionSeverityInfo,
+		"%d of %d Nodes are waiting for the network plugin: %s", len(notReady), len(nodes), strings.Join(names, ", "))
+}
+
+// isNodeNetworkReady returns false if the Node reports that its network is not ready; this happens when the kubelet
+// reports the runtime network as not ready, e.g. because the CNI configuration is not installed yet, or when the
+// NetworkUnavailable condition is set to true, e.g. by the cloud provider or by the network plugin.
+func isNodeNetworkReady(node *corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		switch c.Type {
+		case corev1.NodeNetworkUnavailable:
+			if c.Status == corev1.ConditionTrue {
+				return false
+			}
+		case corev1.NodeReady:
+			if c.Status != corev1.ConditionTrue && isNetworkNotReadyMessage(c.Message) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isNetworkNotReadyMessage returns true if the message of the Node Ready condition is about the runtime network,
+// e.g. "runtime network not ready: NetworkReady=false reason:NetworkPluginNotReady message:docker: network plugin is
+// not ready: cni config uninitialized".
+func isNetworkNotReadyMessage(message string) bool {
+	return strings.Contains(message, "NetworkReady=false") ||
+		strings.Contains(message, "NetworkPluginNotReady") ||
+		strings.Contains(strings.ToLower(message), "network plugin is not ready")
+}
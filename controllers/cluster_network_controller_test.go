@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+func TestSetNodesNetworkReady(t *testing.T) {
+	newNode := func(name string, conditions ...corev1.NodeCondition) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.NodeStatus{Conditions: conditions},
+		}
+	}
+	ready := corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue}
+	cniNotReady := corev1.NodeCondition{
+		Type:    corev1.NodeReady,
+		Status:  corev1.ConditionFalse,
+		Reason:  "KubeletNotReady",
+		Message: "runtime network not ready: NetworkReady=false reason:NetworkPluginNotReady message:docker: network plugin is not ready: cni config uninitialized",
+	}
+	otherNotReady := corev1.NodeCondition{
+		Type:    corev1.NodeReady,
+		Status:  corev1.ConditionFalse,
+		Reason:  "KubeletNotReady",
+		Message: "PLEG is not healthy",
+	}
+	networkUnavailable := corev1.NodeCondition{Type: corev1.NodeNetworkUnavailable, Status: corev1.ConditionTrue}
+
+	manyNotReady := []corev1.Node{}
+	for i := 0; i < 7; i++ {
+		manyNotReady = append(manyNotReady, newNode(fmt.Sprintf("node-%d", i), cniNotReady))
+	}
+
+	tests := []struct {
+		name        string
+		nodes       []corev1.Node
+		err         error
+		wantStatus  corev1.ConditionStatus
+		wantReason  string
+		wantMessage string
+	}{
+		{
+			name:        "probe failed",
+			err:         errors.New("connection refused"),
+			wantStatus:  corev1.ConditionFalse,
+			wantReason:  clusterv1.NodesProbeFailedReason,
+			wantMessage: "connection refused",
+		},
+		{
+			name:       "no nodes",
+			wantStatus: corev1.ConditionFalse,
+			wantReason: clusterv1.WaitingForNodesReason,
+		},
+		{
+			name:       "all nodes ready",
+			nodes:      []corev1.Node{newNode("node-1", ready), newNode("node-2", ready)},
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name:       "nodes not ready for other reasons",
+			nodes:      []corev1.Node{newNode("node-1", ready), newNode("node-2", otherNotReady)},
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name:        "nodes waiting for the network plugin",
+			nodes:       []corev1.Node{newNode("node-2", cniNotReady), newNode("node-1", ready, networkUnavailable), newNode("node-3", ready)},
+			wantStatus:  corev1.ConditionFalse,
+			wantReason:  clusterv1.NodesNetworkNotReadyReason,
+			wantMessage: "2 of 3 Nodes are waiting for the network plugin: node-1, node-2",
+		},
+		{
+			name:        "the list of nodes in the message is truncated",
+			nodes:       manyNotReady,
+			wantStatus:  corev1.ConditionFalse,
+			wantReason:  clusterv1.NodesNetworkNotReadyReason,
+			wantMessage: "7 of 7 Nodes are waiting for the network plugin: node-0, node-1, node-2, node-3, node-4, ...",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := &clusterv1.Cluster{}
+			setNodesNetworkReady(cluster, tt.nodes, tt.err)
+
+			c := conditions.Get(cluster, clusterv1.NodesNetworkReadyCondition)
+			g.Expect(c).NotTo(BeNil())
+			g.Expect(c.Status).To(Equal(tt.wantStatus))
+			g.Expect(c.Reason).To(Equal(tt.wantReason))
+			g.Expect(c.Message).To(Equal(tt.wantMessage))
+		})
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	logutil "sigs.k8s.io/cluster-api/util/log"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+const (
+	// DefaultClusterProbeInterval is the default interval between probes of the workload clusters' API servers.
+	DefaultClusterProbeInterval = 1 * time.Minute
+)
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch;update;patch
+
+// ClusterReachabilityReconciler periodically probes the API server of the workload clusters and reports the
+// result in the ControlPlaneReachable condition and in the ControlPlaneProbe status of the Clusters.
+type ClusterReachabilityReconciler struct {
+	Client  client.Client
+	Log     logr.Logger
+	Tracker *remote.ClusterCacheTracker
+
+	// ProbeInterval is the interval between probes; it defaults to DefaultClusterProbeInterval.
+	ProbeInterval time.Duration
+}
+
+func (r *ClusterReachabilityReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	if r.ProbeInterval == 0 {
+		r.ProbeInterval = DefaultClusterProbeInterval
+	}
+
+	_, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.Cluster{}).
+		Named("clusterreachability").
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPaused(r.Log)).
+		Build(r)
+
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+	return nil
+}
+
+func (r *ClusterReachabilityReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.Background()
+	logger := logutil.WithReconcileID(r.Log).WithValues(logutil.NamespaceKey, req.Namespace, logutil.ClusterKey, req.Name)
+	ctx = logutil.IntoContext(ctx, logger)
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Client.Get(ctx, req.NamespacedName, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the Cluster is paused, is being deleted, or if the control plane is not initialized yet.
+	if annotations.IsPaused(cluster, cluster) {
+		logger.V(4).Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+	if !cluster.DeletionTimestamp.IsZero() || !cluster.Status.ControlPlaneInitialized {
+		return ctrl.Result{}, nil
+	}
+
+	// Probe the API server at most once per interval; this also prevents the status updates made by this controller
+	// from triggering new probes.
+	if next := nextClusterProbe(cluster, r.ProbeInterval, time.Now()); next > 0 {
+		return ctrl.Result{RequeueAfter: next}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(cluster, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err := patchHelper.Patch(ctx, cluster, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{clusterv1.ControlPlaneReachableCondition}}); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	latency, err := r.Tracker.ProbeCluster(ctx, util.ObjectKey(cluster))
+	if err != nil {
+		logger.V(2).Info("The control plane is not reachable", "error", err.Error())
+	}
+	setControlPlaneProbe(cluster, latency, err, time.Now())
+
+	return ctrl.Result{RequeueAfter: r.ProbeInterval}, nil
+}
+
+// nextClusterProbe returns the time until the next probe of the Cluster is due, or zero if it is due now.
+func nextClusterProbe(cluster *clusterv1.Cluster, interval time.Duration, now time.Time) time.Duration {
+	if cluster.Status.ControlPlaneProbe == nil || !conditions.Has(cluster, clusterv1.ControlPlaneReachableCondition) {
+		return 0
+	}
+	next := cluster.Status.ControlPlaneProbe.LastProbeTime.Add(interval).Sub(now)
+	if next < 0 {
+		return 0
+	}
+	return next
+}
+
+// setControlPlaneProbe records the result of a probe in the Cluster status and conditions.
+func setControlPlaneProbe(cluster *clusterv1.Cluster, latency time.Duration, err error, now time.Time) {
+	cluster.Status.ControlPlaneProbe = &clusterv1.ControlPlaneProbe{
+		LastProbeTime: metav1.NewTime(now),
+	}
+
+	if err != nil {
+		conditions.MarkFalse(cluster, clusterv1.ControlPlaneReachableCondition, clusterv1.ControlPlaneUnreachableReason, clusterv1.ConditionSeverityWarning, "%v", err)
+		return
+	}
+
+	cluster.Status.ControlPlaneProbe.Latency = &metav1.Duration{Duration: latency}
+	conditions.MarkTrue(cluster, clusterv1.ControlPlaneReachableCondition)
+}
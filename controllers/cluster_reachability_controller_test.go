@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+func TestSetControlPlaneProbe(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	cluster := &clusterv1.Cluster{}
+
+	setControlPlaneProbe(cluster, 0, errors.New("connection refused"), now)
+	g.Expect(conditions.IsFalse(cluster, clusterv1.ControlPlaneReachableCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(cluster, clusterv1.ControlPlaneReachableCondition)).To(Equal(clusterv1.ControlPlaneUnreachableReason))
+	g.Expect(conditions.GetMessage(cluster, clusterv1.ControlPlaneReachableCondition)).To(Equal("connection refused"))
+	g.Expect(cluster.Status.ControlPlaneProbe.LastProbeTime.Time).To(BeTemporally("==", now))
+	g.Expect(cluster.Status.ControlPlaneProbe.Latency).To(BeNil())
+
+	setControlPlaneProbe(cluster, 20*time.Millisecond, nil, now.Add(time.Minute))
+	g.Expect(conditions.IsTrue(cluster, clusterv1.ControlPlaneReachableCondition)).To(BeTrue())
+	g.Expect(cluster.Status.ControlPlaneProbe.LastProbeTime.Time).To(BeTemporally("==", now.Add(time.Minute)))
+	g.Expect(cluster.Status.ControlPlaneProbe.Latency).To(Equal(&metav1.Duration{Duration: 20 * time.Millisecond}))
+}
+
+func TestNextClusterProbe(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		probe     *clusterv1.ControlPlaneProbe
+		condition bool
+		want      time.Duration
+	}{
+		{
+			name: "due if the cluster was never probed",
+			want: 0,
+		},
+		{
+			name:      "due if the interval is elapsed",
+			probe:     &clusterv1.ControlPlaneProbe{LastProbeTime: metav1.NewTime(now.Add(-2 * time.Minute))},
+			condition: true,
+			want:      0,
+		},
+		{
+			name:      "due if the condition is missing",
+			probe:     &clusterv1.ControlPlaneProbe{LastProbeTime: metav1.NewTime(now.Add(-10 * time.Second))},
+			condition: false,
+			want:      0,
+		},
+		{
+			name:      "not due within the interval",
+			probe:     &clusterv1.ControlPlaneProbe{LastProbeTime: metav1.NewTime(now.Add(-10 * time.Second))},
+			condition: true,
+			want:      50 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := &clusterv1.Cluster{}
+			cluster.Status.ControlPlaneProbe = tt.probe
+			if tt.condition {
+				conditions.MarkTrue(cluster, clusterv1.ControlPlaneReachableCondition)
+			}
+
+			g.Expect(nextClusterProbe(cluster, time.Minute, now)).To(Equal(tt.want))
+		})
+	}
+}
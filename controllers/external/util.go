@@ -73,6 +73,10 @@ type CloneTemplateInput struct {
 	// Labels is an optional map of labels to be added to the object.
 	// +optional
 	Labels map[string]string
+
+	// Name is an optional name for the cloned object; if empty, the name is generated from the template name.
+	// +optional
+	Name string
 }
 
 // CloneTemplate uses the client and the reference to create a new object from the template.
@@ -88,6 +92,7 @@ func CloneTemplate(ctx context.Context, in *CloneTemplateInput) (*corev1.ObjectR
 		ClusterName: in.ClusterName,
 		OwnerRef:    in.OwnerRef,
 		Labels:      in.Labels,
+		Name:        in.Name,
 	}
 	to, err := GenerateTemplate(generateTemplateInput)
 	if err != nil {
@@ -127,6 +132,10 @@ type GenerateTemplateInput struct {
 	// Labels is an optional map of labels to be added to the object.
 	// +optional
 	Labels map[string]string
+
+	// Name is an optional name for the cloned object; if empty, the name is generated from the template name.
+	// +optional
+	Name string
 }
 
 func GenerateTemplate(in *GenerateTemplateInput) (*unstructured.Unstructured, error) {
@@ -143,7 +152,11 @@ func GenerateTemplate(in *GenerateTemplateInput) (*unstructured.Unstructured, er
 	to.SetFinalizers(nil)
 	to.SetUID("")
 	to.SetSelfLink("")
-	to.SetName(names.SimpleNameGenerator.GenerateName(in.Template.GetName() + "-"))
+	if in.Name != "" {
+		to.SetName(in.Name)
+	} else {
+		to.SetName(names.SimpleNameGenerator.GenerateName(in.Template.GetName() + "-"))
+	}
 	to.SetNamespace(in.Namespace)
 
 	if to.GetAnnotations() == nil {
@@ -44,8 +44,11 @@ import (
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/predicates"
+	"sigs.k8s.io/cluster-api/util/trace"
+	"sigs.k8s.io/cluster-api/util/tuning"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -75,6 +78,17 @@ type MachineReconciler struct {
 	Log     logr.Logger
 	Tracker *remote.ClusterCacheTracker
 
+	// NodeVolumeDetachTimeout is the time to wait for the volumes attached to the node of a machine being deleted
+	// to be detached; it defaults to DefaultNodeVolumeDetachTimeout.
+	NodeVolumeDetachTimeout time.Duration
+
+	// EnableDeletionSafetyCheck prevents deleting the infrastructure of machines whose node is still Ready, unless
+	// the machines have been marked unhealthy by a MachineHealthCheck or the deletion is forced with an annotation.
+	EnableDeletionSafetyCheck bool
+
+	// Tuning configures the maximum requeue delay and the per-namespace concurrency of the reconciliations.
+	Tuning tuning.Options
+
 	config          *rest.Config
 	scheme          *runtime.Scheme
 	recorder        record.EventRecorder
@@ -91,7 +105,7 @@ func (r *MachineReconciler) SetupWithManager(mgr ctrl.Manager, options controlle
 		For(&clusterv1.Machine{}).
 		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPaused(r.Log)).
-		Build(r)
+		Build(tuning.Wrap(r, r.Tuning))
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
 	}
@@ -134,7 +148,11 @@ func (r *MachineReconciler) clusterToActiveMachines(a handler.MapObject) []recon
 
 func (r *MachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
 	ctx := context.Background()
-	logger := r.Log.WithValues("machine", req.Name, "namespace", req.Namespace)
+	ctx, span := trace.Start(ctx, "MachineReconciler.Reconcile", trace.String(logutil.NamespaceKey, req.Namespace), trace.String(logutil.MachineKey, req.Name))
+	defer func() {
+		span.RecordError(reterr)
+		span.End()
+	}()
 
 	// Fetch the Machine instance
 	m := &clusterv1.Machine{}
@@ -149,6 +167,9 @@ func (r *MachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr e
 		return ctrl.Result{}, err
 	}
 
+	logger := logutil.ForMachine(logutil.WithReconcileID(r.Log), m)
+	ctx = logutil.IntoContext(ctx, logger)
+
 	cluster, err := util.GetClusterByName(ctx, r.Client, m.ObjectMeta.Namespace, m.Spec.ClusterName)
 	if err != nil {
 		return ctrl.Result{}, errors.Wrapf(err, "failed to get cluster %q for machine %q in namespace %q",
@@ -217,8 +238,7 @@ func (r *MachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr e
 }
 
 func (r *MachineReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (ctrl.Result, error) {
-	logger := r.Log.WithValues("machine", m.Name, "namespace", m.Namespace)
-	logger = logger.WithValues("cluster", cluster.Name)
+	logger := logutil.FromContext(ctx, logutil.ForMachine(r.Log, m))
 
 	// If the Machine belongs to a cluster, add an owner reference.
 	if r.shouldAdopt(m) {
@@ -235,6 +255,9 @@ func (r *MachineReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cl
 		r.reconcileBootstrap(ctx, cluster, m),
 		r.reconcileInfrastructure(ctx, cluster, m),
 		r.reconcileNodeRef(ctx, cluster, m),
+		r.reconcileNodeMetadata(ctx, cluster, m),
+		r.reconcileInterruptibleNodeLabel(ctx, cluster, m),
+		r.reconcileTerminationNotice(ctx, cluster, m),
 	}
 
 	// Parse the errors, making sure we record if there is a RequeueAfterError.
@@ -256,6 +279,33 @@ func (r *MachineReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cl
 	return res, kerrors.NewAggregate(errs)
 }
 
+// reconcileTerminationNotice drains the Node of a Machine as soon as the infrastructure provider reports that the
+// infrastructure is going to be reclaimed, so workloads are moved before the Node disappears.
+func (r *MachineReconciler) reconcileTerminationNotice(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
+	logger := logutil.FromContext(ctx, logutil.ForMachine(r.Log, m))
+
+	if !conditions.IsTrue(m, clusterv1.TerminationNoticeReceivedCondition) || m.Status.NodeRef == nil {
+		return nil
+	}
+	if conditions.IsTrue(m, clusterv1.PreTerminationDrainSucceededCondition) {
+		return nil
+	}
+	if _, exists := m.ObjectMeta.Annotations[clusterv1.ExcludeNodeDrainingAnnotation]; exists {
+		return nil
+	}
+
+	logger.Info("Termination notice received, draining node", "node", m.Status.NodeRef.Name)
+	if err := r.drainNode(ctx, cluster, m.Status.NodeRef.Name, m.Name); err != nil {
+		conditions.MarkFalse(m, clusterv1.PreTerminationDrainSucceededCondition, clusterv1.DrainingFailedReason, clusterv1.ConditionSeverityWarning, "Draining the node failed")
+		r.recorder.Eventf(m, corev1.EventTypeWarning, "FailedDrainNode", "error draining Machine's node %q: %v", m.Status.NodeRef.Name, err)
+		return err
+	}
+
+	conditions.MarkTrue(m, clusterv1.PreTerminationDrainSucceededCondition)
+	r.recorder.Eventf(m, corev1.EventTypeNormal, "SuccessfulDrainNode", "success draining Machine's node %q after a termination notice", m.Status.NodeRef.Name)
+	return nil
+}
+
 func (r *MachineReconciler) reconcileMetrics(_ context.Context, m *clusterv1.Machine) {
 	if m.Status.BootstrapReady {
 		metrics.MachineBootstrapReady.WithLabelValues(m.Name, m.Namespace, m.Spec.ClusterName).Set(1)
@@ -275,10 +325,18 @@ func (r *MachineReconciler) reconcileMetrics(_ context.Context, m *clusterv1.Mac
 }
 
 func (r *MachineReconciler) reconcileDelete(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (ctrl.Result, error) {
-	logger := r.Log.WithValues("machine", m.Name, "namespace", m.Namespace)
-	logger = logger.WithValues("cluster", cluster.Name)
+	logger := logutil.FromContext(ctx, logutil.ForMachine(r.Log, m))
 
-	err := r.isDeleteNodeAllowed(ctx, cluster, m)
+	// Do not drain the node nor delete the infrastructure of a machine whose node is still Ready, if not expected.
+	ok, err := r.reconcileDeletionSafetyCheck(ctx, cluster, m)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !ok {
+		return ctrl.Result{RequeueAfter: deletionSafetyCheckRequeueAfter}, nil
+	}
+
+	err = r.isDeleteNodeAllowed(ctx, cluster, m)
 	isDeleteNodeAllowed := err == nil
 	if err != nil {
 		switch err {
@@ -299,6 +357,17 @@ func (r *MachineReconciler) reconcileDelete(ctx context.Context, cluster *cluste
 			}
 			r.recorder.Eventf(m, corev1.EventTypeNormal, "SuccessfulDrainNode", "success draining Machine's node %q", m.Status.NodeRef.Name)
 		}
+
+		// Wait for the node volumes to be detached before deleting the infrastructure.
+		if _, exists := m.ObjectMeta.Annotations[clusterv1.ExcludeWaitForNodeVolumeDetachAnnotation]; !exists {
+			done, err := r.reconcileVolumeDetach(ctx, cluster, m)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if !done {
+				return ctrl.Result{RequeueAfter: volumeDetachRequeueAfter}, nil
+			}
+		}
 	}
 
 	if ok, err := r.reconcileDeleteExternal(ctx, m); !ok || err != nil {
@@ -363,7 +432,7 @@ func (r *MachineReconciler) isDeleteNodeAllowed(ctx context.Context, cluster *cl
 }
 
 func (r *MachineReconciler) drainNode(ctx context.Context, cluster *clusterv1.Cluster, nodeName string, machineName string) error {
-	logger := r.Log.WithValues("machine", machineName, "node", nodeName, "cluster", cluster.Name, "namespace", cluster.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForCluster(r.Log, cluster).WithValues(logutil.MachineKey, machineName)).WithValues("node", nodeName)
 
 	restConfig, err := remote.RESTConfig(ctx, r.Client, util.ObjectKey(cluster))
 	if err != nil {
@@ -430,7 +499,7 @@ func (r *MachineReconciler) drainNode(ctx context.Context, cluster *clusterv1.Cl
 }
 
 func (r *MachineReconciler) deleteNode(ctx context.Context, cluster *clusterv1.Cluster, name string) error {
-	logger := r.Log.WithValues("machine", name, "cluster", cluster.Name, "namespace", cluster.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForCluster(r.Log, cluster)).WithValues("node", name)
 
 	remoteClient, err := r.Tracker.GetClient(ctx, util.ObjectKey(cluster))
 	if err != nil {
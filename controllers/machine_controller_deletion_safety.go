@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/noderefutil"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	logutil "sigs.k8s.io/cluster-api/util/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// deletionSafetyCheckRequeueAfter is the interval between checks of the node of a machine being deleted whose
+// infrastructure deletion is blocked by the deletion safety check.
+const deletionSafetyCheckRequeueAfter = 30 * time.Second
+
+// reconcileDeletionSafetyCheck checks if the infrastructure of a machine being deleted can be deleted, when the
+// deletion safety check is enabled; it returns true if the node of the machine is not Ready, or if the deletion is
+// expected, i.e. if the machine has been marked unhealthy by a MachineHealthCheck, if the deletion is forced with the
+// force-deletion annotation, or if the cluster is being deleted. An error is returned if the node can't be read.
+// This protects healthy machines from accidental deletions, e.g. deleting the machines matching a wrong selector.
+func (r *MachineReconciler) reconcileDeletionSafetyCheck(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (bool, error) {
+	if !r.EnableDeletionSafetyCheck || m.Status.NodeRef == nil {
+		return true, nil
+	}
+
+	if !cluster.DeletionTimestamp.IsZero() ||
+		annotations.IsMachineDeletionForced(m) ||
+		conditions.IsFalse(m, clusterv1.MachineHealthCheckSuccededCondition) ||
+		conditions.IsFalse(m, clusterv1.MachineOwnerRemediatedCondition) {
+		conditions.MarkTrue(m, clusterv1.DeletionSafetyCheckSucceededCondition)
+		return true, nil
+	}
+
+	remoteClient, err := r.Tracker.GetClient(ctx, util.ObjectKey(cluster))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to create a remote client for checking the node of machine %q", m.Name)
+	}
+
+	return r.checkNodeNotReady(ctx, remoteClient, m)
+}
+
+// checkNodeNotReady returns true if the node of a machine being deleted is not Ready, recording the result in the
+// DeletionSafetyCheckSucceeded condition.
+func (r *MachineReconciler) checkNodeNotReady(ctx context.Context, remoteClient client.Client, m *clusterv1.Machine) (bool, error) {
+	logger := logutil.FromContext(ctx, logutil.ForMachine(r.Log, m)).WithValues("node", m.Status.NodeRef.Name)
+
+	node := &corev1.Node{}
+	if err := remoteClient.Get(ctx, client.ObjectKey{Name: m.Status.NodeRef.Name}, node); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, errors.Wrapf(err, "failed to get node %q", m.Status.NodeRef.Name)
+		}
+		node = nil
+	}
+
+	if node == nil || !noderefutil.IsNodeReady(node) {
+		conditions.MarkTrue(m, clusterv1.DeletionSafetyCheckSucceededCondition)
+		return true, nil
+	}
+
+	logger.Info("Not deleting the infrastructure of the machine, the node is still Ready", "annotation", clusterv1.ForceMachineDeletionAnnotation)
+	if !conditions.IsFalse(m, clusterv1.DeletionSafetyCheckSucceededCondition) {
+		r.recorder.Eventf(m, corev1.EventTypeWarning, "DeletionBlocked",
+			"Machine's node %q is still Ready; set the %s annotation to delete the Machine", m.Status.NodeRef.Name, clusterv1.ForceMachineDeletionAnnotation)
+	}
+	conditions.MarkFalse(m, clusterv1.DeletionSafetyCheckSucceededCondition, clusterv1.NodeStillReadyReason, clusterv1.ConditionSeverityWarning,
+		"The node is still Ready; the machine must be marked unhealthy by a MachineHealthCheck, or annotated with %s, for deleting it", clusterv1.ForceMachineDeletionAnnotation)
+	return false, nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/test/helpers"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestReconcileDeletionSafetyCheck(t *testing.T) {
+	now := metav1.Now()
+
+	tests := []struct {
+		name        string
+		enabled     bool
+		cluster     *clusterv1.Cluster
+		annotations map[string]string
+		conditions  clusterv1.Conditions
+		wantOK      bool
+	}{
+		{
+			name:    "allowed if the check is disabled",
+			cluster: &clusterv1.Cluster{},
+			wantOK:  true,
+		},
+		{
+			name:    "allowed if the cluster is being deleted",
+			enabled: true,
+			cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now}},
+			wantOK:  true,
+		},
+		{
+			name:        "allowed if the deletion is forced",
+			enabled:     true,
+			cluster:     &clusterv1.Cluster{},
+			annotations: map[string]string{clusterv1.ForceMachineDeletionAnnotation: ""},
+			wantOK:      true,
+		},
+		{
+			name:    "allowed if the machine is marked unhealthy by a MachineHealthCheck",
+			enabled: true,
+			cluster: &clusterv1.Cluster{},
+			conditions: clusterv1.Conditions{
+				*conditions.FalseCondition(clusterv1.MachineOwnerRemediatedCondition, clusterv1.WaitingForRemediation, clusterv1.ConditionSeverityWarning, ""),
+			},
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "default", Annotations: tt.annotations},
+				Status: clusterv1.MachineStatus{
+					NodeRef:    &corev1.ObjectReference{Name: "node-1"},
+					Conditions: tt.conditions,
+				},
+			}
+			r := &MachineReconciler{
+				Log:                       log.Log,
+				EnableDeletionSafetyCheck: tt.enabled,
+				recorder:                  record.NewFakeRecorder(32),
+			}
+
+			ok, err := r.reconcileDeletionSafetyCheck(context.Background(), tt.cluster, m)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ok).To(Equal(tt.wantOK))
+		})
+	}
+}
+
+func TestReconcileDeletionSafetyCheckUnreachableCluster(t *testing.T) {
+	g := NewWithT(t)
+
+	// The tracker can't create a remote client, because the kubeconfig secret of the cluster does not exist.
+	tracker, err := remote.NewClusterCacheTracker(log.Log, fakeManager{Client: helpers.NewFakeClientWithScheme(scheme.Scheme)})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	m := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "default"},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: "node-1"},
+		},
+	}
+	r := &MachineReconciler{
+		Log:                       log.Log,
+		Tracker:                   tracker,
+		EnableDeletionSafetyCheck: true,
+		recorder:                  record.NewFakeRecorder(32),
+	}
+
+	ok, err := r.reconcileDeletionSafetyCheck(context.Background(), &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}, m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(ok).To(BeFalse())
+	g.Expect(conditions.Get(m, clusterv1.DeletionSafetyCheckSucceededCondition)).To(BeNil())
+}
+
+// fakeManager is a manager providing only a client and a scheme, enough for creating a ClusterCacheTracker.
+type fakeManager struct {
+	ctrl.Manager
+	Client client.Client
+}
+
+func (m fakeManager) GetClient() client.Client {
+	return m.Client
+}
+
+func (m fakeManager) GetScheme() *runtime.Scheme {
+	return scheme.Scheme
+}
+
+func TestCheckNodeNotReady(t *testing.T) {
+	node := func(ready corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		objs       []runtime.Object
+		wantOK     bool
+		wantReason string
+	}{
+		{
+			name:   "allowed if the node does not exist",
+			wantOK: true,
+		},
+		{
+			name:   "allowed if the node is not ready",
+			objs:   []runtime.Object{node(corev1.ConditionFalse)},
+			wantOK: true,
+		},
+		{
+			name:   "allowed if the node is unreachable",
+			objs:   []runtime.Object{node(corev1.ConditionUnknown)},
+			wantOK: true,
+		},
+		{
+			name:       "blocked if the node is ready",
+			objs:       []runtime.Object{node(corev1.ConditionTrue)},
+			wantOK:     false,
+			wantReason: clusterv1.NodeStillReadyReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "default"},
+				Status: clusterv1.MachineStatus{
+					NodeRef: &corev1.ObjectReference{Name: "node-1"},
+				},
+			}
+			remoteClient := helpers.NewFakeClientWithScheme(scheme.Scheme, tt.objs...)
+			r := &MachineReconciler{
+				Log:                       log.Log,
+				EnableDeletionSafetyCheck: true,
+				recorder:                  record.NewFakeRecorder(32),
+			}
+
+			ok, err := r.checkNodeNotReady(context.Background(), remoteClient, m)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ok).To(Equal(tt.wantOK))
+			g.Expect(conditions.Get(m, clusterv1.DeletionSafetyCheckSucceededCondition)).NotTo(BeNil())
+			g.Expect(conditions.IsTrue(m, clusterv1.DeletionSafetyCheckSucceededCondition)).To(Equal(tt.wantOK))
+			g.Expect(conditions.GetReason(m, clusterv1.DeletionSafetyCheckSucceededCondition)).To(Equal(tt.wantReason))
+		})
+	}
+}
@@ -22,10 +22,12 @@ import (
 
 	"github.com/pkg/errors"
 	apicorev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controllers/noderefutil"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -34,7 +36,7 @@ var (
 )
 
 func (r *MachineReconciler) reconcileNodeRef(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
-	logger := r.Log.WithValues("machine", machine.Name, "namespace", machine.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForMachine(r.Log, machine))
 	// Check that the Machine hasn't been deleted or in the process.
 	if !machine.DeletionTimestamp.IsZero() {
 		return nil
@@ -45,8 +47,6 @@ func (r *MachineReconciler) reconcileNodeRef(ctx context.Context, cluster *clust
 		return nil
 	}
 
-	logger = logger.WithValues("cluster", cluster.Name)
-
 	// Check that the Machine has a valid ProviderID.
 	if machine.Spec.ProviderID == nil || *machine.Spec.ProviderID == "" {
 		logger.Info("Machine doesn't have a valid ProviderID yet")
@@ -82,6 +82,109 @@ func (r *MachineReconciler) reconcileNodeRef(ctx context.Context, cluster *clust
 	return nil
 }
 
+// reconcileNodeMetadata propagates the labels and annotations with the propagated metadata domain from the Machine
+// to its Node, so they can be updated without recreating the Machine.
+func (r *MachineReconciler) reconcileNodeMetadata(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
+	if !machine.DeletionTimestamp.IsZero() || machine.Status.NodeRef == nil {
+		return nil
+	}
+
+	// If the Machine has no propagated labels or annotations, and none were applied to the Node before, there is nothing to do.
+	hasPropagatedMetadata := len(util.RemovePropagatedMetadata(machine.Labels)) != len(machine.Labels) ||
+		len(util.RemovePropagatedMetadata(machine.Annotations)) != len(machine.Annotations)
+	if _, propagated := machine.Annotations[clusterv1.NodeMetadataPropagatedAnnotation]; !hasPropagatedMetadata && !propagated {
+		return nil
+	}
+
+	remoteClient, err := r.Tracker.GetClient(ctx, util.ObjectKey(cluster))
+	if err != nil {
+		return err
+	}
+
+	if err := syncNodeMetadata(ctx, remoteClient, machine); err != nil {
+		return err
+	}
+
+	if hasPropagatedMetadata {
+		if machine.Annotations == nil {
+			machine.Annotations = map[string]string{}
+		}
+		machine.Annotations[clusterv1.NodeMetadataPropagatedAnnotation] = ""
+	} else {
+		delete(machine.Annotations, clusterv1.NodeMetadataPropagatedAnnotation)
+	}
+	return nil
+}
+
+// syncNodeMetadata updates in place the propagated labels and annotations of the Node referenced by the Machine.
+func syncNodeMetadata(ctx context.Context, c client.Client, machine *clusterv1.Machine) error {
+	node := &apicorev1.Node{}
+	if err := c.Get(ctx, client.ObjectKey{Name: machine.Status.NodeRef.Name}, node); err != nil {
+		// Nb. a missing Node is handled by MachineHealthChecks.
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get Node %q", machine.Status.NodeRef.Name)
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	var labelsUpdated, annotationsUpdated bool
+	node.Labels, labelsUpdated = util.SyncPropagatedMetadata(node.Labels, machine.Labels)
+	node.Annotations, annotationsUpdated = util.SyncPropagatedMetadata(node.Annotations, machine.Annotations)
+	if !labelsUpdated && !annotationsUpdated {
+		return nil
+	}
+
+	if err := c.Patch(ctx, node, patch); err != nil {
+		return errors.Wrapf(err, "failed to patch Node %q", node.Name)
+	}
+	return nil
+}
+
+// reconcileInterruptibleNodeLabel sets the interruptible label on the Node of an interruptible Machine, so workloads
+// can be scheduled on, or kept away from, Nodes that can be reclaimed at any time.
+func (r *MachineReconciler) reconcileInterruptibleNodeLabel(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
+	if !machine.DeletionTimestamp.IsZero() || machine.Status.NodeRef == nil {
+		return nil
+	}
+	if _, interruptible := machine.Labels[clusterv1.InterruptibleLabel]; !interruptible {
+		return nil
+	}
+
+	remoteClient, err := r.Tracker.GetClient(ctx, util.ObjectKey(cluster))
+	if err != nil {
+		return err
+	}
+
+	return setInterruptibleNodeLabel(ctx, remoteClient, machine)
+}
+
+// setInterruptibleNodeLabel adds the interruptible label to the Node referenced by the Machine, if missing.
+func setInterruptibleNodeLabel(ctx context.Context, c client.Client, machine *clusterv1.Machine) error {
+	node := &apicorev1.Node{}
+	if err := c.Get(ctx, client.ObjectKey{Name: machine.Status.NodeRef.Name}, node); err != nil {
+		// Nb. a missing Node is handled by MachineHealthChecks.
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get Node %q", machine.Status.NodeRef.Name)
+	}
+
+	if _, ok := node.Labels[clusterv1.InterruptibleLabel]; ok {
+		return nil
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+	node.Labels[clusterv1.InterruptibleLabel] = ""
+	if err := c.Patch(ctx, node, patch); err != nil {
+		return errors.Wrapf(err, "failed to patch Node %q", node.Name)
+	}
+	return nil
+}
+
 func (r *MachineReconciler) getNodeReference(c client.Reader, providerID *noderefutil.ProviderID) (*apicorev1.ObjectReference, error) {
 	logger := r.Log.WithValues("providerID", providerID)
 
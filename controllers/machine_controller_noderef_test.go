@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -126,3 +127,87 @@ func TestGetNodeReference(t *testing.T) {
 
 	}
 }
+
+func TestSyncNodeMetadata(t *testing.T) {
+	g := NewWithT(t)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-1",
+			Labels: map[string]string{
+				"node.cluster.x-k8s.io/stale": "true",
+				"kubernetes.io/hostname":      "node-1",
+			},
+		},
+	}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "machine-1",
+			Labels: map[string]string{
+				"node.cluster.x-k8s.io/role": "ingress",
+				"not-propagated":             "true",
+			},
+			Annotations: map[string]string{
+				"node.cluster.x-k8s.io/owner": "team-a",
+			},
+		},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: "node-1"},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, node)
+	g.Expect(syncNodeMetadata(ctx, c, machine)).To(Succeed())
+
+	updated := &corev1.Node{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: "node-1"}, updated)).To(Succeed())
+	g.Expect(updated.Labels).To(Equal(map[string]string{
+		"node.cluster.x-k8s.io/role": "ingress",
+		"kubernetes.io/hostname":     "node-1",
+	}))
+	g.Expect(updated.Annotations).To(Equal(map[string]string{
+		"node.cluster.x-k8s.io/owner": "team-a",
+	}))
+
+	// A missing Node is ignored.
+	machine.Status.NodeRef.Name = "node-2"
+	g.Expect(syncNodeMetadata(ctx, c, machine)).To(Succeed())
+}
+
+func TestSetInterruptibleNodeLabel(t *testing.T) {
+	g := NewWithT(t)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-1",
+			Labels: map[string]string{
+				"kubernetes.io/hostname": "node-1",
+			},
+		},
+	}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "machine-1",
+			Labels: map[string]string{
+				clusterv1.InterruptibleLabel: "",
+			},
+		},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: "node-1"},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, node)
+	g.Expect(setInterruptibleNodeLabel(ctx, c, machine)).To(Succeed())
+
+	updated := &corev1.Node{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: "node-1"}, updated)).To(Succeed())
+	g.Expect(updated.Labels).To(Equal(map[string]string{
+		clusterv1.InterruptibleLabel: "",
+		"kubernetes.io/hostname":     "node-1",
+	}))
+
+	// A missing Node is ignored.
+	machine.Status.NodeRef.Name = "node-2"
+	g.Expect(setInterruptibleNodeLabel(ctx, c, machine)).To(Succeed())
+}
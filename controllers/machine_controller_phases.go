@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 
@@ -39,6 +40,7 @@ import (
 	"sigs.k8s.io/cluster-api/controllers/external"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/cluster-api/util/patch"
 )
 
@@ -88,7 +90,7 @@ func (r *MachineReconciler) reconcilePhase(_ context.Context, m *clusterv1.Machi
 
 // reconcileExternal handles generic unstructured objects referenced by a Machine.
 func (r *MachineReconciler) reconcileExternal(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine, ref *corev1.ObjectReference) (external.ReconcileOutput, error) {
-	logger := r.Log.WithValues("machine", m.Name, "namespace", m.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForMachine(r.Log, m))
 
 	if err := utilconversion.ConvertReferenceAPIContract(ctx, r.Client, ref); err != nil {
 		return external.ReconcileOutput{}, err
@@ -175,6 +177,27 @@ func (r *MachineReconciler) reconcileExternal(ctx context.Context, cluster *clus
 func (r *MachineReconciler) reconcileBootstrap(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
 	// If the bootstrap data is populated, set ready and return.
 	if m.Spec.Bootstrap.DataSecretName != nil {
+		// If there is no bootstrap object, the data secret is provided by the user and it can be shared by many
+		// machines; wait for it to exist, so the infrastructure provider does not fail reading it.
+		if m.Spec.Bootstrap.ConfigRef == nil {
+			if err := r.Client.Get(ctx, client.ObjectKey{Namespace: m.Namespace, Name: *m.Spec.Bootstrap.DataSecretName}, &corev1.Secret{}); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return errors.Wrapf(err, "failed to get bootstrap data secret %q for Machine %q in namespace %q", *m.Spec.Bootstrap.DataSecretName, m.Name, m.Namespace)
+				}
+				m.Status.BootstrapReady = false
+				conditions.MarkFalse(m, clusterv1.BootstrapReadyCondition, clusterv1.DataSecretNotFoundReason, clusterv1.ConditionSeverityWarning,
+					"Bootstrap data secret %q not found", *m.Spec.Bootstrap.DataSecretName)
+				return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
+					"bootstrap data secret %q for Machine %q in namespace %q not found, requeuing", *m.Spec.Bootstrap.DataSecretName, m.Name, m.Namespace)
+			}
+		}
+		// Until the Machine gets a Node, the bootstrap provider could regenerate the bootstrap data, e.g. because the
+		// join token expired before the infrastructure consumed it.
+		if m.Spec.Bootstrap.ConfigRef != nil && m.Status.NodeRef == nil {
+			if err := r.reconcileBootstrapDataRegenerated(ctx, m); err != nil {
+				return err
+			}
+		}
 		m.Status.BootstrapReady = true
 		conditions.MarkTrue(m, clusterv1.BootstrapReadyCondition)
 		return nil
@@ -232,6 +255,31 @@ func (r *MachineReconciler) reconcileBootstrap(ctx context.Context, cluster *clu
 	return nil
 }
 
+// reconcileBootstrapDataRegenerated propagates the BootstrapDataRegeneratedAnnotation set by the bootstrap provider
+// on the bootstrap config to the Machine.
+func (r *MachineReconciler) reconcileBootstrapDataRegenerated(ctx context.Context, m *clusterv1.Machine) error {
+	bootstrapConfig, err := external.Get(ctx, r.Client, m.Spec.Bootstrap.ConfigRef, m.Namespace)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			return nil
+		}
+		return err
+	}
+
+	regenerated, ok := bootstrapConfig.GetAnnotations()[clusterv1.BootstrapDataRegeneratedAnnotation]
+	if !ok || m.Annotations[clusterv1.BootstrapDataRegeneratedAnnotation] == regenerated {
+		return nil
+	}
+
+	logger := logutil.FromContext(ctx, logutil.ForMachine(r.Log, m))
+	logger.Info("Bootstrap data regenerated by the bootstrap provider", "time", regenerated)
+	if m.Annotations == nil {
+		m.Annotations = map[string]string{}
+	}
+	m.Annotations[clusterv1.BootstrapDataRegeneratedAnnotation] = regenerated
+	return nil
+}
+
 // reconcileInfrastructure reconciles the Spec.InfrastructureRef object on a Machine.
 func (r *MachineReconciler) reconcileInfrastructure(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
 	// Call generic external reconciler.
@@ -256,6 +304,20 @@ func (r *MachineReconciler) reconcileInfrastructure(ctx context.Context, cluster
 		return nil
 	}
 
+	// Signal the infrastructure provider the bootstrap data is regenerated, so providers supporting it can re-inject the user data.
+	if regenerated, ok := m.Annotations[clusterv1.BootstrapDataRegeneratedAnnotation]; ok && infraConfig.GetAnnotations()[clusterv1.BootstrapDataRegeneratedAnnotation] != regenerated {
+		patchBase := client.MergeFrom(infraConfig.DeepCopy())
+		infraAnnotations := infraConfig.GetAnnotations()
+		if infraAnnotations == nil {
+			infraAnnotations = map[string]string{}
+		}
+		infraAnnotations[clusterv1.BootstrapDataRegeneratedAnnotation] = regenerated
+		infraConfig.SetAnnotations(infraAnnotations)
+		if err := r.Client.Patch(ctx, infraConfig, patchBase); err != nil {
+			return errors.Wrapf(err, "failed to signal the regeneration of the bootstrap data to the infrastructure provider for Machine %q in namespace %q", m.Name, m.Namespace)
+		}
+	}
+
 	// Determine if the infrastructure provider is ready.
 	ready, err := external.IsReady(infraConfig)
 	if err != nil {
@@ -269,6 +331,11 @@ func (r *MachineReconciler) reconcileInfrastructure(ctx context.Context, cluster
 		conditions.WithFallbackValue(ready, clusterv1.WaitingForInfrastructureFallbackReason, clusterv1.ConditionSeverityInfo, ""),
 	)
 
+	// Mirror the termination notice reported by providers supporting interruptible machines.
+	if terminationNotice := conditions.Get(conditions.UnstructuredGetter(infraConfig), clusterv1.TerminationNoticeReceivedCondition); terminationNotice != nil {
+		conditions.Set(m, terminationNotice)
+	}
+
 	// If the infrastructure provider is not ready, return early.
 	if !ready {
 		return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
@@ -301,6 +368,22 @@ func (r *MachineReconciler) reconcileInfrastructure(ctx context.Context, cluster
 		m.Spec.FailureDomain = pointer.StringPtr(failureDomain)
 	}
 
+	// Get the interruptible flag from the infrastructure provider, and surface it as a label on the Machine.
+	var interruptible bool
+	err = util.UnstructuredUnmarshalField(infraConfig, &interruptible, "status", "interruptible")
+	switch {
+	case err == util.ErrUnstructuredFieldNotFound: // no-op
+	case err != nil:
+		return errors.Wrapf(err, "failed to retrieve interruptible from infrastructure provider for Machine %q in namespace %q", m.Name, m.Namespace)
+	case interruptible:
+		if m.Labels == nil {
+			m.Labels = map[string]string{}
+		}
+		m.Labels[clusterv1.InterruptibleLabel] = ""
+	default:
+		delete(m.Labels, clusterv1.InterruptibleLabel)
+	}
+
 	m.Spec.ProviderID = pointer.StringPtr(providerID)
 	return nil
 }
@@ -28,12 +28,15 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -810,6 +813,150 @@ func TestReconcileBootstrap(t *testing.T) {
 	}
 }
 
+func TestReconcileBootstrapSharedDataSecret(t *testing.T) {
+	sharedSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "workers-bootstrap-data",
+			Namespace: "default",
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		objs        []runtime.Object
+		expectError bool
+		expectReady bool
+	}{
+		{
+			name:        "shared data secret exists",
+			objs:        []runtime.Object{sharedSecret},
+			expectReady: true,
+		},
+		{
+			name:        "shared data secret does not exist",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+			machine := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "machine-test",
+					Namespace: "default",
+				},
+				Spec: clusterv1.MachineSpec{
+					Bootstrap: clusterv1.Bootstrap{
+						DataSecretName: pointer.StringPtr(sharedSecret.Name),
+					},
+				},
+			}
+
+			r := &MachineReconciler{
+				Client: fake.NewFakeClientWithScheme(scheme.Scheme, append(tc.objs, machine)...),
+				Log:    log.Log,
+				scheme: scheme.Scheme,
+			}
+
+			err := r.reconcileBootstrap(context.Background(), &clusterv1.Cluster{}, machine)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(machine.Status.BootstrapReady).To(Equal(tc.expectReady))
+			if tc.expectReady {
+				g.Expect(conditions.IsTrue(machine, clusterv1.BootstrapReadyCondition)).To(BeTrue())
+			} else {
+				g.Expect(conditions.GetReason(machine, clusterv1.BootstrapReadyCondition)).To(Equal(clusterv1.DataSecretNotFoundReason))
+			}
+		})
+	}
+}
+
+func TestReconcileBootstrapDataRegenerated(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine-test",
+			Namespace: "default",
+		},
+		Spec: clusterv1.MachineSpec{
+			Bootstrap: clusterv1.Bootstrap{
+				ConfigRef: &corev1.ObjectReference{
+					APIVersion: "bootstrap.cluster.x-k8s.io/v1alpha3",
+					Kind:       "BootstrapMachine",
+					Name:       "bootstrap-config1",
+				},
+				DataSecretName: pointer.StringPtr("secret-data"),
+			},
+			InfrastructureRef: corev1.ObjectReference{
+				APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+				Kind:       "InfrastructureMachine",
+				Name:       "infra-config1",
+			},
+		},
+	}
+	bootstrapConfig := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":       "BootstrapMachine",
+		"apiVersion": "bootstrap.cluster.x-k8s.io/v1alpha3",
+		"metadata": map[string]interface{}{
+			"name":      "bootstrap-config1",
+			"namespace": "default",
+			"annotations": map[string]interface{}{
+				clusterv1.BootstrapDataRegeneratedAnnotation: "2020-10-01T10:00:00Z",
+			},
+		},
+		"status": map[string]interface{}{
+			"ready":          true,
+			"dataSecretName": "secret-data",
+		},
+	}}
+	infraConfig := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":       "InfrastructureMachine",
+		"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+		"metadata": map[string]interface{}{
+			"name":      "infra-config1",
+			"namespace": "default",
+		},
+	}}
+
+	r := &MachineReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme,
+			machine,
+			external.TestGenericBootstrapCRD.DeepCopy(),
+			external.TestGenericInfrastructureCRD.DeepCopy(),
+			bootstrapConfig,
+			infraConfig,
+		),
+		Log:    log.Log,
+		scheme: scheme.Scheme,
+	}
+
+	// The regeneration of the bootstrap data is propagated to the Machine...
+	g.Expect(r.reconcileBootstrap(context.Background(), &clusterv1.Cluster{}, machine)).To(Succeed())
+	g.Expect(machine.Annotations).To(HaveKeyWithValue(clusterv1.BootstrapDataRegeneratedAnnotation, "2020-10-01T10:00:00Z"))
+
+	// ...and to the infrastructure machine.
+	g.Expect(r.reconcileInfrastructure(context.Background(), &clusterv1.Cluster{}, machine)).NotTo(Succeed())
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "infra-config1"}, infraConfig)).To(Succeed())
+	g.Expect(infraConfig.GetAnnotations()).To(HaveKeyWithValue(clusterv1.BootstrapDataRegeneratedAnnotation, "2020-10-01T10:00:00Z"))
+
+	// Once the Machine has a Node, the bootstrap config is not checked anymore.
+	machine.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: "machine-test-node"}
+	bootstrapConfig.SetAnnotations(map[string]string{clusterv1.BootstrapDataRegeneratedAnnotation: "2020-10-01T11:00:00Z"})
+	g.Expect(r.Client.Update(context.Background(), bootstrapConfig)).To(Succeed())
+	g.Expect(r.reconcileBootstrap(context.Background(), &clusterv1.Cluster{}, machine)).To(Succeed())
+	g.Expect(machine.Annotations).To(HaveKeyWithValue(clusterv1.BootstrapDataRegeneratedAnnotation, "2020-10-01T10:00:00Z"))
+}
+
 func TestReconcileInfrastructure(t *testing.T) {
 	defaultMachine := clusterv1.Machine{
 		ObjectMeta: metav1.ObjectMeta{
@@ -948,6 +1095,37 @@ func TestReconcileInfrastructure(t *testing.T) {
 				g.Expect(m.Status.GetTypedPhase()).To(Equal(clusterv1.MachinePhaseFailed))
 			},
 		},
+		{
+			name: "interruptible infrastructure with a termination notice",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureMachine",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{
+					"providerID": "test://id-1",
+				},
+				"status": map[string]interface{}{
+					"ready":         true,
+					"interruptible": true,
+					"conditions": []interface{}{
+						map[string]interface{}{
+							"type":               string(clusterv1.TerminationNoticeReceivedCondition),
+							"status":             string(corev1.ConditionTrue),
+							"lastTransitionTime": "2020-07-01T00:00:00Z",
+						},
+					},
+				},
+			},
+			expectError:   false,
+			expectChanged: true,
+			expected: func(g *WithT, m *clusterv1.Machine) {
+				g.Expect(m.Labels).To(HaveKey(clusterv1.InterruptibleLabel))
+				g.Expect(conditions.IsTrue(m, clusterv1.TerminationNoticeReceivedCondition)).To(BeTrue())
+			},
+		},
 		{
 			name: "infrastructure ref is paused",
 			infraConfig: map[string]interface{}{
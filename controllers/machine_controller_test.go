@@ -33,6 +33,7 @@ import (
 	"sigs.k8s.io/cluster-api/controllers/external"
 	"sigs.k8s.io/cluster-api/test/helpers"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -613,6 +614,63 @@ func TestRemoveMachineFinalizerAfterDeleteReconcile(t *testing.T) {
 	g.Expect(actual.ObjectMeta.Finalizers).To(BeEmpty())
 }
 
+func TestReconcileTerminationNotice(t *testing.T) {
+	tests := []struct {
+		name    string
+		machine func(m *clusterv1.Machine)
+	}{
+		{
+			name:    "no termination notice",
+			machine: func(m *clusterv1.Machine) {},
+		},
+		{
+			name: "termination notice without a node",
+			machine: func(m *clusterv1.Machine) {
+				conditions.MarkTrue(m, clusterv1.TerminationNoticeReceivedCondition)
+				m.Status.NodeRef = nil
+			},
+		},
+		{
+			name: "termination notice with the node already drained",
+			machine: func(m *clusterv1.Machine) {
+				conditions.MarkTrue(m, clusterv1.TerminationNoticeReceivedCondition)
+				conditions.MarkTrue(m, clusterv1.PreTerminationDrainSucceededCondition)
+			},
+		},
+		{
+			name: "termination notice with node draining excluded",
+			machine: func(m *clusterv1.Machine) {
+				conditions.MarkTrue(m, clusterv1.TerminationNoticeReceivedCondition)
+				m.Annotations = map[string]string{clusterv1.ExcludeNodeDrainingAnnotation: ""}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine-1", Namespace: "default"},
+				Status: clusterv1.MachineStatus{
+					NodeRef: &corev1.ObjectReference{Name: "node-1"},
+				},
+			}
+			tt.machine(m)
+			drained := conditions.Get(m, clusterv1.PreTerminationDrainSucceededCondition)
+
+			r := &MachineReconciler{
+				Client: helpers.NewFakeClientWithScheme(scheme.Scheme),
+				Log:    log.Log,
+			}
+			cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"}}
+
+			// The node is not drained, so the drain condition is not changed.
+			g.Expect(r.reconcileTerminationNotice(context.Background(), cluster, m)).To(Succeed())
+			g.Expect(conditions.Get(m, clusterv1.PreTerminationDrainSucceededCondition)).To(Equal(drained))
+		})
+	}
+}
+
 func TestReconcileMetrics(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -0,0 +1,112 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	logutil "sigs.k8s.io/cluster-api/util/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultNodeVolumeDetachTimeout is the default time the machine controller waits for the volumes attached to
+	// the node of a machine being deleted to be detached before deleting the VolumeAttachments.
+	DefaultNodeVolumeDetachTimeout = 10 * time.Minute
+
+	// volumeDetachRequeueAfter is the interval between checks of the volumes attached to a node.
+	volumeDetachRequeueAfter = 10 * time.Second
+
+	// waitingForVolumeDetachMessage is the message of the VolumeDetachSucceeded condition while waiting; it must not
+	// change during the wait, otherwise the last transition time of the condition is reset.
+	waitingForVolumeDetachMessage = "Waiting for the volumes to be detached from the node"
+)
+
+// reconcileVolumeDetach waits for the volumes attached to the node of a machine being deleted to be detached, so
+// the infrastructure is not deleted while volumes are still attached to it; it returns true when the machine
+// controller can proceed with the deletion of the infrastructure.
+func (r *MachineReconciler) reconcileVolumeDetach(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (bool, error) {
+	logger := logutil.FromContext(ctx, logutil.ForMachine(r.Log, m)).WithValues("node", m.Status.NodeRef.Name)
+
+	remoteClient, err := r.Tracker.GetClient(ctx, util.ObjectKey(cluster))
+	if err != nil {
+		logger.Error(err, "Error creating a remote client for cluster while waiting for the node volumes to be detached, won't wait")
+		return true, nil
+	}
+
+	return r.waitForVolumeDetach(ctx, remoteClient, m)
+}
+
+// waitForVolumeDetach checks the VolumeAttachments of the node of a machine, recording the wait in the
+// VolumeDetachSucceeded condition; once the timeout expires, the remaining VolumeAttachments are deleted.
+func (r *MachineReconciler) waitForVolumeDetach(ctx context.Context, remoteClient client.Client, m *clusterv1.Machine) (bool, error) {
+	logger := logutil.FromContext(ctx, logutil.ForMachine(r.Log, m)).WithValues("node", m.Status.NodeRef.Name)
+	nodeName := m.Status.NodeRef.Name
+
+	// Do not wait again if the VolumeAttachments were already deleted after the timeout.
+	if conditions.GetReason(m, clusterv1.VolumeDetachSucceededCondition) == clusterv1.VolumeDetachTimedOutReason {
+		return true, nil
+	}
+
+	attachments := &storagev1.VolumeAttachmentList{}
+	if err := remoteClient.List(ctx, attachments); err != nil {
+		return false, errors.Wrapf(err, "failed to list VolumeAttachments for node %q", nodeName)
+	}
+	var attached []storagev1.VolumeAttachment
+	for _, attachment := range attachments.Items {
+		if attachment.Spec.NodeName == nodeName {
+			attached = append(attached, attachment)
+		}
+	}
+
+	if len(attached) == 0 {
+		conditions.MarkTrue(m, clusterv1.VolumeDetachSucceededCondition)
+		return true, nil
+	}
+
+	// The condition preserves its last transition time while waiting, so it records when the wait started.
+	conditions.MarkFalse(m, clusterv1.VolumeDetachSucceededCondition, clusterv1.WaitingForVolumeDetachReason, clusterv1.ConditionSeverityInfo, waitingForVolumeDetachMessage)
+
+	timeout := r.NodeVolumeDetachTimeout
+	if timeout == 0 {
+		timeout = DefaultNodeVolumeDetachTimeout
+	}
+	if waitingSince := conditions.GetLastTransitionTime(m, clusterv1.VolumeDetachSucceededCondition); time.Since(waitingSince.Time) < timeout {
+		logger.Info("Waiting for the node volumes to be detached", "volumeAttachments", len(attached))
+		return false, nil
+	}
+
+	// The volumes were not detached in time, e.g. because the node is unreachable; delete the VolumeAttachments
+	// so the volumes can be attached to other nodes, and proceed with the deletion of the machine.
+	logger.Info("Timed out waiting for the node volumes to be detached, deleting the VolumeAttachments", "volumeAttachments", len(attached))
+	for i := range attached {
+		if err := remoteClient.Delete(ctx, &attached[i]); err != nil && !apierrors.IsNotFound(err) {
+			return false, errors.Wrapf(err, "failed to delete VolumeAttachment %q for node %q", attached[i].Name, nodeName)
+		}
+	}
+	conditions.MarkFalse(m, clusterv1.VolumeDetachSucceededCondition, clusterv1.VolumeDetachTimedOutReason, clusterv1.ConditionSeverityWarning, "Timed out waiting for %d volumes to be detached from the node", len(attached))
+	r.recorder.Eventf(m, corev1.EventTypeWarning, "VolumeDetachTimedOut", "timed out waiting for the volumes of Machine's node %q to be detached, deleted %d VolumeAttachments", nodeName, len(attached))
+	return true, nil
+}
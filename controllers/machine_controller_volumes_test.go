@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/test/helpers"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestWaitForVolumeDetach(t *testing.T) {
+	volumeAttachment := func(name, nodeName string) *storagev1.VolumeAttachment {
+		return &storagev1.VolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       storagev1.VolumeAttachmentSpec{NodeName: nodeName},
+		}
+	}
+
+	twoMinutesAgo := time.Now().Add(-2 * time.Minute)
+
+	tests := []struct {
+		name            string
+		objs            []runtime.Object
+		waitingSince    *time.Time
+		timedOut        bool
+		wantDone        bool
+		wantReason      string
+		wantAttachments []string
+	}{
+		{
+			name:            "done if there are no volumes attached to the node",
+			objs:            []runtime.Object{volumeAttachment("other", "other-node")},
+			wantDone:        true,
+			wantAttachments: []string{"other"},
+		},
+		{
+			name:            "waits for the volumes attached to the node",
+			objs:            []runtime.Object{volumeAttachment("va1", "node-1"), volumeAttachment("other", "other-node")},
+			wantDone:        false,
+			wantReason:      clusterv1.WaitingForVolumeDetachReason,
+			wantAttachments: []string{"va1", "other"},
+		},
+		{
+			name:            "deletes the volume attachments of the node after the timeout",
+			objs:            []runtime.Object{volumeAttachment("va1", "node-1"), volumeAttachment("other", "other-node")},
+			waitingSince:    &twoMinutesAgo,
+			wantDone:        true,
+			wantReason:      clusterv1.VolumeDetachTimedOutReason,
+			wantAttachments: []string{"other"},
+		},
+		{
+			name:            "does not wait again after the timeout",
+			objs:            []runtime.Object{volumeAttachment("va1", "node-1")},
+			timedOut:        true,
+			wantDone:        true,
+			wantReason:      clusterv1.VolumeDetachTimedOutReason,
+			wantAttachments: []string{"va1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "default"},
+				Status: clusterv1.MachineStatus{
+					NodeRef: &corev1.ObjectReference{Name: "node-1"},
+				},
+			}
+			if tt.waitingSince != nil {
+				conditions.Set(m, &clusterv1.Condition{
+					Type:               clusterv1.VolumeDetachSucceededCondition,
+					Status:             corev1.ConditionFalse,
+					Severity:           clusterv1.ConditionSeverityInfo,
+					Reason:             clusterv1.WaitingForVolumeDetachReason,
+					Message:            waitingForVolumeDetachMessage,
+					LastTransitionTime: metav1.NewTime(*tt.waitingSince),
+				})
+			}
+			if tt.timedOut {
+				conditions.MarkFalse(m, clusterv1.VolumeDetachSucceededCondition, clusterv1.VolumeDetachTimedOutReason, clusterv1.ConditionSeverityWarning, "")
+			}
+
+			remoteClient := helpers.NewFakeClientWithScheme(scheme.Scheme, tt.objs...)
+			r := &MachineReconciler{
+				Log:                     log.Log,
+				NodeVolumeDetachTimeout: time.Minute,
+				recorder:                record.NewFakeRecorder(32),
+			}
+
+			done, err := r.waitForVolumeDetach(context.Background(), remoteClient, m)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(done).To(Equal(tt.wantDone))
+
+			if tt.wantReason == "" {
+				g.Expect(conditions.IsTrue(m, clusterv1.VolumeDetachSucceededCondition)).To(BeTrue())
+			} else {
+				g.Expect(conditions.GetReason(m, clusterv1.VolumeDetachSucceededCondition)).To(Equal(tt.wantReason))
+			}
+
+			attachments := &storagev1.VolumeAttachmentList{}
+			g.Expect(remoteClient.List(context.Background(), attachments)).To(Succeed())
+			names := []string{}
+			for _, a := range attachments.Items {
+				names = append(names, a.Name)
+			}
+			g.Expect(names).To(ConsistOf(tt.wantAttachments))
+		})
+	}
+}
@@ -0,0 +1,395 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	logutil "sigs.k8s.io/cluster-api/util/log"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// DefaultMachineOrphanGCInterval is the default interval between garbage collections of the orphaned
+	// infrastructure and bootstrap objects of a Cluster.
+	DefaultMachineOrphanGCInterval = 10 * time.Minute
+
+	// DefaultMachineOrphanGracePeriod is the default minimum age of an object without owners before it is considered
+	// orphaned; it prevents deleting objects created right before the Machine referencing them.
+	DefaultMachineOrphanGracePeriod = 5 * time.Minute
+)
+
+// orphanAction is the action taken by the MachineOrphanReconciler on an infrastructure or bootstrap object.
+type orphanAction int
+
+const (
+	// orphanActionKeep leaves the object untouched.
+	orphanActionKeep orphanAction = iota
+
+	// orphanActionRelink sets the Machine referencing the object as its controller.
+	orphanActionRelink
+
+	// orphanActionDelete deletes the object, whose owning Machine no longer exists.
+	orphanActionDelete
+)
+
+// +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io;bootstrap.cluster.x-k8s.io,resources=*,verbs=get;list;watch;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;machines,verbs=get;list;watch
+
+// MachineOrphanReconciler periodically garbage collects the infrastructure and bootstrap objects of the Clusters whose
+// owning Machine disappeared without deleting them, e.g. because its finalizer was removed by hand or because it was
+// deleted orphaning its dependents, so the cloud resources backing them are not leaked.
+//
+// Objects referenced by a Machine but not controlled by it are re-linked to the Machine instead.
+// Orphaned objects are deleted, not stripped of their finalizers, so the infrastructure and bootstrap providers can
+// release the resources backing them. Unless DeleteOrphans is set, the orphaned objects are only reported.
+type MachineOrphanReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+
+	// APIReader reads the Machines from the API server, bypassing the cache, before an object is considered orphaned;
+	// it defaults to the API reader of the manager.
+	APIReader client.Reader
+
+	// DeleteOrphans enables deleting the orphaned objects and re-linking the objects to the Machines referencing them;
+	// otherwise they are only reported with events and metrics.
+	DeleteOrphans bool
+
+	// Interval is the interval between garbage collections; it defaults to DefaultMachineOrphanGCInterval.
+	Interval time.Duration
+
+	// GracePeriod is the minimum age of an object without owners before it is deleted; it defaults to
+	// DefaultMachineOrphanGracePeriod.
+	GracePeriod time.Duration
+
+	recorder record.EventRecorder
+
+	// kinds are the kinds of the infrastructure and bootstrap objects referenced by the Machines seen so far; only
+	// objects of these kinds are garbage collected.
+	kindsLock sync.Mutex
+	kinds     map[schema.GroupVersionKind]struct{}
+}
+
+func (r *MachineOrphanReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	if r.Interval == 0 {
+		r.Interval = DefaultMachineOrphanGCInterval
+	}
+	if r.GracePeriod == 0 {
+		r.GracePeriod = DefaultMachineOrphanGracePeriod
+	}
+	if r.APIReader == nil {
+		r.APIReader = mgr.GetAPIReader()
+	}
+
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.Cluster{}).
+		Named("machineorphan").
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceNotPaused(r.Log)).
+		Build(r)
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	// Collect the orphaned objects as soon as a Machine is deleted.
+	err = c.Watch(
+		&source.Kind{Type: &clusterv1.Machine{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.machineToCluster)},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to add Watch for Machines to controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("machineorphan-controller")
+	return nil
+}
+
+// machineToCluster is a handler.ToRequestsFunc enqueuing the Cluster of a Machine.
+func (r *MachineOrphanReconciler) machineToCluster(o handler.MapObject) []ctrl.Request {
+	m, ok := o.Object.(*clusterv1.Machine)
+	if !ok {
+		r.Log.Error(nil, fmt.Sprintf("Expected a Machine but got a %T", o.Object))
+		return nil
+	}
+	if m.Spec.ClusterName == "" {
+		return nil
+	}
+	return []ctrl.Request{{
+		NamespacedName: client.ObjectKey{Namespace: m.Namespace, Name: m.Spec.ClusterName},
+	}}
+}
+
+func (r *MachineOrphanReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	logger := logutil.WithReconcileID(r.Log).WithValues(logutil.NamespaceKey, req.Namespace, logutil.ClusterKey, req.Name)
+	ctx = logutil.IntoContext(ctx, logger)
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Client.Get(ctx, req.NamespacedName, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			metrics.MachineOrphanedObjects.DeleteLabelValues(req.Name, req.Namespace)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the Cluster is paused, e.g. while being moved, or if it is being deleted.
+	if annotations.IsPaused(cluster, cluster) {
+		logger.V(4).Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+	if !cluster.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	machines := &clusterv1.MachineList{}
+	if err := r.Client.List(ctx, machines, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list Machines")
+	}
+
+	// The Machines missing from the cache, e.g. because it is not yet in sync, are read from the API server before
+	// deleting an object, at most once per garbage collection.
+	var liveMachines []clusterv1.Machine
+	getLiveMachines := func() ([]clusterv1.Machine, error) {
+		if liveMachines != nil {
+			return liveMachines, nil
+		}
+		list := &clusterv1.MachineList{}
+		if err := r.APIReader.List(ctx, list, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}); err != nil {
+			return nil, errors.Wrap(err, "failed to list Machines")
+		}
+		liveMachines = list.Items
+		return liveMachines, nil
+	}
+
+	orphans := 0
+	var errs []error
+	for _, gvk := range r.observeKinds(machines.Items) {
+		n, err := r.collectOrphans(ctx, cluster, gvk, machines.Items, getLiveMachines)
+		orphans += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	metrics.MachineOrphanedObjects.WithLabelValues(cluster.Name, cluster.Namespace).Set(float64(orphans))
+
+	if len(errs) > 0 {
+		return ctrl.Result{}, kerrors.NewAggregate(errs)
+	}
+	return ctrl.Result{RequeueAfter: r.Interval}, nil
+}
+
+// observeKinds records the kinds of the infrastructure and bootstrap objects referenced by the Machines and returns
+// all the kinds seen so far, sorted.
+func (r *MachineOrphanReconciler) observeKinds(machines []clusterv1.Machine) []schema.GroupVersionKind {
+	r.kindsLock.Lock()
+	defer r.kindsLock.Unlock()
+
+	if r.kinds == nil {
+		r.kinds = map[schema.GroupVersionKind]struct{}{}
+	}
+	for i := range machines {
+		for _, ref := range machineExternalRefs(&machines[i]) {
+			if ref.Kind != "" {
+				r.kinds[ref.GroupVersionKind()] = struct{}{}
+			}
+		}
+	}
+
+	kinds := make([]schema.GroupVersionKind, 0, len(r.kinds))
+	for gvk := range r.kinds {
+		kinds = append(kinds, gvk)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].String() < kinds[j].String() })
+	return kinds
+}
+
+// collectOrphans re-links or deletes the objects of the given kind belonging to the Cluster, and returns the number of
+// orphaned objects found; an object is orphaned only if the Machines read from the API server confirm it.
+func (r *MachineOrphanReconciler) collectOrphans(ctx context.Context, cluster *clusterv1.Cluster, gvk schema.GroupVersionKind, machines []clusterv1.Machine, getLiveMachines func() ([]clusterv1.Machine, error)) (int, error) {
+	logger := logutil.FromContext(ctx, r.Log)
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := r.Client.List(ctx, list, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}); err != nil {
+		if meta.IsNoMatchError(err) {
+			// The provider has been removed from the management cluster.
+			return 0, nil
+		}
+		return 0, errors.Wrapf(err, "failed to list %s objects", gvk.Kind)
+	}
+
+	orphans := 0
+	var errs []error
+	for i := range list.Items {
+		obj := &list.Items[i]
+		action, machine := classifyMachineObject(obj, machines, r.GracePeriod, time.Now())
+		if action == orphanActionDelete {
+			liveMachines, err := getLiveMachines()
+			if err != nil {
+				return orphans, err
+			}
+			if action, _ = classifyMachineObject(obj, liveMachines, r.GracePeriod, time.Now()); action != orphanActionDelete {
+				// The Machine is missing only from the cache; the object is reconciled again once the cache is in sync.
+				continue
+			}
+		}
+
+		switch action {
+		case orphanActionRelink:
+			if !r.DeleteOrphans {
+				logger.V(4).Info("Object not controlled by the Machine referencing it", "kind", gvk.Kind, "name", obj.GetName(), "machine", machine.Name)
+				continue
+			}
+			logger.Info("Re-linking object to the Machine referencing it", "kind", gvk.Kind, "name", obj.GetName(), "machine", machine.Name)
+			if err := r.relink(ctx, obj, machine); err != nil {
+				errs = append(errs, err)
+			}
+		case orphanActionDelete:
+			orphans++
+			if !r.DeleteOrphans {
+				logger.Info("Found orphaned object", "kind", gvk.Kind, "name", obj.GetName())
+				r.recorder.Eventf(cluster, corev1.EventTypeWarning, "OrphanedObject", "%s %s is orphaned, its Machine no longer exists", gvk.Kind, obj.GetName())
+				continue
+			}
+			logger.Info("Deleting orphaned object", "kind", gvk.Kind, "name", obj.GetName())
+			if err := r.Client.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, errors.Wrapf(err, "failed to delete orphaned %s %s", gvk.Kind, obj.GetName()))
+				continue
+			}
+			r.recorder.Eventf(cluster, corev1.EventTypeNormal, "DeletedOrphanedObject", "Deleted %s %s, whose Machine no longer exists", gvk.Kind, obj.GetName())
+		}
+	}
+	return orphans, kerrors.NewAggregate(errs)
+}
+
+// relink sets the Machine as the controller of the object, replacing the owner reference to a previous Machine.
+func (r *MachineOrphanReconciler) relink(ctx context.Context, obj *unstructured.Unstructured, machine *clusterv1.Machine) error {
+	patchHelper, err := patch.NewHelper(obj, r.Client)
+	if err != nil {
+		return err
+	}
+
+	ownerRefs := obj.GetOwnerReferences()
+	if controller := metav1.GetControllerOf(obj); controller != nil {
+		ownerRefs = util.RemoveOwnerRef(ownerRefs, *controller)
+	}
+	ownerRefs = util.EnsureOwnerRef(ownerRefs, *metav1.NewControllerRef(machine, clusterv1.GroupVersion.WithKind("Machine")))
+	obj.SetOwnerReferences(ownerRefs)
+
+	if err := patchHelper.Patch(ctx, obj); err != nil {
+		return errors.Wrapf(err, "failed to re-link %s %s to Machine %s", obj.GetKind(), obj.GetName(), machine.Name)
+	}
+	return nil
+}
+
+// classifyMachineObject returns the action to take on an infrastructure or bootstrap object of a Cluster, given the
+// Machines of the Cluster, and the Machine to re-link the object to, if any.
+//
+// An object is orphaned if it is not referenced by any Machine and either its controller is a Machine that no longer
+// exists, or it has no owners at all and it is older than the grace period.
+// Objects controlled by anything else than a Machine, e.g. a MachinePool, are never touched.
+func classifyMachineObject(obj *unstructured.Unstructured, machines []clusterv1.Machine, gracePeriod time.Duration, now time.Time) (orphanAction, *clusterv1.Machine) {
+	if !obj.GetDeletionTimestamp().IsZero() {
+		return orphanActionKeep, nil
+	}
+
+	controller := metav1.GetControllerOf(obj)
+	controlledByMachine := controller != nil && isMachineOwnerRef(*controller)
+
+	for i := range machines {
+		m := &machines[i]
+		if !isReferencedByMachine(obj, m) {
+			continue
+		}
+		if controller == nil || (controlledByMachine && (controller.Name != m.Name || controller.UID != m.UID)) {
+			return orphanActionRelink, m
+		}
+		return orphanActionKeep, nil
+	}
+
+	if controller != nil {
+		if !controlledByMachine {
+			return orphanActionKeep, nil
+		}
+		for i := range machines {
+			if machines[i].Name == controller.Name && machines[i].UID == controller.UID {
+				return orphanActionKeep, nil
+			}
+		}
+		return orphanActionDelete, nil
+	}
+
+	if len(obj.GetOwnerReferences()) > 0 || now.Sub(obj.GetCreationTimestamp().Time) < gracePeriod {
+		return orphanActionKeep, nil
+	}
+	return orphanActionDelete, nil
+}
+
+// machineExternalRefs returns the references to the infrastructure and bootstrap objects of a Machine.
+func machineExternalRefs(m *clusterv1.Machine) []*corev1.ObjectReference {
+	refs := []*corev1.ObjectReference{&m.Spec.InfrastructureRef}
+	if m.Spec.Bootstrap.ConfigRef != nil {
+		refs = append(refs, m.Spec.Bootstrap.ConfigRef)
+	}
+	return refs
+}
+
+// isReferencedByMachine returns true if the object is the infrastructure or bootstrap object of the Machine,
+// regardless of the API version used in the reference.
+func isReferencedByMachine(obj *unstructured.Unstructured, m *clusterv1.Machine) bool {
+	for _, ref := range machineExternalRefs(m) {
+		if ref.Name == obj.GetName() && ref.GroupVersionKind().GroupKind() == obj.GroupVersionKind().GroupKind() {
+			return true
+		}
+	}
+	return false
+}
+
+// isMachineOwnerRef returns true if the owner reference points to a Machine.
+func isMachineOwnerRef(ref metav1.OwnerReference) bool {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return false
+	}
+	return ref.Kind == "Machine" && gv.Group == clusterv1.GroupVersion.Group
+}
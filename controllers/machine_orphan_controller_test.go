@@ -0,0 +1,255 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/klogr"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var testInfraMachineGVK = schema.GroupVersionKind{Group: "infrastructure.cluster.x-k8s.io", Version: "v1alpha3", Kind: "InfrastructureMachine"}
+
+func newOrphanTestMachine(name string, uid types.UID, infraName string) clusterv1.Machine {
+	return clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			UID:       uid,
+			Labels:    map[string]string{clusterv1.ClusterLabelName: "test-cluster"},
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: "test-cluster",
+			InfrastructureRef: corev1.ObjectReference{
+				APIVersion: testInfraMachineGVK.GroupVersion().String(),
+				Kind:       testInfraMachineGVK.Kind,
+				Name:       infraName,
+			},
+		},
+	}
+}
+
+func newOrphanTestObject(name string, age time.Duration, ownerRefs ...metav1.OwnerReference) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(testInfraMachineGVK)
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetLabels(map[string]string{clusterv1.ClusterLabelName: "test-cluster"})
+	obj.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-age)))
+	obj.SetOwnerReferences(ownerRefs)
+	return obj
+}
+
+func machineControllerRef(name string, uid types.UID) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: clusterv1.GroupVersion.String(),
+		Kind:       "Machine",
+		Name:       name,
+		UID:        uid,
+		Controller: pointer.BoolPtr(true),
+	}
+}
+
+func TestClassifyMachineObject(t *testing.T) {
+	machines := []clusterv1.Machine{newOrphanTestMachine("machine", "uid", "infra")}
+	deleting := newOrphanTestObject("deleting", time.Hour, machineControllerRef("gone", "gone-uid"))
+	deleting.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+
+	tests := []struct {
+		name        string
+		obj         *unstructured.Unstructured
+		wantAction  orphanAction
+		wantMachine bool
+	}{
+		{
+			name:       "keeps an object controlled by the Machine referencing it",
+			obj:        newOrphanTestObject("infra", time.Hour, machineControllerRef("machine", "uid")),
+			wantAction: orphanActionKeep,
+		},
+		{
+			name:        "re-links an object referenced by a Machine without a controller",
+			obj:         newOrphanTestObject("infra", time.Hour),
+			wantAction:  orphanActionRelink,
+			wantMachine: true,
+		},
+		{
+			name:        "re-links an object referenced by a Machine controlled by a previous Machine with the same name",
+			obj:         newOrphanTestObject("infra", time.Hour, machineControllerRef("machine", "old-uid")),
+			wantAction:  orphanActionRelink,
+			wantMachine: true,
+		},
+		{
+			name:       "deletes an object controlled by a Machine that no longer exists",
+			obj:        newOrphanTestObject("other", time.Minute, machineControllerRef("gone", "gone-uid")),
+			wantAction: orphanActionDelete,
+		},
+		{
+			name:       "keeps an object controlled by an existing Machine",
+			obj:        newOrphanTestObject("other", time.Hour, machineControllerRef("machine", "uid")),
+			wantAction: orphanActionKeep,
+		},
+		{
+			name: "keeps an object controlled by something else than a Machine",
+			obj: newOrphanTestObject("other", time.Hour, metav1.OwnerReference{
+				APIVersion: "exp.cluster.x-k8s.io/v1alpha3",
+				Kind:       "MachinePool",
+				Name:       "pool",
+				Controller: pointer.BoolPtr(true),
+			}),
+			wantAction: orphanActionKeep,
+		},
+		{
+			name:       "deletes an object without owners older than the grace period",
+			obj:        newOrphanTestObject("other", time.Hour),
+			wantAction: orphanActionDelete,
+		},
+		{
+			name:       "keeps an object without owners younger than the grace period",
+			obj:        newOrphanTestObject("other", time.Minute),
+			wantAction: orphanActionKeep,
+		},
+		{
+			name:       "keeps an object being deleted",
+			obj:        deleting,
+			wantAction: orphanActionKeep,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			action, machine := classifyMachineObject(tt.obj, machines, DefaultMachineOrphanGracePeriod, time.Now())
+			g.Expect(action).To(Equal(tt.wantAction))
+			g.Expect(machine != nil).To(Equal(tt.wantMachine))
+		})
+	}
+}
+
+func TestMachineOrphanReconciler(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	NewWithT(t).Expect(clusterv1.AddToScheme(testScheme)).To(Succeed())
+	testScheme.AddKnownTypeWithName(testInfraMachineGVK, &unstructured.Unstructured{})
+	testScheme.AddKnownTypeWithName(testInfraMachineGVK.GroupVersion().WithKind(testInfraMachineGVK.Kind+"List"), &unstructured.UnstructuredList{})
+
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-cluster"}}
+	machine := newOrphanTestMachine("machine", "uid", "infra")
+	lateMachine := newOrphanTestMachine("late", "late-uid", "late-infra")
+
+	getObj := func(c client.Client, name string) (*unstructured.Unstructured, error) {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(testInfraMachineGVK)
+		return obj, c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: name}, obj)
+	}
+
+	t.Run("re-links and deletes the orphaned objects if enabled", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewFakeClientWithScheme(testScheme, cluster.DeepCopy(), machine.DeepCopy(),
+			newOrphanTestObject("infra", time.Hour),
+			newOrphanTestObject("orphan", time.Hour, machineControllerRef("gone", "gone-uid")))
+		r := &MachineOrphanReconciler{
+			Client:        c,
+			APIReader:     c,
+			Log:           klogr.New(),
+			DeleteOrphans: true,
+			Interval:      time.Minute,
+			GracePeriod:   DefaultMachineOrphanGracePeriod,
+			recorder:      record.NewFakeRecorder(32),
+		}
+
+		result, err := r.Reconcile(ctrl.Request{NamespacedName: util.ObjectKey(cluster)})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result.RequeueAfter).To(Equal(time.Minute))
+
+		got, err := getObj(c, "infra")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(metav1.GetControllerOf(got)).NotTo(BeNil())
+		g.Expect(metav1.GetControllerOf(got).UID).To(Equal(machine.UID))
+
+		_, err = getObj(c, "orphan")
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	t.Run("only reports the orphaned objects by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewFakeClientWithScheme(testScheme, cluster.DeepCopy(), machine.DeepCopy(),
+			newOrphanTestObject("infra", time.Hour),
+			newOrphanTestObject("orphan", time.Hour, machineControllerRef("gone", "gone-uid")))
+		recorder := record.NewFakeRecorder(32)
+		r := &MachineOrphanReconciler{
+			Client:      c,
+			APIReader:   c,
+			Log:         klogr.New(),
+			Interval:    time.Minute,
+			GracePeriod: DefaultMachineOrphanGracePeriod,
+			recorder:    recorder,
+		}
+
+		_, err := r.Reconcile(ctrl.Request{NamespacedName: util.ObjectKey(cluster)})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		got, err := getObj(c, "infra")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(metav1.GetControllerOf(got)).To(BeNil())
+
+		_, err = getObj(c, "orphan")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(recorder.Events).To(Receive(ContainSubstring("OrphanedObject")))
+	})
+
+	t.Run("keeps the objects whose Machine is missing from the cache", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cache := fake.NewFakeClientWithScheme(testScheme, cluster.DeepCopy(), machine.DeepCopy(),
+			newOrphanTestObject("late-infra", time.Hour, machineControllerRef(lateMachine.Name, lateMachine.UID)))
+		apiReader := fake.NewFakeClientWithScheme(testScheme, cluster.DeepCopy(), machine.DeepCopy(), lateMachine.DeepCopy())
+		r := &MachineOrphanReconciler{
+			Client:        cache,
+			APIReader:     apiReader,
+			Log:           klogr.New(),
+			DeleteOrphans: true,
+			Interval:      time.Minute,
+			GracePeriod:   DefaultMachineOrphanGracePeriod,
+			recorder:      record.NewFakeRecorder(32),
+		}
+
+		_, err := r.Reconcile(ctrl.Request{NamespacedName: util.ObjectKey(cluster)})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		_, err = getObj(cache, "late-infra")
+		g.Expect(err).NotTo(HaveOccurred())
+	})
+}
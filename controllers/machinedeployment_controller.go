@@ -19,6 +19,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -29,10 +30,14 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/predicates"
+	"sigs.k8s.io/cluster-api/util/trace"
+	"sigs.k8s.io/cluster-api/util/tuning"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -56,6 +61,9 @@ type MachineDeploymentReconciler struct {
 	Client client.Client
 	Log    logr.Logger
 
+	// Tuning configures the maximum requeue delay and the per-namespace concurrency of the reconciliations.
+	Tuning tuning.Options
+
 	recorder record.EventRecorder
 }
 
@@ -74,7 +82,7 @@ func (r *MachineDeploymentReconciler) SetupWithManager(mgr ctrl.Manager, options
 		).
 		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPaused(r.Log)).
-		Build(r)
+		Build(tuning.Wrap(r, r.Tuning))
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
 	}
@@ -97,7 +105,11 @@ func (r *MachineDeploymentReconciler) SetupWithManager(mgr ctrl.Manager, options
 
 func (r *MachineDeploymentReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
 	ctx := context.Background()
-	logger := r.Log.WithValues("machinedeployment", req.Name, "namespace", req.Namespace)
+	ctx, span := trace.Start(ctx, "MachineDeploymentReconciler.Reconcile", trace.String(logutil.NamespaceKey, req.Namespace), trace.String(logutil.MachineDeploymentKey, req.Name))
+	defer func() {
+		span.RecordError(reterr)
+		span.End()
+	}()
 
 	// Fetch the MachineDeployment instance.
 	deployment := &clusterv1.MachineDeployment{}
@@ -111,6 +123,9 @@ func (r *MachineDeploymentReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result
 		return ctrl.Result{}, err
 	}
 
+	logger := logutil.ForMachineDeployment(logutil.WithReconcileID(r.Log), deployment)
+	ctx = logutil.IntoContext(ctx, logger)
+
 	cluster, err := util.GetClusterByName(ctx, r.Client, deployment.Namespace, deployment.Spec.ClusterName)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -150,7 +165,7 @@ func (r *MachineDeploymentReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result
 }
 
 func (r *MachineDeploymentReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster, d *clusterv1.MachineDeployment) (ctrl.Result, error) {
-	logger := r.Log.WithValues("machinedeployment", d.Name, "namespace", d.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForMachineDeployment(r.Log, d))
 	logger.V(4).Info("Reconcile MachineDeployment")
 
 	// Reconcile and retrieve the Cluster object.
@@ -197,7 +212,15 @@ func (r *MachineDeploymentReconciler) reconcile(ctx context.Context, cluster *cl
 	}
 
 	if d.Spec.Strategy.Type == clusterv1.RollingUpdateMachineDeploymentStrategyType {
-		return ctrl.Result{}, r.rolloutRolling(d, msList)
+		if err := r.rolloutRolling(d, msList); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// Requeue at the rollout time, if in the future, so the machines are replaced without waiting for other events.
+		if rolloutAfter := mdutil.GetRolloutAfter(d); rolloutAfter != nil && time.Now().Before(rolloutAfter.Time) {
+			return ctrl.Result{RequeueAfter: time.Until(rolloutAfter.Time)}, nil
+		}
+		return ctrl.Result{}, nil
 	}
 
 	return ctrl.Result{}, errors.Errorf("unexpected deployment strategy type: %s", d.Spec.Strategy.Type)
@@ -205,7 +228,7 @@ func (r *MachineDeploymentReconciler) reconcile(ctx context.Context, cluster *cl
 
 // getMachineSetsForDeployment returns a list of MachineSets associated with a MachineDeployment.
 func (r *MachineDeploymentReconciler) getMachineSetsForDeployment(d *clusterv1.MachineDeployment) ([]*clusterv1.MachineSet, error) {
-	logger := r.Log.WithValues("machinedeployemnt", d.Name, "namespace", d.Namespace)
+	logger := logutil.ForMachineDeployment(r.Log, d)
 
 	// List all MachineSets to find those we own but that no longer match our selector.
 	machineSets := &clusterv1.MachineSetList{}
@@ -265,7 +288,7 @@ func (r *MachineDeploymentReconciler) adoptOrphan(deployment *clusterv1.MachineD
 
 // getMachineDeploymentsForMachineSet returns a list of MachineDeployments that could potentially match a MachineSet.
 func (r *MachineDeploymentReconciler) getMachineDeploymentsForMachineSet(ms *clusterv1.MachineSet) []*clusterv1.MachineDeployment {
-	logger := r.Log.WithValues("machineset", ms.Name, "namespace", ms.Namespace)
+	logger := logutil.ForMachineSet(r.Log, ms)
 
 	if len(ms.Labels) == 0 {
 		logger.V(2).Info("No MachineDeployments found for MachineSet because it has no labels", "machineset", ms.Name)
@@ -23,6 +23,7 @@ import (
 	"k8s.io/utils/integer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 )
 
 // rolloutRolling implements the logic for rolling a new machine set.
@@ -97,7 +98,7 @@ func (r *MachineDeploymentReconciler) reconcileNewMachineSet(allMSs []*clusterv1
 }
 
 func (r *MachineDeploymentReconciler) reconcileOldMachineSets(allMSs []*clusterv1.MachineSet, oldMSs []*clusterv1.MachineSet, newMS *clusterv1.MachineSet, deployment *clusterv1.MachineDeployment) error {
-	logger := r.Log.WithValues("machinedeployment", deployment.Name, "namespace", deployment.Namespace)
+	logger := logutil.ForMachineDeployment(r.Log, deployment)
 
 	if deployment.Spec.Replicas == nil {
 		return errors.Errorf("spec replicas for MachineDeployment %q/%q is nil, this is unexpected",
@@ -180,7 +181,7 @@ func (r *MachineDeploymentReconciler) reconcileOldMachineSets(allMSs []*clusterv
 
 // cleanupUnhealthyReplicas will scale down old machine sets with unhealthy replicas, so that all unhealthy replicas will be deleted.
 func (r *MachineDeploymentReconciler) cleanupUnhealthyReplicas(oldMSs []*clusterv1.MachineSet, deployment *clusterv1.MachineDeployment, maxCleanupCount int32) ([]*clusterv1.MachineSet, int32, error) {
-	logger := r.Log.WithValues("machinedeployment", deployment.Name, "namespace", deployment.Namespace)
+	logger := logutil.ForMachineDeployment(r.Log, deployment)
 
 	sort.Sort(mdutil.MachineSetsByCreationTimestamp(oldMSs))
 
@@ -233,7 +234,7 @@ func (r *MachineDeploymentReconciler) cleanupUnhealthyReplicas(oldMSs []*cluster
 // scaleDownOldMachineSetsForRollingUpdate scales down old machine sets when deployment strategy is "RollingUpdate".
 // Need check maxUnavailable to ensure availability
 func (r *MachineDeploymentReconciler) scaleDownOldMachineSetsForRollingUpdate(allMSs []*clusterv1.MachineSet, oldMSs []*clusterv1.MachineSet, deployment *clusterv1.MachineDeployment) (int32, error) {
-	logger := r.Log.WithValues("machinedeployment", deployment.Name, "namespace", deployment.Namespace)
+	logger := logutil.ForMachineDeployment(r.Log, deployment)
 
 	if deployment.Spec.Replicas == nil {
 		return 0, errors.Errorf("spec replicas for deployment %v is nil, this is unexpected", deployment.Name)
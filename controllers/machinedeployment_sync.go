@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -32,6 +33,7 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controllers/mdutil"
 	"sigs.k8s.io/cluster-api/util"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -87,7 +89,7 @@ func (r *MachineDeploymentReconciler) getAllMachineSetsAndSyncRevision(d *cluste
 // 3. If there's no existing new MS and createIfNotExisted is true, create one with appropriate revision number (maxOldRevision + 1) and replicas.
 // Note that the machine-template-hash will be added to adopted MSes and machines.
 func (r *MachineDeploymentReconciler) getNewMachineSet(d *clusterv1.MachineDeployment, msList, oldMSs []*clusterv1.MachineSet, createIfNotExisted bool) (*clusterv1.MachineSet, error) {
-	logger := r.Log.WithValues("machinedeployment", d.Name, "namespace", d.Namespace)
+	logger := logutil.ForMachineDeployment(r.Log, d)
 
 	existingNewMS := mdutil.FindNewMachineSet(d, msList)
 
@@ -111,9 +113,16 @@ func (r *MachineDeploymentReconciler) getNewMachineSet(d *clusterv1.MachineDeplo
 		// Set existing new machine set's annotation
 		annotationsUpdated := mdutil.SetNewMachineSetAnnotations(d, msCopy, newRevision, true, logger)
 
+		// Propagate in place the labels and annotations updated in the deployment's machine template.
+		var templateLabelsUpdated, templateAnnotationsUpdated bool
+		msCopy.Spec.Template.Labels, templateLabelsUpdated = util.SyncPropagatedMetadata(msCopy.Spec.Template.Labels, d.Spec.Template.Labels)
+		msCopy.Spec.Template.Annotations, templateAnnotationsUpdated = util.SyncPropagatedMetadata(msCopy.Spec.Template.Annotations, d.Spec.Template.Annotations)
+
 		minReadySecondsNeedsUpdate := msCopy.Spec.MinReadySeconds != *d.Spec.MinReadySeconds
-		if annotationsUpdated || minReadySecondsNeedsUpdate {
+		spreadNeedsUpdate := msCopy.Spec.SpreadAcrossFailureDomains != d.Spec.SpreadAcrossFailureDomains
+		if annotationsUpdated || templateLabelsUpdated || templateAnnotationsUpdated || minReadySecondsNeedsUpdate || spreadNeedsUpdate {
 			msCopy.Spec.MinReadySeconds = *d.Spec.MinReadySeconds
+			msCopy.Spec.SpreadAcrossFailureDomains = d.Spec.SpreadAcrossFailureDomains
 			return nil, patchHelper.Patch(context.Background(), msCopy)
 		}
 
@@ -130,6 +139,13 @@ func (r *MachineDeploymentReconciler) getNewMachineSet(d *clusterv1.MachineDeplo
 
 	// new MachineSet does not exist, create one.
 	newMSTemplate := *d.Spec.Template.DeepCopy()
+	// Record the rollout time, if passed, so the new machine set name is different from the one of the machine set being replaced.
+	if rolloutAfter := mdutil.GetRolloutAfter(d); rolloutAfter != nil && !time.Now().Before(rolloutAfter.Time) {
+		if newMSTemplate.Annotations == nil {
+			newMSTemplate.Annotations = map[string]string{}
+		}
+		newMSTemplate.Annotations[clusterv1.RolloutAfterAnnotation] = rolloutAfter.UTC().Format(time.RFC3339)
+	}
 	machineTemplateSpecHash := fmt.Sprintf("%d", mdutil.ComputeHash(&newMSTemplate))
 	newMSTemplate.Labels = mdutil.CloneAndAddLabel(d.Spec.Template.Labels,
 		mdutil.DefaultMachineDeploymentUniqueLabelKey, machineTemplateSpecHash)
@@ -153,11 +169,12 @@ func (r *MachineDeploymentReconciler) getNewMachineSet(d *clusterv1.MachineDeplo
 			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(d, machineDeploymentKind)},
 		},
 		Spec: clusterv1.MachineSetSpec{
-			ClusterName:     d.Spec.ClusterName,
-			Replicas:        new(int32),
-			MinReadySeconds: minReadySeconds,
-			Selector:        *newMSSelector,
-			Template:        newMSTemplate,
+			ClusterName:                d.Spec.ClusterName,
+			Replicas:                   new(int32),
+			MinReadySeconds:            minReadySeconds,
+			Selector:                   *newMSSelector,
+			Template:                   newMSTemplate,
+			SpreadAcrossFailureDomains: d.Spec.SpreadAcrossFailureDomains,
 		},
 	}
 
@@ -228,7 +245,7 @@ func (r *MachineDeploymentReconciler) getNewMachineSet(d *clusterv1.MachineDeplo
 // replicas in the event of a problem with the rolled out template. Should run only on scaling events or
 // when a deployment is paused and not during the normal rollout process.
 func (r *MachineDeploymentReconciler) scale(deployment *clusterv1.MachineDeployment, newMS *clusterv1.MachineSet, oldMSs []*clusterv1.MachineSet) error {
-	logger := r.Log.WithValues("machinedeployment", deployment.Name, "namespace", deployment.Namespace)
+	logger := logutil.ForMachineDeployment(r.Log, deployment)
 
 	if deployment.Spec.Replicas == nil {
 		return errors.Errorf("spec replicas for deployment %v is nil, this is unexpected", deployment.Name)
@@ -451,7 +468,7 @@ func (r *MachineDeploymentReconciler) scaleMachineSetOperation(ms *clusterv1.Mac
 // where N=d.Spec.RevisionHistoryLimit. Old machine sets are older versions of the machinetemplate of a deployment kept
 // around by default 1) for historical reasons and 2) for the ability to rollback a deployment.
 func (r *MachineDeploymentReconciler) cleanupDeployment(oldMSs []*clusterv1.MachineSet, deployment *clusterv1.MachineDeployment) error {
-	logger := r.Log.WithValues("machinedeployment", deployment.Name, "namespace", deployment.Namespace)
+	logger := logutil.ForMachineDeployment(r.Log, deployment)
 
 	if deployment.Spec.RevisionHistoryLimit == nil {
 		return nil
@@ -32,12 +32,16 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/predicates"
+	"sigs.k8s.io/cluster-api/util/trace"
+	"sigs.k8s.io/cluster-api/util/tuning"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -54,8 +58,18 @@ const (
 	// EventRemediationRestricted is emitted in case when machine remediation
 	// is restricted by remediation circuit shorting logic
 	EventRemediationRestricted string = "RemediationRestricted"
+
+	// Reasons for restricting or skipping remediation, reported by events and metrics
+
+	remediationRestrictedControlPlaneUnreachable = "ControlPlaneUnreachable"
+	remediationRestrictedMaxUnhealthy            = "MaxUnhealthyExceeded"
+	remediationSkippedPaused                     = "Paused"
+	remediationSkippedAnnotation                 = "SkipRemediationAnnotation"
 )
 
+// remediationRestrictedReasons are the reasons for restricting remediation reported by metrics.
+var remediationRestrictedReasons = []string{remediationRestrictedControlPlaneUnreachable, remediationRestrictedMaxUnhealthy}
+
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;delete
@@ -67,6 +81,9 @@ type MachineHealthCheckReconciler struct {
 	Log     logr.Logger
 	Tracker *remote.ClusterCacheTracker
 
+	// Tuning configures the maximum requeue delay and the per-namespace concurrency of the reconciliations.
+	Tuning tuning.Options
+
 	controller controller.Controller
 	recorder   record.EventRecorder
 	scheme     *runtime.Scheme
@@ -81,7 +98,7 @@ func (r *MachineHealthCheckReconciler) SetupWithManager(mgr ctrl.Manager, option
 		).
 		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPaused(r.Log)).
-		Build(r)
+		Build(tuning.Wrap(r, r.Tuning))
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
 	}
@@ -111,7 +128,12 @@ func (r *MachineHealthCheckReconciler) SetupWithManager(mgr ctrl.Manager, option
 
 func (r *MachineHealthCheckReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
 	ctx := context.Background()
-	logger := r.Log.WithValues("machinehealthcheck", req.Name, "namespace", req.Namespace)
+	ctx, span := trace.Start(ctx, "MachineHealthCheckReconciler.Reconcile", trace.String(logutil.NamespaceKey, req.Namespace), trace.String("machinehealthcheck", req.Name))
+	defer func() {
+		span.RecordError(reterr)
+		span.End()
+	}()
+	logger := logutil.WithReconcileID(r.Log).WithValues(logutil.NamespaceKey, req.Namespace, "machinehealthcheck", req.Name)
 
 	// Fetch the MachineHealthCheck instance
 	m := &clusterv1.MachineHealthCheck{}
@@ -119,6 +141,9 @@ func (r *MachineHealthCheckReconciler) Reconcile(req ctrl.Request) (_ ctrl.Resul
 		if apierrors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
+			for _, reason := range remediationRestrictedReasons {
+				metrics.MachineHealthCheckRemediationRestricted.DeleteLabelValues(req.Name, req.Namespace, reason)
+			}
 			return ctrl.Result{}, nil
 		}
 
@@ -134,7 +159,8 @@ func (r *MachineHealthCheckReconciler) Reconcile(req ctrl.Request) (_ ctrl.Resul
 			m.Spec.ClusterName, m.Name, m.Namespace)
 	}
 
-	logger = r.Log.WithValues("cluster", cluster.Name)
+	logger = logger.WithValues(logutil.ClusterKey, cluster.Name)
+	ctx = logutil.IntoContext(ctx, logger)
 
 	// Return early if the object or Cluster is paused.
 	if annotations.IsPaused(cluster, m) {
@@ -188,6 +214,21 @@ func (r *MachineHealthCheckReconciler) reconcile(ctx context.Context, logger log
 		UID:        cluster.UID,
 	})
 
+	// Short-circuit remediation if the control plane of the workload cluster is not reachable; in this case the
+	// status of the Nodes can not be observed, and all the targets would be eventually considered unhealthy.
+	if conditions.IsFalse(cluster, clusterv1.ControlPlaneReachableCondition) {
+		logger.V(3).Info("Short-circuiting remediation, the control plane is not reachable")
+		r.recorder.Eventf(
+			m,
+			corev1.EventTypeWarning,
+			EventRemediationRestricted,
+			"Remediation restricted because the control plane of cluster %q is not reachable",
+			cluster.Name,
+		)
+		setRemediationRestricted(m, remediationRestrictedControlPlaneUnreachable)
+		return ctrl.Result{}, nil
+	}
+
 	// Get the remote cluster cache to use as a client.Reader.
 	remoteClient, err := r.Tracker.GetClient(ctx, util.ObjectKey(cluster))
 	if err != nil {
@@ -211,9 +252,17 @@ func (r *MachineHealthCheckReconciler) reconcile(ctx context.Context, logger log
 		m.Status.Targets[i] = t.Machine.Name
 	}
 
+	// NodeStartupTimeout is defaulted by the webhook, but fall back to the default value
+	// in case the MachineHealthCheck was created while the webhook was not in place.
+	nodeStartupTimeout := clusterv1.DefaultNodeStartupTimeout.Duration
+	if m.Spec.NodeStartupTimeout != nil {
+		nodeStartupTimeout = m.Spec.NodeStartupTimeout.Duration
+	}
+
 	// health check all targets and reconcile mhc status
-	healthy, unhealthy, nextCheckTimes := r.healthCheckTargets(targets, logger, m.Spec.NodeStartupTimeout.Duration)
+	healthy, unhealthy, nextCheckTimes := r.healthCheckTargets(targets, logger, nodeStartupTimeout)
 	m.Status.CurrentHealthy = int32(len(healthy))
+	m.Status.RemediationsAllowed = remediationsAllowed(m)
 
 	// check MHC current health against MaxUnhealthy
 	if !isAllowedRemediation(m) {
@@ -230,9 +279,10 @@ func (r *MachineHealthCheckReconciler) reconcile(ctx context.Context, logger log
 			EventRemediationRestricted,
 			"Remediation restricted due to exceeded number of unhealthy machines (total: %v, unhealthy: %v, maxUnhealthy: %v)",
 			totalTargets,
-			m.Status.CurrentHealthy,
+			totalTargets-len(healthy),
 			m.Spec.MaxUnhealthy,
 		)
+		setRemediationRestricted(m, remediationRestrictedMaxUnhealthy)
 		for _, t := range append(healthy, unhealthy...) {
 			if err := t.patchHelper.Patch(ctx, t.Machine); err != nil {
 				return ctrl.Result{}, errors.Wrapf(err, "Failed to patch machine status for machine %q", t.Machine.Name)
@@ -246,27 +296,53 @@ func (r *MachineHealthCheckReconciler) reconcile(ctx context.Context, logger log
 		"max unhealthy", m.Spec.MaxUnhealthy,
 		"unhealthy targets", len(unhealthy),
 	)
+	setRemediationRestricted(m, "")
 
 	// mark for remediation
 	errList := []error{}
 	for _, t := range unhealthy {
 		condition := conditions.Get(t.Machine, clusterv1.MachineHealthCheckSuccededCondition)
 
+		skipReason := ""
 		if annotations.IsPaused(cluster, t.Machine) {
 			logger.Info("Machine has failed health check, but machine is paused so skipping remediation", "target", t.string(), "reason", condition.Reason, "message", condition.Message)
+			skipReason = remediationSkippedPaused
+		} else if annotations.IsRemediationSkipped(t.Machine) {
+			logger.Info("Machine has failed health check, but machine is annotated to skip remediation", "target", t.string(), "reason", condition.Reason, "message", condition.Message)
+			skipReason = remediationSkippedAnnotation
 		} else {
 			logger.Info("Target has failed health check, marking for remediation", "target", t.string(), "reason", condition.Reason, "message", condition.Message)
+			// Count the machines newly marked for remediation only, the mark is renewed on every reconcile.
+			if !conditions.IsFalse(t.Machine, clusterv1.MachineOwnerRemediatedCondition) {
+				metrics.MachineHealthCheckMachinesMarkedUnhealthy.WithLabelValues(m.Name, m.Namespace, m.Spec.ClusterName, condition.Reason).Inc()
+			}
 			conditions.MarkFalse(t.Machine, clusterv1.MachineOwnerRemediatedCondition, clusterv1.WaitingForRemediation, clusterv1.ConditionSeverityWarning, "MachineHealthCheck failed")
 		}
 		if err := t.patchHelper.Patch(ctx, t.Machine); err != nil {
 			return ctrl.Result{}, errors.Wrapf(err, "Failed to patch unhealthy machine status for machine %q", t.Machine.Name)
 		}
+		if skipReason != "" {
+			metrics.MachineHealthCheckRemediationsSkipped.WithLabelValues(m.Name, m.Namespace, m.Spec.ClusterName, skipReason).Inc()
+			r.recorder.Eventf(
+				t.Machine,
+				corev1.EventTypeNormal,
+				EventRemediationSkipped,
+				"Machine %v has failed health check (%s: %s), but remediation is skipped (%s)",
+				t.string(),
+				condition.Reason,
+				condition.Message,
+				skipReason,
+			)
+			continue
+		}
 		r.recorder.Eventf(
 			t.Machine,
 			corev1.EventTypeNormal,
 			EventMachineMarkedUnhealthy,
-			"Machine %v has been marked as unhealthy",
+			"Machine %v has been marked as unhealthy (%s: %s)",
 			t.string(),
+			condition.Reason,
+			condition.Message,
 		)
 	}
 	for _, t := range healthy {
@@ -422,20 +498,58 @@ func (r *MachineHealthCheckReconciler) indexMachineByNodeName(object runtime.Obj
 	return nil
 }
 
+// setRemediationRestricted sets the metric reporting whether remediation is
+// short-circuited, to 1 for the given reason and to 0 for the other reasons;
+// an empty reason means remediation is allowed.
+func setRemediationRestricted(mhc *clusterv1.MachineHealthCheck, reason string) {
+	for _, r := range remediationRestrictedReasons {
+		value := 0.0
+		if r == reason {
+			value = 1
+		}
+		metrics.MachineHealthCheckRemediationRestricted.WithLabelValues(mhc.Name, mhc.Namespace, r).Set(value)
+	}
+}
+
 // isAllowedRemediation checks the value of the MaxUnhealthy field to determine
 // whether remediation should be allowed or not
 func isAllowedRemediation(mhc *clusterv1.MachineHealthCheck) bool {
-	if mhc.Spec.MaxUnhealthy == nil {
-		return true
-	}
-	maxUnhealthy, err := intstr.GetValueFromIntOrPercent(mhc.Spec.MaxUnhealthy, int(mhc.Status.ExpectedMachines), false)
+	maxUnhealthy, err := getMaxUnhealthy(mhc)
 	if err != nil {
 		return false
 	}
 
 	// If unhealthy is above maxUnhealthy, short circuit any further remediation
-	unhealthy := mhc.Status.ExpectedMachines - mhc.Status.CurrentHealthy
-	return int(unhealthy) <= maxUnhealthy
+	return int(unhealthyMachineCount(mhc)) <= maxUnhealthy
+}
+
+// remediationsAllowed returns the number of further remediations allowed before
+// the MaxUnhealthy field short circuits remediation
+func remediationsAllowed(mhc *clusterv1.MachineHealthCheck) int32 {
+	maxUnhealthy, err := getMaxUnhealthy(mhc)
+	if err != nil {
+		return 0
+	}
+
+	allowed := maxUnhealthy - int(unhealthyMachineCount(mhc))
+	if allowed < 0 {
+		return 0
+	}
+	return int32(allowed)
+}
+
+// getMaxUnhealthy returns the number of unhealthy machines the MaxUnhealthy field
+// allows; all the machines are allowed to be unhealthy if it is not set
+func getMaxUnhealthy(mhc *clusterv1.MachineHealthCheck) (int, error) {
+	if mhc.Spec.MaxUnhealthy == nil {
+		return int(mhc.Status.ExpectedMachines), nil
+	}
+	return intstr.GetValueFromIntOrPercent(mhc.Spec.MaxUnhealthy, int(mhc.Status.ExpectedMachines), false)
+}
+
+// unhealthyMachineCount returns the number of machines counted as not healthy
+func unhealthyMachineCount(mhc *clusterv1.MachineHealthCheck) int32 {
+	return mhc.Status.ExpectedMachines - mhc.Status.CurrentHealthy
 }
 
 func machineNames(machines []*clusterv1.Machine) []string {
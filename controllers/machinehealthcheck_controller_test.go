@@ -23,6 +23,7 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -35,6 +36,7 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -910,6 +912,101 @@ var _ = Describe("MachineHealthCheck", func() {
 				return
 			}).Should(Equal(0))
 		})
+
+		Specify("when a machine is annotated to skip remediation", func() {
+			mhc.Spec.ClusterName = cluster.Name
+			Expect(testEnv.Create(ctx, mhc)).To(Succeed())
+
+			// Healthy nodes and machines.
+			fakeNodesMachines(1, true, true)
+			targetMachines := make([]string, len(machines))
+			for i, m := range machines {
+				targetMachines[i] = m.Name
+			}
+
+			// Make sure the status matches.
+			Eventually(func() *clusterv1.MachineHealthCheckStatus {
+				err := testEnv.Get(ctx, util.ObjectKey(mhc), mhc)
+				if err != nil {
+					return nil
+				}
+				return &mhc.Status
+			}).Should(MatchMachineHealthCheckStatus(&clusterv1.MachineHealthCheckStatus{
+				ExpectedMachines:   1,
+				CurrentHealthy:     1,
+				ObservedGeneration: 1,
+				Targets:            targetMachines},
+			))
+
+			// Annotate the machine to skip remediation
+			machinePatch := client.MergeFrom(machines[0].DeepCopy())
+			machines[0].Annotations = map[string]string{
+				clusterv1.SkipRemediationAnnotation: "",
+			}
+			Expect(testEnv.Patch(ctx, machines[0], machinePatch)).To(Succeed())
+
+			// Transition the node to unhealthy.
+			node := nodes[0]
+			nodePatch := client.MergeFrom(node.DeepCopy())
+			node.Status.Conditions = []corev1.NodeCondition{
+				{
+					Type:               corev1.NodeReady,
+					Status:             corev1.ConditionUnknown,
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-10 * time.Minute)),
+				},
+			}
+			Expect(testEnv.Status().Patch(ctx, node, nodePatch)).To(Succeed())
+
+			// Make sure the status matches.
+			Eventually(func() *clusterv1.MachineHealthCheckStatus {
+				err := testEnv.Get(ctx, util.ObjectKey(mhc), mhc)
+				if err != nil {
+					return nil
+				}
+				return &mhc.Status
+			}).Should(MatchMachineHealthCheckStatus(&clusterv1.MachineHealthCheckStatus{
+				ExpectedMachines:   1,
+				CurrentHealthy:     0,
+				ObservedGeneration: 1,
+				Targets:            targetMachines},
+			))
+
+			// Calculate how many Machines have health check succeeded = false.
+			Eventually(func() (unhealthy int) {
+				machines := &clusterv1.MachineList{}
+				err := testEnv.List(ctx, machines, client.MatchingLabels{
+					"selector": mhc.Spec.Selector.MatchLabels["selector"],
+				})
+				if err != nil {
+					return -1
+				}
+
+				for i := range machines.Items {
+					if conditions.IsFalse(&machines.Items[i], clusterv1.MachineHealthCheckSuccededCondition) {
+						unhealthy++
+					}
+				}
+				return
+			}).Should(Equal(1))
+
+			// Calculate how many Machines have been remediated.
+			Eventually(func() (remediated int) {
+				machines := &clusterv1.MachineList{}
+				err := testEnv.List(ctx, machines, client.MatchingLabels{
+					"selector": mhc.Spec.Selector.MatchLabels["selector"],
+				})
+				if err != nil {
+					return -1
+				}
+
+				for i := range machines.Items {
+					if conditions.Get(&machines.Items[i], clusterv1.MachineOwnerRemediatedCondition) != nil {
+						remediated++
+					}
+				}
+				return
+			}).Should(Equal(0))
+		})
 	})
 })
 
@@ -1332,6 +1429,7 @@ func TestIsAllowedRemediation(t *testing.T) {
 		expectedMachines   int32
 		currentHealthy     int32
 		allowed            bool
+		remediations       int32
 		observedGeneration int64
 	}{
 		{
@@ -1340,6 +1438,15 @@ func TestIsAllowedRemediation(t *testing.T) {
 			expectedMachines: int32(3),
 			currentHealthy:   int32(0),
 			allowed:          true,
+			remediations:     int32(0),
+		},
+		{
+			name:             "when maxUnhealthy is not set and some machines are healthy",
+			maxUnhealthy:     nil,
+			expectedMachines: int32(3),
+			currentHealthy:   int32(2),
+			allowed:          true,
+			remediations:     int32(2),
 		},
 		{
 			name:             "when maxUnhealthy is not an int or percentage",
@@ -1347,6 +1454,7 @@ func TestIsAllowedRemediation(t *testing.T) {
 			expectedMachines: int32(5),
 			currentHealthy:   int32(2),
 			allowed:          false,
+			remediations:     int32(0),
 		},
 		{
 			name:             "when maxUnhealthy is an int less than current unhealthy",
@@ -1354,6 +1462,7 @@ func TestIsAllowedRemediation(t *testing.T) {
 			expectedMachines: int32(3),
 			currentHealthy:   int32(1),
 			allowed:          false,
+			remediations:     int32(0),
 		},
 		{
 			name:             "when maxUnhealthy is an int equal to current unhealthy",
@@ -1361,6 +1470,7 @@ func TestIsAllowedRemediation(t *testing.T) {
 			expectedMachines: int32(3),
 			currentHealthy:   int32(1),
 			allowed:          true,
+			remediations:     int32(0),
 		},
 		{
 			name:             "when maxUnhealthy is an int greater than current unhealthy",
@@ -1368,6 +1478,7 @@ func TestIsAllowedRemediation(t *testing.T) {
 			expectedMachines: int32(3),
 			currentHealthy:   int32(1),
 			allowed:          true,
+			remediations:     int32(1),
 		},
 		{
 			name:             "when maxUnhealthy is a percentage less than current unhealthy",
@@ -1375,6 +1486,7 @@ func TestIsAllowedRemediation(t *testing.T) {
 			expectedMachines: int32(5),
 			currentHealthy:   int32(2),
 			allowed:          false,
+			remediations:     int32(0),
 		},
 		{
 			name:             "when maxUnhealthy is a percentage equal to current unhealthy",
@@ -1382,6 +1494,7 @@ func TestIsAllowedRemediation(t *testing.T) {
 			expectedMachines: int32(5),
 			currentHealthy:   int32(2),
 			allowed:          true,
+			remediations:     int32(0),
 		},
 		{
 			name:             "when maxUnhealthy is a percentage greater than current unhealthy",
@@ -1389,6 +1502,15 @@ func TestIsAllowedRemediation(t *testing.T) {
 			expectedMachines: int32(5),
 			currentHealthy:   int32(2),
 			allowed:          true,
+			remediations:     int32(0),
+		},
+		{
+			name:             "when maxUnhealthy is a percentage allowing further remediations",
+			maxUnhealthy:     &intstr.IntOrString{Type: intstr.String, StrVal: "100%"},
+			expectedMachines: int32(5),
+			currentHealthy:   int32(2),
+			allowed:          true,
+			remediations:     int32(2),
 		},
 	}
 
@@ -1408,6 +1530,7 @@ func TestIsAllowedRemediation(t *testing.T) {
 			}
 
 			g.Expect(isAllowedRemediation(mhc)).To(Equal(tc.allowed))
+			g.Expect(remediationsAllowed(mhc)).To(Equal(tc.remediations))
 		})
 	}
 }
@@ -1424,3 +1547,28 @@ func ownerReferenceForCluster(ctx context.Context, c *clusterv1.Cluster) metav1.
 		UID:        cc.UID,
 	}
 }
+
+func TestSetRemediationRestricted(t *testing.T) {
+	g := NewWithT(t)
+
+	mhc := &clusterv1.MachineHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{Namespace: defaultNamespaceName, Name: "restricted"},
+	}
+	restricted := func(reason string) float64 {
+		return testutil.ToFloat64(metrics.MachineHealthCheckRemediationRestricted.WithLabelValues(mhc.Name, mhc.Namespace, reason))
+	}
+
+	// The metric is set only for the reason restricting remediation, and it is reset once remediation is allowed.
+	setRemediationRestricted(mhc, remediationRestrictedMaxUnhealthy)
+	setRemediationRestricted(mhc, remediationRestrictedMaxUnhealthy)
+	g.Expect(restricted(remediationRestrictedMaxUnhealthy)).To(Equal(1.0))
+	g.Expect(restricted(remediationRestrictedControlPlaneUnreachable)).To(Equal(0.0))
+
+	setRemediationRestricted(mhc, remediationRestrictedControlPlaneUnreachable)
+	g.Expect(restricted(remediationRestrictedMaxUnhealthy)).To(Equal(0.0))
+	g.Expect(restricted(remediationRestrictedControlPlaneUnreachable)).To(Equal(1.0))
+
+	setRemediationRestricted(mhc, "")
+	g.Expect(restricted(remediationRestrictedMaxUnhealthy)).To(Equal(0.0))
+	g.Expect(restricted(remediationRestrictedControlPlaneUnreachable)).To(Equal(0.0))
+}
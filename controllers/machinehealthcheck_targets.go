@@ -29,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/util/conditions"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -50,6 +51,9 @@ const (
 	EventMachineDeleted string = "MachineDeleted"
 	// EventMachineMarkedUnhealthy is emitted when machine was successfully marked as unhealthy
 	EventMachineMarkedUnhealthy string = "MachineMarkedUnhealthy"
+	// EventRemediationSkipped is emitted when machine has failed health check,
+	// but its remediation is skipped because it is paused or annotated to skip remediation
+	EventRemediationSkipped string = "RemediationSkipped"
 	// EventDetectedUnhealthy is emitted in case a node associated with a
 	// machine was detected unhealthy
 	EventDetectedUnhealthy string = "DetectedUnhealthy"
@@ -116,16 +120,22 @@ func (t *healthCheckTarget) needsRemediation(logger logr.Logger, timeoutForMachi
 
 	// the node has not been set yet
 	if t.Node == nil {
-		// status not updated yet
-		if t.Machine.Status.LastUpdated == nil {
+		// The startup deadline is computed from the last status update of the Machine or, if the status
+		// was never updated (e.g. the infrastructure never got provisioned), from the Machine creation.
+		startTime := t.Machine.CreationTimestamp
+		if t.Machine.Status.LastUpdated != nil {
+			startTime = *t.Machine.Status.LastUpdated
+		}
+		// creation timestamp not set yet
+		if startTime.IsZero() {
 			return false, timeoutForMachineToHaveNode
 		}
-		if t.Machine.Status.LastUpdated.Add(timeoutForMachineToHaveNode).Before(now) {
+		if startTime.Add(timeoutForMachineToHaveNode).Before(now) {
 			conditions.MarkFalse(t.Machine, clusterv1.MachineHealthCheckSuccededCondition, clusterv1.NodeStartupTimeout, clusterv1.ConditionSeverityWarning, "Node failed to report startup in %s", timeoutForMachineToHaveNode.String())
 			logger.V(3).Info("Target is unhealthy: machine has no node", "duration", timeoutForMachineToHaveNode.String())
 			return true, time.Duration(0)
 		}
-		durationUnhealthy := now.Sub(t.Machine.Status.LastUpdated.Time)
+		durationUnhealthy := now.Sub(startTime.Time)
 		nextCheck := timeoutForMachineToHaveNode - durationUnhealthy + time.Second
 		return false, nextCheck
 	}
@@ -239,7 +249,7 @@ func (r *MachineHealthCheckReconciler) healthCheckTargets(targets []healthCheckT
 	var healthy []healthCheckTarget
 
 	for _, t := range targets {
-		logger = logger.WithValues("Target", t.string())
+		logger := logger.WithValues(logutil.MachineKey, t.Machine.Name, "Target", t.string())
 		logger.V(3).Info("Health checking target")
 		needsRemediation, nextCheck := t.needsRemediation(logger, timeoutForMachineToHaveNode)
 
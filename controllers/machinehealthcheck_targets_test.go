@@ -203,6 +203,24 @@ func TestHealthCheckTargets(t *testing.T) {
 		Node:    nil,
 	}
 
+	// Target for when the Machine status has not been updated yet, and the Machine was created recently
+	testMachineCreated400s := testMachine.DeepCopy()
+	testMachineCreated400s.CreationTimestamp = nowMinus400s
+	nodeNotYetStartedNoStatusTarget := healthCheckTarget{
+		MHC:     testMHC,
+		Machine: testMachineCreated400s,
+		Node:    nil,
+	}
+
+	// Target for when the Machine status has not been updated yet, and the Machine was created before the timeout
+	testMachineCreated1200s := testMachine.DeepCopy()
+	testMachineCreated1200s.CreationTimestamp = metav1.NewTime(time.Now().Add(-1200 * time.Second))
+	nodeNeverStartedTarget := healthCheckTarget{
+		MHC:     testMHC,
+		Machine: testMachineCreated1200s,
+		Node:    nil,
+	}
+
 	// Target for when the Node has been seen, but has now gone
 	nodeGoneAway := healthCheckTarget{
 		MHC:         testMHC,
@@ -262,6 +280,20 @@ func TestHealthCheckTargets(t *testing.T) {
 			expectedNeedsRemediation: []healthCheckTarget{},
 			expectedNextCheckTimes:   []time.Duration{200 * time.Second},
 		},
+		{
+			desc:                     "when the node has not yet started and the machine status is not updated",
+			targets:                  []healthCheckTarget{nodeNotYetStartedNoStatusTarget},
+			expectedHealthy:          []healthCheckTarget{},
+			expectedNeedsRemediation: []healthCheckTarget{},
+			expectedNextCheckTimes:   []time.Duration{200 * time.Second},
+		},
+		{
+			desc:                     "when the node did not start within the timeout and the machine status is not updated",
+			targets:                  []healthCheckTarget{nodeNeverStartedTarget},
+			expectedHealthy:          []healthCheckTarget{},
+			expectedNeedsRemediation: []healthCheckTarget{nodeNeverStartedTarget},
+			expectedNextCheckTimes:   []time.Duration{},
+		},
 		{
 			desc:                     "when the node has gone away",
 			targets:                  []healthCheckTarget{nodeGoneAway},
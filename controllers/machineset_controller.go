@@ -25,12 +25,15 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controllers/external"
 	"sigs.k8s.io/cluster-api/controllers/noderefutil"
@@ -39,11 +42,15 @@ import (
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/predicates"
+	"sigs.k8s.io/cluster-api/util/trace"
+	"sigs.k8s.io/cluster-api/util/tuning"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
@@ -72,8 +79,21 @@ type MachineSetReconciler struct {
 	Log     logr.Logger
 	Tracker *remote.ClusterCacheTracker
 
-	recorder record.EventRecorder
-	scheme   *runtime.Scheme
+	// MachineCreationRateLimit is the rate at which the Machines of each Cluster can be created; disabled if not set.
+	MachineCreationRateLimit MachineRateLimit
+
+	// MachineDeletionRateLimit is the rate at which the Machines of each Cluster can be deleted, including the
+	// deletions for remediating unhealthy Machines; disabled if not set.
+	MachineDeletionRateLimit MachineRateLimit
+
+	// Tuning configures the maximum requeue delay and the per-namespace concurrency of the reconciliations.
+	Tuning tuning.Options
+
+	recorder            record.EventRecorder
+	scheme              *runtime.Scheme
+	expectations        *machineSetExpectations
+	creationRateLimiter *clusterRateLimiter
+	deletionRateLimiter *clusterRateLimiter
 }
 
 func (r *MachineSetReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
@@ -91,7 +111,7 @@ func (r *MachineSetReconciler) SetupWithManager(mgr ctrl.Manager, options contro
 		).
 		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPaused(r.Log)).
-		Build(r)
+		Build(tuning.Wrap(r, r.Tuning))
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
 	}
@@ -106,26 +126,56 @@ func (r *MachineSetReconciler) SetupWithManager(mgr ctrl.Manager, options contro
 		return errors.Wrap(err, "failed to add Watch for Clusters to controller manager")
 	}
 
+	// Drop the rate limiters of the deleted Clusters.
+	err = c.Watch(
+		&source.Kind{Type: &clusterv1.Cluster{}},
+		&handler.Funcs{DeleteFunc: r.clusterDeleted},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to add Watch for Cluster deletions to controller manager")
+	}
+
 	r.recorder = mgr.GetEventRecorderFor("machineset-controller")
 	r.scheme = mgr.GetScheme()
+	r.expectations = newMachineSetExpectations()
+	r.creationRateLimiter = newClusterRateLimiter(r.MachineCreationRateLimit)
+	r.deletionRateLimiter = newClusterRateLimiter(r.MachineDeletionRateLimit)
 	return nil
 }
 
-func (r *MachineSetReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+// clusterDeleted removes the state kept for the Machines of a deleted Cluster.
+func (r *MachineSetReconciler) clusterDeleted(e event.DeleteEvent, _ workqueue.RateLimitingInterface) {
+	if e.Meta == nil {
+		return
+	}
+	cluster := types.NamespacedName{Namespace: e.Meta.GetNamespace(), Name: e.Meta.GetName()}
+	r.creationRateLimiter.forget(cluster)
+	r.deletionRateLimiter.forget(cluster)
+}
+
+func (r *MachineSetReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
 	ctx := context.Background()
-	logger := r.Log.WithValues("machineset", req.Name, "namespace", req.Namespace)
+	ctx, span := trace.Start(ctx, "MachineSetReconciler.Reconcile", trace.String(logutil.NamespaceKey, req.Namespace), trace.String(logutil.MachineSetKey, req.Name))
+	defer func() {
+		span.RecordError(reterr)
+		span.End()
+	}()
 
 	machineSet := &clusterv1.MachineSet{}
 	if err := r.Client.Get(ctx, req.NamespacedName, machineSet); err != nil {
 		if apierrors.IsNotFound(err) {
 			// Object not found, return. Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
+			r.expectations.delete(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		return ctrl.Result{}, err
 	}
 
+	logger := logutil.ForMachineSet(logutil.WithReconcileID(r.Log), machineSet)
+	ctx = logutil.IntoContext(ctx, logger)
+
 	cluster, err := util.GetClusterByName(ctx, r.Client, machineSet.ObjectMeta.Namespace, machineSet.Spec.ClusterName)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -140,6 +190,7 @@ func (r *MachineSetReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 	// Ignore deleted MachineSets, this can happen when foregroundDeletion
 	// is enabled
 	if !machineSet.DeletionTimestamp.IsZero() {
+		r.expectations.delete(req.NamespacedName)
 		return ctrl.Result{}, nil
 	}
 
@@ -152,7 +203,7 @@ func (r *MachineSetReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 }
 
 func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster, machineSet *clusterv1.MachineSet) (ctrl.Result, error) {
-	logger := r.Log.WithValues("machineset", machineSet.Name, "namespace", machineSet.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForMachineSet(r.Log, machineSet))
 	logger.V(4).Info("Reconcile MachineSet")
 
 	// Reconcile and retrieve the Cluster object.
@@ -206,6 +257,9 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 		return ctrl.Result{}, errors.Wrap(err, "failed to list machines")
 	}
 
+	// Lower the expectations for the Machine creations and deletions now visible in the cache.
+	r.expectations.observe(machineSet, allMachines.Items)
+
 	// Filter out irrelevant machines (deleting/mismatch labels) and claim orphaned machines.
 	filteredMachines := make([]*clusterv1.Machine, 0, len(allMachines.Items))
 	for idx := range allMachines.Items {
@@ -228,15 +282,29 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 		filteredMachines = append(filteredMachines, machine)
 	}
 
-	var errs []error
+	var (
+		errs               []error
+		rateLimitedRequeue time.Duration
+	)
 	for _, machine := range filteredMachines {
 		if conditions.IsFalse(machine, clusterv1.MachineOwnerRemediatedCondition) {
+			// Nb. the Machine could have been annotated after being marked for remediation.
+			if annotations.IsRemediationSkipped(machine) {
+				logger.Info("Machine is annotated to skip remediation, not deleting unhealthy machine", "machine", machine.GetName())
+				continue
+			}
+			if ok, delay := r.deletionRateLimiter.allow(clusterKey(machineSet), time.Now()); !ok {
+				logger.Info("Machine deletion rate limit reached for the cluster, delaying the deletion of unhealthy machine", "machine", machine.GetName(), "retryAfter", delay)
+				rateLimitedRequeue = delay
+				break
+			}
 			logger.Info("Deleting unhealthy machine", "machine", machine.GetName())
 			patch := client.MergeFrom(machine.DeepCopy())
 			if err := r.Client.Delete(ctx, machine); err != nil {
 				errs = append(errs, errors.Wrap(err, "failed to delete"))
 				continue
 			}
+			r.expectations.expectDeletions(machineSet, machine.Name)
 			conditions.MarkTrue(machine, clusterv1.MachineOwnerRemediatedCondition)
 			if err := r.Client.Status().Patch(ctx, machine, patch); err != nil && !apierrors.IsNotFound(err) {
 				errs = append(errs, errors.Wrap(err, "failed to update status"))
@@ -249,10 +317,45 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 		logger.Info("Failed while deleting unhealthy machines", "err", err)
 		return ctrl.Result{}, errors.Wrap(err, "failed to remediate machines")
 	}
+	if rateLimitedRequeue > 0 {
+		r.recorder.Eventf(machineSet, corev1.EventTypeWarning, "RateLimited", "Machine deletion rate limit reached for cluster %q, delaying the remediation of unhealthy machines", machineSet.Spec.ClusterName)
+		return ctrl.Result{RequeueAfter: rateLimitedRequeue}, nil
+	}
+
+	// Propagate in place the labels and annotations updated in the machine template.
+	if err := r.syncMachinesMetadata(ctx, machineSet, filteredMachines); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to propagate labels and annotations to machines")
+	}
+
+	// Run the preflight checks before creating new Machines; if the checks fail, the creation is paused.
+	var preflightFailure *preflightCheckFailure
+	if machineSet.Spec.Replicas != nil && len(filteredMachines) < int(*machineSet.Spec.Replicas) {
+		preflightFailure, err = r.runPreflightChecks(ctx, cluster, machineSet)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to run preflight checks")
+		}
+	}
+
+	// Skip syncing the replicas while the Machines created or deleted by a previous reconcile are not yet
+	// reflected in the cache, otherwise the MachineSet could create or delete more Machines than required.
+	expectationsSatisfied := r.expectations.satisfied(machineSet)
 
-	syncErr := r.syncReplicas(ctx, machineSet, filteredMachines)
+	var (
+		syncResult ctrl.Result
+		syncErr    error
+	)
+	switch {
+	case !expectationsSatisfied:
+		creations, deletions := r.expectations.pending(machineSet)
+		logger.V(4).Info("Waiting for pending machine creations and deletions to be observed, skipping replicas sync", "creations", creations, "deletions", deletions)
+	case preflightFailure == nil:
+		syncResult, syncErr = r.syncReplicas(ctx, cluster, machineSet, filteredMachines)
+	default:
+		logger.Info("Preflight checks failed, pausing the creation of new machines", "reason", preflightFailure.reason, "message", preflightFailure.message)
+	}
 
 	ms := machineSet.DeepCopy()
+	setPreflightChecksCondition(ms, preflightFailure)
 	newStatus, err := r.calculateStatus(ctx, cluster, ms, filteredMachines)
 	if err != nil {
 		return ctrl.Result{}, errors.Wrapf(err, "failed to calculate MachineSet's Status")
@@ -271,6 +374,18 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 		return ctrl.Result{}, errors.Wrapf(syncErr, "failed to sync MachineSet replicas")
 	}
 
+	if syncResult.RequeueAfter > 0 {
+		return syncResult, nil
+	}
+
+	if !expectationsSatisfied {
+		return ctrl.Result{RequeueAfter: expectationsRequeueAfter}, nil
+	}
+
+	if preflightFailure != nil {
+		return ctrl.Result{RequeueAfter: preflightChecksRequeueAfter}, nil
+	}
+
 	var replicas int32
 	if updatedMS.Spec.Replicas != nil {
 		replicas = *updatedMS.Spec.Replicas
@@ -299,13 +414,17 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 	return ctrl.Result{}, nil
 }
 
-// syncReplicas scales Machine resources up or down.
-func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.MachineSet, machines []*clusterv1.Machine) error {
-	logger := r.Log.WithValues("machineset", ms.Name, "namespace", ms.Namespace)
+// syncReplicas scales Machine resources up or down; if the creation or deletion rate limit of the Cluster is reached,
+// the remaining Machines are created or deleted later, and the returned result requeues the MachineSet accordingly.
+func (r *MachineSetReconciler) syncReplicas(ctx context.Context, cluster *clusterv1.Cluster, ms *clusterv1.MachineSet, machines []*clusterv1.Machine) (ctrl.Result, error) {
+	logger := logutil.FromContext(ctx, logutil.ForMachineSet(r.Log, ms))
 	if ms.Spec.Replicas == nil {
-		return errors.Errorf("the Replicas field in Spec for machineset %v is nil, this should not be allowed", ms.Name)
+		return ctrl.Result{}, errors.Errorf("the Replicas field in Spec for machineset %v is nil, this should not be allowed", ms.Name)
 	}
 
+	// Track the distribution of the machines across failure domains, if the MachineSet spreads them.
+	placement := newFailureDomainPlacement(cluster, ms, machines)
+
 	diff := len(machines) - int(*(ms.Spec.Replicas))
 	switch {
 	case diff < 0:
@@ -315,19 +434,32 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 		var (
 			machineList []*clusterv1.Machine
 			errs        []error
+			result      ctrl.Result
 		)
 
 		for i := 0; i < diff; i++ {
+			if ok, delay := r.creationRateLimiter.allow(clusterKey(ms), time.Now()); !ok {
+				logger.Info("Machine creation rate limit reached for the cluster, delaying the creation of the remaining machines", "remaining", diff-i, "retryAfter", delay)
+				r.recorder.Eventf(ms, corev1.EventTypeWarning, "RateLimited", "Machine creation rate limit reached for cluster %q, delaying the creation of %d machines", ms.Spec.ClusterName, diff-i)
+				result.RequeueAfter = delay
+				break
+			}
+
 			logger.Info(fmt.Sprintf("Creating machine %d of %d, ( spec.replicas(%d) > currentMachineCount(%d) )",
 				i+1, diff, *(ms.Spec.Replicas), len(machines)))
 
-			machine := r.getNewMachine(ms)
+			machine, err := r.getNewMachine(ms)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if failureDomain := placement.next(); failureDomain != nil {
+				machine.Spec.FailureDomain = failureDomain
+			}
 
 			// Clone and set the infrastructure and bootstrap references.
-			var (
-				infraRef, bootstrapRef *corev1.ObjectReference
-				err                    error
-			)
+			// Nb. if the MachineSet defines a naming strategy, the cloned objects are named after the Machine,
+			// because infrastructure providers usually derive the host names from their names.
+			var infraRef, bootstrapRef *corev1.ObjectReference
 
 			if machine.Spec.Bootstrap.ConfigRef != nil {
 				bootstrapRef, err = external.CloneTemplate(ctx, &external.CloneTemplateInput{
@@ -336,9 +468,10 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 					Namespace:   machine.Namespace,
 					ClusterName: machine.Spec.ClusterName,
 					Labels:      machine.Labels,
+					Name:        machine.Name,
 				})
 				if err != nil {
-					return errors.Wrapf(err, "failed to clone bootstrap configuration for MachineSet %q in namespace %q", ms.Name, ms.Namespace)
+					return ctrl.Result{}, errors.Wrapf(err, "failed to clone bootstrap configuration for MachineSet %q in namespace %q", ms.Name, ms.Namespace)
 				}
 				machine.Spec.Bootstrap.ConfigRef = bootstrapRef
 			}
@@ -349,9 +482,10 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 				Namespace:   machine.Namespace,
 				ClusterName: machine.Spec.ClusterName,
 				Labels:      machine.Labels,
+				Name:        machine.Name,
 			})
 			if err != nil {
-				return errors.Wrapf(err, "failed to clone infrastructure configuration for MachineSet %q in namespace %q", ms.Name, ms.Namespace)
+				return ctrl.Result{}, errors.Wrapf(err, "failed to clone infrastructure configuration for MachineSet %q in namespace %q", ms.Name, ms.Namespace)
 			}
 			machine.Spec.InfrastructureRef = *infraRef
 
@@ -372,53 +506,117 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 				continue
 			}
 
+			r.expectations.expectCreations(ms, machine.Name)
 			logger.Info(fmt.Sprintf("Created machine %d of %d with name %q", i+1, diff, machine.Name))
 			r.recorder.Eventf(ms, corev1.EventTypeNormal, "SuccessfulCreate", "Created machine %q", machine.Name)
 			machineList = append(machineList, machine)
 		}
 
 		if len(errs) > 0 {
-			return kerrors.NewAggregate(errs)
+			return ctrl.Result{}, kerrors.NewAggregate(errs)
 		}
-		return r.waitForMachineCreation(machineList)
+		return result, r.waitForMachineCreation(machineList)
 	case diff > 0:
 		logger.Info("Too many replicas", "need", *(ms.Spec.Replicas), "deleting", diff)
 
 		deletePriorityFunc, err := getDeletePriorityFunc(ms)
 		if err != nil {
-			return err
+			return ctrl.Result{}, err
 		}
 		logger.Info("Found delete policy", "delete-policy", ms.Spec.DeletePolicy)
 
-		var errs []error
-		machinesToDelete := getMachinesToDeletePrioritized(machines, diff, deletePriorityFunc)
-		for _, machine := range machinesToDelete {
+		var (
+			errs    []error
+			deleted []*clusterv1.Machine
+			result  ctrl.Result
+		)
+		// Protected Machines are never selected for deletion; if there are not enough unprotected Machines,
+		// the MachineSet keeps more Machines than the desired replicas until the protection is removed.
+		candidates, protected := excludeProtectedMachines(machines)
+		if len(candidates) < diff {
+			logger.Info("Not enough unprotected machines to scale down", "protected", len(protected), "deleting", len(candidates))
+			r.recorder.Eventf(ms, corev1.EventTypeWarning, "ProtectedMachines", "Can't delete %d machines, the remaining machines are protected by the %s annotation", diff-len(candidates), clusterv1.ProtectMachineAnnotation)
+			diff = len(candidates)
+		}
+		machinesToDelete := placement.machinesToDelete(candidates, diff, deletePriorityFunc)
+		for i, machine := range machinesToDelete {
+			if ok, delay := r.deletionRateLimiter.allow(clusterKey(ms), time.Now()); !ok {
+				logger.Info("Machine deletion rate limit reached for the cluster, delaying the deletion of the remaining machines", "remaining", len(machinesToDelete)-i, "retryAfter", delay)
+				r.recorder.Eventf(ms, corev1.EventTypeWarning, "RateLimited", "Machine deletion rate limit reached for cluster %q, delaying the deletion of %d machines", ms.Spec.ClusterName, len(machinesToDelete)-i)
+				result.RequeueAfter = delay
+				break
+			}
+
 			if err := r.Client.Delete(ctx, machine); err != nil {
 				logger.Error(err, "Unable to delete Machine", "machine", machine.Name)
 				r.recorder.Eventf(ms, corev1.EventTypeWarning, "FailedDelete", "Failed to delete machine %q: %v", machine.Name, err)
 				errs = append(errs, err)
 				continue
 			}
+			r.expectations.expectDeletions(ms, machine.Name)
 			logger.Info("Deleted machine", "machine", machine.Name)
 			r.recorder.Eventf(ms, corev1.EventTypeNormal, "SuccessfulDelete", "Deleted machine %q", machine.Name)
+			deleted = append(deleted, machine)
 		}
 
 		if len(errs) > 0 {
-			return kerrors.NewAggregate(errs)
+			return ctrl.Result{}, kerrors.NewAggregate(errs)
 		}
-		return r.waitForMachineDeletion(machinesToDelete)
+		return result, r.waitForMachineDeletion(deleted)
 	}
 
-	return nil
+	return ctrl.Result{}, nil
+}
+
+// excludeProtectedMachines splits the Machines in the ones that can be deleted when scaling down and the protected ones;
+// protected Machines already being deleted are not excluded.
+func excludeProtectedMachines(machines []*clusterv1.Machine) (candidates, protected []*clusterv1.Machine) {
+	for _, m := range machines {
+		if annotations.HasProtectMachineAnnotation(m) && m.DeletionTimestamp.IsZero() {
+			protected = append(protected, m)
+			continue
+		}
+		candidates = append(candidates, m)
+	}
+	return candidates, protected
+}
+
+// clusterKey returns the key of the Cluster of the MachineSet, used for rate limiting the Machine operations per Cluster.
+func clusterKey(ms *clusterv1.MachineSet) types.NamespacedName {
+	return types.NamespacedName{Namespace: ms.Namespace, Name: ms.Spec.ClusterName}
+}
+
+// syncMachinesMetadata propagates the labels and annotations with the propagated metadata domain from the
+// machine template to the given machines, updating them in place.
+func (r *MachineSetReconciler) syncMachinesMetadata(ctx context.Context, ms *clusterv1.MachineSet, machines []*clusterv1.Machine) error {
+	var errs []error
+	for _, machine := range machines {
+		if !machine.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		patch := client.MergeFrom(machine.DeepCopy())
+		var labelsUpdated, annotationsUpdated bool
+		machine.Labels, labelsUpdated = util.SyncPropagatedMetadata(machine.Labels, ms.Spec.Template.Labels)
+		machine.Annotations, annotationsUpdated = util.SyncPropagatedMetadata(machine.Annotations, ms.Spec.Template.Annotations)
+		if !labelsUpdated && !annotationsUpdated {
+			continue
+		}
+
+		if err := r.Client.Patch(ctx, machine, patch); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to patch Machine %q", machine.Name))
+		}
+	}
+	return kerrors.NewAggregate(errs)
 }
 
-// getNewMachine creates a new Machine object. The name of the newly created resource is going
-// to be created by the API server, we set the generateName field.
-func (r *MachineSetReconciler) getNewMachine(machineSet *clusterv1.MachineSet) *clusterv1.Machine {
+// getNewMachine creates a new Machine object. If the MachineSet defines a naming strategy, the name is generated
+// accordingly; otherwise the name of the newly created resource is going to be created by the API server, we set the
+// generateName field.
+func (r *MachineSetReconciler) getNewMachine(machineSet *clusterv1.MachineSet) (*clusterv1.Machine, error) {
 	gv := clusterv1.GroupVersion
 	machine := &clusterv1.Machine{
 		ObjectMeta: metav1.ObjectMeta{
-			GenerateName:    fmt.Sprintf("%s-", machineSet.Name),
 			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(machineSet, machineSetKind)},
 			Namespace:       machineSet.Namespace,
 			Labels:          machineSet.Spec.Template.Labels,
@@ -434,7 +632,17 @@ func (r *MachineSetReconciler) getNewMachine(machineSet *clusterv1.MachineSet) *
 	if machine.Labels == nil {
 		machine.Labels = make(map[string]string)
 	}
-	return machine
+
+	if machineSet.Spec.MachineNamingStrategy == nil {
+		machine.GenerateName = fmt.Sprintf("%s-", machineSet.Name)
+		return machine, nil
+	}
+	name, err := clusterv1.GenerateMachineName(machineSet.Spec.MachineNamingStrategy, machineSet.Spec.ClusterName, machineSet.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to generate the name of a Machine for MachineSet %q in namespace %q", machineSet.Name, machineSet.Namespace)
+	}
+	machine.Name = name
+	return machine, nil
 }
 
 // shouldExcludeMachine returns true if the machine should be filtered out, false otherwise.
@@ -533,7 +741,7 @@ func (r *MachineSetReconciler) MachineToMachineSets(o handler.MapObject) []ctrl.
 }
 
 func (r *MachineSetReconciler) getMachineSetsForMachine(m *clusterv1.Machine) []*clusterv1.MachineSet {
-	logger := r.Log.WithValues("machine", m.Name, "namespace", m.Namespace)
+	logger := logutil.ForMachine(r.Log, m)
 
 	if len(m.Labels) == 0 {
 		logger.Info("No machine sets found because it has no labels")
@@ -559,7 +767,7 @@ func (r *MachineSetReconciler) getMachineSetsForMachine(m *clusterv1.Machine) []
 }
 
 func (r *MachineSetReconciler) hasMatchingLabels(machineSet *clusterv1.MachineSet, machine *clusterv1.Machine) bool {
-	logger := r.Log.WithValues("machineset", machineSet.Name, "namespace", machineSet.Namespace, "machine", machine.Name)
+	logger := logutil.ForMachineSet(r.Log, machineSet).WithValues(logutil.MachineKey, machine.Name)
 
 	selector, err := metav1.LabelSelectorAsSelector(&machineSet.Spec.Selector)
 	if err != nil {
@@ -586,7 +794,7 @@ func (r *MachineSetReconciler) shouldAdopt(ms *clusterv1.MachineSet) bool {
 }
 
 func (r *MachineSetReconciler) calculateStatus(ctx context.Context, cluster *clusterv1.Cluster, ms *clusterv1.MachineSet, filteredMachines []*clusterv1.Machine) (*clusterv1.MachineSetStatus, error) {
-	logger := r.Log.WithValues("machineset", ms.Name, "namespace", ms.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForMachineSet(r.Log, ms))
 	newStatus := ms.Status.DeepCopy()
 
 	// Copy label selector to its status counterpart in string format.
@@ -640,7 +848,7 @@ func (r *MachineSetReconciler) calculateStatus(ctx context.Context, cluster *clu
 
 // patchMachineSetStatus attempts to update the Status.Replicas of the given MachineSet.
 func (r *MachineSetReconciler) patchMachineSetStatus(ctx context.Context, ms *clusterv1.MachineSet, newStatus *clusterv1.MachineSetStatus) (*clusterv1.MachineSet, error) {
-	logger := r.Log.WithValues("machineset", ms.Name, "namespace", ms.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForMachineSet(r.Log, ms))
 
 	// This is the steady state. It happens when the MachineSet doesn't have any expectations, since
 	// we do a periodic relist every 10 minutes. If the generations differ but the replicas are
@@ -649,6 +857,7 @@ func (r *MachineSetReconciler) patchMachineSetStatus(ctx context.Context, ms *cl
 		ms.Status.FullyLabeledReplicas == newStatus.FullyLabeledReplicas &&
 		ms.Status.ReadyReplicas == newStatus.ReadyReplicas &&
 		ms.Status.AvailableReplicas == newStatus.AvailableReplicas &&
+		equality.Semantic.DeepEqual(ms.Status.Conditions, newStatus.Conditions) &&
 		ms.Generation == ms.Status.ObservedGeneration {
 		return ms, nil
 	}
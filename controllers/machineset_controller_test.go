@@ -31,6 +31,7 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/klogr"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -802,3 +803,46 @@ func newMachineSet(name, cluster string) *clusterv1.MachineSet {
 		},
 	}
 }
+
+func TestMachineSetGetNewMachine(t *testing.T) {
+	tests := []struct {
+		name             string
+		strategy         *clusterv1.MachineNamingStrategy
+		wantGenerateName string
+		wantNamePrefix   string
+	}{
+		{
+			name:             "generates the name from the MachineSet name if there is no naming strategy",
+			wantGenerateName: "md-0-abcde-",
+		},
+		{
+			name:           "generates the name according to the naming strategy",
+			strategy:       &clusterv1.MachineNamingStrategy{Template: "{{ .cluster.name }}-w{{ .random }}", MaxLength: pointer.Int32Ptr(20)},
+			wantNamePrefix: "test-cluster-w",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ms := &clusterv1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "md-0-abcde"},
+				Spec: clusterv1.MachineSetSpec{
+					ClusterName:           "test-cluster",
+					MachineNamingStrategy: tt.strategy,
+				},
+			}
+
+			r := &MachineSetReconciler{}
+			machine, err := r.getNewMachine(ms)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(machine.GenerateName).To(Equal(tt.wantGenerateName))
+			if tt.wantNamePrefix != "" {
+				g.Expect(machine.Name).To(HavePrefix(tt.wantNamePrefix))
+				g.Expect(len(machine.Name)).To(BeNumerically("<=", 20))
+			} else {
+				g.Expect(machine.Name).To(BeEmpty())
+			}
+		})
+	}
+}
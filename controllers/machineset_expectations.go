@@ -0,0 +1,199 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+const (
+	// expectationsTimeout is the amount of time after which pending expectations are considered expired,
+	// so a MachineSet does not stay stuck if an expected Machine event never shows up in the cache.
+	expectationsTimeout = 5 * time.Minute
+
+	// expectationsRequeueAfter is the interval for re-checking a MachineSet with pending expectations.
+	expectationsRequeueAfter = 10 * time.Second
+)
+
+// machineSetExpectations tracks the Machine creations and deletions issued by the MachineSet controller
+// that are not yet reflected in the cache, similarly to the expectations used by the ReplicaSet controller.
+// Machines are tracked by name and are observed only when controlled by the MachineSet that issued the operation,
+// so that the replicas are not synced against a stale list of Machines.
+// A nil *machineSetExpectations is valid and is always satisfied.
+type machineSetExpectations struct {
+	lock  sync.Mutex
+	items map[types.NamespacedName]*machineExpectations
+}
+
+// machineExpectations are the pending Machine operations for a single MachineSet.
+type machineExpectations struct {
+	// ownerUID is the UID of the MachineSet the expectations have been set for; it is used to discard
+	// expectations set for a previous MachineSet with the same name.
+	ownerUID  types.UID
+	creations sets.String
+	deletions sets.String
+	timestamp time.Time
+}
+
+// newMachineSetExpectations returns an empty machineSetExpectations.
+func newMachineSetExpectations() *machineSetExpectations {
+	return &machineSetExpectations{
+		items: map[types.NamespacedName]*machineExpectations{},
+	}
+}
+
+// expectCreations records the Machines created for the MachineSet.
+func (e *machineSetExpectations) expectCreations(ms *clusterv1.MachineSet, names ...string) {
+	if e == nil || len(names) == 0 {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	exp := e.getOrCreate(ms)
+	exp.creations.Insert(names...)
+}
+
+// expectDeletions records the Machines deleted for the MachineSet.
+func (e *machineSetExpectations) expectDeletions(ms *clusterv1.MachineSet, names ...string) {
+	if e == nil || len(names) == 0 {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	exp := e.getOrCreate(ms)
+	exp.deletions.Insert(names...)
+}
+
+// observe lowers the expectations of the MachineSet given the Machines currently in the cache; a creation is
+// observed when the Machine exists and is controlled by the MachineSet, a deletion is observed when the
+// Machine is gone, is being deleted, or is no longer controlled by the MachineSet.
+func (e *machineSetExpectations) observe(ms *clusterv1.MachineSet, machines []clusterv1.Machine) {
+	if e == nil {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	exp, ok := e.items[keyForMachineSet(ms)]
+	if !ok {
+		return
+	}
+
+	owned := sets.NewString()
+	active := sets.NewString()
+	for i := range machines {
+		m := &machines[i]
+		if !metav1.IsControlledBy(m, ms) {
+			continue
+		}
+		owned.Insert(m.Name)
+		if m.DeletionTimestamp.IsZero() {
+			active.Insert(m.Name)
+		}
+	}
+
+	for _, name := range exp.creations.UnsortedList() {
+		if owned.Has(name) {
+			exp.creations.Delete(name)
+		}
+	}
+	for _, name := range exp.deletions.UnsortedList() {
+		if !active.Has(name) {
+			exp.deletions.Delete(name)
+		}
+	}
+}
+
+// satisfied returns true if there are no pending expectations for the MachineSet, or if they are expired.
+// Expired expectations, or expectations set for a previous MachineSet with the same name, are dropped.
+func (e *machineSetExpectations) satisfied(ms *clusterv1.MachineSet) bool {
+	if e == nil {
+		return true
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	key := keyForMachineSet(ms)
+	exp, ok := e.items[key]
+	if !ok {
+		return true
+	}
+	if exp.ownerUID != ms.UID || time.Since(exp.timestamp) > expectationsTimeout {
+		delete(e.items, key)
+		return true
+	}
+	if exp.creations.Len() == 0 && exp.deletions.Len() == 0 {
+		delete(e.items, key)
+		return true
+	}
+	return false
+}
+
+// pending returns the number of Machine creations and deletions not yet observed for the MachineSet.
+func (e *machineSetExpectations) pending(ms *clusterv1.MachineSet) (creations, deletions int) {
+	if e == nil {
+		return 0, 0
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	exp, ok := e.items[keyForMachineSet(ms)]
+	if !ok {
+		return 0, 0
+	}
+	return exp.creations.Len(), exp.deletions.Len()
+}
+
+// delete removes the expectations for the MachineSet with the given key, if any.
+func (e *machineSetExpectations) delete(key types.NamespacedName) {
+	if e == nil {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	delete(e.items, key)
+}
+
+// getOrCreate returns the expectations for the MachineSet, resetting the ones set for a previous MachineSet
+// with the same name; the caller must hold the lock.
+func (e *machineSetExpectations) getOrCreate(ms *clusterv1.MachineSet) *machineExpectations {
+	key := keyForMachineSet(ms)
+	exp, ok := e.items[key]
+	if !ok || exp.ownerUID != ms.UID {
+		exp = &machineExpectations{
+			ownerUID:  ms.UID,
+			creations: sets.NewString(),
+			deletions: sets.NewString(),
+		}
+		e.items[key] = exp
+	}
+	exp.timestamp = time.Now()
+	return exp
+}
+
+func keyForMachineSet(ms *clusterv1.MachineSet) types.NamespacedName {
+	return types.NamespacedName{Namespace: ms.Namespace, Name: ms.Name}
+}
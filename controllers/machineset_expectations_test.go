@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+func TestMachineSetExpectations(t *testing.T) {
+	newOwnedMachine := func(ms *clusterv1.MachineSet, name string) clusterv1.Machine {
+		return clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       ms.Namespace,
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(ms, machineSetKind)},
+			},
+		}
+	}
+
+	t.Run("creations are observed when the machines controlled by the MachineSet show up", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ms := newMachineSet("ms1", "cluster1")
+		ms.UID = "ms1-uid"
+		other := newMachineSet("ms2", "cluster1")
+		other.UID = "ms2-uid"
+
+		e := newMachineSetExpectations()
+		e.expectCreations(ms, "m1", "m2")
+		g.Expect(e.satisfied(ms)).To(BeFalse())
+
+		// A machine with the same name controlled by another MachineSet does not count.
+		e.observe(ms, []clusterv1.Machine{newOwnedMachine(ms, "m1"), newOwnedMachine(other, "m2")})
+		g.Expect(e.satisfied(ms)).To(BeFalse())
+		creations, deletions := e.pending(ms)
+		g.Expect(creations).To(Equal(1))
+		g.Expect(deletions).To(Equal(0))
+
+		e.observe(ms, []clusterv1.Machine{newOwnedMachine(ms, "m1"), newOwnedMachine(ms, "m2")})
+		g.Expect(e.satisfied(ms)).To(BeTrue())
+	})
+
+	t.Run("deletions are observed when the machines are gone or being deleted", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ms := newMachineSet("ms1", "cluster1")
+		ms.UID = "ms1-uid"
+
+		e := newMachineSetExpectations()
+		e.expectDeletions(ms, "m1", "m2")
+
+		e.observe(ms, []clusterv1.Machine{newOwnedMachine(ms, "m1"), newOwnedMachine(ms, "m2")})
+		g.Expect(e.satisfied(ms)).To(BeFalse())
+
+		deleting := newOwnedMachine(ms, "m2")
+		now := metav1.Now()
+		deleting.DeletionTimestamp = &now
+		e.observe(ms, []clusterv1.Machine{deleting})
+		g.Expect(e.satisfied(ms)).To(BeTrue())
+	})
+
+	t.Run("expectations of a previous MachineSet with the same name are ignored", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ms := newMachineSet("ms1", "cluster1")
+		ms.UID = "old-uid"
+
+		e := newMachineSetExpectations()
+		e.expectCreations(ms, "m1")
+		g.Expect(e.satisfied(ms)).To(BeFalse())
+
+		recreated := ms.DeepCopy()
+		recreated.UID = "new-uid"
+		g.Expect(e.satisfied(recreated)).To(BeTrue())
+	})
+
+	t.Run("expired expectations are satisfied", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ms := newMachineSet("ms1", "cluster1")
+
+		e := newMachineSetExpectations()
+		e.expectCreations(ms, "m1")
+		e.items[keyForMachineSet(ms)].timestamp = time.Now().Add(-2 * expectationsTimeout)
+		g.Expect(e.satisfied(ms)).To(BeTrue())
+	})
+
+	t.Run("deleted expectations are satisfied", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ms := newMachineSet("ms1", "cluster1")
+
+		e := newMachineSetExpectations()
+		e.expectDeletions(ms, "m1")
+		e.delete(types.NamespacedName{Namespace: ms.Namespace, Name: ms.Name})
+		g.Expect(e.satisfied(ms)).To(BeTrue())
+	})
+
+	t.Run("nil expectations are always satisfied", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ms := newMachineSet("ms1", "cluster1")
+
+		var e *machineSetExpectations
+		e.expectCreations(ms, "m1")
+		e.observe(ms, nil)
+		g.Expect(e.satisfied(ms)).To(BeTrue())
+	})
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sort"
+
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+// failureDomainPlacement tracks the distribution of the Machines of a MachineSet across the failure domains of the
+// Cluster, so new Machines are placed in under-populated failure domains and scale down removes Machines from
+// over-populated failure domains.
+// A nil *failureDomainPlacement is valid and does not place Machines.
+type failureDomainPlacement struct {
+	counts map[string]int
+}
+
+// newFailureDomainPlacement returns the failureDomainPlacement for the Machines of a MachineSet, or nil if the
+// MachineSet does not spread its Machines across failure domains, or the Cluster does not have failure domains.
+func newFailureDomainPlacement(cluster *clusterv1.Cluster, ms *clusterv1.MachineSet, machines []*clusterv1.Machine) *failureDomainPlacement {
+	if !ms.Spec.SpreadAcrossFailureDomains || ms.Spec.Template.Spec.FailureDomain != nil {
+		return nil
+	}
+	if cluster == nil || len(cluster.Status.FailureDomains) == 0 {
+		return nil
+	}
+
+	p := &failureDomainPlacement{counts: map[string]int{}}
+	for id := range cluster.Status.FailureDomains {
+		p.counts[id] = 0
+	}
+	for _, m := range machines {
+		if id, ok := p.failureDomain(m); ok {
+			p.counts[id]++
+		}
+	}
+	return p
+}
+
+// failureDomain returns the failure domain of a Machine, if it is one of the failure domains of the Cluster.
+func (p *failureDomainPlacement) failureDomain(m *clusterv1.Machine) (string, bool) {
+	if m.Spec.FailureDomain == nil {
+		return "", false
+	}
+	_, ok := p.counts[*m.Spec.FailureDomain]
+	return *m.Spec.FailureDomain, ok
+}
+
+// next returns the failure domain with the fewest Machines for a new Machine, and accounts the Machine to it.
+// Ties are broken by failure domain name, so the placement is deterministic.
+func (p *failureDomainPlacement) next() *string {
+	if p == nil {
+		return nil
+	}
+
+	ids := make([]string, 0, len(p.counts))
+	for id := range p.counts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	next := ids[0]
+	for _, id := range ids[1:] {
+		if p.counts[id] < p.counts[next] {
+			next = id
+		}
+	}
+	p.counts[next]++
+	return pointer.StringPtr(next)
+}
+
+// machinesToDelete returns diff Machines to delete when scaling down.
+// Machines that should be deleted regardless of their placement, e.g. failed Machines or Machines marked for deletion,
+// and Machines outside of the failure domains of the Cluster go first; then Machines are picked from the failure
+// domain with the most Machines, following the delete policy within each failure domain.
+func (p *failureDomainPlacement) machinesToDelete(machines []*clusterv1.Machine, diff int, fun deletePriorityFunc) []*clusterv1.Machine {
+	if p == nil {
+		return getMachinesToDeletePrioritized(machines, diff, fun)
+	}
+	if diff >= len(machines) {
+		return machines
+	} else if diff <= 0 {
+		return []*clusterv1.Machine{}
+	}
+
+	sortable := sortableMachines{
+		machines: append([]*clusterv1.Machine{}, machines...),
+		priority: fun,
+	}
+	sort.Stable(sortable)
+
+	counts := map[string]int{}
+	for id, count := range p.counts {
+		counts[id] = count
+	}
+
+	remaining := sortable.machines
+	toDelete := make([]*clusterv1.Machine, 0, diff)
+	for len(toDelete) < diff {
+		pick := -1
+		for i, m := range remaining {
+			id, ok := p.failureDomain(m)
+			if !ok || isDeletionPreferred(m) {
+				pick = i
+				break
+			}
+			if pick < 0 {
+				pick = i
+				continue
+			}
+			if pickID, _ := p.failureDomain(remaining[pick]); counts[id] > counts[pickID] {
+				pick = i
+			}
+		}
+
+		m := remaining[pick]
+		if id, ok := p.failureDomain(m); ok {
+			counts[id]--
+		}
+		toDelete = append(toDelete, m)
+		remaining = append(remaining[:pick], remaining[pick+1:]...)
+	}
+	return toDelete
+}
+
+// isDeletionPreferred returns true if a Machine is given priority for deletion by all the delete policies.
+func isDeletionPreferred(machine *clusterv1.Machine) bool {
+	if !machine.DeletionTimestamp.IsZero() {
+		return true
+	}
+	if machine.ObjectMeta.Annotations != nil && machine.ObjectMeta.Annotations[DeleteNodeAnnotation] != "" {
+		return true
+	}
+	if _, ok := machine.ObjectMeta.Annotations[DeleteMachineAnnotation]; ok {
+		return true
+	}
+	if machine.Status.NodeRef == nil {
+		return true
+	}
+	return machine.Status.FailureReason != nil || machine.Status.FailureMessage != nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/klogr"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+func TestFailureDomainPlacement(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		Status: clusterv1.ClusterStatus{
+			FailureDomains: clusterv1.FailureDomains{
+				"fd1": clusterv1.FailureDomainSpec{},
+				"fd2": clusterv1.FailureDomainSpec{},
+				"fd3": clusterv1.FailureDomainSpec{},
+			},
+		},
+	}
+	spreadMachineSet := &clusterv1.MachineSet{Spec: clusterv1.MachineSetSpec{SpreadAcrossFailureDomains: true}}
+
+	machine := func(name string, failureDomain *string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       clusterv1.MachineSpec{FailureDomain: failureDomain},
+			Status:     clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: name}},
+		}
+	}
+	names := func(machines []*clusterv1.Machine) []string {
+		ret := []string{}
+		for _, m := range machines {
+			ret = append(ret, m.Name)
+		}
+		return ret
+	}
+
+	t.Run("machines are not placed if the MachineSet does not spread them", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(newFailureDomainPlacement(cluster, &clusterv1.MachineSet{}, nil)).To(BeNil())
+		g.Expect(newFailureDomainPlacement(&clusterv1.Cluster{}, spreadMachineSet, nil)).To(BeNil())
+
+		explicit := spreadMachineSet.DeepCopy()
+		explicit.Spec.Template.Spec.FailureDomain = pointer.StringPtr("fd1")
+		g.Expect(newFailureDomainPlacement(cluster, explicit, nil)).To(BeNil())
+
+		var p *failureDomainPlacement
+		g.Expect(p.next()).To(BeNil())
+	})
+
+	t.Run("new machines are placed in the failure domains with the fewest machines", func(t *testing.T) {
+		g := NewWithT(t)
+
+		p := newFailureDomainPlacement(cluster, spreadMachineSet, []*clusterv1.Machine{
+			machine("m1", pointer.StringPtr("fd1")),
+			machine("m2", pointer.StringPtr("fd1")),
+			machine("m3", pointer.StringPtr("fd2")),
+			machine("m4", pointer.StringPtr("unknown")),
+		})
+		placed := []string{}
+		for i := 0; i < 5; i++ {
+			placed = append(placed, *p.next())
+		}
+		g.Expect(placed).To(Equal([]string{"fd3", "fd2", "fd3", "fd1", "fd2"}))
+	})
+
+	t.Run("scale down removes machines from the failure domains with the most machines", func(t *testing.T) {
+		g := NewWithT(t)
+
+		machines := []*clusterv1.Machine{
+			machine("m1", pointer.StringPtr("fd1")),
+			machine("m2", pointer.StringPtr("fd1")),
+			machine("m3", pointer.StringPtr("fd1")),
+			machine("m4", pointer.StringPtr("fd2")),
+			machine("m5", pointer.StringPtr("fd2")),
+		}
+		p := newFailureDomainPlacement(cluster, spreadMachineSet, machines)
+		toDelete := p.machinesToDelete(machines, 3, randomDeletePolicy)
+		g.Expect(names(toDelete)).To(Equal([]string{"m1", "m2", "m4"}))
+	})
+
+	t.Run("scale down removes machines preferred for deletion and outside of the failure domains first", func(t *testing.T) {
+		g := NewWithT(t)
+
+		failed := machine("failed", pointer.StringPtr("fd3"))
+		failed.Status.FailureMessage = pointer.StringPtr("failed")
+		machines := []*clusterv1.Machine{
+			machine("m1", pointer.StringPtr("fd1")),
+			machine("m2", pointer.StringPtr("fd1")),
+			machine("m3", pointer.StringPtr("fd2")),
+			machine("unplaced", nil),
+			failed,
+		}
+		p := newFailureDomainPlacement(cluster, spreadMachineSet, machines)
+		toDelete := p.machinesToDelete(machines, 3, randomDeletePolicy)
+		g.Expect(names(toDelete)).To(ConsistOf("failed", "unplaced", "m1"))
+	})
+}
+
+func TestMachineSetSyncReplicasSpreadAcrossFailureDomains(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster1"},
+		Status: clusterv1.ClusterStatus{
+			FailureDomains: clusterv1.FailureDomains{
+				"fd1": clusterv1.FailureDomainSpec{},
+				"fd2": clusterv1.FailureDomainSpec{},
+			},
+		},
+	}
+	ms := newMachineSet("ms1", "cluster1")
+	ms.Spec.Replicas = pointer.Int32Ptr(4)
+	ms.Spec.SpreadAcrossFailureDomains = true
+	ms.Spec.Template.Spec.InfrastructureRef = corev1.ObjectReference{
+		APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+		Kind:       "InfrastructureMachineTemplate",
+		Name:       "ms-template",
+	}
+
+	infraTmpl := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{},
+			},
+		},
+	}
+	infraTmpl.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1alpha3")
+	infraTmpl.SetKind("InfrastructureMachineTemplate")
+	infraTmpl.SetNamespace(ms.Namespace)
+	infraTmpl.SetName("ms-template")
+
+	r := &MachineSetReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme, ms, infraTmpl),
+		Log:      klogr.New(),
+		recorder: record.NewFakeRecorder(32),
+	}
+	existing := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "m1", Namespace: ms.Namespace, Labels: ms.Spec.Selector.MatchLabels},
+		Spec:       clusterv1.MachineSpec{FailureDomain: pointer.StringPtr("fd1")},
+	}
+	g.Expect(r.Client.Create(context.Background(), existing)).To(Succeed())
+
+	_, err := r.syncReplicas(context.Background(), cluster, ms, []*clusterv1.Machine{existing})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	machineList := &clusterv1.MachineList{}
+	g.Expect(r.Client.List(context.Background(), machineList, client.InNamespace(ms.Namespace))).To(Succeed())
+	counts := map[string]int{}
+	for _, m := range machineList.Items {
+		g.Expect(m.Spec.FailureDomain).NotTo(BeNil())
+		counts[*m.Spec.FailureDomain]++
+	}
+	g.Expect(counts).To(Equal(map[string]int{"fd1": 2, "fd2": 2}))
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+const (
+	// preflightChecksRequeueAfter is the interval for re-running the preflight checks after they failed.
+	preflightChecksRequeueAfter = 30 * time.Second
+
+	// maxKubeletVersionSkew is the maximum number of minor versions the kubelets can be older than the control plane,
+	// according to the Kubernetes version skew policy.
+	maxKubeletVersionSkew = 2
+)
+
+// preflightCheckFailure documents the failure of a preflight check.
+type preflightCheckFailure struct {
+	reason   string
+	severity clusterv1.ConditionSeverity
+	message  string
+}
+
+// runPreflightChecks checks if the MachineSet can create new Machines, that is if the control plane is ready and
+// not rolling out machines, and if the Kubernetes version of the new Machines is within the version skew policy
+// with respect to the control plane; it returns nil if all the checks pass.
+// Nb. The checks are skipped for Clusters without a control plane object, because its state is not known.
+func (r *MachineSetReconciler) runPreflightChecks(ctx context.Context, cluster *clusterv1.Cluster, ms *clusterv1.MachineSet) (*preflightCheckFailure, error) {
+	if _, ok := ms.Annotations[clusterv1.MachineSetSkipPreflightChecksAnnotation]; ok {
+		return nil, nil
+	}
+	if cluster.Spec.ControlPlaneRef == nil {
+		return nil, nil
+	}
+
+	controlPlane, err := external.Get(ctx, r.Client, cluster.Spec.ControlPlaneRef, cluster.Namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the control plane for Cluster %s/%s", cluster.Namespace, cluster.Name)
+	}
+
+	if message := controlPlaneNotStableMessage(controlPlane); message != "" {
+		return &preflightCheckFailure{
+			reason:   clusterv1.ControlPlaneNotStableReason,
+			severity: clusterv1.ConditionSeverityInfo,
+			message:  message,
+		}, nil
+	}
+
+	controlPlaneVersion, found, err := unstructured.NestedString(controlPlane.Object, "spec", "version")
+	if err != nil || !found || ms.Spec.Template.Spec.Version == nil {
+		return nil, nil
+	}
+	if message := kubeletVersionSkewMessage(*ms.Spec.Template.Spec.Version, controlPlaneVersion); message != "" {
+		return &preflightCheckFailure{
+			reason:   clusterv1.KubernetesVersionSkewReason,
+			severity: clusterv1.ConditionSeverityWarning,
+			message:  message,
+		}, nil
+	}
+	return nil, nil
+}
+
+// controlPlaneNotStableMessage returns a message explaining why the control plane is not stable, if it isn't, using the
+// status fields defined by the control plane contract; the fields not implemented by the control plane are ignored.
+func controlPlaneNotStableMessage(controlPlane *unstructured.Unstructured) string {
+	kind := controlPlane.GetKind()
+	name := controlPlane.GetName()
+
+	if ready, _, _ := unstructured.NestedBool(controlPlane.Object, "status", "ready"); !ready {
+		return fmt.Sprintf("%s %s is not ready", kind, name)
+	}
+
+	if observedGeneration, found, _ := unstructured.NestedInt64(controlPlane.Object, "status", "observedGeneration"); found && observedGeneration < controlPlane.GetGeneration() {
+		return fmt.Sprintf("%s %s has changes not yet reconciled", kind, name)
+	}
+
+	replicas, replicasFound, _ := unstructured.NestedInt64(controlPlane.Object, "status", "replicas")
+	if desiredReplicas, found, _ := unstructured.NestedInt64(controlPlane.Object, "spec", "replicas"); found && replicasFound && replicas != desiredReplicas {
+		return fmt.Sprintf("%s %s is scaling from %d to %d replicas", kind, name, replicas, desiredReplicas)
+	}
+	if updatedReplicas, found, _ := unstructured.NestedInt64(controlPlane.Object, "status", "updatedReplicas"); found && replicasFound && updatedReplicas != replicas {
+		return fmt.Sprintf("%s %s is rolling out machines, %d of %d are up to date", kind, name, updatedReplicas, replicas)
+	}
+	return ""
+}
+
+// kubeletVersionSkewMessage returns a message explaining why the kubelet version is not within the version skew
+// policy with respect to the control plane version, if it isn't; the versions that can't be parsed are ignored.
+func kubeletVersionSkewMessage(kubeletVersion, controlPlaneVersion string) string {
+	kubelet, err := version.ParseSemantic(kubeletVersion)
+	if err != nil {
+		return ""
+	}
+	controlPlane, err := version.ParseSemantic(controlPlaneVersion)
+	if err != nil {
+		return ""
+	}
+
+	if controlPlane.LessThan(kubelet) {
+		return fmt.Sprintf("version %s is newer than the control plane version %s", kubeletVersion, controlPlaneVersion)
+	}
+	if kubelet.Major() != controlPlane.Major() || kubelet.Minor()+maxKubeletVersionSkew < controlPlane.Minor() {
+		return fmt.Sprintf("version %s is more than %d minor versions older than the control plane version %s", kubeletVersion, maxKubeletVersionSkew, controlPlaneVersion)
+	}
+	return ""
+}
+
+// setPreflightChecksCondition reports the result of the preflight checks in the MachineSet conditions; the condition
+// is added only when the checks fail, and then it is kept up to date.
+func setPreflightChecksCondition(ms *clusterv1.MachineSet, failure *preflightCheckFailure) {
+	if failure != nil {
+		conditions.MarkFalse(ms, clusterv1.PreflightChecksSucceededCondition, failure.reason, failure.severity, "Creation of new Machines paused: %s", failure.message)
+		return
+	}
+	if conditions.Has(ms, clusterv1.PreflightChecksSucceededCondition) {
+		conditions.MarkTrue(ms, clusterv1.PreflightChecksSucceededCondition)
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+func TestMachineSetReconciler_runPreflightChecks(t *testing.T) {
+	stableControlPlane := func() map[string]interface{} {
+		return map[string]interface{}{
+			"kind":       "ControlPlane",
+			"apiVersion": "controlplane.cluster.x-k8s.io/v1alpha3",
+			"metadata": map[string]interface{}{
+				"name":       "cp1",
+				"namespace":  "default",
+				"generation": int64(2),
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(3),
+				"version":  "v1.18.2",
+			},
+			"status": map[string]interface{}{
+				"ready":              true,
+				"observedGeneration": int64(2),
+				"replicas":           int64(3),
+				"updatedReplicas":    int64(3),
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		controlPlane    map[string]interface{}
+		version         *string
+		skipAnnotation  bool
+		noControlPlane  bool
+		wantReason      string
+		wantCheckFailed bool
+	}{
+		{
+			name:         "Passes if the control plane is stable and the version is within the skew policy",
+			controlPlane: stableControlPlane(),
+			version:      pointer.StringPtr("v1.17.3"),
+		},
+		{
+			name:           "Passes if the Cluster has no control plane object",
+			noControlPlane: true,
+			version:        pointer.StringPtr("v1.17.3"),
+		},
+		{
+			name: "Fails if the control plane is not ready",
+			controlPlane: func() map[string]interface{} {
+				cp := stableControlPlane()
+				_ = unstructured.SetNestedField(cp, false, "status", "ready")
+				return cp
+			}(),
+			wantCheckFailed: true,
+			wantReason:      clusterv1.ControlPlaneNotStableReason,
+		},
+		{
+			name: "Fails if the control plane is rolling out machines",
+			controlPlane: func() map[string]interface{} {
+				cp := stableControlPlane()
+				_ = unstructured.SetNestedField(cp, int64(1), "status", "updatedReplicas")
+				return cp
+			}(),
+			wantCheckFailed: true,
+			wantReason:      clusterv1.ControlPlaneNotStableReason,
+		},
+		{
+			name: "Fails if the control plane did not observe the latest changes",
+			controlPlane: func() map[string]interface{} {
+				cp := stableControlPlane()
+				_ = unstructured.SetNestedField(cp, int64(1), "status", "observedGeneration")
+				return cp
+			}(),
+			wantCheckFailed: true,
+			wantReason:      clusterv1.ControlPlaneNotStableReason,
+		},
+		{
+			name:            "Fails if the version is newer than the control plane",
+			controlPlane:    stableControlPlane(),
+			version:         pointer.StringPtr("v1.19.0"),
+			wantCheckFailed: true,
+			wantReason:      clusterv1.KubernetesVersionSkewReason,
+		},
+		{
+			name:            "Fails if the version is too old",
+			controlPlane:    stableControlPlane(),
+			version:         pointer.StringPtr("v1.15.0"),
+			wantCheckFailed: true,
+			wantReason:      clusterv1.KubernetesVersionSkewReason,
+		},
+		{
+			name:           "Passes if the checks are skipped",
+			controlPlane:   stableControlPlane(),
+			version:        pointer.StringPtr("v1.15.0"),
+			skipAnnotation: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+			cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster1"}}
+			c := fake.NewFakeClientWithScheme(scheme.Scheme, cluster)
+			if !tt.noControlPlane {
+				cluster.Spec.ControlPlaneRef = &corev1.ObjectReference{
+					APIVersion: "controlplane.cluster.x-k8s.io/v1alpha3",
+					Kind:       "ControlPlane",
+					Name:       "cp1",
+				}
+				c = fake.NewFakeClientWithScheme(scheme.Scheme, cluster, &unstructured.Unstructured{Object: tt.controlPlane})
+			}
+
+			ms := newMachineSet("ms1", "cluster1")
+			ms.Spec.Template.Spec.Version = tt.version
+			if tt.skipAnnotation {
+				ms.Annotations = map[string]string{clusterv1.MachineSetSkipPreflightChecksAnnotation: ""}
+			}
+
+			r := &MachineSetReconciler{
+				Client: c,
+				Log:    log.Log,
+			}
+			failure, err := r.runPreflightChecks(context.TODO(), cluster, ms)
+			g.Expect(err).NotTo(HaveOccurred())
+			if !tt.wantCheckFailed {
+				g.Expect(failure).To(BeNil())
+				return
+			}
+			g.Expect(failure).NotTo(BeNil())
+			g.Expect(failure.reason).To(Equal(tt.wantReason))
+		})
+	}
+}
+
+func TestSetPreflightChecksCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	ms := newMachineSet("ms1", "cluster1")
+
+	// The condition is not added if the checks pass.
+	setPreflightChecksCondition(ms, nil)
+	g.Expect(conditions.Has(ms, clusterv1.PreflightChecksSucceededCondition)).To(BeFalse())
+
+	setPreflightChecksCondition(ms, &preflightCheckFailure{
+		reason:   clusterv1.ControlPlaneNotStableReason,
+		severity: clusterv1.ConditionSeverityInfo,
+		message:  "ControlPlane cp1 is not ready",
+	})
+	g.Expect(conditions.IsFalse(ms, clusterv1.PreflightChecksSucceededCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(ms, clusterv1.PreflightChecksSucceededCondition)).To(Equal(clusterv1.ControlPlaneNotStableReason))
+
+	// Once added, the condition is kept up to date.
+	setPreflightChecksCondition(ms, nil)
+	g.Expect(conditions.IsTrue(ms, clusterv1.PreflightChecksSucceededCondition)).To(BeTrue())
+}
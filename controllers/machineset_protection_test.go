@@ -0,0 +1,127 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/annotations"
+)
+
+func TestMachineSetSyncReplicasProtectedMachines(t *testing.T) {
+	tests := []struct {
+		name      string
+		replicas  int32
+		protected []string
+		want      []string
+	}{
+		{
+			name:      "protected machines are not deleted when scaling down",
+			replicas:  1,
+			protected: []string{"m1"},
+			want:      []string{"m1"},
+		},
+		{
+			name:      "the machine set keeps more machines than the desired replicas if the remaining machines are protected",
+			replicas:  0,
+			protected: []string{"m1", "m3"},
+			want:      []string{"m1", "m3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ms := newMachineSet("ms1", "cluster1")
+			ms.Spec.Replicas = &tt.replicas
+			machines := []*clusterv1.Machine{}
+			objs := []runtime.Object{ms}
+			for _, name := range []string{"m1", "m2", "m3"} {
+				m := &clusterv1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: ms.Namespace,
+						Labels:    ms.Spec.Selector.MatchLabels,
+					},
+				}
+				machines = append(machines, m)
+				objs = append(objs, m)
+			}
+			for _, m := range machines {
+				for _, name := range tt.protected {
+					if m.Name == name {
+						m.Annotations = map[string]string{clusterv1.ProtectMachineAnnotation: ""}
+					}
+				}
+			}
+
+			r := &MachineSetReconciler{
+				Client:   fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+				Log:      klogr.New(),
+				recorder: record.NewFakeRecorder(32),
+			}
+
+			_, err := r.syncReplicas(context.Background(), &clusterv1.Cluster{}, ms, machines)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			machineList := &clusterv1.MachineList{}
+			g.Expect(r.Client.List(context.Background(), machineList, client.InNamespace(ms.Namespace))).To(Succeed())
+			names := []string{}
+			for _, m := range machineList.Items {
+				names = append(names, m.Name)
+			}
+			g.Expect(names).To(ConsistOf(tt.want))
+
+			// The deleted machines are not forced, so the deletion safety check still applies to them.
+			for _, m := range machines {
+				g.Expect(annotations.IsMachineDeletionForced(m)).To(BeFalse())
+			}
+		})
+	}
+}
+
+func TestExcludeProtectedMachines(t *testing.T) {
+	g := NewWithT(t)
+
+	deleting := metav1.Now()
+	unprotected := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "unprotected"}}
+	protected := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+		Name:        "protected",
+		Annotations: map[string]string{clusterv1.ProtectMachineAnnotation: ""},
+	}}
+	protectedDeleting := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+		Name:              "protected-deleting",
+		Annotations:       map[string]string{clusterv1.ProtectMachineAnnotation: ""},
+		DeletionTimestamp: &deleting,
+	}}
+
+	candidates, excluded := excludeProtectedMachines([]*clusterv1.Machine{unprotected, protected, protectedDeleting})
+	g.Expect(candidates).To(ConsistOf(unprotected, protectedDeleting))
+	g.Expect(excluded).To(ConsistOf(protected))
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// MachineRateLimit is the rate at which the Machines of a Cluster can be created or deleted by the MachineSet controller.
+type MachineRateLimit struct {
+	// PerMinute is the sustained number of operations allowed per minute; zero disables the rate limit.
+	PerMinute int
+
+	// Burst is the maximum number of operations allowed at once; if zero, it defaults to PerMinute.
+	Burst int
+}
+
+// clusterRateLimiter limits the rate of an operation on the Machines of each Cluster, using a token bucket per Cluster
+// shared by all the MachineSets of the Cluster, so that a bad rollout or a remediation storm does not exceed the
+// quotas of the infrastructure provider.
+// A nil *clusterRateLimiter is valid and always allows the operation.
+type clusterRateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	lock  sync.Mutex
+	items map[types.NamespacedName]*rate.Limiter
+}
+
+// newClusterRateLimiter returns a clusterRateLimiter for the given rate limit, or nil if the rate limit is disabled.
+func newClusterRateLimiter(l MachineRateLimit) *clusterRateLimiter {
+	if l.PerMinute <= 0 {
+		return nil
+	}
+	burst := l.Burst
+	if burst <= 0 {
+		burst = l.PerMinute
+	}
+	return &clusterRateLimiter{
+		limit: rate.Limit(float64(l.PerMinute) / time.Minute.Seconds()),
+		burst: burst,
+		items: map[types.NamespacedName]*rate.Limiter{},
+	}
+}
+
+// allow consumes a token for an operation on the Machines of the Cluster; if no token is available, it returns false
+// and the time to wait before retrying.
+func (l *clusterRateLimiter) allow(cluster types.NamespacedName, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	limiter, ok := l.items[cluster]
+	if !ok {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.items[cluster] = limiter
+	}
+
+	r := limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// forget removes the token bucket of the Cluster, e.g. when the Cluster is deleted, so the limiter does not keep a
+// bucket for every Cluster ever seen.
+func (l *clusterRateLimiter) forget(cluster types.NamespacedName) {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.items, cluster)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+func TestClusterRateLimiter(t *testing.T) {
+	cluster1 := types.NamespacedName{Namespace: "default", Name: "cluster1"}
+	cluster2 := types.NamespacedName{Namespace: "default", Name: "cluster2"}
+	now := time.Now()
+
+	t.Run("a disabled rate limit always allows", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := newClusterRateLimiter(MachineRateLimit{})
+		g.Expect(l).To(BeNil())
+		for i := 0; i < 100; i++ {
+			ok, _ := l.allow(cluster1, now)
+			g.Expect(ok).To(BeTrue())
+		}
+	})
+
+	t.Run("operations are limited per cluster", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := newClusterRateLimiter(MachineRateLimit{PerMinute: 6, Burst: 2})
+
+		// The burst is allowed at once.
+		for i := 0; i < 2; i++ {
+			ok, _ := l.allow(cluster1, now)
+			g.Expect(ok).To(BeTrue())
+		}
+		ok, delay := l.allow(cluster1, now)
+		g.Expect(ok).To(BeFalse())
+		g.Expect(delay).To(Equal(10 * time.Second))
+
+		// Other clusters are not affected.
+		ok, _ = l.allow(cluster2, now)
+		g.Expect(ok).To(BeTrue())
+
+		// A rejected operation does not consume a token.
+		ok, _ = l.allow(cluster1, now.Add(10*time.Second))
+		g.Expect(ok).To(BeTrue())
+		ok, _ = l.allow(cluster1, now.Add(10*time.Second))
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("the burst defaults to the per minute limit", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := newClusterRateLimiter(MachineRateLimit{PerMinute: 3})
+		for i := 0; i < 3; i++ {
+			ok, _ := l.allow(cluster1, now)
+			g.Expect(ok).To(BeTrue())
+		}
+		ok, _ := l.allow(cluster1, now)
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("the bucket of a deleted cluster is dropped", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := newClusterRateLimiter(MachineRateLimit{PerMinute: 1})
+		ok, _ := l.allow(cluster1, now)
+		g.Expect(ok).To(BeTrue())
+		ok, _ = l.allow(cluster2, now)
+		g.Expect(ok).To(BeTrue())
+
+		r := &MachineSetReconciler{deletionRateLimiter: l}
+		r.clusterDeleted(event.DeleteEvent{Meta: &metav1.ObjectMeta{Namespace: cluster1.Namespace, Name: cluster1.Name}}, nil)
+		g.Expect(l.items).To(HaveLen(1))
+		g.Expect(l.items).To(HaveKey(cluster2))
+
+		// A nil rate limiter ignores the deletions.
+		var disabled *clusterRateLimiter
+		disabled.forget(cluster1)
+	})
+}
+
+func TestMachineSetSyncReplicasRateLimited(t *testing.T) {
+	g := NewWithT(t)
+
+	ms := newMachineSet("ms1", "cluster1")
+	machines := []*clusterv1.Machine{}
+	objs := []runtime.Object{ms}
+	for _, name := range []string{"m1", "m2", "m3"} {
+		m := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ms.Namespace,
+				Labels:    ms.Spec.Selector.MatchLabels,
+			},
+		}
+		machines = append(machines, m)
+		objs = append(objs, m)
+	}
+
+	r := &MachineSetReconciler{
+		Client:              fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+		Log:                 klogr.New(),
+		recorder:            record.NewFakeRecorder(32),
+		deletionRateLimiter: newClusterRateLimiter(MachineRateLimit{PerMinute: 1}),
+	}
+
+	// Scaling down to zero deletes only one Machine, then requeues for when the next deletion is allowed.
+	result, err := r.syncReplicas(context.Background(), &clusterv1.Cluster{}, ms, machines)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(BeNumerically("~", time.Minute, time.Second))
+
+	machineList := &clusterv1.MachineList{}
+	g.Expect(r.Client.List(context.Background(), machineList, client.InNamespace(ms.Namespace))).To(Succeed())
+	g.Expect(machineList.Items).To(HaveLen(2))
+}
@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/go-logr/logr"
@@ -34,6 +35,7 @@ import (
 	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/integer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conversion"
 )
 
@@ -387,11 +389,18 @@ func getMachineSetFraction(ms clusterv1.MachineSet, d clusterv1.MachineDeploymen
 }
 
 // EqualMachineTemplate returns true if two given machineTemplateSpec are equal,
-// ignoring the diff in value of Labels["machine-template-hash"], and the version from external references.
+// ignoring the diff in value of Labels["machine-template-hash"], Annotations["machinedeployment.clusters.x-k8s.io/rollout-after"],
+// the propagated labels and annotations, and the version from external references.
 func EqualMachineTemplate(template1, template2 *clusterv1.MachineTemplateSpec) bool {
 	t1Copy := template1.DeepCopy()
 	t2Copy := template2.DeepCopy()
 
+	// Remove the propagated labels and annotations from the comparison, because they are updated in place.
+	t1Copy.Labels = util.RemovePropagatedMetadata(t1Copy.Labels)
+	t1Copy.Annotations = util.RemovePropagatedMetadata(t1Copy.Annotations)
+	t2Copy.Labels = util.RemovePropagatedMetadata(t2Copy.Labels)
+	t2Copy.Annotations = util.RemovePropagatedMetadata(t2Copy.Annotations)
+
 	// Remove `machine-template-hash` from the comparison:
 	// 1. The hash result would be different upon machineTemplateSpec API changes
 	//    (e.g. the addition of a new field will cause the hash code to change)
@@ -399,6 +408,10 @@ func EqualMachineTemplate(template1, template2 *clusterv1.MachineTemplateSpec) b
 	delete(t1Copy.Labels, DefaultMachineDeploymentUniqueLabelKey)
 	delete(t2Copy.Labels, DefaultMachineDeploymentUniqueLabelKey)
 
+	// Remove the rollout time from the comparison, because it is set only on the machine sets created by a rollout.
+	delete(t1Copy.Annotations, clusterv1.RolloutAfterAnnotation)
+	delete(t2Copy.Annotations, clusterv1.RolloutAfterAnnotation)
+
 	// Remove the version part from the references APIVersion field,
 	// for more details see issue #2183 and #2140.
 	t1Copy.Spec.InfrastructureRef.APIVersion = t1Copy.Spec.InfrastructureRef.GroupVersionKind().Group
@@ -413,11 +426,34 @@ func EqualMachineTemplate(template1, template2 *clusterv1.MachineTemplateSpec) b
 	return apiequality.Semantic.DeepEqual(t1Copy, t2Copy)
 }
 
-// FindNewMachineSet returns the new MS this given deployment targets (the one with the same machine template).
+// GetRolloutAfter returns the time after which the machines of the deployment created before it must be replaced,
+// that is the latest between Spec.RolloutAfter and the RestartedAtAnnotation; invalid annotation values are ignored.
+func GetRolloutAfter(deployment *clusterv1.MachineDeployment) *metav1.Time {
+	rolloutAfter := deployment.Spec.RolloutAfter
+	if value, ok := deployment.Annotations[clusterv1.RestartedAtAnnotation]; ok {
+		if restartedAt, err := time.Parse(time.RFC3339, value); err == nil && (rolloutAfter == nil || rolloutAfter.Time.Before(restartedAt)) {
+			rolloutAfter = &metav1.Time{Time: restartedAt}
+		}
+	}
+	return rolloutAfter
+}
+
+// NeedsRollout returns true if the rollout time of the deployment is passed and the machine set was created before it.
+func NeedsRollout(deployment *clusterv1.MachineDeployment, ms *clusterv1.MachineSet, now time.Time) bool {
+	rolloutAfter := GetRolloutAfter(deployment)
+	if rolloutAfter == nil || now.Before(rolloutAfter.Time) {
+		return false
+	}
+	return ms.CreationTimestamp.Time.Before(rolloutAfter.Time)
+}
+
+// FindNewMachineSet returns the new MS this given deployment targets (the one with the same machine template,
+// not created before the rollout time of the deployment, if passed).
 func FindNewMachineSet(deployment *clusterv1.MachineDeployment, msList []*clusterv1.MachineSet) *clusterv1.MachineSet {
 	sort.Sort(MachineSetsByCreationTimestamp(msList))
+	now := time.Now()
 	for i := range msList {
-		if EqualMachineTemplate(&msList[i].Spec.Template, &deployment.Spec.Template) {
+		if EqualMachineTemplate(&msList[i].Spec.Template, &deployment.Spec.Template) && !NeedsRollout(deployment, msList[i], now) {
 			// In rare cases, such as after cluster upgrades, Deployment may end up with
 			// having more than one new MachineSets that have the same template,
 			// see https://github.com/kubernetes/kubernetes/issues/40415
@@ -129,6 +129,12 @@ func TestEqualMachineTemplate(t *testing.T) {
 			Latter:   generateMachineTemplateSpec("foo", map[string]string{}, map[string]string{DefaultMachineDeploymentUniqueLabelKey: "value-2", "something": "else"}),
 			Expected: true,
 		},
+		{
+			Name:     "Same spec, only the rollout-after annotation is different",
+			Former:   generateMachineTemplateSpec("foo", map[string]string{}, map[string]string{"something": "else"}),
+			Latter:   generateMachineTemplateSpec("foo", map[string]string{clusterv1.RolloutAfterAnnotation: "2020-10-01T00:00:00Z"}, map[string]string{"something": "else"}),
+			Expected: true,
+		},
 		{
 			Name:     "Same spec, the label is different, the former doesn't have machine-template-hash label, same number of labels",
 			Former:   generateMachineTemplateSpec("foo", map[string]string{}, map[string]string{"something": "else"}),
@@ -147,6 +153,12 @@ func TestEqualMachineTemplate(t *testing.T) {
 			Latter:   generateMachineTemplateSpec("foo", map[string]string{}, map[string]string{DefaultMachineDeploymentUniqueLabelKey: "value-1", "something": "else"}),
 			Expected: false,
 		},
+		{
+			Name:     "Same spec, only propagated labels and annotations are different",
+			Former:   generateMachineTemplateSpec("foo", map[string]string{"node.cluster.x-k8s.io/a": "1"}, map[string]string{DefaultMachineDeploymentUniqueLabelKey: "value-1", "node.cluster.x-k8s.io/role": "worker"}),
+			Latter:   generateMachineTemplateSpec("foo", map[string]string{}, map[string]string{DefaultMachineDeploymentUniqueLabelKey: "value-1", "node.cluster.x-k8s.io/role": "ingress"}),
+			Expected: true,
+		},
 		{
 			Name:     "Different spec, same labels",
 			Former:   generateMachineTemplateSpec("foo", map[string]string{"former": "value"}, map[string]string{DefaultMachineDeploymentUniqueLabelKey: "value-1", "something": "else"}),
@@ -286,12 +298,50 @@ func TestFindNewMachineSet(t *testing.T) {
 	oldMS := generateMS(oldDeployment)
 	oldMS.Status.FullyLabeledReplicas = *(oldMS.Spec.Replicas)
 
+	before := metav1.Time{Time: now.Add(-time.Hour)}
+	msBeforeRollout := generateMS(deployment)
+	msBeforeRollout.Labels[DefaultMachineDeploymentUniqueLabelKey] = "hash-before-rollout"
+	msBeforeRollout.CreationTimestamp = before
+
+	rolloutDeployment := generateDeployment("nginx")
+	rolloutDeployment.Spec.RolloutAfter = &metav1.Time{Time: now.Add(-time.Minute)}
+
+	futureRolloutDeployment := generateDeployment("nginx")
+	futureRolloutDeployment.Spec.RolloutAfter = &metav1.Time{Time: now.Add(time.Hour)}
+
+	restartedDeployment := generateDeployment("nginx")
+	restartedDeployment.Annotations = map[string]string{clusterv1.RestartedAtAnnotation: now.Add(-time.Minute).Format(time.RFC3339)}
+
 	tests := []struct {
 		Name       string
 		deployment clusterv1.MachineDeployment
 		msList     []*clusterv1.MachineSet
 		expected   *clusterv1.MachineSet
 	}{
+		{
+			Name:       "Get nil new MachineSet if the MachineSet was created before the rollout time",
+			deployment: rolloutDeployment,
+			msList:     []*clusterv1.MachineSet{&msBeforeRollout, &oldMS},
+			expected:   nil,
+		},
+		{
+			Name:       "Get the new MachineSet created after the rollout time",
+			deployment: rolloutDeployment,
+			msList:     []*clusterv1.MachineSet{&msBeforeRollout, &newMS},
+			expected:   &newMS,
+		},
+		{
+			Name:       "Get the new MachineSet if the rollout time is in the future",
+			deployment: futureRolloutDeployment,
+			msList:     []*clusterv1.MachineSet{&msBeforeRollout, &oldMS},
+			expected:   &msBeforeRollout,
+		},
+		{
+			Name:       "Get nil new MachineSet if the MachineSet was created before the restarted-at annotation",
+			deployment: restartedDeployment,
+			msList:     []*clusterv1.MachineSet{&msBeforeRollout, &oldMS},
+			expected:   nil,
+		},
 		{
 			Name:       "Get new MachineSet with the same template as Deployment spec but different machine-template-hash value",
 			deployment: deployment,
@@ -322,6 +372,61 @@ func TestFindNewMachineSet(t *testing.T) {
 	}
 }
 
+func TestGetRolloutAfter(t *testing.T) {
+	earlier := metav1.Date(2020, time.October, 1, 0, 0, 0, 0, time.UTC)
+	later := metav1.Date(2020, time.October, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		rolloutAfter *metav1.Time
+		annotations  map[string]string
+		expected     *metav1.Time
+	}{
+		{
+			name:     "no rollout time",
+			expected: nil,
+		},
+		{
+			name:         "rollout time from the spec",
+			rolloutAfter: &earlier,
+			expected:     &earlier,
+		},
+		{
+			name:        "rollout time from the annotation",
+			annotations: map[string]string{clusterv1.RestartedAtAnnotation: later.Format(time.RFC3339)},
+			expected:    &later,
+		},
+		{
+			name:         "latest between the spec and the annotation",
+			rolloutAfter: &later,
+			annotations:  map[string]string{clusterv1.RestartedAtAnnotation: earlier.Format(time.RFC3339)},
+			expected:     &later,
+		},
+		{
+			name:         "invalid annotation is ignored",
+			rolloutAfter: &earlier,
+			annotations:  map[string]string{clusterv1.RestartedAtAnnotation: "yesterday"},
+			expected:     &earlier,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			deployment := generateDeployment("nginx")
+			deployment.Annotations = tt.annotations
+			deployment.Spec.RolloutAfter = tt.rolloutAfter
+
+			got := GetRolloutAfter(&deployment)
+			if tt.expected == nil {
+				g.Expect(got).To(BeNil())
+				return
+			}
+			g.Expect(got.Time.Equal(tt.expected.Time)).To(BeTrue())
+		})
+	}
+}
+
 func TestFindOldMachineSets(t *testing.T) {
 	now := metav1.Now()
 	later := metav1.Time{Time: now.Add(time.Minute)}
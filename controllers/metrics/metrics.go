@@ -93,6 +93,50 @@ var (
 		},
 		[]string{"machine", "namespace", "cluster"},
 	)
+
+	// MachineOrphanedObjects is a metric that is set to the number of
+	// infrastructure and bootstrap objects of the cluster whose owning machine
+	// no longer exists, found during the last check.
+	MachineOrphanedObjects = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "capi_machine_orphaned_objects",
+			Help: "Number of infrastructure and bootstrap objects whose Machine no longer exists.",
+		},
+		[]string{"cluster", "namespace"},
+	)
+
+	// MachineHealthCheckMachinesMarkedUnhealthy is a metric that counts the
+	// machines marked for remediation by a machine health check, by the reason
+	// of the failed health check.
+	MachineHealthCheckMachinesMarkedUnhealthy = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "capi_machinehealthcheck_machines_marked_unhealthy_total",
+			Help: "Number of machines marked for remediation by the MachineHealthCheck, by reason of the failed health check.",
+		},
+		[]string{"machinehealthcheck", "namespace", "cluster", "reason"},
+	)
+
+	// MachineHealthCheckRemediationsSkipped is a metric that counts the
+	// remediations of unhealthy machines skipped by a machine health check,
+	// by the reason they have been skipped.
+	MachineHealthCheckRemediationsSkipped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "capi_machinehealthcheck_remediations_skipped_total",
+			Help: "Number of remediations of unhealthy machines skipped by the MachineHealthCheck, by reason.",
+		},
+		[]string{"machinehealthcheck", "namespace", "cluster", "reason"},
+	)
+
+	// MachineHealthCheckRemediationRestricted is a metric that is set to 1
+	// if a machine health check short-circuits remediation for the given
+	// reason and 0 if it does not.
+	MachineHealthCheckRemediationRestricted = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "capi_machinehealthcheck_remediation_restricted",
+			Help: "MachineHealthCheck remediation is short-circuited for the reason if set to 1 and not if 0.",
+		},
+		[]string{"machinehealthcheck", "namespace", "reason"},
+	)
 )
 
 func init() {
@@ -104,5 +148,9 @@ func init() {
 		MachineBootstrapReady,
 		MachineInfrastructureReady,
 		MachineNodeReady,
+		MachineOrphanedObjects,
+		MachineHealthCheckMachinesMarkedUnhealthy,
+		MachineHealthCheckRemediationsSkipped,
+		MachineHealthCheckRemediationRestricted,
 	)
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package remote
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"reflect"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -34,6 +36,7 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -57,6 +60,9 @@ const (
 type clusterCache struct {
 	cache.Cache
 
+	// config is the REST client config the cache was created with.
+	config *rest.Config
+
 	lock    sync.Mutex
 	stopped bool
 	stop    chan struct{}
@@ -342,8 +348,9 @@ func (m *ClusterCacheTracker) newClusterCache(ctx context.Context, cluster clien
 	stop := make(chan struct{})
 
 	cc := &clusterCache{
-		Cache: remoteCache,
-		stop:  stop,
+		Cache:  remoteCache,
+		config: config,
+		stop:   stop,
 	}
 	m.clusterCaches[cluster] = cc
 
@@ -366,6 +373,16 @@ func (m *ClusterCacheTracker) deleteClusterCache(cluster client.ObjectKey) {
 	delete(m.clusterCaches, cluster)
 }
 
+// stopClusterCache stops the clusterCache for cluster and deletes it together with the delegating client and
+// the watches for cluster, so they are created again when required.
+func (m *ClusterCacheTracker) stopClusterCache(cluster client.ObjectKey, c *clusterCache) {
+	c.Stop()
+
+	m.deleteClusterCache(cluster)
+	m.deleteDelegatingClient(cluster)
+	m.deleteWatchesForCluster(cluster)
+}
+
 // healthCheckInput provides the input for the healthCheckCluster method
 type healthCheckInput struct {
 	stop               <-chan struct{}
@@ -461,6 +478,21 @@ func (m *ClusterCacheTracker) healthCheckCluster(in *healthCheckInput) {
 	}
 }
 
+// ProbeCluster requests the root path of the API server of the given cluster, and returns the time the API server
+// took to answer or the error that occurred during the request.
+func (m *ClusterCacheTracker) ProbeCluster(ctx context.Context, cluster client.ObjectKey) (time.Duration, error) {
+	config, err := RESTConfig(ctx, m.client, cluster)
+	if err != nil {
+		return 0, errors.Wrap(err, "error fetching REST client config for remote cluster")
+	}
+
+	start := time.Now()
+	if err := healthCheckPath(config, healthCheckRequestTimeout, "/"); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
 // healthCheckPath attempts to request a given absolute path from the API server
 // defined in the rest.Config and returns any errors that occurred during the request.
 func healthCheckPath(sourceCfg *rest.Config, requestTimeout time.Duration, path string) error {
@@ -483,7 +515,8 @@ func healthCheckPath(sourceCfg *rest.Config, requestTimeout time.Duration, path
 }
 
 // ClusterCacheReconciler is responsible for stopping remote cluster caches when
-// the cluster for the remote cache is being deleted.
+// the cluster for the remote cache is being deleted, or when the server or the certificate authority
+// in the kubeconfig of the cluster change, e.g. because the control plane endpoint was migrated or the CA was rotated.
 type ClusterCacheReconciler struct {
 	Log     logr.Logger
 	Client  client.Client
@@ -493,6 +526,10 @@ type ClusterCacheReconciler struct {
 func (r *ClusterCacheReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	_, err := ctrl.NewControllerManagedBy(mgr).
 		For(&clusterv1.Cluster{}).
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(kubeconfigToCluster)},
+		).
 		WithOptions(options).
 		Build(r)
 
@@ -502,8 +539,17 @@ func (r *ClusterCacheReconciler) SetupWithManager(mgr ctrl.Manager, options cont
 	return nil
 }
 
+// kubeconfigToCluster maps a kubeconfig Secret to the reconcile request for its Cluster.
+func kubeconfigToCluster(o handler.MapObject) []reconcile.Request {
+	clusterName, purpose, err := secret.ParseSecretName(o.Meta.GetName())
+	if err != nil || purpose != secret.Kubeconfig {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: o.Meta.GetNamespace(), Name: clusterName}}}
+}
+
 // Reconcile reconciles Clusters and removes ClusterCaches for any Cluster that cannot be retrieved from the
-// management cluster.
+// management cluster, or whose kubeconfig changed server or certificate authority.
 func (r *ClusterCacheReconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
 	ctx := context.Background()
 
@@ -515,7 +561,7 @@ func (r *ClusterCacheReconciler) Reconcile(req reconcile.Request) (reconcile.Res
 	err := r.Client.Get(ctx, req.NamespacedName, &cluster)
 	if err == nil {
 		log.V(4).Info("Cluster still exists")
-		return reconcile.Result{}, nil
+		return r.reconcileKubeconfig(ctx, log, req.NamespacedName)
 	} else if !kerrors.IsNotFound(err) {
 		log.Error(err, "Error retrieving cluster")
 		return reconcile.Result{}, err
@@ -530,13 +576,32 @@ func (r *ClusterCacheReconciler) Reconcile(req reconcile.Request) (reconcile.Res
 	}
 
 	log.V(4).Info("Stopping cluster cache")
-	c.Stop()
+	r.Tracker.stopClusterCache(req.NamespacedName, c)
+
+	return reconcile.Result{}, nil
+}
 
-	r.Tracker.deleteClusterCache(req.NamespacedName)
-	r.Tracker.deleteDelegatingClient(req.NamespacedName)
+// reconcileKubeconfig stops the ClusterCache of an existing Cluster if the server or the certificate authority in
+// the kubeconfig of the Cluster changed since the ClusterCache was created, so the controllers don't keep using
+// stale credentials; the ClusterCache is created again with the new kubeconfig when the controllers require it.
+func (r *ClusterCacheReconciler) reconcileKubeconfig(ctx context.Context, log logr.Logger, cluster client.ObjectKey) (reconcile.Result, error) {
+	c := r.Tracker.getClusterCache(cluster)
+	if c == nil {
+		log.V(4).Info("No current cluster cache exists - nothing to do")
+		return reconcile.Result{}, nil
+	}
+
+	config, err := RESTConfig(ctx, r.Client, cluster)
+	if err != nil {
+		log.Error(err, "Error retrieving kubeconfig")
+		return reconcile.Result{}, err
+	}
+	if config.Host == c.config.Host && bytes.Equal(config.CAData, c.config.CAData) {
+		return reconcile.Result{}, nil
+	}
 
-	log.V(4).Info("Deleting watches for cluster cache")
-	r.Tracker.deleteWatchesForCluster(req.NamespacedName)
+	log.Info("Kubeconfig changed, stopping cluster cache")
+	r.Tracker.stopClusterCache(cluster, c)
 
 	return reconcile.Result{}, nil
 }
@@ -26,9 +26,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
+	"sigs.k8s.io/cluster-api/util/secret"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -323,5 +325,40 @@ var _ = Describe("ClusterCache Reconciler suite", func() {
 				},
 			}),
 		)
+
+		It("should stop the cache of a cluster when the server in its kubeconfig changes", func() {
+			By("Migrating the control plane endpoint of cluster-1")
+			secretKey := client.ObjectKey{Namespace: testNamespace.GetName(), Name: "cluster-1-kubeconfig"}
+			configSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, secretKey, configSecret)).To(Succeed())
+			config, err := clientcmd.Load(configSecret.Data[secret.KubeconfigDataName])
+			Expect(err).NotTo(HaveOccurred())
+			config.Clusters["cluster-1"].Server = "https://cluster-1.example.com:6443"
+			configSecret.Data[secret.KubeconfigDataName], err = clientcmd.Write(*config)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Update(ctx, configSecret)).To(Succeed())
+
+			By("Checking cluster-1's cache is stopped and removed")
+			Eventually(func() bool {
+				clusterCache1.lock.Lock()
+				defer clusterCache1.lock.Unlock()
+				return clusterCache1.stopped
+			}, timeout).Should(BeTrue())
+			Eventually(func() map[client.ObjectKey]*clusterCache {
+				cct.clusterCachesLock.RLock()
+				defer cct.clusterCachesLock.RUnlock()
+				return cct.clusterCaches
+			}, timeout).ShouldNot(HaveKey(clusterRequest1.NamespacedName))
+
+			By("Checking the caches of the other clusters are still running")
+			for _, cc := range []*clusterCache{clusterCache2, clusterCache3} {
+				cc := cc
+				Consistently(func() bool {
+					cc.lock.Lock()
+					defer cc.lock.Unlock()
+					return cc.stopped
+				}).Should(BeFalse())
+			}
+		})
 	})
 })
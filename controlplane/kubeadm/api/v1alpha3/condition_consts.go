@@ -66,3 +66,13 @@ const (
 	// ScalingDownReason (Severity=Info) documents a KubeadmControlPlane that is decreasing the number of replicas.
 	ScalingDownReason = "ScalingDown"
 )
+
+const (
+	// EtcdSnapshotSucceededCondition documents the result of the last periodic etcd snapshot
+	// of a KubeadmControlPlane with etcd snapshots configured.
+	EtcdSnapshotSucceededCondition clusterv1.ConditionType = "EtcdSnapshotSucceeded"
+
+	// EtcdSnapshotFailedReason (Severity=Warning) documents a KubeadmControlPlane whose last etcd snapshot
+	// could not be taken or uploaded to the store.
+	EtcdSnapshotFailedReason = "EtcdSnapshotFailed"
+)
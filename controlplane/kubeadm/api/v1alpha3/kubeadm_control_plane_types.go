@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha3
 
 import (
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
@@ -40,6 +42,24 @@ const (
 	// KubeadmClusterConfigurationAnnotation is a machine annotation that stores the json-marshalled string of KCP ClusterConfiguration.
 	// This annotation is used to detect any changes in ClusterConfiguration and trigger machine rollout in KCP.
 	KubeadmClusterConfigurationAnnotation = "controlplane.cluster.x-k8s.io/kubeadm-cluster-configuration"
+
+	// DefaultEtcdSnapshotStoreRegion is the default region of the etcd snapshot stores.
+	DefaultEtcdSnapshotStoreRegion = "us-east-1"
+
+	// MinEtcdSnapshotInterval is the minimum interval between etcd snapshots.
+	MinEtcdSnapshotInterval = 5 * time.Minute
+
+	// DefaultEtcdSnapshotUploadImage is the default image uploading the etcd snapshots to the store.
+	DefaultEtcdSnapshotUploadImage = "curlimages/curl:7.72.0"
+
+	// DefaultKubeVIPImage is the default image of the kube-vip static pods.
+	DefaultKubeVIPImage = "ghcr.io/kube-vip/kube-vip:v0.3.5"
+
+	// DefaultKubeVIPInterface is the default network interface kube-vip announces the virtual IP on.
+	DefaultKubeVIPInterface = "eth0"
+
+	// KubeVIPManifestPath is the path of the kube-vip static pod manifest generated on the control plane machines.
+	KubeVIPManifestPath = "/etc/kubernetes/manifests/kube-vip.yaml"
 )
 
 // KubeadmControlPlaneSpec defines the desired state of KubeadmControlPlane.
@@ -57,6 +77,13 @@ type KubeadmControlPlaneSpec struct {
 	// offered by an infrastructure provider.
 	InfrastructureTemplate corev1.ObjectReference `json:"infrastructureTemplate"`
 
+	// FailureDomainInfrastructureTemplates overrides the InfrastructureTemplate for the machines created in
+	// the given failure domains, e.g. for using zone specific subnets or instance types. Machines created in
+	// other failure domains, or without a failure domain, use the InfrastructureTemplate.
+	// Changing a template rolls out only the machines in its failure domain.
+	// +optional
+	FailureDomainInfrastructureTemplates []FailureDomainInfrastructureTemplate `json:"failureDomainInfrastructureTemplates,omitempty"`
+
 	// KubeadmConfigSpec is a KubeadmConfigSpec
 	// to use for initializing and joining machines to the control plane.
 	KubeadmConfigSpec cabpkv1.KubeadmConfigSpec `json:"kubeadmConfigSpec"`
@@ -66,6 +93,137 @@ type KubeadmControlPlaneSpec struct {
 	// KubeadmControlPlane
 	// +optional
 	UpgradeAfter *metav1.Time `json:"upgradeAfter,omitempty"`
+
+	// EtcdSnapshots configures periodic snapshots of the etcd database of the control plane,
+	// uploaded to an S3 compatible object store. Snapshots are not supported when using an external etcd.
+	// +optional
+	EtcdSnapshots *EtcdSnapshots `json:"etcdSnapshots,omitempty"`
+
+	// EtcdRestore configures the first control plane machine to restore the etcd database from a snapshot
+	// before running kubeadm init, e.g. for recovering a workload cluster after a disaster.
+	// It is used only when initializing the control plane, and it can't be changed afterwards.
+	// +optional
+	EtcdRestore *EtcdRestore `json:"etcdRestore,omitempty"`
+
+	// ControlPlaneEndpoint defines the implementation of the endpoint of the control plane, i.e. the host and port
+	// defined in the Cluster's spec.controlPlaneEndpoint. If empty, the endpoint is expected to be provided by the
+	// infrastructure provider. It can't be changed afterwards.
+	// +optional
+	ControlPlaneEndpoint *ControlPlaneEndpointSpec `json:"controlPlaneEndpoint,omitempty"`
+}
+
+// FailureDomainInfrastructureTemplate defines the infrastructure template of the machines in a failure domain.
+type FailureDomainInfrastructureTemplate struct {
+	// FailureDomain is the name of the failure domain, as defined in the Cluster's status.failureDomains.
+	FailureDomain string `json:"failureDomain"`
+
+	// InfrastructureTemplate is a reference to a custom resource offered by an infrastructure provider,
+	// used instead of spec.infrastructureTemplate for the machines in the failure domain.
+	InfrastructureTemplate corev1.ObjectReference `json:"infrastructureTemplate"`
+}
+
+// InfrastructureTemplateForFailureDomain returns the infrastructure template of the machines in the given
+// failure domain, falling back to the InfrastructureTemplate if the failure domain has no specific template.
+func (s *KubeadmControlPlaneSpec) InfrastructureTemplateForFailureDomain(failureDomain *string) *corev1.ObjectReference {
+	if failureDomain != nil {
+		for i := range s.FailureDomainInfrastructureTemplates {
+			if s.FailureDomainInfrastructureTemplates[i].FailureDomain == *failureDomain {
+				return &s.FailureDomainInfrastructureTemplates[i].InfrastructureTemplate
+			}
+		}
+	}
+	return &s.InfrastructureTemplate
+}
+
+// ControlPlaneEndpointType defines the implementation of the endpoint of the control plane.
+type ControlPlaneEndpointType string
+
+const (
+	// KubeVIPControlPlaneEndpoint is a virtual IP announced by kube-vip static pods running on the control plane machines.
+	KubeVIPControlPlaneEndpoint = ControlPlaneEndpointType("kube-vip")
+
+	// ExternalControlPlaneEndpoint is a load balancer outside of the control plane machines, e.g. an HAProxy instance
+	// or a cloud load balancer, managed by the infrastructure provider or by the user; nothing is installed on the
+	// control plane machines.
+	ExternalControlPlaneEndpoint = ControlPlaneEndpointType("external")
+)
+
+// ControlPlaneEndpointSpec defines the implementation of the endpoint of the control plane.
+type ControlPlaneEndpointSpec struct {
+	// Type of the control plane endpoint, one of kube-vip or external.
+	// +kubebuilder:validation:Enum=kube-vip;external
+	Type ControlPlaneEndpointType `json:"type"`
+
+	// KubeVIP configures the kube-vip static pods; it can be used only with the kube-vip type.
+	// +optional
+	KubeVIP *KubeVIPSpec `json:"kubeVIP,omitempty"`
+}
+
+// KubeVIPSpec defines the kube-vip static pods announcing the endpoint of the control plane.
+// The virtual IP is the host of the Cluster's spec.controlPlaneEndpoint, which must be an IP address.
+type KubeVIPSpec struct {
+	// Image of kube-vip. Defaults to ghcr.io/kube-vip/kube-vip:v0.3.5.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Interface is the network interface the virtual IP is announced on. Defaults to eth0.
+	// +optional
+	Interface string `json:"interface,omitempty"`
+}
+
+// EtcdSnapshots defines the periodic snapshots of the etcd database of the control plane.
+type EtcdSnapshots struct {
+	// Interval between snapshots, e.g. 6h.
+	Interval metav1.Duration `json:"interval"`
+
+	// Store where the snapshots are uploaded; snapshots are stored with the
+	// <prefix>/etcd-snapshot-<timestamp>.db key.
+	Store EtcdSnapshotStore `json:"store"`
+
+	// UploadImage is the image uploading the snapshots from the control plane nodes to the store, which must
+	// provide curl. Defaults to curlimages/curl:7.72.0.
+	// +optional
+	UploadImage string `json:"uploadImage,omitempty"`
+}
+
+// EtcdSnapshotStore defines an S3 compatible object store for etcd snapshots.
+type EtcdSnapshotStore struct {
+	// Endpoint of the S3 compatible object store, e.g. https://minio.example.com; path style URLs are used.
+	// If empty, the AWS S3 endpoint for the region is used, with virtual-hosted style URLs, or path style URLs
+	// for bucket names with dots.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region of the object store. Defaults to us-east-1.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// Bucket where the snapshots are stored.
+	Bucket string `json:"bucket"`
+
+	// Prefix of the keys of the snapshots. Defaults to <namespace>/<name> of the KubeadmControlPlane.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// CredentialsSecret is a reference to a Secret in the namespace of the KubeadmControlPlane with the
+	// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and, optionally, AWS_SESSION_TOKEN keys.
+	// If empty, requests are sent anonymously.
+	// +optional
+	CredentialsSecret *corev1.LocalObjectReference `json:"credentialsSecret,omitempty"`
+}
+
+// EtcdRestore defines the snapshot the etcd database of a new control plane is restored from.
+type EtcdRestore struct {
+	// Store where the snapshot is read from.
+	Store EtcdSnapshotStore `json:"store"`
+
+	// Key of the snapshot in the store, e.g. the key reported in status.lastEtcdSnapshot
+	// of the KubeadmControlPlane the snapshot was taken from.
+	Key string `json:"key"`
+
+	// Image is the etcd image used for restoring the snapshot with etcdctl, e.g. k8s.gcr.io/etcd:3.4.3-0;
+	// it should match the etcd version of the control plane.
+	Image string `json:"image"`
 }
 
 // KubeadmControlPlaneStatus defines the observed state of KubeadmControlPlane.
@@ -128,6 +286,23 @@ type KubeadmControlPlaneStatus struct {
 	// Conditions defines current service state of the KubeadmControlPlane.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// LastEtcdSnapshot reports the last etcd snapshot successfully uploaded to the store.
+	// +optional
+	LastEtcdSnapshot *EtcdSnapshotStatus `json:"lastEtcdSnapshot,omitempty"`
+}
+
+// EtcdSnapshotStatus describes an etcd snapshot uploaded to the store.
+type EtcdSnapshotStatus struct {
+	// Time the snapshot was taken.
+	Time metav1.Time `json:"time"`
+
+	// Key of the snapshot in the store.
+	Key string `json:"key"`
+
+	// Size of the snapshot in bytes.
+	// +optional
+	Size int64 `json:"size,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -141,6 +316,8 @@ type KubeadmControlPlaneStatus struct {
 // +kubebuilder:printcolumn:name="Ready Replicas",type=integer,JSONPath=".status.readyReplicas",description="Total number of fully running and ready control plane machines"
 // +kubebuilder:printcolumn:name="Updated Replicas",type=integer,JSONPath=".status.updatedReplicas",description="Total number of non-terminated machines targeted by this control plane that have the desired template spec"
 // +kubebuilder:printcolumn:name="Unavailable Replicas",type=integer,JSONPath=".status.unavailableReplicas",description="Total number of unavailable machines targeted by this control plane"
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=".spec.version",description="Kubernetes version associated with this control plane"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of KubeadmControlPlane"
 
 // KubeadmControlPlane is the Schema for the KubeadmControlPlane API.
 type KubeadmControlPlane struct {
@@ -19,6 +19,7 @@ package v1alpha3
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 
@@ -59,10 +60,52 @@ func (in *KubeadmControlPlane) Default() {
 	if in.Spec.InfrastructureTemplate.Namespace == "" {
 		in.Spec.InfrastructureTemplate.Namespace = in.Namespace
 	}
+	for i := range in.Spec.FailureDomainInfrastructureTemplates {
+		if in.Spec.FailureDomainInfrastructureTemplates[i].InfrastructureTemplate.Namespace == "" {
+			in.Spec.FailureDomainInfrastructureTemplates[i].InfrastructureTemplate.Namespace = in.Namespace
+		}
+	}
 
 	if !strings.HasPrefix(in.Spec.Version, "v") {
 		in.Spec.Version = "v" + in.Spec.Version
 	}
+
+	// Renders the DNS domain and the image overrides into ClusterConfiguration, which is what the controller reads,
+	// e.g. for upgrading CoreDNS or etcd.
+	in.Spec.KubeadmConfigSpec.ApplyClusterConfigurationOverrides()
+
+	if in.Spec.EtcdSnapshots != nil {
+		in.Spec.EtcdSnapshots.Store.Default()
+		if in.Spec.EtcdSnapshots.Store.Prefix == "" {
+			in.Spec.EtcdSnapshots.Store.Prefix = in.Namespace + "/" + in.Name
+		}
+		if in.Spec.EtcdSnapshots.UploadImage == "" {
+			in.Spec.EtcdSnapshots.UploadImage = DefaultEtcdSnapshotUploadImage
+		}
+	}
+
+	if in.Spec.EtcdRestore != nil {
+		in.Spec.EtcdRestore.Store.Default()
+	}
+
+	if endpoint := in.Spec.ControlPlaneEndpoint; endpoint != nil && endpoint.Type == KubeVIPControlPlaneEndpoint {
+		if endpoint.KubeVIP == nil {
+			endpoint.KubeVIP = &KubeVIPSpec{}
+		}
+		if endpoint.KubeVIP.Image == "" {
+			endpoint.KubeVIP.Image = DefaultKubeVIPImage
+		}
+		if endpoint.KubeVIP.Interface == "" {
+			endpoint.KubeVIP.Interface = DefaultKubeVIPInterface
+		}
+	}
+}
+
+// Default sets the default values of an EtcdSnapshotStore.
+func (in *EtcdSnapshotStore) Default() {
+	if in.Region == "" {
+		in.Region = DefaultEtcdSnapshotStoreRegion
+	}
 }
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
@@ -104,10 +147,20 @@ func (in *KubeadmControlPlane) ValidateUpdate(old runtime.Object) error {
 		{spec, kubeadmConfigSpec, preKubeadmCommands},
 		{spec, kubeadmConfigSpec, postKubeadmCommands},
 		{spec, kubeadmConfigSpec, files},
+		{spec, kubeadmConfigSpec, "kubelet", "*"},
+		{spec, kubeadmConfigSpec, "sysctls", "*"},
+		{spec, kubeadmConfigSpec, "kernelModules"},
+		{spec, kubeadmConfigSpec, "images"},
+		{spec, kubeadmConfigSpec, "images", "*"},
+		{spec, kubeadmConfigSpec, "auditConfig"},
+		{spec, kubeadmConfigSpec, "auditConfig", "*"},
 		{spec, "infrastructureTemplate", "name"},
+		{spec, "failureDomainInfrastructureTemplates"},
 		{spec, "replicas"},
 		{spec, "version"},
 		{spec, "upgradeAfter"},
+		{spec, "etcdSnapshots"},
+		{spec, "etcdSnapshots", "*"},
 	}
 
 	allErrs := in.validateCommon()
@@ -260,11 +313,105 @@ func (in *KubeadmControlPlane) validateCommon() (allErrs field.ErrorList) {
 		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "version"), in.Spec.Version, "must be a valid semantic version"))
 	}
 
+	allErrs = append(allErrs, in.Spec.KubeadmConfigSpec.ValidateClusterConfigurationOverrides(field.NewPath(spec, kubeadmConfigSpec))...)
+	allErrs = append(allErrs, in.Spec.KubeadmConfigSpec.ValidateCertificateRefs(field.NewPath(spec, kubeadmConfigSpec))...)
 	allErrs = append(allErrs, in.validateCoreDNSImage()...)
+	allErrs = append(allErrs, in.validateEtcdSnapshots(externalEtcd)...)
+	allErrs = append(allErrs, in.validateControlPlaneEndpoint()...)
+	allErrs = append(allErrs, in.validateFailureDomainInfrastructureTemplates()...)
 
 	return allErrs
 }
 
+func (in *KubeadmControlPlane) validateEtcdSnapshots(externalEtcd bool) (allErrs field.ErrorList) {
+	if snapshots := in.Spec.EtcdSnapshots; snapshots != nil {
+		path := field.NewPath("spec", "etcdSnapshots")
+		if externalEtcd {
+			allErrs = append(allErrs, field.Forbidden(path, "cannot be used with external etcd"))
+		}
+		if snapshots.Interval.Duration < MinEtcdSnapshotInterval {
+			allErrs = append(allErrs, field.Invalid(path.Child("interval"), snapshots.Interval.Duration.String(), fmt.Sprintf("must be greater than or equal to %s", MinEtcdSnapshotInterval)))
+		}
+		allErrs = append(allErrs, snapshots.Store.validate(path.Child("store"))...)
+	}
+
+	if restore := in.Spec.EtcdRestore; restore != nil {
+		path := field.NewPath("spec", "etcdRestore")
+		if externalEtcd {
+			allErrs = append(allErrs, field.Forbidden(path, "cannot be used with external etcd"))
+		}
+		if restore.Key == "" {
+			allErrs = append(allErrs, field.Required(path.Child("key"), "is required"))
+		}
+		if restore.Image == "" {
+			allErrs = append(allErrs, field.Required(path.Child("image"), "is required"))
+		}
+		allErrs = append(allErrs, restore.Store.validate(path.Child("store"))...)
+	}
+
+	return allErrs
+}
+
+func (in *KubeadmControlPlane) validateControlPlaneEndpoint() (allErrs field.ErrorList) {
+	endpoint := in.Spec.ControlPlaneEndpoint
+	if endpoint == nil {
+		return allErrs
+	}
+
+	path := field.NewPath("spec", "controlPlaneEndpoint")
+	switch endpoint.Type {
+	case KubeVIPControlPlaneEndpoint:
+		// The kube-vip static pod manifest is generated by KCP, so it can't be defined in the files as well.
+		for i, file := range in.Spec.KubeadmConfigSpec.Files {
+			if file.Path == KubeVIPManifestPath {
+				allErrs = append(allErrs, field.Forbidden(field.NewPath(spec, kubeadmConfigSpec, files).Index(i).Child("path"), fmt.Sprintf("%s is generated when using the %s control plane endpoint", KubeVIPManifestPath, KubeVIPControlPlaneEndpoint)))
+			}
+		}
+	case ExternalControlPlaneEndpoint:
+		if endpoint.KubeVIP != nil {
+			allErrs = append(allErrs, field.Forbidden(path.Child("kubeVIP"), fmt.Sprintf("can be used only with the %s type", KubeVIPControlPlaneEndpoint)))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("type"), endpoint.Type, []string{string(KubeVIPControlPlaneEndpoint), string(ExternalControlPlaneEndpoint)}))
+	}
+	return allErrs
+}
+
+func (in *KubeadmControlPlane) validateFailureDomainInfrastructureTemplates() (allErrs field.ErrorList) {
+	failureDomains := map[string]bool{}
+	for i, template := range in.Spec.FailureDomainInfrastructureTemplates {
+		path := field.NewPath("spec", "failureDomainInfrastructureTemplates").Index(i)
+		switch {
+		case template.FailureDomain == "":
+			allErrs = append(allErrs, field.Required(path.Child("failureDomain"), "is required"))
+		case failureDomains[template.FailureDomain]:
+			allErrs = append(allErrs, field.Duplicate(path.Child("failureDomain"), template.FailureDomain))
+		}
+		failureDomains[template.FailureDomain] = true
+
+		if template.InfrastructureTemplate.Name == "" {
+			allErrs = append(allErrs, field.Required(path.Child("infrastructureTemplate", "name"), "is required"))
+		}
+		if template.InfrastructureTemplate.Namespace != in.Namespace {
+			allErrs = append(allErrs, field.Invalid(path.Child("infrastructureTemplate", "namespace"), template.InfrastructureTemplate.Namespace, "must match metadata.namespace"))
+		}
+	}
+	return allErrs
+}
+
+func (in *EtcdSnapshotStore) validate(path *field.Path) (allErrs field.ErrorList) {
+	if in.Bucket == "" {
+		allErrs = append(allErrs, field.Required(path.Child("bucket"), "is required"))
+	}
+	if in.Endpoint != "" {
+		u, err := url.Parse(in.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(path.Child("endpoint"), in.Endpoint, "must be an http or https URL"))
+		}
+	}
+	return allErrs
+}
+
 func (in *KubeadmControlPlane) validateCoreDNSImage() (allErrs field.ErrorList) {
 	if in.Spec.KubeadmConfigSpec.ClusterConfiguration == nil {
 		return allErrs
@@ -47,6 +47,105 @@ func TestKubeadmControlPlaneDefault(t *testing.T) {
 	g.Expect(kcp.Spec.Version).To(Equal("v1.18.3"))
 }
 
+func TestKubeadmControlPlaneDefaultFailureDomainInfrastructureTemplates(t *testing.T) {
+	g := NewWithT(t)
+
+	kcp := &KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "foo",
+		},
+		Spec: KubeadmControlPlaneSpec{
+			Version: "v1.18.3",
+			FailureDomainInfrastructureTemplates: []FailureDomainInfrastructureTemplate{
+				{FailureDomain: "zone-a", InfrastructureTemplate: corev1.ObjectReference{Name: "infraTemplate-zone-a"}},
+			},
+		},
+	}
+	kcp.Default()
+
+	g.Expect(kcp.Spec.FailureDomainInfrastructureTemplates[0].InfrastructureTemplate.Namespace).To(Equal(kcp.Namespace))
+}
+
+func TestKubeadmControlPlaneSpecInfrastructureTemplateForFailureDomain(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := &KubeadmControlPlaneSpec{
+		InfrastructureTemplate: corev1.ObjectReference{Name: "infraTemplate"},
+		FailureDomainInfrastructureTemplates: []FailureDomainInfrastructureTemplate{
+			{FailureDomain: "zone-a", InfrastructureTemplate: corev1.ObjectReference{Name: "infraTemplate-zone-a"}},
+		},
+	}
+
+	g.Expect(spec.InfrastructureTemplateForFailureDomain(nil).Name).To(Equal("infraTemplate"))
+	g.Expect(spec.InfrastructureTemplateForFailureDomain(pointer.StringPtr("zone-a")).Name).To(Equal("infraTemplate-zone-a"))
+	g.Expect(spec.InfrastructureTemplateForFailureDomain(pointer.StringPtr("zone-b")).Name).To(Equal("infraTemplate"))
+}
+
+func TestKubeadmControlPlaneDefaultEtcdSnapshots(t *testing.T) {
+	g := NewWithT(t)
+
+	kcp := &KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "foo",
+		},
+		Spec: KubeadmControlPlaneSpec{
+			Version: "v1.18.3",
+			EtcdSnapshots: &EtcdSnapshots{
+				Store: EtcdSnapshotStore{Bucket: "backups"},
+			},
+			EtcdRestore: &EtcdRestore{
+				Store: EtcdSnapshotStore{Bucket: "backups", Region: "eu-west-1"},
+			},
+		},
+	}
+	kcp.Default()
+
+	g.Expect(kcp.Spec.EtcdSnapshots.Store.Region).To(Equal(DefaultEtcdSnapshotStoreRegion))
+	g.Expect(kcp.Spec.EtcdSnapshots.Store.Prefix).To(Equal("foo/test"))
+	g.Expect(kcp.Spec.EtcdSnapshots.UploadImage).To(Equal(DefaultEtcdSnapshotUploadImage))
+	g.Expect(kcp.Spec.EtcdRestore.Store.Region).To(Equal("eu-west-1"))
+}
+
+func TestKubeadmControlPlaneDefaultControlPlaneEndpoint(t *testing.T) {
+	g := NewWithT(t)
+
+	kcp := &KubeadmControlPlane{
+		Spec: KubeadmControlPlaneSpec{
+			Version:              "v1.18.3",
+			ControlPlaneEndpoint: &ControlPlaneEndpointSpec{Type: KubeVIPControlPlaneEndpoint},
+		},
+	}
+	kcp.Default()
+
+	g.Expect(kcp.Spec.ControlPlaneEndpoint.KubeVIP).To(Equal(&KubeVIPSpec{Image: DefaultKubeVIPImage, Interface: DefaultKubeVIPInterface}))
+
+	kcp.Spec.ControlPlaneEndpoint = &ControlPlaneEndpointSpec{Type: ExternalControlPlaneEndpoint}
+	kcp.Default()
+
+	g.Expect(kcp.Spec.ControlPlaneEndpoint.KubeVIP).To(BeNil())
+}
+
+func TestKubeadmControlPlaneDefaultClusterConfigurationOverrides(t *testing.T) {
+	g := NewWithT(t)
+
+	kcp := &KubeadmControlPlane{
+		Spec: KubeadmControlPlaneSpec{
+			Version: "v1.18.3",
+			KubeadmConfigSpec: bootstrapv1.KubeadmConfigSpec{
+				DNSDomain: "cluster.example.com",
+				Images:    &bootstrapv1.ImageOverrides{CoreDNSImageTag: "1.6.7"},
+			},
+		},
+	}
+	kcp.Default()
+
+	// The overrides are rendered into ClusterConfiguration, which is what the controller reads.
+	g.Expect(kcp.Spec.KubeadmConfigSpec.ClusterConfiguration).NotTo(BeNil())
+	g.Expect(kcp.Spec.KubeadmConfigSpec.ClusterConfiguration.Networking.DNSDomain).To(Equal("cluster.example.com"))
+	g.Expect(kcp.Spec.KubeadmConfigSpec.ClusterConfiguration.DNS.ImageTag).To(Equal("1.6.7"))
+}
+
 func TestKubeadmControlPlaneValidateCreate(t *testing.T) {
 	valid := &KubeadmControlPlane{
 		ObjectMeta: metav1.ObjectMeta{
@@ -92,6 +191,67 @@ func TestKubeadmControlPlaneValidateCreate(t *testing.T) {
 	invalidVersion2 := valid.DeepCopy()
 	invalidVersion2.Spec.Version = "1.16.6"
 
+	withEtcdSnapshots := valid.DeepCopy()
+	withEtcdSnapshots.Spec.EtcdSnapshots = &EtcdSnapshots{
+		Interval: metav1.Duration{Duration: 6 * time.Hour},
+		Store: EtcdSnapshotStore{
+			Endpoint: "https://minio.example.com",
+			Bucket:   "backups",
+		},
+	}
+
+	etcdSnapshotsShortInterval := withEtcdSnapshots.DeepCopy()
+	etcdSnapshotsShortInterval.Spec.EtcdSnapshots.Interval.Duration = time.Minute
+
+	etcdSnapshotsInvalidEndpoint := withEtcdSnapshots.DeepCopy()
+	etcdSnapshotsInvalidEndpoint.Spec.EtcdSnapshots.Store.Endpoint = "minio.example.com"
+
+	etcdSnapshotsMissingBucket := withEtcdSnapshots.DeepCopy()
+	etcdSnapshotsMissingBucket.Spec.EtcdSnapshots.Store.Bucket = ""
+
+	etcdSnapshotsExternalEtcd := withEtcdSnapshots.DeepCopy()
+	etcdSnapshotsExternalEtcd.Spec.KubeadmConfigSpec = evenReplicasExternalEtcd.Spec.KubeadmConfigSpec
+
+	withEtcdRestore := valid.DeepCopy()
+	withEtcdRestore.Spec.EtcdRestore = &EtcdRestore{
+		Store: EtcdSnapshotStore{Bucket: "backups"},
+		Key:   "foo/test/etcd-snapshot-20200601T120000Z.db",
+		Image: "k8s.gcr.io/etcd:3.4.3-0",
+	}
+
+	etcdRestoreMissingImage := withEtcdRestore.DeepCopy()
+	etcdRestoreMissingImage.Spec.EtcdRestore.Image = ""
+
+	withKubeVIP := valid.DeepCopy()
+	withKubeVIP.Spec.ControlPlaneEndpoint = &ControlPlaneEndpointSpec{Type: KubeVIPControlPlaneEndpoint}
+
+	kubeVIPManifestInFiles := withKubeVIP.DeepCopy()
+	kubeVIPManifestInFiles.Spec.KubeadmConfigSpec.Files = []bootstrapv1.File{{Path: KubeVIPManifestPath}}
+
+	externalEndpointWithKubeVIP := valid.DeepCopy()
+	externalEndpointWithKubeVIP.Spec.ControlPlaneEndpoint = &ControlPlaneEndpointSpec{
+		Type:    ExternalControlPlaneEndpoint,
+		KubeVIP: &KubeVIPSpec{Interface: "eth1"},
+	}
+
+	unknownEndpoint := valid.DeepCopy()
+	unknownEndpoint.Spec.ControlPlaneEndpoint = &ControlPlaneEndpointSpec{Type: "keepalived"}
+
+	withFailureDomainTemplates := valid.DeepCopy()
+	withFailureDomainTemplates.Spec.FailureDomainInfrastructureTemplates = []FailureDomainInfrastructureTemplate{
+		{FailureDomain: "zone-a", InfrastructureTemplate: corev1.ObjectReference{Namespace: "foo", Name: "infraTemplate-zone-a"}},
+		{FailureDomain: "zone-b", InfrastructureTemplate: corev1.ObjectReference{Namespace: "foo", Name: "infraTemplate-zone-b"}},
+	}
+
+	failureDomainTemplateWithoutFailureDomain := withFailureDomainTemplates.DeepCopy()
+	failureDomainTemplateWithoutFailureDomain.Spec.FailureDomainInfrastructureTemplates[0].FailureDomain = ""
+
+	duplicateFailureDomainTemplates := withFailureDomainTemplates.DeepCopy()
+	duplicateFailureDomainTemplates.Spec.FailureDomainInfrastructureTemplates[1].FailureDomain = "zone-a"
+
+	failureDomainTemplateInvalidNamespace := withFailureDomainTemplates.DeepCopy()
+	failureDomainTemplateInvalidNamespace.Spec.FailureDomainInfrastructureTemplates[0].InfrastructureTemplate.Namespace = "bar"
+
 	tests := []struct {
 		name      string
 		expectErr bool
@@ -142,6 +302,81 @@ func TestKubeadmControlPlaneValidateCreate(t *testing.T) {
 			expectErr: true,
 			kcp:       invalidVersion1,
 		},
+		{
+			name:      "should succeed when given valid etcd snapshots",
+			expectErr: false,
+			kcp:       withEtcdSnapshots,
+		},
+		{
+			name:      "should return error when the etcd snapshots interval is too short",
+			expectErr: true,
+			kcp:       etcdSnapshotsShortInterval,
+		},
+		{
+			name:      "should return error when the etcd snapshots store endpoint is not an URL",
+			expectErr: true,
+			kcp:       etcdSnapshotsInvalidEndpoint,
+		},
+		{
+			name:      "should return error when the etcd snapshots store bucket is missing",
+			expectErr: true,
+			kcp:       etcdSnapshotsMissingBucket,
+		},
+		{
+			name:      "should return error when using etcd snapshots with external etcd",
+			expectErr: true,
+			kcp:       etcdSnapshotsExternalEtcd,
+		},
+		{
+			name:      "should succeed when given a valid etcd restore",
+			expectErr: false,
+			kcp:       withEtcdRestore,
+		},
+		{
+			name:      "should return error when the etcd restore image is missing",
+			expectErr: true,
+			kcp:       etcdRestoreMissingImage,
+		},
+		{
+			name:      "should succeed when given a kube-vip control plane endpoint",
+			expectErr: false,
+			kcp:       withKubeVIP,
+		},
+		{
+			name:      "should return error when the kube-vip manifest is defined in the files",
+			expectErr: true,
+			kcp:       kubeVIPManifestInFiles,
+		},
+		{
+			name:      "should return error when configuring kube-vip for an external control plane endpoint",
+			expectErr: true,
+			kcp:       externalEndpointWithKubeVIP,
+		},
+		{
+			name:      "should return error when given an unknown control plane endpoint type",
+			expectErr: true,
+			kcp:       unknownEndpoint,
+		},
+		{
+			name:      "should succeed when given infrastructure templates for failure domains",
+			expectErr: false,
+			kcp:       withFailureDomainTemplates,
+		},
+		{
+			name:      "should return error when the failure domain of an infrastructure template is missing",
+			expectErr: true,
+			kcp:       failureDomainTemplateWithoutFailureDomain,
+		},
+		{
+			name:      "should return error when a failure domain has more infrastructure templates",
+			expectErr: true,
+			kcp:       duplicateFailureDomainTemplates,
+		},
+		{
+			name:      "should return error when the infrastructure template of a failure domain is in another namespace",
+			expectErr: true,
+			kcp:       failureDomainTemplateInvalidNamespace,
+		},
 	}
 
 	for _, tt := range tests {
@@ -241,6 +476,12 @@ func TestKubeadmControlPlaneValidateUpdate(t *testing.T) {
 			Path: "abc",
 		},
 	}
+	validUpdate.Spec.KubeadmConfigSpec.Kubelet = &bootstrapv1.KubeletOptions{
+		ExtraArgs: map[string]string{"max-pods": "110"},
+	}
+	validUpdate.Spec.KubeadmConfigSpec.Sysctls = map[string]string{"net.ipv4.ip_forward": "1"}
+	validUpdate.Spec.KubeadmConfigSpec.KernelModules = []string{"br_netfilter"}
+	validUpdate.Spec.KubeadmConfigSpec.Images = &bootstrapv1.ImageOverrides{ImageRepository: "registry.example.com/k8s"}
 	validUpdate.Spec.Version = "v1.16.6"
 	validUpdate.Spec.InfrastructureTemplate.Name = "orange"
 	validUpdate.Spec.Replicas = pointer.Int32Ptr(5)
@@ -259,6 +500,9 @@ func TestKubeadmControlPlaneValidateUpdate(t *testing.T) {
 	missingReplicas := before.DeepCopy()
 	missingReplicas.Spec.Replicas = nil
 
+	dnsDomain := before.DeepCopy()
+	dnsDomain.Spec.KubeadmConfigSpec.DNSDomain = "cluster.example.com"
+
 	etcdLocalImageTag := before.DeepCopy()
 	etcdLocalImageTag.Spec.KubeadmConfigSpec.ClusterConfiguration.Etcd.Local = &kubeadmv1beta1.LocalEtcd{
 		ImageMeta: kubeadmv1beta1.ImageMeta{
@@ -422,9 +666,39 @@ func TestKubeadmControlPlaneValidateUpdate(t *testing.T) {
 		},
 	}
 
+	withEtcdSnapshots := before.DeepCopy()
+	withEtcdSnapshots.Spec.EtcdSnapshots = &EtcdSnapshots{
+		Interval: metav1.Duration{Duration: 6 * time.Hour},
+		Store:    EtcdSnapshotStore{Bucket: "backups"},
+	}
+
+	withEtcdRestore := before.DeepCopy()
+	withEtcdRestore.Spec.EtcdRestore = &EtcdRestore{
+		Store: EtcdSnapshotStore{Bucket: "backups"},
+		Key:   "foo/test/etcd-snapshot-20200601T120000Z.db",
+		Image: "k8s.gcr.io/etcd:3.4.3-0",
+	}
+
 	withoutClusterConfiguration := before.DeepCopy()
 	withoutClusterConfiguration.Spec.KubeadmConfigSpec.ClusterConfiguration = nil
 
+	withFailureDomainTemplates := before.DeepCopy()
+	withFailureDomainTemplates.Spec.FailureDomainInfrastructureTemplates = []FailureDomainInfrastructureTemplate{
+		{FailureDomain: "zone-a", InfrastructureTemplate: corev1.ObjectReference{Namespace: "foo", Name: "infraTemplate-zone-a"}},
+	}
+
+	changedFailureDomainTemplates := withFailureDomainTemplates.DeepCopy()
+	changedFailureDomainTemplates.Spec.FailureDomainInfrastructureTemplates[0].InfrastructureTemplate.Name = "infraTemplate-zone-a-v2"
+
+	withAuditConfig := before.DeepCopy()
+	withAuditConfig.Spec.KubeadmConfigSpec.AuditConfig = &bootstrapv1.AuditConfig{
+		LogPath: "/var/log/kubernetes/audit/audit.log",
+	}
+
+	changedAuditConfig := withAuditConfig.DeepCopy()
+	changedAuditConfig.Spec.KubeadmConfigSpec.AuditConfig.Policy = "apiVersion: audit.k8s.io/v1\nkind: Policy\nrules:\n- level: RequestResponse"
+	changedAuditConfig.Spec.KubeadmConfigSpec.AuditConfig.LogMaxAge = pointer.Int32Ptr(7)
+
 	tests := []struct {
 		name      string
 		expectErr bool
@@ -437,6 +711,24 @@ func TestKubeadmControlPlaneValidateUpdate(t *testing.T) {
 			before:    before,
 			kcp:       validUpdate,
 		},
+		{
+			name:      "should succeed when adding the auditConfig",
+			expectErr: false,
+			before:    before,
+			kcp:       withAuditConfig,
+		},
+		{
+			name:      "should succeed when changing the auditConfig",
+			expectErr: false,
+			before:    withAuditConfig,
+			kcp:       changedAuditConfig,
+		},
+		{
+			name:      "should succeed when removing the auditConfig",
+			expectErr: false,
+			before:    withAuditConfig,
+			kcp:       before,
+		},
 		{
 			name:      "should return error when trying to mutate the kubeadmconfigspec initconfiguration",
 			expectErr: true,
@@ -497,6 +789,12 @@ func TestKubeadmControlPlaneValidateUpdate(t *testing.T) {
 			before:    beforeExternalEtcdCluster,
 			kcp:       scaleToEvenExternalEtcdCluster,
 		},
+		{
+			name:      "should return error when trying to mutate the dns domain",
+			expectErr: true,
+			before:    before,
+			kcp:       dnsDomain,
+		},
 		{
 			name:      "should succeed when making a change to the local etcd image tag",
 			expectErr: false,
@@ -665,6 +963,36 @@ func TestKubeadmControlPlaneValidateUpdate(t *testing.T) {
 			before:    withoutClusterConfiguration,
 			kcp:       withoutClusterConfiguration,
 		},
+		{
+			name:      "should succeed when enabling etcd snapshots",
+			expectErr: false,
+			before:    before,
+			kcp:       withEtcdSnapshots,
+		},
+		{
+			name:      "should succeed when disabling etcd snapshots",
+			expectErr: false,
+			before:    withEtcdSnapshots,
+			kcp:       before,
+		},
+		{
+			name:      "should fail when setting the etcd restore",
+			expectErr: true,
+			before:    before,
+			kcp:       withEtcdRestore,
+		},
+		{
+			name:      "should succeed when adding infrastructure templates for failure domains",
+			expectErr: false,
+			before:    before,
+			kcp:       withFailureDomainTemplates,
+		},
+		{
+			name:      "should succeed when changing the infrastructure template of a failure domain",
+			expectErr: false,
+			before:    withFailureDomainTemplates,
+			kcp:       changedFailureDomainTemplates,
+		},
 	}
 
 	for _, tt := range tests {
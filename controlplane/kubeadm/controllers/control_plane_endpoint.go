@@ -0,0 +1,127 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+)
+
+// defaultAPIServerPort is the port of the control plane endpoint if not defined in the Cluster.
+const defaultAPIServerPort = 6443
+
+// setControlPlaneEndpoint adds to the bootstrap config of a control plane machine the files implementing the
+// endpoint of the control plane, if any.
+func setControlPlaneEndpoint(bootstrapSpec *bootstrapv1.KubeadmConfigSpec, kcp *controlplanev1.KubeadmControlPlane, cluster *clusterv1.Cluster) error {
+	endpoint := kcp.Spec.ControlPlaneEndpoint
+	if endpoint == nil || endpoint.Type != controlplanev1.KubeVIPControlPlaneEndpoint {
+		return nil
+	}
+
+	// kube-vip announces the host of the control plane endpoint as a virtual IP, so it must be an IP address.
+	address := cluster.Spec.ControlPlaneEndpoint.Host
+	if net.ParseIP(address) == nil {
+		return errors.Errorf("the host of the control plane endpoint of the Cluster %s/%s must be an IP address when using kube-vip, got %q", cluster.Namespace, cluster.Name, address)
+	}
+
+	kubeVIP := endpoint.KubeVIP
+	if kubeVIP == nil {
+		kubeVIP = &controlplanev1.KubeVIPSpec{}
+	}
+	image := kubeVIP.Image
+	if image == "" {
+		image = controlplanev1.DefaultKubeVIPImage
+	}
+	iface := kubeVIP.Interface
+	if iface == "" {
+		iface = controlplanev1.DefaultKubeVIPInterface
+	}
+
+	port := cluster.Spec.ControlPlaneEndpoint.Port
+	if port == 0 {
+		port = defaultAPIServerPort
+	}
+
+	bootstrapSpec.Files = append(bootstrapSpec.Files, bootstrapv1.File{
+		Path:        controlplanev1.KubeVIPManifestPath,
+		Owner:       "root:root",
+		Permissions: "0644",
+		Content:     kubeVIPManifest(image, iface, address, port),
+	})
+	return nil
+}
+
+// kubeVIPManifest returns the manifest of the kube-vip static pod, announcing the virtual IP with ARP from the
+// control plane machine holding the leader election lease.
+// Nb. kube-vip uses the admin kubeconfig generated by kubeadm for the leader election.
+func kubeVIPManifest(image, iface, address string, port int32) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Pod
+metadata:
+  name: kube-vip
+  namespace: kube-system
+spec:
+  containers:
+  - name: kube-vip
+    image: %s
+    imagePullPolicy: IfNotPresent
+    args:
+    - manager
+    env:
+    - name: vip_arp
+      value: "true"
+    - name: vip_interface
+      value: %s
+    - name: address
+      value: %s
+    - name: port
+      value: "%d"
+    - name: vip_cidr
+      value: "32"
+    - name: cp_enable
+      value: "true"
+    - name: cp_namespace
+      value: kube-system
+    - name: vip_leaderelection
+      value: "true"
+    - name: vip_leaseduration
+      value: "15"
+    - name: vip_renewdeadline
+      value: "10"
+    - name: vip_retryperiod
+      value: "2"
+    securityContext:
+      capabilities:
+        add:
+        - NET_ADMIN
+        - NET_RAW
+    volumeMounts:
+    - mountPath: /etc/kubernetes/admin.conf
+      name: kubeconfig
+  hostNetwork: true
+  volumes:
+  - hostPath:
+      path: /etc/kubernetes/admin.conf
+      type: FileOrCreate
+    name: kubeconfig
+`, image, iface, address, port)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/yaml"
+
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+)
+
+func TestSetControlPlaneEndpoint(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoint  *controlplanev1.ControlPlaneEndpointSpec
+		host      string
+		port      int32
+		wantFiles int
+		wantEnv   map[string]string
+		wantImage string
+		wantErr   bool
+	}{
+		{
+			name:      "no files without a control plane endpoint",
+			host:      "10.0.0.100",
+			wantFiles: 0,
+		},
+		{
+			name:      "no files for an external control plane endpoint",
+			endpoint:  &controlplanev1.ControlPlaneEndpointSpec{Type: controlplanev1.ExternalControlPlaneEndpoint},
+			host:      "lb.example.com",
+			wantFiles: 0,
+		},
+		{
+			name:      "kube-vip manifest with defaults",
+			endpoint:  &controlplanev1.ControlPlaneEndpointSpec{Type: controlplanev1.KubeVIPControlPlaneEndpoint},
+			host:      "10.0.0.100",
+			wantFiles: 1,
+			wantImage: controlplanev1.DefaultKubeVIPImage,
+			wantEnv:   map[string]string{"address": "10.0.0.100", "port": "6443", "vip_interface": controlplanev1.DefaultKubeVIPInterface},
+		},
+		{
+			name: "kube-vip manifest with custom image, interface and port",
+			endpoint: &controlplanev1.ControlPlaneEndpointSpec{
+				Type:    controlplanev1.KubeVIPControlPlaneEndpoint,
+				KubeVIP: &controlplanev1.KubeVIPSpec{Image: "registry.example.com/kube-vip:v0.3.5", Interface: "ens192"},
+			},
+			host:      "10.0.0.100",
+			port:      8443,
+			wantFiles: 1,
+			wantImage: "registry.example.com/kube-vip:v0.3.5",
+			wantEnv:   map[string]string{"address": "10.0.0.100", "port": "8443", "vip_interface": "ens192"},
+		},
+		{
+			name:     "fails if the host of the control plane endpoint is not an IP",
+			endpoint: &controlplanev1.ControlPlaneEndpointSpec{Type: controlplanev1.KubeVIPControlPlaneEndpoint},
+			host:     "lb.example.com",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			kcp := &controlplanev1.KubeadmControlPlane{
+				Spec: controlplanev1.KubeadmControlPlaneSpec{ControlPlaneEndpoint: tt.endpoint},
+			}
+			cluster := &clusterv1.Cluster{
+				Spec: clusterv1.ClusterSpec{
+					ControlPlaneEndpoint: clusterv1.APIEndpoint{Host: tt.host, Port: tt.port},
+				},
+			}
+			spec := &bootstrapv1.KubeadmConfigSpec{}
+
+			err := setControlPlaneEndpoint(spec, kcp, cluster)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(spec.Files).To(HaveLen(tt.wantFiles))
+			if tt.wantFiles == 0 {
+				return
+			}
+
+			g.Expect(spec.Files[0].Path).To(Equal(controlplanev1.KubeVIPManifestPath))
+			pod := &corev1.Pod{}
+			g.Expect(yaml.Unmarshal([]byte(spec.Files[0].Content), pod)).To(Succeed())
+			g.Expect(pod.Spec.HostNetwork).To(BeTrue())
+			g.Expect(pod.Spec.Containers).To(HaveLen(1))
+			g.Expect(pod.Spec.Containers[0].Image).To(Equal(tt.wantImage))
+			env := map[string]string{}
+			for _, e := range pod.Spec.Containers[0].Env {
+				env[e.Name] = e.Value
+			}
+			for k, v := range tt.wantEnv {
+				g.Expect(env).To(HaveKeyWithValue(k, v))
+			}
+		})
+	}
+}
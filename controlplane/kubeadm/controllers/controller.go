@@ -54,7 +54,7 @@ import (
 )
 
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
-// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps,namespace=kube-system,verbs=get;list;watch;create
 // +kubebuilder:rbac:groups=rbac,resources=roles,namespace=kube-system,verbs=get;list;watch;create
 // +kubebuilder:rbac:groups=rbac,resources=rolebindings,namespace=kube-system,verbs=get;list;watch;create
@@ -235,6 +235,11 @@ func (r *KubeadmControlPlaneReconciler) reconcile(ctx context.Context, cluster *
 	if err := r.reconcileExternalReference(ctx, cluster, kcp.Spec.InfrastructureTemplate); err != nil {
 		return ctrl.Result{}, err
 	}
+	for _, template := range kcp.Spec.FailureDomainInfrastructureTemplates {
+		if err := r.reconcileExternalReference(ctx, cluster, template.InfrastructureTemplate); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 
 	// Generate Cluster Certificates if needed
 	config := kcp.Spec.KubeadmConfigSpec.DeepCopy()
@@ -243,6 +248,7 @@ func (r *KubeadmControlPlaneReconciler) reconcile(ctx context.Context, cluster *
 		config.ClusterConfiguration = &kubeadmv1.ClusterConfiguration{}
 	}
 	certificates := secret.NewCertificatesForInitialControlPlane(config.ClusterConfiguration)
+	certificates.UseCertificateRefs(config.CertificateRefs)
 	controllerRef := metav1.NewControllerRef(kcp, controlplanev1.GroupVersion.WithKind("KubeadmControlPlane"))
 	if err := certificates.LookupOrGenerate(ctx, r.Client, util.ObjectKey(cluster), *controllerRef); err != nil {
 		logger.Error(err, "unable to lookup or create cluster certificates")
@@ -292,6 +298,17 @@ func (r *KubeadmControlPlaneReconciler) reconcile(ctx context.Context, cluster *
 	// source ref (reason@machine/name) so the problem can be easily tracked down to its source machine.
 	conditions.SetAggregate(controlPlane.KCP, controlplanev1.MachinesReadyCondition, ownedMachines.ConditionGetters(), conditions.AddSourceRef())
 
+	// Keep the URL of the etcd snapshot restored by the first control plane machine valid until it is used.
+	if err := r.reconcileEtcdRestore(ctx, cluster, kcp, controlPlane); err != nil {
+		logger.Error(err, "Failed to reconcile the etcd restore")
+		return ctrl.Result{}, err
+	}
+
+	// Remediate the control plane machines marked as unhealthy by a MachineHealthCheck, if it is safe to do so.
+	if result, err := r.reconcileUnhealthyMachines(ctx, cluster, kcp, controlPlane); err != nil || !result.IsZero() {
+		return result, err
+	}
+
 	// Control plane machines rollout due to configuration changes (e.g. upgrades) takes precedence over other operations.
 	needRollout := controlPlane.MachinesNeedingRollout()
 	switch {
@@ -356,7 +373,8 @@ func (r *KubeadmControlPlaneReconciler) reconcile(ctx context.Context, cluster *
 		return ctrl.Result{}, errors.Wrap(err, "failed to update CoreDNS deployment")
 	}
 
-	return ctrl.Result{}, nil
+	// Take a snapshot of etcd, if required.
+	return r.reconcileEtcdSnapshot(ctx, kcp, workloadCluster)
 }
 
 // reconcileDelete handles KubeadmControlPlane deletion.
@@ -0,0 +1,409 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	kubeadmv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal/objectstore"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/shell"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// etcdSnapshotTimeFormat is the format of the timestamp in the keys of the etcd snapshots.
+	etcdSnapshotTimeFormat = "20060102T150405Z"
+
+	// etcdSnapshotPollInterval is the interval between the checks of a running etcd snapshot Job.
+	etcdSnapshotPollInterval = 30 * time.Second
+
+	// etcdSnapshotUploadURLExpiration is the validity of the URL the etcd snapshot Job uploads the snapshot to, which
+	// must be longer than the deadline of the Job.
+	etcdSnapshotUploadURLExpiration = time.Hour
+
+	// etcdRestoreScriptPath is the path of the script restoring the etcd snapshot on the first control plane machine.
+	etcdRestoreScriptPath = "/run/kubeadm/etcd-restore.sh"
+
+	// etcdRestoreURLPath is the path of the file holding the URL of the etcd snapshot on the first control plane
+	// machine; the file is removed once the snapshot is downloaded.
+	etcdRestoreURLPath = "/run/kubeadm/etcd-restore-url"
+
+	// etcdRestoreURLSecretKey is the key of the URL of the etcd snapshot in the etcd restore Secret.
+	etcdRestoreURLSecretKey = "url"
+
+	// etcdRestoreURLExpiresAtAnnotation records in the etcd restore Secret when the URL of the etcd snapshot expires.
+	etcdRestoreURLExpiresAtAnnotation = "controlplane.cluster.x-k8s.io/etcd-restore-url-expires-at"
+
+	// etcdRestoreURLExpiration is the validity of the URL used for downloading the etcd snapshot. The URL is
+	// regenerated once half of it has elapsed, until the bootstrap data of the first control plane machine is
+	// generated, so the machine has at least half of it for downloading the snapshot.
+	etcdRestoreURLExpiration = 24 * time.Hour
+
+	// etcdDataDirPreflightError is the kubeadm preflight check failing because the etcd data dir is not empty.
+	etcdDataDirPreflightError = "DirAvailable--var-lib-etcd"
+)
+
+// reconcileEtcdSnapshot takes a snapshot of the etcd database and uploads it to the configured store, if the interval
+// since the last snapshot has elapsed. The snapshot is taken and uploaded by a Job in the workload cluster, so the
+// snapshot never goes through the controller; the reconcile loop only starts the Job and records the snapshot once
+// the Job completes.
+func (r *KubeadmControlPlaneReconciler) reconcileEtcdSnapshot(ctx context.Context, kcp *controlplanev1.KubeadmControlPlane, workloadCluster internal.WorkloadCluster) (ctrl.Result, error) {
+	snapshots := kcp.Spec.EtcdSnapshots
+	if snapshots == nil {
+		return ctrl.Result{}, nil
+	}
+
+	job, err := workloadCluster.GetEtcdSnapshotJob(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if job != nil {
+		return r.reconcileEtcdSnapshotJob(ctx, kcp, workloadCluster, job)
+	}
+
+	now := time.Now().UTC()
+	if last := kcp.Status.LastEtcdSnapshot; last != nil {
+		if next := last.Time.Add(snapshots.Interval.Duration); now.Before(next) {
+			return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+		}
+	}
+
+	key, err := r.startEtcdSnapshot(ctx, kcp, workloadCluster, now)
+	if err != nil {
+		conditions.MarkFalse(kcp, controlplanev1.EtcdSnapshotSucceededCondition, controlplanev1.EtcdSnapshotFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		r.recorder.Eventf(kcp, corev1.EventTypeWarning, "FailedEtcdSnapshot", "Failed to start an etcd snapshot: %v", err)
+		return ctrl.Result{}, err
+	}
+
+	r.Log.Info("Started etcd snapshot", "namespace", kcp.Namespace, "kubeadmControlPlane", kcp.Name, "key", key)
+	return ctrl.Result{RequeueAfter: etcdSnapshotPollInterval}, nil
+}
+
+// startEtcdSnapshot creates the Job taking a snapshot and uploading it to a presigned URL, so the credentials of the
+// store are never shared with the workload cluster.
+func (r *KubeadmControlPlaneReconciler) startEtcdSnapshot(ctx context.Context, kcp *controlplanev1.KubeadmControlPlane, workloadCluster internal.WorkloadCluster, now time.Time) (string, error) {
+	store, err := r.etcdSnapshotStore(ctx, kcp.Namespace, &kcp.Spec.EtcdSnapshots.Store)
+	if err != nil {
+		return "", err
+	}
+
+	key := etcdSnapshotKey(kcp, now)
+	uploadURL, err := store.PresignPut(key, etcdSnapshotUploadURLExpiration)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the upload URL of the etcd snapshot %q", key)
+	}
+
+	uploadImage := kcp.Spec.EtcdSnapshots.UploadImage
+	if uploadImage == "" {
+		uploadImage = controlplanev1.DefaultEtcdSnapshotUploadImage
+	}
+	return key, workloadCluster.CreateEtcdSnapshotJob(ctx, internal.EtcdSnapshotJobInput{
+		Key:         key,
+		UploadURL:   uploadURL,
+		UploadImage: uploadImage,
+	})
+}
+
+// reconcileEtcdSnapshotJob records the snapshot uploaded by a completed Job. Failed Jobs are kept for troubleshooting
+// until the snapshot is retried, MinEtcdSnapshotInterval after the Job was created.
+func (r *KubeadmControlPlaneReconciler) reconcileEtcdSnapshotJob(ctx context.Context, kcp *controlplanev1.KubeadmControlPlane, workloadCluster internal.WorkloadCluster, job *batchv1.Job) (ctrl.Result, error) {
+	key := job.Annotations[internal.EtcdSnapshotKeyAnnotation]
+
+	if failed := jobCondition(job, batchv1.JobFailed); failed != nil {
+		conditions.MarkFalse(kcp, controlplanev1.EtcdSnapshotSucceededCondition, controlplanev1.EtcdSnapshotFailedReason, clusterv1.ConditionSeverityWarning,
+			"etcd snapshot Job %s/%s failed: %s", job.Namespace, job.Name, failed.Message)
+		if retry := time.Until(job.CreationTimestamp.Add(controlplanev1.MinEtcdSnapshotInterval)); retry > 0 {
+			return ctrl.Result{RequeueAfter: retry}, nil
+		}
+		r.recorder.Eventf(kcp, corev1.EventTypeWarning, "FailedEtcdSnapshot", "Failed to take the etcd snapshot %q: %s", key, failed.Message)
+		if err := workloadCluster.DeleteEtcdSnapshotJob(ctx); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	if jobCondition(job, batchv1.JobComplete) == nil {
+		return ctrl.Result{RequeueAfter: etcdSnapshotPollInterval}, nil
+	}
+
+	// Checking the uploaded object also reports its size, which the Job can't report back.
+	store, err := r.etcdSnapshotStore(ctx, kcp.Namespace, &kcp.Spec.EtcdSnapshots.Store)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	size, err := store.Size(ctx, key)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to get the uploaded etcd snapshot %q", key)
+		conditions.MarkFalse(kcp, controlplanev1.EtcdSnapshotSucceededCondition, controlplanev1.EtcdSnapshotFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return ctrl.Result{}, err
+	}
+	if err := workloadCluster.DeleteEtcdSnapshotJob(ctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	status := &controlplanev1.EtcdSnapshotStatus{
+		Time: job.CreationTimestamp,
+		Key:  key,
+		Size: size,
+	}
+	r.Log.Info("Uploaded etcd snapshot", "namespace", kcp.Namespace, "kubeadmControlPlane", kcp.Name, "key", status.Key, "size", status.Size)
+	kcp.Status.LastEtcdSnapshot = status
+	conditions.MarkTrue(kcp, controlplanev1.EtcdSnapshotSucceededCondition)
+	r.recorder.Eventf(kcp, corev1.EventTypeNormal, "SuccessfulEtcdSnapshot", "Uploaded etcd snapshot %q", status.Key)
+
+	next := time.Until(status.Time.Add(kcp.Spec.EtcdSnapshots.Interval.Duration))
+	if next < etcdSnapshotPollInterval {
+		next = etcdSnapshotPollInterval
+	}
+	return ctrl.Result{RequeueAfter: next}, nil
+}
+
+// jobCondition returns the condition of the given type of a Job, if it is true.
+func jobCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) *batchv1.JobCondition {
+	for i := range job.Status.Conditions {
+		c := &job.Status.Conditions[i]
+		if c.Type == conditionType && c.Status == corev1.ConditionTrue {
+			return c
+		}
+	}
+	return nil
+}
+
+// etcdSnapshotKey returns the key of a snapshot taken at the given time.
+func etcdSnapshotKey(kcp *controlplanev1.KubeadmControlPlane, now time.Time) string {
+	prefix := kcp.Spec.EtcdSnapshots.Store.Prefix
+	if prefix == "" {
+		prefix = path.Join(kcp.Namespace, kcp.Name)
+	}
+	return path.Join(prefix, fmt.Sprintf("etcd-snapshot-%s.db", now.UTC().Format(etcdSnapshotTimeFormat)))
+}
+
+// etcdSnapshotStore returns a client for the store, reading the credentials from the referenced Secret, if any.
+func (r *KubeadmControlPlaneReconciler) etcdSnapshotStore(ctx context.Context, namespace string, store *controlplanev1.EtcdSnapshotStore) (*objectstore.S3, error) {
+	credentials := objectstore.Credentials{}
+	if store.CredentialsSecret != nil {
+		s := &corev1.Secret{}
+		key := client.ObjectKey{Namespace: namespace, Name: store.CredentialsSecret.Name}
+		if err := r.Client.Get(ctx, key, s); err != nil {
+			return nil, errors.Wrapf(err, "failed to get the etcd snapshot store credentials Secret %s", key)
+		}
+		c, err := objectstore.CredentialsFromSecretData(s.Data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid etcd snapshot store credentials Secret %s", key)
+		}
+		credentials = c
+	}
+
+	region := store.Region
+	if region == "" {
+		region = controlplanev1.DefaultEtcdSnapshotStoreRegion
+	}
+	return objectstore.NewS3(store.Endpoint, region, store.Bucket, credentials)
+}
+
+// addEtcdRestore configures the bootstrap config of the first control plane machine to restore the etcd snapshot
+// before running kubeadm init.
+func (r *KubeadmControlPlaneReconciler) addEtcdRestore(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, bootstrapSpec *bootstrapv1.KubeadmConfigSpec) error {
+	if err := r.reconcileEtcdRestoreURLSecret(ctx, cluster, kcp); err != nil {
+		return err
+	}
+	setEtcdRestore(bootstrapSpec, etcdRestoreSecretName(kcp), kcp.Spec.EtcdRestore.Image)
+	return nil
+}
+
+// reconcileEtcdRestore keeps the URL of the etcd snapshot valid until the bootstrap data of the first control plane
+// machine is generated, and deletes it once the control plane is initialized.
+func (r *KubeadmControlPlaneReconciler) reconcileEtcdRestore(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, controlPlane *internal.ControlPlane) error {
+	if kcp.Spec.EtcdRestore == nil {
+		return nil
+	}
+
+	if kcp.Status.Initialized {
+		s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: kcp.Namespace, Name: etcdRestoreSecretName(kcp)}}
+		if err := r.Client.Delete(ctx, s); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete the etcd restore Secret %s/%s", s.Namespace, s.Name)
+		}
+		return nil
+	}
+
+	// The Secret is created with the first control plane machine, and it is no longer used once its bootstrap data
+	// is generated.
+	if len(controlPlane.Machines) == 0 {
+		return nil
+	}
+	for _, m := range controlPlane.Machines {
+		if m.Spec.Bootstrap.DataSecretName == nil {
+			return r.reconcileEtcdRestoreURLSecret(ctx, cluster, kcp)
+		}
+	}
+	return nil
+}
+
+// reconcileEtcdRestoreURLSecret creates the Secret holding the presigned URL of the etcd snapshot, which the bootstrap
+// config of the first control plane machine references, and regenerates the URL once half of its validity has
+// elapsed. The URL is never stored in the bootstrap config, and the credentials of the store are never shared with
+// the machine.
+func (r *KubeadmControlPlaneReconciler) reconcileEtcdRestoreURLSecret(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane) error {
+	now := time.Now()
+	s := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: kcp.Namespace, Name: etcdRestoreSecretName(kcp)}
+	err := r.Client.Get(ctx, key, s)
+	switch {
+	case apierrors.IsNotFound(err):
+		s = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: key.Namespace,
+				Name:      key.Name,
+				Labels:    map[string]string{clusterv1.ClusterLabelName: cluster.Name},
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(kcp, controlplanev1.GroupVersion.WithKind("KubeadmControlPlane")),
+				},
+			},
+			Type: clusterv1.ClusterSecretType,
+		}
+	case err != nil:
+		return errors.Wrapf(err, "failed to get the etcd restore Secret %s", key)
+	default:
+		if expiresAt, err := time.Parse(time.RFC3339, s.Annotations[etcdRestoreURLExpiresAtAnnotation]); err == nil && expiresAt.Sub(now) > etcdRestoreURLExpiration/2 {
+			return nil
+		}
+	}
+
+	restore := kcp.Spec.EtcdRestore
+	store, err := r.etcdSnapshotStore(ctx, kcp.Namespace, &restore.Store)
+	if err != nil {
+		return err
+	}
+	snapshotURL, err := store.PresignGet(restore.Key, etcdRestoreURLExpiration)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the URL of the etcd snapshot %q", restore.Key)
+	}
+
+	if s.Annotations == nil {
+		s.Annotations = map[string]string{}
+	}
+	s.Annotations[etcdRestoreURLExpiresAtAnnotation] = now.Add(etcdRestoreURLExpiration).UTC().Format(time.RFC3339)
+	s.Data = map[string][]byte{etcdRestoreURLSecretKey: []byte(snapshotURL)}
+	if s.ResourceVersion == "" {
+		if err := r.Client.Create(ctx, s); err != nil {
+			return errors.Wrapf(err, "failed to create the etcd restore Secret %s", key)
+		}
+		return nil
+	}
+	if err := r.Client.Update(ctx, s); err != nil {
+		return errors.Wrapf(err, "failed to update the etcd restore Secret %s", key)
+	}
+	return nil
+}
+
+// etcdRestoreSecretName returns the name of the Secret holding the URL of the etcd snapshot to restore.
+func etcdRestoreSecretName(kcp *controlplanev1.KubeadmControlPlane) string {
+	return kcp.Name + "-etcd-restore"
+}
+
+// setEtcdRestore adds to the bootstrap config a script that downloads the etcd snapshot and restores it into the etcd
+// data dir, which kubeadm then uses when starting the etcd static pod. The URL of the snapshot is read from the given
+// Secret when the bootstrap data is generated.
+func setEtcdRestore(bootstrapSpec *bootstrapv1.KubeadmConfigSpec, urlSecretName, image string) {
+	if bootstrapSpec.InitConfiguration == nil {
+		bootstrapSpec.InitConfiguration = &kubeadmv1.InitConfiguration{}
+	}
+	nodeRegistration := &bootstrapSpec.InitConfiguration.NodeRegistration
+
+	// The etcd member must have the name and peer URL that kubeadm uses in the etcd static pod manifest.
+	nodeName := "$(hostname | tr '[:upper:]' '[:lower:]')"
+	if nodeRegistration.Name != "" {
+		nodeName = shell.Quote(nodeRegistration.Name)
+	}
+	nodeIP := "$(ip -o -4 route get 1.1.1.1 | sed -n 's/.* src \\([0-9.]*\\).*/\\1/p')"
+	if address := bootstrapSpec.InitConfiguration.LocalAPIEndpoint.AdvertiseAddress; address != "" {
+		nodeIP = shell.Quote(address)
+	}
+
+	bootstrapSpec.Files = append(bootstrapSpec.Files,
+		bootstrapv1.File{
+			Path:        etcdRestoreURLPath,
+			Owner:       "root:root",
+			Permissions: "0600",
+			ContentFrom: &bootstrapv1.FileSource{
+				Secret: bootstrapv1.SecretFileSource{Name: urlSecretName, Key: etcdRestoreURLSecretKey},
+			},
+		},
+		bootstrapv1.File{
+			Path:        etcdRestoreScriptPath,
+			Owner:       "root:root",
+			Permissions: "0700",
+			Content:     etcdRestoreScript(image, nodeName, nodeIP),
+		},
+	)
+	bootstrapSpec.PreKubeadmCommands = append(bootstrapSpec.PreKubeadmCommands, etcdRestoreScriptPath)
+
+	for _, e := range nodeRegistration.IgnorePreflightErrors {
+		if e == etcdDataDirPreflightError {
+			return
+		}
+	}
+	nodeRegistration.IgnorePreflightErrors = append(nodeRegistration.IgnorePreflightErrors, etcdDataDirPreflightError)
+}
+
+// etcdRestoreScript returns a script restoring the etcd snapshot with the etcdctl binary of the etcd image, using
+// either containerd or docker.
+func etcdRestoreScript(image, nodeName, nodeIP string) string {
+	return fmt.Sprintf(`#!/bin/sh
+set -e
+
+SNAPSHOT_URL_FILE=%s
+ETCD_IMAGE=%s
+NODE_NAME=%s
+NODE_IP=%s
+SNAPSHOT=/var/lib/etcd-restore/snapshot.db
+
+if [ -d /var/lib/etcd/member ]; then
+  echo "etcd data dir is not empty, skipping the restore"
+  exit 0
+fi
+
+mkdir -p "$(dirname "${SNAPSHOT}")"
+curl -fsSL --retry 5 -o "${SNAPSHOT}" "$(cat "${SNAPSHOT_URL_FILE}")"
+rm -f "${SNAPSHOT_URL_FILE}"
+
+RESTORE="etcdctl snapshot restore ${SNAPSHOT} --data-dir /var/lib/etcd --name ${NODE_NAME} --initial-cluster ${NODE_NAME}=https://${NODE_IP}:2380 --initial-advertise-peer-urls https://${NODE_IP}:2380"
+if command -v ctr >/dev/null 2>&1; then
+  ctr -n k8s.io images pull "${ETCD_IMAGE}"
+  ctr -n k8s.io run --rm --env ETCDCTL_API=3 --mount type=bind,src=/var/lib,dst=/var/lib,options=rbind:rw "${ETCD_IMAGE}" etcd-restore ${RESTORE}
+else
+  docker run --rm -e ETCDCTL_API=3 -v /var/lib:/var/lib "${ETCD_IMAGE}" ${RESTORE}
+fi
+
+rm -rf "$(dirname "${SNAPSHOT}")"
+`, shell.Quote(etcdRestoreURLPath), shell.Quote(image), nodeName, nodeIP)
+}
@@ -0,0 +1,437 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/klogr"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	kubeadmv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal/objectstore"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func etcdSnapshotJob(age time.Duration, conditionType batchv1.JobConditionType) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         metav1.NamespaceSystem,
+			Name:              "etcd-snapshot",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			Annotations:       map[string]string{internal.EtcdSnapshotKeyAnnotation: "test/kcp/etcd-snapshot-20200601T120000Z.db"},
+		},
+	}
+	if conditionType != "" {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: conditionType, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+	}
+	return job
+}
+
+func TestReconcileEtcdSnapshot(t *testing.T) {
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "snapshot-credentials"},
+		Data: map[string][]byte{
+			objectstore.AccessKeyIDKey:     []byte("id"),
+			objectstore.SecretAccessKeyKey: []byte("secret"),
+		},
+	}
+
+	tests := []struct {
+		name          string
+		lastSnapshot  *controlplanev1.EtcdSnapshotStatus
+		job           *batchv1.Job
+		createErr     error
+		storeStatus   int
+		wantCreated   bool
+		wantDeleted   bool
+		wantRecorded  bool
+		wantCondition *bool
+		wantErr       bool
+	}{
+		{
+			name:        "starts the first snapshot",
+			wantCreated: true,
+		},
+		{
+			name:         "starts a snapshot when the interval has elapsed",
+			lastSnapshot: &controlplanev1.EtcdSnapshotStatus{Time: metav1.NewTime(time.Now().Add(-2 * time.Hour)), Key: "old"},
+			wantCreated:  true,
+		},
+		{
+			name:         "waits for the interval to elapse",
+			lastSnapshot: &controlplanev1.EtcdSnapshotStatus{Time: metav1.NewTime(time.Now().Add(-30 * time.Minute)), Key: "old"},
+		},
+		{
+			name:          "fails if the Job can't be created",
+			createErr:     errors.New("no ready etcd members found"),
+			wantCondition: pointer.BoolPtr(false),
+			wantErr:       true,
+		},
+		{
+			name: "waits for the Job to complete",
+			job:  etcdSnapshotJob(time.Minute, ""),
+		},
+		{
+			name:          "records the snapshot uploaded by the Job",
+			job:           etcdSnapshotJob(time.Minute, batchv1.JobComplete),
+			storeStatus:   http.StatusOK,
+			wantDeleted:   true,
+			wantRecorded:  true,
+			wantCondition: pointer.BoolPtr(true),
+		},
+		{
+			name:          "fails if the uploaded snapshot is not found",
+			job:           etcdSnapshotJob(time.Minute, batchv1.JobComplete),
+			storeStatus:   http.StatusNotFound,
+			wantCondition: pointer.BoolPtr(false),
+			wantErr:       true,
+		},
+		{
+			name:          "keeps a failed Job until the snapshot is retried",
+			job:           etcdSnapshotJob(time.Minute, batchv1.JobFailed),
+			wantCondition: pointer.BoolPtr(false),
+		},
+		{
+			name:          "deletes a failed Job for retrying the snapshot",
+			job:           etcdSnapshotJob(10*time.Minute, batchv1.JobFailed),
+			wantDeleted:   true,
+			wantCondition: pointer.BoolPtr(false),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				g.Expect(r.Method).To(Equal(http.MethodHead))
+				g.Expect(r.URL.Path).To(Equal("/backups/test/kcp/etcd-snapshot-20200601T120000Z.db"))
+				w.Header().Set("Content-Length", "8")
+				w.WriteHeader(tt.storeStatus)
+			}))
+			defer server.Close()
+
+			kcp := &controlplanev1.KubeadmControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "kcp"},
+				Spec: controlplanev1.KubeadmControlPlaneSpec{
+					EtcdSnapshots: &controlplanev1.EtcdSnapshots{
+						Interval: metav1.Duration{Duration: time.Hour},
+						Store: controlplanev1.EtcdSnapshotStore{
+							Endpoint:          server.URL,
+							Bucket:            "backups",
+							Prefix:            "test/kcp",
+							CredentialsSecret: &corev1.LocalObjectReference{Name: credentials.Name},
+						},
+					},
+				},
+				Status: controlplanev1.KubeadmControlPlaneStatus{
+					LastEtcdSnapshot: tt.lastSnapshot,
+				},
+			}
+
+			r := &KubeadmControlPlaneReconciler{
+				Client:   newFakeClient(g, credentials.DeepCopy()),
+				Log:      klogr.New(),
+				recorder: record.NewFakeRecorder(32),
+			}
+
+			snapshots := &fakeEtcdSnapshots{Job: tt.job, CreateErr: tt.createErr}
+			result, err := r.reconcileEtcdSnapshot(context.TODO(), kcp, fakeWorkloadCluster{EtcdSnapshots: snapshots})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(result.Requeue || result.RequeueAfter > 0).To(BeTrue())
+			}
+			g.Expect(snapshots.Deleted).To(Equal(tt.wantDeleted))
+
+			if tt.wantCondition == nil {
+				g.Expect(conditions.Has(kcp, controlplanev1.EtcdSnapshotSucceededCondition)).To(BeFalse())
+			} else {
+				g.Expect(conditions.IsTrue(kcp, controlplanev1.EtcdSnapshotSucceededCondition)).To(Equal(*tt.wantCondition))
+			}
+
+			if tt.wantCreated {
+				g.Expect(snapshots.Created).NotTo(BeNil())
+				g.Expect(snapshots.Created.Key).To(HavePrefix("test/kcp/etcd-snapshot-"))
+				g.Expect(snapshots.Created.UploadURL).To(HavePrefix(server.URL + "/backups/" + snapshots.Created.Key + "?"))
+				g.Expect(snapshots.Created.UploadURL).To(ContainSubstring("X-Amz-Signature="))
+				g.Expect(snapshots.Created.UploadImage).To(Equal(controlplanev1.DefaultEtcdSnapshotUploadImage))
+				g.Expect(result.RequeueAfter).To(Equal(etcdSnapshotPollInterval))
+			} else {
+				g.Expect(snapshots.Created).To(BeNil())
+			}
+
+			if !tt.wantRecorded {
+				g.Expect(kcp.Status.LastEtcdSnapshot).To(Equal(tt.lastSnapshot))
+				return
+			}
+			g.Expect(kcp.Status.LastEtcdSnapshot).To(Equal(&controlplanev1.EtcdSnapshotStatus{
+				Time: tt.job.CreationTimestamp,
+				Key:  "test/kcp/etcd-snapshot-20200601T120000Z.db",
+				Size: 8,
+			}))
+			g.Expect(result.RequeueAfter).To(BeNumerically("~", 59*time.Minute, time.Minute))
+		})
+	}
+}
+
+func TestReconcileEtcdSnapshotDisabled(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &KubeadmControlPlaneReconciler{
+		Client:   newFakeClient(g),
+		Log:      klogr.New(),
+		recorder: record.NewFakeRecorder(32),
+	}
+	kcp := &controlplanev1.KubeadmControlPlane{}
+
+	snapshots := &fakeEtcdSnapshots{}
+	result, err := r.reconcileEtcdSnapshot(context.TODO(), kcp, fakeWorkloadCluster{EtcdSnapshots: snapshots})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.IsZero()).To(BeTrue())
+	g.Expect(snapshots.Created).To(BeNil())
+	g.Expect(conditions.Has(kcp, controlplanev1.EtcdSnapshotSucceededCondition)).To(BeFalse())
+}
+
+func TestEtcdSnapshotKey(t *testing.T) {
+	g := NewWithT(t)
+
+	kcp := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "kcp"},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{
+			EtcdSnapshots: &controlplanev1.EtcdSnapshots{},
+		},
+	}
+	now := time.Date(2020, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	g.Expect(etcdSnapshotKey(kcp, now)).To(Equal("test/kcp/etcd-snapshot-20200601T123000Z.db"))
+
+	kcp.Spec.EtcdSnapshots.Store.Prefix = "backups/"
+	g.Expect(etcdSnapshotKey(kcp, now)).To(Equal("backups/etcd-snapshot-20200601T123000Z.db"))
+}
+
+func TestSetEtcdRestore(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          *bootstrapv1.KubeadmConfigSpec
+		wantNodeName  string
+		wantNodeIP    string
+		wantIgnoreErr []string
+	}{
+		{
+			name:          "detects the node name and IP",
+			spec:          &bootstrapv1.KubeadmConfigSpec{},
+			wantNodeName:  "NODE_NAME=$(hostname",
+			wantNodeIP:    "NODE_IP=$(ip -o -4 route get",
+			wantIgnoreErr: []string{etcdDataDirPreflightError},
+		},
+		{
+			name: "uses the node name and IP from the init configuration",
+			spec: &bootstrapv1.KubeadmConfigSpec{
+				InitConfiguration: &kubeadmv1.InitConfiguration{
+					NodeRegistration: kubeadmv1.NodeRegistrationOptions{
+						Name:                  "{{ ds.meta_data.local_hostname }}",
+						IgnorePreflightErrors: []string{"Swap"},
+					},
+					LocalAPIEndpoint: kubeadmv1.APIEndpoint{AdvertiseAddress: "10.0.0.10"},
+				},
+			},
+			wantNodeName:  "NODE_NAME='{{ ds.meta_data.local_hostname }}'",
+			wantNodeIP:    "NODE_IP='10.0.0.10'",
+			wantIgnoreErr: []string{"Swap", etcdDataDirPreflightError},
+		},
+		{
+			name: "does not duplicate the ignored preflight error",
+			spec: &bootstrapv1.KubeadmConfigSpec{
+				InitConfiguration: &kubeadmv1.InitConfiguration{
+					NodeRegistration: kubeadmv1.NodeRegistrationOptions{
+						IgnorePreflightErrors: []string{etcdDataDirPreflightError},
+					},
+				},
+			},
+			wantNodeName:  "NODE_NAME=$(hostname",
+			wantNodeIP:    "NODE_IP=$(ip -o -4 route get",
+			wantIgnoreErr: []string{etcdDataDirPreflightError},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			setEtcdRestore(tt.spec, "kcp-etcd-restore", "k8s.gcr.io/etcd:3.4.3-0")
+
+			g.Expect(tt.spec.PreKubeadmCommands).To(Equal([]string{etcdRestoreScriptPath}))
+			g.Expect(tt.spec.Files).To(HaveLen(2))
+			g.Expect(tt.spec.Files[0].Path).To(Equal(etcdRestoreURLPath))
+			g.Expect(tt.spec.Files[0].Content).To(BeEmpty())
+			g.Expect(tt.spec.Files[0].ContentFrom).To(Equal(&bootstrapv1.FileSource{
+				Secret: bootstrapv1.SecretFileSource{Name: "kcp-etcd-restore", Key: etcdRestoreURLSecretKey},
+			}))
+			g.Expect(tt.spec.Files[1].Path).To(Equal(etcdRestoreScriptPath))
+			script := tt.spec.Files[1].Content
+			g.Expect(script).To(ContainSubstring("SNAPSHOT_URL_FILE='" + etcdRestoreURLPath + "'"))
+			g.Expect(script).To(ContainSubstring("ETCD_IMAGE='k8s.gcr.io/etcd:3.4.3-0'"))
+			g.Expect(script).To(ContainSubstring(tt.wantNodeName))
+			g.Expect(script).To(ContainSubstring(tt.wantNodeIP))
+			g.Expect(tt.spec.InitConfiguration.NodeRegistration.IgnorePreflightErrors).To(Equal(tt.wantIgnoreErr))
+		})
+	}
+}
+
+func TestReconcileEtcdRestore(t *testing.T) {
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "snapshot-credentials"},
+		Data: map[string][]byte{
+			objectstore.AccessKeyIDKey:     []byte("id"),
+			objectstore.SecretAccessKeyKey: []byte("secret"),
+		},
+	}
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "cluster"}}
+	machine := func(bootstrapped bool) *clusterv1.Machine {
+		m := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "machine"}}
+		if bootstrapped {
+			m.Spec.Bootstrap.DataSecretName = pointer.StringPtr("machine")
+		}
+		return m
+	}
+	restoreSecret := func(expiresIn time.Duration) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "test",
+				Name:        "kcp-etcd-restore",
+				Annotations: map[string]string{etcdRestoreURLExpiresAtAnnotation: time.Now().Add(expiresIn).UTC().Format(time.RFC3339)},
+			},
+			Data: map[string][]byte{etcdRestoreURLSecretKey: []byte("https://store/backups/old")},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		initialized bool
+		machines    []*clusterv1.Machine
+		secret      *corev1.Secret
+		wantURL     string
+		wantFresh   bool
+		wantDeleted bool
+	}{
+		{
+			name: "does not create the Secret before the first machine",
+		},
+		{
+			name:      "creates the Secret if missing while the first machine is not bootstrapped",
+			machines:  []*clusterv1.Machine{machine(false)},
+			wantFresh: true,
+		},
+		{
+			name:     "keeps a valid URL",
+			machines: []*clusterv1.Machine{machine(false)},
+			secret:   restoreSecret(20 * time.Hour),
+			wantURL:  "https://store/backups/old",
+		},
+		{
+			name:      "regenerates a URL expiring soon",
+			machines:  []*clusterv1.Machine{machine(false)},
+			secret:    restoreSecret(time.Hour),
+			wantFresh: true,
+		},
+		{
+			name:     "does not regenerate the URL once the first machine is bootstrapped",
+			machines: []*clusterv1.Machine{machine(true)},
+			secret:   restoreSecret(time.Hour),
+			wantURL:  "https://store/backups/old",
+		},
+		{
+			name:        "deletes the Secret once the control plane is initialized",
+			initialized: true,
+			machines:    []*clusterv1.Machine{machine(true)},
+			secret:      restoreSecret(time.Hour),
+			wantDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			kcp := &controlplanev1.KubeadmControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "kcp"},
+				Spec: controlplanev1.KubeadmControlPlaneSpec{
+					EtcdRestore: &controlplanev1.EtcdRestore{
+						Store: controlplanev1.EtcdSnapshotStore{
+							Endpoint:          "https://store",
+							Bucket:            "backups",
+							CredentialsSecret: &corev1.LocalObjectReference{Name: credentials.Name},
+						},
+						Key:   "test/kcp/etcd-snapshot-20200601T120000Z.db",
+						Image: "k8s.gcr.io/etcd:3.4.3-0",
+					},
+				},
+				Status: controlplanev1.KubeadmControlPlaneStatus{Initialized: tt.initialized},
+			}
+			objs := []runtime.Object{credentials.DeepCopy()}
+			if tt.secret != nil {
+				objs = append(objs, tt.secret)
+			}
+			r := &KubeadmControlPlaneReconciler{
+				Client:   newFakeClient(g, objs...),
+				Log:      klogr.New(),
+				recorder: record.NewFakeRecorder(32),
+			}
+			controlPlane := &internal.ControlPlane{KCP: kcp, Cluster: cluster, Machines: internal.NewFilterableMachineCollection(tt.machines...)}
+
+			g.Expect(r.reconcileEtcdRestore(context.TODO(), cluster, kcp, controlPlane)).To(Succeed())
+
+			s := &corev1.Secret{}
+			err := r.Client.Get(context.TODO(), client.ObjectKey{Namespace: "test", Name: "kcp-etcd-restore"}, s)
+			switch {
+			case tt.wantDeleted || (tt.secret == nil && !tt.wantFresh):
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			case tt.wantFresh:
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(string(s.Data[etcdRestoreURLSecretKey])).To(HavePrefix("https://store/backups/test/kcp/etcd-snapshot-20200601T120000Z.db?"))
+				g.Expect(string(s.Data[etcdRestoreURLSecretKey])).To(ContainSubstring("X-Amz-Signature="))
+				expiresAt, err := time.Parse(time.RFC3339, s.Annotations[etcdRestoreURLExpiresAtAnnotation])
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(time.Until(expiresAt)).To(BeNumerically("~", etcdRestoreURLExpiration, time.Minute))
+				if tt.secret == nil {
+					g.Expect(s.Labels).To(HaveKeyWithValue(clusterv1.ClusterLabelName, "cluster"))
+					g.Expect(s.OwnerReferences).To(HaveLen(1))
+				}
+			default:
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(string(s.Data[etcdRestoreURLSecretKey])).To(Equal(tt.wantURL))
+			}
+		})
+	}
+}
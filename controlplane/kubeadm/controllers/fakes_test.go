@@ -21,6 +21,7 @@ import (
 	"errors"
 
 	"github.com/blang/semver"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
@@ -73,17 +74,60 @@ func (f *fakeManagementCluster) TargetClusterEtcdIsHealthy(_ context.Context, _
 
 type fakeWorkloadCluster struct {
 	*internal.Workload
-	Status internal.ClusterStatus
+	Status        internal.ClusterStatus
+	EtcdHealth    internal.HealthCheckResult
+	EtcdSnapshots *fakeEtcdSnapshots
+}
+
+// fakeEtcdSnapshots records the etcd snapshot Jobs of a fakeWorkloadCluster.
+type fakeEtcdSnapshots struct {
+	Job       *batchv1.Job
+	Created   *internal.EtcdSnapshotJobInput
+	Deleted   bool
+	CreateErr error
+}
+
+func (f fakeWorkloadCluster) EtcdIsHealthy(_ context.Context) (internal.HealthCheckResult, error) {
+	return f.EtcdHealth, nil
 }
 
 func (f fakeWorkloadCluster) ForwardEtcdLeadership(_ context.Context, _ *clusterv1.Machine, _ *clusterv1.Machine) error {
 	return nil
 }
 
+func (f fakeWorkloadCluster) RemoveEtcdMemberForMachine(_ context.Context, _ *clusterv1.Machine) error {
+	return nil
+}
+
+func (f fakeWorkloadCluster) RemoveMachineFromKubeadmConfigMap(_ context.Context, _ *clusterv1.Machine) error {
+	return nil
+}
+
 func (f fakeWorkloadCluster) ReconcileEtcdMembers(ctx context.Context) error {
 	return nil
 }
 
+func (f fakeWorkloadCluster) GetEtcdSnapshotJob(_ context.Context) (*batchv1.Job, error) {
+	if f.EtcdSnapshots == nil {
+		return nil, nil
+	}
+	return f.EtcdSnapshots.Job, nil
+}
+
+func (f fakeWorkloadCluster) CreateEtcdSnapshotJob(_ context.Context, input internal.EtcdSnapshotJobInput) error {
+	if f.EtcdSnapshots.CreateErr != nil {
+		return f.EtcdSnapshots.CreateErr
+	}
+	f.EtcdSnapshots.Created = &input
+	return nil
+}
+
+func (f fakeWorkloadCluster) DeleteEtcdSnapshotJob(_ context.Context) error {
+	f.EtcdSnapshots.Job = nil
+	f.EtcdSnapshots.Deleted = true
+	return nil
+}
+
 func (f fakeWorkloadCluster) ClusterStatus(_ context.Context) (internal.ClusterStatus, error) {
 	return f.Status, nil
 }
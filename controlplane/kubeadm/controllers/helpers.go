@@ -73,6 +73,20 @@ func (r *KubeadmControlPlaneReconciler) reconcileKubeconfig(ctx context.Context,
 		return nil
 	}
 
+	// Regenerate the kubeconfig if the control plane endpoint was migrated or the cluster CA was rotated.
+	needsRefresh, err := kubeconfig.NeedsRefresh(ctx, r.Client, configSecret, endpoint.String())
+	if err != nil && !errors.Is(err, kubeconfig.ErrDependentCertificateNotFound) {
+		return errors.Wrap(err, "failed to check kubeconfig")
+	}
+	if needsRefresh {
+		r.Log.Info("refreshing kubeconfig secret")
+		if err := kubeconfig.RefreshSecret(ctx, r.Client, configSecret, endpoint.String()); err != nil {
+			return errors.Wrap(err, "failed to refresh kubeconfig")
+		}
+		// The client certificate of the refreshed kubeconfig does not need rotation.
+		return nil
+	}
+
 	needsRotation, err := kubeconfig.NeedsClientCertRotation(configSecret, certs.ClientCertificateRenewalDuration)
 	if err != nil {
 		return err
@@ -127,10 +141,10 @@ func (r *KubeadmControlPlaneReconciler) cloneConfigsAndGenerateMachine(ctx conte
 		UID:        kcp.UID,
 	}
 
-	// Clone the infrastructure template
+	// Clone the infrastructure template of the failure domain
 	infraRef, err := external.CloneTemplate(ctx, &external.CloneTemplateInput{
 		Client:      r.Client,
-		TemplateRef: &kcp.Spec.InfrastructureTemplate,
+		TemplateRef: kcp.Spec.InfrastructureTemplateForFailureDomain(failureDomain),
 		Namespace:   kcp.Namespace,
 		OwnerRef:    infraCloneOwner,
 		ClusterName: cluster.Name,
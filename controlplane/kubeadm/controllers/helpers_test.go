@@ -202,6 +202,60 @@ func TestKubeadmControlPlaneReconciler_reconcileKubeconfig(t *testing.T) {
 	g.Expect(kubeconfigSecret.Labels).To(HaveKeyWithValue(clusterv1.ClusterLabelName, clusterName.Name))
 }
 
+func TestReconcileKubeconfigRefreshesMigratedEndpoint(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+	}
+
+	kcp := &controlplanev1.KubeadmControlPlane{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "KubeadmControlPlane",
+			APIVersion: controlplanev1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{
+			Version: "v1.16.6",
+		},
+	}
+	clusterName := util.ObjectKey(cluster)
+	oldEndpoint := clusterv1.APIEndpoint{Host: "test.local", Port: 8443}
+	endpoint := clusterv1.APIEndpoint{Host: "new.test.local", Port: 443}
+
+	clusterCerts := secret.NewCertificatesForInitialControlPlane(&kubeadmv1.ClusterConfiguration{})
+	g.Expect(clusterCerts.Generate()).To(Succeed())
+	caCert := clusterCerts.GetByPurpose(secret.ClusterCA)
+	existingCACertSecret := caCert.AsSecret(
+		client.ObjectKey{Namespace: "test", Name: "foo"},
+		*metav1.NewControllerRef(kcp, controlplanev1.GroupVersion.WithKind("KubeadmControlPlane")),
+	)
+
+	fakeClient := newFakeClient(g, kcp.DeepCopy(), existingCACertSecret.DeepCopy())
+	r := &KubeadmControlPlaneReconciler{
+		Client:   fakeClient,
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+	// Creates the kubeconfig for the old endpoint, then reconciles again after the endpoint was migrated.
+	g.Expect(r.reconcileKubeconfig(context.Background(), clusterName, oldEndpoint, kcp)).To(Succeed())
+	g.Expect(r.reconcileKubeconfig(context.Background(), clusterName, endpoint, kcp)).To(Succeed())
+
+	kubeconfigSecret := &corev1.Secret{}
+	secretName := client.ObjectKey{
+		Namespace: "test",
+		Name:      secret.Name(clusterName.Name, secret.Kubeconfig),
+	}
+	g.Expect(r.Client.Get(context.Background(), secretName, kubeconfigSecret)).To(Succeed())
+	g.Expect(kubeconfig.NeedsRefresh(context.Background(), r.Client, kubeconfigSecret, endpoint.String())).To(BeFalse())
+}
+
 func TestCloneConfigsAndGenerateMachine(t *testing.T) {
 	g := NewWithT(t)
 
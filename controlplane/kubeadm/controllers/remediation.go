@@ -0,0 +1,151 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal/machinefilters"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// reconcileUnhealthyMachines remediates the control plane machines marked as unhealthy by a MachineHealthCheck,
+// i.e. with the OwnerRemediated condition set to False, by deleting them one at a time; the deleted machines are then
+// replaced by the scale up. A machine is remediated only if the control plane can tolerate losing it, and in particular
+// if the remaining etcd members can preserve quorum. Machines annotated to skip remediation are never remediated.
+func (r *KubeadmControlPlaneReconciler) reconcileUnhealthyMachines(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, controlPlane *internal.ControlPlane) (ctrl.Result, error) {
+	logger := controlPlane.Logger()
+
+	// Nb. the Machine could have been annotated after being marked for remediation.
+	isRemediationSkipped := func(m *clusterv1.Machine) bool { return annotations.IsRemediationSkipped(m) }
+	unhealthyMachines := controlPlane.Machines.Filter(machinefilters.HasUnhealthyCondition, machinefilters.Not(machinefilters.HasDeletionTimestamp), machinefilters.Not(isRemediationSkipped))
+	if unhealthyMachines.Len() == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	// Remediate the oldest unhealthy machine first; the other ones will be remediated once it has been replaced.
+	machineToBeRemediated := unhealthyMachines.Oldest()
+	logger = logger.WithValues("machine", machineToBeRemediated.Name)
+
+	desiredReplicas := int(*kcp.Spec.Replicas)
+	switch {
+	case desiredReplicas <= 1:
+		logger.Info("Unable to remediate control plane machine, the control plane has a single replica")
+		r.recorder.Eventf(kcp, corev1.EventTypeWarning, "RemediationRestricted",
+			"Unable to remediate control plane Machine %s, the control plane has a single replica", machineToBeRemediated.Name)
+		return ctrl.Result{}, nil
+	case controlPlane.HasDeletingMachine():
+		logger.V(2).Info("Waiting for control plane machine deletion to complete before remediating unhealthy machines")
+		return ctrl.Result{RequeueAfter: deleteRequeueAfter}, nil
+	case controlPlane.Machines.Len() < desiredReplicas:
+		// Let the scale up replace the machines already remediated before remediating other machines.
+		logger.V(2).Info("Waiting for the control plane to scale up before remediating unhealthy machines", "Desired", desiredReplicas, "Existing", controlPlane.Machines.Len())
+		return ctrl.Result{}, nil
+	}
+
+	workloadCluster, err := r.managementCluster.GetWorkloadCluster(ctx, util.ObjectKey(cluster))
+	if err != nil {
+		logger.Error(err, "Failed to create client to workload cluster")
+		return ctrl.Result{}, errors.Wrapf(err, "failed to create client to workload cluster")
+	}
+
+	if isEtcdManaged(kcp) {
+		etcdHealth, err := workloadCluster.EtcdIsHealthy(ctx)
+		if etcdHealth == nil {
+			logger.V(2).Info("Waiting for etcd health check to remediate unhealthy machines", "cause", err)
+			return ctrl.Result{RequeueAfter: healthCheckFailedRequeueAfter}, nil
+		}
+		if !canSafelyRemoveEtcdMember(controlPlane, machineToBeRemediated, etcdHealth) {
+			logger.Info("Unable to remediate control plane machine, removing its etcd member would break the etcd quorum")
+			r.recorder.Eventf(kcp, corev1.EventTypeWarning, "RemediationRestricted",
+				"Unable to remediate control plane Machine %s, removing its etcd member would break the etcd quorum", machineToBeRemediated.Name)
+			return ctrl.Result{RequeueAfter: healthCheckFailedRequeueAfter}, nil
+		}
+
+		// If etcd leadership is on the machine to be remediated, move it to the newest healthy member.
+		etcdLeaderCandidate := controlPlane.Machines.Filter(machinefilters.Not(machinefilters.HasUnhealthyCondition)).Newest()
+		if err := workloadCluster.ForwardEtcdLeadership(ctx, machineToBeRemediated, etcdLeaderCandidate); err != nil {
+			logger.Error(err, "Failed to move leadership to a healthy candidate machine")
+			return ctrl.Result{}, err
+		}
+		if err := workloadCluster.RemoveEtcdMemberForMachine(ctx, machineToBeRemediated); err != nil {
+			logger.Error(err, "Failed to remove etcd member for machine")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := workloadCluster.RemoveMachineFromKubeadmConfigMap(ctx, machineToBeRemediated); err != nil {
+		logger.Error(err, "Failed to remove machine from kubeadm ConfigMap")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.Client.Delete(ctx, machineToBeRemediated); err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "Failed to delete unhealthy control plane machine")
+		r.recorder.Eventf(kcp, corev1.EventTypeWarning, "FailedRemediation",
+			"Failed to delete unhealthy control plane Machine %s for cluster %s/%s control plane: %v", machineToBeRemediated.Name, cluster.Namespace, cluster.Name, err)
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Remediated unhealthy control plane machine")
+	r.recorder.Eventf(kcp, corev1.EventTypeNormal, "SuccessfulRemediation",
+		"Deleted unhealthy control plane Machine %s for cluster %s/%s control plane", machineToBeRemediated.Name, cluster.Namespace, cluster.Name)
+
+	// Requeue the control plane, so the remediated machine is replaced by the scale up.
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// canSafelyRemoveEtcdMember returns true if the etcd cluster preserves quorum after removing the member of the given
+// machine, i.e. if the healthy members hosted on the other machines are a majority of the remaining members.
+// Members hosted on machines marked as unhealthy are not counted as healthy, because they are going to be remediated too.
+func canSafelyRemoveEtcdMember(controlPlane *internal.ControlPlane, machineToBeRemediated *clusterv1.Machine, etcdHealth internal.HealthCheckResult) bool {
+	unhealthyNodes := map[string]bool{}
+	for _, m := range controlPlane.Machines.Filter(machinefilters.HasUnhealthyCondition) {
+		if m.Status.NodeRef != nil {
+			unhealthyNodes[m.Status.NodeRef.Name] = true
+		}
+	}
+
+	remainingMembers, healthyMembers := 0, 0
+	for node, err := range etcdHealth {
+		if machineToBeRemediated.Status.NodeRef != nil && machineToBeRemediated.Status.NodeRef.Name == node {
+			continue
+		}
+		remainingMembers++
+		if err == nil && !unhealthyNodes[node] {
+			healthyMembers++
+		}
+	}
+
+	if remainingMembers == 0 {
+		return false
+	}
+	return healthyMembers >= remainingMembers/2+1
+}
+
+// isEtcdManaged returns true if the etcd cluster is hosted on the control plane machines.
+func isEtcdManaged(kcp *controlplanev1.KubeadmControlPlane) bool {
+	clusterConfiguration := kcp.Spec.KubeadmConfigSpec.ClusterConfiguration
+	return clusterConfiguration == nil || clusterConfiguration.Etcd.External == nil
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	kubeadmv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestKubeadmControlPlaneReconciler_reconcileUnhealthyMachines(t *testing.T) {
+	startDate := time.Date(2000, 1, 1, 1, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		replicas       int32
+		machines       []*clusterv1.Machine
+		etcdHealth     internal.HealthCheckResult
+		externalEtcd   bool
+		wantRequeue    bool
+		wantRemediated string
+	}{
+		{
+			name:     "does nothing if there are no unhealthy machines",
+			replicas: 3,
+			machines: []*clusterv1.Machine{
+				machine("m1", withNodeRef("n1")),
+				machine("m2", withNodeRef("n2")),
+				machine("m3", withNodeRef("n3")),
+			},
+			etcdHealth: internal.HealthCheckResult{"n1": nil, "n2": nil, "n3": nil},
+		},
+		{
+			name:     "remediates an unhealthy machine preserving the etcd quorum",
+			replicas: 3,
+			machines: []*clusterv1.Machine{
+				machine("m1", withNodeRef("n1"), withUnhealthyCondition()),
+				machine("m2", withNodeRef("n2")),
+				machine("m3", withNodeRef("n3")),
+			},
+			etcdHealth:     internal.HealthCheckResult{"n1": errors.New("unhealthy"), "n2": nil, "n3": nil},
+			wantRequeue:    true,
+			wantRemediated: "m1",
+		},
+		{
+			name:     "remediates the oldest unhealthy machine first",
+			replicas: 5,
+			machines: []*clusterv1.Machine{
+				machine("m1", withNodeRef("n1"), withUnhealthyCondition(), withTimestamp(startDate)),
+				machine("m2", withNodeRef("n2"), withUnhealthyCondition(), withTimestamp(startDate.Add(-time.Hour))),
+				machine("m3", withNodeRef("n3"), withTimestamp(startDate)),
+				machine("m4", withNodeRef("n4"), withTimestamp(startDate)),
+				machine("m5", withNodeRef("n5"), withTimestamp(startDate)),
+			},
+			etcdHealth:     internal.HealthCheckResult{"n1": nil, "n2": nil, "n3": nil, "n4": nil, "n5": nil},
+			wantRequeue:    true,
+			wantRemediated: "m2",
+		},
+		{
+			name:     "does not remediate the machines annotated to skip remediation",
+			replicas: 3,
+			machines: []*clusterv1.Machine{
+				machine("m1", withNodeRef("n1"), withUnhealthyCondition(), withAnnotation(clusterv1.SkipRemediationAnnotation)),
+				machine("m2", withNodeRef("n2"), withUnhealthyCondition(), withAnnotation(clusterv1.ProtectMachineAnnotation)),
+				machine("m3", withNodeRef("n3")),
+			},
+			etcdHealth: internal.HealthCheckResult{"n1": errors.New("unhealthy"), "n2": nil, "n3": nil},
+		},
+		{
+			name:     "does not remediate if the control plane has a single replica",
+			replicas: 1,
+			machines: []*clusterv1.Machine{
+				machine("m1", withNodeRef("n1"), withUnhealthyCondition()),
+			},
+			etcdHealth: internal.HealthCheckResult{"n1": errors.New("unhealthy")},
+		},
+		{
+			name:     "does not remediate while the control plane is scaling up",
+			replicas: 3,
+			machines: []*clusterv1.Machine{
+				machine("m1", withNodeRef("n1"), withUnhealthyCondition()),
+				machine("m2", withNodeRef("n2")),
+			},
+			etcdHealth: internal.HealthCheckResult{"n1": nil, "n2": nil},
+		},
+		{
+			name:     "does not remediate if removing the etcd member breaks the etcd quorum",
+			replicas: 3,
+			machines: []*clusterv1.Machine{
+				machine("m1", withNodeRef("n1"), withUnhealthyCondition()),
+				machine("m2", withNodeRef("n2")),
+				machine("m3", withNodeRef("n3")),
+			},
+			etcdHealth:  internal.HealthCheckResult{"n1": nil, "n2": nil, "n3": errors.New("unhealthy")},
+			wantRequeue: true,
+		},
+		{
+			name:     "remediates without checking etcd if the etcd cluster is external",
+			replicas: 3,
+			machines: []*clusterv1.Machine{
+				machine("m1", withNodeRef("n1"), withUnhealthyCondition()),
+				machine("m2", withNodeRef("n2")),
+				machine("m3", withNodeRef("n3")),
+			},
+			externalEtcd:   true,
+			wantRequeue:    true,
+			wantRemediated: "m1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := &clusterv1.Cluster{}
+			kcp := &controlplanev1.KubeadmControlPlane{
+				Spec: controlplanev1.KubeadmControlPlaneSpec{
+					Replicas: pointer.Int32Ptr(tt.replicas),
+				},
+			}
+			if tt.externalEtcd {
+				kcp.Spec.KubeadmConfigSpec.ClusterConfiguration = &kubeadmv1.ClusterConfiguration{
+					Etcd: kubeadmv1.Etcd{External: &kubeadmv1.ExternalEtcd{}},
+				}
+			}
+
+			objs := []runtime.Object{}
+			for _, m := range tt.machines {
+				objs = append(objs, m.DeepCopy())
+			}
+			fakeClient := newFakeClient(g, objs...)
+
+			r := &KubeadmControlPlaneReconciler{
+				Log:      log.Log,
+				recorder: record.NewFakeRecorder(32),
+				Client:   fakeClient,
+				managementCluster: &fakeManagementCluster{
+					Workload: fakeWorkloadCluster{EtcdHealth: tt.etcdHealth},
+				},
+			}
+			controlPlane := &internal.ControlPlane{
+				KCP:      kcp,
+				Cluster:  cluster,
+				Machines: internal.NewFilterableMachineCollection(tt.machines...),
+			}
+
+			result, err := r.reconcileUnhealthyMachines(context.Background(), cluster, kcp, controlPlane)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(result.IsZero()).To(Equal(!tt.wantRequeue))
+
+			for _, m := range tt.machines {
+				err := fakeClient.Get(context.Background(), util.ObjectKey(m), &clusterv1.Machine{})
+				if m.Name == tt.wantRemediated {
+					g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+					continue
+				}
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestCanSafelyRemoveEtcdMember(t *testing.T) {
+	tests := []struct {
+		name       string
+		machines   []*clusterv1.Machine
+		etcdHealth internal.HealthCheckResult
+		want       bool
+	}{
+		{
+			name: "allows removing an unhealthy member of a three members cluster",
+			machines: []*clusterv1.Machine{
+				machine("m1", withNodeRef("n1"), withUnhealthyCondition()),
+				machine("m2", withNodeRef("n2")),
+				machine("m3", withNodeRef("n3")),
+			},
+			etcdHealth: internal.HealthCheckResult{"n1": errors.New("unhealthy"), "n2": nil, "n3": nil},
+			want:       true,
+		},
+		{
+			name: "does not allow removing a member if another member is unhealthy",
+			machines: []*clusterv1.Machine{
+				machine("m1", withNodeRef("n1"), withUnhealthyCondition()),
+				machine("m2", withNodeRef("n2")),
+				machine("m3", withNodeRef("n3")),
+			},
+			etcdHealth: internal.HealthCheckResult{"n1": nil, "n2": nil, "n3": errors.New("unhealthy")},
+			want:       false,
+		},
+		{
+			name: "does not count the members on machines to be remediated as healthy",
+			machines: []*clusterv1.Machine{
+				machine("m1", withNodeRef("n1"), withUnhealthyCondition()),
+				machine("m2", withNodeRef("n2"), withUnhealthyCondition()),
+				machine("m3", withNodeRef("n3")),
+			},
+			etcdHealth: internal.HealthCheckResult{"n1": nil, "n2": nil, "n3": nil},
+			want:       false,
+		},
+		{
+			name: "allows removing two members of a five members cluster, one at a time",
+			machines: []*clusterv1.Machine{
+				machine("m1", withNodeRef("n1"), withUnhealthyCondition()),
+				machine("m2", withNodeRef("n2"), withUnhealthyCondition()),
+				machine("m3", withNodeRef("n3")),
+				machine("m4", withNodeRef("n4")),
+				machine("m5", withNodeRef("n5")),
+			},
+			etcdHealth: internal.HealthCheckResult{"n1": errors.New("unhealthy"), "n2": errors.New("unhealthy"), "n3": nil, "n4": nil, "n5": nil},
+			want:       true,
+		},
+		{
+			name: "allows removing a machine without a node if the etcd cluster is healthy",
+			machines: []*clusterv1.Machine{
+				machine("m1", withUnhealthyCondition()),
+				machine("m2", withNodeRef("n2")),
+				machine("m3", withNodeRef("n3")),
+			},
+			etcdHealth: internal.HealthCheckResult{"n2": nil, "n3": nil},
+			want:       true,
+		},
+		{
+			name: "does not allow removing the last member",
+			machines: []*clusterv1.Machine{
+				machine("m1", withNodeRef("n1"), withUnhealthyCondition()),
+			},
+			etcdHealth: internal.HealthCheckResult{"n1": nil},
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			controlPlane := &internal.ControlPlane{
+				Machines: internal.NewFilterableMachineCollection(tt.machines...),
+			}
+			g.Expect(canSafelyRemoveEtcdMember(controlPlane, tt.machines[0], tt.etcdHealth)).To(Equal(tt.want))
+		})
+	}
+}
+
+func withNodeRef(name string) machineOpt {
+	return func(m *clusterv1.Machine) {
+		m.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: name}
+	}
+}
+
+func withAnnotation(key string) machineOpt {
+	return func(m *clusterv1.Machine) {
+		if m.Annotations == nil {
+			m.Annotations = map[string]string{}
+		}
+		m.Annotations[key] = ""
+	}
+}
+
+func withUnhealthyCondition() machineOpt {
+	return func(m *clusterv1.Machine) {
+		conditions.MarkFalse(m, clusterv1.MachineOwnerRemediatedCondition, clusterv1.WaitingForRemediation, clusterv1.ConditionSeverityWarning, "")
+	}
+}
@@ -49,6 +49,16 @@ func (r *KubeadmControlPlaneReconciler) initializeControlPlane(ctx context.Conte
 	}
 
 	bootstrapSpec := controlPlane.InitialControlPlaneConfig()
+	if kcp.Spec.EtcdRestore != nil {
+		if err := r.addEtcdRestore(ctx, cluster, kcp, bootstrapSpec); err != nil {
+			logger.Error(err, "Failed to configure the etcd restore for the initial control plane Machine")
+			return ctrl.Result{}, err
+		}
+	}
+	if err := setControlPlaneEndpoint(bootstrapSpec, kcp, cluster); err != nil {
+		logger.Error(err, "Failed to configure the control plane endpoint for the initial control plane Machine")
+		return ctrl.Result{}, err
+	}
 	fd := controlPlane.NextFailureDomainForScaleUp()
 	if err := r.cloneConfigsAndGenerateMachine(ctx, cluster, kcp, bootstrapSpec, fd); err != nil {
 		logger.Error(err, "Failed to create initial control plane Machine")
@@ -70,6 +80,10 @@ func (r *KubeadmControlPlaneReconciler) scaleUpControlPlane(ctx context.Context,
 
 	// Create the bootstrap configuration
 	bootstrapSpec := controlPlane.JoinControlPlaneConfig()
+	if err := setControlPlaneEndpoint(bootstrapSpec, kcp, cluster); err != nil {
+		logger.Error(err, "Failed to configure the control plane endpoint for the additional control plane Machine")
+		return ctrl.Result{}, err
+	}
 	fd := controlPlane.NextFailureDomainForScaleUp()
 	if err := r.cloneConfigsAndGenerateMachine(ctx, cluster, kcp, bootstrapSpec, fd); err != nil {
 		logger.Error(err, "Failed to create additional control plane Machine")
@@ -134,6 +148,7 @@ func (r *KubeadmControlPlaneReconciler) scaleDownControlPlane(
 	}
 
 	logger = logger.WithValues("machine", machineToDelete)
+
 	if err := r.Client.Delete(ctx, machineToDelete); err != nil && !apierrors.IsNotFound(err) {
 		logger.Error(err, "Failed to delete control plane machine")
 		r.recorder.Eventf(kcp, corev1.EventTypeWarning, "FailedScaleDown",
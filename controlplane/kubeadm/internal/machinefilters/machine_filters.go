@@ -140,6 +140,15 @@ func HasDeletionTimestamp(machine *clusterv1.Machine) bool {
 	return !machine.DeletionTimestamp.IsZero()
 }
 
+// HasUnhealthyCondition is a filter to find all machines marked for remediation by a MachineHealthCheck,
+// i.e. with the OwnerRemediated condition equals to False.
+func HasUnhealthyCondition(machine *clusterv1.Machine) bool {
+	if machine == nil {
+		return false
+	}
+	return conditions.IsFalse(machine, clusterv1.MachineOwnerRemediatedCondition)
+}
+
 // IsReady returns a filter to find all machines with the ReadyCondition equals to True.
 func IsReady() Func {
 	return func(machine *clusterv1.Machine) bool {
@@ -199,7 +208,7 @@ func MatchesKCPConfiguration(infraConfigs map[string]*unstructured.Unstructured,
 	)
 }
 
-// MatchesTemplateClonedFrom returns a filter to find all machines that match a given KCP infra template.
+// MatchesTemplateClonedFrom returns a filter to find all machines that match the KCP infra template of their failure domain.
 func MatchesTemplateClonedFrom(infraConfigs map[string]*unstructured.Unstructured, kcp *controlplanev1.KubeadmControlPlane) Func {
 	return func(machine *clusterv1.Machine) bool {
 		if machine == nil {
@@ -220,9 +229,11 @@ func MatchesTemplateClonedFrom(infraConfigs map[string]*unstructured.Unstructure
 			return true
 		}
 
-		// Check if the machine's infrastructure reference has been created from the current KCP infrastructure template.
-		if clonedFromName != kcp.Spec.InfrastructureTemplate.Name ||
-			clonedFromGroupKind != kcp.Spec.InfrastructureTemplate.GroupVersionKind().GroupKind().String() {
+		// Check if the machine's infrastructure reference has been created from the current KCP infrastructure template
+		// of the machine's failure domain.
+		template := kcp.Spec.InfrastructureTemplateForFailureDomain(machine.Spec.FailureDomain)
+		if clonedFromName != template.Name ||
+			clonedFromGroupKind != template.GroupVersionKind().GroupKind().String() {
 			return false
 		}
 		return true
@@ -317,7 +328,7 @@ func matchInitOrJoinConfiguration(machineConfigs map[string]*bootstrapv1.Kubeadm
 	kcpConfig := getAdjustedKcpConfig(kcp, machineConfig)
 
 	// cleanups all the fields that are not relevant for the comparison.
-	cleanupConfigFields(kcpConfig, machineConfig)
+	cleanupConfigFields(kcp, kcpConfig, machineConfig)
 
 	return reflect.DeepEqual(&machineConfig.Spec, kcpConfig)
 }
@@ -344,7 +355,7 @@ func getAdjustedKcpConfig(kcp *controlplanev1.KubeadmControlPlane, machineConfig
 }
 
 // cleanupConfigFields cleanups all the fields that are not relevant for the comparison.
-func cleanupConfigFields(kcpConfig *bootstrapv1.KubeadmConfigSpec, machineConfig *bootstrapv1.KubeadmConfig) {
+func cleanupConfigFields(kcp *controlplanev1.KubeadmControlPlane, kcpConfig *bootstrapv1.KubeadmConfigSpec, machineConfig *bootstrapv1.KubeadmConfig) {
 	// KCP ClusterConfiguration will only be compared with a machine's ClusterConfiguration annotation, so
 	// we are cleaning up from the reflect.DeepEqual comparison.
 	kcpConfig.ClusterConfiguration = nil
@@ -378,6 +389,21 @@ func cleanupConfigFields(kcpConfig *bootstrapv1.KubeadmConfigSpec, machineConfig
 		machineConfig.Spec.JoinConfiguration.NodeRegistration = emptyNodeRegistration
 	}
 
+	// Remove the kube-vip static pod manifest generated by KCP, which is not part of the KCP KubeadmConfigSpec.
+	// NOTE: the control plane endpoint can't be changed, so the manifest can't trigger rollout.
+	if endpoint := kcp.Spec.ControlPlaneEndpoint; endpoint != nil && endpoint.Type == controlplanev1.KubeVIPControlPlaneEndpoint {
+		files := []bootstrapv1.File{}
+		for _, file := range machineConfig.Spec.Files {
+			if file.Path != controlplanev1.KubeVIPManifestPath {
+				files = append(files, file)
+			}
+		}
+		if len(files) == 0 && kcpConfig.Files == nil {
+			files = nil
+		}
+		machineConfig.Spec.Files = files
+	}
+
 	// Clear up the TypeMeta information from the comparison.
 	// NOTE: KCP types don't carry this information.
 	if machineConfig.Spec.InitConfiguration != nil && kcpConfig.InitConfiguration != nil {
@@ -30,6 +30,7 @@ import (
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
 	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal/machinefilters"
+	"sigs.k8s.io/cluster-api/util/conditions"
 )
 
 func falseFilter(_ *clusterv1.Machine) bool {
@@ -101,6 +102,26 @@ func TestHasDeletionTimestamp(t *testing.T) {
 	})
 }
 
+func TestHasUnhealthyCondition(t *testing.T) {
+	t.Run("machine without OwnerRemediated condition returns false", func(t *testing.T) {
+		g := NewWithT(t)
+		m := &clusterv1.Machine{}
+		g.Expect(machinefilters.HasUnhealthyCondition(m)).To(BeFalse())
+	})
+	t.Run("machine with OwnerRemediated condition set to False returns true", func(t *testing.T) {
+		g := NewWithT(t)
+		m := &clusterv1.Machine{}
+		conditions.MarkFalse(m, clusterv1.MachineOwnerRemediatedCondition, clusterv1.WaitingForRemediation, clusterv1.ConditionSeverityWarning, "")
+		g.Expect(machinefilters.HasUnhealthyCondition(m)).To(BeTrue())
+	})
+	t.Run("machine with OwnerRemediated condition set to True returns false", func(t *testing.T) {
+		g := NewWithT(t)
+		m := &clusterv1.Machine{}
+		conditions.MarkTrue(m, clusterv1.MachineOwnerRemediatedCondition)
+		g.Expect(machinefilters.HasUnhealthyCondition(m)).To(BeFalse())
+	})
+}
+
 func TestShouldRolloutAfter(t *testing.T) {
 	reconciliationTime := metav1.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
 	t.Run("if the machine is nil it returns false", func(t *testing.T) {
@@ -344,3 +365,90 @@ func TestMatchesTemplateClonedFrom_WithClonedFromAnnotations(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchesTemplateClonedFrom_WithFailureDomainInfrastructureTemplates(t *testing.T) {
+	kcp := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+		},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{
+			InfrastructureTemplate: corev1.ObjectReference{
+				Kind:       "GenericMachineTemplate",
+				Namespace:  "default",
+				Name:       "infra-foo",
+				APIVersion: "generic.io/v1",
+			},
+			FailureDomainInfrastructureTemplates: []controlplanev1.FailureDomainInfrastructureTemplate{
+				{
+					FailureDomain: "zone-b",
+					InfrastructureTemplate: corev1.ObjectReference{
+						Kind:       "GenericMachineTemplate",
+						Namespace:  "default",
+						Name:       "infra-zone-b",
+						APIVersion: "generic.io/v1",
+					},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name          string
+		failureDomain *string
+		clonedFrom    string
+		expectMatch   bool
+	}{
+		{
+			name:        "returns true if a machine without failure domain is cloned from the default template",
+			clonedFrom:  "infra-foo",
+			expectMatch: true,
+		},
+		{
+			name:          "returns true if a machine in a failure domain without template is cloned from the default template",
+			failureDomain: pointer.StringPtr("zone-a"),
+			clonedFrom:    "infra-foo",
+			expectMatch:   true,
+		},
+		{
+			name:          "returns true if a machine is cloned from the template of its failure domain",
+			failureDomain: pointer.StringPtr("zone-b"),
+			clonedFrom:    "infra-zone-b",
+			expectMatch:   true,
+		},
+		{
+			name:          "returns false if a machine is cloned from the default template instead of the template of its failure domain",
+			failureDomain: pointer.StringPtr("zone-b"),
+			clonedFrom:    "infra-foo",
+			expectMatch:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			machine := &clusterv1.Machine{
+				Spec: clusterv1.MachineSpec{
+					FailureDomain: tt.failureDomain,
+				},
+			}
+			infraConfigs := map[string]*unstructured.Unstructured{
+				machine.Name: {
+					Object: map[string]interface{}{
+						"kind":       "InfrastructureMachine",
+						"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+						"metadata": map[string]interface{}{
+							"name":      "infra-config1",
+							"namespace": "default",
+							"annotations": map[string]interface{}{
+								clusterv1.TemplateClonedFromNameAnnotation:      tt.clonedFrom,
+								clusterv1.TemplateClonedFromGroupKindAnnotation: "GenericMachineTemplate.generic.io",
+							},
+						},
+					},
+				},
+			}
+			g.Expect(
+				machinefilters.MatchesTemplateClonedFrom(infraConfigs, kcp)(machine),
+			).To(Equal(tt.expectMatch))
+		})
+	}
+}
@@ -157,7 +157,7 @@ func TestCleanupConfigFields(t *testing.T) {
 				ClusterConfiguration: &kubeadmv1beta1.ClusterConfiguration{},
 			},
 		}
-		cleanupConfigFields(kcpConfig, machineConfig)
+		cleanupConfigFields(&controlplanev1.KubeadmControlPlane{}, kcpConfig, machineConfig)
 		g.Expect(kcpConfig.ClusterConfiguration).To(gomega.BeNil())
 		g.Expect(machineConfig.Spec.ClusterConfiguration).To(gomega.BeNil())
 	})
@@ -171,7 +171,7 @@ func TestCleanupConfigFields(t *testing.T) {
 				JoinConfiguration: &kubeadmv1beta1.JoinConfiguration{}, // Machine gets a default JoinConfiguration from CABPK
 			},
 		}
-		cleanupConfigFields(kcpConfig, machineConfig)
+		cleanupConfigFields(&controlplanev1.KubeadmControlPlane{}, kcpConfig, machineConfig)
 		g.Expect(kcpConfig.JoinConfiguration).To(gomega.BeNil())
 		g.Expect(machineConfig.Spec.JoinConfiguration).To(gomega.BeNil())
 	})
@@ -189,7 +189,7 @@ func TestCleanupConfigFields(t *testing.T) {
 				},
 			},
 		}
-		cleanupConfigFields(kcpConfig, machineConfig)
+		cleanupConfigFields(&controlplanev1.KubeadmControlPlane{}, kcpConfig, machineConfig)
 		g.Expect(kcpConfig.JoinConfiguration.Discovery).To(gomega.Equal(kubeadmv1beta1.Discovery{}))
 		g.Expect(machineConfig.Spec.JoinConfiguration.Discovery).To(gomega.Equal(kubeadmv1beta1.Discovery{}))
 	})
@@ -207,7 +207,7 @@ func TestCleanupConfigFields(t *testing.T) {
 				},
 			},
 		}
-		cleanupConfigFields(kcpConfig, machineConfig)
+		cleanupConfigFields(&controlplanev1.KubeadmControlPlane{}, kcpConfig, machineConfig)
 		g.Expect(kcpConfig.JoinConfiguration).ToNot(gomega.BeNil())
 		g.Expect(machineConfig.Spec.JoinConfiguration.ControlPlane).To(gomega.BeNil())
 	})
@@ -225,7 +225,7 @@ func TestCleanupConfigFields(t *testing.T) {
 				},
 			},
 		}
-		cleanupConfigFields(kcpConfig, machineConfig)
+		cleanupConfigFields(&controlplanev1.KubeadmControlPlane{}, kcpConfig, machineConfig)
 		g.Expect(kcpConfig.JoinConfiguration).ToNot(gomega.BeNil())
 		g.Expect(machineConfig.Spec.JoinConfiguration.NodeRegistration).To(gomega.Equal(kubeadmv1beta1.NodeRegistrationOptions{}))
 	})
@@ -244,7 +244,7 @@ func TestCleanupConfigFields(t *testing.T) {
 				},
 			},
 		}
-		cleanupConfigFields(kcpConfig, machineConfig)
+		cleanupConfigFields(&controlplanev1.KubeadmControlPlane{}, kcpConfig, machineConfig)
 		g.Expect(kcpConfig.InitConfiguration).ToNot(gomega.BeNil())
 		g.Expect(machineConfig.Spec.InitConfiguration.TypeMeta).To(gomega.Equal(metav1.TypeMeta{}))
 	})
@@ -263,10 +263,40 @@ func TestCleanupConfigFields(t *testing.T) {
 				},
 			},
 		}
-		cleanupConfigFields(kcpConfig, machineConfig)
+		cleanupConfigFields(&controlplanev1.KubeadmControlPlane{}, kcpConfig, machineConfig)
 		g.Expect(kcpConfig.JoinConfiguration).ToNot(gomega.BeNil())
 		g.Expect(machineConfig.Spec.JoinConfiguration.TypeMeta).To(gomega.Equal(metav1.TypeMeta{}))
 	})
+	t.Run("The kube-vip static pod manifest gets removed from MachineConfig because it is generated by KCP", func(t *testing.T) {
+		g := gomega.NewWithT(t)
+		kcp := &controlplanev1.KubeadmControlPlane{
+			Spec: controlplanev1.KubeadmControlPlaneSpec{
+				ControlPlaneEndpoint: &controlplanev1.ControlPlaneEndpointSpec{
+					Type: controlplanev1.KubeVIPControlPlaneEndpoint,
+				},
+			},
+		}
+		kcpConfig := &bootstrapv1.KubeadmConfigSpec{
+			Files: []bootstrapv1.File{{Path: "/etc/foo"}},
+		}
+		machineConfig := &bootstrapv1.KubeadmConfig{
+			Spec: bootstrapv1.KubeadmConfigSpec{
+				Files: []bootstrapv1.File{{Path: "/etc/foo"}, {Path: controlplanev1.KubeVIPManifestPath}},
+			},
+		}
+		cleanupConfigFields(kcp, kcpConfig, machineConfig)
+		g.Expect(machineConfig.Spec.Files).To(gomega.Equal(kcpConfig.Files))
+
+		// Files are nil in the machine config if not defined in KCP.
+		kcpConfig = &bootstrapv1.KubeadmConfigSpec{}
+		machineConfig = &bootstrapv1.KubeadmConfig{
+			Spec: bootstrapv1.KubeadmConfigSpec{
+				Files: []bootstrapv1.File{{Path: controlplanev1.KubeVIPManifestPath}},
+			},
+		}
+		cleanupConfigFields(kcp, kcpConfig, machineConfig)
+		g.Expect(machineConfig.Spec.Files).To(gomega.BeNil())
+	})
 }
 
 func TestMatchInitOrJoinConfiguration(t *testing.T) {
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package objectstore implements a client for S3 compatible object stores, used for storing etcd snapshots.
+package objectstore
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AccessKeyIDKey is the key of the access key ID in the credentials Secret.
+	AccessKeyIDKey = "AWS_ACCESS_KEY_ID"
+
+	// SecretAccessKeyKey is the key of the secret access key in the credentials Secret.
+	SecretAccessKeyKey = "AWS_SECRET_ACCESS_KEY"
+
+	// SessionTokenKey is the key of the optional session token in the credentials Secret.
+	SessionTokenKey = "AWS_SESSION_TOKEN"
+)
+
+// Credentials are the credentials used for signing requests; if empty, requests are sent anonymously.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// CredentialsFromSecretData returns the credentials stored in the data of a Secret.
+func CredentialsFromSecretData(data map[string][]byte) (Credentials, error) {
+	c := Credentials{
+		AccessKeyID:     string(data[AccessKeyIDKey]),
+		SecretAccessKey: string(data[SecretAccessKeyKey]),
+		SessionToken:    string(data[SessionTokenKey]),
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return Credentials{}, errors.Errorf("the %s and %s keys are required", AccessKeyIDKey, SecretAccessKeyKey)
+	}
+	return c, nil
+}
+
+// S3 is a client for a bucket of an S3 compatible object store.
+type S3 struct {
+	Bucket string
+
+	client *s3.S3
+}
+
+// NewS3 returns a client for the given bucket. If the endpoint is empty, the AWS S3 endpoint for the region is used;
+// custom endpoints, e.g. S3 compatible services, are used with path style URLs.
+func NewS3(endpoint, region, bucket string, c Credentials) (*S3, error) {
+	config := aws.NewConfig().
+		WithRegion(region).
+		WithHTTPClient(&http.Client{Timeout: 30 * time.Second}).
+		WithCredentials(credentials.AnonymousCredentials)
+	if c.AccessKeyID != "" {
+		config = config.WithCredentials(credentials.NewStaticCredentials(c.AccessKeyID, c.SecretAccessKey, c.SessionToken))
+	}
+	if endpoint != "" {
+		config = config.WithEndpoint(strings.TrimSuffix(endpoint, "/")).WithS3ForcePathStyle(true)
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the S3 client")
+	}
+	return &S3{
+		Bucket: bucket,
+		client: s3.New(sess),
+	}, nil
+}
+
+// Size returns the size of an object.
+func (s *S3) Size(ctx context.Context, key string) (int64, error) {
+	out, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get the object %q in bucket %q", key, s.Bucket)
+	}
+	return aws.Int64Value(out.ContentLength), nil
+}
+
+// PresignGet returns an URL for reading an object, valid for the given duration; the URL is not signed if there are
+// no credentials.
+func (s *S3) PresignGet(key string, expires time.Duration) (string, error) {
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	return s.presign(req, key, expires)
+}
+
+// PresignPut returns an URL for uploading an object, valid for the given duration, e.g. for uploading an object
+// from a workload cluster without sharing the credentials; the URL is not signed if there are no credentials.
+func (s *S3) PresignPut(key string, expires time.Duration) (string, error) {
+	req, _ := s.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	return s.presign(req, key, expires)
+}
+
+func (s *S3) presign(req *request.Request, key string, expires time.Duration) (string, error) {
+	u, err := req.Presign(expires)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to presign the URL of the object %q in bucket %q", key, s.Bucket)
+	}
+	return u, nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCredentialsFromSecretData(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := CredentialsFromSecretData(map[string][]byte{AccessKeyIDKey: []byte("id")})
+	g.Expect(err).To(HaveOccurred())
+
+	c, err := CredentialsFromSecretData(map[string][]byte{
+		AccessKeyIDKey:     []byte("id"),
+		SecretAccessKeyKey: []byte("secret"),
+		SessionTokenKey:    []byte("token"),
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c).To(Equal(Credentials{AccessKeyID: "id", SecretAccessKey: "secret", SessionToken: "token"}))
+}
+
+func TestS3Size(t *testing.T) {
+	tests := []struct {
+		name        string
+		credentials Credentials
+		status      int
+		wantSigned  bool
+		wantErr     bool
+	}{
+		{
+			name:        "returns the size of a signed request",
+			credentials: Credentials{AccessKeyID: "id", SecretAccessKey: "secret", SessionToken: "token"},
+			status:      http.StatusOK,
+			wantSigned:  true,
+		},
+		{
+			name:   "returns the size of an anonymous request",
+			status: http.StatusOK,
+		},
+		{
+			name:        "fails if the object does not exist",
+			credentials: Credentials{AccessKeyID: "id", SecretAccessKey: "secret"},
+			status:      http.StatusNotFound,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var gotReq *http.Request
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotReq = r
+				w.Header().Set("Content-Length", "8")
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			s, err := NewS3(server.URL+"/", "us-east-1", "backups", tt.credentials)
+			g.Expect(err).NotTo(HaveOccurred())
+			size, err := s.Size(context.Background(), "ns/cluster/etcd-snapshot.db")
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(size).To(Equal(int64(8)))
+
+			g.Expect(gotReq.Method).To(Equal(http.MethodHead))
+			g.Expect(gotReq.URL.Path).To(Equal("/backups/ns/cluster/etcd-snapshot.db"))
+			if tt.wantSigned {
+				g.Expect(gotReq.Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=id/"))
+				g.Expect(gotReq.Header.Get("X-Amz-Security-Token")).To(Equal("token"))
+			} else {
+				g.Expect(gotReq.Header.Get("Authorization")).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestS3PresignGet(t *testing.T) {
+	g := NewWithT(t)
+
+	s, err := NewS3("", "eu-west-1", "backups", Credentials{AccessKeyID: "id", SecretAccessKey: "secret"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	got, err := s.PresignGet("ns/cluster/etcd-snapshot.db", time.Hour)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	u, err := url.Parse(got)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(u.Host).To(Equal("backups.s3.eu-west-1.amazonaws.com"))
+	g.Expect(u.Path).To(Equal("/ns/cluster/etcd-snapshot.db"))
+	g.Expect(u.Query().Get("X-Amz-Credential")).To(HavePrefix("id/"))
+	g.Expect(u.Query().Get("X-Amz-Credential")).To(HaveSuffix("/eu-west-1/s3/aws4_request"))
+	g.Expect(u.Query().Get("X-Amz-Expires")).To(Equal("3600"))
+	g.Expect(u.Query().Get("X-Amz-Signature")).To(HaveLen(64))
+
+	// Bucket names with dots are not valid host names for TLS, so path style URLs are used.
+	dotted, err := NewS3("", "eu-west-1", "etcd.backups", Credentials{AccessKeyID: "id", SecretAccessKey: "secret"})
+	g.Expect(err).NotTo(HaveOccurred())
+	got, err = dotted.PresignGet("etcd-snapshot.db", time.Hour)
+	g.Expect(err).NotTo(HaveOccurred())
+	u, err = url.Parse(got)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(u.Host).To(Equal("s3.eu-west-1.amazonaws.com"))
+	g.Expect(u.Path).To(Equal("/etcd.backups/etcd-snapshot.db"))
+
+	anonymous, err := NewS3("https://minio.example.com", "us-east-1", "backups", Credentials{})
+	g.Expect(err).NotTo(HaveOccurred())
+	got, err = anonymous.PresignGet("etcd-snapshot.db", time.Hour)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal("https://minio.example.com/backups/etcd-snapshot.db"))
+	g.Expect(strings.Contains(got, "X-Amz")).To(BeFalse())
+}
+
+func TestS3PresignPut(t *testing.T) {
+	g := NewWithT(t)
+
+	s, err := NewS3("https://minio.example.com", "us-east-1", "backups", Credentials{AccessKeyID: "id", SecretAccessKey: "secret"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	put, err := s.PresignPut("etcd-snapshot.db", time.Hour)
+	g.Expect(err).NotTo(HaveOccurred())
+	get, err := s.PresignGet("etcd-snapshot.db", time.Hour)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	u, err := url.Parse(put)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(u.Host).To(Equal("minio.example.com"))
+	g.Expect(u.Path).To(Equal("/backups/etcd-snapshot.db"))
+	g.Expect(u.Query().Get("X-Amz-Signature")).To(HaveLen(64))
+	g.Expect(put).NotTo(Equal(get))
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/blang/semver"
 	"github.com/pkg/errors"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -77,6 +78,11 @@ type WorkloadCluster interface {
 
 	// State recovery tasks.
 	ReconcileEtcdMembers(ctx context.Context) error
+
+	// Etcd snapshot tasks.
+	GetEtcdSnapshotJob(ctx context.Context) (*batchv1.Job, error)
+	CreateEtcdSnapshotJob(ctx context.Context, input EtcdSnapshotJobInput) error
+	DeleteEtcdSnapshotJob(ctx context.Context) error
 }
 
 // Workload defines operations on workload clusters.
@@ -375,6 +381,10 @@ func (w *Workload) UpdateKubeProxyImageInfo(ctx context.Context, kcp *controlpla
 	if _, ok := kcp.Annotations[controlplanev1.SkipKubeProxyAnnotation]; ok {
 		return nil
 	}
+	// Return early if kube-proxy has not been installed by kubeadm.
+	if addons := kcp.Spec.KubeadmConfigSpec.Addons; addons != nil && addons.SkipKubeProxy {
+		return nil
+	}
 
 	ds := &appsv1.DaemonSet{}
 
@@ -72,6 +72,10 @@ func (w *Workload) UpdateCoreDNS(ctx context.Context, kcp *controlplanev1.Kubead
 	if _, ok := kcp.Annotations[controlplanev1.SkipCoreDNSAnnotation]; ok {
 		return nil
 	}
+	// Return early if CoreDNS has not been installed by kubeadm.
+	if addons := kcp.Spec.KubeadmConfigSpec.Addons; addons != nil && addons.SkipCoreDNS {
+		return nil
+	}
 
 	// Return early if the configuration is nil.
 	if kcp.Spec.KubeadmConfigSpec.ClusterConfiguration == nil {
@@ -145,6 +145,25 @@ kind: ClusterConfiguration
 			objs:      []runtime.Object{badCM},
 			expectErr: false,
 		},
+		{
+			name: "returns early without error if CoreDNS is skipped in the addons",
+			kcp: &controlplanev1.KubeadmControlPlane{
+				Spec: controlplanev1.KubeadmControlPlaneSpec{
+					KubeadmConfigSpec: cabpkv1.KubeadmConfigSpec{
+						ClusterConfiguration: &kubeadmv1.ClusterConfiguration{
+							DNS: kubeadmv1.DNS{
+								Type: "",
+							},
+						},
+						Addons: &cabpkv1.KubeadmAddons{
+							SkipCoreDNS: true,
+						},
+					},
+				},
+			},
+			objs:      []runtime.Object{badCM},
+			expectErr: false,
+		},
 		{
 			name: "returns early without error if KCP ClusterConfiguration is nil",
 			kcp: &controlplanev1.KubeadmControlPlane{
@@ -0,0 +1,233 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// EtcdSnapshotKeyAnnotation is the annotation of the etcd snapshot Job with the key the snapshot is uploaded to.
+	EtcdSnapshotKeyAnnotation = "controlplane.cluster.x-k8s.io/etcd-snapshot-key"
+
+	// etcdSnapshotName is the name of the Job taking the etcd snapshots, and of the Secret with the upload URL.
+	etcdSnapshotName = "etcd-snapshot"
+
+	// etcdSnapshotURLKey is the key of the upload URL in the Secret.
+	etcdSnapshotURLKey = "url"
+
+	// etcdSnapshotDeadline is the maximum duration of the etcd snapshot Job.
+	etcdSnapshotDeadline = 30 * 60
+
+	etcdPKIDir       = "/etc/kubernetes/pki/etcd"
+	etcdSnapshotDir  = "/snapshot"
+	etcdSnapshotFile = etcdSnapshotDir + "/snapshot.db"
+)
+
+// EtcdSnapshotJobInput defines the etcd snapshot to be taken by the Job.
+type EtcdSnapshotJobInput struct {
+	// Key of the snapshot in the store.
+	Key string
+
+	// UploadURL is the presigned URL the snapshot is uploaded to.
+	UploadURL string
+
+	// UploadImage is the image uploading the snapshot with curl.
+	UploadImage string
+}
+
+// GetEtcdSnapshotJob returns the Job taking an etcd snapshot, or nil if there is none.
+func (w *Workload) GetEtcdSnapshotJob(ctx context.Context) (*batchv1.Job, error) {
+	job := &batchv1.Job{}
+	key := ctrlclient.ObjectKey{Namespace: metav1.NamespaceSystem, Name: etcdSnapshotName}
+	if err := w.Client.Get(ctx, key, job); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get the etcd snapshot Job %s", key)
+	}
+	return job, nil
+}
+
+// CreateEtcdSnapshotJob creates a Job taking an etcd snapshot on the node of one of the ready etcd members, with the
+// etcdctl binary of its image, and uploading it to the given presigned URL; the snapshot never leaves the workload
+// cluster except for being uploaded to the store.
+func (w *Workload) CreateEtcdSnapshotJob(ctx context.Context, input EtcdSnapshotJobInput) error {
+	etcdPod, err := w.getReadyEtcdPod(ctx)
+	if err != nil {
+		return err
+	}
+	var etcdImage string
+	for _, c := range etcdPod.Spec.Containers {
+		if c.Name == "etcd" {
+			etcdImage = c.Image
+		}
+	}
+	if etcdImage == "" {
+		return errors.Errorf("failed to get the etcd image from pod %s/%s", etcdPod.Namespace, etcdPod.Name)
+	}
+
+	// The presigned URL grants access to the store, so it is kept out of the Job spec.
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: etcdSnapshotName},
+		Data:       map[string][]byte{etcdSnapshotURLKey: []byte(input.UploadURL)},
+	}
+	if err := w.Client.Create(ctx, secret); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return errors.Wrap(err, "failed to create the etcd snapshot Secret")
+		}
+		// The Secret of a previous snapshot was not deleted.
+		if err := w.Client.Get(ctx, ctrlclient.ObjectKey{Namespace: secret.Namespace, Name: secret.Name}, secret); err != nil {
+			return errors.Wrap(err, "failed to get the etcd snapshot Secret")
+		}
+		secret.Data = map[string][]byte{etcdSnapshotURLKey: []byte(input.UploadURL)}
+		if err := w.Client.Update(ctx, secret); err != nil {
+			return errors.Wrap(err, "failed to update the etcd snapshot Secret")
+		}
+	}
+
+	if err := w.Client.Create(ctx, etcdSnapshotJob(input, etcdPod.Spec.NodeName, etcdImage)); err != nil {
+		return errors.Wrap(err, "failed to create the etcd snapshot Job")
+	}
+	return nil
+}
+
+// DeleteEtcdSnapshotJob deletes the Job taking an etcd snapshot, with its pods, and the Secret with the upload URL.
+func (w *Workload) DeleteEtcdSnapshotJob(ctx context.Context) error {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: etcdSnapshotName}}
+	if err := w.Client.Delete(ctx, job, ctrlclient.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to delete the etcd snapshot Job")
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: etcdSnapshotName}}
+	if err := w.Client.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to delete the etcd snapshot Secret")
+	}
+	return nil
+}
+
+// getReadyEtcdPod returns the etcd static pod of the first control plane node where it is ready.
+func (w *Workload) getReadyEtcdPod(ctx context.Context) (*corev1.Pod, error) {
+	nodes, err := w.getControlPlaneNodes(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list control plane nodes")
+	}
+	for _, node := range nodes.Items {
+		pod := &corev1.Pod{}
+		key := ctrlclient.ObjectKey{Namespace: metav1.NamespaceSystem, Name: staticPodName("etcd", node.Name)}
+		if err := w.Client.Get(ctx, key, pod); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to get etcd pod %s", key)
+		}
+		if checkStaticPodReadyCondition(*pod) == nil {
+			return pod, nil
+		}
+	}
+	return nil, errors.New("no ready etcd members found")
+}
+
+// etcdSnapshotJob returns the Job taking an etcd snapshot on the given node. The snapshot is saved by an init
+// container connecting to the local etcd member with the client certificate used by the kubeadm health checks,
+// then uploaded by the main container.
+func etcdSnapshotJob(input EtcdSnapshotJobInput, nodeName, etcdImage string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   metav1.NamespaceSystem,
+			Name:        etcdSnapshotName,
+			Annotations: map[string]string{EtcdSnapshotKeyAnnotation: input.Key},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          pointer.Int32Ptr(2),
+			ActiveDeadlineSeconds: pointer.Int64Ptr(etcdSnapshotDeadline),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					NodeName:      nodeName,
+					HostNetwork:   true,
+					RestartPolicy: corev1.RestartPolicyNever,
+					Tolerations:   []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+					InitContainers: []corev1.Container{
+						{
+							Name:  "snapshot",
+							Image: etcdImage,
+							Command: []string{
+								"etcdctl",
+								"--endpoints=https://127.0.0.1:2379",
+								"--cacert=" + etcdPKIDir + "/ca.crt",
+								"--cert=" + etcdPKIDir + "/healthcheck-client.crt",
+								"--key=" + etcdPKIDir + "/healthcheck-client.key",
+								"snapshot", "save", etcdSnapshotFile,
+							},
+							Env: []corev1.EnvVar{{Name: "ETCDCTL_API", Value: "3"}},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "etcd-certs", MountPath: etcdPKIDir, ReadOnly: true},
+								{Name: "snapshot", MountPath: etcdSnapshotDir},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "upload",
+							Image:   input.UploadImage,
+							Command: []string{"curl", "-fsS", "--retry", "5", "-T", etcdSnapshotFile, "$(UPLOAD_URL)"},
+							Env: []corev1.EnvVar{
+								{
+									Name: "UPLOAD_URL",
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{Name: etcdSnapshotName},
+											Key:                  etcdSnapshotURLKey,
+										},
+									},
+								},
+							},
+							// etcdctl saves the snapshot readable only by root.
+							SecurityContext: &corev1.SecurityContext{RunAsUser: pointer.Int64Ptr(0)},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "snapshot", MountPath: etcdSnapshotDir, ReadOnly: true},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "etcd-certs",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{Path: etcdPKIDir, Type: hostPathType(corev1.HostPathDirectory)},
+							},
+						},
+						{
+							Name:         "snapshot",
+							VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func hostPathType(t corev1.HostPathType) *corev1.HostPathType {
+	return &t
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func etcdStaticPod(nodeName string, ready corev1.ConditionStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: staticPodName("etcd", nodeName)},
+		Spec: corev1.PodSpec{
+			NodeName:   nodeName,
+			Containers: []corev1.Container{{Name: "etcd", Image: "k8s.gcr.io/etcd:3.4.3-0"}},
+		},
+		Status: corev1.PodStatus{Conditions: []corev1.PodCondition{podReady(ready)}},
+	}
+}
+
+func controlPlaneNode(name string) *corev1.Node {
+	node := nodeNamed(name)
+	node.Labels = map[string]string{labelNodeRoleControlPlane: ""}
+	return &node
+}
+
+func TestCreateEtcdSnapshotJob(t *testing.T) {
+	input := EtcdSnapshotJobInput{
+		Key:         "ns/kcp/etcd-snapshot-20200601T120000Z.db",
+		UploadURL:   "https://store/backups/ns/kcp/etcd-snapshot-20200601T120000Z.db?X-Amz-Signature=a",
+		UploadImage: "curlimages/curl:7.72.0",
+	}
+
+	tests := []struct {
+		name     string
+		objs     []runtime.Object
+		wantNode string
+		wantErr  bool
+	}{
+		{
+			name: "runs on the node of the first ready etcd member",
+			objs: []runtime.Object{
+				controlPlaneNode("cp1"), etcdStaticPod("cp1", corev1.ConditionFalse),
+				controlPlaneNode("cp2"), etcdStaticPod("cp2", corev1.ConditionTrue),
+			},
+			wantNode: "cp2",
+		},
+		{
+			name: "replaces the Secret of a previous snapshot",
+			objs: []runtime.Object{
+				controlPlaneNode("cp1"), etcdStaticPod("cp1", corev1.ConditionTrue),
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: etcdSnapshotName},
+					Data:       map[string][]byte{etcdSnapshotURLKey: []byte("https://store/old")},
+				},
+			},
+			wantNode: "cp1",
+		},
+		{
+			name: "fails if there are no ready etcd members",
+			objs: []runtime.Object{
+				controlPlaneNode("cp1"), etcdStaticPod("cp1", corev1.ConditionFalse),
+				controlPlaneNode("cp2"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			w := &Workload{Client: fake.NewFakeClientWithScheme(scheme.Scheme, tt.objs...)}
+			err := w.CreateEtcdSnapshotJob(context.TODO(), input)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				job, err := w.GetEtcdSnapshotJob(context.TODO())
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(job).To(BeNil())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			job, err := w.GetEtcdSnapshotJob(context.TODO())
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(job).NotTo(BeNil())
+			g.Expect(job.Annotations).To(HaveKeyWithValue(EtcdSnapshotKeyAnnotation, input.Key))
+
+			pod := job.Spec.Template.Spec
+			g.Expect(pod.NodeName).To(Equal(tt.wantNode))
+			g.Expect(pod.HostNetwork).To(BeTrue())
+			g.Expect(pod.InitContainers).To(HaveLen(1))
+			g.Expect(pod.InitContainers[0].Image).To(Equal("k8s.gcr.io/etcd:3.4.3-0"))
+			g.Expect(pod.InitContainers[0].Command).To(ContainElement(etcdSnapshotFile))
+			g.Expect(pod.Containers).To(HaveLen(1))
+			g.Expect(pod.Containers[0].Image).To(Equal(input.UploadImage))
+
+			// The upload URL is read from the Secret, and not stored in the Job.
+			g.Expect(pod.Containers[0].Command).NotTo(ContainElement(input.UploadURL))
+			g.Expect(pod.Containers[0].Env[0].ValueFrom.SecretKeyRef.Name).To(Equal(etcdSnapshotName))
+			secret := &corev1.Secret{}
+			g.Expect(w.Client.Get(context.TODO(), ctrlclient.ObjectKey{Namespace: metav1.NamespaceSystem, Name: etcdSnapshotName}, secret)).To(Succeed())
+			g.Expect(string(secret.Data[etcdSnapshotURLKey])).To(Equal(input.UploadURL))
+		})
+	}
+}
+
+func TestDeleteEtcdSnapshotJob(t *testing.T) {
+	g := NewWithT(t)
+
+	w := &Workload{Client: fake.NewFakeClientWithScheme(scheme.Scheme,
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: etcdSnapshotName}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: etcdSnapshotName}},
+	)}
+
+	g.Expect(w.DeleteEtcdSnapshotJob(context.TODO())).To(Succeed())
+	job, err := w.GetEtcdSnapshotJob(context.TODO())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(job).To(BeNil())
+	secret := &corev1.Secret{}
+	err = w.Client.Get(context.TODO(), ctrlclient.ObjectKey{Namespace: metav1.NamespaceSystem, Name: etcdSnapshotName}, secret)
+	g.Expect(err).To(HaveOccurred())
+
+	// Deleting again is a no-op.
+	g.Expect(w.DeleteEtcdSnapshotJob(context.TODO())).To(Succeed())
+}
@@ -37,8 +37,10 @@ import (
 	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/predicates"
+	"sigs.k8s.io/cluster-api/util/tuning"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -58,6 +60,9 @@ type MachinePoolReconciler struct {
 	Client client.Client
 	Log    logr.Logger
 
+	// Tuning configures the maximum requeue delay and the per-namespace concurrency of the reconciliations.
+	Tuning tuning.Options
+
 	config           *rest.Config
 	controller       controller.Controller
 	recorder         record.EventRecorder
@@ -75,7 +80,7 @@ func (r *MachinePoolReconciler) SetupWithManager(mgr ctrl.Manager, options contr
 		For(&expv1.MachinePool{}).
 		WithOptions(options).
 		WithEventFilter(predicates.ResourceNotPaused(r.Log)).
-		Build(r)
+		Build(tuning.Wrap(r, r.Tuning))
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
 	}
@@ -100,7 +105,7 @@ func (r *MachinePoolReconciler) SetupWithManager(mgr ctrl.Manager, options contr
 
 func (r *MachinePoolReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
 	ctx := context.Background()
-	logger := r.Log.WithValues("machinepool", req.NamespacedName)
+	logger := logutil.WithReconcileID(r.Log).WithValues(logutil.NamespaceKey, req.Namespace, logutil.MachinePoolKey, req.Name)
 
 	mp := &expv1.MachinePool{}
 	if err := r.Client.Get(ctx, req.NamespacedName, mp); err != nil {
@@ -113,6 +118,9 @@ func (r *MachinePoolReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, rete
 		return ctrl.Result{}, err
 	}
 
+	logger = logger.WithValues(logutil.ClusterKey, mp.Spec.ClusterName)
+	ctx = logutil.IntoContext(ctx, logger)
+
 	cluster, err := util.GetClusterByName(ctx, r.Client, mp.ObjectMeta.Namespace, mp.Spec.ClusterName)
 	if err != nil {
 		logger.Error(err, "Failed to get Cluster %s for MachinePool.", mp.Spec.ClusterName)
@@ -169,8 +177,7 @@ func (r *MachinePoolReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, rete
 }
 
 func (r *MachinePoolReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster, mp *expv1.MachinePool) (ctrl.Result, error) {
-	logger := r.Log.WithValues("machinepool", mp.Name, "namespace", mp.Namespace)
-	logger = logger.WithValues("cluster", cluster.Name)
+	logger := logutil.FromContext(ctx, logutil.ForMachinePool(r.Log, mp))
 
 	// Ensure the MachinePool is owned by the Cluster it belongs to.
 	mp.OwnerReferences = util.EnsureOwnerRef(mp.OwnerReferences, metav1.OwnerReference{
@@ -30,6 +30,7 @@ import (
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/util"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -44,7 +45,7 @@ type getNodeReferencesResult struct {
 }
 
 func (r *MachinePoolReconciler) reconcileNodeRefs(ctx context.Context, cluster *clusterv1.Cluster, mp *expv1.MachinePool) error {
-	logger := r.Log.WithValues("machinepool", mp.Name, "namespace", mp.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForMachinePool(r.Log, mp))
 	// Check that the MachinePool hasn't been deleted or in the process.
 	if !mp.DeletionTimestamp.IsZero() {
 		return nil
@@ -61,8 +62,6 @@ func (r *MachinePoolReconciler) reconcileNodeRefs(ctx context.Context, cluster *
 		return nil
 	}
 
-	logger = logger.WithValues("cluster", cluster.Name)
-
 	// Check that the MachinePool has valid ProviderIDList.
 	if len(mp.Spec.ProviderIDList) == 0 {
 		logger.V(2).Info("MachinePool doesn't have any ProviderIDs yet")
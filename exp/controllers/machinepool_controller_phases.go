@@ -34,7 +34,9 @@ import (
 	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
+	logutil "sigs.k8s.io/cluster-api/util/log"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -78,7 +80,7 @@ func (r *MachinePoolReconciler) reconcilePhase(mp *expv1.MachinePool) {
 
 // reconcileExternal handles generic unstructured objects referenced by a MachinePool.
 func (r *MachinePoolReconciler) reconcileExternal(ctx context.Context, cluster *clusterv1.Cluster, m *expv1.MachinePool, ref *corev1.ObjectReference) (external.ReconcileOutput, error) {
-	logger := r.Log.WithValues("machinepool", m.Name, "namespace", m.Namespace)
+	logger := logutil.FromContext(ctx, logutil.ForMachinePool(r.Log, m))
 
 	obj, err := external.Get(ctx, r.Client, ref, m.Namespace)
 	if err != nil {
@@ -171,6 +173,18 @@ func (r *MachinePoolReconciler) reconcileBootstrap(ctx context.Context, cluster
 
 	// If the bootstrap data secret is populated, set ready and return.
 	if m.Spec.Template.Spec.Bootstrap.Data != nil || m.Spec.Template.Spec.Bootstrap.DataSecretName != nil {
+		// If there is no bootstrap object, the data secret is provided by the user and it can be shared by many
+		// machine pools; wait for it to exist, so the infrastructure provider does not fail reading it.
+		if secretName := m.Spec.Template.Spec.Bootstrap.DataSecretName; bootstrapConfig == nil && secretName != nil {
+			if err := r.Client.Get(ctx, client.ObjectKey{Namespace: m.Namespace, Name: *secretName}, &corev1.Secret{}); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return errors.Wrapf(err, "failed to get bootstrap data secret %q for MachinePool %q in namespace %q", *secretName, m.Name, m.Namespace)
+				}
+				m.Status.BootstrapReady = false
+				return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
+					"bootstrap data secret %q for MachinePool %q in namespace %q not found, requeuing", *secretName, m.Name, m.Namespace)
+			}
+		}
 		m.Status.BootstrapReady = true
 		return nil
 	}
@@ -580,6 +580,36 @@ func TestReconcileMachinePoolBootstrap(t *testing.T) {
 				g.Expect(m.Status.BootstrapReady).To(BeTrue())
 			},
 		},
+		{
+			name: "new machinepool, shared bootstrap data secret does not exist",
+			bootstrapConfig: map[string]interface{}{
+				"kind":       "BootstrapConfig",
+				"apiVersion": "bootstrap.cluster.x-k8s.io/v1alpha3",
+				"metadata": map[string]interface{}{
+					"name":      "bootstrap-config1",
+					"namespace": "default",
+				},
+			},
+			machinepool: &expv1.MachinePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "bootstrap-test-shared",
+					Namespace: "default",
+				},
+				Spec: expv1.MachinePoolSpec{
+					Template: clusterv1.MachineTemplateSpec{
+						Spec: clusterv1.MachineSpec{
+							Bootstrap: clusterv1.Bootstrap{
+								DataSecretName: pointer.StringPtr("workers-bootstrap-data"),
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+			expected: func(g *WithT, m *expv1.MachinePool) {
+				g.Expect(m.Status.BootstrapReady).To(BeFalse())
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,69 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/codes"
+	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// logExporter writes each span to a logger, with its duration, its attributes and the IDs correlating it with the
+// other spans of the same trace.
+type logExporter struct {
+	log logr.Logger
+}
+
+var _ exporttrace.SpanExporter = &logExporter{}
+
+func (e *logExporter) ExportSpans(_ context.Context, spans []*exporttrace.SpanSnapshot) error {
+	for _, s := range spans {
+		kvs := []interface{}{"span", s.Name, "traceID", s.SpanContext.TraceID.String(), "spanID", s.SpanContext.SpanID.String()}
+		if s.ParentSpanID != (oteltrace.SpanID{}) {
+			kvs = append(kvs, "parentSpanID", s.ParentSpanID.String())
+		}
+		kvs = append(kvs, "duration", s.EndTime.Sub(s.StartTime).String())
+		for _, a := range s.Attributes {
+			kvs = append(kvs, string(a.Key), a.Value.Emit())
+		}
+		if s.StatusCode == codes.Error {
+			kvs = append(kvs, "error", errorMessage(s))
+		}
+		e.log.Info("Span ended", kvs...)
+	}
+	return nil
+}
+
+func (e *logExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// errorMessage returns the message of the last error recorded by the span.
+func errorMessage(s *exporttrace.SpanSnapshot) string {
+	message := s.StatusMessage
+	for _, event := range s.MessageEvents {
+		for _, a := range event.Attributes {
+			if a.Key == "error.message" {
+				message = a.Value.AsString()
+			}
+		}
+	}
+	return message
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing registers the OpenTelemetry tracer provider exporting the spans created by the util/trace package.
+// It is used only by the controller manager and clusterctl binaries, so the packages imported by other projects
+// depend only on the OpenTelemetry API and not on its SDK and exporters.
+package tracing
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlphttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+// Options configures the export of the spans.
+type Options struct {
+	// Endpoint is the URL of the OTLP/HTTP receiver of an OpenTelemetry collector, e.g. http://otel-collector:4318;
+	// the spans are posted to the /v1/traces path of the endpoint. The export is disabled if empty.
+	Endpoint string
+
+	// ServiceName is the service.name attribute of the resource producing the spans.
+	ServiceName string
+
+	// Headers are added to each export request, e.g. to authenticate with the collector.
+	Headers map[string]string
+
+	// LogSpans writes each span to Log when it ends.
+	LogSpans bool
+
+	// Log receives the spans if LogSpans is set, and the errors exporting the spans; if nil, they are discarded.
+	Log logr.Logger
+}
+
+// Setup registers the global OpenTelemetry tracer provider and the W3C trace context propagator, and returns a
+// function exporting the spans still queued, to be called before the program exits.
+// If neither an endpoint nor the logging of the spans is configured, no tracer provider is registered and the spans
+// are discarded.
+func Setup(ctx context.Context, options Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	if options.Endpoint == "" && !options.LogSpans {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	providerOptions := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.ServiceNameKey.String(options.ServiceName))),
+	}
+	if options.LogSpans && options.Log != nil {
+		providerOptions = append(providerOptions, sdktrace.WithSyncer(&logExporter{log: options.Log}))
+	}
+	if options.Endpoint != "" {
+		driverOptions, err := driverOptions(options.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		if len(options.Headers) > 0 {
+			driverOptions = append(driverOptions, otlphttp.WithHeaders(options.Headers))
+		}
+		exporter, err := otlp.NewExporter(ctx, otlphttp.NewDriver(driverOptions...))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create the OTLP exporter")
+		}
+		providerOptions = append(providerOptions, sdktrace.WithBatcher(exporter))
+	}
+	if options.Log != nil {
+		otel.SetErrorHandler(errorHandler{log: options.Log})
+	}
+
+	provider := sdktrace.NewTracerProvider(providerOptions...)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// driverOptions returns the options of the OTLP/HTTP driver posting the spans to the endpoint URL.
+func driverOptions(endpoint string) ([]otlphttp.Option, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid OTLP endpoint %q", endpoint)
+	}
+	if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, errors.Errorf("invalid OTLP endpoint %q: an http or https URL is required", endpoint)
+	}
+
+	options := []otlphttp.Option{
+		otlphttp.WithEndpoint(u.Host),
+		otlphttp.WithTracesURLPath(strings.TrimSuffix(u.Path, "/") + otlphttp.DefaultTracesPath),
+	}
+	if u.Scheme == "http" {
+		options = append(options, otlphttp.WithInsecure())
+	}
+	return options, nil
+}
+
+// errorHandler logs the errors of the OpenTelemetry SDK, e.g. failing to export the spans.
+type errorHandler struct {
+	log logr.Logger
+}
+
+func (h errorHandler) Handle(err error) {
+	h.log.Error(err, "OpenTelemetry error")
+}
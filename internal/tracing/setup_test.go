@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"sigs.k8s.io/cluster-api/util/trace"
+)
+
+func TestSetup_InvalidEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"otel-collector:4318", "grpc://otel-collector:4317", "http://"} {
+		t.Run(endpoint, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := Setup(context.Background(), Options{Endpoint: endpoint})
+			g.Expect(err).To(HaveOccurred())
+		})
+	}
+}
+
+func TestSetup_ExportsSpans(t *testing.T) {
+	g := NewWithT(t)
+
+	var (
+		lock     sync.Mutex
+		requests []*http.Request
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(body).NotTo(BeEmpty())
+
+		lock.Lock()
+		requests = append(requests, r)
+		lock.Unlock()
+	}))
+	defer server.Close()
+
+	shutdown, err := Setup(context.Background(), Options{
+		Endpoint:    server.URL + "/otlp/",
+		ServiceName: "capi-controller-manager",
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	defer otel.SetTracerProvider(oteltrace.NewNoopTracerProvider())
+
+	_, span := trace.Start(context.Background(), "op", trace.String("cluster", "cluster1"))
+	g.Expect(span.IsRecording()).To(BeTrue())
+	span.End()
+
+	// The queued spans are exported on shutdown.
+	g.Expect(shutdown(context.Background())).To(Succeed())
+
+	lock.Lock()
+	defer lock.Unlock()
+	g.Expect(requests).To(HaveLen(1))
+	g.Expect(requests[0].Method).To(Equal(http.MethodPost))
+	g.Expect(requests[0].URL.Path).To(Equal("/otlp/v1/traces"))
+	g.Expect(requests[0].Header.Get("Content-Type")).To(Equal("application/x-protobuf"))
+	g.Expect(requests[0].Header.Get("Authorization")).To(Equal("Bearer token"))
+}
+
+// fakeLogger records the key/value pairs of each Info call.
+type fakeLogger struct {
+	entries []map[string]interface{}
+}
+
+func (l *fakeLogger) Info(msg string, keysAndValues ...interface{}) {
+	entry := map[string]interface{}{}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		entry[keysAndValues[i].(string)] = keysAndValues[i+1]
+	}
+	l.entries = append(l.entries, entry)
+}
+func (l *fakeLogger) Enabled() bool                                             { return true }
+func (l *fakeLogger) Error(err error, msg string, keysAndValues ...interface{}) {}
+func (l *fakeLogger) V(level int) logr.InfoLogger                               { return l }
+func (l *fakeLogger) WithName(name string) logr.Logger                          { return l }
+func (l *fakeLogger) WithValues(keysAndValues ...interface{}) logr.Logger       { return l }
+
+func TestSetup_LogSpans(t *testing.T) {
+	g := NewWithT(t)
+
+	logger := &fakeLogger{}
+	shutdown, err := Setup(context.Background(), Options{LogSpans: true, Log: logger})
+	g.Expect(err).NotTo(HaveOccurred())
+	defer otel.SetTracerProvider(oteltrace.NewNoopTracerProvider())
+
+	ctx, parent := trace.Start(context.Background(), "parent", trace.String("cluster", "cluster1"))
+	_, child := trace.Start(ctx, "child", trace.Int("objects", 3))
+	child.RecordError(errors.New("failed"))
+	child.End()
+	parent.RecordError(nil)
+	parent.End()
+	g.Expect(shutdown(context.Background())).To(Succeed())
+
+	g.Expect(logger.entries).To(HaveLen(2))
+	childEntry, parentEntry := logger.entries[0], logger.entries[1]
+
+	g.Expect(parentEntry).To(HaveKeyWithValue("span", "parent"))
+	g.Expect(parentEntry).To(HaveKeyWithValue("cluster", "cluster1"))
+	g.Expect(parentEntry).NotTo(HaveKey("parentSpanID"))
+	g.Expect(parentEntry).NotTo(HaveKey("error"))
+	g.Expect(parentEntry).To(HaveKey("duration"))
+
+	// The child span belongs to the same trace of the parent span.
+	g.Expect(childEntry).To(HaveKeyWithValue("span", "child"))
+	g.Expect(childEntry).To(HaveKeyWithValue("objects", "3"))
+	g.Expect(childEntry).To(HaveKeyWithValue("error", "failed"))
+	g.Expect(childEntry["traceID"]).To(Equal(parentEntry["traceID"]))
+	g.Expect(childEntry["parentSpanID"]).To(Equal(parentEntry["spanID"]))
+	g.Expect(childEntry["spanID"]).NotTo(Equal(parentEntry["spanID"]))
+}
@@ -16,6 +16,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"math/rand"
 	"net/http"
@@ -24,9 +25,11 @@ import (
 	"time"
 
 	"github.com/spf13/pflag"
+	"golang.org/x/time/rate"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 	"k8s.io/klog/klogr"
 	clusterv1alpha2 "sigs.k8s.io/cluster-api/api/v1alpha2"
@@ -39,7 +42,9 @@ import (
 	expv1alpha3 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
 	expcontrollers "sigs.k8s.io/cluster-api/exp/controllers"
 	"sigs.k8s.io/cluster-api/feature"
+	"sigs.k8s.io/cluster-api/internal/tracing"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/tuning"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
@@ -51,23 +56,52 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 
 	// flags
-	metricsAddr                   string
-	enableLeaderElection          bool
-	leaderElectionLeaseDuration   time.Duration
-	leaderElectionRenewDeadline   time.Duration
-	leaderElectionRetryPeriod     time.Duration
-	watchNamespace                string
-	profilerAddress               string
-	clusterConcurrency            int
-	machineConcurrency            int
-	machineSetConcurrency         int
-	machineDeploymentConcurrency  int
-	machinePoolConcurrency        int
-	clusterResourceSetConcurrency int
-	machineHealthCheckConcurrency int
-	syncPeriod                    time.Duration
-	webhookPort                   int
-	healthAddr                    string
+	metricsAddr                       string
+	enableLeaderElection              bool
+	leaderElectionNamespace           string
+	leaderElectionLeaseDuration       time.Duration
+	leaderElectionRenewDeadline       time.Duration
+	leaderElectionRetryPeriod         time.Duration
+	watchNamespace                    string
+	profilerAddress                   string
+	clusterConcurrency                int
+	machineConcurrency                int
+	machineSetConcurrency             int
+	machineDeploymentConcurrency      int
+	machinePoolConcurrency            int
+	clusterResourceSetConcurrency     int
+	machineHealthCheckConcurrency     int
+	clusterProbeInterval              time.Duration
+	enableNodesNetworkCheck           bool
+	enableMachineOrphanGC             bool
+	machineOrphanGCInterval           time.Duration
+	nodeVolumeDetachTimeout           time.Duration
+	enableMachineDeletionSafety       bool
+	machineCreationsPerMinute         int
+	machineCreationBurst              int
+	machineDeletionsPerMinute         int
+	machineDeletionBurst              int
+	syncPeriod                        time.Duration
+	clusterMaxRequeueDelay            time.Duration
+	machineMaxRequeueDelay            time.Duration
+	machineSetMaxRequeueDelay         time.Duration
+	machineDeploymentMaxRequeueDelay  time.Duration
+	machinePoolMaxRequeueDelay        time.Duration
+	clusterResourceSetMaxRequeueDelay time.Duration
+	machineHealthCheckMaxRequeueDelay time.Duration
+	namespaceConcurrency              int
+	kubeAPIQPS                        float32
+	kubeAPIBurst                      int
+	rateLimiterBaseDelay              time.Duration
+	rateLimiterMaxDelay               time.Duration
+	rateLimiterQPS                    float64
+	rateLimiterBurst                  int
+	webhookPort                       int
+	healthAddr                        string
+	enableTracing                     bool
+	otlpEndpoint                      string
+	otlpServiceName                   string
+	otlpHeaders                       map[string]string
 )
 
 func init() {
@@ -90,6 +124,9 @@ func InitFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 
+	fs.StringVar(&leaderElectionNamespace, "leader-election-namespace", "",
+		"Namespace where the leader election ConfigMap is created. If unspecified, the namespace of the controller manager is used.")
+
 	fs.DurationVar(&leaderElectionLeaseDuration, "leader-election-lease-duration", 15*time.Second,
 		"Interval at which non-leader candidates will wait to force acquire leadership (duration string)")
 
@@ -126,15 +163,99 @@ func InitFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&machineHealthCheckConcurrency, "machinehealthcheck-concurrency", 10,
 		"Number of machine health checks to process simultaneously")
 
+	fs.DurationVar(&clusterProbeInterval, "cluster-probe-interval", controllers.DefaultClusterProbeInterval,
+		"The interval at which the API servers of the workload clusters are probed for reachability (e.g. 1m)")
+
+	fs.BoolVar(&enableNodesNetworkCheck, "enable-nodes-network-check", false,
+		"Enable the check of the network plugin readiness on the Nodes of the workload clusters, reported in the NodesNetworkReady condition of the Clusters. The Nodes are checked at the cluster-probe-interval.")
+
+	fs.BoolVar(&enableMachineOrphanGC, "enable-machine-orphan-gc", false,
+		"Enable deleting the infrastructure and bootstrap objects whose Machine no longer exists; otherwise they are only reported with events and the capi_machine_orphaned_objects metric.")
+
+	fs.DurationVar(&machineOrphanGCInterval, "machine-orphan-gc-interval", controllers.DefaultMachineOrphanGCInterval,
+		"The interval at which the infrastructure and bootstrap objects whose Machine no longer exists are garbage collected, or reported (e.g. 10m)")
+
+	fs.DurationVar(&nodeVolumeDetachTimeout, "node-volume-detach-timeout", controllers.DefaultNodeVolumeDetachTimeout,
+		"The time to wait for the volumes attached to the Node of a Machine being deleted to be detached before deleting the VolumeAttachments (e.g. 10m)")
+
+	fs.BoolVar(&enableMachineDeletionSafety, "enable-machine-deletion-safety-check", false,
+		"Prevent deleting the infrastructure of Machines whose Node is still Ready, unless the Machines have been marked unhealthy by a MachineHealthCheck or annotated with machine.cluster.x-k8s.io/force-deletion. The annotation is never set by the controllers: MachineSet scale-downs, MachineDeployment rollouts and KubeadmControlPlane upgrades block until the old Machines are annotated by hand.")
+
+	fs.IntVar(&machineCreationsPerMinute, "machine-creations-per-minute", 0,
+		"Maximum number of Machines of each cluster created per minute by the MachineSet controller; the rate limit is disabled if zero")
+
+	fs.IntVar(&machineCreationBurst, "machine-creation-burst", 0,
+		"Maximum number of Machines of each cluster that can be created at once by the MachineSet controller; defaults to machine-creations-per-minute if zero")
+
+	fs.IntVar(&machineDeletionsPerMinute, "machine-deletions-per-minute", 0,
+		"Maximum number of Machines of each cluster deleted per minute by the MachineSet controller, including the remediation of unhealthy Machines; the rate limit is disabled if zero")
+
+	fs.IntVar(&machineDeletionBurst, "machine-deletion-burst", 0,
+		"Maximum number of Machines of each cluster that can be deleted at once by the MachineSet controller; defaults to machine-deletions-per-minute if zero")
+
 	fs.DurationVar(&syncPeriod, "sync-period", 10*time.Minute,
 		"The minimum interval at which watched resources are reconciled (e.g. 15m)")
 
+	fs.DurationVar(&clusterMaxRequeueDelay, "cluster-max-requeue-delay", 0,
+		"The maximum delay of the requeues requested when reconciling Clusters, e.g. while waiting on other objects; disabled if zero")
+
+	fs.DurationVar(&machineMaxRequeueDelay, "machine-max-requeue-delay", 0,
+		"The maximum delay of the requeues requested when reconciling Machines, e.g. while waiting on other objects; disabled if zero")
+
+	fs.DurationVar(&machineSetMaxRequeueDelay, "machineset-max-requeue-delay", 0,
+		"The maximum delay of the requeues requested when reconciling MachineSets, e.g. while waiting on other objects; disabled if zero")
+
+	fs.DurationVar(&machineDeploymentMaxRequeueDelay, "machinedeployment-max-requeue-delay", 0,
+		"The maximum delay of the requeues requested when reconciling MachineDeployments, e.g. while waiting on other objects; disabled if zero")
+
+	fs.DurationVar(&machinePoolMaxRequeueDelay, "machinepool-max-requeue-delay", 0,
+		"The maximum delay of the requeues requested when reconciling MachinePools, e.g. while waiting on other objects; disabled if zero")
+
+	fs.DurationVar(&clusterResourceSetMaxRequeueDelay, "clusterresourceset-max-requeue-delay", 0,
+		"The maximum delay of the requeues requested when reconciling ClusterResourceSets, e.g. while waiting on other objects; disabled if zero")
+
+	fs.DurationVar(&machineHealthCheckMaxRequeueDelay, "machinehealthcheck-max-requeue-delay", 0,
+		"The maximum delay of the requeues requested when reconciling MachineHealthChecks, e.g. while waiting on other objects; disabled if zero")
+
+	fs.IntVar(&namespaceConcurrency, "namespace-concurrency", 0,
+		"Maximum number of objects of the same namespace reconciled at the same time by each controller, so a namespace with many objects can't starve the others; unlimited if zero")
+
+	fs.Float32Var(&kubeAPIQPS, "kube-api-qps", 20,
+		"Maximum queries per second from the controller client to the Kubernetes API server")
+
+	fs.IntVar(&kubeAPIBurst, "kube-api-burst", 30,
+		"Maximum number of queries that should be allowed in one burst from the controller client to the Kubernetes API server")
+
+	fs.DurationVar(&rateLimiterBaseDelay, "rate-limiter-base-delay", 5*time.Millisecond,
+		"Initial delay before requeuing an object whose reconciliation failed; the delay doubles on each consecutive failure")
+
+	fs.DurationVar(&rateLimiterMaxDelay, "rate-limiter-max-delay", 1000*time.Second,
+		"Maximum delay before requeuing an object whose reconciliation failed")
+
+	fs.Float64Var(&rateLimiterQPS, "rate-limiter-qps", 10,
+		"Maximum number of objects added to the work queue of each controller per second")
+
+	fs.IntVar(&rateLimiterBurst, "rate-limiter-burst", 100,
+		"Maximum number of objects that can be added at once to the work queue of each controller")
+
 	fs.IntVar(&webhookPort, "webhook-port", 0,
 		"Webhook Server port, disabled by default. When enabled, the manager will only work as webhook server, no reconcilers are installed.")
 
 	fs.StringVar(&healthAddr, "health-addr", ":9440",
 		"The address the health endpoint binds to.")
 
+	fs.BoolVar(&enableTracing, "enable-tracing", false,
+		"Enable tracing of the reconcile loops, writing a log entry with the duration of each span.")
+
+	fs.StringVar(&otlpEndpoint, "otlp-endpoint", "",
+		"URL of the OTLP/HTTP receiver of an OpenTelemetry collector the spans of the reconcile loops are exported to (e.g. http://otel-collector:4318); disabled if empty")
+
+	fs.StringVar(&otlpServiceName, "otlp-service-name", "capi-controller-manager",
+		"The service name of the spans exported to the OpenTelemetry collector")
+
+	fs.StringToStringVar(&otlpHeaders, "otlp-headers", nil,
+		"Headers added to the requests to the OpenTelemetry collector, e.g. to authenticate (e.g. Authorization=Bearer <token>)")
+
 	feature.MutableGates.AddFlag(fs)
 }
 
@@ -147,6 +268,8 @@ func main() {
 
 	ctrl.SetLogger(klogr.New())
 
+	stopTracing := setupTracing()
+
 	if profilerAddress != "" {
 		klog.Infof("Profiler listening for requests at %s", profilerAddress)
 		go func() {
@@ -154,19 +277,24 @@ func main() {
 		}()
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		MetricsBindAddress:     metricsAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "controller-leader-election-capi",
-		LeaseDuration:          &leaderElectionLeaseDuration,
-		RenewDeadline:          &leaderElectionRenewDeadline,
-		RetryPeriod:            &leaderElectionRetryPeriod,
-		Namespace:              watchNamespace,
-		SyncPeriod:             &syncPeriod,
-		NewClient:              util.ManagerDelegatingClientFunc,
-		Port:                   webhookPort,
-		HealthProbeBindAddress: healthAddr,
+	restConfig := ctrl.GetConfigOrDie()
+	restConfig.QPS = kubeAPIQPS
+	restConfig.Burst = kubeAPIBurst
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
+		Scheme:                  scheme,
+		MetricsBindAddress:      metricsAddr,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionID:        "controller-leader-election-capi",
+		LeaderElectionNamespace: leaderElectionNamespace,
+		LeaseDuration:           &leaderElectionLeaseDuration,
+		RenewDeadline:           &leaderElectionRenewDeadline,
+		RetryPeriod:             &leaderElectionRetryPeriod,
+		Namespace:               watchNamespace,
+		SyncPeriod:              &syncPeriod,
+		NewClient:               util.ManagerDelegatingClientFunc,
+		Port:                    webhookPort,
+		HealthProbeBindAddress:  healthAddr,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -179,12 +307,37 @@ func main() {
 
 	// +kubebuilder:scaffold:builder
 	setupLog.Info("starting manager", "version", version.Get().String())
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	err = mgr.Start(ctrl.SetupSignalHandler())
+	stopTracing()
+	if err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
 
+// setupTracing registers the tracer provider of the reconcile loops, and returns a function exporting the spans still
+// queued when the manager stops.
+func setupTracing() func() {
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{
+		Endpoint:    otlpEndpoint,
+		ServiceName: otlpServiceName,
+		Headers:     otlpHeaders,
+		LogSpans:    enableTracing,
+		Log:         ctrl.Log.WithName("trace"),
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to setup tracing")
+		os.Exit(1)
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			setupLog.Error(err, "unable to export the spans")
+		}
+	}
+}
+
 func setupChecks(mgr ctrl.Manager) {
 	if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to create ready check")
@@ -224,22 +377,44 @@ func setupReconcilers(mgr ctrl.Manager) {
 	if err := (&controllers.ClusterReconciler{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("Cluster"),
+		Tuning: tuningOptions(clusterMaxRequeueDelay),
 	}).SetupWithManager(mgr, concurrency(clusterConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Cluster")
 		os.Exit(1)
 	}
 	if err := (&controllers.MachineReconciler{
-		Client:  mgr.GetClient(),
-		Log:     ctrl.Log.WithName("controllers").WithName("Machine"),
-		Tracker: tracker,
+		Client:                    mgr.GetClient(),
+		Log:                       ctrl.Log.WithName("controllers").WithName("Machine"),
+		Tracker:                   tracker,
+		NodeVolumeDetachTimeout:   nodeVolumeDetachTimeout,
+		EnableDeletionSafetyCheck: enableMachineDeletionSafety,
+		Tuning:                    tuningOptions(machineMaxRequeueDelay),
 	}).SetupWithManager(mgr, concurrency(machineConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Machine")
 		os.Exit(1)
 	}
+	if err := (&controllers.MachineOrphanReconciler{
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("MachineOrphan"),
+		DeleteOrphans: enableMachineOrphanGC,
+		Interval:      machineOrphanGCInterval,
+	}).SetupWithManager(mgr, concurrency(clusterConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MachineOrphan")
+		os.Exit(1)
+	}
 	if err := (&controllers.MachineSetReconciler{
 		Client:  mgr.GetClient(),
 		Log:     ctrl.Log.WithName("controllers").WithName("MachineSet"),
 		Tracker: tracker,
+		MachineCreationRateLimit: controllers.MachineRateLimit{
+			PerMinute: machineCreationsPerMinute,
+			Burst:     machineCreationBurst,
+		},
+		MachineDeletionRateLimit: controllers.MachineRateLimit{
+			PerMinute: machineDeletionsPerMinute,
+			Burst:     machineDeletionBurst,
+		},
+		Tuning: tuningOptions(machineSetMaxRequeueDelay),
 	}).SetupWithManager(mgr, concurrency(machineSetConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MachineSet")
 		os.Exit(1)
@@ -247,6 +422,7 @@ func setupReconcilers(mgr ctrl.Manager) {
 	if err := (&controllers.MachineDeploymentReconciler{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("MachineDeployment"),
+		Tuning: tuningOptions(machineDeploymentMaxRequeueDelay),
 	}).SetupWithManager(mgr, concurrency(machineDeploymentConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MachineDeployment")
 		os.Exit(1)
@@ -256,6 +432,7 @@ func setupReconcilers(mgr ctrl.Manager) {
 		if err := (&expcontrollers.MachinePoolReconciler{
 			Client: mgr.GetClient(),
 			Log:    ctrl.Log.WithName("controllers").WithName("MachinePool"),
+			Tuning: tuningOptions(machinePoolMaxRequeueDelay),
 		}).SetupWithManager(mgr, concurrency(machinePoolConcurrency)); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "MachinePool")
 			os.Exit(1)
@@ -267,6 +444,7 @@ func setupReconcilers(mgr ctrl.Manager) {
 			Client:  mgr.GetClient(),
 			Log:     ctrl.Log.WithName("controllers").WithName("ClusterResourceSet"),
 			Tracker: tracker,
+			Tuning:  tuningOptions(clusterResourceSetMaxRequeueDelay),
 		}).SetupWithManager(mgr, concurrency(clusterResourceSetConcurrency)); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "ClusterResourceSet")
 			os.Exit(1)
@@ -280,10 +458,31 @@ func setupReconcilers(mgr ctrl.Manager) {
 		}
 	}
 
+	if err := (&controllers.ClusterReachabilityReconciler{
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("ClusterReachability"),
+		Tracker:       tracker,
+		ProbeInterval: clusterProbeInterval,
+	}).SetupWithManager(mgr, concurrency(clusterConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterReachability")
+		os.Exit(1)
+	}
+	if enableNodesNetworkCheck {
+		if err := (&controllers.ClusterNetworkReconciler{
+			Client:        mgr.GetClient(),
+			Log:           ctrl.Log.WithName("controllers").WithName("ClusterNetwork"),
+			Tracker:       tracker,
+			ProbeInterval: clusterProbeInterval,
+		}).SetupWithManager(mgr, concurrency(clusterConcurrency)); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ClusterNetwork")
+			os.Exit(1)
+		}
+	}
 	if err := (&controllers.MachineHealthCheckReconciler{
 		Client:  mgr.GetClient(),
 		Log:     ctrl.Log.WithName("controllers").WithName("MachineHealthCheck"),
 		Tracker: tracker,
+		Tuning:  tuningOptions(machineHealthCheckMaxRequeueDelay),
 	}).SetupWithManager(mgr, concurrency(machineHealthCheckConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MachineHealthCheck")
 		os.Exit(1)
@@ -371,6 +570,26 @@ func setupWebhooks(mgr ctrl.Manager) {
 	}
 }
 
+// tuningOptions returns the tuning options of a controller with the given maximum requeue delay.
+func tuningOptions(maxRequeueDelay time.Duration) tuning.Options {
+	return tuning.Options{
+		MaxRequeueDelay:                     maxRequeueDelay,
+		MaxConcurrentReconcilesPerNamespace: namespaceConcurrency,
+	}
+}
+
 func concurrency(c int) controller.Options {
-	return controller.Options{MaxConcurrentReconciles: c}
+	return controller.Options{
+		MaxConcurrentReconciles: c,
+		RateLimiter:             rateLimiter(),
+	}
+}
+
+// rateLimiter returns the rate limiter for a controller work queue; it combines a per-item exponential backoff
+// for failed reconciliations with an overall token bucket, so large fleets do not flood the API server.
+func rateLimiter() workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(rateLimiterBaseDelay, rateLimiterMaxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(rateLimiterQPS), rateLimiterBurst)},
+	)
 }
@@ -347,3 +347,35 @@ func ScaleAndWaitMachineDeployment(ctx context.Context, input ScaleAndWaitMachin
 		return nodeRefCount, nil
 	}, input.WaitForMachineDeployments...).Should(Equal(int(*input.MachineDeployment.Spec.Replicas)))
 }
+
+// WaitForMachineDeploymentsReadyInput is the input type for WaitForMachineDeploymentsReady.
+type WaitForMachineDeploymentsReadyInput struct {
+	Getter             Getter
+	MachineDeployments []*clusterv1.MachineDeployment
+}
+
+// WaitForMachineDeploymentsReady waits until all the MachineDeployments have observed their latest spec and
+// all their replicas are updated and ready.
+func WaitForMachineDeploymentsReady(ctx context.Context, input WaitForMachineDeploymentsReadyInput, intervals ...interface{}) {
+	Expect(ctx).NotTo(BeNil(), "ctx is required for WaitForMachineDeploymentsReady")
+	Expect(input.Getter).ToNot(BeNil(), "Invalid argument. input.Getter can't be nil when calling WaitForMachineDeploymentsReady")
+
+	for _, md := range input.MachineDeployments {
+		By(fmt.Sprintf("Waiting for MachineDeployment %s/%s to be ready", md.Namespace, md.Name))
+		key := client.ObjectKey{Namespace: md.Namespace, Name: md.Name}
+		Eventually(func() (bool, error) {
+			current := &clusterv1.MachineDeployment{}
+			if err := input.Getter.Get(ctx, key, current); err != nil {
+				return false, err
+			}
+			replicas := int32(1)
+			if current.Spec.Replicas != nil {
+				replicas = *current.Spec.Replicas
+			}
+			return current.Status.ObservedGeneration >= current.Generation &&
+				current.Status.UpdatedReplicas == replicas &&
+				current.Status.ReadyReplicas == replicas &&
+				current.Status.Replicas == replicas, nil
+		}, intervals...).Should(BeTrue(), "MachineDeployment %s/%s is not ready", md.Namespace, md.Name)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterLogCollector collects the logs of the machines of a workload cluster, e.g. the cloud-init and the kubelet logs;
+// it is implemented by infrastructure providers, because accessing the machines is infrastructure specific.
+type ClusterLogCollector interface {
+	// CollectMachineLog collects the logs of a machine into the output path.
+	CollectMachineLog(ctx context.Context, managementClusterClient client.Client, m clusterv1.Machine, outputPath string) error
+}
+
+// SetupSpecNamespaceInput is the input type for SetupSpecNamespace.
+type SetupSpecNamespaceInput struct {
+	SpecName       string
+	ClusterProxy   ClusterProxy
+	ArtifactFolder string
+}
+
+// SetupSpecNamespace creates a namespace with a random name for hosting a test spec, and starts watching its events;
+// events are written into the artifact folder.
+func SetupSpecNamespace(ctx context.Context, input SetupSpecNamespaceInput) (*corev1.Namespace, context.CancelFunc) {
+	Expect(ctx).NotTo(BeNil(), "ctx is required for SetupSpecNamespace")
+	Expect(input.ClusterProxy).ToNot(BeNil(), "Invalid argument. input.ClusterProxy can't be nil when calling SetupSpecNamespace")
+	Expect(input.SpecName).ToNot(BeEmpty(), "Invalid argument. input.SpecName can't be empty when calling SetupSpecNamespace")
+
+	By(fmt.Sprintf("Creating a namespace for hosting the %q test spec", input.SpecName))
+	return CreateNamespaceAndWatchEvents(ctx, CreateNamespaceAndWatchEventsInput{
+		Creator:   input.ClusterProxy.GetClient(),
+		ClientSet: input.ClusterProxy.GetClientSet(),
+		Name:      fmt.Sprintf("%s-%s", input.SpecName, util.RandomString(6)),
+		LogFolder: filepath.Join(input.ArtifactFolder, "clusters", input.ClusterProxy.GetName()),
+	})
+}
+
+// DumpSpecResourcesAndCleanupInput is the input type for DumpSpecResourcesAndCleanup.
+type DumpSpecResourcesAndCleanupInput struct {
+	SpecName       string
+	ClusterProxy   ClusterProxy
+	ArtifactFolder string
+	Namespace      *corev1.Namespace
+	CancelWatches  context.CancelFunc
+
+	// Cluster is the cluster created by the spec, if any; it is used only for reporting, because all the clusters
+	// in the namespace are deleted.
+	Cluster *clusterv1.Cluster
+
+	// LogCollector, if set, is used for collecting the logs of the machines in the namespace when the spec failed.
+	LogCollector ClusterLogCollector
+
+	// IntervalsGetter returns the intervals for waiting for the deletion of the clusters, using the "wait-delete-cluster" key.
+	IntervalsGetter func(spec, key string) []interface{}
+
+	// SkipCleanup preserves the clusters and the namespace, e.g. for debugging.
+	SkipCleanup bool
+}
+
+// DumpSpecResourcesAndCleanup collects the artifacts of a test spec, and then deletes all the clusters in the spec
+// namespace and the namespace itself. All the Cluster API resources in the namespace are dumped into the artifact folder;
+// if the spec failed and a LogCollector is set, the logs of all the machines in the namespace are collected too.
+func DumpSpecResourcesAndCleanup(ctx context.Context, input DumpSpecResourcesAndCleanupInput) {
+	Expect(ctx).NotTo(BeNil(), "ctx is required for DumpSpecResourcesAndCleanup")
+	Expect(input.ClusterProxy).ToNot(BeNil(), "Invalid argument. input.ClusterProxy can't be nil when calling DumpSpecResourcesAndCleanup")
+	Expect(input.Namespace).ToNot(BeNil(), "Invalid argument. input.Namespace can't be nil when calling DumpSpecResourcesAndCleanup")
+
+	By(fmt.Sprintf("Dumping all the Cluster API resources in the %q namespace", input.Namespace.Name))
+	// Dump all Cluster API related resources to artifacts before deleting them.
+	DumpAllResources(ctx, DumpAllResourcesInput{
+		Lister:    input.ClusterProxy.GetClient(),
+		Namespace: input.Namespace.Name,
+		LogPath:   filepath.Join(input.ArtifactFolder, "clusters", input.ClusterProxy.GetName(), "resources"),
+	})
+
+	if input.LogCollector != nil && CurrentGinkgoTestDescription().Failed {
+		By(fmt.Sprintf("Collecting the logs of the machines in the %q namespace", input.Namespace.Name))
+		CollectMachineLogs(ctx, CollectMachineLogsInput{
+			ClusterProxy:   input.ClusterProxy,
+			Namespace:      input.Namespace.Name,
+			LogCollector:   input.LogCollector,
+			ArtifactFolder: input.ArtifactFolder,
+		})
+	}
+
+	if !input.SkipCleanup {
+		Expect(input.IntervalsGetter).ToNot(BeNil(), "Invalid argument. input.IntervalsGetter can't be nil when calling DumpSpecResourcesAndCleanup without SkipCleanup")
+
+		if input.Cluster != nil {
+			By(fmt.Sprintf("Deleting cluster %s/%s", input.Cluster.Namespace, input.Cluster.Name))
+		} else {
+			By(fmt.Sprintf("Deleting all the clusters in the %q namespace", input.Namespace.Name))
+		}
+		// While https://github.com/kubernetes-sigs/cluster-api/issues/2955 is addressed in future iterations, there is a chance
+		// that the cluster variable is not set even if the cluster exists, so we are calling DeleteAllClustersAndWait
+		// instead of DeleteClusterAndWait
+		DeleteAllClustersAndWait(ctx, DeleteAllClustersAndWaitInput{
+			Client:    input.ClusterProxy.GetClient(),
+			Namespace: input.Namespace.Name,
+		}, input.IntervalsGetter(input.SpecName, "wait-delete-cluster")...)
+
+		By(fmt.Sprintf("Deleting namespace used for hosting the %q test spec", input.SpecName))
+		DeleteNamespace(ctx, DeleteNamespaceInput{
+			Deleter: input.ClusterProxy.GetClient(),
+			Name:    input.Namespace.Name,
+		})
+	}
+	if input.CancelWatches != nil {
+		input.CancelWatches()
+	}
+}
+
+// CollectMachineLogsInput is the input type for CollectMachineLogs.
+type CollectMachineLogsInput struct {
+	ClusterProxy   ClusterProxy
+	Namespace      string
+	LogCollector   ClusterLogCollector
+	ArtifactFolder string
+}
+
+// CollectMachineLogs collects the logs of all the machines in a namespace into the artifact folder,
+// in the clusters/<cluster name>/machines/<machine name> folder.
+// Failures are reported without failing the spec, so the collection of the other artifacts can continue.
+func CollectMachineLogs(ctx context.Context, input CollectMachineLogsInput) {
+	Expect(ctx).NotTo(BeNil(), "ctx is required for CollectMachineLogs")
+	Expect(input.ClusterProxy).ToNot(BeNil(), "Invalid argument. input.ClusterProxy can't be nil when calling CollectMachineLogs")
+	Expect(input.LogCollector).ToNot(BeNil(), "Invalid argument. input.LogCollector can't be nil when calling CollectMachineLogs")
+
+	c := input.ClusterProxy.GetClient()
+	machines := &clusterv1.MachineList{}
+	if err := c.List(ctx, machines, client.InNamespace(input.Namespace)); err != nil {
+		fmt.Fprintf(GinkgoWriter, "Failed to list the machines in the %q namespace: %v\n", input.Namespace, err)
+		return
+	}
+
+	for i := range machines.Items {
+		m := machines.Items[i]
+		outputPath := filepath.Join(input.ArtifactFolder, "clusters", m.Spec.ClusterName, "machines", m.Name)
+		if err := input.LogCollector.CollectMachineLog(ctx, c, m, outputPath); err != nil {
+			fmt.Fprintf(GinkgoWriter, "Failed to collect the logs of the %s/%s machine: %v\n", m.Namespace, m.Name, err)
+		}
+	}
+}
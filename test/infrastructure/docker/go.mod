@@ -17,3 +17,5 @@ require (
 )
 
 replace sigs.k8s.io/cluster-api => ../../..
+
+replace google.golang.org/grpc => google.golang.org/grpc v1.26.0
@@ -129,6 +129,13 @@ func ImageTagIsValid(tagName string) bool {
 	return !ociTagAllowedChars.MatchString(tagName)
 }
 
+// ImageRepositoryIsValid ensures that a given image repository, e.g. registry.example.com:5000/k8s, is a valid
+// reference name without a tag or digest.
+func ImageRepositoryIsValid(repositoryName string) bool {
+	_, err := reference.WithName(repositoryName)
+	return err == nil
+}
+
 // SemverToOCIImageTag is a helper function that replaces all
 // non-allowed symbols in tag strings with underscores.
 // Image tag can only contain lowercase and uppercase letters, digits,
@@ -218,3 +218,13 @@ func TestModifyImageTag(t *testing.T) {
 		g.Expect(res).To(Equal("example.com/image:v1.17.4_build1"))
 	})
 }
+
+func TestImageRepositoryIsValid(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(ImageRepositoryIsValid("k8s.gcr.io")).To(BeTrue())
+	g.Expect(ImageRepositoryIsValid("registry.example.com:5000/k8s/images")).To(BeTrue())
+	g.Expect(ImageRepositoryIsValid("")).To(BeFalse())
+	g.Expect(ImageRepositoryIsValid("registry.example.com/k8s:v1.0")).To(BeFalse())
+	g.Expect(ImageRepositoryIsValid("Registry/K8s")).To(BeFalse())
+}
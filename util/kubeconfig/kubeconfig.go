@@ -173,6 +173,59 @@ func NeedsClientCertRotation(configSecret *corev1.Secret, threshold time.Duratio
 	return false, nil
 }
 
+// NeedsRefresh returns whether the Kubeconfig secret's server differs from the given endpoint, or its certificate
+// authority differs from the cluster CA, e.g. because the control plane endpoint was migrated or the CA was rotated.
+func NeedsRefresh(ctx context.Context, c client.Reader, configSecret *corev1.Secret, endpoint string) (bool, error) {
+	clusterName, _, err := secret.ParseSecretName(configSecret.Name)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to parse secret name")
+	}
+	data, err := toKubeconfigBytes(configSecret)
+	if err != nil {
+		return false, err
+	}
+
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to convert kubeconfig Secret into a clientcmdapi.Config")
+	}
+	cluster, ok := config.Clusters[clusterName]
+	if !ok {
+		return true, nil
+	}
+	if cluster.Server != fmt.Sprintf("https://%s", endpoint) {
+		return true, nil
+	}
+
+	clusterCA, err := secret.GetFromNamespacedName(ctx, c, client.ObjectKey{Name: clusterName, Namespace: configSecret.Namespace}, secret.ClusterCA)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, ErrDependentCertificateNotFound
+		}
+		return false, err
+	}
+	caCert, err := certs.DecodeCertPEM(clusterCA.Data[secret.TLSCrtDataName])
+	if err != nil {
+		return false, errors.Wrap(err, "failed to decode CA Cert")
+	} else if caCert == nil {
+		return false, errors.New("certificate not found in config")
+	}
+	cert, err := certs.DecodeCertPEM(cluster.CertificateAuthorityData)
+	if err != nil || cert == nil {
+		return true, nil
+	}
+	return !cert.Equal(caCert), nil
+}
+
+// RefreshSecret creates and stores a new Kubeconfig for the given endpoint in the given secret.
+func RefreshSecret(ctx context.Context, c client.Client, configSecret *corev1.Secret, endpoint string) error {
+	clusterName, _, err := secret.ParseSecretName(configSecret.Name)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse secret name")
+	}
+	return regenerateSecret(ctx, c, configSecret, clusterName, fmt.Sprintf("https://%s", endpoint))
+}
+
 // RegenerateSecret creates and stores a new Kubeconfig in the given secret.
 func RegenerateSecret(ctx context.Context, c client.Client, configSecret *corev1.Secret) error {
 	clusterName, _, err := secret.ParseSecretName(configSecret.Name)
@@ -189,8 +242,12 @@ func RegenerateSecret(ctx context.Context, c client.Client, configSecret *corev1
 		return errors.Wrap(err, "failed to convert kubeconfig Secret into a clientcmdapi.Config")
 	}
 	endpoint := config.Clusters[clusterName].Server
+	return regenerateSecret(ctx, c, configSecret, clusterName, endpoint)
+}
+
+func regenerateSecret(ctx context.Context, c client.Client, configSecret *corev1.Secret, clusterName, server string) error {
 	key := client.ObjectKey{Name: clusterName, Namespace: configSecret.Namespace}
-	out, err := generateKubeconfig(ctx, c, key, endpoint)
+	out, err := generateKubeconfig(ctx, c, key, server)
 	if err != nil {
 		return err
 	}
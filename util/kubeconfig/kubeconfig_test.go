@@ -421,3 +421,119 @@ func TestRegenerateClientCerts(t *testing.T) {
 
 	g.Expect(newCert.NotAfter).To(BeTemporally(">", oldCert.NotAfter))
 }
+
+func TestNeedsRefresh(t *testing.T) {
+	g := NewWithT(t)
+	caKey, err := certs.NewPrivateKey()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	caCert, err := getTestCACert(caKey)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	rotatedCAKey, err := certs.NewPrivateKey()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	rotatedCACert, err := getTestCACert(rotatedCAKey)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	config, err := New("test1", "https://test-cluster-api:6443", caCert, caKey)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	out, err := clientcmd.Write(*config)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	kubeconfigSecret := GenerateSecretWithOwner(client.ObjectKey{Name: "test1", Namespace: "test"}, out, metav1.OwnerReference{})
+
+	caSecret := func(cert *x509.Certificate, key *rsa.PrivateKey) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test1-ca",
+				Namespace: "test",
+			},
+			Data: map[string][]byte{
+				secret.TLSKeyDataName: certs.EncodePrivateKeyPEM(key),
+				secret.TLSCrtDataName: certs.EncodeCertPEM(cert),
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		objs     []runtime.Object
+		endpoint string
+		want     bool
+		wantErr  error
+	}{
+		{
+			name:     "same endpoint and CA",
+			objs:     []runtime.Object{caSecret(caCert, caKey)},
+			endpoint: "test-cluster-api:6443",
+			want:     false,
+		},
+		{
+			name:     "endpoint migrated",
+			objs:     []runtime.Object{caSecret(caCert, caKey)},
+			endpoint: "test-cluster-api.example.com:443",
+			want:     true,
+		},
+		{
+			name:     "CA rotated",
+			objs:     []runtime.Object{caSecret(rotatedCACert, rotatedCAKey)},
+			endpoint: "test-cluster-api:6443",
+			want:     true,
+		},
+		{
+			name:     "CA not found",
+			endpoint: "test-cluster-api:6443",
+			wantErr:  ErrDependentCertificateNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fake.NewFakeClientWithScheme(setupScheme(), tt.objs...)
+			got, err := NeedsRefresh(context.Background(), c, kubeconfigSecret, tt.endpoint)
+			if tt.wantErr != nil {
+				g.Expect(err).To(Equal(tt.wantErr))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestRefreshSecret(t *testing.T) {
+	g := NewWithT(t)
+	caKey, err := certs.NewPrivateKey()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	caCert, err := getTestCACert(caKey)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	caSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test1-ca",
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			secret.TLSKeyDataName: certs.EncodePrivateKeyPEM(caKey),
+			secret.TLSCrtDataName: certs.EncodeCertPEM(caCert),
+		},
+	}
+
+	configSecret := validSecret.DeepCopy()
+	c := fake.NewFakeClientWithScheme(setupScheme(), configSecret, caSecret)
+
+	g.Expect(NeedsRefresh(context.Background(), c, configSecret, "test-cluster-api.example.com:443")).To(BeTrue())
+	g.Expect(RefreshSecret(context.Background(), c, configSecret, "test-cluster-api.example.com:443")).To(Succeed())
+
+	newSecret := &corev1.Secret{}
+	g.Expect(c.Get(context.Background(), util.ObjectKey(configSecret), newSecret)).To(Succeed())
+	newConfig, err := clientcmd.Load(newSecret.Data[secret.KubeconfigDataName])
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(newConfig.Clusters["test1"].Server).To(Equal("https://test-cluster-api.example.com:443"))
+	g.Expect(newConfig.Clusters["test1"].CertificateAuthorityData).To(Equal(certs.EncodeCertPEM(caCert)))
+	g.Expect(NeedsRefresh(context.Background(), c, newSecret, "test-cluster-api.example.com:443")).To(BeFalse())
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package log implements helpers for adding consistent structured keys to the controllers logs,
+// so logs related to the same objects can be correlated across controllers.
+package log
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+)
+
+const (
+	// NamespaceKey is the log key for the namespace of the reconciled object.
+	NamespaceKey = "namespace"
+
+	// ClusterKey is the log key for the name of the Cluster the reconciled object belongs to.
+	ClusterKey = "cluster"
+
+	// MachineKey is the log key for the name of a Machine.
+	MachineKey = "machine"
+
+	// MachineSetKey is the log key for the name of a MachineSet.
+	MachineSetKey = "machineset"
+
+	// MachineDeploymentKey is the log key for the name of a MachineDeployment.
+	MachineDeploymentKey = "machinedeployment"
+
+	// MachinePoolKey is the log key for the name of a MachinePool.
+	MachinePoolKey = "machinepool"
+
+	// ReconcileIDKey is the log key for the unique identifier of a reconcile loop.
+	ReconcileIDKey = "reconcileID"
+
+	reconcileIDLength = 8
+)
+
+type loggerKey struct{}
+
+// WithReconcileID returns a logger with a new random reconcileID, that allows to tell apart
+// the logs of subsequent reconcile loops for the same object.
+func WithReconcileID(logger logr.Logger) logr.Logger {
+	return logger.WithValues(ReconcileIDKey, util.RandomString(reconcileIDLength))
+}
+
+// ForCluster returns a logger with the keys identifying a Cluster.
+func ForCluster(logger logr.Logger, cluster *clusterv1.Cluster) logr.Logger {
+	return logger.WithValues(NamespaceKey, cluster.Namespace, ClusterKey, cluster.Name)
+}
+
+// ForMachine returns a logger with the keys identifying a Machine, the Cluster it belongs to and,
+// if any, the MachineSet and the MachineDeployment controlling it.
+func ForMachine(logger logr.Logger, m *clusterv1.Machine) logr.Logger {
+	logger = logger.WithValues(NamespaceKey, m.Namespace, ClusterKey, m.Spec.ClusterName, MachineKey, m.Name)
+	if name, ok := m.Labels[clusterv1.MachineSetLabelName]; ok {
+		logger = logger.WithValues(MachineSetKey, name)
+	}
+	if name, ok := m.Labels[clusterv1.MachineDeploymentLabelName]; ok {
+		logger = logger.WithValues(MachineDeploymentKey, name)
+	}
+	return logger
+}
+
+// ForMachineSet returns a logger with the keys identifying a MachineSet, the Cluster it belongs to and,
+// if any, the MachineDeployment controlling it.
+func ForMachineSet(logger logr.Logger, ms *clusterv1.MachineSet) logr.Logger {
+	logger = logger.WithValues(NamespaceKey, ms.Namespace, ClusterKey, ms.Spec.ClusterName, MachineSetKey, ms.Name)
+	if name, ok := ms.Labels[clusterv1.MachineDeploymentLabelName]; ok {
+		logger = logger.WithValues(MachineDeploymentKey, name)
+	}
+	return logger
+}
+
+// ForMachineDeployment returns a logger with the keys identifying a MachineDeployment and the Cluster it belongs to.
+func ForMachineDeployment(logger logr.Logger, d *clusterv1.MachineDeployment) logr.Logger {
+	return logger.WithValues(NamespaceKey, d.Namespace, ClusterKey, d.Spec.ClusterName, MachineDeploymentKey, d.Name)
+}
+
+// ForMachinePool returns a logger with the keys identifying a MachinePool and the Cluster it belongs to.
+func ForMachinePool(logger logr.Logger, mp *expv1.MachinePool) logr.Logger {
+	return logger.WithValues(NamespaceKey, mp.Namespace, ClusterKey, mp.Spec.ClusterName, MachinePoolKey, mp.Name)
+}
+
+// IntoContext returns a context carrying the given logger, so it can be retrieved by the functions
+// called during a reconcile loop.
+func IntoContext(ctx context.Context, logger logr.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by the context, or the fallback logger if there is none.
+func FromContext(ctx context.Context, fallback logr.Logger) logr.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(logr.Logger); ok {
+		return logger
+	}
+	return fallback
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+// fakeLogger records the key/value pairs added to the logger.
+type fakeLogger struct {
+	values []interface{}
+}
+
+func (l *fakeLogger) Info(msg string, keysAndValues ...interface{})             {}
+func (l *fakeLogger) Enabled() bool                                             { return true }
+func (l *fakeLogger) Error(err error, msg string, keysAndValues ...interface{}) {}
+func (l *fakeLogger) V(level int) logr.InfoLogger                               { return l }
+func (l *fakeLogger) WithName(name string) logr.Logger                          { return l }
+func (l *fakeLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	return &fakeLogger{values: append(append([]interface{}{}, l.values...), keysAndValues...)}
+}
+
+func TestForMachine(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []interface{}
+	}{
+		{
+			name: "Machine without owners",
+			want: []interface{}{NamespaceKey, "ns1", ClusterKey, "cluster1", MachineKey, "machine1"},
+		},
+		{
+			name: "Machine owned by a MachineDeployment",
+			labels: map[string]string{
+				clusterv1.MachineSetLabelName:        "ms1",
+				clusterv1.MachineDeploymentLabelName: "md1",
+			},
+			want: []interface{}{NamespaceKey, "ns1", ClusterKey, "cluster1", MachineKey, "machine1", MachineSetKey, "ms1", MachineDeploymentKey, "md1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "machine1", Labels: tt.labels},
+				Spec:       clusterv1.MachineSpec{ClusterName: "cluster1"},
+			}
+			logger := ForMachine(&fakeLogger{}, m)
+			g.Expect(logger.(*fakeLogger).values).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestWithReconcileID(t *testing.T) {
+	g := NewWithT(t)
+
+	first := WithReconcileID(&fakeLogger{}).(*fakeLogger).values
+	second := WithReconcileID(&fakeLogger{}).(*fakeLogger).values
+	g.Expect(first).To(HaveLen(2))
+	g.Expect(first[0]).To(Equal(ReconcileIDKey))
+	g.Expect(first[1]).NotTo(Equal(second[1]))
+}
+
+func TestContext(t *testing.T) {
+	g := NewWithT(t)
+
+	fallback := &fakeLogger{}
+	g.Expect(FromContext(context.Background(), fallback)).To(BeIdenticalTo(fallback))
+
+	logger := &fakeLogger{values: []interface{}{ClusterKey, "cluster1"}}
+	ctx := IntoContext(context.Background(), logger)
+	g.Expect(FromContext(ctx, fallback)).To(BeIdenticalTo(logger))
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"strings"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+// IsPropagatedMetadataKey returns true if the label or annotation key has the propagated metadata domain,
+// or a subdomain of it, as prefix.
+func IsPropagatedMetadataKey(key string) bool {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	return parts[0] == clusterv1.PropagatedMetadataDomain || strings.HasSuffix(parts[0], "."+clusterv1.PropagatedMetadataDomain)
+}
+
+// RemovePropagatedMetadata returns a copy of the given labels or annotations without the propagated keys.
+func RemovePropagatedMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	out := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if !IsPropagatedMetadataKey(k) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// SyncPropagatedMetadata sets the propagated keys of the destination labels or annotations to the propagated
+// keys of the source, removing the propagated keys missing in the source; other keys are preserved.
+// It returns the resulting map and true if it is different from the destination.
+func SyncPropagatedMetadata(dst, src map[string]string) (map[string]string, bool) {
+	changed := false
+	for k := range dst {
+		if _, ok := src[k]; IsPropagatedMetadataKey(k) && !ok {
+			delete(dst, k)
+			changed = true
+		}
+	}
+	for k, v := range src {
+		if !IsPropagatedMetadataKey(k) {
+			continue
+		}
+		if current, ok := dst[k]; ok && current == v {
+			continue
+		}
+		if dst == nil {
+			dst = map[string]string{}
+		}
+		dst[k] = v
+		changed = true
+	}
+	return dst, changed
+}
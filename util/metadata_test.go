@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestIsPropagatedMetadataKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "node.cluster.x-k8s.io/role", want: true},
+		{key: "example.node.cluster.x-k8s.io/role", want: true},
+		{key: "cluster.x-k8s.io/role", want: false},
+		{key: "examplenode.cluster.x-k8s.io/role", want: false},
+		{key: "node.cluster.x-k8s.io", want: false},
+		{key: "role", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(IsPropagatedMetadataKey(tt.key)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestSyncPropagatedMetadata(t *testing.T) {
+	tests := []struct {
+		name        string
+		dst         map[string]string
+		src         map[string]string
+		want        map[string]string
+		wantChanged bool
+	}{
+		{
+			name:        "no changes if the propagated keys are in sync",
+			dst:         map[string]string{"node.cluster.x-k8s.io/a": "1", "other": "x"},
+			src:         map[string]string{"node.cluster.x-k8s.io/a": "1", "something": "else"},
+			want:        map[string]string{"node.cluster.x-k8s.io/a": "1", "other": "x"},
+			wantChanged: false,
+		},
+		{
+			name:        "adds and updates propagated keys",
+			dst:         map[string]string{"node.cluster.x-k8s.io/a": "1", "other": "x"},
+			src:         map[string]string{"node.cluster.x-k8s.io/a": "2", "node.cluster.x-k8s.io/b": "3"},
+			want:        map[string]string{"node.cluster.x-k8s.io/a": "2", "node.cluster.x-k8s.io/b": "3", "other": "x"},
+			wantChanged: true,
+		},
+		{
+			name:        "removes propagated keys missing in the source",
+			dst:         map[string]string{"node.cluster.x-k8s.io/a": "1", "other": "x"},
+			src:         map[string]string{},
+			want:        map[string]string{"other": "x"},
+			wantChanged: true,
+		},
+		{
+			name:        "initializes the destination if required",
+			dst:         nil,
+			src:         map[string]string{"node.cluster.x-k8s.io/a": "1"},
+			want:        map[string]string{"node.cluster.x-k8s.io/a": "1"},
+			wantChanged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, changed := SyncPropagatedMetadata(tt.dst, tt.src)
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(changed).To(Equal(tt.wantChanged))
+		})
+	}
+}
@@ -21,9 +21,11 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/hex"
+	"encoding/pem"
 	"math/big"
 	"path/filepath"
 	"strings"
@@ -162,7 +164,19 @@ func (c Certificates) GetByPurpose(purpose Purpose) *Certificate {
 	return nil
 }
 
+// UseCertificateRefs configures the certificates to be read from the user-provided Secrets referenced by refs
+// when the cluster certificate Secrets do not exist yet.
+func (c Certificates) UseCertificateRefs(refs []bootstrapv1.CertificateRef) {
+	for _, ref := range refs {
+		if certificate := c.GetByPurpose(Purpose(ref.Purpose)); certificate != nil {
+			certificate.ProvidedSecretName = ref.SecretName
+		}
+	}
+}
+
 // Lookup looks up each certificate from secrets and populates the certificate with the secret data.
+// If the secret of a certificate does not exist and the certificate is provided by the user, the user-provided
+// secret is read and validated instead.
 func (c Certificates) Lookup(ctx context.Context, ctrlclient client.Client, clusterName client.ObjectKey) error {
 	// Look up each certificate as a secret and populate the certificate/key
 	for _, certificate := range c {
@@ -176,6 +190,11 @@ func (c Certificates) Lookup(ctx context.Context, ctrlclient client.Client, clus
 				if certificate.External {
 					return errors.WithMessage(err, "external certificate not found")
 				}
+				if certificate.ProvidedSecretName != "" {
+					if err := certificate.lookupProvided(ctx, ctrlclient, clusterName.Namespace); err != nil {
+						return err
+					}
+				}
 				continue
 			}
 			return errors.WithStack(err)
@@ -234,10 +253,10 @@ func (c Certificates) Generate() error {
 	return nil
 }
 
-// SaveGenerated will save any certificates that have been generated as Kubernetes secrets.
+// SaveGenerated will save any certificates that have been generated, or read from user-provided secrets, as Kubernetes secrets.
 func (c Certificates) SaveGenerated(ctx context.Context, ctrlclient client.Client, clusterName client.ObjectKey, owner metav1.OwnerReference) error {
 	for _, certificate := range c {
-		if !certificate.Generated {
+		if !certificate.Generated && !certificate.Provided {
 			continue
 		}
 		s := certificate.AsSecret(clusterName, owner)
@@ -272,9 +291,96 @@ func (c Certificates) LookupOrGenerate(ctx context.Context, ctrlclient client.Cl
 type Certificate struct {
 	Generated         bool
 	External          bool
+	Provided          bool
 	Purpose           Purpose
 	KeyPair           *certs.KeyPair
 	CertFile, KeyFile string
+
+	// ProvidedSecretName is the name of a user-provided secret the certificate is read from when the secret of
+	// the certificate does not exist yet.
+	ProvidedSecretName string
+}
+
+// lookupProvided reads the certificate from the user-provided secret, validating it.
+func (c *Certificate) lookupProvided(ctx context.Context, ctrlclient client.Client, namespace string) error {
+	s := &corev1.Secret{}
+	key := client.ObjectKey{
+		Name:      c.ProvidedSecretName,
+		Namespace: namespace,
+	}
+	if err := ctrlclient.Get(ctx, key, s); err != nil {
+		return errors.Wrapf(err, "failed to get secret %s provided for certificate: %s", c.ProvidedSecretName, c.Purpose)
+	}
+	kp, err := secretToKeyPair(s)
+	if err != nil {
+		return errors.Wrapf(err, "invalid secret %s provided for certificate: %s", c.ProvidedSecretName, c.Purpose)
+	}
+	if err := ValidateKeyPair(c.Purpose, kp, time.Now()); err != nil {
+		return errors.Wrapf(err, "invalid secret %s provided", c.ProvidedSecretName)
+	}
+	c.KeyPair = kp
+	c.Provided = true
+	return nil
+}
+
+// ValidateKeyPair checks a key pair has both the certificate and the key, the key matches the certificate and,
+// for a CA, the certificate is a CA certificate valid at the given time.
+// For service account keys, the certificate is the PEM encoded public key.
+func ValidateKeyPair(purpose Purpose, kp *certs.KeyPair, now time.Time) error {
+	if len(kp.Cert) == 0 {
+		return errors.Wrapf(ErrMissingCrt, "for certificate: %s", purpose)
+	}
+	if len(kp.Key) == 0 {
+		return errors.Wrapf(ErrMissingKey, "for certificate: %s", purpose)
+	}
+
+	if purpose == ServiceAccount {
+		return validateServiceAccountKeys(kp)
+	}
+
+	if _, err := tls.X509KeyPair(kp.Cert, kp.Key); err != nil {
+		return errors.Wrapf(err, "invalid key pair for certificate: %s", purpose)
+	}
+	cert, err := certs.DecodeCertPEM(kp.Cert)
+	if err != nil {
+		return errors.Wrapf(err, "invalid crt data for certificate: %s", purpose)
+	}
+	if !cert.IsCA {
+		return errors.Errorf("certificate: %s is not a CA certificate", purpose)
+	}
+	if now.Before(cert.NotBefore) {
+		return errors.Errorf("certificate: %s is not valid before %s", purpose, cert.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return errors.Errorf("certificate: %s expired on %s", purpose, cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// validateServiceAccountKeys checks the public key of a service account key pair matches the private key.
+func validateServiceAccountKeys(kp *certs.KeyPair) error {
+	key, err := certs.DecodePrivateKeyPEM(kp.Key)
+	if err != nil || key == nil {
+		return errors.Errorf("invalid key data for certificate: %s", ServiceAccount)
+	}
+	privPub, ok := key.Public().(*rsa.PublicKey)
+	if !ok {
+		return errors.Errorf("key for certificate: %s is not an RSA key", ServiceAccount)
+	}
+
+	block, _ := pem.Decode(kp.Cert)
+	if block == nil {
+		return errors.Errorf("invalid crt data for certificate: %s", ServiceAccount)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return errors.Wrapf(err, "invalid crt data for certificate: %s", ServiceAccount)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok || rsaPub.E != privPub.E || rsaPub.N.Cmp(privPub.N) != 0 {
+		return errors.Errorf("public key for certificate: %s does not match the key", ServiceAccount)
+	}
+	return nil
 }
 
 // Hashes hashes all the certificates stored in a CA certificate.
@@ -312,7 +418,7 @@ func (c *Certificate) AsSecret(clusterName client.ObjectKey, owner metav1.OwnerR
 		},
 	}
 
-	if c.Generated {
+	if c.Generated || c.Provided {
 		s.OwnerReferences = []metav1.OwnerReference{owner}
 	}
 	return s
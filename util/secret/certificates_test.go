@@ -17,12 +17,26 @@ limitations under the License.
 package secret_test
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+	"sigs.k8s.io/cluster-api/util/certs"
 	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestNewCertificatesForControlPlane_Stacked(t *testing.T) {
@@ -45,3 +59,177 @@ func TestNewCertificatesForControlPlane_External(t *testing.T) {
 	certs := secret.NewCertificatesForInitialControlPlane(config)
 	g.Expect(certs.GetByPurpose(secret.EtcdCA).KeyFile).To(BeEmpty())
 }
+
+func TestValidateKeyPair(t *testing.T) {
+	now := time.Now()
+	caKey, err := certs.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := certs.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saPub, err := certs.EncodePublicKeyPEM(&caKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		purpose secret.Purpose
+		kp      *certs.KeyPair
+		wantErr bool
+	}{
+		{
+			name:    "valid CA",
+			purpose: secret.ClusterCA,
+			kp:      &certs.KeyPair{Cert: newCACertPEM(t, caKey, now.Add(-time.Hour), now.Add(time.Hour), true), Key: certs.EncodePrivateKeyPEM(caKey)},
+		},
+		{
+			name:    "missing key",
+			purpose: secret.ClusterCA,
+			kp:      &certs.KeyPair{Cert: newCACertPEM(t, caKey, now.Add(-time.Hour), now.Add(time.Hour), true)},
+			wantErr: true,
+		},
+		{
+			name:    "key not matching the certificate",
+			purpose: secret.EtcdCA,
+			kp:      &certs.KeyPair{Cert: newCACertPEM(t, caKey, now.Add(-time.Hour), now.Add(time.Hour), true), Key: certs.EncodePrivateKeyPEM(otherKey)},
+			wantErr: true,
+		},
+		{
+			name:    "expired CA",
+			purpose: secret.FrontProxyCA,
+			kp:      &certs.KeyPair{Cert: newCACertPEM(t, caKey, now.Add(-2*time.Hour), now.Add(-time.Hour), true), Key: certs.EncodePrivateKeyPEM(caKey)},
+			wantErr: true,
+		},
+		{
+			name:    "not yet valid CA",
+			purpose: secret.ClusterCA,
+			kp:      &certs.KeyPair{Cert: newCACertPEM(t, caKey, now.Add(time.Hour), now.Add(2*time.Hour), true), Key: certs.EncodePrivateKeyPEM(caKey)},
+			wantErr: true,
+		},
+		{
+			name:    "not a CA",
+			purpose: secret.ClusterCA,
+			kp:      &certs.KeyPair{Cert: newCACertPEM(t, caKey, now.Add(-time.Hour), now.Add(time.Hour), false), Key: certs.EncodePrivateKeyPEM(caKey)},
+			wantErr: true,
+		},
+		{
+			name:    "valid service account keys",
+			purpose: secret.ServiceAccount,
+			kp:      &certs.KeyPair{Cert: saPub, Key: certs.EncodePrivateKeyPEM(caKey)},
+		},
+		{
+			name:    "service account public key not matching the key",
+			purpose: secret.ServiceAccount,
+			kp:      &certs.KeyPair{Cert: saPub, Key: certs.EncodePrivateKeyPEM(otherKey)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := secret.ValidateKeyPair(tt.purpose, tt.kp, now)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
+
+func TestLookupOrGenerate_CertificateRefs(t *testing.T) {
+	now := time.Now()
+	cluster := client.ObjectKey{Namespace: "default", Name: "foo"}
+	owner := metav1.OwnerReference{APIVersion: "bootstrap.cluster.x-k8s.io/v1alpha3", Kind: "KubeadmConfig", Name: "foo", UID: "uid"}
+
+	caKey, err := certs.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert := newCACertPEM(t, caKey, now.Add(-time.Hour), now.Add(time.Hour), true)
+	expiredCACert := newCACertPEM(t, caKey, now.Add(-2*time.Hour), now.Add(-time.Hour), true)
+
+	providedSecret := func(name string, cert []byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: cluster.Namespace, Name: name},
+			Data: map[string][]byte{
+				secret.TLSCrtDataName: cert,
+				secret.TLSKeyDataName: certs.EncodePrivateKeyPEM(caKey),
+			},
+		}
+	}
+
+	t.Run("the provided CA is saved as the cluster CA", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewFakeClientWithScheme(scheme.Scheme, providedSecret("my-ca", caCert))
+		certificates := secret.NewCertificatesForInitialControlPlane(&v1beta1.ClusterConfiguration{})
+		certificates.UseCertificateRefs([]bootstrapv1.CertificateRef{{Purpose: bootstrapv1.ClusterCACertificate, SecretName: "my-ca"}})
+		g.Expect(certificates.LookupOrGenerate(context.Background(), c, cluster, owner)).To(Succeed())
+
+		clusterCA := &corev1.Secret{}
+		g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: cluster.Namespace, Name: secret.Name(cluster.Name, secret.ClusterCA)}, clusterCA)).To(Succeed())
+		g.Expect(clusterCA.Data[secret.TLSCrtDataName]).To(Equal(caCert))
+		g.Expect(clusterCA.OwnerReferences).To(ConsistOf(owner))
+
+		// The certificates not provided are generated.
+		g.Expect(certificates.GetByPurpose(secret.ClusterCA).Provided).To(BeTrue())
+		g.Expect(certificates.GetByPurpose(secret.EtcdCA).Generated).To(BeTrue())
+	})
+
+	t.Run("an existing cluster CA takes precedence over the provided CA", func(t *testing.T) {
+		g := NewWithT(t)
+
+		existing := providedSecret(secret.Name(cluster.Name, secret.ClusterCA), caCert)
+		c := fake.NewFakeClientWithScheme(scheme.Scheme, existing)
+		certificates := secret.NewCertificatesForInitialControlPlane(&v1beta1.ClusterConfiguration{})
+		certificates.UseCertificateRefs([]bootstrapv1.CertificateRef{{Purpose: bootstrapv1.ClusterCACertificate, SecretName: "does-not-exist"}})
+		g.Expect(certificates.LookupOrGenerate(context.Background(), c, cluster, owner)).To(Succeed())
+		g.Expect(certificates.GetByPurpose(secret.ClusterCA).Provided).To(BeFalse())
+	})
+
+	t.Run("a missing provided CA fails", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewFakeClientWithScheme(scheme.Scheme)
+		certificates := secret.NewCertificatesForInitialControlPlane(&v1beta1.ClusterConfiguration{})
+		certificates.UseCertificateRefs([]bootstrapv1.CertificateRef{{Purpose: bootstrapv1.ClusterCACertificate, SecretName: "my-ca"}})
+		g.Expect(certificates.LookupOrGenerate(context.Background(), c, cluster, owner)).NotTo(Succeed())
+	})
+
+	t.Run("an expired provided CA fails", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewFakeClientWithScheme(scheme.Scheme, providedSecret("my-ca", expiredCACert))
+		certificates := secret.NewCertificatesForInitialControlPlane(&v1beta1.ClusterConfiguration{})
+		certificates.UseCertificateRefs([]bootstrapv1.CertificateRef{{Purpose: bootstrapv1.ClusterCACertificate, SecretName: "my-ca"}})
+		err := certificates.LookupOrGenerate(context.Background(), c, cluster, owner)
+		g.Expect(err).To(MatchError(ContainSubstring("expired")))
+	})
+}
+
+func newCACertPEM(t *testing.T, key *rsa.PrivateKey, notBefore, notAfter time.Time, isCA bool) []byte {
+	tmpl := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "my-ca"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	b, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := x509.ParseCertificate(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certs.EncodeCertPEM(c)
+}
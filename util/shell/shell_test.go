@@ -0,0 +1,32 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shell
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestQuote(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(Quote("")).To(Equal(`''`))
+	g.Expect(Quote("a b")).To(Equal(`'a b'`))
+	g.Expect(Quote("it's")).To(Equal(`'it'\''s'`))
+	g.Expect(Quote("$(rm -rf /)")).To(Equal(`'$(rm -rf /)'`))
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package trace instruments the reconcile loops of the controllers and the clusterctl operations with OpenTelemetry
+// spans.
+// Spans are created with the global OpenTelemetry tracer provider, and are discarded unless a provider is registered,
+// e.g. by the controller manager and clusterctl, that export the spans to an OpenTelemetry collector with the OTLP/HTTP
+// protocol and/or write them to a logger.
+package trace
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/label"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer creating the spans.
+const instrumentationName = "sigs.k8s.io/cluster-api"
+
+// String returns a string attribute.
+func String(key, value string) label.KeyValue {
+	return label.String(key, value)
+}
+
+// Int returns an integer attribute.
+func Int(key string, value int) label.KeyValue {
+	return label.Int(key, value)
+}
+
+// Start creates a span with the global tracer provider, child of the span in the context if any, and returns a
+// context holding it.
+func Start(ctx context.Context, name string, attrs ...label.KeyValue) (context.Context, oteltrace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, oteltrace.WithAttributes(attrs...))
+}
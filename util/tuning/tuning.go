@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tuning implements per-controller tuning of the reconciliations, complementing the work queue options
+// of controller-runtime: a per-controller cap of the requeue delays and a per-namespace limit of concurrent
+// reconciliations.
+package tuning
+
+import (
+	"sync"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Options tunes the reconciliations of a controller.
+type Options struct {
+	// MaxRequeueDelay caps the delay of the requeues requested by the reconciler, so the objects a controller waits
+	// on are reconciled again at least this often. Objects whose reconciliation does not request a requeue are not
+	// requeued, and only the manager's sync period applies to them. If zero, delays are not capped.
+	MaxRequeueDelay time.Duration
+
+	// MaxConcurrentReconcilesPerNamespace limits the reconciliations running at the same time for the objects of
+	// a namespace, so a namespace with a large number of objects can't use all the workers of the controller and
+	// starve the other namespaces. The reconciliations exceeding the limit are requeued through the rate limiter of
+	// the controller's work queue, so they back off while the namespace stays busy. If zero, there is no limit.
+	MaxConcurrentReconcilesPerNamespace int
+}
+
+// Wrap returns a reconciler applying the tuning options to the given reconciler; the reconciler is returned
+// unchanged if no options are set.
+func Wrap(r reconcile.Reconciler, options Options) reconcile.Reconciler {
+	if options.MaxRequeueDelay == 0 && options.MaxConcurrentReconcilesPerNamespace == 0 {
+		return r
+	}
+	return &tunedReconciler{
+		Reconciler: r,
+		options:    options,
+		running:    map[string]int{},
+	}
+}
+
+// tunedReconciler implements Wrap.
+type tunedReconciler struct {
+	reconcile.Reconciler
+	options Options
+
+	lock    sync.Mutex
+	running map[string]int
+}
+
+func (r *tunedReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	if !r.acquire(req.Namespace) {
+		return ctrl.Result{Requeue: true}, nil
+	}
+	defer r.release(req.Namespace)
+
+	result, err := r.Reconciler.Reconcile(req)
+	if err != nil || r.options.MaxRequeueDelay == 0 {
+		return result, err
+	}
+	if result.RequeueAfter > r.options.MaxRequeueDelay {
+		result.RequeueAfter = r.options.MaxRequeueDelay
+	}
+	return result, nil
+}
+
+// acquire reserves a reconciliation slot for the namespace, returning false if the namespace has no free slots.
+func (r *tunedReconciler) acquire(namespace string) bool {
+	if r.options.MaxConcurrentReconcilesPerNamespace == 0 {
+		return true
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.running[namespace] >= r.options.MaxConcurrentReconcilesPerNamespace {
+		return false
+	}
+	r.running[namespace]++
+	return true
+}
+
+// release frees a reconciliation slot of the namespace; namespaces without running reconciliations are removed,
+// so the map does not grow with every namespace ever seen.
+func (r *tunedReconciler) release(namespace string) {
+	if r.options.MaxConcurrentReconcilesPerNamespace == 0 {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.running[namespace]--
+	if r.running[namespace] <= 0 {
+		delete(r.running, namespace)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tuning
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type fakeReconciler struct {
+	result ctrl.Result
+	err    error
+	// block, if set, is waited for before returning, so the reconciliation keeps its slot.
+	block chan struct{}
+}
+
+func (f *fakeReconciler) Reconcile(_ ctrl.Request) (ctrl.Result, error) {
+	if f.block != nil {
+		<-f.block
+	}
+	return f.result, f.err
+}
+
+func TestWrap(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &fakeReconciler{}
+	g.Expect(Wrap(r, Options{})).To(BeIdenticalTo(r))
+}
+
+func TestMaxRequeueDelay(t *testing.T) {
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns1", Name: "foo"}}
+
+	tests := []struct {
+		name   string
+		result ctrl.Result
+		err    error
+		want   ctrl.Result
+	}{
+		{
+			name: "does not requeue if not requested",
+			want: ctrl.Result{},
+		},
+		{
+			name:   "preserves an earlier requeue",
+			result: ctrl.Result{RequeueAfter: 10 * time.Second},
+			want:   ctrl.Result{RequeueAfter: 10 * time.Second},
+		},
+		{
+			name:   "shortens a later requeue",
+			result: ctrl.Result{RequeueAfter: time.Hour},
+			want:   ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			name:   "preserves an immediate requeue",
+			result: ctrl.Result{Requeue: true},
+			want:   ctrl.Result{Requeue: true},
+		},
+		{
+			name: "does not requeue errors",
+			err:  errors.New("failed"),
+			want: ctrl.Result{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := Wrap(&fakeReconciler{result: tt.result, err: tt.err}, Options{MaxRequeueDelay: time.Minute})
+			got, err := r.Reconcile(req)
+			if tt.err != nil {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestMaxConcurrentReconcilesPerNamespace(t *testing.T) {
+	g := NewWithT(t)
+
+	block := make(chan struct{})
+	r := Wrap(&fakeReconciler{block: block}, Options{MaxConcurrentReconcilesPerNamespace: 1}).(*tunedReconciler)
+
+	// A reconciliation holds the only slot of ns1.
+	done := make(chan reconcile.Result)
+	go func() {
+		result, _ := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns1", Name: "foo"}})
+		done <- result
+	}()
+	g.Eventually(func() int {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		return r.running["ns1"]
+	}).Should(Equal(1))
+
+	// Another reconciliation in ns1 is requeued with backoff without running, while ns2 is not affected.
+	got, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns1", Name: "bar"}})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(ctrl.Result{Requeue: true}))
+	g.Expect(r.acquire("ns2")).To(BeTrue())
+	r.release("ns2")
+
+	close(block)
+	g.Eventually(done).Should(Receive(Equal(reconcile.Result{})))
+	g.Expect(r.running).To(BeEmpty())
+}